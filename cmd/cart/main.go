@@ -12,17 +12,25 @@ import (
 	"time"
 
 	"go-shopping-poc/internal/contracts/events"
+	"go-shopping-poc/internal/platform/auth"
 	"go-shopping-poc/internal/platform/cors"
 	"go-shopping-poc/internal/platform/database"
 	"go-shopping-poc/internal/platform/event"
+	"go-shopping-poc/internal/platform/fx"
+	"go-shopping-poc/internal/platform/idempotency"
 	"go-shopping-poc/internal/platform/logging"
+	"go-shopping-poc/internal/platform/middleware"
 	"go-shopping-poc/internal/platform/outbox"
 	"go-shopping-poc/internal/platform/outbox/providers"
+	"go-shopping-poc/internal/platform/ratelimit"
+	"go-shopping-poc/internal/platform/shipping"
 	"go-shopping-poc/internal/platform/sse"
+	"go-shopping-poc/internal/platform/tax"
 	"go-shopping-poc/internal/service/cart"
 	"go-shopping-poc/internal/service/cart/eventhandlers"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 func main() {
@@ -116,8 +124,36 @@ func main() {
 		db, eventBus, outboxWriter, outboxPublisher, corsHandler, sseProvider,
 	)
 
+	logger.Debug("Loading tax rate table", "path", cfg.TaxRateTable)
+	taxTable, err := tax.LoadRateTable(cfg.TaxRateTable)
+	if err != nil {
+		logger.Error("Failed to load tax rate table", logging.ErrorAttr(err))
+		os.Exit(1)
+	}
+	logger.Info("Tax rate table loaded", "version", taxTable.Version, "rates", len(taxTable.Rates))
+
+	logger.Debug("Loading shipping rate table", "path", cfg.ShippingRateTable)
+	shippingTable, err := shipping.LoadRateTable(cfg.ShippingRateTable)
+	if err != nil {
+		logger.Error("Failed to load shipping rate table", logging.ErrorAttr(err))
+		os.Exit(1)
+	}
+	logger.Info("Shipping rate table loaded", "version", shippingTable.Version, "methods", len(shippingTable.Methods))
+
+	fxProvider, err := newFXProvider(cfg, logger)
+	if err != nil {
+		logger.Error("Failed to create exchange rate provider", logging.ErrorAttr(err))
+		os.Exit(1)
+	}
+
 	logger.Debug("Creating cart service")
-	service := cart.NewCartService(logger, infrastructure, cfg)
+	service := cart.NewCartService(logger, infrastructure, cfg, cart.Pricing{
+		Tax:                 tax.NewTableCalculator(taxTable),
+		Shipping:            shipping.NewTableCalculator(shippingTable, shipping.WithExchange(fxProvider)),
+		Promotions:          cart.NewPromotionRepository(db),
+		FX:                  fxProvider,
+		RejectMixedCurrency: cfg.RejectMixedCurrency(),
+	})
 
 	logger.Debug("Registering event handlers")
 	if err := registerEventHandlers(service, sseProvider.GetHub(), logger); err != nil {
@@ -125,19 +161,78 @@ func main() {
 		os.Exit(1)
 	}
 
-	logger.Debug("Starting event consumer", "topics", service.EventBus().ReadTopics())
+	// Bootstrap identity cache from historical CustomerEvents
+	logger.Info("Bootstrapping identity cache from CustomerEvents")
+	if err := service.BootstrapIdentityCache(context.Background()); err != nil {
+		logger.Warn("Identity cache bootstrap had issues — claims wait for live customer events", "error", err)
+	}
+	logger.Info("Identity cache ready", "entries", service.GetIdentityCache().Count())
+
+	// Warm the product cache from historical ProductEvents, then start
+	// consuming live events. /ready fails until the cache is warm.
 	go func() {
 		ctx := context.Background()
+		logger.Info("Bootstrapping product cache from ProductEvents")
+		productEventHandler := eventhandlers.NewOnProductEvent(service.GetProductCache(), logger.With("component", "product_cache_bootstrap"))
+		if err := service.BootstrapProductCache(ctx, productEventHandler.CreateHandler()); err != nil {
+			logger.Warn("Product cache bootstrap had issues — products are validated by events until they arrive", "error", err)
+		}
+		logger.Info("Product cache ready", "entries", service.GetProductCache().Count())
+
+		logger.Debug("Starting event consumer", "topics", service.EventBus().ReadTopics())
 		if err := service.Start(ctx); err != nil {
 			logger.Error("Event consumer error", logging.ErrorAttr(err))
 		}
 	}()
 
+	expiryCtx, cancelExpiry := context.WithCancel(context.Background())
+	defer cancelExpiry()
+	go service.RunExpiry(expiryCtx)
+
+	reconcileCtx, cancelReconcile := context.WithCancel(context.Background())
+	defer cancelReconcile()
+	go service.RunValidationReconciler(reconcileCtx)
+
+	checkoutTimeoutCtx, cancelCheckoutTimeout := context.WithCancel(context.Background())
+	defer cancelCheckoutTimeout()
+	go service.RunCheckoutTimeouts(checkoutTimeoutCtx)
+
 	logger.Debug("Creating cart handler")
 	handler := cart.NewCartHandler(logger, service)
 
+	logger.Debug("Creating rate limiter")
+	rateLimitCfg, err := ratelimit.LoadConfig()
+	if err != nil {
+		logger.Error("Failed to load rate limit config", logging.ErrorAttr(err))
+		os.Exit(1)
+	}
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(),
+		ratelimit.WithKeyFunc(rateLimitCfg.KeyFunc()),
+		ratelimit.WithLogger(logger),
+	)
+
+	logger.Debug("Creating idempotency middleware")
+	idemStore := idempotency.NewPostgresStore(db)
+	idem := idempotency.NewMiddleware(idemStore, idempotency.WithLogger(logger))
+	cleanupCtx, cancelCleanup := context.WithCancel(context.Background())
+	defer cancelCleanup()
+	go idemStore.RunCleanup(cleanupCtx, idempotency.DefaultCleanupInterval, logger)
+
+	// Claiming a cart and wishlists need a signed-in customer. Without
+	// Keycloak config no claims are available and those endpoints reject
+	// every request.
+	requireAuth := func(next http.Handler) http.Handler { return next }
+	if cfg.KeycloakIssuer != "" && cfg.KeycloakJWKSURL != "" {
+		validator := auth.NewKeycloakValidator(cfg.KeycloakIssuer, cfg.KeycloakJWKSURL)
+		requireAuth = auth.RequireAuth(validator, "")
+		logger.Info("Auth middleware enabled for cart claims and wishlists")
+	} else {
+		logger.Warn("Keycloak config not set — cart claims and wishlists disabled")
+	}
+
 	logger.Debug("Setting up HTTP router")
 	router := chi.NewRouter()
+	router.Use(middleware.Stack(logger)...)
 	router.Use(corsHandler)
 
 	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -146,14 +241,20 @@ func main() {
 		w.Write([]byte(`{"status":"ok"}`))
 	})
 
+	router.Get("/ready", handler.Ready)
+
+	router.Handle("/metrics", promhttp.Handler())
+
 	cartRouter := chi.NewRouter()
-	cartRouter.Post("/carts", handler.CreateCart)
+	cartRouter.Use(cart.IfMatch)
+	cartRouter.With(limiter.Middleware("cart.create", cfg.CreateCartLimit()), idem.Handler("cart.create")).Post("/carts", handler.CreateCart)
 	cartRouter.Get("/carts/{id}", handler.GetCart)
 	cartRouter.Delete("/carts/{id}", handler.DeleteCart)
 
-	cartRouter.Post("/carts/{id}/items", handler.AddItem)
+	cartRouter.With(limiter.Middleware("cart.add_item", cfg.AddItemLimit()), idem.Handler("cart.add_item")).Post("/carts/{id}/items", handler.AddItem)
 	cartRouter.Put("/carts/{id}/items/{line}", handler.UpdateItem)
 	cartRouter.Delete("/carts/{id}/items/{line}", handler.RemoveItem)
+	cartRouter.With(limiter.Middleware("cart.add_item", cfg.AddItemLimit()), idem.Handler("cart.update_items")).Post("/carts/{id}/items/batch", handler.UpdateItems)
 
 	cartRouter.Put("/carts/{id}/contact", handler.SetContact)
 
@@ -161,10 +262,40 @@ func main() {
 
 	cartRouter.Put("/carts/{id}/payment", handler.SetPayment)
 
-	cartRouter.Post("/carts/{id}/checkout", handler.Checkout)
+	cartRouter.Put("/carts/{id}/currency", handler.SetCurrency)
+
+	cartRouter.Post("/carts/{id}/price-changes/acknowledge", handler.AcknowledgePriceChanges)
+
+	cartRouter.Get("/carts/{id}/shipping", handler.GetShippingOptions)
+	cartRouter.Put("/carts/{id}/shipping", handler.SetShipping)
+
+	cartRouter.Post("/carts/{id}/coupons", handler.ApplyCoupon)
+	cartRouter.Delete("/carts/{id}/coupons/{code}", handler.RemoveCoupon)
+
+	cartRouter.With(idem.Handler("cart.checkout")).Post("/carts/{id}/checkout", handler.Checkout)
+
+	cartRouter.With(requireAuth).Post("/carts/{id}/claim", handler.ClaimCart)
 
 	cartRouter.Get("/carts/{id}/stream", sseProvider.GetHandler().ServeHTTP)
 
+	// Wishlists belong to the signed-in customer. Alerts for a list are
+	// streamed under its ID on the same hub as carts, to its owner only.
+	cartRouter.With(requireAuth).Post("/carts/{id}/items/{line}/save-for-later", handler.SaveForLater)
+	cartRouter.With(requireAuth).Get("/wishlists", handler.ListWishlists)
+	cartRouter.With(requireAuth).Post("/wishlists", handler.CreateWishlist)
+	cartRouter.With(requireAuth).Get("/wishlists/{id}", handler.GetWishlist)
+	cartRouter.With(requireAuth).Delete("/wishlists/{id}", handler.DeleteWishlist)
+	cartRouter.With(requireAuth).Post("/wishlists/{id}/items", handler.AddWishlistItem)
+	cartRouter.With(requireAuth).Delete("/wishlists/{id}/items/{product}", handler.RemoveWishlistItem)
+	cartRouter.With(requireAuth).Post("/wishlists/{id}/items/{product}/move-to-cart", handler.MoveToCart)
+
+	wishlistStream := sse.NewHandler(sseProvider.GetHub(),
+		sse.WithMissingIDMessage("Missing wishlist ID"),
+		sse.WithConnectedIDField("wishlist_id"),
+		sse.WithLogIDKey("wishlist_id"),
+	)
+	cartRouter.With(requireAuth, handler.WishlistOwner).Get("/wishlists/{id}/stream", wishlistStream.ServeHTTP)
+
 	router.Mount("/api/v1", cartRouter)
 
 	serverAddr := "0.0.0.0" + cfg.ServicePort
@@ -200,28 +331,83 @@ func main() {
 	logger.Info("Server exited")
 }
 
+// newFXProvider returns the exchange rate provider: the rates service when a
+// URL is configured, otherwise the static rate table.
+func newFXProvider(cfg *cart.Config, logger *slog.Logger) (fx.Provider, error) {
+	if cfg.FXRatesURL != "" {
+		logger.Info("Using exchange rates service", "url", cfg.FXRatesURL, "currency_mode", cfg.CurrencyMode)
+		return fx.NewHTTPProvider(cfg.FXRatesURL, fx.WithLogger(logger)), nil
+	}
+
+	logger.Debug("Loading exchange rate table", "path", cfg.FXRateTable)
+	table, err := fx.LoadRateTable(cfg.FXRateTable)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("Exchange rate table loaded",
+		"version", table.Version,
+		"base", table.Base,
+		"currencies", len(table.Rates),
+		"currency_mode", cfg.CurrencyMode,
+	)
+	return fx.NewStaticProvider(table), nil
+}
+
 func registerEventHandlers(service *cart.CartService, sseHub *sse.Hub, logger *slog.Logger) error {
 	logger.Debug("Registering event handlers")
 
 	handlerLogger := logger.With("component", "event_handler")
 
-	orderCreatedHandler := eventhandlers.NewOnOrderCreated(sseHub, handlerLogger)
+	// Order event handler — advances the checkout saga on order.created
+	// and order.rejected.
+	orderEventHandler := eventhandlers.NewOnOrderEvent(service.GetCheckoutSagas(), sseHub, handlerLogger)
 	logger.Debug("Registering handler",
-		"event_type", orderCreatedHandler.EventType(),
+		"event_type", orderEventHandler.EventType(),
 		"topic", events.OrderEvent{}.Topic(),
 	)
 
 	if err := cart.RegisterHandler(
 		service,
-		orderCreatedHandler.CreateFactory(),
-		orderCreatedHandler.CreateHandler(),
+		orderEventHandler.CreateFactory(),
+		orderEventHandler.CreateHandler(),
+	); err != nil {
+		return fmt.Errorf("failed to register OrderEvent handler: %w", err)
+	}
+
+	logger.Debug("Successfully registered OrderEvent handler")
+
+	// Reorder handler — adds the items of a past order to the customer's
+	// cart on order.reorder_requested and answers with
+	// cart.reorder_completed.
+	reorderHandler := eventhandlers.NewOnReorderRequested(service, handlerLogger)
+	logger.Debug("Registering handler", "event_type", reorderHandler.EventType())
+
+	if err := cart.RegisterHandler(
+		service,
+		reorderHandler.CreateFactory(),
+		reorderHandler.CreateHandler(),
+	); err != nil {
+		return fmt.Errorf("failed to register reorder handler: %w", err)
+	}
+
+	logger.Debug("Successfully registered reorder handler")
+
+	// Order status handler — pushes order.status_changed to the SSE
+	// subscribers of the order's cart.
+	orderStatusHandler := eventhandlers.NewOnOrderStatusChanged(sseHub, handlerLogger)
+	logger.Debug("Registering handler", "event_type", orderStatusHandler.EventType())
+
+	if err := cart.RegisterHandler(
+		service,
+		orderStatusHandler.CreateFactory(),
+		orderStatusHandler.CreateHandler(),
 	); err != nil {
-		return fmt.Errorf("failed to register OrderCreated handler: %w", err)
+		return fmt.Errorf("failed to register order status handler: %w", err)
 	}
 
-	logger.Debug("Successfully registered OrderCreated handler")
+	logger.Debug("Successfully registered order status handler")
 
-	productValidatedHandler := eventhandlers.NewOnProductValidated(service.GetRepository(), sseHub, handlerLogger)
+	productValidatedHandler := eventhandlers.NewOnProductValidated(service.GetRepository(), service.GetPricing(), sseHub, handlerLogger)
 	logger.Debug("Registering handler", "event_type", productValidatedHandler.EventType())
 
 	if err := cart.RegisterHandler(
@@ -250,6 +436,84 @@ func registerEventHandlers(service *cart.CartService, sseHub *sse.Hub, logger *s
 	}
 
 	logger.Debug("Successfully registered ProductEvent handler")
+
+	// Product price change handler — reprices the lines of active carts
+	// on product.updated and flags them until the shopper acknowledges.
+	priceChangedHandler := eventhandlers.NewOnProductPriceChanged(service, handlerLogger)
+	logger.Debug("Registering handler", "event_type", priceChangedHandler.EventType())
+
+	if err := cart.RegisterHandler(
+		service,
+		priceChangedHandler.CreateFactory(),
+		priceChangedHandler.CreateHandler(),
+	); err != nil {
+		return fmt.Errorf("failed to register product price change handler: %w", err)
+	}
+
+	logger.Debug("Successfully registered product price change handler")
+
+	// Wishlist product handler — keeps saved products current on
+	// product.updated and pushes stock and price alerts to the wishlists'
+	// SSE subscribers.
+	wishlistProductHandler := eventhandlers.NewOnWishlistProductEvent(service, handlerLogger)
+	logger.Debug("Registering handler", "event_type", wishlistProductHandler.EventType())
+
+	if err := cart.RegisterHandler(
+		service,
+		wishlistProductHandler.CreateFactory(),
+		wishlistProductHandler.CreateHandler(),
+	); err != nil {
+		return fmt.Errorf("failed to register wishlist product handler: %w", err)
+	}
+
+	logger.Debug("Successfully registered wishlist product handler")
+
+	// Inventory event handler — records stock reported by reservation
+	// outcomes, advances the checkout saga and pushes them to the cart's
+	// SSE subscribers.
+	inventoryEventHandler := eventhandlers.NewOnInventoryEvent(productCache, service.GetCheckoutSagas(), sseHub, handlerLogger)
+	logger.Debug("Registering handler", "event_type", inventoryEventHandler.EventType())
+
+	if err := cart.RegisterHandler(
+		service,
+		inventoryEventHandler.CreateFactory(),
+		inventoryEventHandler.CreateHandler(),
+	); err != nil {
+		return fmt.Errorf("failed to register InventoryEvent handler: %w", err)
+	}
+
+	logger.Debug("Successfully registered InventoryEvent handler")
+
+	// Wishlist inventory handler — keeps the stock of saved products
+	// current from the stock left reported by inventory events and pushes
+	// stock alerts to the wishlists' SSE subscribers.
+	wishlistInventoryHandler := eventhandlers.NewOnWishlistInventoryEvent(service, handlerLogger)
+	logger.Debug("Registering handler", "event_type", wishlistInventoryHandler.EventType())
+
+	if err := cart.RegisterHandler(
+		service,
+		wishlistInventoryHandler.CreateFactory(),
+		wishlistInventoryHandler.CreateHandler(),
+	); err != nil {
+		return fmt.Errorf("failed to register wishlist inventory handler: %w", err)
+	}
+
+	logger.Debug("Successfully registered wishlist inventory handler")
+
+	// Customer event handler — keeps the identity cache used to match a
+	// signed-in shopper to their customer when claiming a cart.
+	customerEventHandler := eventhandlers.NewOnCustomerEvent(service.GetIdentityCache(), handlerLogger)
+	logger.Debug("Registering handler", "event_type", customerEventHandler.EventType())
+
+	if err := cart.RegisterHandler(
+		service,
+		customerEventHandler.CreateFactory(),
+		customerEventHandler.CreateHandler(),
+	); err != nil {
+		return fmt.Errorf("failed to register CustomerEvent handler: %w", err)
+	}
+
+	logger.Debug("Successfully registered CustomerEvent handler")
 	logger.Debug("Event handler registration completed")
 
 	return nil
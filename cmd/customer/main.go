@@ -13,8 +13,11 @@ import (
 	"go-shopping-poc/internal/platform/cors"
 	"go-shopping-poc/internal/platform/database"
 	"go-shopping-poc/internal/platform/event"
+	"go-shopping-poc/internal/platform/idempotency"
 	"go-shopping-poc/internal/platform/logging"
+	"go-shopping-poc/internal/platform/middleware"
 	"go-shopping-poc/internal/platform/outbox/providers"
+	"go-shopping-poc/internal/platform/ratelimit"
 
 	"go-shopping-poc/internal/service/customer"
 	"go-shopping-poc/internal/service/customer/eventhandlers"
@@ -136,10 +139,29 @@ func main() {
 		}
 	}()
 
+	logger.Debug("Creating rate limiter")
+	rateLimitCfg, err := ratelimit.LoadConfig()
+	if err != nil {
+		logger.Error("Failed to load rate limit config", logging.ErrorAttr(err))
+		os.Exit(1)
+	}
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(),
+		ratelimit.WithKeyFunc(rateLimitCfg.KeyFunc()),
+		ratelimit.WithLogger(logger),
+	)
+
+	logger.Debug("Creating idempotency middleware")
+	idemStore := idempotency.NewPostgresStore(db)
+	idem := idempotency.NewMiddleware(idemStore, idempotency.WithLogger(logger))
+	cleanupCtx, cancelCleanup := context.WithCancel(context.Background())
+	defer cancelCleanup()
+	go idemStore.RunCleanup(cleanupCtx, idempotency.DefaultCleanupInterval, logger)
+
 	logger.Debug("Setting up HTTP router")
 	router := chi.NewRouter()
 	logger.Debug("Router setup completed")
 
+	router.Use(middleware.Stack(logger)...)
 	router.Use(corsHandler)
 
 	router.Get("/health", healthHandler)
@@ -148,12 +170,12 @@ func main() {
 
 	// POST /customers — uses optional auth (claims extracted in handler if present)
 	if authMiddleware != nil {
-		protected := customerRouter.With(authMiddleware)
+		protected := customerRouter.With(authMiddleware, idem.Handler("customer.create"))
 		protected.Post("/customers", handler.CreateCustomer)
 	} else {
-		customerRouter.Post("/customers", handler.CreateCustomer)
+		customerRouter.With(idem.Handler("customer.create")).Post("/customers", handler.CreateCustomer)
 	}
-	customerRouter.Get("/customers/{email}", handler.GetCustomerByEmailPath)
+	customerRouter.With(limiter.Middleware("customer.lookup", cfg.LookupLimit())).Get("/customers/{email}", handler.GetCustomerByEmailPath)
 
 	// Protected routes — apply auth middleware (if configured)
 	if authMiddleware != nil {
@@ -0,0 +1,79 @@
+// Command fxrates serves an exchange rate table over HTTP. It stands in for
+// a real rates service during local development; point the cart service at
+// it with CART_FX_RATES_URL.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"go-shopping-poc/internal/platform/fx"
+	"go-shopping-poc/internal/platform/logging"
+	"go-shopping-poc/internal/platform/middleware"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func main() {
+	addr := flag.String("addr", ":8090", "Address to listen on")
+	ratesPath := flag.String("rates", "", "Path to a JSON rate table (optional, uses the embedded table if not provided)")
+	flag.Parse()
+
+	loggerProvider, err := logging.NewLoggerProvider(logging.DefaultLoggerConfig("fxrates"))
+	if err != nil {
+		log.Fatalf("FX rates: Failed to create logger provider: %v", err)
+	}
+	logger := loggerProvider.Logger()
+
+	table, err := fx.LoadRateTable(*ratesPath)
+	if err != nil {
+		logger.Error("Failed to load exchange rate table", logging.ErrorAttr(err))
+		os.Exit(1)
+	}
+	logger.Info("Exchange rate table loaded",
+		"version", table.Version,
+		"base", table.Base,
+		"currencies", table.Currencies(),
+	)
+
+	router := chi.NewRouter()
+	router.Use(middleware.Stack(logger)...)
+	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"status":"ok"}`))
+	})
+	router.Mount("/", fx.NewHandler(table))
+
+	server := &http.Server{
+		Addr:        *addr,
+		Handler:     router,
+		ReadTimeout: 30 * time.Second,
+		IdleTimeout: 120 * time.Second,
+	}
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		logger.Info("Starting HTTP server", "address", *addr)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error("Failed to start HTTP server", logging.ErrorAttr(err))
+		}
+	}()
+
+	<-quit
+	logger.Info("Shutting down server")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		logger.Error("Server forced to shutdown", logging.ErrorAttr(err))
+	}
+}
@@ -12,11 +12,14 @@ import (
 	"time"
 
 	"go-shopping-poc/internal/platform/auth"
+	"go-shopping-poc/internal/platform/carrier"
 	"go-shopping-poc/internal/platform/cors"
 	"go-shopping-poc/internal/platform/database"
 	"go-shopping-poc/internal/platform/event"
 	"go-shopping-poc/internal/platform/logging"
+	"go-shopping-poc/internal/platform/middleware"
 	"go-shopping-poc/internal/platform/outbox/providers"
+	"go-shopping-poc/internal/platform/payment"
 	"go-shopping-poc/internal/service/order"
 	"go-shopping-poc/internal/service/order/eventhandlers"
 
@@ -96,7 +99,13 @@ func main() {
 	corsHandler := corsProvider.GetCORSHandler()
 
 	logger.Debug("Creating order infrastructure")
-	infrastructure := order.NewOrderInfrastructure(db, eventBus, outboxWriter, outboxPublisher, corsHandler)
+	carriers := carrier.NewRegistry(carrier.NewFakeCarrier(cfg.CarrierWebhookSecret))
+	gateways := []payment.Gateway{payment.NewFakeGateway()}
+	if cfg.PaymentGatewayURL != "" {
+		gateways = append(gateways, payment.NewHTTPGateway(cfg.PaymentGatewayURL, nil))
+	}
+	payments := payment.NewRegistry(gateways...)
+	infrastructure := order.NewOrderInfrastructure(db, eventBus, outboxWriter, outboxPublisher, corsHandler, carriers, payments)
 
 	logger.Debug("Creating order service")
 	service := order.NewOrderService(logger, infrastructure, cfg)
@@ -125,10 +134,13 @@ func main() {
 	handler := order.NewOrderHandler(service)
 
 	// Set up auth middleware
-	var authMiddleware func(http.Handler) http.Handler
+	var authMiddleware, fulfillmentAuth func(http.Handler) http.Handler
 	if cfg.KeycloakIssuer != "" && cfg.KeycloakJWKSURL != "" {
 		validator := auth.NewKeycloakValidator(cfg.KeycloakIssuer, cfg.KeycloakJWKSURL)
 		authMiddleware = auth.RequireAuth(validator, "")
+		if cfg.FulfillmentRole != "" {
+			fulfillmentAuth = auth.RequireAuth(validator, cfg.FulfillmentRole)
+		}
 		logger.Info("Auth middleware enabled")
 	} else {
 		logger.Warn("Keycloak config not set — auth middleware disabled (all endpoints open)")
@@ -136,6 +148,7 @@ func main() {
 
 	logger.Debug("Setting up HTTP router")
 	router := chi.NewRouter()
+	router.Use(middleware.Stack(logger)...)
 	router.Use(corsHandler)
 
 	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -152,7 +165,24 @@ func main() {
 	orderRouter.Get("/orders/customer/{customerId}", handler.GetOrdersByCustomer)
 	orderRouter.Delete("/orders/{id}", handler.CancelOrder)
 	orderRouter.Patch("/orders/{id}/status", handler.UpdateOrderStatus)
+	orderRouter.Post("/orders/{id}/reorder", handler.Reorder)
+
+	orderRouter.Post("/orders/{id}/returns", handler.RequestReturn)
+
+	// Fulfillment staff book shipments and handle returns; carriers report
+	// tracking through signed webhooks, outside token authentication. With
+	// auth on, the fulfillment endpoints are only served behind the
+	// fulfillment role, never to any signed-in user.
+	switch {
+	case authMiddleware == nil:
+		mountFulfillmentRoutes(orderRouter, handler)
+	case fulfillmentAuth != nil:
+		mountFulfillmentRoutes(orderRouter.With(fulfillmentAuth), handler)
+	default:
+		logger.Warn("Fulfillment role not set — fulfillment endpoints disabled")
+	}
 
+	router.Post("/api/v1/carriers/{carrier}/webhook", handler.CarrierWebhook)
 	router.Mount("/api/v1", orderRouter)
 
 	serverAddr := "0.0.0.0" + cfg.ServicePort
@@ -204,6 +234,18 @@ func registerEventHandlers(service *order.OrderService, logger *slog.Logger) err
 		return fmt.Errorf("failed to register CartCheckedOut handler: %w", err)
 	}
 
+	// Cancel orders of checkouts the cart service rolled back
+	cartCheckoutFailedHandler := eventhandlers.NewOnCartCheckoutFailed(service, handlerLogger)
+	logger.Debug("Registering handler", "event_type", cartCheckoutFailedHandler.EventType())
+
+	if err := order.RegisterHandler(
+		service,
+		cartCheckoutFailedHandler.CreateFactory(),
+		cartCheckoutFailedHandler.CreateHandler(),
+	); err != nil {
+		return fmt.Errorf("failed to register CartCheckoutFailed handler: %w", err)
+	}
+
 	// Keep identity cache current via ongoing CustomerCreated/CustomerUpdated events
 	identityUpdateHandler := eventhandlers.NewOnCustomerIdentityUpdate(service.IdentityCache(), handlerLogger)
 	logger.Debug("Registering handler", "event_type", identityUpdateHandler.EventType())
@@ -228,7 +270,28 @@ func registerEventHandlers(service *order.OrderService, logger *slog.Logger) err
 		return fmt.Errorf("failed to register IdentityVerificationCompleted handler: %w", err)
 	}
 
+	// Hand reorder results from the cart service to the waiting requests
+	reorderHandler := eventhandlers.NewOnReorderCompleted(service, handlerLogger)
+	logger.Debug("Registering handler", "event_type", reorderHandler.EventType())
+
+	if err := order.RegisterHandler(
+		service,
+		reorderHandler.CreateFactory(),
+		reorderHandler.CreateHandler(),
+	); err != nil {
+		return fmt.Errorf("failed to register ReorderCompleted handler: %w", err)
+	}
+
 	logger.Debug("Event handler registration completed")
 
 	return nil
 }
+
+// mountFulfillmentRoutes mounts the endpoints of fulfillment staff on r.
+func mountFulfillmentRoutes(r chi.Router, handler *order.OrderHandler) {
+	r.Post("/orders/{id}/shipments", handler.CreateShipment)
+	r.Post("/returns/{id}/approve", handler.ApproveReturn)
+	r.Post("/returns/{id}/reject", handler.RejectReturn)
+	r.Post("/returns/{id}/receive", handler.ReceiveReturn)
+	r.Post("/returns/{id}/refund", handler.RefundReturn)
+}
@@ -0,0 +1,68 @@
+// Command paymentgw serves the payment gateway protocol in front of the fake
+// gateway. It stands in for a real payment provider during local
+// development; point the order service at it with ORDER_PAYMENT_GATEWAY=http
+// and ORDER_PAYMENT_GATEWAY_URL.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"go-shopping-poc/internal/platform/logging"
+	"go-shopping-poc/internal/platform/middleware"
+	"go-shopping-poc/internal/platform/payment"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func main() {
+	addr := flag.String("addr", ":8091", "Address to listen on")
+	flag.Parse()
+
+	loggerProvider, err := logging.NewLoggerProvider(logging.DefaultLoggerConfig("paymentgw"))
+	if err != nil {
+		log.Fatalf("Payment gateway: Failed to create logger provider: %v", err)
+	}
+	logger := loggerProvider.Logger()
+
+	router := chi.NewRouter()
+	router.Use(middleware.Stack(logger)...)
+	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"status":"ok"}`))
+	})
+	router.Mount("/", payment.NewMockServer(payment.NewFakeGateway()))
+
+	server := &http.Server{
+		Addr:        *addr,
+		Handler:     router,
+		ReadTimeout: 30 * time.Second,
+		IdleTimeout: 120 * time.Second,
+	}
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		logger.Info("Starting HTTP server", "address", *addr)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error("Failed to start HTTP server", logging.ErrorAttr(err))
+		}
+	}()
+
+	<-quit
+	logger.Info("Shutting down server")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		logger.Error("Server forced to shutdown", logging.ErrorAttr(err))
+	}
+}
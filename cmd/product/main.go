@@ -15,7 +15,9 @@ import (
 	"go-shopping-poc/internal/platform/database"
 	"go-shopping-poc/internal/platform/event"
 	"go-shopping-poc/internal/platform/logging"
+	"go-shopping-poc/internal/platform/middleware"
 	"go-shopping-poc/internal/platform/outbox/providers"
+	"go-shopping-poc/internal/platform/ratelimit"
 	"go-shopping-poc/internal/platform/storage/minio"
 	"go-shopping-poc/internal/service/product"
 	"go-shopping-poc/internal/service/product/eventhandlers"
@@ -96,8 +98,10 @@ func main() {
 	catalogService := product.NewCatalogService(logger, catalogInfra, cfg)
 	logger.Debug("Service created successfully")
 
+	inventoryService := product.NewInventoryService(logger, catalogInfra, cfg)
+
 	logger.Debug("Registering event handlers")
-	cartItemAddedHandler := eventhandlers.NewOnCartItemAdded(catalogService, logger)
+	cartItemAddedHandler := eventhandlers.NewOnCartItemAdded(catalogService, inventoryService, logger)
 	if err := product.RegisterHandler(
 		catalogService,
 		cartItemAddedHandler.CreateFactory(),
@@ -106,6 +110,42 @@ func main() {
 		logger.Error("Failed to register CartItemAdded handler", "error", err.Error())
 		os.Exit(1)
 	}
+	cartCheckedOutHandler := eventhandlers.NewOnCartCheckedOut(inventoryService, logger)
+	if err := product.RegisterHandler(
+		catalogService,
+		cartCheckedOutHandler.CreateFactory(),
+		cartCheckedOutHandler.CreateHandler(),
+	); err != nil {
+		logger.Error("Failed to register CartCheckedOut handler", "error", err.Error())
+		os.Exit(1)
+	}
+	cartCheckoutFailedHandler := eventhandlers.NewOnCartCheckoutFailed(inventoryService, logger)
+	if err := product.RegisterHandler(
+		catalogService,
+		cartCheckoutFailedHandler.CreateFactory(),
+		cartCheckoutFailedHandler.CreateHandler(),
+	); err != nil {
+		logger.Error("Failed to register CartCheckoutFailed handler", "error", err.Error())
+		os.Exit(1)
+	}
+	orderEventHandler := eventhandlers.NewOnOrderEvent(inventoryService, logger)
+	if err := product.RegisterHandler(
+		catalogService,
+		orderEventHandler.CreateFactory(),
+		orderEventHandler.CreateHandler(),
+	); err != nil {
+		logger.Error("Failed to register OrderEvent handler", "error", err.Error())
+		os.Exit(1)
+	}
+	orderReturnHandler := eventhandlers.NewOnOrderReturnReceived(inventoryService, logger)
+	if err := product.RegisterHandler(
+		catalogService,
+		orderReturnHandler.CreateFactory(),
+		orderReturnHandler.CreateHandler(),
+	); err != nil {
+		logger.Error("Failed to register OrderReturnReceived handler", "error", err.Error())
+		os.Exit(1)
+	}
 	logger.Debug("Event handlers registered successfully")
 
 	consumerCtx, consumerCancel := context.WithCancel(context.Background())
@@ -117,6 +157,8 @@ func main() {
 		}
 	}()
 
+	go inventoryService.RunExpiry(consumerCtx)
+
 	logger.Debug("Loading MinIO configuration")
 	minioCfg, err := config.LoadConfig[minio.PlatformConfig]("platform-minio")
 	if err != nil {
@@ -147,8 +189,20 @@ func main() {
 
 	logger.Debug("Creating catalog handler")
 	catalogHandler := product.NewCatalogHandler(logger, catalogService, minioStorage, cfg.MinIOBucket)
+	inventoryHandler := product.NewInventoryHandler(logger, inventoryService)
 	logger.Debug("Handler created successfully")
 
+	logger.Debug("Creating rate limiter")
+	rateLimitCfg, err := ratelimit.LoadConfig()
+	if err != nil {
+		logger.Error("Failed to load rate limit config", logging.ErrorAttr(err))
+		os.Exit(1)
+	}
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(),
+		ratelimit.WithKeyFunc(rateLimitCfg.KeyFunc()),
+		ratelimit.WithLogger(logger),
+	)
+
 	logger.Debug("Setting up HTTP router")
 	router := chi.NewRouter()
 	logger.Debug("Router setup completed")
@@ -160,6 +214,7 @@ func main() {
 		os.Exit(1)
 	}
 	corsHandler := corsProvider.GetCORSHandler()
+	router.Use(middleware.Stack(logger)...)
 	router.Use(corsHandler)
 
 	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -171,10 +226,12 @@ func main() {
 	productRouter := chi.NewRouter()
 	productRouter.Get("/products", catalogHandler.GetAllProducts)
 	productRouter.Get("/products/{id}", catalogHandler.GetProduct)
-	productRouter.Get("/products/search", catalogHandler.SearchProducts)
+	productRouter.With(limiter.Middleware("product.search", cfg.SearchLimit())).Get("/products/search", catalogHandler.SearchProducts)
 	productRouter.Get("/products/category/{category}", catalogHandler.GetProductsByCategory)
 	productRouter.Get("/products/brand/{brand}", catalogHandler.GetProductsByBrand)
 	productRouter.Get("/products/in-stock", catalogHandler.GetProductsInStock)
+	productRouter.Get("/products/{id}/stock", inventoryHandler.GetStockLevel)
+	productRouter.Get("/reservations/{cartId}", inventoryHandler.GetReservation)
 	productRouter.Get("/products/{id}/images", catalogHandler.GetProductImages)
 	productRouter.Get("/products/{id}/main-image", catalogHandler.GetProductMainImage)
 	// Direct image access: /api/v1/products/{id}/images/{imageName:.+}
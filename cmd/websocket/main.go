@@ -10,8 +10,10 @@ import (
 	"time"
 
 	"go-shopping-poc/internal/platform/logging"
+	"go-shopping-poc/internal/platform/middleware"
 	ws "go-shopping-poc/internal/platform/websocket"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/gorilla/websocket"
 )
 
@@ -54,17 +56,20 @@ func main() {
 	addr := wsCfg.Port
 	logger.Info("WebSocket server listening", "address", addr+"/ws")
 
+	router := chi.NewRouter()
+	router.Use(middleware.Stack(logger)...)
+	router.HandleFunc("/ws", wsServer.Handle(func(conn *websocket.Conn) {
+		echoHandler(logger, conn)
+	}))
+
 	httpServer := &http.Server{
 		Addr:         addr,
+		Handler:      router,
 		ReadTimeout:  30 * time.Second,
 		WriteTimeout: 30 * time.Second,
 		IdleTimeout:  120 * time.Second,
 	}
 
-	http.HandleFunc("/ws", wsServer.Handle(func(conn *websocket.Conn) {
-		echoHandler(logger, conn)
-	}))
-
 	go func() {
 		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			logger.Error("ListenAndServe error", "error", err.Error())
@@ -2,8 +2,11 @@ package events
 
 import (
 	"encoding/json"
+	"strconv"
 	"time"
 
+	"go-shopping-poc/internal/platform/money"
+
 	"github.com/google/uuid"
 )
 
@@ -13,6 +16,15 @@ const (
 	CartCreated    CartEventType = "cart.created"
 	CartDeleted    CartEventType = "cart.deleted"
 	CartCheckedOut CartEventType = "cart.checked_out"
+	CartAbandoned  CartEventType = "cart.abandoned"
+	CartClaimed    CartEventType = "cart.claimed"
+	CartMerged     CartEventType = "cart.merged"
+	// CartCheckoutFailed reports a checkout rolled back by the checkout
+	// saga. The order and stock reservation made for it are to be cancelled.
+	CartCheckoutFailed CartEventType = "cart.checkout_failed"
+	// CartReorderCompleted answers an order.reorder_requested with the
+	// cart the order's items were added to.
+	CartReorderCompleted CartEventType = "cart.reorder_completed"
 )
 
 // CartItemEventType defines cart item-specific event types
@@ -26,13 +38,13 @@ const (
 
 // CartItemPayload contains cart item event data
 type CartItemPayload struct {
-	CartID       string  `json:"cart_id"`
-	LineNumber   string  `json:"line_number"`
-	ProductID    string  `json:"product_id"`
-	Quantity     int     `json:"quantity"`
-	ProductName  string  `json:"product_name,omitempty"`
-	UnitPrice    float64 `json:"unit_price,omitempty"`
-	ValidationID string  `json:"validation_id,omitempty"`
+	CartID       string      `json:"cart_id"`
+	LineNumber   string      `json:"line_number"`
+	ProductID    string      `json:"product_id"`
+	Quantity     int         `json:"quantity"`
+	ProductName  string      `json:"product_name,omitempty"`
+	UnitPrice    money.Money `json:"unit_price,omitzero"`
+	ValidationID string      `json:"validation_id,omitempty"`
 }
 
 // CartItemEvent represents cart item lifecycle events
@@ -75,7 +87,7 @@ func NewCartItemAddedEvent(cartID, lineNumber, productID string, quantity int, v
 	}
 }
 
-func NewCartItemConfirmedEvent(cartID, lineNumber, productID, productName string, unitPrice float64, quantity int) *CartItemEvent {
+func NewCartItemConfirmedEvent(cartID, lineNumber, productID, productName string, unitPrice money.Money, quantity int) *CartItemEvent {
 	return &CartItemEvent{
 		ID:        uuid.New().String(),
 		EventType: CartItemConfirmed,
@@ -107,24 +119,36 @@ func NewCartItemRejectedEvent(cartID, lineNumber, productID, reason string) *Car
 type CartEventPayload struct {
 	CartID     string            `json:"cart_id"`
 	CustomerID *string           `json:"customer_id,omitempty"`
-	TotalPrice float64           `json:"total_price,omitempty"`
+	TotalPrice money.Money       `json:"total_price,omitzero"`
 	ItemCount  int               `json:"item_count,omitempty"`
 	Details    map[string]string `json:"details,omitempty"`
 
 	CartSnapshot *CartSnapshot `json:"cart_snapshot,omitempty"`
 }
 
+// CheckoutAttempt returns the number of the checkout of its cart a
+// cart.checked_out or cart.checkout_failed event belongs to. Each checkout
+// of a cart is numbered one higher than the last; 0 is returned for events
+// published before checkouts were numbered.
+func (p CartEventPayload) CheckoutAttempt() int {
+	attempt, _ := strconv.Atoi(p.Details["attempt"])
+	return attempt
+}
+
 type CartSnapshot struct {
-	Currency   string            `json:"currency"`
-	NetPrice   float64           `json:"net_price"`
-	Tax        float64           `json:"tax"`
-	Shipping   float64           `json:"shipping"`
-	TotalPrice float64           `json:"total_price"`
-	CustomerID *string           `json:"customer_id,omitempty"`
-	Contact    *SnapshotContact  `json:"contact,omitempty"`
-	CreditCard *SnapshotPayment  `json:"credit_card,omitempty"`
-	Addresses  []SnapshotAddress `json:"addresses,omitempty"`
-	Items      []SnapshotItem    `json:"items,omitempty"`
+	Currency       string             `json:"currency"`
+	NetPrice       money.Money        `json:"net_price"`
+	Tax            money.Money        `json:"tax"`
+	Shipping       money.Money        `json:"shipping"`
+	ShippingMethod string             `json:"shipping_method,omitempty"`
+	Discount       money.Money        `json:"discount"`
+	Discounts      []SnapshotDiscount `json:"discounts,omitempty"`
+	TotalPrice     money.Money        `json:"total_price"`
+	CustomerID     *string            `json:"customer_id,omitempty"`
+	Contact        *SnapshotContact   `json:"contact,omitempty"`
+	CreditCard     *SnapshotPayment   `json:"credit_card,omitempty"`
+	Addresses      []SnapshotAddress  `json:"addresses,omitempty"`
+	Items          []SnapshotItem     `json:"items,omitempty"`
 }
 
 type SnapshotContact struct {
@@ -154,13 +178,42 @@ type SnapshotAddress struct {
 }
 
 type SnapshotItem struct {
-	LineNumber  string  `json:"line_number"`
-	ProductID   string  `json:"product_id"`
-	ProductName string  `json:"product_name"`
-	UnitPrice   float64 `json:"unit_price"`
-	Quantity    int     `json:"quantity"`
-	TotalPrice  float64 `json:"total_price"`
-	ImageURL    string  `json:"image_url"`
+	LineNumber  string      `json:"line_number"`
+	ProductID   string      `json:"product_id"`
+	ProductName string      `json:"product_name"`
+	UnitPrice   money.Money `json:"unit_price"`
+	Quantity    int         `json:"quantity"`
+	TotalPrice  money.Money `json:"total_price"`
+	ImageURL    string      `json:"image_url"`
+
+	// Variant options selected for the line
+	Size        string `json:"size,omitempty"`
+	Color       string `json:"color,omitempty"`
+	GiftMessage string `json:"gift_message,omitempty"`
+
+	TaxCode      string            `json:"tax_code,omitempty"`
+	Tax          money.Money       `json:"tax"`
+	TaxBreakdown []SnapshotTaxRate `json:"tax_breakdown,omitempty"`
+
+	Discount money.Money `json:"discount,omitzero"`
+}
+
+// SnapshotDiscount is a promotion applied to the cart at checkout.
+type SnapshotDiscount struct {
+	PromotionID int64       `json:"promotion_id"`
+	Code        string      `json:"code,omitempty"`
+	Name        string      `json:"name"`
+	Amount      money.Money `json:"amount"`
+}
+
+// SnapshotTaxRate is a jurisdiction rate applied to a snapshot item.
+type SnapshotTaxRate struct {
+	Jurisdiction string      `json:"jurisdiction"`
+	Name         string      `json:"name,omitempty"`
+	TaxCode      string      `json:"tax_code"`
+	Rate         float64     `json:"rate"`
+	Amount       money.Money `json:"amount"`
+	TableVersion string      `json:"table_version"`
 }
 
 type CartEvent struct {
@@ -185,7 +238,7 @@ func (e CartEvent) ToJSON() ([]byte, error) { return json.Marshal(e) }
 func (e CartEvent) GetEntityID() string     { return e.EventPayload.CartID }
 func (e CartEvent) GetResourceID() string   { return e.ID }
 
-func NewCartEvent(cartID string, eventType CartEventType, customerID *string, totalPrice float64, itemCount int, details map[string]string) *CartEvent {
+func NewCartEvent(cartID string, eventType CartEventType, customerID *string, totalPrice money.Money, itemCount int, details map[string]string) *CartEvent {
 	payload := CartEventPayload{
 		CartID:     cartID,
 		CustomerID: customerID,
@@ -203,23 +256,53 @@ func NewCartEvent(cartID string, eventType CartEventType, customerID *string, to
 }
 
 func NewCartCreatedEvent(cartID string, customerID *string) *CartEvent {
-	return NewCartEvent(cartID, CartCreated, customerID, 0, 0, nil)
+	return NewCartEvent(cartID, CartCreated, customerID, money.Money{}, 0, nil)
 }
 
 func NewCartDeletedEvent(cartID string, customerID *string) *CartEvent {
-	return NewCartEvent(cartID, CartDeleted, customerID, 0, 0, nil)
+	return NewCartEvent(cartID, CartDeleted, customerID, money.Money{}, 0, nil)
 }
 
-func NewCartCheckedOutEvent(cartID string, customerID *string, totalPrice float64, itemCount int) *CartEvent {
+func NewCartCheckedOutEvent(cartID string, customerID *string, totalPrice money.Money, itemCount int) *CartEvent {
 	return NewCartEvent(cartID, CartCheckedOut, customerID, totalPrice, itemCount, nil)
 }
 
-func NewCartCheckedOutEventWithSnapshot(cartID string, customerID *string, snapshot *CartSnapshot) *CartEvent {
+// NewCartClaimedEvent creates a cart.claimed event for a guest cart
+// assigned to the customer who signed in with it.
+func NewCartClaimedEvent(cartID string, customerID string) *CartEvent {
+	return NewCartEvent(cartID, CartClaimed, &customerID, money.Money{}, 0, nil)
+}
+
+// NewCartMergedEvent creates a cart.merged event for the customer cart
+// sourceCartID's items were merged into. itemCount is the number of lines
+// taken from the source cart.
+func NewCartMergedEvent(cartID string, sourceCartID string, customerID string, itemCount int) *CartEvent {
+	return NewCartEvent(cartID, CartMerged, &customerID, money.Money{}, itemCount, map[string]string{
+		"source_cart_id": sourceCartID,
+	})
+}
+
+// NewCartCheckoutFailedEvent creates a cart.checkout_failed event for
+// checkout attempt of a cart that was rolled back for reason. orderID is
+// empty when no order was created for the checkout.
+func NewCartCheckoutFailedEvent(cartID string, customerID *string, attempt int, orderID string, reason string) *CartEvent {
+	details := map[string]string{"reason": reason, "attempt": strconv.Itoa(attempt)}
+	if orderID != "" {
+		details["order_id"] = orderID
+	}
+	return NewCartEvent(cartID, CartCheckoutFailed, customerID, money.Money{}, 0, details)
+}
+
+// NewCartCheckedOutEventWithSnapshot creates a cart.checked_out event for
+// checkout attempt of a cart, carrying what the order and stock
+// reservation are made from.
+func NewCartCheckedOutEventWithSnapshot(cartID string, customerID *string, attempt int, snapshot *CartSnapshot) *CartEvent {
 	payload := CartEventPayload{
 		CartID:       cartID,
 		CustomerID:   customerID,
 		TotalPrice:   snapshot.TotalPrice,
 		ItemCount:    len(snapshot.Items),
+		Details:      map[string]string{"attempt": strconv.Itoa(attempt)},
 		CartSnapshot: snapshot,
 	}
 
@@ -230,3 +313,93 @@ func NewCartCheckedOutEventWithSnapshot(cartID string, customerID *string, snaps
 		EventPayload: payload,
 	}
 }
+
+// NewCartAbandonedEvent creates a cart.abandoned event for a cart expired
+// after inactivity. The snapshot carries what re-engagement needs and no
+// payment details.
+func NewCartAbandonedEvent(cartID string, customerID *string, snapshot *CartSnapshot) *CartEvent {
+	payload := CartEventPayload{
+		CartID:       cartID,
+		CustomerID:   customerID,
+		TotalPrice:   snapshot.TotalPrice,
+		ItemCount:    len(snapshot.Items),
+		CartSnapshot: snapshot,
+	}
+
+	return &CartEvent{
+		ID:           uuid.New().String(),
+		EventType:    CartAbandoned,
+		Timestamp:    time.Now(),
+		EventPayload: payload,
+	}
+}
+
+// Reorder line outcomes
+const (
+	ReorderLineAdded       = "added"
+	ReorderLinePending     = "pending_validation"
+	ReorderLineBackorder   = "backorder"
+	ReorderLineUnavailable = "unavailable"
+)
+
+// ReorderLineResult reports what became of one line of a reorder
+type ReorderLineResult struct {
+	ProductID   string `json:"product_id"`
+	ProductName string `json:"product_name,omitempty"`
+	Quantity    int    `json:"quantity"`
+	LineNumber  string `json:"line_number,omitempty"`
+	Status      string `json:"status"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+// CartReorderResultPayload carries a reorder response from the cart service
+type CartReorderResultPayload struct {
+	RequestID  string              `json:"request_id"`
+	OrderID    string              `json:"order_id"`
+	CartID     string              `json:"cart_id,omitempty"`
+	CustomerID string              `json:"customer_id"`
+	Lines      []ReorderLineResult `json:"lines"`
+	Error      string              `json:"error,omitempty"`
+}
+
+// CartReorderCompletedEvent is published to CartEvents by the cart service
+type CartReorderCompletedEvent struct {
+	ID        string                   `json:"id"`
+	EventType CartEventType            `json:"type"`
+	Timestamp time.Time                `json:"timestamp"`
+	Data      CartReorderResultPayload `json:"payload"`
+}
+
+func (e CartReorderCompletedEvent) Type() string            { return string(e.EventType) }
+func (e CartReorderCompletedEvent) Topic() string           { return "CartEvents" }
+func (e CartReorderCompletedEvent) Payload() any            { return e.Data }
+func (e CartReorderCompletedEvent) ToJSON() ([]byte, error) { return json.Marshal(e) }
+func (e CartReorderCompletedEvent) GetEntityID() string     { return e.Data.CartID }
+func (e CartReorderCompletedEvent) GetResourceID() string   { return e.ID }
+
+// CartReorderCompletedEventFactory implements EventFactory
+type CartReorderCompletedEventFactory struct{}
+
+func (f CartReorderCompletedEventFactory) FromJSON(data []byte) (CartReorderCompletedEvent, error) {
+	var event CartReorderCompletedEvent
+	err := json.Unmarshal(data, &event)
+	return event, err
+}
+
+// NewCartReorderCompletedEvent creates a cart.reorder_completed event. errStr
+// is set, and cartID empty, when no cart could be used for the reorder.
+func NewCartReorderCompletedEvent(requestID, orderID, cartID, customerID string, lines []ReorderLineResult, errStr string) *CartReorderCompletedEvent {
+	return &CartReorderCompletedEvent{
+		ID:        uuid.New().String(),
+		EventType: CartReorderCompleted,
+		Timestamp: time.Now(),
+		Data: CartReorderResultPayload{
+			RequestID:  requestID,
+			OrderID:    orderID,
+			CartID:     cartID,
+			CustomerID: customerID,
+			Lines:      lines,
+			Error:      errStr,
+		},
+	}
+}
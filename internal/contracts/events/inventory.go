@@ -0,0 +1,121 @@
+package events
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// InventoryEventType defines stock reservation event types. Inventory events
+// are published by the product service on the ProductEvents topic.
+type InventoryEventType string
+
+const (
+	InventoryReserved          InventoryEventType = "inventory.reserved"
+	InventoryReservationFailed InventoryEventType = "inventory.reservation_failed"
+	InventoryCommitted         InventoryEventType = "inventory.committed"
+	InventoryReleased          InventoryEventType = "inventory.released"
+	// InventoryRestocked reports returned items put back on hand.
+	InventoryRestocked InventoryEventType = "inventory.restocked"
+)
+
+// InventoryLine is the stock outcome for one product of a reservation.
+// Available is the quantity left to sell after the change; for a failed
+// reservation it is what could have been reserved instead of Quantity.
+type InventoryLine struct {
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+	Available int    `json:"available"`
+}
+
+// InventoryPayload contains reservation event data for a checked-out cart.
+// Attempt is the checkout attempt of the cart the reservation was made for;
+// see CartEventPayload.CheckoutAttempt.
+type InventoryPayload struct {
+	CartID    string          `json:"cart_id"`
+	Attempt   int             `json:"attempt,omitempty"`
+	OrderID   string          `json:"order_id,omitempty"`
+	ReturnID  string          `json:"return_id,omitempty"`
+	Reason    string          `json:"reason,omitempty"`
+	ExpiresAt *time.Time      `json:"expires_at,omitempty"`
+	Lines     []InventoryLine `json:"lines"`
+}
+
+// InventoryEvent represents stock reservation lifecycle events
+type InventoryEvent struct {
+	ID        string             `json:"id"`
+	EventType InventoryEventType `json:"type"`
+	Timestamp time.Time          `json:"timestamp"`
+	Data      InventoryPayload   `json:"payload"`
+}
+
+// InventoryEventFactory implements EventFactory
+type InventoryEventFactory struct{}
+
+func (f InventoryEventFactory) FromJSON(data []byte) (InventoryEvent, error) {
+	var event InventoryEvent
+	err := json.Unmarshal(data, &event)
+	return event, err
+}
+
+func (e InventoryEvent) Type() string            { return string(e.EventType) }
+func (e InventoryEvent) Topic() string           { return "ProductEvents" }
+func (e InventoryEvent) Payload() any            { return e.Data }
+func (e InventoryEvent) ToJSON() ([]byte, error) { return json.Marshal(e) }
+func (e InventoryEvent) GetEntityID() string     { return e.Data.CartID }
+func (e InventoryEvent) GetResourceID() string   { return e.ID }
+
+func NewInventoryEvent(t InventoryEventType, payload InventoryPayload) *InventoryEvent {
+	return &InventoryEvent{
+		ID:        uuid.New().String(),
+		EventType: t,
+		Timestamp: time.Now(),
+		Data:      payload,
+	}
+}
+
+func NewInventoryReservedEvent(cartID string, expiresAt time.Time, lines []InventoryLine) *InventoryEvent {
+	return NewInventoryEvent(InventoryReserved, InventoryPayload{
+		CartID:    cartID,
+		ExpiresAt: &expiresAt,
+		Lines:     lines,
+	})
+}
+
+func NewInventoryReservationFailedEvent(cartID, reason string, lines []InventoryLine) *InventoryEvent {
+	return NewInventoryEvent(InventoryReservationFailed, InventoryPayload{
+		CartID: cartID,
+		Reason: reason,
+		Lines:  lines,
+	})
+}
+
+func NewInventoryCommittedEvent(cartID, orderID string, lines []InventoryLine) *InventoryEvent {
+	return NewInventoryEvent(InventoryCommitted, InventoryPayload{
+		CartID:  cartID,
+		OrderID: orderID,
+		Lines:   lines,
+	})
+}
+
+func NewInventoryReleasedEvent(cartID, orderID, reason string, lines []InventoryLine) *InventoryEvent {
+	return NewInventoryEvent(InventoryReleased, InventoryPayload{
+		CartID:  cartID,
+		OrderID: orderID,
+		Reason:  reason,
+		Lines:   lines,
+	})
+}
+
+// NewInventoryRestockedEvent creates an inventory.restocked event for the
+// returned lines of an order put back on hand.
+func NewInventoryRestockedEvent(cartID, orderID, returnID string, lines []InventoryLine) *InventoryEvent {
+	return NewInventoryEvent(InventoryRestocked, InventoryPayload{
+		CartID:   cartID,
+		OrderID:  orderID,
+		ReturnID: returnID,
+		Reason:   "returned",
+		Lines:    lines,
+	})
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"time"
 
+	"go-shopping-poc/internal/platform/money"
+
 	"github.com/google/uuid"
 )
 
@@ -13,16 +15,36 @@ const (
 	OrderCreated                          OrderEventType = "order.created"
 	OrderUpdated                          OrderEventType = "order.updated"
 	OrderDeleted                          OrderEventType = "order.deleted"
+	OrderCancelled                        OrderEventType = "order.cancelled"
+	OrderRejected                         OrderEventType = "order.rejected"
 	CustomerIdentityVerificationRequested OrderEventType = "order.customer.identity_verification_requested"
 	CustomerIdentityVerificationCompleted OrderEventType = "order.customer.identity_verification_completed"
+	// OrderReorderRequested asks the cart service to add the items of a
+	// past order to the customer's active cart.
+	OrderReorderRequested OrderEventType = "order.reorder_requested"
+	// OrderStatusChanged reports a transition of an order from one status
+	// to another.
+	OrderStatusChanged OrderEventType = "order.status_changed"
+	// Return (RMA) lifecycle: a customer requests a return of order lines,
+	// staff approve or reject it, receive the items and refund them.
+	OrderReturnRequested OrderEventType = "order.return_requested"
+	OrderReturnApproved  OrderEventType = "order.return_approved"
+	OrderReturnRejected  OrderEventType = "order.return_rejected"
+	OrderReturnReceived  OrderEventType = "order.return_received"
+	OrderReturnRefunded  OrderEventType = "order.return_refunded"
 )
 
 type OrderEventPayload struct {
-	OrderID     string  `json:"order_id"`
-	OrderNumber string  `json:"order_number"`
-	CartID      string  `json:"cart_id"`
-	CustomerID  *string `json:"customer_id,omitempty"`
-	Total       float64 `json:"total"`
+	OrderID     string      `json:"order_id"`
+	OrderNumber string      `json:"order_number"`
+	CartID      string      `json:"cart_id"`
+	CustomerID  *string     `json:"customer_id,omitempty"`
+	Total       money.Money `json:"total"`
+	// Reason is set on order.rejected
+	Reason string `json:"reason,omitempty"`
+	// Attempt is the checkout attempt of the cart the order was created
+	// for, or rejected in; see CartEventPayload.CheckoutAttempt.
+	Attempt int `json:"attempt,omitempty"`
 }
 
 type OrderEvent struct {
@@ -40,7 +62,7 @@ func (f OrderEventFactory) FromJSON(data []byte) (OrderEvent, error) {
 	return event, err
 }
 
-func NewOrderEvent(orderID string, orderNumber string, t OrderEventType, cartID string, customerID *string, total float64) *OrderEvent {
+func NewOrderEvent(orderID string, orderNumber string, t OrderEventType, cartID string, customerID *string, total money.Money) *OrderEvent {
 	payload := OrderEventPayload{
 		OrderID:     orderID,
 		OrderNumber: orderNumber,
@@ -64,18 +86,30 @@ func (e OrderEvent) ToJSON() ([]byte, error) { return json.Marshal(e) }
 func (e OrderEvent) GetEntityID() string     { return e.Data.CartID }
 func (e OrderEvent) GetResourceID() string   { return e.ID }
 
-func NewOrderCreatedEvent(orderID string, orderNumber string, cartID string, customerID *string, total float64) *OrderEvent {
+func NewOrderCreatedEvent(orderID string, orderNumber string, cartID string, customerID *string, total money.Money) *OrderEvent {
 	return NewOrderEvent(orderID, orderNumber, OrderCreated, cartID, customerID, total)
 }
 
-func NewOrderUpdatedEvent(orderID string, orderNumber string, cartID string, customerID *string, total float64) *OrderEvent {
+func NewOrderUpdatedEvent(orderID string, orderNumber string, cartID string, customerID *string, total money.Money) *OrderEvent {
 	return NewOrderEvent(orderID, orderNumber, OrderUpdated, cartID, customerID, total)
 }
 
-func NewOrderDeletedEvent(orderID string, orderNumber string, cartID string, customerID *string, total float64) *OrderEvent {
+func NewOrderDeletedEvent(orderID string, orderNumber string, cartID string, customerID *string, total money.Money) *OrderEvent {
 	return NewOrderEvent(orderID, orderNumber, OrderDeleted, cartID, customerID, total)
 }
 
+func NewOrderCancelledEvent(orderID string, orderNumber string, cartID string, customerID *string, total money.Money) *OrderEvent {
+	return NewOrderEvent(orderID, orderNumber, OrderCancelled, cartID, customerID, total)
+}
+
+// NewOrderRejectedEvent creates an order.rejected event for a checked out
+// cart no order could be created for.
+func NewOrderRejectedEvent(cartID string, customerID *string, reason string) *OrderEvent {
+	event := NewOrderEvent("", "", OrderRejected, cartID, customerID, money.Money{})
+	event.Data.Reason = reason
+	return event
+}
+
 // CustomerIdentityVerificationRequestPayload carries a verification request from the order service
 type CustomerIdentityVerificationRequestPayload struct {
 	RequestID   string `json:"request_id"`
@@ -167,3 +201,185 @@ func NewCustomerIdentityVerificationCompletedEvent(requestID string, authorized
 		},
 	}
 }
+
+// ReorderItem is an order line to be added to a cart again
+type ReorderItem struct {
+	ProductID   string `json:"product_id"`
+	ProductName string `json:"product_name,omitempty"`
+	Quantity    int    `json:"quantity"`
+	ImageURL    string `json:"image_url,omitempty"`
+	Size        string `json:"size,omitempty"`
+	Color       string `json:"color,omitempty"`
+	GiftMessage string `json:"gift_message,omitempty"`
+}
+
+// OrderReorderRequestPayload carries a reorder request from the order service
+type OrderReorderRequestPayload struct {
+	RequestID  string        `json:"request_id"`
+	OrderID    string        `json:"order_id"`
+	CustomerID string        `json:"customer_id"`
+	Currency   string        `json:"currency,omitempty"`
+	Items      []ReorderItem `json:"items"`
+}
+
+// OrderReorderRequestEvent is published to OrderEvents by the order service
+type OrderReorderRequestEvent struct {
+	ID        string                     `json:"id"`
+	EventType OrderEventType             `json:"type"`
+	Timestamp time.Time                  `json:"timestamp"`
+	Data      OrderReorderRequestPayload `json:"payload"`
+}
+
+func (e OrderReorderRequestEvent) Type() string            { return string(e.EventType) }
+func (e OrderReorderRequestEvent) Topic() string           { return "OrderEvents" }
+func (e OrderReorderRequestEvent) Payload() any            { return e.Data }
+func (e OrderReorderRequestEvent) ToJSON() ([]byte, error) { return json.Marshal(e) }
+func (e OrderReorderRequestEvent) GetEntityID() string     { return e.Data.OrderID }
+func (e OrderReorderRequestEvent) GetResourceID() string   { return e.ID }
+
+// OrderReorderRequestEventFactory implements EventFactory
+type OrderReorderRequestEventFactory struct{}
+
+func (f OrderReorderRequestEventFactory) FromJSON(data []byte) (OrderReorderRequestEvent, error) {
+	var event OrderReorderRequestEvent
+	err := json.Unmarshal(data, &event)
+	return event, err
+}
+
+func NewOrderReorderRequestedEvent(requestID, orderID, customerID, currency string, items []ReorderItem) *OrderReorderRequestEvent {
+	return &OrderReorderRequestEvent{
+		ID:        uuid.New().String(),
+		EventType: OrderReorderRequested,
+		Timestamp: time.Now(),
+		Data: OrderReorderRequestPayload{
+			RequestID:  requestID,
+			OrderID:    orderID,
+			CustomerID: customerID,
+			Currency:   currency,
+			Items:      items,
+		},
+	}
+}
+
+// OrderStatusChangedPayload describes a status transition of an order.
+// Actor names who made the change: "system", or "customer:<id>".
+type OrderStatusChangedPayload struct {
+	OrderID     string    `json:"order_id"`
+	OrderNumber string    `json:"order_number"`
+	CartID      string    `json:"cart_id"`
+	CustomerID  *string   `json:"customer_id,omitempty"`
+	FromStatus  string    `json:"from_status"`
+	ToStatus    string    `json:"to_status"`
+	Notes       string    `json:"notes,omitempty"`
+	Actor       string    `json:"actor"`
+	ChangedAt   time.Time `json:"changed_at"`
+}
+
+// OrderStatusChangedEvent is published to OrderEvents by the order service
+// for every status transition of an order.
+type OrderStatusChangedEvent struct {
+	ID        string                    `json:"id"`
+	EventType OrderEventType            `json:"type"`
+	Timestamp time.Time                 `json:"timestamp"`
+	Data      OrderStatusChangedPayload `json:"payload"`
+}
+
+func (e OrderStatusChangedEvent) Type() string            { return string(e.EventType) }
+func (e OrderStatusChangedEvent) Topic() string           { return "OrderEvents" }
+func (e OrderStatusChangedEvent) Payload() any            { return e.Data }
+func (e OrderStatusChangedEvent) ToJSON() ([]byte, error) { return json.Marshal(e) }
+func (e OrderStatusChangedEvent) GetEntityID() string     { return e.Data.OrderID }
+func (e OrderStatusChangedEvent) GetResourceID() string   { return e.ID }
+
+// OrderStatusChangedEventFactory implements EventFactory
+type OrderStatusChangedEventFactory struct{}
+
+func (f OrderStatusChangedEventFactory) FromJSON(data []byte) (OrderStatusChangedEvent, error) {
+	var event OrderStatusChangedEvent
+	err := json.Unmarshal(data, &event)
+	return event, err
+}
+
+func NewOrderStatusChangedEvent(orderID, orderNumber, cartID string, customerID *string, fromStatus, toStatus, notes, actor string, changedAt time.Time) *OrderStatusChangedEvent {
+	return &OrderStatusChangedEvent{
+		ID:        uuid.New().String(),
+		EventType: OrderStatusChanged,
+		Timestamp: time.Now(),
+		Data: OrderStatusChangedPayload{
+			OrderID:     orderID,
+			OrderNumber: orderNumber,
+			CartID:      cartID,
+			CustomerID:  customerID,
+			FromStatus:  fromStatus,
+			ToStatus:    toStatus,
+			Notes:       notes,
+			Actor:       actor,
+			ChangedAt:   changedAt,
+		},
+	}
+}
+
+// ReturnLine is an order line, or part of one, in a return. Restock is
+// set once the items are received and tells whether they go back on sale.
+// RefundAmount and RefundTax are set once the return is refunded.
+type ReturnLine struct {
+	LineNumber   int         `json:"line_number"`
+	ProductID    string      `json:"product_id"`
+	Quantity     int         `json:"quantity"`
+	Reason       string      `json:"reason"`
+	Restock      bool        `json:"restock"`
+	RefundAmount money.Money `json:"refund_amount"`
+	RefundTax    money.Money `json:"refund_tax"`
+}
+
+// OrderReturnPayload describes a return of an order at one step of its
+// lifecycle. RefundTotal and Refunded are set on order.return_refunded:
+// the refund of this return and the total refunded on the order so far.
+type OrderReturnPayload struct {
+	ReturnID    string       `json:"return_id"`
+	RMANumber   string       `json:"rma_number"`
+	OrderID     string       `json:"order_id"`
+	OrderNumber string       `json:"order_number"`
+	CartID      string       `json:"cart_id"`
+	CustomerID  *string      `json:"customer_id,omitempty"`
+	Status      string       `json:"status"`
+	Notes       string       `json:"notes,omitempty"`
+	Actor       string       `json:"actor"`
+	Lines       []ReturnLine `json:"lines"`
+	RefundTotal money.Money  `json:"refund_total"`
+	Refunded    money.Money  `json:"refunded"`
+}
+
+// OrderReturnEvent is published to OrderEvents by the order service for
+// every step of a return.
+type OrderReturnEvent struct {
+	ID        string             `json:"id"`
+	EventType OrderEventType     `json:"type"`
+	Timestamp time.Time          `json:"timestamp"`
+	Data      OrderReturnPayload `json:"payload"`
+}
+
+func (e OrderReturnEvent) Type() string            { return string(e.EventType) }
+func (e OrderReturnEvent) Topic() string           { return "OrderEvents" }
+func (e OrderReturnEvent) Payload() any            { return e.Data }
+func (e OrderReturnEvent) ToJSON() ([]byte, error) { return json.Marshal(e) }
+func (e OrderReturnEvent) GetEntityID() string     { return e.Data.OrderID }
+func (e OrderReturnEvent) GetResourceID() string   { return e.ID }
+
+// OrderReturnEventFactory implements EventFactory
+type OrderReturnEventFactory struct{}
+
+func (f OrderReturnEventFactory) FromJSON(data []byte) (OrderReturnEvent, error) {
+	var event OrderReturnEvent
+	err := json.Unmarshal(data, &event)
+	return event, err
+}
+
+func NewOrderReturnEvent(t OrderEventType, payload OrderReturnPayload) *OrderReturnEvent {
+	return &OrderReturnEvent{
+		ID:        uuid.New().String(),
+		EventType: t,
+		Timestamp: time.Now(),
+		Data:      payload,
+	}
+}
@@ -2,9 +2,12 @@ package events
 
 import (
 	"encoding/json"
-	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
+	"go-shopping-poc/internal/platform/money"
+
 	"github.com/google/uuid"
 )
 
@@ -149,24 +152,34 @@ func NewProductCategoryViewedEvent(category string, details map[string]string) *
 
 // ProductValidationPayload contains product validation results
 type ProductValidationPayload struct {
-	ProductID   string  `json:"product_id"`
-	ProductName string  `json:"product_name,omitempty"`
-	UnitPrice   float64 `json:"unit_price,omitempty"`
-	IsAvailable bool    `json:"is_available"`
-	Reason      string  `json:"reason,omitempty"`
+	ProductID   string      `json:"product_id"`
+	ProductName string      `json:"product_name,omitempty"`
+	UnitPrice   money.Money `json:"unit_price,omitzero"`
+	IsAvailable bool        `json:"is_available"`
+	Reason      string      `json:"reason,omitempty"`
 
 	// Context information (optional, for correlation)
 	CartID     string `json:"cart_id,omitempty"`
 	LineNumber string `json:"line_number,omitempty"`
 }
 
-// NewProductValidatedEvent creates a product validation success event
-func NewProductValidatedEvent(productID, productName string, unitPrice float64, cartID, lineNumber, validationID string) *ProductEvent {
+// NewProductValidatedEvent creates a product validation success event.
+// NewProductValidatedEvent creates a product validation success event.
+// sizes and color are the variant options of the product, against which
+// the cart checks the options selected for the line; weightLb is its
+// shipping weight, zero when unknown.
+func NewProductValidatedEvent(productID, productName, category, brand string, unitPrice money.Money, sizes []string, color string, weightLb float64, cartID, lineNumber, validationID string) *ProductEvent {
 	details := map[string]string{
 		"cart_id":       cartID,
 		"line_number":   lineNumber,
-		"unit_price":    fmt.Sprintf("%.2f", unitPrice),
+		"unit_price":    unitPrice.String(),
+		"currency":      unitPrice.Currency(),
 		"product_name":  productName,
+		"category":      category,
+		"brand":         brand,
+		"sizes":         EncodeSizes(sizes),
+		"color":         color,
+		"weight_lb":     EncodeWeight(weightLb),
 		"validation_id": validationID,
 	}
 
@@ -204,3 +217,50 @@ func NewProductUnavailableEvent(productID, reason string, cartID, lineNumber, va
 		},
 	}
 }
+
+// EncodeSizes returns the "sizes" detail of a product event, the sizes the
+// product is offered in as a JSON array. It is empty when there are none.
+func EncodeSizes(sizes []string) string {
+	if len(sizes) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(sizes)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// DecodeSizes parses the "sizes" detail of a product event. Events written
+// before sizes were published, or with a malformed detail, yield nil.
+func DecodeSizes(detail string) []string {
+	if strings.TrimSpace(detail) == "" {
+		return nil
+	}
+	var sizes []string
+	if err := json.Unmarshal([]byte(detail), &sizes); err != nil {
+		return nil
+	}
+	return sizes
+}
+
+// EncodeWeight returns the "weight_lb" detail of a product event, the
+// shipping weight of the product in pounds. It is empty when the weight is
+// not known.
+func EncodeWeight(weightLb float64) string {
+	if weightLb <= 0 {
+		return ""
+	}
+	return strconv.FormatFloat(weightLb, 'f', -1, 64)
+}
+
+// DecodeWeight parses the "weight_lb" detail of a product event. Events
+// written before weights were published, or with a malformed or negative
+// detail, yield zero, which shipping treats as unknown.
+func DecodeWeight(detail string) float64 {
+	weightLb, err := strconv.ParseFloat(strings.TrimSpace(detail), 64)
+	if err != nil || weightLb < 0 {
+		return 0
+	}
+	return weightLb
+}
@@ -13,6 +13,9 @@ import (
 	"os"
 	"strings"
 
+	"go-shopping-poc/internal/platform/httperr"
+	"go-shopping-poc/internal/platform/logging"
+
 	"github.com/golang-jwt/jwt/v5"
 )
 
@@ -63,9 +66,9 @@ func init() {
 			level = slog.LevelError
 		}
 	}
-	authLogger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+	authLogger = slog.New(logging.NewRedactingHandler(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
 		Level: level,
-	})).With("component", "auth")
+	}))).With("component", "auth")
 }
 
 // NewKeycloakValidator creates a new Keycloak JWT validator
@@ -186,13 +189,13 @@ func RequireAuth(validator *KeycloakValidator, requiredRole string) func(http.Ha
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
 				authLogger.Info("Missing authorization header", "component", "auth", "operation", "RequireAuth")
-				http.Error(w, ErrMissingAuthHeader.Error(), http.StatusUnauthorized)
+				httperr.FromError(w, ErrMissingAuthHeader, "authentication failed")
 				return
 			}
 
 			if !strings.HasPrefix(authHeader, "Bearer ") {
 				authLogger.Info("Invalid authorization header format", "component", "auth", "operation", "RequireAuth", "auth_header", authHeader)
-				http.Error(w, ErrInvalidAuthHeader.Error(), http.StatusUnauthorized)
+				httperr.FromError(w, ErrInvalidAuthHeader, "authentication failed")
 				return
 			}
 
@@ -201,13 +204,13 @@ func RequireAuth(validator *KeycloakValidator, requiredRole string) func(http.Ha
 			claims, err := validator.ValidateToken(r.Context(), token)
 			if err != nil {
 				authLogger.Info("Invalid token", "component", "auth", "operation", "RequireAuth", "error", err)
-				http.Error(w, ErrInvalidToken.Error(), http.StatusUnauthorized)
+				httperr.FromError(w, ErrInvalidToken, "authentication failed")
 				return
 			}
 
 			if requiredRole != "" && !claims.HasRole(requiredRole) {
 				authLogger.Info("Missing required role", "component", "auth", "operation", "RequireAuth", "required_role", requiredRole, "user_roles", claims.RealmAccess.Roles)
-				http.Error(w, ErrMissingRole.Error(), http.StatusForbidden)
+				httperr.FromError(w, ErrMissingRole, "authorization failed")
 				return
 			}
 
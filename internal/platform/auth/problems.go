@@ -0,0 +1,16 @@
+package auth
+
+import (
+	"net/http"
+
+	platformerrors "go-shopping-poc/internal/platform/errors"
+	"go-shopping-poc/internal/platform/httperr"
+)
+
+// init maps authentication errors to problem responses.
+func init() {
+	httperr.Register(ErrMissingAuthHeader, httperr.Mapping{Status: http.StatusUnauthorized, Type: platformerrors.ErrorTypeUnauthorized, Code: "auth_missing_header"})
+	httperr.Register(ErrInvalidAuthHeader, httperr.Mapping{Status: http.StatusUnauthorized, Type: platformerrors.ErrorTypeUnauthorized, Code: "auth_invalid_header"})
+	httperr.Register(ErrInvalidToken, httperr.Mapping{Status: http.StatusUnauthorized, Type: platformerrors.ErrorTypeUnauthorized, Code: "auth_invalid_token"})
+	httperr.Register(ErrMissingRole, httperr.Mapping{Status: http.StatusForbidden, Type: platformerrors.ErrorTypeForbidden, Code: "auth_missing_role"})
+}
@@ -0,0 +1,125 @@
+package carrier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+)
+
+var (
+	// ErrUnknownCarrier is returned for a carrier name no adapter is
+	// registered for.
+	ErrUnknownCarrier = errors.New("unknown carrier")
+	// ErrBookingFailed is returned when a carrier does not accept a
+	// shipment.
+	ErrBookingFailed = errors.New("carrier booking failed")
+	// ErrInvalidWebhook is returned for a webhook request that cannot be
+	// read.
+	ErrInvalidWebhook = errors.New("invalid carrier webhook")
+	// ErrInvalidSignature is returned for a webhook request whose signature
+	// does not match.
+	ErrInvalidSignature = errors.New("invalid carrier webhook signature")
+)
+
+// Tracking statuses of a shipment, in the order a parcel usually goes
+// through them. StatusException reports a delivery problem.
+const (
+	StatusLabelCreated   = "label_created"
+	StatusInTransit      = "in_transit"
+	StatusOutForDelivery = "out_for_delivery"
+	StatusDelivered      = "delivered"
+	StatusException      = "exception"
+)
+
+// ValidStatus reports whether status is a tracking status.
+func ValidStatus(status string) bool {
+	switch status {
+	case StatusLabelCreated, StatusInTransit, StatusOutForDelivery, StatusDelivered, StatusException:
+		return true
+	}
+	return false
+}
+
+// Carrier books shipments with a parcel carrier.
+type Carrier interface {
+	// Name returns the name the carrier is registered under.
+	Name() string
+	// Book books a shipment and returns its tracking number. It returns
+	// ErrBookingFailed when the carrier does not accept it.
+	Book(ctx context.Context, shipment Shipment) (*Booking, error)
+	// ParseWebhook reads the tracking updates of a webhook request. It
+	// returns ErrInvalidWebhook or ErrInvalidSignature when it cannot.
+	ParseWebhook(r *http.Request) ([]TrackingUpdate, error)
+}
+
+// Address is the ship-to address of a shipment.
+type Address struct {
+	Name     string
+	Address1 string
+	Address2 string
+	City     string
+	State    string
+	Zip      string
+}
+
+// Shipment is the input to Carrier.Book. Reference identifies the shipment
+// to the shipper, such as an order number; Method is the service level.
+type Shipment struct {
+	Reference   string
+	Method      string
+	Destination Address
+	Parcels     int
+}
+
+// Booking is a shipment accepted by a carrier.
+type Booking struct {
+	Carrier        string
+	TrackingNumber string
+	Status         string
+	TrackingURL    string
+}
+
+// TrackingUpdate is a status change of a shipment reported by a carrier.
+type TrackingUpdate struct {
+	TrackingNumber string    `json:"tracking_number"`
+	Status         string    `json:"status"`
+	OccurredAt     time.Time `json:"occurred_at"`
+	Location       string    `json:"location,omitempty"`
+	Description    string    `json:"description,omitempty"`
+}
+
+// Registry holds the carriers shipments can be booked with.
+type Registry struct {
+	carriers map[string]Carrier
+}
+
+// NewRegistry creates a registry of carriers.
+func NewRegistry(carriers ...Carrier) *Registry {
+	r := &Registry{carriers: make(map[string]Carrier, len(carriers))}
+	for _, c := range carriers {
+		r.carriers[c.Name()] = c
+	}
+	return r
+}
+
+// Get returns the carrier registered as name.
+func (r *Registry) Get(name string) (Carrier, error) {
+	c, ok := r.carriers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCarrier, name)
+	}
+	return c, nil
+}
+
+// Names lists the registered carriers.
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.carriers))
+	for name := range r.carriers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,12 @@
+// Package carrier books shipments with parcel carriers and reads their
+// tracking webhooks.
+//
+// A Carrier books a Shipment and returns its tracking number, and turns the
+// webhook requests the carrier sends as the parcel moves into
+// TrackingUpdates. Carriers are looked up by name in a Registry, so the
+// order service can ship with any adapter registered at startup.
+//
+// FakeCarrier is a local stand-in: it issues tracking numbers itself and
+// accepts webhooks in its own JSON format, optionally signed with an
+// HMAC-SHA256 secret, so tracking can be driven by hand or from tests.
+package carrier
@@ -0,0 +1,117 @@
+package carrier
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newShipment() Shipment {
+	return Shipment{
+		Reference:   "ORD-2026-1000",
+		Method:      "standard",
+		Destination: Address{Name: "Ada Lovelace", Address1: "1 Main St", City: "Springfield", State: "IL", Zip: "62701"},
+		Parcels:     1,
+	}
+}
+
+func TestFakeCarrierBook(t *testing.T) {
+	t.Parallel()
+
+	c := NewFakeCarrier("")
+	first, err := c.Book(context.Background(), newShipment())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := c.Book(context.Background(), newShipment())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(first.TrackingNumber, "FK") || first.TrackingNumber == second.TrackingNumber {
+		t.Errorf("expected distinct FK tracking numbers, got %s and %s", first.TrackingNumber, second.TrackingNumber)
+	}
+	if first.Carrier != FakeName || first.Status != StatusLabelCreated {
+		t.Errorf("expected fake label_created booking, got %+v", first)
+	}
+
+	shipment := newShipment()
+	shipment.Destination.Zip = ""
+	if _, err := c.Book(context.Background(), shipment); !errors.Is(err, ErrBookingFailed) {
+		t.Errorf("expected ErrBookingFailed without a zip, got %v", err)
+	}
+}
+
+func TestFakeCarrierParseWebhook(t *testing.T) {
+	t.Parallel()
+
+	body := `{"events":[{"tracking_number":"FK0001","status":"in_transit","location":"Chicago"},` +
+		`{"tracking_number":"FK0001","status":"delivered","occurred_at":"2026-10-18T12:00:00Z"}]}`
+
+	c := NewFakeCarrier("secret")
+	req := httptest.NewRequest("POST", "/carriers/fake/webhook", strings.NewReader(body))
+	req.Header.Set(FakeSignatureHeader, c.Sign([]byte(body)))
+
+	updates, err := c.ParseWebhook(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(updates) != 2 {
+		t.Fatalf("expected 2 updates, got %d", len(updates))
+	}
+	if updates[0].Status != StatusInTransit || updates[0].Location != "Chicago" || updates[0].OccurredAt.IsZero() {
+		t.Errorf("unexpected first update: %+v", updates[0])
+	}
+	if updates[1].Status != StatusDelivered || updates[1].OccurredAt.Hour() != 12 {
+		t.Errorf("unexpected second update: %+v", updates[1])
+	}
+}
+
+func TestFakeCarrierParseWebhookRejects(t *testing.T) {
+	t.Parallel()
+
+	signed := NewFakeCarrier("secret")
+	unsigned := NewFakeCarrier("")
+	valid := `{"events":[{"tracking_number":"FK0001","status":"delivered"}]}`
+
+	tests := []struct {
+		name      string
+		carrier   *FakeCarrier
+		body      string
+		signature string
+		want      error
+	}{
+		{name: "missing signature", carrier: signed, body: valid, want: ErrInvalidSignature},
+		{name: "wrong signature", carrier: signed, body: valid, signature: NewFakeCarrier("other").Sign([]byte(valid)), want: ErrInvalidSignature},
+		{name: "no secret", carrier: unsigned, body: valid, signature: unsigned.Sign([]byte(valid)), want: ErrInvalidSignature},
+		{name: "malformed body", carrier: signed, body: `{"events":`, want: ErrInvalidWebhook},
+		{name: "unknown status", carrier: signed, body: `{"events":[{"tracking_number":"FK0001","status":"lost"}]}`, want: ErrInvalidWebhook},
+		{name: "no tracking number", carrier: signed, body: `{"events":[{"status":"delivered"}]}`, want: ErrInvalidWebhook},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/carriers/fake/webhook", strings.NewReader(tt.body))
+		switch {
+		case tt.signature != "":
+			req.Header.Set(FakeSignatureHeader, tt.signature)
+		case tt.want == ErrInvalidWebhook:
+			req.Header.Set(FakeSignatureHeader, tt.carrier.Sign([]byte(tt.body)))
+		}
+		if _, err := tt.carrier.ParseWebhook(req); !errors.Is(err, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, err)
+		}
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry(NewFakeCarrier(""))
+	c, err := r.Get(FakeName)
+	if err != nil || c.Name() != FakeName {
+		t.Fatalf("expected fake carrier, got %v, %v", c, err)
+	}
+	if _, err := r.Get("ups"); !errors.Is(err, ErrUnknownCarrier) {
+		t.Errorf("expected ErrUnknownCarrier, got %v", err)
+	}
+}
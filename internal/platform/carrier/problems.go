@@ -0,0 +1,16 @@
+package carrier
+
+import (
+	"net/http"
+
+	platformerrors "go-shopping-poc/internal/platform/errors"
+	"go-shopping-poc/internal/platform/httperr"
+)
+
+// init maps carrier errors to problem responses.
+func init() {
+	httperr.Register(ErrUnknownCarrier, httperr.Mapping{Status: http.StatusNotFound, Type: platformerrors.ErrorTypeNotFound, Code: "carrier_unknown", Message: "Unknown carrier"})
+	httperr.Register(ErrBookingFailed, httperr.Mapping{Status: http.StatusBadGateway, Type: platformerrors.ErrorTypeInternal, Code: "carrier_booking_failed", Message: "Carrier did not accept the shipment"})
+	httperr.Register(ErrInvalidWebhook, httperr.Mapping{Status: http.StatusBadRequest, Type: platformerrors.ErrorTypeValidation, Code: "carrier_invalid_webhook", ExposeDetail: true})
+	httperr.Register(ErrInvalidSignature, httperr.Mapping{Status: http.StatusUnauthorized, Type: platformerrors.ErrorTypeUnauthorized, Code: "carrier_invalid_signature", Message: "Invalid carrier webhook signature"})
+}
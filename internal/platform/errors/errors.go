@@ -2,13 +2,25 @@
 //
 // This package defines structured error responses and helper functions
 // for consistent error handling across all services in the application.
+// Responses follow RFC 7807 (application/problem+json); the legacy "error"
+// and "message" members are kept as extension members for existing clients.
 package errors
 
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 )
 
+// ContentTypeProblemJSON is the media type for RFC 7807 problem details.
+const ContentTypeProblemJSON = "application/problem+json"
+
+// RequestIDHeader carries the request correlation ID on requests and responses.
+const RequestIDHeader = "X-Request-ID"
+
+// problemTypePrefix namespaces problem type URIs by their stable code.
+const problemTypePrefix = "urn:go-shopping-poc:problem:"
+
 // ErrorResponse represents a structured error response
 type ErrorResponse struct {
 	Error   string `json:"error"`
@@ -16,36 +28,97 @@ type ErrorResponse struct {
 	Code    string `json:"code,omitempty"`
 }
 
+// FieldError describes a validation failure for a single request field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Problem is an RFC 7807 problem details document.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+
+	// Legacy envelope members
+	Error   string `json:"error"`
+	Message string `json:"message,omitempty"`
+}
+
 // ErrorType constants for consistent error categorization
 const (
-	ErrorTypeInvalidRequest = "invalid_request"
-	ErrorTypeValidation     = "validation_error"
-	ErrorTypeInternal       = "internal_error"
-	ErrorTypeNotFound       = "not_found"
-	ErrorTypeUnauthorized   = "unauthorized"
-	ErrorTypeForbidden      = "forbidden"
-	ErrorTypeGatewayTimeout = "gateway_timeout"
+	ErrorTypeInvalidRequest     = "invalid_request"
+	ErrorTypeValidation         = "validation_error"
+	ErrorTypeInternal           = "internal_error"
+	ErrorTypeNotFound           = "not_found"
+	ErrorTypeUnauthorized       = "unauthorized"
+	ErrorTypeForbidden          = "forbidden"
+	ErrorTypeConflict           = "conflict"
+	ErrorTypePreconditionFailed = "precondition_failed"
+	ErrorTypeTooManyRequests    = "too_many_requests"
+	ErrorTypeGatewayTimeout     = "gateway_timeout"
 )
 
-// SendError sends a structured JSON error response
-func SendError(w http.ResponseWriter, statusCode int, errorType, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	response := ErrorResponse{
+// NewProblem builds a problem document. When code is empty the error type
+// doubles as the stable code.
+func NewProblem(statusCode int, errorType, message, code string) Problem {
+	if code == "" {
+		code = errorType
+	}
+	return Problem{
+		Type:    problemTypePrefix + code,
+		Title:   http.StatusText(statusCode),
+		Status:  statusCode,
+		Detail:  message,
+		Code:    code,
 		Error:   errorType,
 		Message: message,
 	}
-	_ = json.NewEncoder(w).Encode(response)
 }
 
-// SendErrorWithCode sends a structured JSON error response with error code
+// SendProblem writes a problem+json response. The request ID is taken from
+// the X-Request-ID response header when the problem does not carry one.
+func SendProblem(w http.ResponseWriter, problem Problem) {
+	if problem.RequestID == "" {
+		problem.RequestID = w.Header().Get(RequestIDHeader)
+	}
+	w.Header().Set("Content-Type", ContentTypeProblemJSON)
+	w.WriteHeader(problem.Status)
+	_ = json.NewEncoder(w).Encode(problem)
+}
+
+// SendError sends a structured problem+json error response
+func SendError(w http.ResponseWriter, statusCode int, errorType, message string) {
+	SendProblem(w, NewProblem(statusCode, errorType, message, ""))
+}
+
+// SendErrorWithCode sends a structured problem+json error response with error code
 func SendErrorWithCode(w http.ResponseWriter, statusCode int, errorType, message, code string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	response := ErrorResponse{
-		Error:   errorType,
-		Message: message,
-		Code:    code,
+	SendProblem(w, NewProblem(statusCode, errorType, message, code))
+}
+
+// ValidationError is a domain error carrying field-level validation failures.
+// Transport layers render it as a validation_error problem with one entry per
+// field.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// NewValidationError returns a ValidationError for a single field.
+func NewValidationError(field, message string) *ValidationError {
+	return &ValidationError{Fields: []FieldError{{Field: field, Message: message}}}
+}
+
+// Error joins the field messages.
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Message
 	}
-	_ = json.NewEncoder(w).Encode(response)
+	return strings.Join(msgs, "; ")
 }
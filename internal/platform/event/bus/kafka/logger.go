@@ -3,6 +3,8 @@ package kafka
 import (
 	"log/slog"
 	"os"
+
+	"go-shopping-poc/internal/platform/logging"
 )
 
 var (
@@ -10,7 +12,7 @@ var (
 )
 
 func init() {
-	logger = slog.New(slog.NewJSONHandler(os.Stderr, nil)).
+	logger = slog.New(logging.NewRedactingHandler(slog.NewJSONHandler(os.Stderr, nil))).
 		With("platform", "event", "component", "kafka")
 }
 
@@ -0,0 +1,14 @@
+// Package fx provides foreign exchange rates for pricing carts in a
+// currency other than the catalog's.
+//
+// A Provider quotes the Rate between two currencies. StaticProvider serves a
+// versioned RateTable read from JSON (see LoadRateTable); when no path is
+// configured the embedded rates/default.json table is used. HTTPProvider
+// fetches the same table from a rates service and caches it, and NewHandler
+// serves a table over HTTP so cmd/fxrates can stand in for that service
+// locally.
+//
+// Tables list rates against a single base currency; rates between two other
+// currencies are crossed through the base. Callers snapshot the Rate they
+// used, so later table updates do not change prices already quoted.
+package fx
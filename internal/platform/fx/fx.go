@@ -0,0 +1,41 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go-shopping-poc/internal/platform/money"
+)
+
+var (
+	// ErrUnsupportedCurrency is returned for a currency the rates do not cover.
+	ErrUnsupportedCurrency = errors.New("unsupported currency")
+	// ErrRateUnavailable is returned when rates cannot be obtained.
+	ErrRateUnavailable = errors.New("exchange rate unavailable")
+	// ErrInvalidRateTable is returned when a rate table cannot be used.
+	ErrInvalidRateTable = errors.New("invalid exchange rate table")
+)
+
+// Provider quotes exchange rates.
+type Provider interface {
+	// Rate returns the rate converting from into to. It returns
+	// ErrUnsupportedCurrency or ErrRateUnavailable when it cannot.
+	Rate(ctx context.Context, from, to string) (Rate, error)
+}
+
+// Rate converts amounts in From into To: one unit of From buys Value units
+// of To. AsOf and Source identify the table the rate came from.
+type Rate struct {
+	From   string    `json:"from"`
+	To     string    `json:"to"`
+	Value  float64   `json:"value"`
+	AsOf   time.Time `json:"as_of"`
+	Source string    `json:"source"`
+}
+
+// Convert converts m into r.To. An amount of unspecified currency is taken
+// to be in r.From.
+func (r Rate) Convert(m money.Money) money.Money {
+	return m.In(r.From).Exchange(r.Value, r.To)
+}
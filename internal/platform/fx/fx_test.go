@@ -0,0 +1,156 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-shopping-poc/internal/platform/money"
+)
+
+func newDefaultTable(t *testing.T) *RateTable {
+	t.Helper()
+
+	table, err := DefaultRateTable()
+	if err != nil {
+		t.Fatalf("failed to load default table: %v", err)
+	}
+	return table
+}
+
+func TestStaticProviderRates(t *testing.T) {
+	t.Parallel()
+
+	provider := NewStaticProvider(newDefaultTable(t))
+
+	tests := []struct {
+		from, to string
+		amount   string
+		want     string
+	}{
+		{from: "USD", to: "USD", amount: "10.00", want: "10.00 USD"},
+		{from: "USD", to: "EUR", amount: "10.00", want: "9.24 EUR"},
+		{from: "eur", to: "usd", amount: "9.24", want: "10.00 USD"},
+		{from: "EUR", to: "GBP", amount: "100.00", want: "85.45 GBP"},
+		{from: "USD", to: "MXN", amount: "19.99", want: "368.06 MXN"},
+	}
+	for _, tt := range tests {
+		rate, err := provider.Rate(context.Background(), tt.from, tt.to)
+		if err != nil {
+			t.Fatalf("%s->%s: unexpected error: %v", tt.from, tt.to, err)
+		}
+		got := rate.Convert(money.MustParse(tt.amount, tt.from))
+		if got.Format() != tt.want {
+			t.Errorf("%s %s->%s: expected %s, got %s", tt.amount, tt.from, tt.to, tt.want, got.Format())
+		}
+		if rate.Source != "static:2026.10" || rate.AsOf.IsZero() {
+			t.Errorf("%s->%s: rate not attributed: %+v", tt.from, tt.to, rate)
+		}
+	}
+}
+
+func TestStaticProviderUnsupportedCurrency(t *testing.T) {
+	t.Parallel()
+
+	provider := NewStaticProvider(newDefaultTable(t))
+	if _, err := provider.Rate(context.Background(), "USD", "XAU"); !errors.Is(err, ErrUnsupportedCurrency) {
+		t.Fatalf("expected ErrUnsupportedCurrency, got %v", err)
+	}
+}
+
+func TestParseRateTableRejectsInvalid(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"missing version": `{"base":"USD","as_of":"2026-10-01T00:00:00Z","rates":{"EUR":0.9}}`,
+		"bad base":        `{"version":"1","base":"US","as_of":"2026-10-01T00:00:00Z","rates":{}}`,
+		"missing as_of":   `{"version":"1","base":"USD","rates":{"EUR":0.9}}`,
+		"zero rate":       `{"version":"1","base":"USD","as_of":"2026-10-01T00:00:00Z","rates":{"EUR":0}}`,
+	}
+	for name, data := range tests {
+		if _, err := ParseRateTableJSON([]byte(data)); !errors.Is(err, ErrInvalidRateTable) {
+			t.Errorf("%s: expected ErrInvalidRateTable, got %v", name, err)
+		}
+	}
+}
+
+func TestRebase(t *testing.T) {
+	t.Parallel()
+
+	table := newDefaultTable(t)
+	rebased, err := table.Rebase("EUR")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rebased.Rates["EUR"] != 1 {
+		t.Errorf("expected base rate 1, got %v", rebased.Rates["EUR"])
+	}
+
+	want, _ := table.Rate("EUR", "GBP")
+	got, _ := rebased.Rate("EUR", "GBP")
+	if got.Value != want.Value {
+		t.Errorf("expected EUR->GBP %v, got %v", want.Value, got.Value)
+	}
+}
+
+func TestHTTPProviderFetchesAndCaches(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+	var failing atomic.Bool
+	handler := NewHandler(newDefaultTable(t))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	provider := NewHTTPProvider(server.URL, WithRefreshInterval(time.Minute))
+	provider.now = func() time.Time { return now }
+
+	for range 3 {
+		rate, err := provider.Rate(context.Background(), "USD", "EUR")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if rate.Value != 0.9238 {
+			t.Fatalf("expected 0.9238, got %v", rate.Value)
+		}
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("expected 1 fetch, got %d", got)
+	}
+
+	// After the refresh interval a failing service keeps the cached table.
+	failing.Store(true)
+	now = now.Add(2 * time.Minute)
+	if _, err := provider.Rate(context.Background(), "USD", "EUR"); err != nil {
+		t.Fatalf("expected cached rate, got %v", err)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("expected a refresh attempt, got %d fetches", got)
+	}
+}
+
+func TestHTTPProviderUnavailable(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	provider := NewHTTPProvider(server.URL)
+	if _, err := provider.Rate(context.Background(), "USD", "EUR"); !errors.Is(err, ErrRateUnavailable) {
+		t.Fatalf("expected ErrRateUnavailable, got %v", err)
+	}
+}
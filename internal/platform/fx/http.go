@@ -0,0 +1,168 @@
+package fx
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go-shopping-poc/internal/platform/httperr"
+	"go-shopping-poc/internal/platform/httpx"
+)
+
+const defaultRefreshInterval = 15 * time.Minute
+
+// HTTPOption is a functional option for configuring HTTPProvider.
+type HTTPOption func(*HTTPProvider)
+
+// WithHTTPClient sets the client used to fetch rates.
+func WithHTTPClient(client *http.Client) HTTPOption {
+	return func(p *HTTPProvider) {
+		p.client = client
+	}
+}
+
+// WithRefreshInterval sets how long a fetched table is used before it is
+// fetched again.
+func WithRefreshInterval(interval time.Duration) HTTPOption {
+	return func(p *HTTPProvider) {
+		p.refresh = interval
+	}
+}
+
+// WithLogger sets the logger for the HTTPProvider.
+func WithLogger(logger *slog.Logger) HTTPOption {
+	return func(p *HTTPProvider) {
+		p.logger = logger
+	}
+}
+
+// HTTPProvider implements Provider by fetching a RateTable from
+// GET {baseURL}/rates, as served by NewHandler. The table is cached for the
+// refresh interval; if a refresh fails the previous table keeps being used
+// so a rates outage does not stop carts from pricing.
+type HTTPProvider struct {
+	baseURL string
+	client  *http.Client
+	refresh time.Duration
+	logger  *slog.Logger
+	now     func() time.Time
+
+	mu        sync.Mutex
+	table     *RateTable
+	fetchedAt time.Time
+}
+
+// NewHTTPProvider creates a provider for the rates service at baseURL.
+func NewHTTPProvider(baseURL string, opts ...HTTPOption) *HTTPProvider {
+	p := &HTTPProvider{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: 5 * time.Second},
+		refresh: defaultRefreshInterval,
+		now:     time.Now,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.logger == nil {
+		p.logger = slog.Default()
+	}
+	p.logger = p.logger.With("component", "fx_http_provider")
+	return p
+}
+
+// Rate implements Provider.
+func (p *HTTPProvider) Rate(ctx context.Context, from, to string) (Rate, error) {
+	table, err := p.currentTable(ctx)
+	if err != nil {
+		return Rate{}, err
+	}
+	return table.Rate(from, to)
+}
+
+func (p *HTTPProvider) currentTable(ctx context.Context) (*RateTable, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.table != nil && p.now().Sub(p.fetchedAt) < p.refresh {
+		return p.table, nil
+	}
+
+	table, err := p.fetch(ctx)
+	if err != nil {
+		if p.table != nil {
+			p.logger.Warn("Failed to refresh exchange rates, using cached table",
+				"version", p.table.Version,
+				"error", err.Error(),
+			)
+			return p.table, nil
+		}
+		return nil, err
+	}
+
+	p.table = table
+	p.fetchedAt = p.now()
+	p.logger.Debug("Exchange rates refreshed", "version", table.Version, "base", table.Base)
+	return table, nil
+}
+
+func (p *HTTPProvider) fetch(ctx context.Context) (*RateTable, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/rates", nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrRateUnavailable, err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrRateUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: rates service returned %s", ErrRateUnavailable, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrRateUnavailable, err)
+	}
+	table, err := ParseRateTableJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrRateUnavailable, err)
+	}
+	return table, nil
+}
+
+var _ Provider = (*HTTPProvider)(nil)
+
+// NewHandler serves table at GET /rates, optionally rebased with
+// ?base=XXX, and a single rate at GET /rates/{from}/{to}.
+func NewHandler(table *RateTable) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /rates", func(w http.ResponseWriter, r *http.Request) {
+		served := table
+		if base := r.URL.Query().Get("base"); base != "" {
+			rebased, err := table.Rebase(base)
+			if err != nil {
+				httperr.Validation(w, err.Error())
+				return
+			}
+			served = rebased
+		}
+		_ = httpx.WriteJSON(w, http.StatusOK, served)
+	})
+	mux.HandleFunc("GET /rates/{from}/{to}", func(w http.ResponseWriter, r *http.Request) {
+		rate, err := table.Rate(r.PathValue("from"), r.PathValue("to"))
+		if err != nil {
+			httperr.NotFound(w, err.Error())
+			return
+		}
+		_ = httpx.WriteJSON(w, http.StatusOK, rate)
+	})
+	return mux
+}
@@ -0,0 +1,25 @@
+package fx
+
+import "context"
+
+// StaticProvider implements Provider over a fixed RateTable.
+type StaticProvider struct {
+	table *RateTable
+}
+
+// NewStaticProvider creates a provider for table.
+func NewStaticProvider(table *RateTable) *StaticProvider {
+	return &StaticProvider{table: table}
+}
+
+// Table returns the provider's rate table.
+func (p *StaticProvider) Table() *RateTable {
+	return p.table
+}
+
+// Rate implements Provider.
+func (p *StaticProvider) Rate(_ context.Context, from, to string) (Rate, error) {
+	return p.table.Rate(from, to)
+}
+
+var _ Provider = (*StaticProvider)(nil)
@@ -0,0 +1,152 @@
+package fx
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	"go-shopping-poc/internal/platform/money"
+)
+
+//go:embed rates/default.json
+var defaultRates embed.FS
+
+// RateTable is a versioned set of rates against Base: one unit of Base buys
+// Rates[c] units of c. Base itself need not be listed.
+type RateTable struct {
+	Version string             `json:"version"`
+	Source  string             `json:"source,omitempty"`
+	Base    string             `json:"base"`
+	AsOf    time.Time          `json:"as_of"`
+	Rates   map[string]float64 `json:"rates"`
+}
+
+// Validate checks the table is usable and normalizes its currency codes.
+func (t *RateTable) Validate() error {
+	if t.Version == "" {
+		return fmt.Errorf("%w: version is required", ErrInvalidRateTable)
+	}
+	if !money.ValidCurrency(t.Base) {
+		return fmt.Errorf("%w: invalid base currency %q", ErrInvalidRateTable, t.Base)
+	}
+	if t.AsOf.IsZero() {
+		return fmt.Errorf("%w: as_of is required", ErrInvalidRateTable)
+	}
+	t.Base = money.NormalizeCurrency(t.Base)
+
+	rates := make(map[string]float64, len(t.Rates))
+	for code, value := range t.Rates {
+		if !money.ValidCurrency(code) {
+			return fmt.Errorf("%w: invalid currency %q", ErrInvalidRateTable, code)
+		}
+		if value <= 0 || math.IsInf(value, 0) || math.IsNaN(value) {
+			return fmt.Errorf("%w: rate for %s must be positive", ErrInvalidRateTable, code)
+		}
+		rates[money.NormalizeCurrency(code)] = value
+	}
+	rates[t.Base] = 1
+	t.Rates = rates
+	return nil
+}
+
+// Currencies lists the currencies the table covers, sorted.
+func (t *RateTable) Currencies() []string {
+	codes := make([]string, 0, len(t.Rates))
+	for code := range t.Rates {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// Rate returns the rate converting from into to, crossed through the base
+// currency when neither is the base.
+func (t *RateTable) Rate(from, to string) (Rate, error) {
+	from, to = money.NormalizeCurrency(from), money.NormalizeCurrency(to)
+	fromRate, ok := t.Rates[from]
+	if !ok {
+		return Rate{}, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, from)
+	}
+	toRate, ok := t.Rates[to]
+	if !ok {
+		return Rate{}, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, to)
+	}
+	return Rate{
+		From:   from,
+		To:     to,
+		Value:  roundRate(toRate / fromRate),
+		AsOf:   t.AsOf,
+		Source: t.source(),
+	}, nil
+}
+
+// Rebase returns the table expressed against base.
+func (t *RateTable) Rebase(base string) (*RateTable, error) {
+	base = money.NormalizeCurrency(base)
+	baseRate, ok := t.Rates[base]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, base)
+	}
+	rebased := &RateTable{
+		Version: t.Version,
+		Source:  t.Source,
+		Base:    base,
+		AsOf:    t.AsOf,
+		Rates:   make(map[string]float64, len(t.Rates)),
+	}
+	for code, value := range t.Rates {
+		rebased.Rates[code] = roundRate(value / baseRate)
+	}
+	return rebased, nil
+}
+
+func (t *RateTable) source() string {
+	if t.Source == "" {
+		return t.Version
+	}
+	return t.Source + ":" + t.Version
+}
+
+// roundRate keeps eight decimal places, which is finer than any minor unit
+// a converted cart line is rounded to.
+func roundRate(v float64) float64 {
+	return math.Round(v*1e8) / 1e8
+}
+
+// DefaultRateTable returns the embedded rate table.
+func DefaultRateTable() (*RateTable, error) {
+	data, err := defaultRates.ReadFile("rates/default.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read default exchange rates: %w", err)
+	}
+	return ParseRateTableJSON(data)
+}
+
+// LoadRateTable reads a JSON rate table. An empty path returns the embedded
+// default table.
+func LoadRateTable(path string) (*RateTable, error) {
+	if path == "" {
+		return DefaultRateTable()
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read exchange rate table: %w", err)
+	}
+	return ParseRateTableJSON(data)
+}
+
+// ParseRateTableJSON decodes and validates a JSON rate table.
+func ParseRateTableJSON(data []byte) (*RateTable, error) {
+	var table RateTable
+	if err := json.Unmarshal(data, &table); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidRateTable, err)
+	}
+	if err := table.Validate(); err != nil {
+		return nil, err
+	}
+	return &table, nil
+}
@@ -1,5 +1,8 @@
 // Package httperr provides reusable transport-level HTTP error helpers.
 //
-// Domain services own status selection and message semantics; this package only
-// centralizes response envelope mechanics.
+// Every response is an RFC 7807 application/problem+json document carrying a
+// stable machine-readable code and the request ID from the X-Request-ID
+// response header. Domain packages register their sentinel errors with
+// Register so handlers can translate them with FromError instead of matching
+// on error text.
 package httperr
@@ -0,0 +1,101 @@
+package httperr
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+
+	platformerrors "go-shopping-poc/internal/platform/errors"
+)
+
+// Mapping describes how a domain error is rendered as a problem response.
+type Mapping struct {
+	// Status is the HTTP status code.
+	Status int
+	// Type is the error category, e.g. platformerrors.ErrorTypeNotFound.
+	Type string
+	// Code is the stable machine-readable code clients can switch on.
+	Code string
+	// Message is the client-facing detail. When empty the registered
+	// error's own text is used; wrapped context is not exposed.
+	Message string
+	// ExposeDetail uses the full error text, including wrapped context, as
+	// the detail. Only set it for errors whose context is safe to show.
+	ExposeDetail bool
+}
+
+type registration struct {
+	target  error
+	mapping Mapping
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   []registration
+)
+
+// Register maps a sentinel error to a problem response. Lookups match with
+// errors.Is in registration order, so register more specific errors first.
+// Domain packages register their errors from init.
+func Register(target error, mapping Mapping) {
+	if mapping.Status == 0 {
+		mapping.Status = http.StatusInternalServerError
+	}
+	if mapping.Type == "" {
+		mapping.Type = platformerrors.ErrorTypeInternal
+	}
+	if mapping.Message == "" {
+		mapping.Message = target.Error()
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry = append(registry, registration{target: target, mapping: mapping})
+}
+
+// Lookup returns the mapping registered for err, if any.
+func Lookup(err error) (Mapping, bool) {
+	if err == nil {
+		return Mapping{}, false
+	}
+
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	for _, reg := range registry {
+		if errors.Is(err, reg.target) {
+			return reg.mapping, true
+		}
+	}
+	return Mapping{}, false
+}
+
+// TryFromError writes the problem response registered for err and reports
+// whether a mapping was found. Nothing is written when it returns false.
+func TryFromError(w http.ResponseWriter, err error) bool {
+	mapping, ok := Lookup(err)
+	if !ok {
+		return false
+	}
+	message := mapping.Message
+	if mapping.ExposeDetail {
+		message = err.Error()
+	}
+	SendWithCode(w, mapping.Status, mapping.Type, message, mapping.Code)
+	return true
+}
+
+// FromError writes the problem response registered for err, falling back to
+// a 500 internal_error with fallbackMessage for unregistered errors. Errors
+// wrapping a *platformerrors.ValidationError are rendered as a 400 listing
+// the invalid fields.
+func FromError(w http.ResponseWriter, err error, fallbackMessage string) {
+	var validationErr *platformerrors.ValidationError
+	if errors.As(err, &validationErr) {
+		ValidationFields(w, validationErr.Error(), validationErr.Fields...)
+		return
+	}
+	if TryFromError(w, err) {
+		return
+	}
+	Internal(w, fallbackMessage)
+}
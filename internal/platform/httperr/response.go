@@ -45,3 +45,23 @@ func Forbidden(w http.ResponseWriter, message string) {
 func GatewayTimeout(w http.ResponseWriter, message string) {
 	Send(w, http.StatusGatewayTimeout, platformerrors.ErrorTypeGatewayTimeout, message)
 }
+
+// Unauthorized writes a 401 unauthorized error response.
+func Unauthorized(w http.ResponseWriter, message string) {
+	Send(w, http.StatusUnauthorized, platformerrors.ErrorTypeUnauthorized, message)
+}
+
+// Conflict writes a 409 conflict error response.
+func Conflict(w http.ResponseWriter, message string) {
+	Send(w, http.StatusConflict, platformerrors.ErrorTypeConflict, message)
+}
+
+// FieldError describes a validation failure for a single request field.
+type FieldError = platformerrors.FieldError
+
+// ValidationFields writes a 400 validation_error response listing the invalid fields.
+func ValidationFields(w http.ResponseWriter, message string, fields ...FieldError) {
+	problem := platformerrors.NewProblem(http.StatusBadRequest, platformerrors.ErrorTypeValidation, message, "")
+	problem.Errors = fields
+	platformerrors.SendProblem(w, problem)
+}
@@ -2,15 +2,25 @@ package httperr
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	platformerrors "go-shopping-poc/internal/platform/errors"
 )
 
 type errorEnvelope struct {
-	Error   string `json:"error"`
-	Message string `json:"message"`
-	Code    string `json:"code"`
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail"`
+	RequestID string       `json:"request_id"`
+	Errors    []FieldError `json:"errors"`
+	Error     string       `json:"error"`
+	Message   string       `json:"message"`
+	Code      string       `json:"code"`
 }
 
 func TestSend(t *testing.T) {
@@ -29,6 +39,84 @@ func TestSend(t *testing.T) {
 	if payload.Error != "invalid_request" || payload.Message != "bad input" {
 		t.Fatalf("unexpected payload: %+v", payload)
 	}
+	if ct := rr.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Fatalf("unexpected content type: %s", ct)
+	}
+	if payload.Status != http.StatusBadRequest || payload.Title != "Bad Request" || payload.Detail != "bad input" {
+		t.Fatalf("unexpected problem fields: %+v", payload)
+	}
+	if payload.Code != "invalid_request" || payload.Type == "" {
+		t.Fatalf("expected code and type to default from error type: %+v", payload)
+	}
+}
+
+func TestSendIncludesRequestID(t *testing.T) {
+	rr := httptest.NewRecorder()
+	rr.Header().Set("X-Request-ID", "req-123")
+	NotFound(rr, "missing")
+
+	var payload errorEnvelope
+	if err := json.Unmarshal(rr.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if payload.RequestID != "req-123" {
+		t.Fatalf("expected request id req-123, got %q", payload.RequestID)
+	}
+}
+
+func TestValidationFields(t *testing.T) {
+	rr := httptest.NewRecorder()
+	ValidationFields(rr, "invalid input", FieldError{Field: "quantity", Message: "quantity must be positive"})
+
+	var payload errorEnvelope
+	if err := json.Unmarshal(rr.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if rr.Code != http.StatusBadRequest || payload.Code != "validation_error" {
+		t.Fatalf("unexpected response: %d %+v", rr.Code, payload)
+	}
+	if len(payload.Errors) != 1 || payload.Errors[0].Field != "quantity" {
+		t.Fatalf("unexpected field errors: %+v", payload.Errors)
+	}
+}
+
+func TestFromError(t *testing.T) {
+	errRegistered := errors.New("widget not found")
+	errDetailed := errors.New("widget locked")
+	Register(errRegistered, Mapping{Status: http.StatusNotFound, Type: "not_found", Code: "widget_not_found"})
+	Register(errDetailed, Mapping{Status: http.StatusConflict, Type: "conflict", Code: "widget_locked", ExposeDetail: true})
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+		wantDetail string
+	}{
+		{"wrapped registered", fmt.Errorf("lookup: %w", errRegistered), http.StatusNotFound, "widget_not_found", "widget not found"},
+		{"exposed detail", fmt.Errorf("%w: by order 7", errDetailed), http.StatusConflict, "widget_locked", "widget locked: by order 7"},
+		{"validation", fmt.Errorf("bad: %w", platformerrors.NewValidationError("name", "name is required")), http.StatusBadRequest, "validation_error", "name is required"},
+		{"unregistered", errors.New("db down"), http.StatusInternalServerError, "internal_error", "fallback"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			FromError(rr, tt.err, "fallback")
+
+			var payload errorEnvelope
+			if err := json.Unmarshal(rr.Body.Bytes(), &payload); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if rr.Code != tt.wantStatus || payload.Code != tt.wantCode || payload.Detail != tt.wantDetail {
+				t.Fatalf("got %d %q %q, want %d %q %q", rr.Code, payload.Code, payload.Detail, tt.wantStatus, tt.wantCode, tt.wantDetail)
+			}
+		})
+	}
+
+	if TryFromError(httptest.NewRecorder(), errors.New("unknown")) {
+		t.Fatal("expected TryFromError to report unmapped error")
+	}
 }
 
 func TestSendWithCodeAndHelpers(t *testing.T) {
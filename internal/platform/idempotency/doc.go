@@ -0,0 +1,16 @@
+// Package idempotency provides Idempotency-Key handling for mutating HTTP
+// endpoints.
+//
+// The first request with a given key runs normally and its response is
+// stored together with a fingerprint of the request. Repeats with the same
+// fingerprint replay the stored response; repeats with a different body get a
+// 409. Responses with a 5xx status are not stored so clients can retry.
+//
+// Each service keeps keys in its own database in the idempotency schema (see
+// the service migrations), using PostgresStore.
+//
+// Usage:
+//
+//	idem := idempotency.NewMiddleware(idempotency.NewPostgresStore(db), idempotency.WithLogger(logger))
+//	router.With(idem.Handler("cart.checkout")).Post("/carts/{id}/checkout", handler.Checkout)
+package idempotency
@@ -0,0 +1,219 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"go-shopping-poc/internal/platform/auth"
+	platformerrors "go-shopping-poc/internal/platform/errors"
+	"go-shopping-poc/internal/platform/httperr"
+)
+
+const (
+	// HeaderKey is the request header carrying the client's idempotency key.
+	HeaderKey = "Idempotency-Key"
+	// HeaderReplayed marks responses served from the store.
+	HeaderReplayed = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+	maxBodyBytes = 1 << 20
+)
+
+// storedHeaders are the response headers kept for replay.
+var storedHeaders = []string{"Content-Type", "Location", "ETag"}
+
+// Option is a functional option for configuring Middleware.
+type Option func(*Middleware)
+
+// WithLogger sets the logger for the Middleware.
+func WithLogger(logger *slog.Logger) Option {
+	return func(m *Middleware) {
+		m.logger = logger
+	}
+}
+
+// Middleware applies Idempotency-Key semantics to routes.
+type Middleware struct {
+	store  Store
+	logger *slog.Logger
+}
+
+// NewMiddleware creates idempotency middleware backed by store.
+func NewMiddleware(store Store, opts ...Option) *Middleware {
+	m := &Middleware{store: store}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.logger == nil {
+		m.logger = slog.Default()
+	}
+	m.logger = m.logger.With("component", "idempotency")
+	return m
+}
+
+// Handler returns middleware for the route named scope. Requests without an
+// Idempotency-Key header pass through unchanged.
+func (m *Middleware) Handler(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(HeaderKey)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxKeyLength {
+				httperr.SendWithCode(w, http.StatusBadRequest, platformerrors.ErrorTypeInvalidRequest,
+					"Idempotency-Key is too long", "idempotency_key_invalid")
+				return
+			}
+
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxBodyBytes+1))
+			if err != nil {
+				httperr.InvalidRequest(w, "Failed to read request body")
+				return
+			}
+			if len(body) > maxBodyBytes {
+				httperr.InvalidRequest(w, "Request body too large")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			fullScope := scopeFor(scope, r)
+			log := m.logger.With("scope", scope)
+
+			fp := fingerprint(r, body)
+
+			acquired, existing, err := m.store.Acquire(r.Context(), fullScope, key, fp)
+			if err != nil {
+				log.Error("Failed to acquire idempotency key", "error", err.Error())
+				httperr.Internal(w, "Failed to process idempotency key")
+				return
+			}
+
+			if !acquired {
+				m.handleExisting(w, existing, fp, log)
+				return
+			}
+
+			// The outcome is stored even when the client gave up waiting and
+			// cancelled the request, which is when it is most likely retried.
+			// Unless it is stored, also when the handler panics, the key is
+			// released so that the request can be retried.
+			storeCtx := context.WithoutCancel(r.Context())
+			stored := false
+			defer func() {
+				if stored {
+					return
+				}
+				if err := m.store.Release(storeCtx, fullScope, key); err != nil {
+					log.Error("Failed to release idempotency key", "error", err.Error())
+				}
+			}()
+
+			rec := newRecorder(w)
+			next.ServeHTTP(rec, r)
+
+			if rec.Status() >= http.StatusInternalServerError {
+				return
+			}
+
+			stored = true
+			if err := m.store.Complete(storeCtx, fullScope, key, rec.response()); err != nil {
+				log.Error("Failed to store idempotent response", "error", err.Error())
+			}
+		})
+	}
+}
+
+func (m *Middleware) handleExisting(w http.ResponseWriter, existing *Record, fp string, log *slog.Logger) {
+	if existing.Fingerprint != fp {
+		log.Warn("Idempotency key reused with a different request")
+		httperr.SendWithCode(w, http.StatusConflict, platformerrors.ErrorTypeConflict,
+			"Idempotency-Key was already used with a different request", "idempotency_key_mismatch")
+		return
+	}
+	if !existing.Completed || existing.Response == nil {
+		httperr.SendWithCode(w, http.StatusConflict, platformerrors.ErrorTypeConflict,
+			"A request with this Idempotency-Key is still being processed", "idempotency_request_in_progress")
+		return
+	}
+
+	log.Debug("Replaying stored response", "status", existing.Response.Status)
+	for name, values := range existing.Response.Header {
+		for _, v := range values {
+			w.Header().Add(name, v)
+		}
+	}
+	w.Header().Set(HeaderReplayed, "true")
+	w.WriteHeader(existing.Response.Status)
+	_, _ = w.Write(existing.Response.Body)
+}
+
+// scopeFor namespaces keys by route, path and authenticated subject so two
+// clients choosing the same key cannot see each other's responses.
+func scopeFor(scope string, r *http.Request) string {
+	s := scope + "|" + r.URL.Path
+	if claims, ok := auth.GetClaims(r.Context()); ok && claims.Subject != "" {
+		s += "|" + claims.Subject
+	}
+	return s
+}
+
+// fingerprint hashes the parts of the request that must match on replay.
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method))
+	h.Write([]byte{0})
+	h.Write([]byte(r.URL.Path))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recorder passes the response through while keeping a copy for storage.
+type recorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func newRecorder(w http.ResponseWriter) *recorder {
+	return &recorder{ResponseWriter: w}
+}
+
+func (rec *recorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *recorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+func (rec *recorder) Status() int {
+	if rec.status == 0 {
+		return http.StatusOK
+	}
+	return rec.status
+}
+
+func (rec *recorder) response() Response {
+	header := http.Header{}
+	for _, name := range storedHeaders {
+		if v := rec.Header().Get(name); v != "" {
+			header.Set(name, v)
+		}
+	}
+	return Response{Status: rec.Status(), Header: header, Body: rec.body.Bytes()}
+}
@@ -0,0 +1,224 @@
+package idempotency
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type memoryStore struct {
+	mu      sync.Mutex
+	records map[string]*Record
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{records: make(map[string]*Record)}
+}
+
+func (s *memoryStore) Acquire(_ context.Context, scope, key, fingerprint string) (bool, *Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rec, ok := s.records[scope+"/"+key]; ok {
+		copied := *rec
+		return false, &copied, nil
+	}
+	s.records[scope+"/"+key] = &Record{Fingerprint: fingerprint}
+	return true, nil, nil
+}
+
+func (s *memoryStore) Complete(ctx context.Context, scope, key string, resp Response) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec := s.records[scope+"/"+key]
+	rec.Completed = true
+	rec.Response = &resp
+	return nil
+}
+
+func (s *memoryStore) Release(ctx context.Context, scope, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, scope+"/"+key)
+	return nil
+}
+
+func countingHandler(calls *int, status int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", "/carts/1")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"echo":` + string(body) + `}`))
+	})
+}
+
+func doRequest(h http.Handler, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/carts/1/items", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(HeaderKey, key)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandlerReplaysCompletedResponse(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	h := NewMiddleware(newMemoryStore()).Handler("cart.add_item")(countingHandler(&calls, http.StatusCreated))
+
+	first := doRequest(h, "key-1", `{"qty":1}`)
+	second := doRequest(h, "key-1", `{"qty":1}`)
+
+	if calls != 1 {
+		t.Fatalf("expected handler to run once, ran %d times", calls)
+	}
+	if second.Code != http.StatusCreated || second.Body.String() != first.Body.String() {
+		t.Errorf("expected replay of %d %q, got %d %q", first.Code, first.Body.String(), second.Code, second.Body.String())
+	}
+	if second.Header().Get(HeaderReplayed) != "true" {
+		t.Error("expected replayed header on repeat")
+	}
+	if second.Header().Get("Location") != "/carts/1" {
+		t.Errorf("expected stored Location header, got %q", second.Header().Get("Location"))
+	}
+	if first.Header().Get(HeaderReplayed) != "" {
+		t.Error("first response must not be marked as replayed")
+	}
+}
+
+func TestHandlerRejectsDifferentBody(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	h := NewMiddleware(newMemoryStore()).Handler("cart.add_item")(countingHandler(&calls, http.StatusCreated))
+
+	doRequest(h, "key-1", `{"qty":1}`)
+	rec := doRequest(h, "key-1", `{"qty":2}`)
+
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "idempotency_key_mismatch") {
+		t.Errorf("expected mismatch code in %s", rec.Body.String())
+	}
+	if calls != 1 {
+		t.Errorf("expected handler to run once, ran %d times", calls)
+	}
+}
+
+func TestHandlerInProgress(t *testing.T) {
+	t.Parallel()
+
+	store := newMemoryStore()
+	m := NewMiddleware(store)
+	req := httptest.NewRequest(http.MethodPost, "/carts/1/items", strings.NewReader(`{}`))
+	_, _, _ = store.Acquire(context.Background(), scopeFor("cart.add_item", req), "key-1", fingerprint(req, []byte(`{}`)))
+
+	calls := 0
+	rec := doRequest(m.Handler("cart.add_item")(countingHandler(&calls, http.StatusCreated)), "key-1", `{}`)
+
+	if rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "idempotency_request_in_progress") {
+		t.Fatalf("expected in-progress conflict, got %d %s", rec.Code, rec.Body.String())
+	}
+	if calls != 0 {
+		t.Errorf("handler must not run while key is in progress")
+	}
+}
+
+func TestHandlerReleasesOnServerError(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	h := NewMiddleware(newMemoryStore()).Handler("cart.checkout")(countingHandler(&calls, http.StatusInternalServerError))
+
+	doRequest(h, "key-1", `{}`)
+	doRequest(h, "key-1", `{}`)
+
+	if calls != 2 {
+		t.Errorf("expected 5xx responses to allow retry, handler ran %d times", calls)
+	}
+}
+
+func TestHandlerStoresAfterClientCancels(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	ctx, cancel := context.WithCancel(context.Background())
+	h := NewMiddleware(newMemoryStore()).Handler("cart.checkout")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		cancel()
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/carts/1/items", strings.NewReader(`{}`)).WithContext(ctx)
+	req.Header.Set(HeaderKey, "key-1")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	second := doRequest(h, "key-1", `{}`)
+	if calls != 1 || second.Header().Get(HeaderReplayed) != "true" {
+		t.Errorf("expected the response of the cancelled request replayed, handler ran %d times", calls)
+	}
+}
+
+func TestHandlerReleasesOnPanic(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	h := NewMiddleware(newMemoryStore()).Handler("cart.checkout")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			panic("boom")
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	func() {
+		defer func() { _ = recover() }()
+		doRequest(h, "key-1", `{}`)
+	}()
+	if rec := doRequest(h, "key-1", `{}`); rec.Code != http.StatusCreated || calls != 2 {
+		t.Errorf("expected a retry after a panic to run, got %d after %d calls", rec.Code, calls)
+	}
+}
+
+func TestHandlerWithoutKeyPassesThrough(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	h := NewMiddleware(newMemoryStore()).Handler("cart.create")(countingHandler(&calls, http.StatusCreated))
+
+	doRequest(h, "", `{}`)
+	doRequest(h, "", `{}`)
+
+	if calls != 2 {
+		t.Errorf("expected requests without a key to always run, ran %d times", calls)
+	}
+}
+
+func TestHandlerRejectsLongKey(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	h := NewMiddleware(newMemoryStore()).Handler("cart.create")(countingHandler(&calls, http.StatusCreated))
+
+	rec := doRequest(h, strings.Repeat("k", maxKeyLength+1), `{}`)
+	if rec.Code != http.StatusBadRequest || calls != 0 {
+		t.Errorf("expected 400 without calling handler, got %d (calls=%d)", rec.Code, calls)
+	}
+}
@@ -0,0 +1,178 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"go-shopping-poc/internal/platform/database"
+)
+
+const (
+	// DefaultRetention is how long completed responses are replayed.
+	DefaultRetention = 24 * time.Hour
+	// DefaultLockTimeout is how long an in-progress key blocks repeats
+	// before it is considered abandoned.
+	DefaultLockTimeout = time.Minute
+	// DefaultCleanupInterval is how often RunCleanup deletes expired
+	// records.
+	DefaultCleanupInterval = time.Hour
+)
+
+// PostgresStore stores records in idempotency.idempotency_keys.
+type PostgresStore struct {
+	db          database.Database
+	retention   time.Duration
+	lockTimeout time.Duration
+	now         func() time.Time
+}
+
+// NewPostgresStore creates a store with DefaultRetention and DefaultLockTimeout.
+func NewPostgresStore(db database.Database) *PostgresStore {
+	return &PostgresStore{
+		db:          db,
+		retention:   DefaultRetention,
+		lockTimeout: DefaultLockTimeout,
+		now:         time.Now,
+	}
+}
+
+// Acquire implements Store. Expired completed records and abandoned
+// in-progress records are taken over in the same statement.
+func (s *PostgresStore) Acquire(ctx context.Context, scope, key, fingerprint string) (bool, *Record, error) {
+	now := s.now()
+
+	query := `
+		INSERT INTO idempotency.idempotency_keys (scope, idempotency_key, fingerprint, status, created_at)
+		VALUES ($1, $2, $3, 'in_progress', $4)
+		ON CONFLICT (scope, idempotency_key) DO UPDATE SET
+			fingerprint = EXCLUDED.fingerprint,
+			status = 'in_progress',
+			response_status = NULL,
+			response_headers = NULL,
+			response_body = NULL,
+			created_at = EXCLUDED.created_at,
+			completed_at = NULL
+		WHERE (idempotency.idempotency_keys.status = 'completed' AND idempotency.idempotency_keys.created_at < $5)
+		   OR (idempotency.idempotency_keys.status = 'in_progress' AND idempotency.idempotency_keys.created_at < $6)
+		RETURNING scope`
+
+	var claimed string
+	err := s.db.QueryRow(ctx, query, scope, key, fingerprint, now, now.Add(-s.retention), now.Add(-s.lockTimeout)).Scan(&claimed)
+	if err == nil {
+		return true, nil, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return false, nil, fmt.Errorf("failed to acquire idempotency key: %w", err)
+	}
+
+	record, err := s.get(ctx, scope, key)
+	if err != nil {
+		return false, nil, err
+	}
+	return false, record, nil
+}
+
+func (s *PostgresStore) get(ctx context.Context, scope, key string) (*Record, error) {
+	query := `
+		SELECT fingerprint, status, response_status, response_headers, response_body
+		FROM idempotency.idempotency_keys
+		WHERE scope = $1 AND idempotency_key = $2`
+
+	var (
+		fingerprint string
+		status      string
+		respStatus  sql.NullInt64
+		respHeaders []byte
+		respBody    []byte
+	)
+	err := s.db.QueryRow(ctx, query, scope, key).Scan(&fingerprint, &status, &respStatus, &respHeaders, &respBody)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRecordNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load idempotency key: %w", err)
+	}
+
+	record := &Record{Fingerprint: fingerprint, Completed: status == "completed"}
+	if record.Completed {
+		header := http.Header{}
+		if len(respHeaders) > 0 {
+			if err := json.Unmarshal(respHeaders, &header); err != nil {
+				return nil, fmt.Errorf("failed to decode stored headers: %w", err)
+			}
+		}
+		record.Response = &Response{Status: int(respStatus.Int64), Header: header, Body: respBody}
+	}
+	return record, nil
+}
+
+// Complete implements Store.
+func (s *PostgresStore) Complete(ctx context.Context, scope, key string, resp Response) error {
+	headers, err := json.Marshal(resp.Header)
+	if err != nil {
+		return fmt.Errorf("failed to encode response headers: %w", err)
+	}
+
+	query := `
+		UPDATE idempotency.idempotency_keys
+		SET status = 'completed', response_status = $3, response_headers = $4, response_body = $5, completed_at = $6
+		WHERE scope = $1 AND idempotency_key = $2`
+
+	if _, err := s.db.Exec(ctx, query, scope, key, resp.Status, headers, resp.Body, s.now()); err != nil {
+		return fmt.Errorf("failed to store idempotent response: %w", err)
+	}
+	return nil
+}
+
+// Release implements Store.
+func (s *PostgresStore) Release(ctx context.Context, scope, key string) error {
+	query := `DELETE FROM idempotency.idempotency_keys WHERE scope = $1 AND idempotency_key = $2 AND status = 'in_progress'`
+	if _, err := s.db.Exec(ctx, query, scope, key); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired removes records older than the retention period. In-progress
+// records that old were abandoned.
+func (s *PostgresStore) DeleteExpired(ctx context.Context) (int64, error) {
+	query := `DELETE FROM idempotency.idempotency_keys WHERE created_at < $1`
+	result, err := s.db.Exec(ctx, query, s.now().Add(-s.retention))
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// RunCleanup runs DeleteExpired every interval until ctx is cancelled.
+func (s *PostgresStore) RunCleanup(ctx context.Context, interval time.Duration, logger *slog.Logger) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logger = logger.With("component", "idempotency")
+	logger.Info("Idempotency key cleanup started", "interval", interval.String(), "retention", s.retention.String())
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("Idempotency key cleanup stopped")
+			return
+		case <-ticker.C:
+			deleted, err := s.DeleteExpired(ctx)
+			if err != nil {
+				logger.Error("Idempotency key cleanup failed", "error", err.Error())
+			}
+			if deleted > 0 {
+				logger.Info("Idempotency key cleanup completed", "deleted", deleted)
+			}
+		}
+	}
+}
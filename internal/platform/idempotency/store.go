@@ -0,0 +1,36 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// ErrRecordNotFound is returned when a key has no stored record.
+var ErrRecordNotFound = errors.New("idempotency record not found")
+
+// Response is a stored HTTP response.
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Record is the stored state of an idempotency key.
+type Record struct {
+	Fingerprint string
+	Completed   bool
+	Response    *Response
+}
+
+// Store persists idempotency records. Implementations must make Acquire
+// atomic so only one request per key proceeds.
+type Store interface {
+	// Acquire claims scope/key for a new request. When the key is already
+	// held it returns acquired=false and the existing record.
+	Acquire(ctx context.Context, scope, key, fingerprint string) (acquired bool, existing *Record, err error)
+	// Complete stores the response for a claimed key.
+	Complete(ctx context.Context, scope, key string, resp Response) error
+	// Release drops a claimed key so the request can be retried.
+	Release(ctx context.Context, scope, key string) error
+}
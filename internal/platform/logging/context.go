@@ -9,6 +9,7 @@ type contextKey int
 
 const (
 	loggerKey contextKey = iota
+	requestIDKey
 )
 
 func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
@@ -24,9 +25,18 @@ func FromContext(ctx context.Context) *slog.Logger {
 
 func WithRequestID(ctx context.Context, requestID string) context.Context {
 	logger := FromContext(ctx)
+	ctx = context.WithValue(ctx, requestIDKey, requestID)
 	return WithLogger(ctx, logger.With("request_id", requestID))
 }
 
+// RequestIDFromContext returns the request ID stored by WithRequestID, or "".
+func RequestIDFromContext(ctx context.Context) string {
+	if requestID, ok := ctx.Value(requestIDKey).(string); ok {
+		return requestID
+	}
+	return ""
+}
+
 func WithTraceID(ctx context.Context, traceID string) context.Context {
 	logger := FromContext(ctx)
 	return WithLogger(ctx, logger.With("trace_id", traceID))
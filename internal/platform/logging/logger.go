@@ -11,6 +11,12 @@ type LoggerConfig struct {
 	ServiceName string
 	Level       string
 	Format      string
+
+	// DisableRedaction turns off masking of sensitive attribute values.
+	// Redaction is on by default.
+	DisableRedaction bool
+	// RedactKeys overrides DefaultRedactKeys when non-empty.
+	RedactKeys []string
 }
 
 func DefaultLoggerConfig(serviceName string) LoggerConfig {
@@ -18,6 +24,8 @@ func DefaultLoggerConfig(serviceName string) LoggerConfig {
 		ServiceName: serviceName,
 		Level:       getEnv("LOG_LEVEL", "info"),
 		Format:      getEnv("LOG_FORMAT", "json"),
+
+		DisableRedaction: strings.EqualFold(getEnv("LOG_REDACT", "true"), "false"),
 	}
 }
 
@@ -58,6 +66,10 @@ func NewLoggerProvider(config LoggerConfig) (*LoggerProvider, error) {
 		handler = slog.NewJSONHandler(os.Stdout, handlerOptions)
 	}
 
+	if !config.DisableRedaction {
+		handler = NewRedactingHandler(handler, config.RedactKeys...)
+	}
+
 	logger := slog.New(handler).With("service", config.ServiceName)
 
 	return &LoggerProvider{logger: logger}, nil
@@ -0,0 +1,205 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// RedactedValue replaces any value that is masked by RedactingHandler.
+const RedactedValue = "[REDACTED]"
+
+// DefaultRedactKeys lists attribute keys whose values are always masked.
+// Keys are matched case-insensitively.
+var DefaultRedactKeys = []string{
+	"authorization",
+	"auth_header",
+	"access_token",
+	"refresh_token",
+	"id_token",
+	"token",
+	"password",
+	"secret",
+	"client_secret",
+	"api_key",
+	"email",
+	"phone",
+	"card_number",
+	"card_cvv",
+	"cvv",
+	"card_holder_name",
+	"contact",
+	"credit_card",
+}
+
+var (
+	jwtPattern   = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	cardPattern  = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+)
+
+// RedactingHandler is a slog.Handler wrapper that masks sensitive data before
+// it reaches the underlying handler.
+//
+// Values are masked when their attribute key matches a configured key, or when
+// a string value contains a JWT, an email address or a Luhn-valid card number.
+// String values that embed JSON have configured keys masked as well, which
+// covers raw event payloads logged by the event bus.
+type RedactingHandler struct {
+	next    slog.Handler
+	keys    map[string]struct{}
+	jsonKey *regexp.Regexp
+}
+
+// NewRedactingHandler wraps next with redaction of the given keys.
+// When no keys are supplied DefaultRedactKeys is used.
+func NewRedactingHandler(next slog.Handler, keys ...string) *RedactingHandler {
+	if len(keys) == 0 {
+		keys = DefaultRedactKeys
+	}
+
+	keySet := make(map[string]struct{}, len(keys))
+	quoted := make([]string, 0, len(keys))
+	for _, k := range keys {
+		k = strings.ToLower(strings.TrimSpace(k))
+		if k == "" {
+			continue
+		}
+		if _, exists := keySet[k]; exists {
+			continue
+		}
+		keySet[k] = struct{}{}
+		quoted = append(quoted, regexp.QuoteMeta(k))
+	}
+
+	var jsonKey *regexp.Regexp
+	if len(quoted) > 0 {
+		jsonKey = regexp.MustCompile(`(?i)("(?:` + strings.Join(quoted, "|") + `)"\s*:\s*)("(?:[^"\\]|\\.)*"|-?\d+(?:\.\d+)?)`)
+	}
+
+	return &RedactingHandler{next: next, keys: keySet, jsonKey: jsonKey}
+}
+
+// Enabled reports whether the underlying handler handles records at the given level.
+func (h *RedactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle redacts the record message and attributes and forwards the record.
+func (h *RedactingHandler) Handle(ctx context.Context, r slog.Record) error {
+	redacted := slog.NewRecord(r.Time, r.Level, h.redactString(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		redacted.AddAttrs(h.redactAttr(a))
+		return true
+	})
+	return h.next.Handle(ctx, redacted)
+}
+
+// WithAttrs redacts attrs before attaching them to the underlying handler.
+func (h *RedactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = h.redactAttr(a)
+	}
+	return &RedactingHandler{next: h.next.WithAttrs(redacted), keys: h.keys, jsonKey: h.jsonKey}
+}
+
+// WithGroup returns a handler that nests subsequent attributes under name.
+func (h *RedactingHandler) WithGroup(name string) slog.Handler {
+	return &RedactingHandler{next: h.next.WithGroup(name), keys: h.keys, jsonKey: h.jsonKey}
+}
+
+func (h *RedactingHandler) redactAttr(a slog.Attr) slog.Attr {
+	if _, sensitive := h.keys[strings.ToLower(a.Key)]; sensitive {
+		return slog.String(a.Key, RedactedValue)
+	}
+
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, h.redactString(v.String()))
+	case slog.KindGroup:
+		group := v.Group()
+		redacted := make([]any, len(group))
+		for i, ga := range group {
+			redacted[i] = h.redactAttr(ga)
+		}
+		return slog.Group(a.Key, redacted...)
+	case slog.KindAny:
+		return slog.Attr{Key: a.Key, Value: h.redactAny(v)}
+	default:
+		return slog.Attr{Key: a.Key, Value: v}
+	}
+}
+
+// redactAny masks values of arbitrary type by inspecting their printed form.
+// The original value is kept when nothing sensitive is found so handlers can
+// still render it natively.
+func (h *RedactingHandler) redactAny(v slog.Value) slog.Value {
+	var text string
+	switch val := v.Any().(type) {
+	case nil:
+		return v
+	case error:
+		text = val.Error()
+	case []byte:
+		text = string(val)
+	case fmt.Stringer:
+		text = val.String()
+	default:
+		text = fmt.Sprintf("%+v", val)
+	}
+
+	if redacted := h.redactString(text); redacted != text {
+		return slog.StringValue(redacted)
+	}
+	if _, isBytes := v.Any().([]byte); isBytes {
+		return slog.StringValue(text)
+	}
+	return v
+}
+
+func (h *RedactingHandler) redactString(s string) string {
+	if s == "" {
+		return s
+	}
+	if h.jsonKey != nil && strings.Contains(s, `"`) {
+		s = h.jsonKey.ReplaceAllString(s, `${1}"`+RedactedValue+`"`)
+	}
+	s = jwtPattern.ReplaceAllString(s, RedactedValue)
+	s = emailPattern.ReplaceAllString(s, RedactedValue)
+	s = cardPattern.ReplaceAllStringFunc(s, func(m string) string {
+		if luhnValid(m) {
+			return RedactedValue
+		}
+		return m
+	})
+	return s
+}
+
+// luhnValid reports whether the digits in s pass the Luhn checksum.
+// Separators such as spaces and dashes are ignored.
+func luhnValid(s string) bool {
+	sum := 0
+	digits := 0
+	double := false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		digits++
+		double = !double
+	}
+	return digits >= 13 && sum%10 == 0
+}
@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"bufio"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"go-shopping-poc/internal/platform/logging"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// AccessLog emits one structured log line per request with the matched route
+// pattern, status, response size and latency. Server errors are logged at
+// error level, client errors at warn and everything else at info.
+func AccessLog(logger *slog.Logger) func(http.Handler) http.Handler {
+	logger = logger.With("component", "access_log")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rw := newResponseWriter(w)
+
+			next.ServeHTTP(rw, r)
+
+			level := slog.LevelInfo
+			switch {
+			case rw.Status() >= http.StatusInternalServerError:
+				level = slog.LevelError
+			case rw.Status() >= http.StatusBadRequest:
+				level = slog.LevelWarn
+			}
+
+			logger.LogAttrs(r.Context(), level, "HTTP request",
+				slog.String("method", r.Method),
+				slog.String("route", routePattern(r)),
+				slog.Int("status", rw.Status()),
+				slog.Int64("bytes", rw.BytesWritten()),
+				slog.Int64("duration_ms", time.Since(start).Milliseconds()),
+				slog.String("request_id", logging.RequestIDFromContext(r.Context())),
+			)
+		})
+	}
+}
+
+// routePattern returns the chi route pattern, falling back to the raw path
+// for requests that were not routed by chi.
+func routePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		if pattern := rctx.RoutePattern(); pattern != "" {
+			return pattern
+		}
+	}
+	return r.URL.Path
+}
+
+// responseWriter records the status code and bytes written. It forwards
+// Flush and Hijack so SSE and WebSocket handlers keep working.
+type responseWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+func newResponseWriter(w http.ResponseWriter) *responseWriter {
+	return &responseWriter{ResponseWriter: w}
+}
+
+func (rw *responseWriter) WriteHeader(status int) {
+	if !rw.wroteHeader {
+		rw.status = status
+		rw.wroteHeader = true
+	}
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytes += int64(n)
+	return n, err
+}
+
+// Status returns the response status, 200 if the handler never set one.
+func (rw *responseWriter) Status() int {
+	if rw.status == 0 {
+		return http.StatusOK
+	}
+	return rw.status
+}
+
+// BytesWritten returns the number of body bytes written.
+func (rw *responseWriter) BytesWritten() int64 {
+	return rw.bytes
+}
+
+// Flush implements http.Flusher.
+func (rw *responseWriter) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		if !rw.wroteHeader {
+			rw.WriteHeader(http.StatusOK)
+		}
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker.
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := rw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	rw.status = http.StatusSwitchingProtocols
+	rw.wroteHeader = true
+	return h.Hijack()
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
@@ -0,0 +1,9 @@
+// Package middleware provides the standard HTTP middleware stack shared by all
+// service routers: request ID propagation, structured access logging and panic
+// recovery.
+//
+// Usage:
+//
+//	router := chi.NewRouter()
+//	router.Use(middleware.Stack(logger)...)
+package middleware
@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-shopping-poc/internal/platform/logging"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func newTestRouter(buf *bytes.Buffer) *chi.Mux {
+	logger := slog.New(slog.NewJSONHandler(buf, nil))
+	router := chi.NewRouter()
+	router.Use(Stack(logger)...)
+	return router
+}
+
+func TestRequestIDGeneratedAndPropagated(t *testing.T) {
+	var buf bytes.Buffer
+	router := newTestRouter(&buf)
+
+	var seen string
+	router.Get("/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		seen = logging.RequestIDFromContext(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/items/42", nil))
+
+	header := rr.Header().Get("X-Request-ID")
+	if header == "" || header != seen {
+		t.Fatalf("expected generated request id in header and context, got header=%q context=%q", header, seen)
+	}
+}
+
+func TestRequestIDAcceptsClientValue(t *testing.T) {
+	var buf bytes.Buffer
+	router := newTestRouter(&buf)
+	router.Get("/", func(w http.ResponseWriter, r *http.Request) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-ID", "client-abc")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if got := rr.Header().Get("X-Request-ID"); got != "client-abc" {
+		t.Fatalf("expected client request id to be kept, got %q", got)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-ID", "bad id\nwith newline")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if got := rr.Header().Get("X-Request-ID"); got == "" || strings.Contains(got, " ") {
+		t.Fatalf("expected invalid request id to be replaced, got %q", got)
+	}
+}
+
+func TestAccessLogLine(t *testing.T) {
+	var buf bytes.Buffer
+	router := newTestRouter(&buf)
+	router.Get("/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("hello"))
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items/42", nil))
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("expected a single JSON access log line, got %q: %v", buf.String(), err)
+	}
+	if entry["route"] != "/items/{id}" || entry["status"] != float64(http.StatusCreated) || entry["bytes"] != float64(5) {
+		t.Fatalf("unexpected access log entry: %v", entry)
+	}
+	if _, ok := entry["duration_ms"]; !ok {
+		t.Fatalf("expected duration_ms in access log entry: %v", entry)
+	}
+}
+
+func TestRecovererReturnsProblem(t *testing.T) {
+	var buf bytes.Buffer
+	router := newTestRouter(&buf)
+	router.Get("/boom", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/boom", nil))
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", rr.Code)
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Fatalf("expected problem response, got %q", ct)
+	}
+
+	var problem map[string]any
+	if err := json.Unmarshal(rr.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("failed to decode problem: %v", err)
+	}
+	if problem["request_id"] != rr.Header().Get("X-Request-ID") {
+		t.Fatalf("expected request id in problem, got %v", problem["request_id"])
+	}
+	if !strings.Contains(buf.String(), `"status":500`) {
+		t.Fatalf("expected access log to record 500, got %s", buf.String())
+	}
+}
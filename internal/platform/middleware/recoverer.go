@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"go-shopping-poc/internal/platform/httperr"
+	"go-shopping-poc/internal/platform/logging"
+)
+
+// Recoverer turns handler panics into a 500 problem response and logs the
+// panic with its stack. http.ErrAbortHandler is re-raised so the server can
+// abort the connection as intended.
+func Recoverer(logger *slog.Logger) func(http.Handler) http.Handler {
+	logger = logger.With("component", "recoverer")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+
+				logger.ErrorContext(r.Context(), "Panic recovered in HTTP handler",
+					"request_id", logging.RequestIDFromContext(r.Context()),
+					"panic", rec,
+					"method", r.Method,
+					"route", routePattern(r),
+					"stack_trace", string(debug.Stack()),
+				)
+
+				if rw, ok := w.(*responseWriter); ok && rw.wroteHeader {
+					return
+				}
+				httperr.Internal(w, "Internal server error")
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
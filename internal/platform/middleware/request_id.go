@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+
+	platformerrors "go-shopping-poc/internal/platform/errors"
+	"go-shopping-poc/internal/platform/logging"
+
+	"github.com/google/uuid"
+)
+
+// maxRequestIDLength bounds client-supplied request IDs.
+const maxRequestIDLength = 128
+
+// RequestID accepts a well-formed X-Request-ID header or generates a new ID.
+// The ID is echoed on the response and stored in the request context together
+// with a request-scoped logger, so logging.FromContext and
+// logging.RequestIDFromContext work in handlers.
+func RequestID(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID := r.Header.Get(platformerrors.RequestIDHeader)
+			if !validRequestID(requestID) {
+				requestID = uuid.NewString()
+			}
+
+			w.Header().Set(platformerrors.RequestIDHeader, requestID)
+
+			ctx := logging.WithLogger(r.Context(), logger)
+			ctx = logging.WithRequestID(ctx, requestID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// validRequestID rejects empty, oversized or non-printable IDs so clients
+// cannot inject arbitrary content into logs and headers.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,16 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+// Stack returns the standard middleware chain in mount order: request ID,
+// access log, then panic recovery so recovered panics are logged as 500s.
+func Stack(logger *slog.Logger) []func(http.Handler) http.Handler {
+	return []func(http.Handler) http.Handler{
+		RequestID(logger),
+		AccessLog(logger),
+		Recoverer(logger),
+	}
+}
@@ -0,0 +1,47 @@
+package money
+
+import "strings"
+
+// DefaultExponent is the number of decimal places used for currencies that
+// are unknown or unspecified.
+const DefaultExponent = 2
+
+// exponents lists currencies whose minor unit is not a hundredth.
+var exponents = map[string]int{
+	"BHD": 3,
+	"CLP": 0,
+	"ISK": 0,
+	"JOD": 3,
+	"JPY": 0,
+	"KRW": 0,
+	"KWD": 3,
+	"OMR": 3,
+	"TND": 3,
+	"VND": 0,
+}
+
+// Exponent returns the number of decimal places of currency.
+func Exponent(currency string) int {
+	if exp, ok := exponents[NormalizeCurrency(currency)]; ok {
+		return exp
+	}
+	return DefaultExponent
+}
+
+// NormalizeCurrency trims and upper-cases an ISO 4217 code.
+func NormalizeCurrency(currency string) string {
+	return strings.ToUpper(strings.TrimSpace(currency))
+}
+
+// ValidCurrency reports whether currency looks like an ISO 4217 code.
+func ValidCurrency(currency string) bool {
+	if len(currency) != 3 {
+		return false
+	}
+	for _, r := range currency {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
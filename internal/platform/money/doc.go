@@ -0,0 +1,20 @@
+// Package money represents monetary amounts as integer minor units of an
+// ISO 4217 currency.
+//
+// A Money value never holds a fraction of a minor unit, so sums are exact.
+// Conversions that can produce fractions (parsing, FromMajor, MulRate, In)
+// use banker's rounding (round half to even). Allocate splits an amount by
+// ratios without losing or creating a cent.
+//
+// A Money with no currency is "unspecified": it is read as having two
+// decimal places and adopts the currency of the first specified value it is
+// combined with. Values scanned from numeric columns start out unspecified
+// because the currency lives in a sibling column. Adding, subtracting or
+// comparing amounts in two different currencies fails with
+// ErrCurrencyMismatch.
+//
+// For compatibility with clients and events written when amounts were
+// float64, Money encodes to JSON as a plain decimal number (12.5 encodes as
+// 12.50) and decodes from a number, a decimal string, or an object with
+// "amount" and "currency".
+package money
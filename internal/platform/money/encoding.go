@@ -0,0 +1,86 @@
+package money
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// MarshalJSON encodes m as a decimal number in major units, the same shape
+// float64 amounts had.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON decodes a number (12.34), a decimal string ("12.34") or an
+// object ({"amount": "12.34", "currency": "USD"}). The currency already set
+// on m is kept unless the object carries one.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		*m = Money{currency: m.currency}
+		return nil
+	}
+
+	switch data[0] {
+	case '"':
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		return m.parseInto(s, m.currency)
+	case '{':
+		var obj struct {
+			Amount   json.RawMessage `json:"amount"`
+			Currency string          `json:"currency"`
+		}
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return err
+		}
+		currency := m.currency
+		if obj.Currency != "" {
+			currency = obj.Currency
+		}
+		amount := string(bytes.Trim(bytes.TrimSpace(obj.Amount), `"`))
+		return m.parseInto(amount, currency)
+	default:
+		return m.parseInto(string(data), m.currency)
+	}
+}
+
+func (m *Money) parseInto(s, currency string) error {
+	parsed, err := Parse(s, currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Value implements driver.Valuer, writing the decimal string for numeric
+// columns.
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// Scan implements sql.Scanner for numeric columns. NULL scans as zero.
+func (m *Money) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*m = Money{currency: m.currency}
+		return nil
+	case string:
+		return m.parseInto(v, m.currency)
+	case []byte:
+		return m.parseInto(string(v), m.currency)
+	case float64:
+		*m = FromMajor(v, m.currency)
+		return nil
+	case int64:
+		return m.parseInto(strconv.FormatInt(v, 10), m.currency)
+	default:
+		return fmt.Errorf("cannot scan %T into money", src)
+	}
+}
@@ -0,0 +1,409 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidAmount is returned when an amount cannot be parsed.
+	ErrInvalidAmount = errors.New("invalid monetary amount")
+	// ErrCurrencyMismatch is returned when amounts in two different
+	// currencies are combined or compared.
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+)
+
+// Money is an amount in integer minor units of a currency. The zero value
+// is zero in an unspecified currency.
+type Money struct {
+	minor    int64
+	currency string
+}
+
+// New returns minor units of currency.
+func New(minor int64, currency string) Money {
+	return Money{minor: minor, currency: NormalizeCurrency(currency)}
+}
+
+// Zero returns zero in currency.
+func Zero(currency string) Money {
+	return New(0, currency)
+}
+
+// FromMajor converts a major-unit amount such as 12.34 to Money, using
+// banker's rounding beyond the currency's precision. It exists for
+// boundaries that still carry float64.
+func FromMajor(major float64, currency string) Money {
+	if math.IsNaN(major) || math.IsInf(major, 0) {
+		return Zero(currency)
+	}
+	m, err := Parse(strconv.FormatFloat(major, 'f', -1, 64), currency)
+	if err != nil {
+		return Zero(currency)
+	}
+	return m
+}
+
+// Parse reads a decimal string such as "12.34" or "-0.5" as currency,
+// using banker's rounding beyond the currency's precision.
+func Parse(s, currency string) (Money, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.Contains(s, "/") {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	currency = NormalizeCurrency(currency)
+	minor, err := roundRat(r.Mul(r, pow10Rat(Exponent(currency))))
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q: %w", ErrInvalidAmount, s, err)
+	}
+	return Money{minor: minor, currency: currency}, nil
+}
+
+// MustParse is like Parse but panics on error. It is intended for tests and
+// constants.
+func MustParse(s, currency string) Money {
+	m, err := Parse(s, currency)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// Minor returns the amount in minor units.
+func (m Money) Minor() int64 { return m.minor }
+
+// Currency returns the ISO currency code, or "" when unspecified.
+func (m Money) Currency() string { return m.currency }
+
+// Exponent returns the number of decimal places of the amount.
+func (m Money) Exponent() int { return Exponent(m.currency) }
+
+// IsZero reports whether the amount is zero.
+func (m Money) IsZero() bool { return m.minor == 0 }
+
+// IsNegative reports whether the amount is below zero.
+func (m Money) IsNegative() bool { return m.minor < 0 }
+
+// IsPositive reports whether the amount is above zero.
+func (m Money) IsPositive() bool { return m.minor > 0 }
+
+// Float64 returns the amount in major units. Use it only at boundaries that
+// still require float64; arithmetic should stay in Money.
+func (m Money) Float64() float64 {
+	return float64(m.minor) / math.Pow10(m.Exponent())
+}
+
+// String formats the amount in major units with the currency's precision,
+// without the currency code, e.g. "12.30".
+func (m Money) String() string {
+	exp := m.Exponent()
+	if exp == 0 {
+		return strconv.FormatInt(m.minor, 10)
+	}
+	sign := ""
+	minor := m.minor
+	if minor < 0 {
+		sign = "-"
+		minor = -minor
+	}
+	scale := int64(math.Pow10(exp))
+	return fmt.Sprintf("%s%d.%0*d", sign, minor/scale, exp, minor%scale)
+}
+
+// Format returns the amount followed by its currency, e.g. "12.30 USD".
+func (m Money) Format() string {
+	if m.currency == "" {
+		return m.String()
+	}
+	return m.String() + " " + m.currency
+}
+
+// WithCurrency returns m with currency set, keeping the minor units. It is
+// for values whose currency is known from context, such as a cart column.
+func (m Money) WithCurrency(currency string) Money {
+	return m.In(currency)
+}
+
+// In re-expresses m in currency without converting its value: 12.30 of an
+// unspecified currency becomes 12 JPY (rounded half to even) or 12.300 KWD.
+// Use an exchange rate to convert between currencies.
+func (m Money) In(currency string) Money {
+	currency = NormalizeCurrency(currency)
+	from, to := m.Exponent(), Exponent(currency)
+	minor := m.minor
+	switch {
+	case to > from:
+		minor *= int64(math.Pow10(to - from))
+	case to < from:
+		minor = roundHalfEven(minor, int64(math.Pow10(from-to)))
+	}
+	return Money{minor: minor, currency: currency}
+}
+
+// align brings a and b to a common currency. An unspecified currency adopts
+// the other's; two different specified currencies are ErrCurrencyMismatch.
+func align(a, b Money) (Money, Money, error) {
+	switch {
+	case a.currency == b.currency:
+		return a, b, nil
+	case a.currency == "":
+		return a.In(b.currency), b, nil
+	case b.currency == "":
+		return a, b.In(a.currency), nil
+	default:
+		return a, b, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, a.currency, b.currency)
+	}
+}
+
+// SameCurrency reports whether a and b can be combined without conversion.
+func SameCurrency(a, b Money) bool {
+	return a.currency == b.currency || a.currency == "" || b.currency == ""
+}
+
+// Add returns m + o.
+func (m Money) Add(o Money) (Money, error) {
+	m, o, err := align(m, o)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{minor: m.minor + o.minor, currency: m.currency}, nil
+}
+
+// Sub returns m - o.
+func (m Money) Sub(o Money) (Money, error) {
+	m, o, err := align(m, o)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{minor: m.minor - o.minor, currency: m.currency}, nil
+}
+
+// Neg returns -m.
+func (m Money) Neg() Money {
+	return Money{minor: -m.minor, currency: m.currency}
+}
+
+// Abs returns |m|.
+func (m Money) Abs() Money {
+	if m.minor < 0 {
+		return m.Neg()
+	}
+	return m
+}
+
+// Mul returns m multiplied by a whole quantity.
+func (m Money) Mul(qty int64) Money {
+	return Money{minor: m.minor * qty, currency: m.currency}
+}
+
+// MulRate returns m multiplied by rate, such as a tax rate or exchange
+// rate, with banker's rounding to a minor unit.
+func (m Money) MulRate(rate float64) Money {
+	r, ok := new(big.Rat).SetString(strconv.FormatFloat(rate, 'f', -1, 64))
+	if !ok {
+		return Money{currency: m.currency}
+	}
+	r.Mul(r, new(big.Rat).SetInt64(m.minor))
+	minor, err := roundRat(r)
+	if err != nil {
+		return Money{currency: m.currency}
+	}
+	return Money{minor: minor, currency: m.currency}
+}
+
+// Exchange converts m to currency at rate (units of currency per unit of
+// m's currency), with banker's rounding to a minor unit of currency.
+func (m Money) Exchange(rate float64, currency string) Money {
+	currency = NormalizeCurrency(currency)
+	r, ok := new(big.Rat).SetString(strconv.FormatFloat(rate, 'f', -1, 64))
+	if !ok {
+		return Money{currency: currency}
+	}
+	r.Mul(r, new(big.Rat).SetInt64(m.minor))
+	if shift := Exponent(currency) - m.Exponent(); shift >= 0 {
+		r.Mul(r, pow10Rat(shift))
+	} else {
+		r.Quo(r, pow10Rat(-shift))
+	}
+	minor, err := roundRat(r)
+	if err != nil {
+		return Money{currency: currency}
+	}
+	return Money{minor: minor, currency: currency}
+}
+
+// Cmp compares m and o, returning -1, 0 or +1.
+func (m Money) Cmp(o Money) (int, error) {
+	m, o, err := align(m, o)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case m.minor < o.minor:
+		return -1, nil
+	case m.minor > o.minor:
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
+
+// Equal reports whether m and o are the same amount. Amounts in different
+// currencies are never equal.
+func (m Money) Equal(o Money) bool {
+	c, err := m.Cmp(o)
+	return err == nil && c == 0
+}
+
+// LessThan reports whether m < o.
+func (m Money) LessThan(o Money) (bool, error) {
+	c, err := m.Cmp(o)
+	return c < 0, err
+}
+
+// GreaterThan reports whether m > o.
+func (m Money) GreaterThan(o Money) (bool, error) {
+	c, err := m.Cmp(o)
+	return c > 0, err
+}
+
+// Min returns the smaller of m and o.
+func Min(m, o Money) (Money, error) {
+	m, o, err := align(m, o)
+	if err != nil {
+		return Money{}, err
+	}
+	if o.minor < m.minor {
+		return o, nil
+	}
+	return m, nil
+}
+
+// Max returns the larger of m and o.
+func Max(m, o Money) (Money, error) {
+	m, o, err := align(m, o)
+	if err != nil {
+		return Money{}, err
+	}
+	if o.minor > m.minor {
+		return o, nil
+	}
+	return m, nil
+}
+
+// Sum adds amounts, returning zero in currency when there are none.
+func Sum(currency string, amounts ...Money) (Money, error) {
+	total := Zero(currency)
+	for _, a := range amounts {
+		var err error
+		if total, err = total.Add(a); err != nil {
+			return Money{}, err
+		}
+	}
+	return total, nil
+}
+
+// Allocate splits m in proportion to ratios. The parts always sum to m;
+// leftover minor units go one each to the parts with the largest
+// remainders, earliest first. A nil result means ratios sum to zero.
+func (m Money) Allocate(ratios ...int64) []Money {
+	var total int64
+	for _, r := range ratios {
+		if r < 0 {
+			return nil
+		}
+		total += r
+	}
+	if total == 0 {
+		return nil
+	}
+
+	parts := make([]Money, len(ratios))
+	remainders := make([]int64, len(ratios))
+	allocated := int64(0)
+	for i, r := range ratios {
+		share := new(big.Int).Mul(big.NewInt(m.minor), big.NewInt(r))
+		q, rem := new(big.Int).QuoRem(share, big.NewInt(total), new(big.Int))
+		parts[i] = Money{minor: q.Int64(), currency: m.currency}
+		remainders[i] = rem.Int64()
+		allocated += q.Int64()
+	}
+
+	left := m.minor - allocated
+	step := int64(1)
+	if left < 0 {
+		step = -1
+		left = -left
+	}
+	for ; left > 0; left-- {
+		best := -1
+		for i := range remainders {
+			if ratios[i] == 0 {
+				continue
+			}
+			if best < 0 || abs(remainders[i]) > abs(remainders[best]) {
+				best = i
+			}
+		}
+		parts[best].minor += step
+		remainders[best] = 0
+	}
+	return parts
+}
+
+// Split divides m into n parts that differ by at most one minor unit.
+func (m Money) Split(n int) []Money {
+	if n <= 0 {
+		return nil
+	}
+	ratios := make([]int64, n)
+	for i := range ratios {
+		ratios[i] = 1
+	}
+	return m.Allocate(ratios...)
+}
+
+func abs(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func pow10Rat(exp int) *big.Rat {
+	return new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exp)), nil))
+}
+
+// roundRat rounds r to an integer, half to even.
+func roundRat(r *big.Rat) (int64, error) {
+	num, den := r.Num(), r.Denom()
+	q, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+
+	twice := new(big.Int).Mul(new(big.Int).Abs(rem), big.NewInt(2))
+	switch twice.Cmp(den) {
+	case 1:
+		q.Add(q, big.NewInt(int64(num.Sign())))
+	case 0:
+		if q.Bit(0) == 1 {
+			q.Add(q, big.NewInt(int64(num.Sign())))
+		}
+	}
+	if !q.IsInt64() {
+		return 0, errors.New("amount out of range")
+	}
+	return q.Int64(), nil
+}
+
+// roundHalfEven divides v by d, rounding half to even.
+func roundHalfEven(v, d int64) int64 {
+	r, _ := roundRat(big.NewRat(v, d))
+	return r
+}
@@ -0,0 +1,219 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseRoundsHalfToEven(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in       string
+		currency string
+		want     int64
+	}{
+		{in: "12.34", currency: "USD", want: 1234},
+		{in: "0.125", currency: "USD", want: 12},
+		{in: "0.135", currency: "USD", want: 14},
+		{in: "-0.125", currency: "USD", want: -12},
+		{in: "2.5", currency: "JPY", want: 2},
+		{in: "3.5", currency: "JPY", want: 4},
+		{in: "1.2345", currency: "KWD", want: 1234},
+		{in: "7", currency: "", want: 700},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in+tt.currency, func(t *testing.T) {
+			t.Parallel()
+
+			m, err := Parse(tt.in, tt.currency)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if m.Minor() != tt.want {
+				t.Errorf("expected %d minor units, got %d", tt.want, m.Minor())
+			}
+		})
+	}
+}
+
+func TestParseRejectsInvalid(t *testing.T) {
+	t.Parallel()
+
+	for _, in := range []string{"", "abc", "1/3", "1.2.3"} {
+		if _, err := Parse(in, "USD"); !errors.Is(err, ErrInvalidAmount) {
+			t.Errorf("%q: expected ErrInvalidAmount, got %v", in, err)
+		}
+	}
+}
+
+func TestSumIsExact(t *testing.T) {
+	t.Parallel()
+
+	total := Zero("USD")
+	for range 10 {
+		var err error
+		if total, err = total.Add(FromMajor(0.1, "USD")); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if total.Minor() != 100 || total.String() != "1.00" {
+		t.Errorf("expected 1.00, got %s", total)
+	}
+}
+
+func TestUnspecifiedCurrencyAdopts(t *testing.T) {
+	t.Parallel()
+
+	sum, err := MustParse("1.50", "").Add(New(3, "JPY"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sum.Currency() != "JPY" || sum.Minor() != 5 {
+		t.Errorf("expected 5 JPY, got %s", sum.Format())
+	}
+}
+
+func TestMismatchedCurrency(t *testing.T) {
+	t.Parallel()
+
+	usd, eur := New(100, "USD"), New(100, "EUR")
+	if _, err := usd.Add(eur); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Add: expected ErrCurrencyMismatch, got %v", err)
+	}
+	if _, err := usd.Sub(eur); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Sub: expected ErrCurrencyMismatch, got %v", err)
+	}
+	if _, err := usd.GreaterThan(eur); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("GreaterThan: expected ErrCurrencyMismatch, got %v", err)
+	}
+	if _, err := Sum("USD", usd, eur); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Sum: expected ErrCurrencyMismatch, got %v", err)
+	}
+	if usd.Equal(eur) {
+		t.Error("Equal: amounts in different currencies should differ")
+	}
+}
+
+func TestMulRate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		amount string
+		rate   float64
+		want   string
+	}{
+		{amount: "19.99", rate: 0.0725, want: "1.45"},
+		{amount: "10.00", rate: 0.0825, want: "0.82"},
+		{amount: "10.00", rate: 0.0835, want: "0.84"},
+	}
+	for _, tt := range tests {
+		got := MustParse(tt.amount, "USD").MulRate(tt.rate)
+		if got.String() != tt.want {
+			t.Errorf("%s * %v: expected %s, got %s", tt.amount, tt.rate, tt.want, got)
+		}
+	}
+}
+
+func TestExchange(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		amount Money
+		rate   float64
+		to     string
+		want   string
+	}{
+		{amount: MustParse("10.00", "EUR"), rate: 1.0825, to: "USD", want: "10.82 USD"},
+		{amount: MustParse("19.99", "USD"), rate: 151.37, to: "JPY", want: "3026 JPY"},
+		{amount: MustParse("1500", "JPY"), rate: 0.0066, to: "USD", want: "9.90 USD"},
+		{amount: MustParse("12.50", "USD"), rate: 0.3075, to: "KWD", want: "3.844 KWD"},
+	}
+	for _, tt := range tests {
+		got := tt.amount.Exchange(tt.rate, tt.to)
+		if got.Format() != tt.want {
+			t.Errorf("%s at %v: expected %s, got %s", tt.amount.Format(), tt.rate, tt.want, got.Format())
+		}
+	}
+}
+
+func TestAllocateKeepsTotal(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		amount Money
+		ratios []int64
+		want   []int64
+	}{
+		{name: "even split", amount: New(100, "USD"), ratios: []int64{1, 1, 1}, want: []int64{34, 33, 33}},
+		{name: "weighted", amount: New(1000, "USD"), ratios: []int64{70, 20, 10}, want: []int64{700, 200, 100}},
+		{name: "remainder to largest fraction", amount: New(7, "USD"), ratios: []int64{3, 7}, want: []int64{2, 5}},
+		{name: "negative", amount: New(-100, "USD"), ratios: []int64{1, 1, 1}, want: []int64{-34, -33, -33}},
+		{name: "zero ratio", amount: New(10, "USD"), ratios: []int64{0, 1}, want: []int64{0, 10}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			parts := tt.amount.Allocate(tt.ratios...)
+			for i, p := range parts {
+				if p.Minor() != tt.want[i] {
+					t.Errorf("part %d: expected %d, got %d", i, tt.want[i], p.Minor())
+				}
+			}
+			total, err := Sum("USD", parts...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !total.Equal(tt.amount) {
+				t.Errorf("parts sum to %s, expected %s", total, tt.amount)
+			}
+		})
+	}
+}
+
+func TestJSONCompatibility(t *testing.T) {
+	t.Parallel()
+
+	type line struct {
+		UnitPrice Money `json:"unit_price"`
+	}
+
+	data, err := json.Marshal(line{UnitPrice: MustParse("12.5", "USD")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != `{"unit_price":12.50}` {
+		t.Errorf("unexpected encoding %s", data)
+	}
+
+	for _, in := range []string{`{"unit_price":12.5}`, `{"unit_price":"12.50"}`, `{"unit_price":{"amount":"12.50","currency":"usd"}}`} {
+		var got line
+		if err := json.Unmarshal([]byte(in), &got); err != nil {
+			t.Fatalf("%s: unexpected error: %v", in, err)
+		}
+		if got.UnitPrice.Minor() != 1250 {
+			t.Errorf("%s: expected 1250 minor units, got %d", in, got.UnitPrice.Minor())
+		}
+	}
+}
+
+func TestScanNumeric(t *testing.T) {
+	t.Parallel()
+
+	var m Money
+	if err := m.Scan([]byte("19.9900")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.Minor() != 1999 {
+		t.Errorf("expected 1999, got %d", m.Minor())
+	}
+	v, err := m.Value()
+	if err != nil || v != "19.99" {
+		t.Errorf("expected 19.99, got %v (%v)", v, err)
+	}
+}
@@ -0,0 +1,9 @@
+// Package moneytest provides helpers for tests that build money amounts.
+package moneytest
+
+import "go-shopping-poc/internal/platform/money"
+
+// USD parses amount as US dollars. It panics on a malformed amount.
+func USD(amount string) money.Money {
+	return money.MustParse(amount, "USD")
+}
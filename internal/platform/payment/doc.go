@@ -0,0 +1,15 @@
+// Package payment charges cards through payment gateways.
+//
+// A Gateway authorizes an amount on a card, then captures, voids or
+// refunds that authorization. Every call carries an idempotency key: a
+// gateway answers a repeated key with the result of the first call instead
+// of charging again, so callers can retry a call whose outcome they missed.
+// A declined card is a Result, not an error; errors mean the gateway could
+// not be asked or refused the request itself. Gateways are looked up by
+// name in a Registry.
+//
+// FakeGateway is an in-memory stand-in that approves every card except a
+// few test numbers. HTTPGateway speaks a small JSON protocol to a gateway
+// over HTTP, and NewMockServer serves that protocol in front of any
+// Gateway, so the HTTP path can run locally against the fake.
+package payment
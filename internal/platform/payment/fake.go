@@ -0,0 +1,214 @@
+package payment
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"go-shopping-poc/internal/platform/money"
+)
+
+// FakeName is the name FakeGateway is registered under.
+const FakeName = "fake"
+
+// Card numbers FakeGateway declines, with the decline code it gives.
+const (
+	FakeCardDeclined          = "4000000000000002"
+	FakeCardInsufficientFunds = "4000000000009995"
+)
+
+var fakeDeclines = map[string]string{
+	FakeCardDeclined:          "card_declined",
+	FakeCardInsufficientFunds: "insufficient_funds",
+}
+
+// FakeGateway implements Gateway in memory. It approves every card but the
+// FakeCard numbers and keeps authorizations until the process exits.
+type FakeGateway struct {
+	mu             sync.Mutex
+	calls          map[string]fakeCall
+	authorizations map[string]*fakeAuthorization
+}
+
+// fakeCall is the result of a call, kept under its idempotency key.
+type fakeCall struct {
+	op     string
+	result Result
+}
+
+type fakeAuthorization struct {
+	amount   money.Money
+	captured money.Money
+	refunded money.Money
+	voided   bool
+}
+
+// NewFakeGateway creates a fake gateway.
+func NewFakeGateway() *FakeGateway {
+	return &FakeGateway{
+		calls:          make(map[string]fakeCall),
+		authorizations: make(map[string]*fakeAuthorization),
+	}
+}
+
+// Name implements Gateway.
+func (g *FakeGateway) Name() string {
+	return FakeName
+}
+
+// Authorize implements Gateway.
+func (g *FakeGateway) Authorize(_ context.Context, req AuthorizeRequest) (*Result, error) {
+	return g.call("authorize", req.IdempotencyKey, func() (Result, error) {
+		amount := req.Amount.WithCurrency(req.Currency)
+		if !amount.IsPositive() {
+			return Result{}, fmt.Errorf("%w: amount must be positive", ErrInvalidRequest)
+		}
+		if req.Card.Number == "" {
+			return Result{}, fmt.Errorf("%w: card number required", ErrInvalidRequest)
+		}
+
+		result := Result{TransactionID: fakeID("fk_txn_"), Amount: amount}
+		if code, ok := fakeDeclines[req.Card.Number]; ok {
+			result.Status = StatusDeclined
+			result.DeclineCode = code
+			return result, nil
+		}
+		result.AuthorizationID = fakeID("fk_auth_")
+		result.Status = StatusApproved
+		g.authorizations[result.AuthorizationID] = &fakeAuthorization{
+			amount:   amount,
+			captured: money.Zero(amount.Currency()),
+			refunded: money.Zero(amount.Currency()),
+		}
+		return result, nil
+	})
+}
+
+// Capture implements Gateway.
+func (g *FakeGateway) Capture(_ context.Context, req CaptureRequest) (*Result, error) {
+	return g.call("capture", req.IdempotencyKey, func() (Result, error) {
+		auth, err := g.authorization(req.AuthorizationID)
+		if err != nil {
+			return Result{}, err
+		}
+		amount := req.Amount.WithCurrency(auth.amount.Currency())
+		exceeds, err := exceedsLeft(amount, auth.amount, auth.captured)
+		if err != nil {
+			return Result{}, err
+		}
+		if !amount.IsPositive() || exceeds {
+			return Result{}, fmt.Errorf("%w: capture of %s exceeds what is left of %s", ErrInvalidRequest, amount, auth.amount)
+		}
+		if auth.captured, err = auth.captured.Add(amount); err != nil {
+			return Result{}, err
+		}
+		return g.approved(req.AuthorizationID, amount), nil
+	})
+}
+
+// Void implements Gateway.
+func (g *FakeGateway) Void(_ context.Context, req VoidRequest) (*Result, error) {
+	return g.call("void", req.IdempotencyKey, func() (Result, error) {
+		auth, err := g.authorization(req.AuthorizationID)
+		if err != nil {
+			return Result{}, err
+		}
+		if !auth.captured.IsZero() {
+			return Result{}, fmt.Errorf("%w: authorization was captured", ErrInvalidRequest)
+		}
+		auth.voided = true
+		return g.approved(req.AuthorizationID, auth.amount), nil
+	})
+}
+
+// Refund implements Gateway.
+func (g *FakeGateway) Refund(_ context.Context, req RefundRequest) (*Result, error) {
+	return g.call("refund", req.IdempotencyKey, func() (Result, error) {
+		auth, err := g.authorization(req.AuthorizationID)
+		if err != nil {
+			return Result{}, err
+		}
+		amount := req.Amount.WithCurrency(auth.amount.Currency())
+		exceeds, err := exceedsLeft(amount, auth.captured, auth.refunded)
+		if err != nil {
+			return Result{}, err
+		}
+		if !amount.IsPositive() || exceeds {
+			return Result{}, fmt.Errorf("%w: refund of %s exceeds what is left of %s captured", ErrInvalidRequest, amount, auth.captured)
+		}
+		if auth.refunded, err = auth.refunded.Add(amount); err != nil {
+			return Result{}, err
+		}
+		return g.approved(req.AuthorizationID, amount), nil
+	})
+}
+
+// exceedsLeft reports whether amount is more than what is left of total
+// once used is taken off.
+func exceedsLeft(amount, total, used money.Money) (bool, error) {
+	left, err := total.Sub(used)
+	if err != nil {
+		return false, fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+	}
+	exceeds, err := amount.GreaterThan(left)
+	if err != nil {
+		return false, fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+	}
+	return exceeds, nil
+}
+
+// call runs op once per idempotency key and answers repeated keys with the
+// first result. Failed calls are not kept, so they can be retried.
+func (g *FakeGateway) call(op, key string, run func() (Result, error)) (*Result, error) {
+	if key == "" {
+		return nil, ErrIdempotencyKeyRequired
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if prev, ok := g.calls[key]; ok {
+		if prev.op != op {
+			return nil, fmt.Errorf("%w: idempotency key %s was used for a %s", ErrInvalidRequest, key, prev.op)
+		}
+		result := prev.result
+		return &result, nil
+	}
+
+	result, err := run()
+	if err != nil {
+		return nil, err
+	}
+	g.calls[key] = fakeCall{op: op, result: result}
+	return &result, nil
+}
+
+// authorization returns the live authorization id. The caller holds g.mu.
+func (g *FakeGateway) authorization(id string) (*fakeAuthorization, error) {
+	auth, ok := g.authorizations[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown authorization %s", ErrInvalidRequest, id)
+	}
+	if auth.voided {
+		return nil, fmt.Errorf("%w: authorization %s was voided", ErrInvalidRequest, id)
+	}
+	return auth, nil
+}
+
+func (g *FakeGateway) approved(authorizationID string, amount money.Money) Result {
+	return Result{
+		TransactionID:   fakeID("fk_txn_"),
+		AuthorizationID: authorizationID,
+		Status:          StatusApproved,
+		Amount:          amount,
+	}
+}
+
+// fakeID returns a random ID with prefix.
+func fakeID(prefix string) string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return prefix + hex.EncodeToString(b[:])
+}
@@ -0,0 +1,197 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go-shopping-poc/internal/platform/httperr"
+	"go-shopping-poc/internal/platform/httpx"
+	"go-shopping-poc/internal/platform/idempotency"
+)
+
+// HTTPName is the name HTTPGateway is registered under.
+const HTTPName = "http"
+
+// maxResponseBytes bounds the body of a gateway response.
+const maxResponseBytes = 1 << 20
+
+// HTTPGateway implements Gateway against a gateway speaking the protocol
+// NewMockServer serves:
+//
+//	POST {baseURL}/authorizations                 AuthorizeRequest
+//	POST {baseURL}/authorizations/{id}/capture    CaptureRequest
+//	POST {baseURL}/authorizations/{id}/void
+//	POST {baseURL}/authorizations/{id}/refunds    RefundRequest
+//
+// Each request carries its idempotency key in the Idempotency-Key header
+// and is answered with a Result. A 4xx response is ErrInvalidRequest; any
+// other failure is ErrGatewayUnavailable.
+type HTTPGateway struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewHTTPGateway creates a gateway for the payment service at baseURL. A
+// nil client uses one with a 10 second timeout.
+func NewHTTPGateway(baseURL string, client *http.Client) *HTTPGateway {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &HTTPGateway{baseURL: strings.TrimRight(baseURL, "/"), client: client}
+}
+
+// Name implements Gateway.
+func (g *HTTPGateway) Name() string {
+	return HTTPName
+}
+
+// Authorize implements Gateway.
+func (g *HTTPGateway) Authorize(ctx context.Context, req AuthorizeRequest) (*Result, error) {
+	return g.post(ctx, "/authorizations", req.IdempotencyKey, req)
+}
+
+// Capture implements Gateway.
+func (g *HTTPGateway) Capture(ctx context.Context, req CaptureRequest) (*Result, error) {
+	return g.post(ctx, authorizationPath(req.AuthorizationID, "capture"), req.IdempotencyKey, req)
+}
+
+// Void implements Gateway.
+func (g *HTTPGateway) Void(ctx context.Context, req VoidRequest) (*Result, error) {
+	return g.post(ctx, authorizationPath(req.AuthorizationID, "void"), req.IdempotencyKey, req)
+}
+
+// Refund implements Gateway.
+func (g *HTTPGateway) Refund(ctx context.Context, req RefundRequest) (*Result, error) {
+	return g.post(ctx, authorizationPath(req.AuthorizationID, "refunds"), req.IdempotencyKey, req)
+}
+
+func (g *HTTPGateway) post(ctx context.Context, path, key string, body any) (*Result, error) {
+	if key == "" {
+		return nil, ErrIdempotencyKeyRequired
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrGatewayUnavailable, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set(idempotency.HeaderKey, key)
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrGatewayUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrGatewayUnavailable, err)
+	}
+	switch {
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		return nil, fmt.Errorf("%w: %s", ErrInvalidRequest, problemDetail(data, resp.Status))
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("%w: gateway returned %s", ErrGatewayUnavailable, resp.Status)
+	}
+
+	var result Result
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrGatewayUnavailable, err)
+	}
+	return &result, nil
+}
+
+var _ Gateway = (*HTTPGateway)(nil)
+
+// NewMockServer serves the HTTPGateway protocol in front of gateway, so
+// cmd/paymentgw can stand in for a payment service locally.
+func NewMockServer(gateway Gateway) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /authorizations", func(w http.ResponseWriter, r *http.Request) {
+		var req AuthorizeRequest
+		if !decodeMockRequest(w, r, &req) {
+			return
+		}
+		req.IdempotencyKey = r.Header.Get(idempotency.HeaderKey)
+		writeMockResult(w)(gateway.Authorize(r.Context(), req))
+	})
+	mux.HandleFunc("POST /authorizations/{id}/capture", func(w http.ResponseWriter, r *http.Request) {
+		var req CaptureRequest
+		if !decodeMockRequest(w, r, &req) {
+			return
+		}
+		req.IdempotencyKey = r.Header.Get(idempotency.HeaderKey)
+		req.AuthorizationID = r.PathValue("id")
+		writeMockResult(w)(gateway.Capture(r.Context(), req))
+	})
+	mux.HandleFunc("POST /authorizations/{id}/void", func(w http.ResponseWriter, r *http.Request) {
+		var req VoidRequest
+		if !decodeMockRequest(w, r, &req) {
+			return
+		}
+		req.IdempotencyKey = r.Header.Get(idempotency.HeaderKey)
+		req.AuthorizationID = r.PathValue("id")
+		writeMockResult(w)(gateway.Void(r.Context(), req))
+	})
+	mux.HandleFunc("POST /authorizations/{id}/refunds", func(w http.ResponseWriter, r *http.Request) {
+		var req RefundRequest
+		if !decodeMockRequest(w, r, &req) {
+			return
+		}
+		req.IdempotencyKey = r.Header.Get(idempotency.HeaderKey)
+		req.AuthorizationID = r.PathValue("id")
+		writeMockResult(w)(gateway.Refund(r.Context(), req))
+	})
+	return mux
+}
+
+func decodeMockRequest(w http.ResponseWriter, r *http.Request, target any) bool {
+	if err := httpx.DecodeJSON(r, target); err != nil && !errors.Is(err, io.EOF) {
+		httperr.InvalidRequest(w, "Invalid JSON in request body")
+		return false
+	}
+	return true
+}
+
+func writeMockResult(w http.ResponseWriter) func(*Result, error) {
+	return func(result *Result, err error) {
+		switch {
+		case errors.Is(err, ErrIdempotencyKeyRequired), errors.Is(err, ErrInvalidRequest):
+			httperr.InvalidRequest(w, err.Error())
+		case err != nil:
+			httperr.Internal(w, err.Error())
+		default:
+			_ = httpx.WriteJSON(w, http.StatusOK, result)
+		}
+	}
+}
+
+// authorizationPath returns the path of action on an authorization.
+func authorizationPath(authorizationID, action string) string {
+	return "/authorizations/" + url.PathEscape(authorizationID) + "/" + action
+}
+
+// problemDetail returns the detail of a problem response body, or
+// fallback.
+func problemDetail(data []byte, fallback string) string {
+	var problem struct {
+		Detail string `json:"detail"`
+	}
+	if err := json.Unmarshal(data, &problem); err != nil || problem.Detail == "" {
+		return fallback
+	}
+	return problem.Detail
+}
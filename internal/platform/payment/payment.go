@@ -0,0 +1,133 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"go-shopping-poc/internal/platform/money"
+)
+
+var (
+	// ErrUnknownGateway is returned for a gateway name no adapter is
+	// registered for.
+	ErrUnknownGateway = errors.New("unknown payment gateway")
+	// ErrIdempotencyKeyRequired is returned for a call without an
+	// idempotency key.
+	ErrIdempotencyKeyRequired = errors.New("payment idempotency key required")
+	// ErrInvalidRequest is returned for a call the gateway refuses, such
+	// as capturing more than was authorized or refunding a voided
+	// authorization.
+	ErrInvalidRequest = errors.New("invalid payment request")
+	// ErrGatewayUnavailable is returned when the gateway cannot be reached
+	// or fails.
+	ErrGatewayUnavailable = errors.New("payment gateway unavailable")
+)
+
+// Result statuses.
+const (
+	StatusApproved = "approved"
+	StatusDeclined = "declined"
+)
+
+// Gateway charges cards through a payment provider.
+type Gateway interface {
+	// Name returns the name the gateway is registered under.
+	Name() string
+	// Authorize holds an amount on a card.
+	Authorize(ctx context.Context, req AuthorizeRequest) (*Result, error)
+	// Capture takes up to the authorized amount.
+	Capture(ctx context.Context, req CaptureRequest) (*Result, error)
+	// Void releases an authorization nothing was captured from.
+	Void(ctx context.Context, req VoidRequest) (*Result, error)
+	// Refund gives back up to the captured amount.
+	Refund(ctx context.Context, req RefundRequest) (*Result, error)
+}
+
+// Card is the card an authorization charges.
+type Card struct {
+	Number     string `json:"number"`
+	HolderName string `json:"holder_name"`
+	Expires    string `json:"expires"`
+	CVV        string `json:"cvv"`
+}
+
+// AuthorizeRequest is the input to Gateway.Authorize. Reference
+// identifies the payment to the merchant, such as an order number.
+type AuthorizeRequest struct {
+	IdempotencyKey string      `json:"-"`
+	Reference      string      `json:"reference"`
+	Amount         money.Money `json:"amount"`
+	Currency       string      `json:"currency"`
+	Card           Card        `json:"card"`
+}
+
+// CaptureRequest is the input to Gateway.Capture.
+type CaptureRequest struct {
+	IdempotencyKey  string      `json:"-"`
+	AuthorizationID string      `json:"-"`
+	Amount          money.Money `json:"amount"`
+}
+
+// VoidRequest is the input to Gateway.Void.
+type VoidRequest struct {
+	IdempotencyKey  string `json:"-"`
+	AuthorizationID string `json:"-"`
+}
+
+// RefundRequest is the input to Gateway.Refund.
+type RefundRequest struct {
+	IdempotencyKey  string      `json:"-"`
+	AuthorizationID string      `json:"-"`
+	Amount          money.Money `json:"amount"`
+}
+
+// Result is the outcome of a gateway call. TransactionID identifies the
+// call at the gateway; AuthorizationID the authorization it acted on.
+// DeclineCode tells why a declined call was.
+type Result struct {
+	TransactionID   string      `json:"transaction_id"`
+	AuthorizationID string      `json:"authorization_id"`
+	Status          string      `json:"status"`
+	Amount          money.Money `json:"amount"`
+	DeclineCode     string      `json:"decline_code,omitempty"`
+}
+
+// Approved reports whether the call was approved.
+func (r *Result) Approved() bool {
+	return r.Status == StatusApproved
+}
+
+// Registry holds the gateways payments can be taken with.
+type Registry struct {
+	gateways map[string]Gateway
+}
+
+// NewRegistry creates a registry of gateways.
+func NewRegistry(gateways ...Gateway) *Registry {
+	r := &Registry{gateways: make(map[string]Gateway, len(gateways))}
+	for _, g := range gateways {
+		r.gateways[g.Name()] = g
+	}
+	return r
+}
+
+// Get returns the gateway registered as name.
+func (r *Registry) Get(name string) (Gateway, error) {
+	g, ok := r.gateways[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownGateway, name)
+	}
+	return g, nil
+}
+
+// Names lists the registered gateways.
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.gateways))
+	for name := range r.gateways {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,226 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-shopping-poc/internal/platform/money"
+	"go-shopping-poc/internal/platform/money/moneytest"
+)
+
+func authorize(t *testing.T, g Gateway, key, card string) *Result {
+	t.Helper()
+
+	result, err := g.Authorize(context.Background(), AuthorizeRequest{
+		IdempotencyKey: key,
+		Reference:      "ORD-1",
+		Amount:         money.MustParse("25.00", "USD"),
+		Currency:       "USD",
+		Card:           Card{Number: card},
+	})
+	if err != nil {
+		t.Fatalf("authorize: unexpected error: %v", err)
+	}
+	return result
+}
+
+func TestFakeGatewayLifecycle(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	g := NewFakeGateway()
+
+	auth := authorize(t, g, "a1", "4242424242424242")
+	if !auth.Approved() || auth.AuthorizationID == "" {
+		t.Fatalf("expected approved authorization, got %+v", auth)
+	}
+
+	capture, err := g.Capture(ctx, CaptureRequest{IdempotencyKey: "c1", AuthorizationID: auth.AuthorizationID, Amount: money.MustParse("25.00", "USD")})
+	if err != nil || !capture.Approved() {
+		t.Fatalf("capture: expected approval, got %+v, %v", capture, err)
+	}
+	if _, err := g.Capture(ctx, CaptureRequest{IdempotencyKey: "c2", AuthorizationID: auth.AuthorizationID, Amount: money.MustParse("0.01", "USD")}); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("over-capture: expected ErrInvalidRequest, got %v", err)
+	}
+	if _, err := g.Void(ctx, VoidRequest{IdempotencyKey: "v1", AuthorizationID: auth.AuthorizationID}); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("void after capture: expected ErrInvalidRequest, got %v", err)
+	}
+
+	refund, err := g.Refund(ctx, RefundRequest{IdempotencyKey: "r1", AuthorizationID: auth.AuthorizationID, Amount: money.MustParse("10.00", "USD")})
+	if err != nil || !refund.Approved() {
+		t.Fatalf("refund: expected approval, got %+v, %v", refund, err)
+	}
+	if _, err := g.Refund(ctx, RefundRequest{IdempotencyKey: "r2", AuthorizationID: auth.AuthorizationID, Amount: money.MustParse("15.01", "USD")}); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("over-refund: expected ErrInvalidRequest, got %v", err)
+	}
+}
+
+func TestFakeGatewayDeclines(t *testing.T) {
+	t.Parallel()
+
+	g := NewFakeGateway()
+	tests := map[string]string{
+		FakeCardDeclined:          "card_declined",
+		FakeCardInsufficientFunds: "insufficient_funds",
+	}
+	for card, code := range tests {
+		result := authorize(t, g, "decline-"+card, card)
+		if result.Approved() || result.DeclineCode != code || result.AuthorizationID != "" {
+			t.Errorf("%s: expected decline %s, got %+v", card, code, result)
+		}
+	}
+}
+
+func TestFakeGatewayIdempotency(t *testing.T) {
+	t.Parallel()
+
+	capture := func(g *FakeGateway, authID, key, amount string) (*Result, error) {
+		return g.Capture(context.Background(), CaptureRequest{IdempotencyKey: key, AuthorizationID: authID, Amount: moneytest.USD(amount)})
+	}
+	void := func(g *FakeGateway, authID, key string) (*Result, error) {
+		return g.Void(context.Background(), VoidRequest{IdempotencyKey: key, AuthorizationID: authID})
+	}
+
+	tests := []struct {
+		name string
+		// call makes the call under test with key against authorization
+		// authID.
+		call func(g *FakeGateway, authID, key string) (*Result, error)
+		// fail makes a call of the same kind with key that fails, or is nil.
+		fail func(g *FakeGateway, authID, key string) (*Result, error)
+		// other makes a call of another kind with key.
+		other func(g *FakeGateway, authID, key string) (*Result, error)
+		// applied checks that the call took effect once.
+		applied func(t *testing.T, g *FakeGateway, authID string, first *Result)
+	}{
+		{
+			name: "authorize",
+			call: func(g *FakeGateway, _, key string) (*Result, error) {
+				return g.Authorize(context.Background(), AuthorizeRequest{IdempotencyKey: key, Amount: moneytest.USD("25.00"), Currency: "USD", Card: Card{Number: "4242424242424242"}})
+			},
+			fail: func(g *FakeGateway, _, key string) (*Result, error) {
+				return g.Authorize(context.Background(), AuthorizeRequest{IdempotencyKey: key, Amount: moneytest.USD("25.00"), Currency: "USD"})
+			},
+			other: void,
+			applied: func(t *testing.T, g *FakeGateway, _ string, first *Result) {
+				if other := authorize(t, g, "another", "4242424242424242"); other.AuthorizationID == first.AuthorizationID {
+					t.Errorf("another key reused authorization %s", first.AuthorizationID)
+				}
+			},
+		},
+		{
+			name: "capture",
+			call: func(g *FakeGateway, authID, key string) (*Result, error) {
+				return capture(g, authID, key, "20.00")
+			},
+			fail: func(g *FakeGateway, authID, key string) (*Result, error) {
+				return capture(g, authID, key, "25.01")
+			},
+			other: void,
+			applied: func(t *testing.T, g *FakeGateway, authID string, _ *Result) {
+				// A replayed capture must not count twice, so the rest is
+				// still left and no more.
+				if _, err := capture(g, authID, "rest", "5.00"); err != nil {
+					t.Errorf("capture of remainder: unexpected error: %v", err)
+				}
+				if _, err := capture(g, authID, "over", "0.01"); !errors.Is(err, ErrInvalidRequest) {
+					t.Errorf("capture past amount: expected ErrInvalidRequest, got %v", err)
+				}
+			},
+		},
+		{
+			name:  "void",
+			call:  void,
+			other: func(g *FakeGateway, authID, key string) (*Result, error) { return capture(g, authID, key, "1.00") },
+			applied: func(t *testing.T, g *FakeGateway, authID string, _ *Result) {
+				if _, err := capture(g, authID, "after-void", "1.00"); !errors.Is(err, ErrInvalidRequest) {
+					t.Errorf("capture after void: expected ErrInvalidRequest, got %v", err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			g := NewFakeGateway()
+			authID := authorize(t, g, "auth", "4242424242424242").AuthorizationID
+
+			if _, err := tt.call(g, authID, ""); !errors.Is(err, ErrIdempotencyKeyRequired) {
+				t.Errorf("missing key: expected ErrIdempotencyKeyRequired, got %v", err)
+			}
+			// Failed calls are not kept, so their key can be retried.
+			if tt.fail != nil {
+				if _, err := tt.fail(g, authID, "k1"); err == nil {
+					t.Fatalf("failing call: expected an error")
+				}
+			}
+
+			first, err := tt.call(g, authID, "k1")
+			if err != nil || !first.Approved() {
+				t.Fatalf("first call: expected approval, got %+v, %v", first, err)
+			}
+			again, err := tt.call(g, authID, "k1")
+			if err != nil {
+				t.Fatalf("replayed call: unexpected error: %v", err)
+			}
+			if *again != *first {
+				t.Errorf("expected replayed result %+v, got %+v", first, again)
+			}
+
+			if _, err := tt.other(g, authID, "k1"); !errors.Is(err, ErrInvalidRequest) {
+				t.Errorf("key reused for another call: expected ErrInvalidRequest, got %v", err)
+			}
+			tt.applied(t, g, authID, first)
+		})
+	}
+}
+
+func TestHTTPGatewayAgainstMockServer(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(NewMockServer(NewFakeGateway()))
+	defer server.Close()
+
+	ctx := context.Background()
+	g := NewHTTPGateway(server.URL, nil)
+
+	auth := authorize(t, g, "a1", "4242424242424242")
+	if !auth.Approved() || auth.AuthorizationID == "" {
+		t.Fatalf("expected approved authorization, got %+v", auth)
+	}
+	if again := authorize(t, g, "a1", "4242424242424242"); again.TransactionID != auth.TransactionID {
+		t.Errorf("expected replayed transaction %s, got %s", auth.TransactionID, again.TransactionID)
+	}
+	if declined := authorize(t, g, "a2", FakeCardDeclined); declined.Approved() || declined.DeclineCode != "card_declined" {
+		t.Errorf("expected decline, got %+v", declined)
+	}
+
+	if _, err := g.Capture(ctx, CaptureRequest{IdempotencyKey: "c1", AuthorizationID: auth.AuthorizationID, Amount: money.MustParse("30.00", "USD")}); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("over-capture: expected ErrInvalidRequest, got %v", err)
+	}
+	void, err := g.Void(ctx, VoidRequest{IdempotencyKey: "v1", AuthorizationID: auth.AuthorizationID})
+	if err != nil || !void.Approved() {
+		t.Errorf("void: expected approval, got %+v, %v", void, err)
+	}
+}
+
+func TestHTTPGatewayUnavailable(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	g := NewHTTPGateway(server.URL, nil)
+	_, err := g.Void(context.Background(), VoidRequest{IdempotencyKey: "v1", AuthorizationID: "auth"})
+	if !errors.Is(err, ErrGatewayUnavailable) {
+		t.Fatalf("expected ErrGatewayUnavailable, got %v", err)
+	}
+}
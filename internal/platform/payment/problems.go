@@ -0,0 +1,16 @@
+package payment
+
+import (
+	"net/http"
+
+	platformerrors "go-shopping-poc/internal/platform/errors"
+	"go-shopping-poc/internal/platform/httperr"
+)
+
+// init maps payment errors to problem responses.
+func init() {
+	httperr.Register(ErrUnknownGateway, httperr.Mapping{Status: http.StatusInternalServerError, Type: platformerrors.ErrorTypeInternal, Code: "payment_gateway_unknown", Message: "Payment gateway is not configured"})
+	httperr.Register(ErrIdempotencyKeyRequired, httperr.Mapping{Status: http.StatusInternalServerError, Type: platformerrors.ErrorTypeInternal, Code: "payment_idempotency_key_required", Message: "Payment could not be processed"})
+	httperr.Register(ErrInvalidRequest, httperr.Mapping{Status: http.StatusConflict, Type: platformerrors.ErrorTypeConflict, Code: "payment_invalid_request", ExposeDetail: true})
+	httperr.Register(ErrGatewayUnavailable, httperr.Mapping{Status: http.StatusBadGateway, Type: platformerrors.ErrorTypeInternal, Code: "payment_gateway_unavailable", Message: "Payment gateway is unavailable"})
+}
@@ -0,0 +1,16 @@
+// Package promotion evaluates discounts for cart lines.
+//
+// A Promotion is either automatic (no code) or redeemed with a coupon code.
+// It takes a percent or fixed amount off, or gives units free with
+// buy-X-get-Y. Promotions may be scoped to product categories and brands,
+// require a minimum spend on the lines they apply to, limit redemptions
+// globally and per customer, and run only inside a validity window.
+//
+// Evaluate checks a single promotion and explains why it does not apply;
+// Apply stacks several promotions, skipping those that do not qualify. Each
+// Discount is allocated to the lines it reduces so tax can be charged on the
+// discounted amounts, and no line is ever discounted below zero.
+//
+// The package only evaluates. Loading promotions and recording redemptions
+// is left to the owning service.
+package promotion
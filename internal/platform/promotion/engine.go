@@ -0,0 +1,212 @@
+package promotion
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+
+	"go-shopping-poc/internal/platform/money"
+)
+
+// Evaluate checks whether p applies to req and returns its discount. The
+// error explains why a promotion does not apply.
+func Evaluate(p Promotion, req Request) (*Discount, error) {
+	return evaluate(p, req, remainingAmounts(req.Lines))
+}
+
+// Apply stacks promos in order and returns the discounts that apply. Each
+// promotion sees the line amounts left after the ones before it, so lines
+// are never discounted below zero. Promotions that do not qualify are skipped.
+func Apply(promos []Promotion, req Request) Discounts {
+	remaining := remainingAmounts(req.Lines)
+
+	var discounts Discounts
+	for _, p := range promos {
+		disc, err := evaluate(p, req, remaining)
+		if err != nil {
+			continue
+		}
+		left, err := deduct(remaining, disc.Lines)
+		if err != nil {
+			continue
+		}
+		remaining = left
+		discounts = append(discounts, *disc)
+	}
+	return discounts
+}
+
+func remainingAmounts(lines []Line) map[string]money.Money {
+	remaining := make(map[string]money.Money, len(lines))
+	for _, l := range lines {
+		remaining[l.LineNumber] = l.Amount()
+	}
+	return remaining
+}
+
+// deduct returns what is left of remaining once lines are taken off.
+func deduct(remaining map[string]money.Money, lines []LineDiscount) (map[string]money.Money, error) {
+	left := maps.Clone(remaining)
+	for _, l := range lines {
+		amount, err := left[l.LineNumber].Sub(l.Amount)
+		if err != nil {
+			return nil, err
+		}
+		left[l.LineNumber] = amount
+	}
+	return left, nil
+}
+
+func evaluate(p Promotion, req Request, remaining map[string]money.Money) (*Discount, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	if !p.Active || !p.InWindow(req.Now) {
+		return nil, ErrNotActive
+	}
+	if p.FixedAmounts() && p.Currency != "" && req.Currency != "" && !strings.EqualFold(p.Currency, req.Currency) {
+		return nil, fmt.Errorf("%w: amounts are in %s", ErrNotApplicable, p.Currency)
+	}
+	if p.MaxUses > 0 && p.Redemptions >= p.MaxUses {
+		return nil, ErrUsageLimitReached
+	}
+	if p.MaxUsesPerCustomer > 0 {
+		if req.CustomerKey == "" {
+			return nil, ErrCustomerRequired
+		}
+		if p.CustomerRedemptions >= p.MaxUsesPerCustomer {
+			return nil, ErrCustomerUsageLimitReached
+		}
+	}
+
+	var eligible []Line
+	spend := money.Zero(req.Currency)
+	for _, l := range req.Lines {
+		if l.Quantity <= 0 || !p.Matches(l) {
+			continue
+		}
+		eligible = append(eligible, l)
+		var err error
+		if spend, err = spend.Add(l.Amount()); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrNotApplicable, err)
+		}
+	}
+	if len(eligible) == 0 {
+		return nil, ErrNotApplicable
+	}
+	// Minimum spend is measured on the lines in scope, before discounts.
+	short, err := spend.LessThan(p.MinSubtotal)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNotApplicable, err)
+	}
+	if short {
+		return nil, fmt.Errorf("%w: requires %s, eligible items total %s", ErrMinimumSpendNotMet, p.MinSubtotal, spend)
+	}
+
+	var lines []LineDiscount
+	switch p.Kind {
+	case KindPercentOff:
+		lines = percentOff(eligible, remaining, p.Value)
+	case KindAmountOff:
+		lines, err = amountOff(eligible, remaining, p.Amount)
+	case KindBuyXGetY:
+		lines, err = buyXGetY(eligible, remaining, p)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	disc := &Discount{PromotionID: p.ID, Code: p.Code, Name: p.Name, Kind: p.Kind, Amount: money.Zero(req.Currency)}
+	for _, l := range lines {
+		if !l.Amount.IsPositive() {
+			continue
+		}
+		disc.Lines = append(disc.Lines, l)
+		if disc.Amount, err = disc.Amount.Add(l.Amount); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrNotApplicable, err)
+		}
+	}
+	if !disc.Amount.IsPositive() {
+		return nil, ErrNotApplicable
+	}
+	return disc, nil
+}
+
+// percentOff takes percent off what is left on each line. percent is at
+// most 100, so no line goes below zero.
+func percentOff(eligible []Line, remaining map[string]money.Money, percent float64) []LineDiscount {
+	out := make([]LineDiscount, 0, len(eligible))
+	for _, l := range eligible {
+		out = append(out, LineDiscount{LineNumber: l.LineNumber, Amount: remaining[l.LineNumber].MulRate(percent / 100)})
+	}
+	return out
+}
+
+// amountOff spreads amount across the eligible lines in proportion to what
+// is left on each, capped at the total left.
+func amountOff(eligible []Line, remaining map[string]money.Money, amount money.Money) ([]LineDiscount, error) {
+	left := make([]money.Money, len(eligible))
+	for i, l := range eligible {
+		left[i] = remaining[l.LineNumber]
+	}
+	base, err := money.Sum("", left...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNotApplicable, err)
+	}
+	if amount, err = money.Min(amount, base); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNotApplicable, err)
+	}
+	if !amount.IsPositive() {
+		return nil, nil
+	}
+
+	ratios := make([]int64, len(left))
+	for i, l := range left {
+		ratios[i] = max(l.In(amount.Currency()).Minor(), 0)
+	}
+	out := make([]LineDiscount, 0, len(eligible))
+	for i, share := range amount.Allocate(ratios...) {
+		out = append(out, LineDiscount{LineNumber: eligible[i].LineNumber, Amount: share})
+	}
+	return out, nil
+}
+
+// buyXGetY discounts the cheapest eligible units: for every BuyQuantity +
+// GetQuantity units in scope, GetQuantity of them are discounted.
+func buyXGetY(eligible []Line, remaining map[string]money.Money, p Promotion) ([]LineDiscount, error) {
+	units := 0
+	for _, l := range eligible {
+		units += l.Quantity
+	}
+	group := p.BuyQuantity + p.GetQuantity
+	free := (units / group) * p.GetQuantity
+	if free == 0 {
+		return nil, fmt.Errorf("%w: requires %d eligible items", ErrNotApplicable, group)
+	}
+
+	percent := p.Value
+	if percent == 0 {
+		percent = 100
+	}
+
+	// Lines of a request share its currency, so minor units compare.
+	byPrice := make([]Line, len(eligible))
+	copy(byPrice, eligible)
+	sort.SliceStable(byPrice, func(i, j int) bool { return byPrice[i].UnitPrice.Minor() < byPrice[j].UnitPrice.Minor() })
+
+	out := make([]LineDiscount, 0, len(byPrice))
+	for _, l := range byPrice {
+		if free == 0 {
+			break
+		}
+		n := min(free, l.Quantity)
+		free -= n
+		amount, err := money.Min(l.UnitPrice.Mul(int64(n)).MulRate(percent/100), remaining[l.LineNumber])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrNotApplicable, err)
+		}
+		out = append(out, LineDiscount{LineNumber: l.LineNumber, Amount: amount})
+	}
+	return out, nil
+}
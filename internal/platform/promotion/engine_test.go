@@ -0,0 +1,170 @@
+package promotion
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go-shopping-poc/internal/platform/money"
+	"go-shopping-poc/internal/platform/money/moneytest"
+)
+
+var now = time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func sampleLines() []Line {
+	return []Line{
+		{LineNumber: "001", ProductID: "1", Category: "Shoes", Brand: "Acme", UnitPrice: moneytest.USD("50"), Quantity: 2},
+		{LineNumber: "002", ProductID: "2", Category: "Apparel", Brand: "Globex", UnitPrice: moneytest.USD("20"), Quantity: 1},
+	}
+}
+
+func TestEvaluateKinds(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		promo Promotion
+		want  string
+		lines map[string]string
+	}{
+		{
+			name:  "percent off cart",
+			promo: Promotion{ID: 1, Kind: KindPercentOff, Value: 10, Active: true},
+			want:  "12.00",
+			lines: map[string]string{"001": "10.00", "002": "2.00"},
+		},
+		{
+			name:  "amount off split by line",
+			promo: Promotion{ID: 2, Kind: KindAmountOff, Amount: moneytest.USD("30"), Active: true},
+			want:  "30.00",
+			lines: map[string]string{"001": "25.00", "002": "5.00"},
+		},
+		{
+			name:  "amount off capped at spend",
+			promo: Promotion{ID: 3, Kind: KindAmountOff, Amount: moneytest.USD("500"), Active: true, Categories: []string{"apparel"}},
+			want:  "20.00",
+			lines: map[string]string{"002": "20.00"},
+		},
+		{
+			name:  "brand scoped percent",
+			promo: Promotion{ID: 4, Kind: KindPercentOff, Value: 50, Active: true, Brands: []string{"ACME"}},
+			want:  "50.00",
+			lines: map[string]string{"001": "50.00"},
+		},
+		{
+			name:  "buy two get one discounts cheapest",
+			promo: Promotion{ID: 5, Kind: KindBuyXGetY, BuyQuantity: 2, GetQuantity: 1, Active: true},
+			want:  "20.00",
+			lines: map[string]string{"002": "20.00"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			disc, err := Evaluate(tt.promo, Request{Currency: "USD", Lines: sampleLines(), Now: now})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if disc.Amount.String() != tt.want {
+				t.Errorf("expected amount %s, got %s", tt.want, disc.Amount)
+			}
+			got := Discounts{*disc}
+			for line, want := range tt.lines {
+				if amt, err := got.ForLine(line, "USD"); err != nil || amt.String() != want {
+					t.Errorf("line %s: expected %s, got %s (%v)", line, want, amt, err)
+				}
+			}
+		})
+	}
+}
+
+func TestEvaluateRejections(t *testing.T) {
+	t.Parallel()
+
+	past := now.Add(-48 * time.Hour)
+	yesterday := now.Add(-24 * time.Hour)
+	tomorrow := now.Add(24 * time.Hour)
+
+	tests := []struct {
+		name     string
+		promo    Promotion
+		customer string
+		want     error
+	}{
+		{name: "inactive", promo: Promotion{Kind: KindPercentOff, Value: 10}, want: ErrNotActive},
+		{name: "not started", promo: Promotion{Kind: KindPercentOff, Value: 10, Active: true, StartsAt: &tomorrow}, want: ErrNotActive},
+		{name: "expired", promo: Promotion{Kind: KindPercentOff, Value: 10, Active: true, StartsAt: &past, EndsAt: &yesterday}, want: ErrNotActive},
+		{name: "minimum spend", promo: Promotion{Kind: KindAmountOff, Amount: moneytest.USD("5"), Active: true, MinSubtotal: moneytest.USD("150")}, want: ErrMinimumSpendNotMet},
+		{name: "scoped minimum spend", promo: Promotion{Kind: KindAmountOff, Amount: moneytest.USD("5"), Active: true, MinSubtotal: moneytest.USD("50"), Categories: []string{"Apparel"}}, want: ErrMinimumSpendNotMet},
+		{name: "out of scope", promo: Promotion{Kind: KindPercentOff, Value: 10, Active: true, Brands: []string{"Initech"}}, want: ErrNotApplicable},
+		{name: "not enough units", promo: Promotion{Kind: KindBuyXGetY, BuyQuantity: 3, GetQuantity: 1, Active: true}, want: ErrNotApplicable},
+		{name: "global limit", promo: Promotion{Kind: KindPercentOff, Value: 10, Active: true, MaxUses: 5, Redemptions: 5}, want: ErrUsageLimitReached},
+		{name: "guest with customer limit", promo: Promotion{Kind: KindPercentOff, Value: 10, Active: true, MaxUsesPerCustomer: 1}, want: ErrCustomerRequired},
+		{name: "customer limit", promo: Promotion{Kind: KindPercentOff, Value: 10, Active: true, MaxUsesPerCustomer: 1, CustomerRedemptions: 1}, customer: "c1", want: ErrCustomerUsageLimitReached},
+		{name: "invalid percent", promo: Promotion{Kind: KindPercentOff, Value: 120, Active: true}, want: ErrInvalidPromotion},
+		{name: "amount in other currency", promo: Promotion{Kind: KindAmountOff, Amount: money.MustParse("5", "EUR"), Active: true, Currency: "EUR"}, want: ErrNotApplicable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := Evaluate(tt.promo, Request{CustomerKey: tt.customer, Currency: "USD", Lines: sampleLines(), Now: now})
+			if !errors.Is(err, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestApplyStacksWithoutOverDiscounting(t *testing.T) {
+	t.Parallel()
+
+	promos := []Promotion{
+		{ID: 1, Kind: KindPercentOff, Value: 50, Active: true},
+		{ID: 2, Code: "TAKE100", Kind: KindAmountOff, Amount: moneytest.USD("100"), Active: true},
+		{ID: 3, Kind: KindPercentOff, Value: 10, Active: false},
+	}
+
+	discounts := Apply(promos, Request{Currency: "USD", Lines: sampleLines(), Now: now})
+	if len(discounts) != 2 {
+		t.Fatalf("expected 2 discounts, got %d", len(discounts))
+	}
+	if !discounts[0].Amount.Equal(moneytest.USD("60")) {
+		t.Errorf("expected first discount 60, got %s", discounts[0].Amount)
+	}
+	if !discounts[1].Amount.Equal(moneytest.USD("60")) {
+		t.Errorf("expected second discount capped at 60, got %s", discounts[1].Amount)
+	}
+	if total, err := discounts.Total("USD"); err != nil || !total.Equal(moneytest.USD("120")) {
+		t.Errorf("expected total 120, got %s (%v)", total, err)
+	}
+	for _, line := range sampleLines() {
+		if got, err := discounts.ForLine(line.LineNumber, "USD"); err != nil || !got.Equal(line.Amount()) {
+			t.Errorf("line %s: expected fully discounted %s, got %s (%v)", line.LineNumber, line.Amount(), got, err)
+		}
+	}
+}
+
+func TestDiscountsScanRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	in := Discounts{{PromotionID: 7, Code: "SAVE", Name: "Save", Kind: KindAmountOff, Amount: moneytest.USD("5"), Lines: []LineDiscount{{LineNumber: "001", Amount: moneytest.USD("5")}}}}
+	v, err := in.Value()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var out Discounts
+	if err := out.Scan(v); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(out) != 1 || out[0].Code != "SAVE" {
+		t.Fatalf("unexpected round trip result: %+v", out)
+	}
+	if amount, err := out.ForLine("001", "USD"); err != nil || !amount.Equal(moneytest.USD("5")) {
+		t.Errorf("unexpected round trip result: %+v", out)
+	}
+}
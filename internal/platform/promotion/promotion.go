@@ -0,0 +1,227 @@
+package promotion
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go-shopping-poc/internal/platform/money"
+)
+
+// Kind is the type of discount a promotion gives.
+type Kind string
+
+const (
+	// KindPercentOff takes Value percent off the eligible lines.
+	KindPercentOff Kind = "percent_off"
+	// KindAmountOff takes a fixed Amount off the eligible lines.
+	KindAmountOff Kind = "amount_off"
+	// KindBuyXGetY discounts GetQuantity units for every BuyQuantity units
+	// bought. Value is the percent off the discounted units (100 when zero).
+	KindBuyXGetY Kind = "buy_x_get_y"
+)
+
+var (
+	ErrInvalidPromotion          = errors.New("invalid promotion")
+	ErrNotActive                 = errors.New("promotion is not active")
+	ErrMinimumSpendNotMet        = errors.New("minimum spend not met")
+	ErrNotApplicable             = errors.New("promotion does not apply to cart items")
+	ErrUsageLimitReached         = errors.New("promotion usage limit reached")
+	ErrCustomerUsageLimitReached = errors.New("promotion already used by customer")
+	ErrCustomerRequired          = errors.New("promotion requires an identified customer")
+)
+
+// Promotion describes a discount and the rules for when it applies.
+type Promotion struct {
+	ID   int64
+	Code string // empty for automatic promotions
+	Name string
+	Kind Kind
+
+	Value       float64     // percent off, for percent_off and buy_x_get_y
+	Amount      money.Money // amount off, for amount_off
+	BuyQuantity int
+	GetQuantity int
+
+	// Scope; empty lists match every line
+	Categories []string
+	Brands     []string
+
+	// Currency of Amount and MinSubtotal; empty matches carts in any
+	// currency
+	Currency string
+
+	MinSubtotal        money.Money
+	MaxUses            int // 0 for unlimited
+	MaxUsesPerCustomer int // 0 for unlimited
+
+	StartsAt *time.Time
+	EndsAt   *time.Time
+	Active   bool
+
+	// Redemptions counted when the promotion was loaded
+	Redemptions         int
+	CustomerRedemptions int
+}
+
+// FixedAmounts reports whether the promotion has amounts that only hold in
+// its own currency.
+func (p Promotion) FixedAmounts() bool {
+	return p.Kind == KindAmountOff || p.MinSubtotal.IsPositive()
+}
+
+// Automatic reports whether the promotion applies without a coupon code.
+func (p Promotion) Automatic() bool {
+	return p.Code == ""
+}
+
+// Validate checks the promotion definition.
+func (p Promotion) Validate() error {
+	switch p.Kind {
+	case KindPercentOff:
+		if p.Value <= 0 || p.Value > 100 {
+			return fmt.Errorf("%w: percent_off value must be between 0 and 100", ErrInvalidPromotion)
+		}
+	case KindAmountOff:
+		if !p.Amount.IsPositive() {
+			return fmt.Errorf("%w: amount_off value must be positive", ErrInvalidPromotion)
+		}
+	case KindBuyXGetY:
+		if p.BuyQuantity <= 0 || p.GetQuantity <= 0 {
+			return fmt.Errorf("%w: buy_x_get_y quantities must be positive", ErrInvalidPromotion)
+		}
+		if p.Value < 0 || p.Value > 100 {
+			return fmt.Errorf("%w: buy_x_get_y value must be between 0 and 100", ErrInvalidPromotion)
+		}
+	default:
+		return fmt.Errorf("%w: unknown kind %q", ErrInvalidPromotion, p.Kind)
+	}
+	if p.StartsAt != nil && p.EndsAt != nil && !p.EndsAt.After(*p.StartsAt) {
+		return fmt.Errorf("%w: ends_at must be after starts_at", ErrInvalidPromotion)
+	}
+	return nil
+}
+
+// InWindow reports whether now falls inside the validity window.
+func (p Promotion) InWindow(now time.Time) bool {
+	if p.StartsAt != nil && now.Before(*p.StartsAt) {
+		return false
+	}
+	if p.EndsAt != nil && !now.Before(*p.EndsAt) {
+		return false
+	}
+	return true
+}
+
+// Matches reports whether the promotion's category and brand scope covers line.
+func (p Promotion) Matches(line Line) bool {
+	return matchesAny(p.Categories, line.Category) && matchesAny(p.Brands, line.Brand)
+}
+
+func matchesAny(scope []string, value string) bool {
+	if len(scope) == 0 {
+		return true
+	}
+	for _, s := range scope {
+		if strings.EqualFold(strings.TrimSpace(s), strings.TrimSpace(value)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Line is a discountable cart line.
+type Line struct {
+	LineNumber string
+	ProductID  string
+	Category   string
+	Brand      string
+	UnitPrice  money.Money
+	Quantity   int
+}
+
+// Amount is the line total before discounts.
+func (l Line) Amount() money.Money {
+	return l.UnitPrice.Mul(int64(l.Quantity))
+}
+
+// Request is the input to Evaluate and Apply. CustomerKey identifies the
+// customer for per-customer limits and is empty for anonymous carts.
+// Currency is the currency of the line prices.
+type Request struct {
+	CustomerKey string
+	Currency    string
+	Lines       []Line
+	Now         time.Time
+}
+
+// LineDiscount is the part of a discount allocated to one line.
+type LineDiscount struct {
+	LineNumber string      `json:"line_number"`
+	Amount     money.Money `json:"amount"`
+}
+
+// Discount is a promotion applied to a cart.
+type Discount struct {
+	PromotionID int64          `json:"promotion_id"`
+	Code        string         `json:"code,omitempty"`
+	Name        string         `json:"name"`
+	Kind        Kind           `json:"kind"`
+	Amount      money.Money    `json:"amount"`
+	Lines       []LineDiscount `json:"lines,omitempty"`
+}
+
+// Discounts lists the discounts applied to a cart. It is stored as JSONB.
+type Discounts []Discount
+
+// Value implements driver.Valuer.
+func (d Discounts) Value() (driver.Value, error) {
+	if d == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(d)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode discounts: %w", err)
+	}
+	return data, nil
+}
+
+// Scan implements sql.Scanner.
+func (d *Discounts) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*d = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, d)
+	case string:
+		return json.Unmarshal([]byte(v), d)
+	default:
+		return fmt.Errorf("cannot scan %T into discounts", src)
+	}
+}
+
+// Total sums the discount amounts in currency.
+func (d Discounts) Total(currency string) (money.Money, error) {
+	amounts := make([]money.Money, len(d))
+	for i, disc := range d {
+		amounts[i] = disc.Amount
+	}
+	return money.Sum(currency, amounts...)
+}
+
+// ForLine sums the discounts allocated to lineNumber in currency.
+func (d Discounts) ForLine(lineNumber, currency string) (money.Money, error) {
+	var amounts []money.Money
+	for _, disc := range d {
+		for _, l := range disc.Lines {
+			if l.LineNumber == lineNumber {
+				amounts = append(amounts, l.Amount)
+			}
+		}
+	}
+	return money.Sum(currency, amounts...)
+}
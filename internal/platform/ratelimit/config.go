@@ -0,0 +1,29 @@
+package ratelimit
+
+import (
+	"go-shopping-poc/internal/platform/config"
+)
+
+// Config defines shared rate limiting configuration. Per-route limits live in
+// each service's own config.
+type Config struct {
+	// TrustForwardedFor keys anonymous clients by X-Forwarded-For. Enable
+	// only when services sit behind a proxy that sets the header.
+	TrustForwardedFor bool `mapstructure:"RATE_LIMIT_TRUST_FORWARDED_FOR"`
+	// APIKeys are the X-API-Key values clients are keyed by. Other values
+	// are ignored.
+	APIKeys []string `mapstructure:"RATE_LIMIT_API_KEYS"`
+}
+
+// LoadConfig loads the platform rate limiting configuration.
+func LoadConfig() (*Config, error) {
+	return config.LoadConfig[Config]("platform-ratelimit")
+}
+
+// KeyFunc returns the identity key function implied by the configuration.
+func (c *Config) KeyFunc() KeyFunc {
+	if c.TrustForwardedFor {
+		return KeyByIdentity(KeyByForwardedIP, c.APIKeys...)
+	}
+	return KeyByIdentity(KeyByIP, c.APIKeys...)
+}
@@ -0,0 +1,15 @@
+// Package ratelimit provides token-bucket rate limiting for HTTP routes.
+//
+// Each route gets its own Limit; requests are keyed by a configured API key,
+// authenticated subject or client IP (see KeyByIdentity). Rejected requests
+// receive a 429 problem response with Retry-After and RateLimit-* headers.
+//
+// Buckets live in a Store. MemoryStore is the default; a shared backend such
+// as Redis can implement Store to enforce limits across replicas.
+//
+// Usage:
+//
+//	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), ratelimit.WithLogger(logger))
+//	router.With(limiter.Middleware("cart.create", ratelimit.Limit{Requests: 30, Period: time.Minute})).
+//	    Post("/carts", handler.CreateCart)
+package ratelimit
@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"go-shopping-poc/internal/platform/auth"
+)
+
+// APIKeyHeader is the request header carrying a client API key.
+const APIKeyHeader = "X-API-Key"
+
+// KeyFunc derives the rate limit identity for a request.
+type KeyFunc func(r *http.Request) string
+
+// KeyByIP keys requests by the connection's remote address.
+func KeyByIP(r *http.Request) string {
+	return "ip:" + remoteIP(r)
+}
+
+// KeyByForwardedIP keys requests by the rightmost X-Forwarded-For hop,
+// falling back to the remote address. The rightmost hop is the address the
+// proxy in front of the service saw; hops left of it are whatever the client
+// sent. Only use it behind a single proxy that appends to the header.
+func KeyByForwardedIP(r *http.Request) string {
+	if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+		hops := strings.Split(fwd, ",")
+		if ip := strings.TrimSpace(hops[len(hops)-1]); ip != "" {
+			return "ip:" + ip
+		}
+	}
+	return KeyByIP(r)
+}
+
+// KeyBySubject keys requests by the authenticated JWT subject, or "" when the
+// request carries no claims.
+func KeyBySubject(r *http.Request) string {
+	if claims, ok := auth.GetClaims(r.Context()); ok && claims.Subject != "" {
+		return "sub:" + claims.Subject
+	}
+	return ""
+}
+
+// KeyByAPIKey keys requests by the X-API-Key header when it is one of keys,
+// or "" otherwise. Unknown keys are ignored so that clients cannot get a
+// fresh bucket by sending a new key.
+func KeyByAPIKey(keys ...string) KeyFunc {
+	known := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if key != "" {
+			known[key] = true
+		}
+	}
+	return func(r *http.Request) string {
+		if key := r.Header.Get(APIKeyHeader); known[key] {
+			return "key:" + key
+		}
+		return ""
+	}
+}
+
+// KeyByIdentity prefers a known API key, then the authenticated subject, then
+// the client IP as resolved by ipKey.
+func KeyByIdentity(ipKey KeyFunc, apiKeys ...string) KeyFunc {
+	apiKey := KeyByAPIKey(apiKeys...)
+	return func(r *http.Request) string {
+		if key := apiKey(r); key != "" {
+			return key
+		}
+		if key := KeyBySubject(r); key != "" {
+			return key
+		}
+		return ipKey(r)
+	}
+}
+
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
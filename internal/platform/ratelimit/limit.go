@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit allows Requests per Period, refilled continuously. Requests is also
+// the bucket size, so a full bucket absorbs a burst of that many requests.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// Valid reports whether the limit can be enforced.
+func (l Limit) Valid() bool {
+	return l.Requests > 0 && l.Period > 0
+}
+
+// rate returns the refill rate in tokens per second.
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// String formats the limit as accepted by ParseLimit.
+func (l Limit) String() string {
+	return fmt.Sprintf("%d/%s", l.Requests, l.Period)
+}
+
+// ParseLimit parses limits such as "30/m", "5/s", "1000/h" or "10/30s".
+func ParseLimit(s string) (Limit, error) {
+	count, period, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: expected <requests>/<period>", s)
+	}
+
+	requests, err := strconv.Atoi(strings.TrimSpace(count))
+	if err != nil || requests <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: requests must be a positive integer", s)
+	}
+
+	var d time.Duration
+	switch p := strings.TrimSpace(period); p {
+	case "s", "sec", "second":
+		d = time.Second
+	case "m", "min", "minute":
+		d = time.Minute
+	case "h", "hour":
+		d = time.Hour
+	default:
+		d, err = time.ParseDuration(p)
+		if err != nil || d <= 0 {
+			return Limit{}, fmt.Errorf("invalid rate limit %q: unknown period %q", s, p)
+		}
+	}
+
+	return Limit{Requests: requests, Period: d}, nil
+}
+
+// ParseLimitOrDefault parses s, returning def when s is empty.
+func ParseLimitOrDefault(s string, def Limit) (Limit, error) {
+	if strings.TrimSpace(s) == "" {
+		return def, nil
+	}
+	return ParseLimit(s)
+}
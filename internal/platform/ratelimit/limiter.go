@@ -0,0 +1,104 @@
+package ratelimit
+
+import (
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	platformerrors "go-shopping-poc/internal/platform/errors"
+	"go-shopping-poc/internal/platform/httperr"
+)
+
+// Option is a functional option for configuring Limiter.
+type Option func(*Limiter)
+
+// WithLogger sets the logger for the Limiter.
+func WithLogger(logger *slog.Logger) Option {
+	return func(l *Limiter) {
+		l.logger = logger
+	}
+}
+
+// WithKeyFunc overrides how requests are mapped to buckets.
+func WithKeyFunc(fn KeyFunc) Option {
+	return func(l *Limiter) {
+		l.keyFunc = fn
+	}
+}
+
+// WithClock overrides the time source, for tests.
+func WithClock(now func() time.Time) Option {
+	return func(l *Limiter) {
+		l.now = now
+	}
+}
+
+// Limiter enforces per-route limits against a Store.
+type Limiter struct {
+	store   Store
+	keyFunc KeyFunc
+	logger  *slog.Logger
+	now     func() time.Time
+}
+
+// NewLimiter creates a limiter. By default requests are keyed with
+// KeyByIdentity(KeyByIP).
+func NewLimiter(store Store, opts ...Option) *Limiter {
+	l := &Limiter{
+		store:   store,
+		keyFunc: KeyByIdentity(KeyByIP),
+		now:     time.Now,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	if l.logger == nil {
+		l.logger = slog.Default()
+	}
+	l.logger = l.logger.With("component", "rate_limiter")
+	return l
+}
+
+// Middleware limits the route named route to limit. Each route has its own
+// buckets, so limits on different routes do not interact. Store failures
+// fail open so an unavailable backend does not take the route down.
+func (l *Limiter) Middleware(route string, limit Limit) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !limit.Valid() {
+			l.logger.Warn("Rate limit disabled for route with invalid limit", "route", route, "limit", limit.String())
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := route + "|" + l.keyFunc(r)
+
+			result, err := l.store.Take(r.Context(), key, limit, l.now())
+			if err != nil {
+				l.logger.Error("Rate limit store failed, allowing request", "route", route, "error", err.Error())
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(limit.Requests))
+			h.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+			if !result.Allowed {
+				h.Set("Retry-After", strconv.Itoa(max(1, ceilSeconds(result.RetryAfter))))
+				l.logger.Warn("Rate limit exceeded", "route", route, "limit", limit.String())
+				httperr.SendWithCode(w, http.StatusTooManyRequests, platformerrors.ErrorTypeTooManyRequests,
+					"Too many requests, please retry later", "rate_limited")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,125 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Limit
+		wantErr bool
+	}{
+		{in: "30/m", want: Limit{Requests: 30, Period: time.Minute}},
+		{in: "5/s", want: Limit{Requests: 5, Period: time.Second}},
+		{in: " 1000 / h ", want: Limit{Requests: 1000, Period: time.Hour}},
+		{in: "10/30s", want: Limit{Requests: 10, Period: 30 * time.Second}},
+		{in: "10", wantErr: true},
+		{in: "0/m", wantErr: true},
+		{in: "10/fortnight", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseLimit(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("ParseLimit(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Fatalf("ParseLimit(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMiddlewareLimitsAndRefills(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	limiter := NewLimiter(NewMemoryStore(), WithClock(func() time.Time { return now }))
+	handler := limiter.Middleware("test", Limit{Requests: 2, Period: time.Minute})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
+
+	do := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	for i := 0; i < 2; i++ {
+		if rr := do(); rr.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i, rr.Code)
+		}
+	}
+
+	rr := do()
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", rr.Code)
+	}
+	if rr.Header().Get("Retry-After") != "30" {
+		t.Fatalf("expected Retry-After 30, got %q", rr.Header().Get("Retry-After"))
+	}
+	if rr.Header().Get("RateLimit-Limit") != "2" || rr.Header().Get("RateLimit-Remaining") != "0" {
+		t.Fatalf("unexpected RateLimit headers: %v", rr.Header())
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Fatalf("expected problem response, got %q", ct)
+	}
+
+	now = now.Add(30 * time.Second)
+	if rr := do(); rr.Code != http.StatusOK {
+		t.Fatalf("expected refill after 30s, got %d", rr.Code)
+	}
+}
+
+func TestMiddlewareKeysAreIndependent(t *testing.T) {
+	limiter := NewLimiter(NewMemoryStore(), WithKeyFunc(KeyByIdentity(KeyByIP, "client-key")))
+	limit := Limit{Requests: 1, Period: time.Hour}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	routeA := limiter.Middleware("a", limit)(ok)
+	routeB := limiter.Middleware("b", limit)(ok)
+
+	send := func(h http.Handler, remote, apiKey string) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remote
+		if apiKey != "" {
+			req.Header.Set(APIKeyHeader, apiKey)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	if send(routeA, "10.0.0.1:1", "") != http.StatusOK {
+		t.Fatal("first request should pass")
+	}
+	if send(routeA, "10.0.0.1:2", "") != http.StatusTooManyRequests {
+		t.Fatal("same IP on same route should be limited")
+	}
+	if send(routeB, "10.0.0.1:1", "") != http.StatusOK {
+		t.Fatal("other route should have its own bucket")
+	}
+	if send(routeA, "10.0.0.2:1", "") != http.StatusOK {
+		t.Fatal("other IP should have its own bucket")
+	}
+	if send(routeA, "10.0.0.1:1", "client-key") != http.StatusOK {
+		t.Fatal("API key should take precedence over IP")
+	}
+	if send(routeA, "10.0.0.1:1", "made-up-key") != http.StatusTooManyRequests {
+		t.Fatal("unknown API key should be keyed by IP")
+	}
+}
+
+func TestKeyByForwardedIP(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Forwarded-For", "198.51.100.1, 203.0.113.7")
+
+	if got := KeyByForwardedIP(req); got != "ip:203.0.113.7" {
+		t.Fatalf("unexpected key %q", got)
+	}
+	if got := KeyByIP(req); got != "ip:10.0.0.1" {
+		t.Fatalf("unexpected key %q", got)
+	}
+}
@@ -0,0 +1,24 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Result is the outcome of taking a token from a bucket.
+type Result struct {
+	// Allowed reports whether the request may proceed.
+	Allowed bool
+	// Remaining is the number of whole tokens left after this request.
+	Remaining int
+	// RetryAfter is how long until a token is available; zero when allowed.
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+}
+
+// Store holds token buckets. Implementations must be safe for concurrent use.
+type Store interface {
+	// Take consumes one token from the bucket identified by key.
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+}
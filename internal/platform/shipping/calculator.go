@@ -0,0 +1,194 @@
+package shipping
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go-shopping-poc/internal/platform/fx"
+	"go-shopping-poc/internal/platform/money"
+)
+
+// Option is a functional option for configuring TableCalculator.
+type Option func(*TableCalculator)
+
+// WithExchange lets the calculator price requests in currencies other than
+// the table's, converting the free shipping threshold and the charge at
+// provider's rates.
+func WithExchange(provider fx.Provider) Option {
+	return func(c *TableCalculator) {
+		c.fx = provider
+	}
+}
+
+// TableCalculator implements Calculator over a RateTable.
+type TableCalculator struct {
+	table *RateTable
+	fx    fx.Provider
+}
+
+// NewTableCalculator creates a calculator for table.
+func NewTableCalculator(table *RateTable, opts ...Option) *TableCalculator {
+	c := &TableCalculator{table: table}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Version returns the rate table version.
+func (c *TableCalculator) Version() string {
+	return c.table.Version
+}
+
+// Methods implements Calculator.
+func (c *TableCalculator) Methods() []Method {
+	methods := make([]Method, len(c.table.Methods))
+	copy(methods, c.table.Methods)
+	return methods
+}
+
+// Quote implements Calculator. Methods that cannot be used for the request
+// are left out.
+func (c *TableCalculator) Quote(ctx context.Context, req Request) ([]Quote, error) {
+	quotes := make([]Quote, 0, len(c.table.Methods))
+	for _, m := range c.table.Methods {
+		q, err := c.Rate(ctx, m.Code, req)
+		if errors.Is(err, ErrDestinationRequired) || errors.Is(err, ErrMethodUnavailable) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		quotes = append(quotes, *q)
+	}
+	return quotes, nil
+}
+
+// Rate implements Calculator. A request in another currency than the table
+// is converted with the exchange provider, or fails with
+// fx.ErrUnsupportedCurrency when there is none.
+func (c *TableCalculator) Rate(ctx context.Context, code string, req Request) (*Quote, error) {
+	method, ok := c.method(code)
+	if !ok {
+		return nil, ErrUnknownMethod
+	}
+
+	currency := c.table.CurrencyCode()
+	exchange := fx.Rate{From: currency, To: currency, Value: 1}
+	if req.Currency != "" && money.NormalizeCurrency(req.Currency) != currency {
+		if c.fx == nil {
+			return nil, fmt.Errorf("%w: shipping rates are in %s, not %s", fx.ErrUnsupportedCurrency, currency, req.Currency)
+		}
+		rate, err := c.fx.Rate(ctx, currency, req.Currency)
+		if err != nil {
+			return nil, err
+		}
+		exchange = rate
+	}
+
+	zone := ""
+	if method.RequiresAddress {
+		if req.Destination == nil || strings.TrimSpace(req.Destination.State) == "" {
+			return nil, ErrDestinationRequired
+		}
+		zone = c.zone(req.Destination.State)
+	}
+
+	items, weight := c.totals(req.Lines)
+	if method.MaxItems > 0 && items > method.MaxItems {
+		return nil, ErrMethodUnavailable
+	}
+
+	rule, ok := matchRule(method.Rules, zone, weight)
+	if !ok {
+		return nil, ErrMethodUnavailable
+	}
+
+	quote := &Quote{
+		Method:        method.Code,
+		Name:          method.Name,
+		Zone:          zone,
+		EstimatedDays: method.EstimatedDays,
+		TableVersion:  c.table.Version,
+		Currency:      exchange.To,
+	}
+	if method.FreeOver.IsPositive() {
+		below, err := req.Subtotal.LessThan(exchange.Convert(method.FreeOver))
+		if err != nil {
+			return nil, err
+		}
+		if !below {
+			quote.Amount = money.Zero(exchange.To)
+			quote.Free = true
+			return quote, nil
+		}
+	}
+
+	amount, err := money.Sum(currency, rule.Base, rule.PerItem.Mul(int64(items)), rule.PerPound.MulRate(weight))
+	if err != nil {
+		return nil, err
+	}
+	quote.Amount = exchange.Convert(amount)
+	quote.Free = quote.Amount.IsZero()
+	return quote, nil
+}
+
+func (c *TableCalculator) method(code string) (Method, bool) {
+	for _, m := range c.table.Methods {
+		if strings.EqualFold(m.Code, strings.TrimSpace(code)) {
+			return m, true
+		}
+	}
+	return Method{}, false
+}
+
+// zone returns the first zone listing state, or the first catch-all zone.
+func (c *TableCalculator) zone(state string) string {
+	state = strings.TrimSpace(state)
+	for _, z := range c.table.Zones {
+		if len(z.States) == 0 {
+			return z.Code
+		}
+		for _, s := range z.States {
+			if strings.EqualFold(s, state) {
+				return z.Code
+			}
+		}
+	}
+	return ""
+}
+
+func (c *TableCalculator) totals(lines []Line) (int, float64) {
+	items := 0
+	weight := 0.0
+	for _, l := range lines {
+		w := l.Weight
+		if w == 0 {
+			w = c.table.DefaultItemWeight
+		}
+		items += l.Quantity
+		weight += w * float64(l.Quantity)
+	}
+	return items, weight
+}
+
+// matchRule returns the first rule for zone whose weight band contains weight.
+func matchRule(rules []RateRule, zone string, weight float64) (RateRule, bool) {
+	for _, r := range rules {
+		if r.Zone != "" && r.Zone != zone {
+			continue
+		}
+		if weight < r.MinWeight {
+			continue
+		}
+		if r.MaxWeight != 0 && weight > r.MaxWeight {
+			continue
+		}
+		return r, true
+	}
+	return RateRule{}, false
+}
+
+var _ Calculator = (*TableCalculator)(nil)
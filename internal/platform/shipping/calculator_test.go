@@ -0,0 +1,173 @@
+package shipping
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go-shopping-poc/internal/platform/fx"
+	"go-shopping-poc/internal/platform/money"
+)
+
+func newDefaultCalculator(t *testing.T) *TableCalculator {
+	t.Helper()
+
+	table, err := DefaultRateTable()
+	if err != nil {
+		t.Fatalf("failed to load default table: %v", err)
+	}
+	return NewTableCalculator(table)
+}
+
+func lines(quantities ...int) []Line {
+	out := make([]Line, len(quantities))
+	for i, q := range quantities {
+		out[i] = Line{Quantity: q}
+	}
+	return out
+}
+
+func TestRateByWeightBand(t *testing.T) {
+	t.Parallel()
+
+	calc := newDefaultCalculator(t)
+	dest := &Destination{State: "TX", Zip: "73301"}
+
+	tests := []struct {
+		name  string
+		lines []Line
+		want  string
+	}{
+		{name: "light", lines: lines(2), want: "5.99 USD"},
+		{name: "medium", lines: lines(4, 6), want: "11.49 USD"},
+		{name: "heavy", lines: []Line{{Quantity: 2, Weight: 15}}, want: "29.99 USD"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			q, err := calc.Rate(context.Background(), "standard", Request{Destination: dest, Subtotal: money.MustParse("20", ""), Lines: tt.lines})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if q.Amount.Format() != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, q.Amount.Format())
+			}
+			if q.Zone != "continental" {
+				t.Errorf("expected continental zone, got %q", q.Zone)
+			}
+		})
+	}
+}
+
+func TestRateFreeOverThreshold(t *testing.T) {
+	t.Parallel()
+
+	q, err := newDefaultCalculator(t).Rate(context.Background(), "standard", Request{
+		Destination: &Destination{State: "NY"},
+		Subtotal:    money.MustParse("75", ""),
+		Lines:       lines(1),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !q.Free || !q.Amount.IsZero() {
+		t.Errorf("expected free shipping, got %+v", q)
+	}
+}
+
+func TestRateConvertsCurrency(t *testing.T) {
+	t.Parallel()
+
+	table, err := DefaultRateTable()
+	if err != nil {
+		t.Fatalf("failed to load default table: %v", err)
+	}
+	rates, err := fx.DefaultRateTable()
+	if err != nil {
+		t.Fatalf("failed to load exchange rates: %v", err)
+	}
+	dest := &Destination{State: "NY"}
+
+	if _, err := NewTableCalculator(table).Rate(context.Background(), "standard", Request{Currency: "EUR", Destination: dest, Lines: lines(1)}); !errors.Is(err, fx.ErrUnsupportedCurrency) {
+		t.Fatalf("expected ErrUnsupportedCurrency without an exchange, got %v", err)
+	}
+
+	calc := NewTableCalculator(table, WithExchange(fx.NewStaticProvider(rates)))
+	q, err := calc.Rate(context.Background(), "standard", Request{Currency: "EUR", Destination: dest, Subtotal: money.MustParse("20", "EUR"), Lines: lines(1)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if q.Amount.Format() != "5.53 EUR" || q.Currency != "EUR" {
+		t.Errorf("expected 5.53 EUR, got %s %s", q.Amount.Format(), q.Currency)
+	}
+
+	// 75 EUR is over the 75 USD threshold once converted
+	q, err = calc.Rate(context.Background(), "standard", Request{Currency: "EUR", Destination: dest, Subtotal: money.MustParse("75", "EUR"), Lines: lines(1)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !q.Free {
+		t.Errorf("expected free shipping, got %+v", q)
+	}
+}
+
+func TestRateByZoneAndItemCount(t *testing.T) {
+	t.Parallel()
+
+	q, err := newDefaultCalculator(t).Rate(context.Background(), "express", Request{
+		Destination: &Destination{State: "ca"},
+		Subtotal:    money.MustParse("200", ""),
+		Lines:       lines(3),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if q.Zone != "west" || q.Amount.String() != "19.49" {
+		t.Errorf("expected west express 19.49, got %+v", q)
+	}
+}
+
+func TestRateErrors(t *testing.T) {
+	t.Parallel()
+
+	calc := newDefaultCalculator(t)
+	ctx := context.Background()
+
+	if _, err := calc.Rate(ctx, "drone", Request{}); !errors.Is(err, ErrUnknownMethod) {
+		t.Errorf("expected ErrUnknownMethod, got %v", err)
+	}
+	if _, err := calc.Rate(ctx, "standard", Request{Lines: lines(1)}); !errors.Is(err, ErrDestinationRequired) {
+		t.Errorf("expected ErrDestinationRequired, got %v", err)
+	}
+	if _, err := calc.Rate(ctx, "express", Request{Destination: &Destination{State: "HI"}, Lines: lines(1)}); !errors.Is(err, ErrMethodUnavailable) {
+		t.Errorf("expected express to be unavailable for remote zone, got %v", err)
+	}
+	if _, err := calc.Rate(ctx, "pickup", Request{Lines: lines(30)}); !errors.Is(err, ErrMethodUnavailable) {
+		t.Errorf("expected pickup item limit, got %v", err)
+	}
+}
+
+func TestQuoteSkipsUnavailableMethods(t *testing.T) {
+	t.Parallel()
+
+	quotes, err := newDefaultCalculator(t).Quote(context.Background(), Request{Lines: lines(1)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(quotes) != 1 || quotes[0].Method != "pickup" || !quotes[0].Free {
+		t.Errorf("expected only free pickup without an address, got %+v", quotes)
+	}
+}
+
+func TestParseRateTableJSONValidates(t *testing.T) {
+	t.Parallel()
+
+	if _, err := ParseRateTableJSON([]byte(`{"version":"x","methods":[{"code":"a","rules":[]}]}`)); err == nil {
+		t.Error("expected method without rules to fail")
+	}
+	if _, err := ParseRateTableJSON([]byte(`{"version":"x","methods":[{"code":"a","rules":[{"base":1}]},{"code":"a","rules":[{"base":1}]}]}`)); err == nil {
+		t.Error("expected duplicate method to fail")
+	}
+}
@@ -0,0 +1,16 @@
+// Package shipping prices delivery methods for carts.
+//
+// A Calculator offers a set of methods (standard, express, pickup by default)
+// and prices each one from rate rules keyed by destination zone and total
+// weight. A rule charges a base amount plus per-item and per-pound amounts;
+// a method may waive its charge above a subtotal threshold (free shipping).
+//
+// TableCalculator is the default implementation. It reads a versioned
+// RateTable from JSON (see LoadRateTable); when no path is configured the
+// embedded rates/default.json table is used. Lines carry the weight their
+// product reports; lines without one use the table's default_item_weight_lb.
+//
+// Table amounts are in the table's currency (USD by default). With
+// WithExchange, requests in other currencies are priced by converting the
+// free shipping threshold and the charge out of the table currency.
+package shipping
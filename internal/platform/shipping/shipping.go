@@ -0,0 +1,64 @@
+package shipping
+
+import (
+	"context"
+	"errors"
+
+	"go-shopping-poc/internal/platform/money"
+)
+
+var (
+	// ErrUnknownMethod is returned for a method code the table does not offer.
+	ErrUnknownMethod = errors.New("unknown shipping method")
+	// ErrDestinationRequired is returned when a method needs a shipping address.
+	ErrDestinationRequired = errors.New("shipping address required for this shipping method")
+	// ErrMethodUnavailable is returned when no rate rule covers the shipment.
+	ErrMethodUnavailable = errors.New("shipping method not available for this cart")
+	// ErrInvalidRateTable is returned when a rate table cannot be used.
+	ErrInvalidRateTable = errors.New("invalid shipping rate table")
+)
+
+// Calculator prices shipping methods.
+type Calculator interface {
+	// Methods lists every method offered, available or not.
+	Methods() []Method
+	// Quote prices every method available for the request.
+	Quote(ctx context.Context, req Request) ([]Quote, error)
+	// Rate prices a single method. It returns ErrUnknownMethod,
+	// ErrDestinationRequired or ErrMethodUnavailable when it cannot be used.
+	Rate(ctx context.Context, method string, req Request) (*Quote, error)
+}
+
+// Destination is the ship-to location.
+type Destination struct {
+	State string
+	Zip   string
+}
+
+// Line is a shipped cart line. A zero Weight uses the table default.
+type Line struct {
+	Quantity int
+	Weight   float64
+}
+
+// Request is the input to Calculator.Quote and Calculator.Rate. Destination is
+// nil when no shipping address is known. Subtotal is in Currency; an empty
+// Currency uses the table's.
+type Request struct {
+	Currency    string
+	Destination *Destination
+	Subtotal    money.Money
+	Lines       []Line
+}
+
+// Quote is the price of a method for a request.
+type Quote struct {
+	Method        string      `json:"method"`
+	Name          string      `json:"name"`
+	Amount        money.Money `json:"amount"`
+	Free          bool        `json:"free"`
+	Zone          string      `json:"zone,omitempty"`
+	EstimatedDays int         `json:"estimated_days,omitempty"`
+	Currency      string      `json:"currency"`
+	TableVersion  string      `json:"table_version"`
+}
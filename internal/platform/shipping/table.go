@@ -0,0 +1,134 @@
+package shipping
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"go-shopping-poc/internal/platform/money"
+)
+
+//go:embed rates/default.json
+var defaultRates embed.FS
+
+// Zone groups destination states. A zone with no states matches any
+// destination and should be listed last.
+type Zone struct {
+	Code   string   `json:"code"`
+	States []string `json:"states,omitempty"`
+}
+
+// RateRule prices a shipment in a zone within a weight band. An empty Zone
+// matches every zone; a zero MaxWeight has no upper bound.
+type RateRule struct {
+	Zone      string      `json:"zone,omitempty"`
+	MinWeight float64     `json:"min_weight_lb,omitempty"`
+	MaxWeight float64     `json:"max_weight_lb,omitempty"`
+	Base      money.Money `json:"base"`
+	PerItem   money.Money `json:"per_item,omitzero"`
+	PerPound  money.Money `json:"per_lb,omitzero"`
+}
+
+// Method is a delivery option and its rate rules.
+type Method struct {
+	Code            string      `json:"code"`
+	Name            string      `json:"name"`
+	EstimatedDays   int         `json:"estimated_days,omitempty"`
+	RequiresAddress bool        `json:"requires_address"`
+	FreeOver        money.Money `json:"free_over,omitzero"`
+	MaxItems        int         `json:"max_items,omitempty"`
+	Rules           []RateRule  `json:"rules"`
+}
+
+// RateTable is a versioned set of shipping methods. Amounts are in Currency,
+// USD when unset.
+type RateTable struct {
+	Version           string   `json:"version"`
+	EffectiveDate     string   `json:"effective_date,omitempty"`
+	Currency          string   `json:"currency,omitempty"`
+	DefaultItemWeight float64  `json:"default_item_weight_lb"`
+	Zones             []Zone   `json:"zones"`
+	Methods           []Method `json:"methods"`
+}
+
+// Validate checks the table is usable.
+func (t *RateTable) Validate() error {
+	if t.Version == "" {
+		return fmt.Errorf("%w: version is required", ErrInvalidRateTable)
+	}
+	if t.Currency != "" && !money.ValidCurrency(t.Currency) {
+		return fmt.Errorf("%w: invalid currency %q", ErrInvalidRateTable, t.Currency)
+	}
+	if t.DefaultItemWeight < 0 {
+		return fmt.Errorf("%w: default_item_weight_lb cannot be negative", ErrInvalidRateTable)
+	}
+	if len(t.Methods) == 0 {
+		return fmt.Errorf("%w: at least one method is required", ErrInvalidRateTable)
+	}
+
+	seen := make(map[string]bool, len(t.Methods))
+	for _, m := range t.Methods {
+		if m.Code == "" {
+			return fmt.Errorf("%w: method code is required", ErrInvalidRateTable)
+		}
+		if seen[m.Code] {
+			return fmt.Errorf("%w: duplicate method %q", ErrInvalidRateTable, m.Code)
+		}
+		seen[m.Code] = true
+		if len(m.Rules) == 0 {
+			return fmt.Errorf("%w: method %q has no rules", ErrInvalidRateTable, m.Code)
+		}
+		for _, r := range m.Rules {
+			if r.Base.IsNegative() || r.PerItem.IsNegative() || r.PerPound.IsNegative() {
+				return fmt.Errorf("%w: method %q has a negative rate", ErrInvalidRateTable, m.Code)
+			}
+			if r.MaxWeight != 0 && r.MaxWeight < r.MinWeight {
+				return fmt.Errorf("%w: method %q has an empty weight band", ErrInvalidRateTable, m.Code)
+			}
+		}
+	}
+	return nil
+}
+
+// CurrencyCode returns the currency the table's amounts are in.
+func (t *RateTable) CurrencyCode() string {
+	if t.Currency == "" {
+		return "USD"
+	}
+	return money.NormalizeCurrency(t.Currency)
+}
+
+// DefaultRateTable returns the embedded rate table.
+func DefaultRateTable() (*RateTable, error) {
+	data, err := defaultRates.ReadFile("rates/default.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read default shipping rates: %w", err)
+	}
+	return ParseRateTableJSON(data)
+}
+
+// LoadRateTable reads a JSON rate table. An empty path returns the embedded
+// default table.
+func LoadRateTable(path string) (*RateTable, error) {
+	if path == "" {
+		return DefaultRateTable()
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read shipping rate table: %w", err)
+	}
+	return ParseRateTableJSON(data)
+}
+
+// ParseRateTableJSON decodes and validates a JSON rate table.
+func ParseRateTableJSON(data []byte) (*RateTable, error) {
+	var table RateTable
+	if err := json.Unmarshal(data, &table); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidRateTable, err)
+	}
+	if err := table.Validate(); err != nil {
+		return nil, err
+	}
+	return &table, nil
+}
@@ -0,0 +1,133 @@
+package tax
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go-shopping-poc/internal/platform/money"
+)
+
+// TableCalculator implements TaxCalculator over a RateTable.
+type TableCalculator struct {
+	table *RateTable
+}
+
+// NewTableCalculator creates a calculator for table.
+func NewTableCalculator(table *RateTable) *TableCalculator {
+	return &TableCalculator{table: table}
+}
+
+// Version returns the rate table version.
+func (c *TableCalculator) Version() string {
+	return c.table.Version
+}
+
+// TaxCode implements TaxCalculator. Unknown categories use the table's
+// default tax code.
+func (c *TableCalculator) TaxCode(category string) string {
+	for name, code := range c.table.Categories {
+		if strings.EqualFold(name, strings.TrimSpace(category)) {
+			return code
+		}
+	}
+	return c.table.DefaultTaxCode
+}
+
+// Calculate implements TaxCalculator. Each applied rate is rounded to the
+// minor unit of the request currency, half to even.
+func (c *TableCalculator) Calculate(_ context.Context, req Request) (*Result, error) {
+	result := &Result{
+		Version: c.table.Version,
+		Lines:   make([]LineResult, 0, len(req.Lines)),
+		Total:   money.Zero(req.Currency),
+	}
+
+	var rates []Rate
+	if req.Destination == nil || strings.TrimSpace(req.Destination.State) == "" {
+		result.Estimated = true
+	} else {
+		rates = c.matchDestination(*req.Destination)
+	}
+
+	for _, line := range req.Lines {
+		code := line.TaxCode
+		if code == "" {
+			code = c.table.DefaultTaxCode
+		}
+
+		lr := LineResult{LineNumber: line.LineNumber, TaxCode: code, Taxable: line.Amount}
+		if result.Estimated {
+			lr.Breakdown = Breakdown{c.applied(Rate{Jurisdiction: EstimateJurisdiction, Rate: c.table.EstimateRate}, code, line.Amount)}
+		} else {
+			lr.Breakdown = c.applyRates(rates, code, line.Amount)
+		}
+
+		var err error
+		if lr.Tax, err = lr.Breakdown.Total(req.Currency); err != nil {
+			return nil, fmt.Errorf("line %s: %w", line.LineNumber, err)
+		}
+		if result.Total, err = result.Total.Add(lr.Tax); err != nil {
+			return nil, fmt.Errorf("line %s: %w", line.LineNumber, err)
+		}
+		result.Lines = append(result.Lines, lr)
+	}
+	return result, nil
+}
+
+// matchDestination returns rows for the destination state whose zip prefix
+// matches, in table order.
+func (c *TableCalculator) matchDestination(dest Destination) []Rate {
+	state := strings.TrimSpace(dest.State)
+	zip := strings.TrimSpace(dest.Zip)
+
+	var matched []Rate
+	for _, r := range c.table.Rates {
+		if !strings.EqualFold(r.State, state) {
+			continue
+		}
+		if r.ZipPrefix != "" && !strings.HasPrefix(zip, r.ZipPrefix) {
+			continue
+		}
+		matched = append(matched, r)
+	}
+	return matched
+}
+
+// applyRates picks one row per jurisdiction: the row for code when present,
+// otherwise the jurisdiction's general row.
+func (c *TableCalculator) applyRates(rates []Rate, code string, amount money.Money) Breakdown {
+	var order []string
+	chosen := make(map[string]Rate)
+	for _, r := range rates {
+		if r.TaxCode != "" && r.TaxCode != code {
+			continue
+		}
+		current, seen := chosen[r.Jurisdiction]
+		if !seen {
+			order = append(order, r.Jurisdiction)
+		}
+		if !seen || (current.TaxCode == "" && r.TaxCode == code) {
+			chosen[r.Jurisdiction] = r
+		}
+	}
+
+	breakdown := make(Breakdown, 0, len(order))
+	for _, j := range order {
+		breakdown = append(breakdown, c.applied(chosen[j], code, amount))
+	}
+	return breakdown
+}
+
+func (c *TableCalculator) applied(r Rate, code string, amount money.Money) AppliedRate {
+	return AppliedRate{
+		Jurisdiction: r.Jurisdiction,
+		Name:         r.Name,
+		TaxCode:      code,
+		Rate:         r.Rate,
+		Amount:       amount.MulRate(r.Rate),
+		TableVersion: c.table.Version,
+	}
+}
+
+var _ TaxCalculator = (*TableCalculator)(nil)
@@ -0,0 +1,192 @@
+package tax
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go-shopping-poc/internal/platform/money"
+)
+
+func newDefaultCalculator(t *testing.T) *TableCalculator {
+	t.Helper()
+
+	table, err := DefaultRateTable()
+	if err != nil {
+		t.Fatalf("failed to load default table: %v", err)
+	}
+	return NewTableCalculator(table)
+}
+
+func TestCalculateStackedJurisdictions(t *testing.T) {
+	t.Parallel()
+
+	calc := newDefaultCalculator(t)
+	result, err := calc.Calculate(context.Background(), Request{
+		Currency:    "USD",
+		Destination: &Destination{State: "ca", Zip: "90012"},
+		Lines: []Line{
+			{LineNumber: "001", TaxCode: "general", Amount: money.MustParse("100", "USD")},
+			{LineNumber: "002", TaxCode: "food", Amount: money.MustParse("50", "USD")},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	general, _ := result.Line("001")
+	if general.Tax.String() != "9.75" {
+		t.Errorf("expected state + county tax of 9.75, got %v", general.Tax)
+	}
+	if len(general.Breakdown) != 2 || general.Breakdown[0].Jurisdiction != "US-CA" || general.Breakdown[1].Jurisdiction != "US-CA-LA" {
+		t.Errorf("unexpected breakdown: %+v", general.Breakdown)
+	}
+	if general.Breakdown[0].TableVersion != calc.Version() {
+		t.Errorf("expected table version %q on applied rates, got %q", calc.Version(), general.Breakdown[0].TableVersion)
+	}
+
+	food, _ := result.Line("002")
+	if !food.Tax.IsZero() {
+		t.Errorf("expected food to be exempt, got %v", food.Tax)
+	}
+	if !result.Total.Equal(money.MustParse("9.75", "USD")) || result.Estimated {
+		t.Errorf("unexpected result total=%v estimated=%v", result.Total, result.Estimated)
+	}
+}
+
+func TestCalculateOutsideZipPrefix(t *testing.T) {
+	t.Parallel()
+
+	result, err := newDefaultCalculator(t).Calculate(context.Background(), Request{
+		Destination: &Destination{State: "CA", Zip: "94105"},
+		Lines:       []Line{{LineNumber: "001", Amount: money.MustParse("100", "")}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Total.String() != "7.25" {
+		t.Errorf("expected state rate only, got %v", result.Total)
+	}
+	if result.Lines[0].TaxCode != "general" {
+		t.Errorf("expected default tax code, got %q", result.Lines[0].TaxCode)
+	}
+}
+
+func TestCalculateEstimateWithoutDestination(t *testing.T) {
+	t.Parallel()
+
+	result, err := newDefaultCalculator(t).Calculate(context.Background(), Request{
+		Lines: []Line{{LineNumber: "001", Amount: money.MustParse("33.33", "")}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Estimated {
+		t.Error("expected estimated result without destination")
+	}
+	if result.Total.String() != "1.00" {
+		t.Errorf("expected rounded estimate of 1.00, got %v", result.Total)
+	}
+	if result.Lines[0].Breakdown[0].Jurisdiction != EstimateJurisdiction {
+		t.Errorf("unexpected breakdown: %+v", result.Lines[0].Breakdown)
+	}
+}
+
+func TestCalculateUnknownStateHasNoTax(t *testing.T) {
+	t.Parallel()
+
+	result, err := newDefaultCalculator(t).Calculate(context.Background(), Request{
+		Destination: &Destination{State: "MT", Zip: "59001"},
+		Lines:       []Line{{LineNumber: "001", Amount: money.MustParse("100", "")}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Total.IsZero() || len(result.Lines[0].Breakdown) != 0 {
+		t.Errorf("expected no tax, got %+v", result)
+	}
+}
+
+func TestTaxCode(t *testing.T) {
+	t.Parallel()
+
+	calc := newDefaultCalculator(t)
+	if got := calc.TaxCode("clothing"); got != "apparel" {
+		t.Errorf("expected apparel, got %q", got)
+	}
+	if got := calc.TaxCode("Electronics"); got != "general" {
+		t.Errorf("expected default code, got %q", got)
+	}
+}
+
+func TestLoadRateTableCSV(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "rates.csv")
+	data := "version,jurisdiction,state,zip_prefix,tax_code,rate\n" +
+		"2026.2,US-NV,NV,,,0.0685\n" +
+		"2026.2,US-NV-CLARK,NV,891,,0.0153\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	table, err := LoadRateTable(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if table.Version != "2026.2" || len(table.Rates) != 2 {
+		t.Fatalf("unexpected table: %+v", table)
+	}
+
+	result, _ := NewTableCalculator(table).Calculate(context.Background(), Request{
+		Destination: &Destination{State: "NV", Zip: "89101"},
+		Lines:       []Line{{LineNumber: "001", Amount: money.MustParse("200", "")}},
+	})
+	if result.Total.String() != "16.76" {
+		t.Errorf("expected 16.76, got %v", result.Total)
+	}
+}
+
+func TestLoadRateTableRejectsMixedVersions(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "rates.csv")
+	data := "version,jurisdiction,state,rate\n2026.1,US-NV,NV,0.0685\n2026.2,US-UT,UT,0.0485\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadRateTable(path); err == nil {
+		t.Error("expected mixed versions to be rejected")
+	}
+}
+
+func TestParseRateTableJSONValidates(t *testing.T) {
+	t.Parallel()
+
+	if _, err := ParseRateTableJSON([]byte(`{"rates":[]}`)); err == nil {
+		t.Error("expected missing version to fail")
+	}
+	if _, err := ParseRateTableJSON([]byte(`{"version":"x","rates":[{"jurisdiction":"A","state":"CA","rate":7.25}]}`)); err == nil {
+		t.Error("expected percentage-style rate to fail")
+	}
+}
+
+func TestBreakdownScan(t *testing.T) {
+	t.Parallel()
+
+	in := Breakdown{{Jurisdiction: "US-TX", TaxCode: "general", Rate: 0.0625, Amount: money.MustParse("6.25", ""), TableVersion: "2026.1"}}
+	value, err := in.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out Breakdown
+	if err := out.Scan(value); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(out) != 1 || out[0] != in[0] {
+		t.Errorf("round trip mismatch: %+v", out)
+	}
+}
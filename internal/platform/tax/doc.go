@@ -0,0 +1,16 @@
+// Package tax computes sales tax for cart and order lines.
+//
+// A TaxCalculator prices each line separately using the shipping destination
+// (state and zip) and the line's tax code. Tax codes are resolved from product
+// categories by the rate table, so apparel or groceries can carry different
+// rates from general merchandise.
+//
+// TableCalculator is the default implementation. It reads jurisdiction rates
+// from a versioned RateTable loaded from JSON or CSV (see LoadRateTable). When
+// no path is configured the embedded rates/default.json table is used. Every
+// applied rate is reported in a Breakdown that records the jurisdiction, rate
+// and table version so orders show exactly which rates were charged.
+//
+// Until a shipping address is known the calculator returns an estimate using
+// the table's estimate_rate.
+package tax
@@ -0,0 +1,140 @@
+package tax
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go-shopping-poc/internal/platform/csv"
+)
+
+//go:embed rates/default.json
+var defaultRates embed.FS
+
+// Rate is one jurisdiction rate row. A row with an empty TaxCode applies to
+// every code that has no row of its own in the same jurisdiction; an empty
+// ZipPrefix covers the whole state.
+type Rate struct {
+	Jurisdiction string  `json:"jurisdiction"`
+	Name         string  `json:"name,omitempty"`
+	State        string  `json:"state"`
+	ZipPrefix    string  `json:"zip_prefix,omitempty"`
+	TaxCode      string  `json:"tax_code,omitempty"`
+	Rate         float64 `json:"rate"`
+}
+
+// RateTable is a versioned set of jurisdiction rates.
+type RateTable struct {
+	Version        string            `json:"version"`
+	EffectiveDate  string            `json:"effective_date,omitempty"`
+	DefaultTaxCode string            `json:"default_tax_code"`
+	EstimateRate   float64           `json:"estimate_rate"`
+	Categories     map[string]string `json:"categories,omitempty"`
+	Rates          []Rate            `json:"rates"`
+}
+
+// csvRate is a CSV row; the version is repeated on every row.
+type csvRate struct {
+	Version      string  `csv:"version"`
+	Jurisdiction string  `csv:"jurisdiction"`
+	Name         string  `csv:"name,optional"`
+	State        string  `csv:"state"`
+	ZipPrefix    string  `csv:"zip_prefix,optional"`
+	TaxCode      string  `csv:"tax_code,optional"`
+	Rate         float64 `csv:"rate"`
+}
+
+// Validate checks the table is usable.
+func (t *RateTable) Validate() error {
+	if t.Version == "" {
+		return fmt.Errorf("%w: version is required", ErrInvalidRateTable)
+	}
+	if t.EstimateRate < 0 || t.EstimateRate >= 1 {
+		return fmt.Errorf("%w: estimate_rate must be between 0 and 1", ErrInvalidRateTable)
+	}
+	for i, r := range t.Rates {
+		if r.Jurisdiction == "" || r.State == "" {
+			return fmt.Errorf("%w: rate %d needs jurisdiction and state", ErrInvalidRateTable, i)
+		}
+		if r.Rate < 0 || r.Rate >= 1 {
+			return fmt.Errorf("%w: rate %d (%s) must be between 0 and 1", ErrInvalidRateTable, i, r.Jurisdiction)
+		}
+	}
+	return nil
+}
+
+// DefaultRateTable returns the embedded rate table.
+func DefaultRateTable() (*RateTable, error) {
+	data, err := defaultRates.ReadFile("rates/default.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read default tax rates: %w", err)
+	}
+	return ParseRateTableJSON(data)
+}
+
+// LoadRateTable reads a rate table from a .json or .csv file. An empty path
+// returns the embedded default table.
+func LoadRateTable(path string) (*RateTable, error) {
+	if path == "" {
+		return DefaultRateTable()
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tax rate table: %w", err)
+		}
+		return ParseRateTableJSON(data)
+	case ".csv":
+		return loadRateTableCSV(path)
+	default:
+		return nil, fmt.Errorf("%w: unsupported file type %q", ErrInvalidRateTable, filepath.Ext(path))
+	}
+}
+
+// ParseRateTableJSON decodes and validates a JSON rate table.
+func ParseRateTableJSON(data []byte) (*RateTable, error) {
+	var table RateTable
+	if err := json.Unmarshal(data, &table); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidRateTable, err)
+	}
+	if err := table.Validate(); err != nil {
+		return nil, err
+	}
+	return &table, nil
+}
+
+// loadRateTableCSV reads rate rows from CSV. CSV tables carry rates only:
+// every product uses the default tax code and no estimate rate is applied.
+func loadRateTableCSV(path string) (*RateTable, error) {
+	rows, err := csv.NewParser[csvRate](path).Parse()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidRateTable, err)
+	}
+
+	table := &RateTable{Rates: make([]Rate, 0, len(rows))}
+	for i, row := range rows {
+		if table.Version == "" {
+			table.Version = row.Version
+		} else if row.Version != table.Version {
+			return nil, fmt.Errorf("%w: row %d has version %q, expected %q", ErrInvalidRateTable, i+1, row.Version, table.Version)
+		}
+		table.Rates = append(table.Rates, Rate{
+			Jurisdiction: row.Jurisdiction,
+			Name:         row.Name,
+			State:        row.State,
+			ZipPrefix:    row.ZipPrefix,
+			TaxCode:      row.TaxCode,
+			Rate:         row.Rate,
+		})
+	}
+
+	if err := table.Validate(); err != nil {
+		return nil, err
+	}
+	return table, nil
+}
@@ -0,0 +1,123 @@
+package tax
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"go-shopping-poc/internal/platform/money"
+)
+
+// EstimateJurisdiction names the applied rate used when no destination is known.
+const EstimateJurisdiction = "ESTIMATE"
+
+// ErrInvalidRateTable is returned when a rate table cannot be used.
+var ErrInvalidRateTable = errors.New("invalid tax rate table")
+
+// TaxCalculator computes per-line tax.
+type TaxCalculator interface {
+	// TaxCode resolves the tax code for a product category.
+	TaxCode(category string) string
+	// Calculate returns the tax for every line in the request.
+	Calculate(ctx context.Context, req Request) (*Result, error)
+}
+
+// Destination is the ship-to location used to select jurisdictions.
+type Destination struct {
+	State string
+	Zip   string
+}
+
+// Line is a taxable amount for one cart or order line.
+type Line struct {
+	LineNumber string
+	ProductID  string
+	TaxCode    string
+	Amount     money.Money
+}
+
+// Request is the input to TaxCalculator.Calculate. Destination is nil when
+// no shipping address is known.
+type Request struct {
+	Currency    string
+	Destination *Destination
+	Lines       []Line
+}
+
+// AppliedRate is a single jurisdiction rate charged on a line.
+type AppliedRate struct {
+	Jurisdiction string      `json:"jurisdiction"`
+	Name         string      `json:"name,omitempty"`
+	TaxCode      string      `json:"tax_code"`
+	Rate         float64     `json:"rate"`
+	Amount       money.Money `json:"amount"`
+	TableVersion string      `json:"table_version"`
+}
+
+// Breakdown lists the rates applied to a line. It is stored as JSONB.
+type Breakdown []AppliedRate
+
+// Value implements driver.Valuer.
+func (b Breakdown) Value() (driver.Value, error) {
+	if b == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode tax breakdown: %w", err)
+	}
+	return data, nil
+}
+
+// Scan implements sql.Scanner.
+func (b *Breakdown) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*b = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, b)
+	case string:
+		return json.Unmarshal([]byte(v), b)
+	default:
+		return fmt.Errorf("cannot scan %T into tax breakdown", src)
+	}
+}
+
+// Total sums the applied amounts in currency.
+func (b Breakdown) Total(currency string) (money.Money, error) {
+	amounts := make([]money.Money, len(b))
+	for i, r := range b {
+		amounts[i] = r.Amount
+	}
+	return money.Sum(currency, amounts...)
+}
+
+// LineResult is the tax computed for one line.
+type LineResult struct {
+	LineNumber string
+	TaxCode    string
+	Taxable    money.Money
+	Tax        money.Money
+	Breakdown  Breakdown
+}
+
+// Result is the output of TaxCalculator.Calculate.
+type Result struct {
+	Version   string
+	Estimated bool
+	Lines     []LineResult
+	Total     money.Money
+}
+
+// Line returns the result for lineNumber.
+func (r *Result) Line(lineNumber string) (LineResult, bool) {
+	for _, l := range r.Lines {
+		if l.LineNumber == lineNumber {
+			return l, true
+		}
+	}
+	return LineResult{}, false
+}
@@ -0,0 +1,101 @@
+package cart
+
+import (
+	"strings"
+
+	platformerrors "go-shopping-poc/internal/platform/errors"
+)
+
+// A cart line carries the variant options the shopper selected: a size and
+// color, checked against the options the product is offered in, and a gift
+// message. Lines of one product in different sizes or colors are separate
+// lines; the options travel with the checkout snapshot into the order.
+
+// maxGiftMessageLength bounds the gift message of a line.
+const maxGiftMessageLength = 250
+
+// maxAttributeLength bounds the size and color of a line.
+const maxAttributeLength = 100
+
+// LineAttributes are the variant options selected for a cart line.
+type LineAttributes struct {
+	Size        string `json:"size,omitempty" db:"size"`
+	Color       string `json:"color,omitempty" db:"color"`
+	GiftMessage string `json:"gift_message,omitempty" db:"gift_message"`
+}
+
+// SameVariant reports whether a and b select the same variant of a
+// product. Gift messages do not make a different variant.
+func (a LineAttributes) SameVariant(b LineAttributes) bool {
+	return strings.EqualFold(a.Size, b.Size) && strings.EqualFold(a.Color, b.Color)
+}
+
+// variantKey identifies the product variant of a line.
+func (ci *CartItem) variantKey() string {
+	return ci.ProductID + "\x00" + strings.ToLower(ci.Size) + "\x00" + strings.ToLower(ci.Color)
+}
+
+// Normalize trims the attributes and checks their length.
+func (a *LineAttributes) Normalize() error {
+	a.Size = strings.TrimSpace(a.Size)
+	a.Color = strings.TrimSpace(a.Color)
+	a.GiftMessage = strings.TrimSpace(a.GiftMessage)
+	if len(a.Size) > maxAttributeLength {
+		return platformerrors.NewValidationError("size", "size is too long")
+	}
+	if len(a.Color) > maxAttributeLength {
+		return platformerrors.NewValidationError("color", "color is too long")
+	}
+	if len(a.GiftMessage) > maxGiftMessageLength {
+		return platformerrors.NewValidationError("gift_message", "gift_message is too long")
+	}
+	return nil
+}
+
+// ResolveVariant checks the size and color against the options of the
+// product and sets them to the catalog spelling. A product offered in a
+// single size or color has it selected when none is given. Entries cached
+// before products published their options accept any size and color.
+func (a *LineAttributes) ResolveVariant(entry ProductEntry) error {
+	if !entry.VariantsKnown {
+		return nil
+	}
+	size, err := resolveOption("size", a.Size, entry.Sizes)
+	if err != nil {
+		return err
+	}
+	var colors []string
+	if entry.Color != "" {
+		colors = []string{entry.Color}
+	}
+	color, err := resolveOption("color", a.Color, colors)
+	if err != nil {
+		return err
+	}
+	a.Size, a.Color = size, color
+	return nil
+}
+
+// resolveOption returns the option of options matching selected, ignoring
+// case.
+func resolveOption(field, selected string, options []string) (string, error) {
+	if selected == "" {
+		switch len(options) {
+		case 0:
+			return "", nil
+		case 1:
+			return options[0], nil
+		default:
+			return "", platformerrors.NewValidationError(field, field+" is required")
+		}
+	}
+	for _, option := range options {
+		if strings.EqualFold(option, selected) {
+			return option, nil
+		}
+	}
+	if len(options) == 0 {
+		return "", platformerrors.NewValidationError(field, "product has no "+field+" options")
+	}
+	return "", platformerrors.NewValidationError(field, field+" must be one of: "+strings.Join(options, ", "))
+}
@@ -2,8 +2,37 @@ package cart
 
 import (
 	"errors"
+	"fmt"
+	"time"
 
 	"go-shopping-poc/internal/platform/config"
+	"go-shopping-poc/internal/platform/ratelimit"
+)
+
+var (
+	defaultCreateCartLimit = ratelimit.Limit{Requests: 30, Period: time.Minute}
+	defaultAddItemLimit    = ratelimit.Limit{Requests: 120, Period: time.Minute}
+)
+
+const (
+	defaultInactiveTTL       = 72 * time.Hour
+	defaultGuestPurgeAfter   = 30 * 24 * time.Hour
+	defaultExpirySweepPeriod = 10 * time.Minute
+
+	defaultPendingValidationTimeout     = 2 * time.Minute
+	defaultPendingValidationMaxAttempts = 3
+	defaultValidationSweepPeriod        = 30 * time.Second
+
+	defaultCheckoutTimeout     = 10 * time.Minute
+	defaultCheckoutSweepPeriod = 30 * time.Second
+
+	defaultProductCacheTTL = time.Hour
+)
+
+// Currency modes for items priced in another currency than the cart.
+const (
+	CurrencyModeConvert = "convert"
+	CurrencyModeReject  = "reject"
 )
 
 type Config struct {
@@ -12,6 +41,58 @@ type Config struct {
 	WriteTopic  string   `mapstructure:"cart_write_topic" validate:"required"`
 	ReadTopics  []string `mapstructure:"cart_read_topics"`
 	Group       string   `mapstructure:"cart_group"`
+
+	// Rate limits, e.g. "30/m"; empty uses the defaults
+	RateLimitCreateCart string `mapstructure:"cart_rate_limit_create_cart"`
+	RateLimitAddItem    string `mapstructure:"cart_rate_limit_add_item"`
+
+	// Tax rate table (.json or .csv); empty uses the embedded default table
+	TaxRateTable string `mapstructure:"cart_tax_rate_table"`
+
+	// Shipping rate table (.json); empty uses the embedded default table
+	ShippingRateTable string `mapstructure:"cart_shipping_rate_table"`
+
+	// Exchange rates come from the rates service at FXRatesURL when set,
+	// otherwise from the FXRateTable file (.json; empty uses the embedded
+	// default table)
+	FXRatesURL  string `mapstructure:"cart_fx_rates_url"`
+	FXRateTable string `mapstructure:"cart_fx_rate_table"`
+
+	// How items priced in another currency than the cart are handled:
+	// "convert" (default) or "reject"
+	CurrencyMode string `mapstructure:"cart_currency_mode"`
+
+	// Active carts not updated within InactiveTTL are expired; expired
+	// and merged guest carts have their contact and card data purged
+	// GuestPurgeAfter later. The expiry job runs every ExpirySweepPeriod. Zero uses the
+	// defaults.
+	InactiveTTL       time.Duration `mapstructure:"cart_inactive_ttl"`
+	GuestPurgeAfter   time.Duration `mapstructure:"cart_guest_purge_after"`
+	ExpirySweepPeriod time.Duration `mapstructure:"cart_expiry_sweep_period"`
+
+	// Items left pending_validation longer than PendingValidationTimeout
+	// have their validation requested again, PendingValidationMaxAttempts
+	// times in all, and are then backordered. The reconciler runs every
+	// ValidationSweepPeriod. Zero uses the defaults.
+	PendingValidationTimeout     time.Duration `mapstructure:"cart_pending_validation_timeout"`
+	PendingValidationMaxAttempts int           `mapstructure:"cart_pending_validation_max_attempts"`
+	ValidationSweepPeriod        time.Duration `mapstructure:"cart_validation_sweep_period"`
+
+	// A checkout without its order and stock reservation after
+	// CheckoutTimeout is rolled back; the timeout job runs every
+	// CheckoutSweepPeriod. Keep the timeout below the product service's
+	// reservation hold. Zero uses the defaults.
+	CheckoutTimeout     time.Duration `mapstructure:"cart_checkout_timeout"`
+	CheckoutSweepPeriod time.Duration `mapstructure:"cart_checkout_sweep_period"`
+
+	// Product cache entries not refreshed within ProductCacheTTL are stale
+	// and validated by the product service again. Zero uses the default.
+	ProductCacheTTL time.Duration `mapstructure:"cart_product_cache_ttl"`
+
+	// Keycloak settings for the endpoints that need a signed-in customer;
+	// when unset those endpoints reject every request
+	KeycloakIssuer  string `mapstructure:"keycloak_issuer"`
+	KeycloakJWKSURL string `mapstructure:"keycloak_jwks_url"`
 }
 
 func LoadConfig() (*Config, error) {
@@ -28,6 +109,109 @@ func (c *Config) Validate() error {
 	if c.WriteTopic == "" {
 		return errors.New("write topic is required")
 	}
+	switch c.CurrencyMode {
+	case "", CurrencyModeConvert, CurrencyModeReject:
+	default:
+		return fmt.Errorf("currency mode must be %q or %q", CurrencyModeConvert, CurrencyModeReject)
+	}
+	if c.InactiveTTL < 0 || c.GuestPurgeAfter < 0 || c.ExpirySweepPeriod < 0 {
+		return errors.New("cart expiry durations cannot be negative")
+	}
+	if c.PendingValidationTimeout < 0 || c.PendingValidationMaxAttempts < 0 || c.ValidationSweepPeriod < 0 {
+		return errors.New("pending validation settings cannot be negative")
+	}
+	if c.CheckoutTimeout < 0 || c.CheckoutSweepPeriod < 0 {
+		return errors.New("checkout timeout durations cannot be negative")
+	}
+	if c.ProductCacheTTL < 0 {
+		return errors.New("product cache TTL cannot be negative")
+	}
+	if _, err := ratelimit.ParseLimitOrDefault(c.RateLimitCreateCart, defaultCreateCartLimit); err != nil {
+		return fmt.Errorf("create cart rate limit: %w", err)
+	}
+	if _, err := ratelimit.ParseLimitOrDefault(c.RateLimitAddItem, defaultAddItemLimit); err != nil {
+		return fmt.Errorf("add item rate limit: %w", err)
+	}
 
 	return nil
 }
+
+// CreateCartLimit returns the rate limit for POST /carts.
+func (c *Config) CreateCartLimit() ratelimit.Limit {
+	limit, _ := ratelimit.ParseLimitOrDefault(c.RateLimitCreateCart, defaultCreateCartLimit)
+	return limit
+}
+
+// AddItemLimit returns the rate limit for POST /carts/{id}/items.
+func (c *Config) AddItemLimit() ratelimit.Limit {
+	limit, _ := ratelimit.ParseLimitOrDefault(c.RateLimitAddItem, defaultAddItemLimit)
+	return limit
+}
+
+// RejectMixedCurrency reports whether items priced in another currency than
+// the cart are refused instead of converted.
+func (c *Config) RejectMixedCurrency() bool {
+	return c.CurrencyMode == CurrencyModeReject
+}
+
+// CartInactiveTTL returns how long an active cart may go without updates
+// before it expires.
+func (c *Config) CartInactiveTTL() time.Duration {
+	return durationOrDefault(c.InactiveTTL, defaultInactiveTTL)
+}
+
+// CartGuestPurgeAfter returns how long after expiry or merge a guest cart's
+// contact and card data are purged.
+func (c *Config) CartGuestPurgeAfter() time.Duration {
+	return durationOrDefault(c.GuestPurgeAfter, defaultGuestPurgeAfter)
+}
+
+// CartExpirySweepInterval returns how often the expiry job runs.
+func (c *Config) CartExpirySweepInterval() time.Duration {
+	return durationOrDefault(c.ExpirySweepPeriod, defaultExpirySweepPeriod)
+}
+
+// CartPendingValidationTimeout returns how long an item may wait for
+// validation before it is requested again.
+func (c *Config) CartPendingValidationTimeout() time.Duration {
+	return durationOrDefault(c.PendingValidationTimeout, defaultPendingValidationTimeout)
+}
+
+// CartPendingValidationMaxAttempts returns how many times validation of an
+// item is requested before it is backordered.
+func (c *Config) CartPendingValidationMaxAttempts() int {
+	if c.PendingValidationMaxAttempts == 0 {
+		return defaultPendingValidationMaxAttempts
+	}
+	return c.PendingValidationMaxAttempts
+}
+
+// CartValidationSweepInterval returns how often the pending validation
+// reconciler runs.
+func (c *Config) CartValidationSweepInterval() time.Duration {
+	return durationOrDefault(c.ValidationSweepPeriod, defaultValidationSweepPeriod)
+}
+
+// CartCheckoutTimeout returns how long a checkout may wait for its order
+// and stock reservation before it is rolled back.
+func (c *Config) CartCheckoutTimeout() time.Duration {
+	return durationOrDefault(c.CheckoutTimeout, defaultCheckoutTimeout)
+}
+
+// CartCheckoutSweepInterval returns how often timed out checkouts are
+// rolled back.
+func (c *Config) CartCheckoutSweepInterval() time.Duration {
+	return durationOrDefault(c.CheckoutSweepPeriod, defaultCheckoutSweepPeriod)
+}
+
+// CartProductCacheTTL returns how long a product cache entry stays fresh.
+func (c *Config) CartProductCacheTTL() time.Duration {
+	return durationOrDefault(c.ProductCacheTTL, defaultProductCacheTTL)
+}
+
+func durationOrDefault(d, def time.Duration) time.Duration {
+	if d == 0 {
+		return def
+	}
+	return d
+}
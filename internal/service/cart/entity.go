@@ -1,29 +1,42 @@
 package cart
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
 	"time"
 
+	platformerrors "go-shopping-poc/internal/platform/errors"
+	"go-shopping-poc/internal/platform/money"
+	"go-shopping-poc/internal/platform/promotion"
+	"go-shopping-poc/internal/platform/shipping"
+	"go-shopping-poc/internal/platform/tax"
+
 	"github.com/google/uuid"
 )
 
 // Cart represents a shopping cart entity
 type Cart struct {
-	CartID        uuid.UUID  `json:"cart_id" db:"cart_id"`
-	CustomerID    *uuid.UUID `json:"customer_id,omitempty" db:"customer_id"`
-	ContactID     *int64     `json:"-" db:"contact_id"`
-	CreditCardID  *int64     `json:"-" db:"credit_card_id"`
-	CurrentStatus string     `json:"current_status" db:"current_status"`
-	Currency      string     `json:"currency" db:"currency"`
-	NetPrice      float64    `json:"net_price" db:"net_price"`
-	Tax           float64    `json:"tax" db:"tax"`
-	Shipping      float64    `json:"shipping" db:"shipping"`
-	TotalPrice    float64    `json:"total_price" db:"total_price"`
-	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
-	Version       int        `json:"version" db:"version"`
+	CartID         uuid.UUID   `json:"cart_id" db:"cart_id"`
+	CustomerID     *uuid.UUID  `json:"customer_id,omitempty" db:"customer_id"`
+	ContactID      *int64      `json:"-" db:"contact_id"`
+	CreditCardID   *int64      `json:"-" db:"credit_card_id"`
+	CurrentStatus  string      `json:"current_status" db:"current_status"`
+	Currency       string      `json:"currency" db:"currency"`
+	NetPrice       money.Money `json:"net_price" db:"net_price"`
+	Tax            money.Money `json:"tax" db:"tax"`
+	Shipping       money.Money `json:"shipping" db:"shipping"`
+	ShippingMethod string      `json:"shipping_method,omitempty" db:"shipping_method"`
+	Discount       money.Money `json:"discount" db:"discount"`
+	TotalPrice     money.Money `json:"total_price" db:"total_price"`
+	CreatedAt      time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at" db:"updated_at"`
+	Version        int         `json:"version" db:"version"`
+
+	// Discounts applied by promotions, recomputed with the totals
+	Discounts promotion.Discounts `json:"discounts,omitempty" db:"discounts"`
+	Coupons   []string            `json:"coupons,omitempty"`
 
 	Contact       *Contact     `json:"contact,omitempty"`
 	Addresses     []Address    `json:"addresses,omitempty"`
@@ -37,31 +50,205 @@ func (c *Cart) Validate() error {
 	if c.Currency == "" {
 		return errors.New("currency is required")
 	}
-	validStatuses := []string{"active", "checked_out", "completed", "cancelled"}
+	if !money.ValidCurrency(c.Currency) {
+		return errors.New("invalid currency")
+	}
+	validStatuses := []string{"active", "checked_out", "completed", "cancelled", "expired", "purged", "merged"}
 	if !contains(validStatuses, c.CurrentStatus) {
 		return errors.New("invalid cart status")
 	}
 	return nil
 }
 
-// CalculateTotals computes all cart totals (3% tax, $0 shipping)
-func (c *Cart) CalculateTotals() {
-	c.NetPrice = 0
+// CalculateTotals computes all cart totals. Promotions are applied first and
+// allocated to lines; tax is then computed per discounted line using the
+// shipping address, and shipping is priced for the selected method. A method
+// that no longer applies (for example after the address changes zone) is
+// cleared so it must be chosen again before checkout.
+func (c *Cart) CalculateTotals(ctx context.Context, pricing Pricing) error {
+	lineTotals := make([]money.Money, len(c.Items))
 	for i := range c.Items {
 		c.Items[i].CalculateLineTotal()
-		c.NetPrice += c.Items[i].TotalPrice
+		lineTotals[i] = c.Items[i].TotalPrice
+	}
+	netPrice, err := money.Sum(c.Currency, lineTotals...)
+	if err != nil {
+		return fmt.Errorf("failed to total cart lines: %w", err)
+	}
+	c.NetPrice = netPrice
+
+	c.Discounts = nil
+	if pricing.Promotions != nil {
+		promos, err := pricing.Promotions.Promotions(ctx, c.CustomerKey(), c.Coupons)
+		if err != nil {
+			return fmt.Errorf("failed to load promotions: %w", err)
+		}
+		c.Discounts = promotion.Apply(promos, c.PromotionRequest(time.Now()))
+	}
+	if c.Discount, err = c.Discounts.Total(c.Currency); err != nil {
+		return fmt.Errorf("failed to total discounts: %w", err)
+	}
+
+	taxReq, err := c.taxRequest()
+	if err != nil {
+		return err
+	}
+	result, err := pricing.Tax.Calculate(ctx, taxReq)
+	if err != nil {
+		return fmt.Errorf("failed to calculate tax: %w", err)
 	}
-	c.Tax = c.calculateTax()
-	c.Shipping = c.calculateShipping()
-	c.TotalPrice = c.NetPrice + c.Tax + c.Shipping
+	lineTaxes := make([]money.Money, len(c.Items))
+	for i := range c.Items {
+		line, _ := result.Line(c.Items[i].LineNumber)
+		c.Items[i].TaxCode = line.TaxCode
+		c.Items[i].Tax = line.Tax.WithCurrency(c.Currency)
+		c.Items[i].TaxBreakdown = line.Breakdown
+		lineTaxes[i] = c.Items[i].Tax
+	}
+	if c.Tax, err = money.Sum(c.Currency, lineTaxes...); err != nil {
+		return fmt.Errorf("failed to total tax: %w", err)
+	}
+
+	c.Shipping = money.Zero(c.Currency)
+	if c.ShippingMethod != "" {
+		shipReq, err := c.ShippingRequest()
+		if err != nil {
+			return err
+		}
+		quote, err := pricing.Shipping.Rate(ctx, c.ShippingMethod, shipReq)
+		switch {
+		case errors.Is(err, shipping.ErrUnknownMethod),
+			errors.Is(err, shipping.ErrDestinationRequired),
+			errors.Is(err, shipping.ErrMethodUnavailable):
+			c.ShippingMethod = ""
+		case err != nil:
+			return fmt.Errorf("failed to calculate shipping: %w", err)
+		default:
+			c.Shipping = quote.Amount.WithCurrency(c.Currency)
+		}
+	}
+
+	subtotal, err := c.NetPrice.Sub(c.Discount)
+	if err != nil {
+		return fmt.Errorf("failed to apply discounts: %w", err)
+	}
+	if c.TotalPrice, err = money.Sum(c.Currency, subtotal, c.Tax, c.Shipping); err != nil {
+		return fmt.Errorf("failed to total cart: %w", err)
+	}
+	return nil
 }
 
-func (c *Cart) calculateTax() float64 {
-	return c.NetPrice * 0.03
+// LineDiscount returns the promotion discount allocated to lineNumber.
+func (c *Cart) LineDiscount(lineNumber string) (money.Money, error) {
+	return c.Discounts.ForLine(lineNumber, c.Currency)
 }
 
-func (c *Cart) calculateShipping() float64 {
-	return 0.0
+// CustomerKey identifies the customer for per-customer promotion limits: the
+// customer ID, or the contact email for guest carts.
+func (c *Cart) CustomerKey() string {
+	if c.CustomerID != nil {
+		return c.CustomerID.String()
+	}
+	if c.Contact != nil {
+		return strings.ToLower(strings.TrimSpace(c.Contact.Email))
+	}
+	return ""
+}
+
+// PromotionRequest builds the promotion input from the cart lines.
+// Backordered lines are not discounted.
+func (c *Cart) PromotionRequest(now time.Time) promotion.Request {
+	req := promotion.Request{
+		CustomerKey: c.CustomerKey(),
+		Currency:    c.Currency,
+		Lines:       make([]promotion.Line, 0, len(c.Items)),
+		Now:         now,
+	}
+	for _, item := range c.Items {
+		if item.IsBackorder() {
+			continue
+		}
+		req.Lines = append(req.Lines, promotion.Line{
+			LineNumber: item.LineNumber,
+			ProductID:  item.ProductID,
+			Category:   item.Category,
+			Brand:      item.Brand,
+			UnitPrice:  item.UnitPrice.WithCurrency(c.Currency),
+			Quantity:   item.Quantity,
+		})
+	}
+	return req
+}
+
+// HasCoupon reports whether code has been applied to the cart.
+func (c *Cart) HasCoupon(code string) bool {
+	for _, applied := range c.Coupons {
+		if strings.EqualFold(applied, code) {
+			return true
+		}
+	}
+	return false
+}
+
+// ShippingRequest builds the shipping input from the cart lines and address.
+func (c *Cart) ShippingRequest() (shipping.Request, error) {
+	subtotal, err := c.NetPrice.Sub(c.Discount)
+	if err != nil {
+		return shipping.Request{}, fmt.Errorf("failed to apply discounts: %w", err)
+	}
+	req := shipping.Request{
+		Currency: c.Currency,
+		Subtotal: subtotal,
+		Lines:    make([]shipping.Line, 0, len(c.Items)),
+	}
+	for _, item := range c.Items {
+		if item.IsBackorder() {
+			continue
+		}
+		req.Lines = append(req.Lines, shipping.Line{Quantity: item.Quantity, Weight: item.WeightLb})
+	}
+	if addr := c.ShippingAddress(); addr != nil {
+		req.Destination = &shipping.Destination{State: addr.State, Zip: addr.Zip}
+	}
+	return req, nil
+}
+
+// taxRequest builds the tax input from the cart lines and shipping address.
+func (c *Cart) taxRequest() (tax.Request, error) {
+	req := tax.Request{
+		Currency: c.Currency,
+		Lines:    make([]tax.Line, len(c.Items)),
+	}
+	for i, item := range c.Items {
+		discount, err := c.LineDiscount(item.LineNumber)
+		if err != nil {
+			return tax.Request{}, fmt.Errorf("failed to discount line %s: %w", item.LineNumber, err)
+		}
+		taxable, err := item.TotalPrice.Sub(discount)
+		if err != nil {
+			return tax.Request{}, fmt.Errorf("failed to discount line %s: %w", item.LineNumber, err)
+		}
+		req.Lines[i] = tax.Line{
+			LineNumber: item.LineNumber,
+			ProductID:  item.ProductID,
+			TaxCode:    item.TaxCode,
+			Amount:     taxable.WithCurrency(c.Currency),
+		}
+	}
+	if addr := c.ShippingAddress(); addr != nil {
+		req.Destination = &tax.Destination{State: addr.State, Zip: addr.Zip}
+	}
+	return req, nil
+}
+
+// ShippingAddress returns the most recently added shipping address, or nil.
+func (c *Cart) ShippingAddress() *Address {
+	for i := len(c.Addresses) - 1; i >= 0; i-- {
+		if c.Addresses[i].AddressType == "shipping" {
+			return &c.Addresses[i]
+		}
+	}
+	return nil
 }
 
 // CanCheckout validates cart is ready for checkout
@@ -72,22 +259,48 @@ func (c *Cart) CanCheckout() error {
 	if len(c.Items) == 0 {
 		return ErrCartMustHaveItemsForCheckout
 	}
+	if err := c.checkItemsResolved(); err != nil {
+		return err
+	}
 	if c.Contact == nil {
 		return ErrCartContactRequiredForCheckout
 	}
 	if c.CreditCard == nil {
 		return ErrCartPaymentRequiredForCheckout
 	}
+	if c.ShippingMethod == "" {
+		return ErrCartShippingMethodRequiredForCheckout
+	}
+	return nil
+}
+
+// checkItemsResolved refuses checkout while a line awaits validation, is
+// backordered or has an unacknowledged price change.
+func (c *Cart) checkItemsResolved() error {
+	for _, item := range c.Items {
+		if item.IsPendingValidation() {
+			return ErrCartItemsPendingValidation
+		}
+		if item.IsBackorder() {
+			return ErrCartItemsBackordered
+		}
+		if item.HasPriceChange() {
+			return ErrCartPriceChangesUnacknowledged
+		}
+	}
 	return nil
 }
 
 // SetStatus updates cart status with validation
 func (c *Cart) SetStatus(newStatus string) error {
 	validTransitions := map[string][]string{
-		"active":      {"checked_out", "cancelled"},
-		"checked_out": {"completed", "cancelled"},
+		"active":      {"checked_out", "cancelled", "expired", "merged"},
+		"checked_out": {"completed", "cancelled", "active"},
 		"completed":   {},
 		"cancelled":   {},
+		"expired":     {"purged"},
+		"purged":      {},
+		"merged":      {"purged"},
 	}
 
 	allowed, ok := validTransitions[c.CurrentStatus]
@@ -110,35 +323,102 @@ func (c *Cart) SetStatus(newStatus string) error {
 
 // CartItem represents an item in the cart
 type CartItem struct {
-	ID          int64     `json:"id" db:"id"`
-	CartID      uuid.UUID `json:"cart_id" db:"cart_id"`
-	LineNumber  string    `json:"line_number" db:"line_number"`
-	ProductID   string    `json:"product_id" db:"product_id"`
-	ProductName string    `json:"product_name" db:"product_name"`
-	UnitPrice   float64   `json:"unit_price" db:"unit_price"`
-	Quantity    int       `json:"quantity" db:"quantity"`
-	TotalPrice  float64   `json:"total_price" db:"total_price"`
-	ImageURL    string    `json:"image_url" db:"image_url"`
+	ID          int64       `json:"id" db:"id"`
+	CartID      uuid.UUID   `json:"cart_id" db:"cart_id"`
+	LineNumber  string      `json:"line_number" db:"line_number"`
+	ProductID   string      `json:"product_id" db:"product_id"`
+	ProductName string      `json:"product_name" db:"product_name"`
+	UnitPrice   money.Money `json:"unit_price" db:"unit_price"`
+	Quantity    int         `json:"quantity" db:"quantity"`
+	TotalPrice  money.Money `json:"total_price" db:"total_price"`
+	ImageURL    string      `json:"image_url" db:"image_url"`
+	Category    string      `json:"category,omitempty" db:"category"`
+	Brand       string      `json:"brand,omitempty" db:"brand"`
+	// WeightLb is the shipping weight of one unit in pounds, zero when the
+	// product did not report one
+	WeightLb float64 `json:"weight_lb,omitempty" db:"weight_lb"`
+
+	// Variant options selected for the line, see LineAttributes
+	LineAttributes
 
 	// Validation state tracking for event-driven decoupling
 	Status          string  `json:"status" db:"status"`                               // "confirmed", "pending_validation", "backorder"
 	ValidationID    *string `json:"validation_id,omitempty" db:"validation_id"`       // correlation ID linking request to response
 	BackorderReason *string `json:"backorder_reason,omitempty" db:"backorder_reason"` // reason for backorder status
+
+	// When validation of a pending line was last requested and how many
+	// times; see CartService.ReconcilePendingValidations
+	ValidationRequestedAt *time.Time `json:"-" db:"validation_requested_at"`
+	ValidationAttempts    int        `json:"-" db:"validation_attempts"`
+
+	// Tax applied to this line, recomputed with the cart totals
+	TaxCode      string        `json:"tax_code" db:"tax_code"`
+	Tax          money.Money   `json:"tax" db:"tax"`
+	TaxBreakdown tax.Breakdown `json:"tax_breakdown,omitempty" db:"tax_breakdown"`
+
+	// Catalog price and exchange rate when the product is priced in another
+	// currency than the cart, snapshotted when the item was priced
+	OriginalUnitPrice money.Money `json:"original_unit_price,omitzero" db:"original_unit_price"`
+	OriginalCurrency  string      `json:"original_currency,omitempty" db:"original_currency"`
+	FXRate            float64     `json:"fx_rate,omitempty" db:"fx_rate"`
+	FXSource          string      `json:"fx_source,omitempty" db:"fx_source"`
+	FXAsOf            *time.Time  `json:"fx_as_of,omitempty" db:"fx_as_of"`
+
+	// Unit price before the product price last changed, and when, until the
+	// shopper acknowledges the change; see CartService.ReconcileProductPrice
+	PreviousUnitPrice *money.Money `json:"previous_unit_price,omitempty" db:"previous_unit_price"`
+	PriceChangedAt    *time.Time   `json:"price_changed_at,omitempty" db:"price_changed_at"`
+}
+
+// CatalogPrice returns the unit price the product was listed at, in its own
+// currency. cartCurrency is the currency of UnitPrice.
+func (ci *CartItem) CatalogPrice(cartCurrency string) money.Money {
+	if ci.OriginalCurrency != "" {
+		return ci.OriginalUnitPrice.In(ci.OriginalCurrency)
+	}
+	return ci.UnitPrice.In(cartCurrency)
+}
+
+// HasPriceChange reports whether the line was repriced after a product
+// price change the shopper has not acknowledged yet.
+func (ci *CartItem) HasPriceChange() bool {
+	return ci.PriceChangedAt != nil
+}
+
+// recordPriceChange flags the line as repriced at at from previous, its
+// unit price before. The price the shopper last saw is kept across further
+// changes, and the flag is dropped if the price returns to it.
+func (ci *CartItem) recordPriceChange(previous money.Money, at time.Time) {
+	if ci.PreviousUnitPrice != nil {
+		previous = *ci.PreviousUnitPrice
+	}
+	if ci.UnitPrice.Equal(previous) {
+		ci.clearPriceChange()
+		return
+	}
+	ci.PreviousUnitPrice = &previous
+	ci.PriceChangedAt = &at
+}
+
+// clearPriceChange acknowledges the price change of the line.
+func (ci *CartItem) clearPriceChange() {
+	ci.PreviousUnitPrice = nil
+	ci.PriceChangedAt = nil
 }
 
 func (ci *CartItem) CalculateLineTotal() {
-	ci.TotalPrice = float64(ci.Quantity) * ci.UnitPrice
+	ci.TotalPrice = ci.UnitPrice.Mul(int64(ci.Quantity))
 }
 
 func (ci *CartItem) Validate() error {
 	if ci.ProductID == "" {
-		return errors.New("product_id is required")
+		return platformerrors.NewValidationError("product_id", "product_id is required")
 	}
 	if ci.Quantity <= 0 {
-		return errors.New("quantity must be positive")
+		return platformerrors.NewValidationError("quantity", "quantity must be positive")
 	}
-	if ci.UnitPrice < 0 {
-		return errors.New("unit_price cannot be negative")
+	if ci.UnitPrice.IsNegative() {
+		return platformerrors.NewValidationError("unit_price", "unit_price cannot be negative")
 	}
 	return nil
 }
@@ -158,15 +438,27 @@ func (ci *CartItem) IsConfirmed() bool {
 	return ci.Status == "confirmed"
 }
 
+// requestValidation stamps a pending item with its first validation request.
+func (ci *CartItem) requestValidation(now time.Time) {
+	if !ci.IsPendingValidation() {
+		ci.ValidationRequestedAt = nil
+		ci.ValidationAttempts = 0
+		return
+	}
+	ci.ValidationRequestedAt = &now
+	ci.ValidationAttempts = 1
+}
+
 // ConfirmItem updates item with validated product details and marks as confirmed
 // Can only be called on items with status "pending_validation"
-func (ci *CartItem) ConfirmItem(productName string, unitPrice float64) error {
+func (ci *CartItem) ConfirmItem(productName string, unitPrice money.Money, taxCode string) error {
 	if ci.Status != "pending_validation" {
 		return fmt.Errorf("cannot confirm item: expected status 'pending_validation', got '%s'", ci.Status)
 	}
 	ci.Status = "confirmed"
 	ci.ProductName = productName
 	ci.UnitPrice = unitPrice
+	ci.TaxCode = taxCode
 	ci.CalculateLineTotal()
 	ci.BackorderReason = nil
 	return nil
@@ -195,19 +487,19 @@ type Contact struct {
 
 func (c *Contact) Validate() error {
 	if strings.TrimSpace(c.Email) == "" {
-		return errors.New("email is required")
+		return platformerrors.NewValidationError("email", "email is required")
 	}
 	if !strings.Contains(c.Email, "@") {
-		return errors.New("invalid email format")
+		return platformerrors.NewValidationError("email", "invalid email format")
 	}
 	if strings.TrimSpace(c.FirstName) == "" {
-		return errors.New("first_name is required")
+		return platformerrors.NewValidationError("first_name", "first_name is required")
 	}
 	if strings.TrimSpace(c.LastName) == "" {
-		return errors.New("last_name is required")
+		return platformerrors.NewValidationError("last_name", "last_name is required")
 	}
 	if strings.TrimSpace(c.Phone) == "" {
-		return errors.New("phone is required")
+		return platformerrors.NewValidationError("phone", "phone is required")
 	}
 	return nil
 }
@@ -229,19 +521,19 @@ type Address struct {
 func (a *Address) Validate() error {
 	validTypes := []string{"shipping", "billing"}
 	if !contains(validTypes, a.AddressType) {
-		return errors.New("address_type must be shipping or billing")
+		return platformerrors.NewValidationError("address_type", "address_type must be shipping or billing")
 	}
 	if strings.TrimSpace(a.Address1) == "" {
-		return errors.New("address_1 is required")
+		return platformerrors.NewValidationError("address_1", "address_1 is required")
 	}
 	if strings.TrimSpace(a.City) == "" {
-		return errors.New("city is required")
+		return platformerrors.NewValidationError("city", "city is required")
 	}
 	if strings.TrimSpace(a.State) == "" {
-		return errors.New("state is required")
+		return platformerrors.NewValidationError("state", "state is required")
 	}
 	if strings.TrimSpace(a.Zip) == "" {
-		return errors.New("zip is required")
+		return platformerrors.NewValidationError("zip", "zip is required")
 	}
 	return nil
 }
@@ -259,16 +551,16 @@ type CreditCard struct {
 
 func (cc *CreditCard) Validate() error {
 	if strings.TrimSpace(cc.CardNumber) == "" {
-		return errors.New("card_number is required")
+		return platformerrors.NewValidationError("card_number", "card_number is required")
 	}
 	if strings.TrimSpace(cc.CardHolderName) == "" {
-		return errors.New("card_holder_name is required")
+		return platformerrors.NewValidationError("card_holder_name", "card_holder_name is required")
 	}
 	if strings.TrimSpace(cc.CardExpires) == "" {
-		return errors.New("card_expires is required")
+		return platformerrors.NewValidationError("card_expires", "card_expires is required")
 	}
 	if strings.TrimSpace(cc.CardCVV) == "" {
-		return errors.New("card_cvv is required")
+		return platformerrors.NewValidationError("card_cvv", "card_cvv is required")
 	}
 	return nil
 }
@@ -3,10 +3,29 @@ package cart
 import "errors"
 
 var (
-	ErrCartNotReadyForCheckout        = errors.New("cart not ready for checkout")
-	ErrCartMustBeActiveForCheckout    = errors.New("cart must be active to checkout")
-	ErrCartMustHaveItemsForCheckout   = errors.New("cart must have at least one item")
-	ErrCartContactRequiredForCheckout = errors.New("contact information required")
-	ErrCartPaymentRequiredForCheckout = errors.New("payment method required")
-	ErrCartItemsPendingValidation     = errors.New("cannot checkout: some items are still being validated, please wait")
+	ErrCartNotReadyForCheckout               = errors.New("cart not ready for checkout")
+	ErrCartMustBeActiveForCheckout           = errors.New("cart must be active to checkout")
+	ErrCartMustHaveItemsForCheckout          = errors.New("cart must have at least one item")
+	ErrCartContactRequiredForCheckout        = errors.New("contact information required")
+	ErrCartPaymentRequiredForCheckout        = errors.New("payment method required")
+	ErrCartShippingMethodRequiredForCheckout = errors.New("shipping method required")
+	ErrCartItemsPendingValidation            = errors.New("cannot checkout: some items are still being validated, please wait")
+	ErrCartItemsBackordered                  = errors.New("cannot checkout: some items are backordered, remove them to continue")
+	ErrCartPriceChangesUnacknowledged        = errors.New("cannot checkout: some item prices changed, acknowledge the changes to continue")
+	ErrInsufficientStock                     = errors.New("cannot checkout: insufficient stock for some items")
+
+	ErrCartNotActive            = errors.New("cart is not active")
+	ErrInvalidQuantity          = errors.New("quantity must be positive")
+	ErrInvalidCustomerID        = errors.New("invalid customer ID")
+	ErrItemAlreadyInCart        = errors.New("product already exists in cart, use update quantity instead")
+	ErrItemValidationInProgress = errors.New("product is already being added to cart, please wait for validation")
+	ErrCouponNotFound           = errors.New("coupon not found")
+	ErrCurrencyMismatch         = errors.New("product currency does not match cart currency")
+	ErrQuantityNotInStock       = errors.New("requested quantity is not in stock")
+
+	ErrCustomerNotLinked        = errors.New("signed-in user is not linked to a customer")
+	ErrCartOwnedByOtherCustomer = errors.New("cart belongs to another customer")
+	ErrCartNotClaimed           = errors.New("cart must be claimed by a signed-in customer")
+
+	ErrWishlistProductUnknown = errors.New("product not found")
 )
@@ -0,0 +1,517 @@
+package eventhandlers_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	events "go-shopping-poc/internal/contracts/events"
+	"go-shopping-poc/internal/platform/event/bus"
+	"go-shopping-poc/internal/platform/money"
+	"go-shopping-poc/internal/service/cart"
+	"go-shopping-poc/internal/service/cart/eventhandlers"
+)
+
+// fakeBus is an in-memory bus.Bus. Publish encodes the event and hands it
+// to every handler subscribed to its topic, as the Kafka bus does, and
+// records it.
+type fakeBus struct {
+	mu        sync.Mutex
+	handlers  map[string][]func(ctx context.Context, data []byte) error
+	published []events.Event
+}
+
+var _ bus.Bus = (*fakeBus)(nil)
+
+func newFakeBus() *fakeBus {
+	return &fakeBus{handlers: make(map[string][]func(ctx context.Context, data []byte) error)}
+}
+
+func subscribe[T events.Event](b *fakeBus, topic string, factory events.EventFactory[T], handle bus.HandlerFunc[T]) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[topic] = append(b.handlers[topic], func(ctx context.Context, data []byte) error {
+		event, err := factory.FromJSON(data)
+		if err != nil {
+			return err
+		}
+		return handle(ctx, event)
+	})
+}
+
+func (b *fakeBus) Publish(ctx context.Context, topic string, event events.Event) error {
+	data, err := event.ToJSON()
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	b.published = append(b.published, event)
+	handlers := append([]func(context.Context, []byte) error(nil), b.handlers[topic]...)
+	b.mu.Unlock()
+
+	for _, handle := range handlers {
+		if err := handle(ctx, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *fakeBus) PublishRaw(ctx context.Context, topic string, eventType string, data []byte) error {
+	return errors.New("not supported")
+}
+
+func (b *fakeBus) StartConsuming(ctx context.Context) error { return nil }
+func (b *fakeBus) WriteTopic() string                       { return "CartEvents" }
+func (b *fakeBus) ReadTopics() []string                     { return []string{"OrderEvents", "ProductEvents"} }
+
+// checkoutFailures returns the cart.checkout_failed events published.
+func (b *fakeBus) checkoutFailures() []events.CartEventPayload {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var failures []events.CartEventPayload
+	for _, event := range b.published {
+		if e, ok := event.(*events.CartEvent); ok && e.EventType == events.CartCheckoutFailed {
+			failures = append(failures, e.EventPayload)
+		}
+	}
+	return failures
+}
+
+// fakeCart is the part of a cart the saga store changes.
+type fakeCart struct {
+	status     string
+	customerID *string
+}
+
+// fakeSagaStore keeps checkout sagas in memory and publishes the events the
+// repository would write to the outbox straight to the bus.
+type fakeSagaStore struct {
+	mu    sync.Mutex
+	bus   *fakeBus
+	sagas map[string]cart.CheckoutSaga
+	carts map[string]*fakeCart
+	// customers with another active cart
+	activeCustomers map[string]bool
+}
+
+func newFakeSagaStore(b *fakeBus) *fakeSagaStore {
+	return &fakeSagaStore{
+		bus:             b,
+		sagas:           make(map[string]cart.CheckoutSaga),
+		carts:           make(map[string]*fakeCart),
+		activeCustomers: make(map[string]bool),
+	}
+}
+
+// checkout starts the saga of a checked out cart.
+func (s *fakeSagaStore) checkout(customerID *string, deadline time.Time) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := uuid.New()
+	s.carts[id.String()] = &fakeCart{status: "checked_out", customerID: customerID}
+	s.sagas[id.String()] = cart.CheckoutSaga{
+		CartID:          id,
+		Attempt:         1,
+		Status:          cart.SagaPending,
+		InventoryStatus: cart.SagaInventoryPending,
+		Deadline:        deadline,
+	}
+	return id.String()
+}
+
+// recheckout checks a reopened cart out again, starting the next attempt
+// of its saga.
+func (s *fakeSagaStore) recheckout(t *testing.T, cartID string, deadline time.Time) int {
+	t.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if status := s.carts[cartID].status; status != "active" {
+		t.Fatalf("cart status = %q, want active to check out again", status)
+	}
+	s.carts[cartID].status = "checked_out"
+	saga := s.sagas[cartID]
+	s.sagas[cartID] = cart.CheckoutSaga{
+		CartID:          saga.CartID,
+		Attempt:         saga.Attempt + 1,
+		Status:          cart.SagaPending,
+		InventoryStatus: cart.SagaInventoryPending,
+		Deadline:        deadline,
+	}
+	return saga.Attempt + 1
+}
+
+func (s *fakeSagaStore) cartStatus(cartID string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.carts[cartID].status
+}
+
+func (s *fakeSagaStore) AdvanceCheckoutSaga(ctx context.Context, cartID string, step cart.SagaStep) (*cart.SagaResult, error) {
+	s.mu.Lock()
+	saga, ok := s.sagas[cartID]
+	if !ok {
+		s.mu.Unlock()
+		return nil, cart.ErrCheckoutSagaNotFound
+	}
+	c := s.carts[cartID]
+
+	action := step(&saga)
+	s.sagas[cartID] = saga
+
+	var failed *events.CartEvent
+	switch action {
+	case cart.SagaActionComplete:
+		if c.status == "checked_out" {
+			c.status = "completed"
+		}
+	case cart.SagaActionCompensate, cart.SagaActionCancelLate:
+		if action == cart.SagaActionCompensate && c.status == "checked_out" {
+			c.status = cart.ReopenedCartStatus(c.customerID != nil && s.activeCustomers[*c.customerID])
+		}
+		failed = events.NewCartCheckoutFailedEvent(cartID, c.customerID, saga.Attempt, deref(saga.OrderID), deref(saga.Reason))
+	}
+	result := &cart.SagaResult{Saga: saga, Action: action, CartStatus: c.status}
+	s.mu.Unlock()
+
+	if failed != nil {
+		if err := s.bus.Publish(ctx, failed.Topic(), failed); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func (s *fakeSagaStore) ListExpiredCheckoutSagas(ctx context.Context, now time.Time, limit int) ([]cart.CheckoutSaga, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var expired []cart.CheckoutSaga
+	for _, saga := range s.sagas {
+		if saga.Status == cart.SagaPending && saga.Deadline.Before(now) && len(expired) < limit {
+			expired = append(expired, saga)
+		}
+	}
+	return expired, nil
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// sseRecorder records the SSE events published per cart.
+type sseRecorder struct {
+	mu     sync.Mutex
+	events map[string][]sseEvent
+}
+
+type sseEvent struct {
+	name string
+	data map[string]interface{}
+}
+
+func (r *sseRecorder) Publish(streamID string, event string, data interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.events == nil {
+		r.events = make(map[string][]sseEvent)
+	}
+	m, _ := data.(map[string]interface{})
+	r.events[streamID] = append(r.events[streamID], sseEvent{name: event, data: m})
+}
+
+func (r *sseRecorder) names(cartID string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var names []string
+	for _, e := range r.events[cartID] {
+		names = append(names, e.name)
+	}
+	return names
+}
+
+func (r *sseRecorder) last(cartID string) sseEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	all := r.events[cartID]
+	if len(all) == 0 {
+		return sseEvent{}
+	}
+	return all[len(all)-1]
+}
+
+type sagaHarness struct {
+	bus   *fakeBus
+	store *fakeSagaStore
+	sse   *sseRecorder
+	sagas *cart.CheckoutSagas
+}
+
+// newSagaHarness wires the cart's order and inventory handlers to a fake
+// bus, with checkout sagas kept in a fake store.
+func newSagaHarness() *sagaHarness {
+	b := newFakeBus()
+	h := &sagaHarness{bus: b, store: newFakeSagaStore(b), sse: &sseRecorder{}}
+	h.sagas = cart.NewCheckoutSagas(h.store, h.sse, nil, nil)
+
+	orderHandler := eventhandlers.NewOnOrderEvent(h.sagas, nil, nil)
+	subscribe(b, events.OrderEvent{}.Topic(), orderHandler.CreateFactory(), orderHandler.CreateHandler())
+	inventoryHandler := eventhandlers.NewOnInventoryEvent(cart.NewProductCache(), h.sagas, nil, nil)
+	subscribe(b, events.InventoryEvent{}.Topic(), inventoryHandler.CreateFactory(), inventoryHandler.CreateHandler())
+	return h
+}
+
+func (h *sagaHarness) publish(t *testing.T, event events.Event) {
+	t.Helper()
+	if err := h.bus.Publish(context.Background(), event.Topic(), event); err != nil {
+		t.Fatalf("publish %s: %v", event.Type(), err)
+	}
+}
+
+func orderCreated(cartID, orderID string) *events.OrderEvent {
+	return events.NewOrderCreatedEvent(orderID, "ORD-1", cartID, nil, money.Money{})
+}
+
+// The events below carry the checkout attempt they belong to, as the order
+// and product services echo it from cart.checked_out.
+
+func orderCreatedIn(cartID string, attempt int, orderID string) *events.OrderEvent {
+	event := orderCreated(cartID, orderID)
+	event.Data.Attempt = attempt
+	return event
+}
+
+func orderRejectedIn(cartID string, attempt int, reason string) *events.OrderEvent {
+	event := events.NewOrderRejectedEvent(cartID, nil, reason)
+	event.Data.Attempt = attempt
+	return event
+}
+
+func reservedIn(cartID string, attempt int) *events.InventoryEvent {
+	event := events.NewInventoryReservedEvent(cartID, time.Now().Add(15*time.Minute), nil)
+	event.Data.Attempt = attempt
+	return event
+}
+
+func reservationFailedIn(cartID string, attempt int, reason string) *events.InventoryEvent {
+	event := events.NewInventoryReservationFailedEvent(cartID, reason, nil)
+	event.Data.Attempt = attempt
+	return event
+}
+
+func TestCheckoutSaga_CompletesWhenOrderCreatedAndStockReserved(t *testing.T) {
+	h := newSagaHarness()
+	cartID := h.store.checkout(nil, time.Now().Add(time.Minute))
+
+	h.publish(t, events.NewInventoryReservedEvent(cartID, time.Now().Add(15*time.Minute), nil))
+	if got := h.store.cartStatus(cartID); got != "checked_out" {
+		t.Fatalf("cart status after reservation = %q, want checked_out", got)
+	}
+
+	h.publish(t, orderCreated(cartID, "order-1"))
+	if got := h.store.cartStatus(cartID); got != "completed" {
+		t.Fatalf("cart status = %q, want completed", got)
+	}
+	if failures := h.bus.checkoutFailures(); len(failures) != 0 {
+		t.Fatalf("unexpected checkout failures: %+v", failures)
+	}
+	last := h.sse.last(cartID)
+	if last.name != "checkout.completed" || last.data["order_id"] != "order-1" {
+		t.Fatalf("last SSE event = %+v, want checkout.completed for order-1", last)
+	}
+}
+
+func TestCheckoutSaga_OrderRejectedReopensCart(t *testing.T) {
+	h := newSagaHarness()
+	cartID := h.store.checkout(nil, time.Now().Add(time.Minute))
+
+	h.publish(t, events.NewOrderRejectedEvent(cartID, nil, "order_creation_failed"))
+
+	if got := h.store.cartStatus(cartID); got != "active" {
+		t.Fatalf("cart status = %q, want active", got)
+	}
+	failures := h.bus.checkoutFailures()
+	if len(failures) != 1 {
+		t.Fatalf("checkout failures = %d, want 1", len(failures))
+	}
+	if failures[0].Details["reason"] != "order_creation_failed" || failures[0].Details["order_id"] != "" {
+		t.Fatalf("checkout failure details = %v", failures[0].Details)
+	}
+	last := h.sse.last(cartID)
+	if last.name != "checkout.failed" || last.data["status"] != "active" {
+		t.Fatalf("last SSE event = %+v, want checkout.failed with status active", last)
+	}
+
+	// Stock reserved after the rollback is released too
+	h.publish(t, events.NewInventoryReservedEvent(cartID, time.Now().Add(15*time.Minute), nil))
+	if failures := h.bus.checkoutFailures(); len(failures) != 2 {
+		t.Fatalf("checkout failures after late reservation = %d, want 2", len(failures))
+	}
+	if got := h.store.cartStatus(cartID); got != "active" {
+		t.Fatalf("cart status after late reservation = %q, want active", got)
+	}
+}
+
+func TestCheckoutSaga_ReservationFailedAfterOrderCancelsOrder(t *testing.T) {
+	h := newSagaHarness()
+	cartID := h.store.checkout(nil, time.Now().Add(time.Minute))
+
+	h.publish(t, orderCreated(cartID, "order-1"))
+	if got := h.store.cartStatus(cartID); got != "checked_out" {
+		t.Fatalf("cart status after order = %q, want checked_out", got)
+	}
+	if last := h.sse.last(cartID); last.name != "checkout.updated" {
+		t.Fatalf("last SSE event = %q, want checkout.updated", last.name)
+	}
+
+	h.publish(t, events.NewInventoryReservationFailedEvent(cartID, "insufficient_stock", nil))
+
+	if got := h.store.cartStatus(cartID); got != "active" {
+		t.Fatalf("cart status = %q, want active", got)
+	}
+	failures := h.bus.checkoutFailures()
+	if len(failures) != 1 {
+		t.Fatalf("checkout failures = %d, want 1", len(failures))
+	}
+	if failures[0].Details["order_id"] != "order-1" || failures[0].Details["reason"] != "insufficient_stock" {
+		t.Fatalf("checkout failure details = %v", failures[0].Details)
+	}
+}
+
+func TestCheckoutSaga_TimeoutThenLateOrderCancelsOrder(t *testing.T) {
+	h := newSagaHarness()
+	cartID := h.store.checkout(nil, time.Now().Add(-time.Second))
+
+	expired, err := h.sagas.ExpireCheckouts(context.Background())
+	if err != nil {
+		t.Fatalf("ExpireCheckouts: %v", err)
+	}
+	if expired != 1 {
+		t.Fatalf("expired = %d, want 1", expired)
+	}
+	if got := h.store.cartStatus(cartID); got != "active" {
+		t.Fatalf("cart status = %q, want active", got)
+	}
+	failures := h.bus.checkoutFailures()
+	if len(failures) != 1 || failures[0].Details["reason"] != cart.SagaReasonTimeout {
+		t.Fatalf("checkout failures = %+v, want one timeout", failures)
+	}
+
+	h.publish(t, orderCreated(cartID, "order-late"))
+
+	if got := h.store.cartStatus(cartID); got != "active" {
+		t.Fatalf("cart status after late order = %q, want active", got)
+	}
+	failures = h.bus.checkoutFailures()
+	if len(failures) != 2 || failures[1].Details["order_id"] != "order-late" {
+		t.Fatalf("checkout failures = %+v, want the late order cancelled", failures)
+	}
+	for _, name := range h.sse.names(cartID) {
+		if name == "checkout.completed" {
+			t.Fatal("timed out checkout was completed")
+		}
+	}
+
+	// Redelivery of the late order does not cancel it again
+	h.publish(t, orderCreated(cartID, "order-late"))
+	if failures := h.bus.checkoutFailures(); len(failures) != 2 {
+		t.Fatalf("checkout failures after redelivery = %d, want 2", len(failures))
+	}
+}
+
+func TestCheckoutSaga_CancelsCartWhenCustomerHasActiveCart(t *testing.T) {
+	h := newSagaHarness()
+	customerID := uuid.New().String()
+	cartID := h.store.checkout(&customerID, time.Now().Add(time.Minute))
+	h.store.activeCustomers[customerID] = true
+
+	h.publish(t, events.NewOrderRejectedEvent(cartID, &customerID, "order_creation_failed"))
+
+	if got := h.store.cartStatus(cartID); got != "cancelled" {
+		t.Fatalf("cart status = %q, want cancelled", got)
+	}
+	last := h.sse.last(cartID)
+	if last.name != "checkout.failed" || last.data["status"] != "cancelled" {
+		t.Fatalf("last SSE event = %+v, want checkout.failed with status cancelled", last)
+	}
+	failures := h.bus.checkoutFailures()
+	if len(failures) != 1 || deref(failures[0].CustomerID) != customerID {
+		t.Fatalf("checkout failures = %+v, want one for the customer", failures)
+	}
+}
+
+func TestCheckoutSaga_IgnoresCartsWithoutSaga(t *testing.T) {
+	h := newSagaHarness()
+	cartID := uuid.New().String()
+
+	h.publish(t, orderCreated(cartID, "order-1"))
+	h.publish(t, events.NewInventoryReservationFailedEvent(cartID, "insufficient_stock", nil))
+
+	if failures := h.bus.checkoutFailures(); len(failures) != 0 {
+		t.Fatalf("unexpected checkout failures: %+v", failures)
+	}
+}
+
+func TestCheckoutSaga_RejectedCheckoutCompletesWhenCheckedOutAgain(t *testing.T) {
+	h := newSagaHarness()
+	cartID := h.store.checkout(nil, time.Now().Add(time.Minute))
+
+	h.publish(t, orderRejectedIn(cartID, 1, "order_creation_failed"))
+	if got := h.store.cartStatus(cartID); got != "active" {
+		t.Fatalf("cart status after rejection = %q, want active", got)
+	}
+
+	if attempt := h.store.recheckout(t, cartID, time.Now().Add(time.Minute)); attempt != 2 {
+		t.Fatalf("attempt = %d, want 2", attempt)
+	}
+
+	// Outcomes of the first attempt reported late leave the second alone
+	h.publish(t, reservedIn(cartID, 1))
+	h.publish(t, orderCreatedIn(cartID, 1, "order-1"))
+	h.publish(t, reservationFailedIn(cartID, 1, "insufficient_stock"))
+	if got := h.store.cartStatus(cartID); got != "checked_out" {
+		t.Fatalf("cart status after late first attempt events = %q, want checked_out", got)
+	}
+
+	h.publish(t, reservedIn(cartID, 2))
+	h.publish(t, orderCreatedIn(cartID, 2, "order-2"))
+
+	if got := h.store.cartStatus(cartID); got != "completed" {
+		t.Fatalf("cart status = %q, want completed", got)
+	}
+	last := h.sse.last(cartID)
+	if last.name != "checkout.completed" || last.data["order_id"] != "order-2" {
+		t.Fatalf("last SSE event = %+v, want checkout.completed for order-2", last)
+	}
+	failures := h.bus.checkoutFailures()
+	if len(failures) != 1 || failures[0].CheckoutAttempt() != 1 {
+		t.Fatalf("checkout failures = %+v, want only the first attempt's", failures)
+	}
+}
+
+func TestCheckoutSaga_FailureOfSecondAttemptNamesIt(t *testing.T) {
+	h := newSagaHarness()
+	cartID := h.store.checkout(nil, time.Now().Add(time.Minute))
+
+	h.publish(t, reservationFailedIn(cartID, 1, "insufficient_stock"))
+	h.store.recheckout(t, cartID, time.Now().Add(time.Minute))
+	h.publish(t, orderCreatedIn(cartID, 2, "order-2"))
+	h.publish(t, reservationFailedIn(cartID, 2, "insufficient_stock"))
+
+	if got := h.store.cartStatus(cartID); got != "active" {
+		t.Fatalf("cart status = %q, want active", got)
+	}
+	failures := h.bus.checkoutFailures()
+	if len(failures) != 2 {
+		t.Fatalf("checkout failures = %d, want 2", len(failures))
+	}
+	if got := failures[1]; got.CheckoutAttempt() != 2 || got.Details["order_id"] != "order-2" {
+		t.Fatalf("second checkout failure details = %v, want attempt 2 cancelling order-2", got.Details)
+	}
+}
@@ -0,0 +1,68 @@
+package eventhandlers
+
+import (
+	"context"
+	"log/slog"
+
+	events "go-shopping-poc/internal/contracts/events"
+	"go-shopping-poc/internal/platform/event/bus"
+	"go-shopping-poc/internal/service/cart"
+)
+
+// OnCustomerEvent keeps the identity cache current by processing
+// CustomerCreated and CustomerUpdated events, so a customer who signs in
+// can claim a guest cart.
+type OnCustomerEvent struct {
+	cache  *cart.IdentityCache
+	logger *slog.Logger
+}
+
+// NewOnCustomerEvent creates a new customer event handler.
+func NewOnCustomerEvent(cache *cart.IdentityCache, logger *slog.Logger) *OnCustomerEvent {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &OnCustomerEvent{
+		cache:  cache,
+		logger: logger.With("component", "cart_on_customer_event"),
+	}
+}
+
+// Handle links the customer's Keycloak subject to their customer ID.
+// Customers not linked to Keycloak and other event types are ignored.
+func (h *OnCustomerEvent) Handle(ctx context.Context, event events.Event) error {
+	switch e := event.(type) {
+	case events.CustomerEvent:
+		if e.EventType != events.CustomerCreated && e.EventType != events.CustomerUpdated {
+			return nil
+		}
+		keycloakSub := e.EventPayload.Details["keycloak_sub"]
+		if keycloakSub == "" {
+			return nil
+		}
+		h.cache.Set(keycloakSub, e.EventPayload.CustomerID)
+		h.logger.Debug("Identity cache updated",
+			"customer_id", e.EventPayload.CustomerID,
+			"event_type", string(e.EventType))
+	case *events.CustomerEvent:
+		return h.Handle(ctx, *e)
+	}
+	return nil
+}
+
+// EventType returns the event type this handler is registered under.
+func (h *OnCustomerEvent) EventType() string {
+	return string(events.CustomerCreated)
+}
+
+// CreateFactory returns the factory for CustomerEvents.
+func (h *OnCustomerEvent) CreateFactory() events.EventFactory[events.CustomerEvent] {
+	return events.CustomerEventFactory{}
+}
+
+// CreateHandler returns the bus handler function.
+func (h *OnCustomerEvent) CreateHandler() bus.HandlerFunc[events.CustomerEvent] {
+	return func(ctx context.Context, event events.CustomerEvent) error {
+		return h.Handle(ctx, event)
+	}
+}
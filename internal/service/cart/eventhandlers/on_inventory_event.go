@@ -0,0 +1,151 @@
+package eventhandlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	events "go-shopping-poc/internal/contracts/events"
+	"go-shopping-poc/internal/platform/event/bus"
+	"go-shopping-poc/internal/platform/event/handler"
+	"go-shopping-poc/internal/platform/sse"
+	"go-shopping-poc/internal/service/cart"
+)
+
+// OnInventoryEvent processes stock reservation outcomes from the product
+// service. Every event reports the stock left for its products, which is
+// recorded in the product cache so that checkout is refused for carts
+// asking for more than is available. The outcome of a checkout reservation
+// advances the cart's checkout saga and is pushed to the cart's SSE
+// subscribers.
+type OnInventoryEvent struct {
+	cache  *cart.ProductCache
+	sagas  *cart.CheckoutSagas
+	sseHub *sse.Hub
+	logger *slog.Logger
+}
+
+// NewOnInventoryEvent creates a new inventory event handler.
+func NewOnInventoryEvent(cache *cart.ProductCache, sagas *cart.CheckoutSagas, sseHub *sse.Hub, logger *slog.Logger) *OnInventoryEvent {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &OnInventoryEvent{
+		cache:  cache,
+		sagas:  sagas,
+		sseHub: sseHub,
+		logger: logger.With("component", "cart_on_inventory_event"),
+	}
+}
+
+func (h *OnInventoryEvent) Handle(ctx context.Context, event events.Event) error {
+	inventoryEvent, ok := event.(events.InventoryEvent)
+	if !ok {
+		h.logger.Error("Expected InventoryEvent", "actual_type", fmt.Sprintf("%T", event))
+		return nil
+	}
+
+	switch inventoryEvent.EventType {
+	case events.InventoryReserved, events.InventoryReservationFailed,
+		events.InventoryCommitted, events.InventoryReleased, events.InventoryRestocked:
+	default:
+		return nil
+	}
+
+	payload := inventoryEvent.Data
+	log := h.logger.With(
+		"operation", "handle_inventory_event",
+		"event_id", inventoryEvent.ID,
+		"event_type", inventoryEvent.EventType,
+		"cart_id", payload.CartID,
+	)
+
+	utils := handler.NewEventUtils()
+	utils.LogEventProcessing(ctx, string(inventoryEvent.EventType), payload.CartID, payload.OrderID)
+
+	for _, line := range payload.Lines {
+		h.cache.SetAvailable(line.ProductID, line.Available)
+	}
+
+	if inventoryEvent.EventType == events.InventoryReservationFailed {
+		log.Warn("Stock reservation failed", "reason", payload.Reason, "lines", len(payload.Lines))
+	}
+
+	if h.sseHub != nil && payload.CartID != "" {
+		sseData := map[string]interface{}{
+			"cart_id": payload.CartID,
+			"lines":   payload.Lines,
+		}
+		if payload.OrderID != "" {
+			sseData["order_id"] = payload.OrderID
+		}
+		if payload.Reason != "" {
+			sseData["reason"] = payload.Reason
+		}
+		if payload.ExpiresAt != nil {
+			sseData["expires_at"] = payload.ExpiresAt
+		}
+		h.sseHub.Publish(payload.CartID, string(inventoryEvent.EventType), sseData)
+	}
+
+	err := h.advanceSaga(ctx, inventoryEvent)
+	utils.LogEventCompletion(ctx, string(inventoryEvent.EventType), payload.CartID, err)
+	if err != nil {
+		log.Error("Advance checkout saga failed", "error", err.Error())
+		return err
+	}
+	log.Debug("Inventory event processed", "lines", len(payload.Lines))
+	return nil
+}
+
+// advanceSaga feeds the outcome of a checkout reservation to the cart's
+// checkout saga.
+func (h *OnInventoryEvent) advanceSaga(ctx context.Context, inventoryEvent events.InventoryEvent) error {
+	cartID := inventoryEvent.Data.CartID
+	if h.sagas == nil || cartID == "" {
+		return nil
+	}
+
+	var err error
+	switch inventoryEvent.EventType {
+	case events.InventoryReserved:
+		_, err = h.sagas.InventoryReserved(ctx, cartID, inventoryEvent.Data.Attempt)
+	case events.InventoryReservationFailed:
+		_, err = h.sagas.InventoryFailed(ctx, cartID, inventoryEvent.Data.Attempt, inventoryEvent.Data.Reason)
+	default:
+		return nil
+	}
+
+	// Carts checked out before checkout sagas were introduced
+	if errors.Is(err, cart.ErrCheckoutSagaNotFound) {
+		h.logger.Warn("No checkout saga for inventory event", "cart_id", cartID, "event_type", inventoryEvent.EventType)
+		return nil
+	}
+	return err
+}
+
+// EventType returns the event types this handler processes.
+func (h *OnInventoryEvent) EventType() string {
+	return string(events.InventoryReserved) + "," +
+		string(events.InventoryReservationFailed) + "," +
+		string(events.InventoryCommitted) + "," +
+		string(events.InventoryReleased) + "," +
+		string(events.InventoryRestocked)
+}
+
+// CreateHandler returns a bus.HandlerFunc that wraps the Handle method.
+func (h *OnInventoryEvent) CreateHandler() bus.HandlerFunc[events.InventoryEvent] {
+	return func(ctx context.Context, event events.InventoryEvent) error {
+		return h.Handle(ctx, event)
+	}
+}
+
+// CreateFactory returns an EventFactory for InventoryEvent.
+func (h *OnInventoryEvent) CreateFactory() events.EventFactory[events.InventoryEvent] {
+	return events.InventoryEventFactory{}
+}
+
+// Ensure OnInventoryEvent implements the shared interfaces
+var _ handler.EventHandler = (*OnInventoryEvent)(nil)
+var _ handler.HandlerFactory[events.InventoryEvent] = (*OnInventoryEvent)(nil)
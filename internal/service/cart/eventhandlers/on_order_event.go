@@ -0,0 +1,130 @@
+package eventhandlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	events "go-shopping-poc/internal/contracts/events"
+	"go-shopping-poc/internal/platform/event/bus"
+	"go-shopping-poc/internal/platform/event/handler"
+	"go-shopping-poc/internal/platform/sse"
+	"go-shopping-poc/internal/service/cart"
+)
+
+// OnOrderEvent handles order.created and order.rejected events. Both
+// advance the checkout saga of the order's cart, and order.created is
+// pushed to the cart's SSE subscribers.
+type OnOrderEvent struct {
+	sagas  *cart.CheckoutSagas
+	sseHub *sse.Hub
+	logger *slog.Logger
+}
+
+// NewOnOrderEvent creates a new order event handler
+func NewOnOrderEvent(sagas *cart.CheckoutSagas, sseHub *sse.Hub, logger *slog.Logger) *OnOrderEvent {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &OnOrderEvent{
+		sagas:  sagas,
+		sseHub: sseHub,
+		logger: logger.With("component", "cart_on_order_event"),
+	}
+}
+
+func (h *OnOrderEvent) Handle(ctx context.Context, event events.Event) error {
+	orderEvent, ok := event.(events.OrderEvent)
+	if !ok {
+		h.logger.Error("Expected OrderEvent", "actual_type", fmt.Sprintf("%T", event))
+		return nil
+	}
+	log := h.logger.With(
+		"operation", "handle_order_event",
+		"event_id", orderEvent.ID,
+		"event_type", orderEvent.EventType,
+	)
+
+	log.Debug("Order event received",
+		"topic", orderEvent.Topic(),
+	)
+
+	switch orderEvent.EventType {
+	case events.OrderCreated, events.OrderRejected:
+	default:
+		log.Debug("Ignore event type")
+		return nil
+	}
+
+	utils := handler.NewEventUtils()
+	utils.LogEventProcessing(ctx, string(orderEvent.EventType),
+		orderEvent.Data.OrderID,
+		orderEvent.Data.CartID)
+
+	cartID := orderEvent.Data.CartID
+	var err error
+	if orderEvent.EventType == events.OrderCreated {
+		h.publishOrderCreated(orderEvent)
+		_, err = h.sagas.OrderCreated(ctx, cartID, orderEvent.Data.Attempt, orderEvent.Data.OrderID)
+	} else {
+		log.Warn("Order rejected", "cart_id", cartID, "reason", orderEvent.Data.Reason)
+		_, err = h.sagas.OrderRejected(ctx, cartID, orderEvent.Data.Attempt, orderEvent.Data.Reason)
+	}
+
+	// Carts checked out before checkout sagas were introduced
+	if errors.Is(err, cart.ErrCheckoutSagaNotFound) {
+		log.Warn("No checkout saga for order event", "cart_id", cartID)
+		err = nil
+	}
+	utils.LogEventCompletion(ctx, string(orderEvent.EventType), cartID, err)
+	if err != nil {
+		log.Error("Advance checkout saga failed", "cart_id", cartID, "error", err.Error())
+		return err
+	}
+	return nil
+}
+
+// publishOrderCreated pushes an order.created event to the cart's SSE
+// subscribers.
+func (h *OnOrderEvent) publishOrderCreated(orderEvent events.OrderEvent) {
+	log := h.logger.With("cart_id", orderEvent.Data.CartID)
+	if h.sseHub == nil {
+		log.Warn("SSE hub unavailable")
+		return
+	}
+
+	sseData := map[string]interface{}{
+		"order_id":     orderEvent.Data.OrderID,
+		"order_number": orderEvent.Data.OrderNumber,
+		"cart_id":      orderEvent.Data.CartID,
+		"total":        orderEvent.Data.Total,
+	}
+	h.sseHub.Publish(
+		orderEvent.Data.CartID,
+		"order.created",
+		sseData,
+	)
+	log.Info("Order event sent to frontend", "order_id", orderEvent.Data.OrderID)
+}
+
+// EventType returns the event types this handler processes
+func (h *OnOrderEvent) EventType() string {
+	return string(events.OrderCreated) + "," + string(events.OrderRejected)
+}
+
+// CreateHandler returns a bus.HandlerFunc that wraps the Handle method
+func (h *OnOrderEvent) CreateHandler() bus.HandlerFunc[events.OrderEvent] {
+	return func(ctx context.Context, event events.OrderEvent) error {
+		return h.Handle(ctx, event)
+	}
+}
+
+// CreateFactory returns an EventFactory for OrderEvent
+func (h *OnOrderEvent) CreateFactory() events.EventFactory[events.OrderEvent] {
+	return events.OrderEventFactory{}
+}
+
+// Ensure OnOrderEvent implements the shared interfaces
+var _ handler.EventHandler = (*OnOrderEvent)(nil)
+var _ handler.HandlerFactory[events.OrderEvent] = (*OnOrderEvent)(nil)
@@ -0,0 +1,90 @@
+package eventhandlers
+
+import (
+	"context"
+	"log/slog"
+
+	events "go-shopping-poc/internal/contracts/events"
+	"go-shopping-poc/internal/platform/event/bus"
+	"go-shopping-poc/internal/platform/event/handler"
+	"go-shopping-poc/internal/service/cart"
+)
+
+// OnOrderStatusChanged pushes order.status_changed events to the SSE
+// subscribers of the cart the order was placed from, so the shopper sees
+// the order move through its lifecycle live.
+type OnOrderStatusChanged struct {
+	sse    cart.SSEPublisher
+	logger *slog.Logger
+}
+
+// NewOnOrderStatusChanged creates a new order status change handler.
+func NewOnOrderStatusChanged(sse cart.SSEPublisher, logger *slog.Logger) *OnOrderStatusChanged {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &OnOrderStatusChanged{
+		sse:    sse,
+		logger: logger.With("component", "cart_on_order_status_changed"),
+	}
+}
+
+// Handle publishes an order.status_changed event to the cart's stream.
+// Other events on OrderEvents are ignored.
+func (h *OnOrderStatusChanged) Handle(ctx context.Context, event events.Event) error {
+	var changed events.OrderStatusChangedEvent
+	switch e := event.(type) {
+	case events.OrderStatusChangedEvent:
+		changed = e
+	case *events.OrderStatusChangedEvent:
+		changed = *e
+	default:
+		return nil
+	}
+	if changed.EventType != events.OrderStatusChanged {
+		return nil
+	}
+
+	data := changed.Data
+	log := h.logger.With("order_id", data.OrderID, "cart_id", data.CartID)
+	if data.CartID == "" {
+		log.Debug("Order status change without cart, not published")
+		return nil
+	}
+	if h.sse == nil {
+		log.Warn("SSE hub unavailable")
+		return nil
+	}
+
+	h.sse.Publish(data.CartID, "order.status_changed", map[string]interface{}{
+		"order_id":     data.OrderID,
+		"order_number": data.OrderNumber,
+		"from_status":  data.FromStatus,
+		"to_status":    data.ToStatus,
+		"notes":        data.Notes,
+		"actor":        data.Actor,
+		"changed_at":   data.ChangedAt,
+	})
+	log.Info("Order status change sent to frontend", "from_status", data.FromStatus, "to_status", data.ToStatus)
+	return nil
+}
+
+// CreateHandler returns a bus.HandlerFunc that wraps the Handle method.
+func (h *OnOrderStatusChanged) CreateHandler() bus.HandlerFunc[events.OrderStatusChangedEvent] {
+	return func(ctx context.Context, event events.OrderStatusChangedEvent) error {
+		return h.Handle(ctx, event)
+	}
+}
+
+// CreateFactory returns an EventFactory for OrderStatusChangedEvent.
+func (h *OnOrderStatusChanged) CreateFactory() events.EventFactory[events.OrderStatusChangedEvent] {
+	return events.OrderStatusChangedEventFactory{}
+}
+
+// Ensure OnOrderStatusChanged implements handler.EventHandler.
+var _ handler.EventHandler = (*OnOrderStatusChanged)(nil)
+
+// EventType returns the event types this handler processes.
+func (h *OnOrderStatusChanged) EventType() string {
+	return string(events.OrderStatusChanged)
+}
@@ -0,0 +1,84 @@
+package eventhandlers_test
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	events "go-shopping-poc/internal/contracts/events"
+	"go-shopping-poc/internal/platform/money/moneytest"
+	"go-shopping-poc/internal/service/cart/eventhandlers"
+)
+
+func TestOnOrderStatusChangedPublishesToCartStream(t *testing.T) {
+	t.Parallel()
+
+	changedAt := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		event    events.Event
+		byValue  bool
+		cartID   string
+		wantSent bool
+	}{
+		{
+			name:     "status change is sent to the cart stream",
+			event:    events.NewOrderStatusChangedEvent("o1", "ORD-1", "c1", nil, "confirmed", "processing", "picked", "staff:ann", changedAt),
+			byValue:  true,
+			cartID:   "c1",
+			wantSent: true,
+		},
+		{
+			name:     "pointer events are sent too",
+			event:    events.NewOrderStatusChangedEvent("o2", "ORD-2", "c2", nil, "created", "cancelled", "", "system", changedAt),
+			cartID:   "c2",
+			wantSent: true,
+		},
+		{
+			name:   "status change without cart is not sent",
+			event:  events.NewOrderStatusChangedEvent("o3", "ORD-3", "", nil, "created", "confirmed", "", "system", changedAt),
+			cartID: "",
+		},
+		{
+			name:   "other order events are ignored",
+			event:  events.NewOrderCancelledEvent("o4", "ORD-4", "c4", nil, moneytest.USD("10.00")),
+			cartID: "c4",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rec := &sseRecorder{}
+			h := eventhandlers.NewOnOrderStatusChanged(rec, slog.New(slog.DiscardHandler))
+			event := tt.event
+			if changed, ok := event.(*events.OrderStatusChangedEvent); ok && tt.byValue {
+				event = *changed
+			}
+			if err := h.Handle(context.Background(), event); err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+
+			names := rec.names(tt.cartID)
+			if !tt.wantSent {
+				if len(names) != 0 {
+					t.Fatalf("published %v, want nothing", names)
+				}
+				return
+			}
+			if len(names) != 1 || names[0] != "order.status_changed" {
+				t.Fatalf("published %v, want [order.status_changed]", names)
+			}
+			data := tt.event.(*events.OrderStatusChangedEvent).Data
+			got := rec.last(tt.cartID).data
+			if got["order_id"] != data.OrderID || got["from_status"] != data.FromStatus ||
+				got["to_status"] != data.ToStatus || got["actor"] != data.Actor || got["changed_at"] != changedAt {
+				t.Errorf("published data = %v, want %+v", got, data)
+			}
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 	events "go-shopping-poc/internal/contracts/events"
 	"go-shopping-poc/internal/platform/event/bus"
 	"go-shopping-poc/internal/platform/event/handler"
+	"go-shopping-poc/internal/platform/money"
 	"go-shopping-poc/internal/service/cart"
 )
 
@@ -108,21 +109,28 @@ func (h *OnProductEvent) handleProductValidated(_ context.Context, event events.
 
 	inStock := true
 
-	finalPrice := 0.0
+	var finalPrice money.Money
 	if priceStr := details["unit_price"]; priceStr != "" {
-		if parsed, err := strconv.ParseFloat(priceStr, 64); err == nil {
+		if parsed, err := money.Parse(priceStr, details["currency"]); err == nil {
 			finalPrice = parsed
 		}
 	}
 
 	name := details["product_name"]
+	category := details["category"]
+	brand := details["brand"]
 
-	h.cache.Set(productID, cart.ProductEntry{
+	entry := cart.ProductEntry{
 		ProductID:  productID,
 		InStock:    inStock,
 		FinalPrice: finalPrice,
 		Name:       name,
-	})
+		Category:   category,
+		Brand:      brand,
+		WeightLb:   events.DecodeWeight(details["weight_lb"]),
+	}
+	setVariantOptions(&entry, details)
+	h.cache.Set(productID, entry, event.Timestamp)
 
 	h.logger.Debug("Product cache updated from validation",
 		"product_id", productID,
@@ -147,21 +155,30 @@ func (h *OnProductEvent) handleProductUnavailable(_ context.Context, event event
 
 	inStock := false
 
-	finalPrice := 0.0
+	var finalPrice money.Money
 	if priceStr := details["unit_price"]; priceStr != "" {
-		if parsed, err := strconv.ParseFloat(priceStr, 64); err == nil {
+		if parsed, err := money.Parse(priceStr, details["currency"]); err == nil {
 			finalPrice = parsed
 		}
 	}
 
 	name := details["product_name"]
-
-	h.cache.Set(productID, cart.ProductEntry{
+	entry := cart.ProductEntry{
 		ProductID:  productID,
 		InStock:    inStock,
 		FinalPrice: finalPrice,
 		Name:       name,
-	})
+	}
+	if existing, ok := h.cache.Peek(productID); ok {
+		entry.Category = existing.Category
+		entry.Brand = existing.Brand
+		entry.Sizes = existing.Sizes
+		entry.Color = existing.Color
+		entry.VariantsKnown = existing.VariantsKnown
+		entry.WeightLb = existing.WeightLb
+	}
+
+	h.cache.Set(productID, entry, event.Timestamp)
 
 	h.logger.Debug("Product cache updated from unavailable event",
 		"product_id", productID,
@@ -193,21 +210,42 @@ func (h *OnProductEvent) upsertProduct(event events.ProductEvent) {
 		}
 	}
 
-	finalPrice := 0.0
+	var finalPrice money.Money
 	if priceStr := details["final_price"]; priceStr != "" {
-		if parsed, err := strconv.ParseFloat(priceStr, 64); err == nil {
+		if parsed, err := money.Parse(priceStr, details["currency"]); err == nil {
 			finalPrice = parsed
 		}
 	}
 
 	name := details["name"]
+	category := details["category"]
+	brand := details["brand"]
 
-	h.cache.Set(productID, cart.ProductEntry{
+	entry := cart.ProductEntry{
 		ProductID:  productID,
 		InStock:    inStock,
 		FinalPrice: finalPrice,
 		Name:       name,
-	})
+		Category:   category,
+		Brand:      brand,
+		WeightLb:   events.DecodeWeight(details["weight_lb"]),
+	}
+	setVariantOptions(&entry, details)
+	h.cache.Set(productID, entry, event.Timestamp)
+}
+
+// setVariantOptions records the sizes and color of a product event in
+// entry. Events written before products published them leave the options
+// unknown.
+func setVariantOptions(entry *cart.ProductEntry, details map[string]string) {
+	_, hasSizes := details["sizes"]
+	_, hasColor := details["color"]
+	if !hasSizes && !hasColor {
+		return
+	}
+	entry.Sizes = events.DecodeSizes(details["sizes"])
+	entry.Color = details["color"]
+	entry.VariantsKnown = true
 }
 
 // CreateHandler returns a bus.HandlerFunc that wraps the Handle method.
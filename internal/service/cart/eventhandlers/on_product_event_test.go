@@ -0,0 +1,78 @@
+package eventhandlers_test
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	events "go-shopping-poc/internal/contracts/events"
+	"go-shopping-poc/internal/platform/money"
+	"go-shopping-poc/internal/service/cart"
+	"go-shopping-poc/internal/service/cart/eventhandlers"
+)
+
+func TestOnProductEventStampsEventTimestamp(t *testing.T) {
+	t.Parallel()
+
+	const ttl = time.Hour
+
+	tests := []struct {
+		name    string
+		event   *events.ProductEvent
+		age     time.Duration
+		wantGet bool
+	}{
+		{
+			name:    "recent created event is fresh",
+			event:   events.NewProductCreatedEvent("p1", map[string]string{"name": "Shirt"}),
+			age:     time.Minute,
+			wantGet: true,
+		},
+		{
+			name:    "replayed created event older than the TTL is stale",
+			event:   events.NewProductCreatedEvent("p1", map[string]string{"name": "Shirt"}),
+			age:     2 * ttl,
+			wantGet: false,
+		},
+		{
+			name:    "replayed validated event older than the TTL is stale",
+			event:   events.NewProductValidatedEvent("p1", "Shirt", "tops", "acme", money.MustParse("10.00", "USD"), nil, "", 0, "c1", "001", "v1"),
+			age:     2 * ttl,
+			wantGet: false,
+		},
+		{
+			name:    "replayed unavailable event older than the TTL is stale",
+			event:   events.NewProductUnavailableEvent("p1", "out of stock", "c1", "001", "v1"),
+			age:     2 * ttl,
+			wantGet: false,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cache := cart.NewProductCacheWithTTL(ttl)
+			h := eventhandlers.NewOnProductEvent(cache, slog.New(slog.DiscardHandler))
+
+			event := *tt.event
+			event.Timestamp = time.Now().Add(-tt.age).Truncate(time.Second)
+			if err := h.Handle(context.Background(), event); err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+
+			entry, ok := cache.Peek("p1")
+			if !ok {
+				t.Fatal("Peek() found no entry")
+			}
+			if !entry.UpdatedAt.Equal(event.Timestamp) {
+				t.Errorf("UpdatedAt = %v, want the event timestamp %v", entry.UpdatedAt, event.Timestamp)
+			}
+			if _, ok := cache.Get("p1"); ok != tt.wantGet {
+				t.Errorf("Get() found = %v, want %v", ok, tt.wantGet)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package eventhandlers
+
+import (
+	"context"
+	"log/slog"
+
+	events "go-shopping-poc/internal/contracts/events"
+	"go-shopping-poc/internal/platform/event/bus"
+	"go-shopping-poc/internal/platform/event/handler"
+	"go-shopping-poc/internal/platform/money"
+	"go-shopping-poc/internal/service/cart"
+)
+
+// OnProductPriceChanged reprices the lines of active carts when a
+// product.updated event reports a final price other than the one they were
+// priced at. Repriced lines are flagged until the shopper acknowledges the
+// change, see cart.CartService.ReconcileProductPrice.
+//
+// It only runs on live events: the product cache bootstrap replays
+// historical events through OnProductEvent alone.
+type OnProductPriceChanged struct {
+	service *cart.CartService
+	logger  *slog.Logger
+}
+
+// NewOnProductPriceChanged creates a new product price change handler.
+func NewOnProductPriceChanged(service *cart.CartService, logger *slog.Logger) *OnProductPriceChanged {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &OnProductPriceChanged{
+		service: service,
+		logger:  logger.With("component", "cart_on_product_price_changed"),
+	}
+}
+
+// Handle reconciles carts against the final price of a product.updated
+// event. Events without a parsable final price are ignored.
+func (h *OnProductPriceChanged) Handle(ctx context.Context, event events.Event) error {
+	productEvent, ok := event.(events.ProductEvent)
+	if !ok {
+		h.logger.Error("Expected ProductEvent", "actual_type", event.Type())
+		return nil
+	}
+	if productEvent.EventType != events.ProductUpdated {
+		return nil
+	}
+
+	productID := productEvent.EventPayload.ProductID
+	if productID == "" {
+		productID = productEvent.EventPayload.ResourceID
+	}
+	log := h.logger.With("product_id", productID, "event_id", productEvent.ID)
+
+	details := productEvent.EventPayload.Details
+	priceStr := details["final_price"]
+	if priceStr == "" {
+		return nil
+	}
+	price, err := money.Parse(priceStr, details["currency"])
+	if err != nil {
+		log.Warn("Invalid final price in product event", "final_price", priceStr, "error", err.Error())
+		return nil
+	}
+
+	repriced, err := h.service.ReconcileProductPrice(ctx, productID, price)
+	if err != nil {
+		log.Error("Reprice carts failed", "error", err.Error())
+		return err
+	}
+	if repriced > 0 {
+		log.Info("Carts repriced after product price change", "lines", repriced, "final_price", price.String())
+	}
+	return nil
+}
+
+// CreateHandler returns a bus.HandlerFunc that wraps the Handle method.
+func (h *OnProductPriceChanged) CreateHandler() bus.HandlerFunc[events.ProductEvent] {
+	return func(ctx context.Context, event events.ProductEvent) error {
+		return h.Handle(ctx, event)
+	}
+}
+
+// CreateFactory returns an EventFactory for ProductEvent.
+func (h *OnProductPriceChanged) CreateFactory() events.EventFactory[events.ProductEvent] {
+	return events.ProductEventFactory{}
+}
+
+// Ensure OnProductPriceChanged implements handler.EventHandler.
+var _ handler.EventHandler = (*OnProductPriceChanged)(nil)
+
+// EventType returns the event types this handler processes.
+func (h *OnProductPriceChanged) EventType() string {
+	return string(events.ProductUpdated)
+}
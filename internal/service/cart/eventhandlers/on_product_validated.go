@@ -2,13 +2,14 @@ package eventhandlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
-	"strconv"
 
 	events "go-shopping-poc/internal/contracts/events"
 	"go-shopping-poc/internal/platform/event/bus"
 	"go-shopping-poc/internal/platform/event/handler"
+	"go-shopping-poc/internal/platform/money"
 	"go-shopping-poc/internal/platform/sse"
 	"go-shopping-poc/internal/service/cart"
 )
@@ -16,20 +17,22 @@ import (
 // OnProductValidated handles product validation events
 // It updates cart items based on product validation results
 type OnProductValidated struct {
-	repo   cart.CartRepository
-	sseHub *sse.Hub
-	logger *slog.Logger
+	repo    cart.CartRepository
+	pricing cart.Pricing
+	sseHub  *sse.Hub
+	logger  *slog.Logger
 }
 
 // NewOnProductValidated creates a new product validation handler
-func NewOnProductValidated(repo cart.CartRepository, sseHub *sse.Hub, logger *slog.Logger) *OnProductValidated {
+func NewOnProductValidated(repo cart.CartRepository, pricing cart.Pricing, sseHub *sse.Hub, logger *slog.Logger) *OnProductValidated {
 	if logger == nil {
 		logger = slog.Default()
 	}
 	return &OnProductValidated{
-		repo:   repo,
-		sseHub: sseHub,
-		logger: logger.With("component", "cart_on_product_validated"),
+		repo:    repo,
+		pricing: pricing,
+		sseHub:  sseHub,
+		logger:  logger.With("component", "cart_on_product_validated"),
 	}
 }
 
@@ -53,10 +56,10 @@ func (h *OnProductValidated) Handle(ctx context.Context, event events.Event) err
 	switch productEvent.EventType {
 	case events.ProductValidated:
 		log.Debug("Process product validated event", "product_id", productEvent.EventPayload.ProductID)
-		return h.handleProductValidated(ctx, productEvent)
+		return cart.RetryOnVersionConflict(func() error { return h.handleProductValidated(ctx, productEvent) })
 	case events.ProductUnavailable:
 		log.Debug("Process product unavailable event", "product_id", productEvent.EventPayload.ProductID)
-		return h.handleProductUnavailable(ctx, productEvent)
+		return cart.RetryOnVersionConflict(func() error { return h.handleProductUnavailable(ctx, productEvent) })
 	default:
 		log.Debug("Ignore product event type", "event_type", productEvent.EventType)
 		return nil
@@ -103,11 +106,23 @@ func (h *OnProductValidated) handleProductValidated(ctx context.Context, event e
 		log.Debug("Item not found in cart", "line_number", lineNumber, "cart_id", cartID)
 		return nil
 	}
+	if !targetItem.IsPendingValidation() {
+		log.Debug("Item already validated", "line_number", lineNumber, "cart_id", cartID, "status", targetItem.Status)
+		return nil
+	}
+
+	// Check the variant selected for the line against the product's options
+	var options cart.ProductEntry
+	setVariantOptions(&options, details)
+	if err := targetItem.ResolveVariant(options); err != nil {
+		log.Warn("Selected variant not offered", "line_number", lineNumber, "size", targetItem.Size, "color", targetItem.Color, "error", err.Error())
+		return h.backorderItem(ctx, cartObj, targetItem, productID, "variant_unavailable")
+	}
 
 	// Parse unit price from details
-	unitPrice := 0.0
+	var unitPrice money.Money
 	if priceStr := details["unit_price"]; priceStr != "" {
-		if parsed, err := strconv.ParseFloat(priceStr, 64); err == nil {
+		if parsed, err := money.Parse(priceStr, details["currency"]); err == nil {
 			unitPrice = parsed
 		}
 	}
@@ -118,27 +133,35 @@ func (h *OnProductValidated) handleProductValidated(ctx context.Context, event e
 		productName = targetItem.ProductName // Keep existing if not provided
 	}
 
-	if err := targetItem.ConfirmItem(productName, unitPrice); err != nil {
-		log.Error("Confirm cart item failed", "line_number", lineNumber, "error", err.Error())
-		return err
+	// Price the item in the cart currency. A product that cannot be priced
+	// in it (mixed currencies rejected, or no exchange rate) is backordered
+	// so the shopper sees why instead of the line staying pending.
+	if err := h.pricing.PriceItem(ctx, targetItem, unitPrice, cartObj.Currency); err != nil {
+		reason := "currency_mismatch"
+		if !errors.Is(err, cart.ErrCurrencyMismatch) {
+			reason = "exchange_rate_unavailable"
+		}
+		log.Warn("Cannot price item in cart currency", "line_number", lineNumber, "currency", cartObj.Currency, "error", err.Error())
+		return h.backorderItem(ctx, cartObj, targetItem, productID, reason)
 	}
+	unitPrice = targetItem.UnitPrice
 
-	// Update item in database
-	if err := h.repo.UpdateItemStatus(ctx, targetItem); err != nil {
-		log.Error("Update item status failed", "line_number", lineNumber, "error", err.Error())
+	taxCode := h.pricing.Tax.TaxCode(details["category"])
+	if err := targetItem.ConfirmItem(productName, unitPrice, taxCode); err != nil {
+		log.Error("Confirm cart item failed", "line_number", lineNumber, "error", err.Error())
 		return err
 	}
+	targetItem.Category = details["category"]
+	targetItem.Brand = details["brand"]
+	targetItem.WeightLb = events.DecodeWeight(details["weight_lb"])
 
-	// Recalculate cart totals
-	for i := range cartObj.Items {
-		if cartObj.Items[i].LineNumber == targetItem.LineNumber {
-			cartObj.Items[i] = *targetItem
-			break
-		}
+	// Recalculate cart totals and save them with the item
+	if err := cartObj.CalculateTotals(ctx, h.pricing); err != nil {
+		log.Error("Calculate cart totals failed", "cart_id", cartID, "error", err.Error())
+		return err
 	}
-	cartObj.CalculateTotals()
-	if err := h.repo.UpdateCart(ctx, cartObj); err != nil {
-		log.Error("Update cart totals failed", "cart_id", cartID, "error", err.Error())
+	if err := h.repo.UpdateItemStatusAndTotals(ctx, cartObj, targetItem); err != nil {
+		log.Error("Update item status failed", "line_number", lineNumber, "error", err.Error())
 		return err
 	}
 
@@ -206,6 +229,20 @@ func (h *OnProductValidated) handleProductUnavailable(ctx context.Context, event
 		log.Debug("Item not found in cart", "line_number", lineNumber, "cart_id", cartID)
 		return nil
 	}
+	if !targetItem.IsPendingValidation() {
+		log.Debug("Item already validated", "line_number", lineNumber, "cart_id", cartID, "status", targetItem.Status)
+		return nil
+	}
+
+	return h.backorderItem(ctx, cartObj, targetItem, productID, reason)
+}
+
+// backorderItem marks a pending item as backordered, saves the cart totals
+// and notifies the shopper.
+func (h *OnProductValidated) backorderItem(ctx context.Context, cartObj *cart.Cart, targetItem *cart.CartItem, productID, reason string) error {
+	cartID := cartObj.CartID.String()
+	lineNumber := targetItem.LineNumber
+	log := h.logger.With("operation", "backorder_item", "product_id", productID)
 
 	// Mark as backorder
 	if err := targetItem.MarkAsBackorder(reason); err != nil {
@@ -213,22 +250,13 @@ func (h *OnProductValidated) handleProductUnavailable(ctx context.Context, event
 		return err
 	}
 
-	// Update item in database
-	if err := h.repo.UpdateItemStatus(ctx, targetItem); err != nil {
-		log.Error("Update item status failed", "line_number", lineNumber, "error", err.Error())
+	// Recalculate cart totals and save them with the item
+	if err := cartObj.CalculateTotals(ctx, h.pricing); err != nil {
+		log.Error("Calculate cart totals failed", "cart_id", cartID, "error", err.Error())
 		return err
 	}
-
-	// Recalculate cart totals
-	for i := range cartObj.Items {
-		if cartObj.Items[i].LineNumber == targetItem.LineNumber {
-			cartObj.Items[i] = *targetItem
-			break
-		}
-	}
-	cartObj.CalculateTotals()
-	if err := h.repo.UpdateCart(ctx, cartObj); err != nil {
-		log.Error("Update cart totals failed", "cart_id", cartID, "error", err.Error())
+	if err := h.repo.UpdateItemStatusAndTotals(ctx, cartObj, targetItem); err != nil {
+		log.Error("Update item status failed", "line_number", lineNumber, "error", err.Error())
 		return err
 	}
 
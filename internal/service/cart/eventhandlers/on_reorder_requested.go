@@ -0,0 +1,94 @@
+package eventhandlers
+
+import (
+	"context"
+	"log/slog"
+
+	events "go-shopping-poc/internal/contracts/events"
+	"go-shopping-poc/internal/platform/event/bus"
+	"go-shopping-poc/internal/platform/event/handler"
+	"go-shopping-poc/internal/service/cart"
+)
+
+// OnReorderRequested adds the items of a past order to the customer's
+// active cart on order.reorder_requested, and answers the order service
+// with a cart.reorder_completed event reporting each item's outcome. A
+// redelivered request is answered with its recorded outcome.
+type OnReorderRequested struct {
+	service *cart.CartService
+	logger  *slog.Logger
+}
+
+// NewOnReorderRequested creates a new reorder request handler.
+func NewOnReorderRequested(service *cart.CartService, logger *slog.Logger) *OnReorderRequested {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &OnReorderRequested{
+		service: service,
+		logger:  logger.With("component", "cart_on_reorder_requested"),
+	}
+}
+
+// Handle runs the reorder of an order.reorder_requested event. Other events
+// on OrderEvents are ignored.
+func (h *OnReorderRequested) Handle(ctx context.Context, event events.Event) error {
+	var reqEvent events.OrderReorderRequestEvent
+	switch e := event.(type) {
+	case events.OrderReorderRequestEvent:
+		reqEvent = e
+	case *events.OrderReorderRequestEvent:
+		reqEvent = *e
+	default:
+		return nil
+	}
+	if reqEvent.EventType != events.OrderReorderRequested {
+		return nil
+	}
+
+	data := reqEvent.Data
+	log := h.logger.With("request_id", data.RequestID, "order_id", data.OrderID, "customer_id", data.CustomerID)
+	if data.RequestID == "" {
+		log.Warn("Received reorder request with empty request ID")
+		return nil
+	}
+
+	c, lines, err := h.service.Reorder(ctx, data.RequestID, data.CustomerID, data.Currency, data.Items)
+	if err != nil {
+		log.Error("Reorder failed", "error", err.Error())
+		h.publishResult(ctx, data, "", nil, err.Error())
+		return nil
+	}
+
+	cartID := c.CartID.String()
+	log.Info("Reorder completed", "cart_id", cartID, "lines", len(lines))
+	h.publishResult(ctx, data, cartID, lines, "")
+	return nil
+}
+
+func (h *OnReorderRequested) publishResult(ctx context.Context, req events.OrderReorderRequestPayload, cartID string, lines []events.ReorderLineResult, errStr string) {
+	respEvent := events.NewCartReorderCompletedEvent(req.RequestID, req.OrderID, cartID, req.CustomerID, lines, errStr)
+	if err := h.service.EventBus().Publish(ctx, respEvent.Topic(), respEvent); err != nil {
+		h.logger.Error("Failed to publish reorder result", "request_id", req.RequestID, "error", err.Error())
+	}
+}
+
+// CreateHandler returns a bus.HandlerFunc that wraps the Handle method.
+func (h *OnReorderRequested) CreateHandler() bus.HandlerFunc[events.OrderReorderRequestEvent] {
+	return func(ctx context.Context, event events.OrderReorderRequestEvent) error {
+		return h.Handle(ctx, event)
+	}
+}
+
+// CreateFactory returns an EventFactory for OrderReorderRequestEvent.
+func (h *OnReorderRequested) CreateFactory() events.EventFactory[events.OrderReorderRequestEvent] {
+	return events.OrderReorderRequestEventFactory{}
+}
+
+// Ensure OnReorderRequested implements handler.EventHandler.
+var _ handler.EventHandler = (*OnReorderRequested)(nil)
+
+// EventType returns the event types this handler processes.
+func (h *OnReorderRequested) EventType() string {
+	return string(events.OrderReorderRequested)
+}
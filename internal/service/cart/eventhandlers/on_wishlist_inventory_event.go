@@ -0,0 +1,92 @@
+package eventhandlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	events "go-shopping-poc/internal/contracts/events"
+	"go-shopping-poc/internal/platform/event/bus"
+	"go-shopping-poc/internal/platform/event/handler"
+	"go-shopping-poc/internal/service/cart"
+)
+
+// OnWishlistInventoryEvent keeps the stock of the products saved to
+// wishlists current from inventory events, which report the stock left
+// after reservations, releases and restocks that product.updated events do
+// not carry. A product with stock left is in stock; stock alerts are pushed
+// like those of OnWishlistProductEvent.
+type OnWishlistInventoryEvent struct {
+	service *cart.CartService
+	logger  *slog.Logger
+}
+
+// NewOnWishlistInventoryEvent creates a new wishlist inventory event handler.
+func NewOnWishlistInventoryEvent(service *cart.CartService, logger *slog.Logger) *OnWishlistInventoryEvent {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &OnWishlistInventoryEvent{
+		service: service,
+		logger:  logger.With("component", "cart_on_wishlist_inventory_event"),
+	}
+}
+
+// Handle records the stock of each product of an inventory event on the
+// wishlists holding it.
+func (h *OnWishlistInventoryEvent) Handle(ctx context.Context, event events.Event) error {
+	inventoryEvent, ok := event.(events.InventoryEvent)
+	if !ok {
+		h.logger.Error("Expected InventoryEvent", "actual_type", fmt.Sprintf("%T", event))
+		return nil
+	}
+
+	switch inventoryEvent.EventType {
+	case events.InventoryReserved, events.InventoryReservationFailed,
+		events.InventoryCommitted, events.InventoryReleased, events.InventoryRestocked:
+	default:
+		return nil
+	}
+
+	log := h.logger.With("event_id", inventoryEvent.ID, "event_type", inventoryEvent.EventType)
+	for _, line := range inventoryEvent.Data.Lines {
+		if line.ProductID == "" {
+			continue
+		}
+		inStock := line.Available > 0
+		updated, err := h.service.UpdateSavedProduct(ctx, line.ProductID, "", nil, &inStock)
+		if err != nil {
+			log.Error("Update saved product stock failed", "product_id", line.ProductID, "error", err.Error())
+			return err
+		}
+		if updated > 0 {
+			log.Debug("Saved product stock updated on wishlists", "product_id", line.ProductID, "items", updated)
+		}
+	}
+	return nil
+}
+
+// EventType returns the event types this handler processes.
+func (h *OnWishlistInventoryEvent) EventType() string {
+	return string(events.InventoryReserved) + "," +
+		string(events.InventoryReservationFailed) + "," +
+		string(events.InventoryCommitted) + "," +
+		string(events.InventoryReleased) + "," +
+		string(events.InventoryRestocked)
+}
+
+// CreateHandler returns a bus.HandlerFunc that wraps the Handle method.
+func (h *OnWishlistInventoryEvent) CreateHandler() bus.HandlerFunc[events.InventoryEvent] {
+	return func(ctx context.Context, event events.InventoryEvent) error {
+		return h.Handle(ctx, event)
+	}
+}
+
+// CreateFactory returns an EventFactory for InventoryEvent.
+func (h *OnWishlistInventoryEvent) CreateFactory() events.EventFactory[events.InventoryEvent] {
+	return events.InventoryEventFactory{}
+}
+
+// Ensure OnWishlistInventoryEvent implements the shared interfaces
+var _ handler.EventHandler = (*OnWishlistInventoryEvent)(nil)
+var _ handler.HandlerFactory[events.InventoryEvent] = (*OnWishlistInventoryEvent)(nil)
@@ -0,0 +1,107 @@
+package eventhandlers
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+
+	events "go-shopping-poc/internal/contracts/events"
+	"go-shopping-poc/internal/platform/event/bus"
+	"go-shopping-poc/internal/platform/event/handler"
+	"go-shopping-poc/internal/platform/money"
+	"go-shopping-poc/internal/service/cart"
+)
+
+// OnWishlistProductEvent keeps the products saved to wishlists current
+// from product.updated events, and has stock and price alerts pushed to
+// the SSE subscribers of the lists holding a product whose in_stock or
+// final_price changed. OnWishlistInventoryEvent keeps the stock current
+// between product updates.
+//
+// Like OnProductPriceChanged it only runs on live events.
+type OnWishlistProductEvent struct {
+	service *cart.CartService
+	logger  *slog.Logger
+}
+
+// NewOnWishlistProductEvent creates a new wishlist product event handler.
+func NewOnWishlistProductEvent(service *cart.CartService, logger *slog.Logger) *OnWishlistProductEvent {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &OnWishlistProductEvent{
+		service: service,
+		logger:  logger.With("component", "cart_on_wishlist_product_event"),
+	}
+}
+
+// Handle updates the saved items of the product of a product.updated
+// event. Fields missing from the event or failing to parse are left as
+// they were.
+func (h *OnWishlistProductEvent) Handle(ctx context.Context, event events.Event) error {
+	productEvent, ok := event.(events.ProductEvent)
+	if !ok {
+		h.logger.Error("Expected ProductEvent", "actual_type", event.Type())
+		return nil
+	}
+	if productEvent.EventType != events.ProductUpdated {
+		return nil
+	}
+
+	productID := productEvent.EventPayload.ProductID
+	if productID == "" {
+		productID = productEvent.EventPayload.ResourceID
+	}
+	log := h.logger.With("product_id", productID, "event_id", productEvent.ID)
+	details := productEvent.EventPayload.Details
+
+	var price *money.Money
+	if priceStr := details["final_price"]; priceStr != "" {
+		if parsed, err := money.Parse(priceStr, details["currency"]); err == nil {
+			price = &parsed
+		} else {
+			log.Warn("Invalid final price in product event", "final_price", priceStr, "error", err.Error())
+		}
+	}
+
+	var inStock *bool
+	if stockStr := details["in_stock"]; stockStr != "" {
+		if parsed, err := strconv.ParseBool(stockStr); err == nil {
+			inStock = &parsed
+		}
+	}
+
+	if price == nil && inStock == nil && details["name"] == "" {
+		return nil
+	}
+
+	updated, err := h.service.UpdateSavedProduct(ctx, productID, details["name"], price, inStock)
+	if err != nil {
+		log.Error("Update saved product failed", "error", err.Error())
+		return err
+	}
+	if updated > 0 {
+		log.Debug("Saved product updated on wishlists", "items", updated)
+	}
+	return nil
+}
+
+// CreateHandler returns a bus.HandlerFunc that wraps the Handle method.
+func (h *OnWishlistProductEvent) CreateHandler() bus.HandlerFunc[events.ProductEvent] {
+	return func(ctx context.Context, event events.ProductEvent) error {
+		return h.Handle(ctx, event)
+	}
+}
+
+// CreateFactory returns an EventFactory for ProductEvent.
+func (h *OnWishlistProductEvent) CreateFactory() events.EventFactory[events.ProductEvent] {
+	return events.ProductEventFactory{}
+}
+
+// Ensure OnWishlistProductEvent implements handler.EventHandler.
+var _ handler.EventHandler = (*OnWishlistProductEvent)(nil)
+
+// EventType returns the event types this handler processes.
+func (h *OnWishlistProductEvent) EventType() string {
+	return string(events.ProductUpdated)
+}
@@ -2,27 +2,35 @@ package cart
 
 import (
 	"errors"
+	"io"
 	"log/slog"
 	"net/http"
 
+	"go-shopping-poc/internal/platform/auth"
 	"go-shopping-poc/internal/platform/httperr"
 	"go-shopping-poc/internal/platform/httpx"
 )
 
 type CreateCartRequest struct {
 	CustomerID *string `json:"customer_id,omitempty"`
+	Currency   string  `json:"currency,omitempty"`
 }
 
 type AddItemRequest struct {
 	ProductID string `json:"product_id"`
 	Quantity  int    `json:"quantity"`
 	ImageURL  string `json:"image_url"`
+	LineAttributes
 }
 
 type UpdateItemRequest struct {
 	Quantity int `json:"quantity"`
 }
 
+type UpdateItemsRequest struct {
+	Operations []ItemOperation `json:"operations"`
+}
+
 type SetContactRequest struct {
 	Email     string `json:"email"`
 	FirstName string `json:"first_name"`
@@ -49,6 +57,36 @@ type SetPaymentRequest struct {
 	CardCVV        string `json:"card_cvv"`
 }
 
+type SetShippingRequest struct {
+	Method string `json:"method"`
+}
+
+type SetCurrencyRequest struct {
+	Currency string `json:"currency"`
+}
+
+type ApplyCouponRequest struct {
+	Code string `json:"code"`
+}
+
+type CreateWishlistRequest struct {
+	Name string `json:"name"`
+}
+
+type AddWishlistItemRequest struct {
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity,omitempty"`
+	ImageURL  string `json:"image_url,omitempty"`
+}
+
+type SaveForLaterRequest struct {
+	WishlistID string `json:"wishlist_id,omitempty"`
+}
+
+type MoveToCartRequest struct {
+	CartID string `json:"cart_id"`
+}
+
 type CartHandler struct {
 	service *CartService
 	logger  *slog.Logger
@@ -65,9 +103,9 @@ func (h *CartHandler) CreateCart(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cart, err := h.service.CreateCart(r.Context(), req.CustomerID)
+	cart, err := h.service.CreateCart(r.Context(), req.CustomerID, req.Currency)
 	if err != nil {
-		httperr.Internal(w, "Failed to create cart")
+		httperr.FromError(w, err, "Failed to create cart")
 		return
 	}
 
@@ -84,14 +122,11 @@ func (h *CartHandler) GetCart(w http.ResponseWriter, r *http.Request) {
 
 	cart, err := h.service.GetCart(r.Context(), cartID)
 	if err != nil {
-		if errors.Is(err, ErrCartNotFound) {
-			httperr.NotFound(w, "Cart not found")
-			return
-		}
-		httperr.Internal(w, "Failed to get cart")
+		httperr.FromError(w, err, "Failed to get cart")
 		return
 	}
 
+	w.Header().Set("ETag", ETag(cart.Version))
 	if err := httpx.WriteJSON(w, http.StatusOK, cart); err != nil {
 		h.logger.Error("Failed to write get cart response", "error", err.Error())
 	}
@@ -104,11 +139,7 @@ func (h *CartHandler) DeleteCart(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.service.DeleteCart(r.Context(), cartID); err != nil {
-		if errors.Is(err, ErrCartNotFound) {
-			httperr.NotFound(w, "Cart not found")
-			return
-		}
-		httperr.Internal(w, "Failed to delete cart")
+		httperr.FromError(w, err, "Failed to delete cart")
 		return
 	}
 
@@ -127,8 +158,15 @@ func (h *CartHandler) AddItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.ProductID == "" || req.Quantity <= 0 {
-		httperr.Validation(w, "Invalid product_id or quantity")
+	var fieldErrs []httperr.FieldError
+	if req.ProductID == "" {
+		fieldErrs = append(fieldErrs, httperr.FieldError{Field: "product_id", Message: "product_id is required"})
+	}
+	if req.Quantity <= 0 {
+		fieldErrs = append(fieldErrs, httperr.FieldError{Field: "quantity", Message: "quantity must be positive"})
+	}
+	if len(fieldErrs) > 0 {
+		httperr.ValidationFields(w, "Invalid product_id or quantity", fieldErrs...)
 		return
 	}
 
@@ -138,9 +176,9 @@ func (h *CartHandler) AddItem(w http.ResponseWriter, r *http.Request) {
 		"quantity", req.Quantity,
 		"image_url", req.ImageURL,
 	)
-	item, err := h.service.AddItem(r.Context(), cartID, req.ProductID, req.Quantity, req.ImageURL)
+	item, err := h.service.AddItem(r.Context(), cartID, req.ProductID, req.Quantity, req.ImageURL, req.LineAttributes)
 	if err != nil {
-		httperr.Internal(w, "Failed to add item")
+		httperr.FromError(w, err, "Failed to add item")
 		return
 	}
 
@@ -166,12 +204,13 @@ func (h *CartHandler) UpdateItem(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if req.Quantity <= 0 {
-		httperr.Validation(w, "Quantity must be positive")
+		httperr.ValidationFields(w, "Quantity must be positive",
+			httperr.FieldError{Field: "quantity", Message: "quantity must be positive"})
 		return
 	}
 
 	if err := h.service.UpdateItemQuantity(r.Context(), cartID, lineNumber, req.Quantity); err != nil {
-		httperr.Internal(w, "Failed to update item")
+		httperr.FromError(w, err, "Failed to update item")
 		return
 	}
 
@@ -189,13 +228,38 @@ func (h *CartHandler) RemoveItem(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.service.RemoveItem(r.Context(), cartID, lineNumber); err != nil {
-		httperr.Internal(w, "Failed to remove item")
+		httperr.FromError(w, err, "Failed to remove item")
 		return
 	}
 
 	httpx.WriteNoContent(w)
 }
 
+// UpdateItems adds, updates and removes many lines of a cart at once. The
+// operations are applied together or not at all.
+func (h *CartHandler) UpdateItems(w http.ResponseWriter, r *http.Request) {
+	cartID, ok := requiredPathParam(w, r, "id", "Missing cart ID")
+	if !ok {
+		return
+	}
+
+	var req UpdateItemsRequest
+	if err := httpx.DecodeJSON(r, &req); err != nil {
+		httperr.InvalidRequest(w, "Invalid JSON")
+		return
+	}
+
+	cart, err := h.service.UpdateItems(r.Context(), cartID, req.Operations)
+	if err != nil {
+		httperr.FromError(w, err, "Failed to update items")
+		return
+	}
+
+	if err := httpx.WriteJSON(w, http.StatusOK, cart); err != nil {
+		h.logger.Error("Failed to write update items response", "error", err.Error())
+	}
+}
+
 func (h *CartHandler) SetContact(w http.ResponseWriter, r *http.Request) {
 	cartID, ok := requiredPathParam(w, r, "id", "Missing cart ID")
 	if !ok {
@@ -216,7 +280,7 @@ func (h *CartHandler) SetContact(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.service.SetContact(r.Context(), cartID, contact); err != nil {
-		httperr.Internal(w, "Failed to set contact")
+		httperr.FromError(w, err, "Failed to set contact")
 		return
 	}
 
@@ -247,7 +311,7 @@ func (h *CartHandler) AddAddress(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.service.AddAddress(r.Context(), cartID, address); err != nil {
-		httperr.Internal(w, "Failed to add address")
+		httperr.FromError(w, err, "Failed to add address")
 		return
 	}
 
@@ -279,13 +343,137 @@ func (h *CartHandler) SetPayment(w http.ResponseWriter, r *http.Request) {
 
 	if err := h.service.SetCreditCard(r.Context(), cartID, card); err != nil {
 		h.logger.Debug("Failed to set payment for cart", "cart_id", cartID, "error", err)
-		httperr.Internal(w, "Failed to set payment")
+		httperr.FromError(w, err, "Failed to set payment")
 		return
 	}
 
 	httpx.WriteNoContent(w)
 }
 
+func (h *CartHandler) GetShippingOptions(w http.ResponseWriter, r *http.Request) {
+	cartID, ok := requiredPathParam(w, r, "id", "Missing cart ID")
+	if !ok {
+		return
+	}
+
+	quotes, err := h.service.GetShippingQuotes(r.Context(), cartID)
+	if err != nil {
+		httperr.FromError(w, err, "Failed to get shipping options")
+		return
+	}
+
+	if err := httpx.WriteJSON(w, http.StatusOK, map[string]any{"methods": quotes}); err != nil {
+		h.logger.Error("Failed to write shipping options response", "error", err.Error())
+	}
+}
+
+func (h *CartHandler) SetShipping(w http.ResponseWriter, r *http.Request) {
+	cartID, ok := requiredPathParam(w, r, "id", "Missing cart ID")
+	if !ok {
+		return
+	}
+
+	var req SetShippingRequest
+	if err := httpx.DecodeJSON(r, &req); err != nil {
+		httperr.InvalidRequest(w, "Invalid JSON")
+		return
+	}
+
+	cart, err := h.service.SetShippingMethod(r.Context(), cartID, req.Method)
+	if err != nil {
+		httperr.FromError(w, err, "Failed to set shipping method")
+		return
+	}
+
+	if err := httpx.WriteJSON(w, http.StatusOK, cart); err != nil {
+		h.logger.Error("Failed to write set shipping response", "error", err.Error())
+	}
+}
+
+func (h *CartHandler) SetCurrency(w http.ResponseWriter, r *http.Request) {
+	cartID, ok := requiredPathParam(w, r, "id", "Missing cart ID")
+	if !ok {
+		return
+	}
+
+	var req SetCurrencyRequest
+	if err := httpx.DecodeJSON(r, &req); err != nil {
+		httperr.InvalidRequest(w, "Invalid JSON")
+		return
+	}
+
+	cart, err := h.service.ChangeCurrency(r.Context(), cartID, req.Currency)
+	if err != nil {
+		httperr.FromError(w, err, "Failed to change cart currency")
+		return
+	}
+
+	if err := httpx.WriteJSON(w, http.StatusOK, cart); err != nil {
+		h.logger.Error("Failed to write set currency response", "error", err.Error())
+	}
+}
+
+func (h *CartHandler) AcknowledgePriceChanges(w http.ResponseWriter, r *http.Request) {
+	cartID, ok := requiredPathParam(w, r, "id", "Missing cart ID")
+	if !ok {
+		return
+	}
+
+	cart, err := h.service.AcknowledgePriceChanges(r.Context(), cartID)
+	if err != nil {
+		httperr.FromError(w, err, "Failed to acknowledge price changes")
+		return
+	}
+
+	if err := httpx.WriteJSON(w, http.StatusOK, cart); err != nil {
+		h.logger.Error("Failed to write acknowledge price changes response", "error", err.Error())
+	}
+}
+
+func (h *CartHandler) ApplyCoupon(w http.ResponseWriter, r *http.Request) {
+	cartID, ok := requiredPathParam(w, r, "id", "Missing cart ID")
+	if !ok {
+		return
+	}
+
+	var req ApplyCouponRequest
+	if err := httpx.DecodeJSON(r, &req); err != nil {
+		httperr.InvalidRequest(w, "Invalid JSON")
+		return
+	}
+
+	cart, err := h.service.ApplyCoupon(r.Context(), cartID, req.Code)
+	if err != nil {
+		httperr.FromError(w, err, "Failed to apply coupon")
+		return
+	}
+
+	if err := httpx.WriteJSON(w, http.StatusOK, cart); err != nil {
+		h.logger.Error("Failed to write apply coupon response", "error", err.Error())
+	}
+}
+
+func (h *CartHandler) RemoveCoupon(w http.ResponseWriter, r *http.Request) {
+	cartID, ok := requiredPathParam(w, r, "id", "Missing cart ID or coupon code")
+	if !ok {
+		return
+	}
+	code, ok := requiredPathParam(w, r, "code", "Missing cart ID or coupon code")
+	if !ok {
+		return
+	}
+
+	cart, err := h.service.RemoveCoupon(r.Context(), cartID, code)
+	if err != nil {
+		httperr.FromError(w, err, "Failed to remove coupon")
+		return
+	}
+
+	if err := httpx.WriteJSON(w, http.StatusOK, cart); err != nil {
+		h.logger.Error("Failed to write remove coupon response", "error", err.Error())
+	}
+}
+
 func (h *CartHandler) Checkout(w http.ResponseWriter, r *http.Request) {
 	cartID, ok := requiredPathParam(w, r, "id", "Missing cart ID")
 	if !ok {
@@ -294,10 +482,7 @@ func (h *CartHandler) Checkout(w http.ResponseWriter, r *http.Request) {
 
 	cart, err := h.service.Checkout(r.Context(), cartID)
 	if err != nil {
-		if h.handleCheckoutValidationError(w, err) {
-			return
-		}
-		httperr.Internal(w, "Checkout failed")
+		httperr.FromError(w, err, "Checkout failed")
 		return
 	}
 
@@ -306,25 +491,295 @@ func (h *CartHandler) Checkout(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (h *CartHandler) handleCheckoutValidationError(w http.ResponseWriter, err error) bool {
-	if errors.Is(err, ErrCartMustBeActiveForCheckout) {
-		httperr.Validation(w, "cart not ready for checkout: cart must be active to checkout")
-		return true
+// ClaimCart attaches a guest cart to the signed-in customer, merging it
+// into their active cart if they have one, and returns that cart.
+func (h *CartHandler) ClaimCart(w http.ResponseWriter, r *http.Request) {
+	cartID, ok := requiredPathParam(w, r, "id", "Missing cart ID")
+	if !ok {
+		return
+	}
+
+	claims, ok := auth.GetClaims(r.Context())
+	if !ok {
+		httperr.Unauthorized(w, "authentication required")
+		return
+	}
+
+	cart, err := h.service.ClaimCart(r.Context(), cartID, claims)
+	if err != nil {
+		httperr.FromError(w, err, "Failed to claim cart")
+		return
+	}
+
+	if err := httpx.WriteJSON(w, http.StatusOK, cart); err != nil {
+		h.logger.Error("Failed to write claim cart response", "error", err.Error())
+	}
+}
+
+// Ready reports whether the service can serve carts: 503 until the product
+// cache has been warmed from ProductEvents, 200 after.
+func (h *CartHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	status, body := http.StatusOK, "ready"
+	if !h.service.GetProductCache().IsWarm() {
+		status, body = http.StatusServiceUnavailable, "warming"
+	}
+
+	if err := httpx.WriteJSON(w, status, map[string]string{"status": body}); err != nil {
+		h.logger.Error("Failed to write ready response", "error", err.Error())
+	}
+}
+
+func (h *CartHandler) ListWishlists(w http.ResponseWriter, r *http.Request) {
+	customerID, ok := h.customerID(w, r)
+	if !ok {
+		return
+	}
+
+	wishlists, err := h.service.ListWishlists(r.Context(), customerID)
+	if err != nil {
+		httperr.FromError(w, err, "Failed to list wishlists")
+		return
+	}
+
+	if err := httpx.WriteJSON(w, http.StatusOK, wishlists); err != nil {
+		h.logger.Error("Failed to write list wishlists response", "error", err.Error())
+	}
+}
+
+func (h *CartHandler) CreateWishlist(w http.ResponseWriter, r *http.Request) {
+	customerID, ok := h.customerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req CreateWishlistRequest
+	if err := httpx.DecodeJSON(r, &req); err != nil {
+		httperr.InvalidRequest(w, "Invalid JSON")
+		return
+	}
+
+	wishlist, err := h.service.CreateWishlist(r.Context(), customerID, req.Name)
+	if err != nil {
+		httperr.FromError(w, err, "Failed to create wishlist")
+		return
+	}
+
+	if err := httpx.WriteJSON(w, http.StatusCreated, wishlist); err != nil {
+		h.logger.Error("Failed to write create wishlist response", "error", err.Error())
+	}
+}
+
+func (h *CartHandler) GetWishlist(w http.ResponseWriter, r *http.Request) {
+	customerID, ok := h.customerID(w, r)
+	if !ok {
+		return
 	}
-	if errors.Is(err, ErrCartMustHaveItemsForCheckout) {
-		httperr.Validation(w, "Cart is empty")
-		return true
+	wishlistID, ok := requiredPathParam(w, r, "id", "Missing wishlist ID")
+	if !ok {
+		return
 	}
-	if errors.Is(err, ErrCartContactRequiredForCheckout) {
-		httperr.Validation(w, "Contact information required")
-		return true
+
+	wishlist, err := h.service.GetWishlist(r.Context(), customerID, wishlistID)
+	if err != nil {
+		httperr.FromError(w, err, "Failed to get wishlist")
+		return
 	}
-	if errors.Is(err, ErrCartPaymentRequiredForCheckout) {
-		httperr.Validation(w, "Payment method required")
-		return true
+
+	if err := httpx.WriteJSON(w, http.StatusOK, wishlist); err != nil {
+		h.logger.Error("Failed to write get wishlist response", "error", err.Error())
 	}
+}
 
-	return false
+func (h *CartHandler) DeleteWishlist(w http.ResponseWriter, r *http.Request) {
+	customerID, ok := h.customerID(w, r)
+	if !ok {
+		return
+	}
+	wishlistID, ok := requiredPathParam(w, r, "id", "Missing wishlist ID")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteWishlist(r.Context(), customerID, wishlistID); err != nil {
+		httperr.FromError(w, err, "Failed to delete wishlist")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *CartHandler) AddWishlistItem(w http.ResponseWriter, r *http.Request) {
+	customerID, ok := h.customerID(w, r)
+	if !ok {
+		return
+	}
+	wishlistID, ok := requiredPathParam(w, r, "id", "Missing wishlist ID")
+	if !ok {
+		return
+	}
+
+	var req AddWishlistItemRequest
+	if err := httpx.DecodeJSON(r, &req); err != nil {
+		httperr.InvalidRequest(w, "Invalid JSON")
+		return
+	}
+	if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+
+	var fieldErrs []httperr.FieldError
+	if req.ProductID == "" {
+		fieldErrs = append(fieldErrs, httperr.FieldError{Field: "product_id", Message: "product_id is required"})
+	}
+	if req.Quantity < 0 {
+		fieldErrs = append(fieldErrs, httperr.FieldError{Field: "quantity", Message: "quantity must be positive"})
+	}
+	if len(fieldErrs) > 0 {
+		httperr.ValidationFields(w, "Invalid product_id or quantity", fieldErrs...)
+		return
+	}
+
+	item, err := h.service.AddWishlistItem(r.Context(), customerID, wishlistID, req.ProductID, req.Quantity, req.ImageURL)
+	if err != nil {
+		httperr.FromError(w, err, "Failed to add wishlist item")
+		return
+	}
+
+	if err := httpx.WriteJSON(w, http.StatusCreated, item); err != nil {
+		h.logger.Error("Failed to write add wishlist item response", "error", err.Error())
+	}
+}
+
+func (h *CartHandler) RemoveWishlistItem(w http.ResponseWriter, r *http.Request) {
+	customerID, ok := h.customerID(w, r)
+	if !ok {
+		return
+	}
+	wishlistID, ok := requiredPathParam(w, r, "id", "Missing wishlist ID or product ID")
+	if !ok {
+		return
+	}
+	productID, ok := requiredPathParam(w, r, "product", "Missing wishlist ID or product ID")
+	if !ok {
+		return
+	}
+
+	if err := h.service.RemoveWishlistItem(r.Context(), customerID, wishlistID, productID); err != nil {
+		httperr.FromError(w, err, "Failed to remove wishlist item")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// MoveToCart adds a saved product to the customer's cart and takes it off
+// the wishlist.
+func (h *CartHandler) MoveToCart(w http.ResponseWriter, r *http.Request) {
+	customerID, ok := h.customerID(w, r)
+	if !ok {
+		return
+	}
+	wishlistID, ok := requiredPathParam(w, r, "id", "Missing wishlist ID or product ID")
+	if !ok {
+		return
+	}
+	productID, ok := requiredPathParam(w, r, "product", "Missing wishlist ID or product ID")
+	if !ok {
+		return
+	}
+
+	var req MoveToCartRequest
+	if err := httpx.DecodeJSON(r, &req); err != nil {
+		httperr.InvalidRequest(w, "Invalid JSON")
+		return
+	}
+	if req.CartID == "" {
+		httperr.ValidationFields(w, "Invalid cart_id", httperr.FieldError{Field: "cart_id", Message: "cart_id is required"})
+		return
+	}
+
+	item, err := h.service.MoveToCart(r.Context(), customerID, wishlistID, productID, req.CartID)
+	if err != nil {
+		httperr.FromError(w, err, "Failed to move item to cart")
+		return
+	}
+
+	if err := httpx.WriteJSON(w, http.StatusCreated, item); err != nil {
+		h.logger.Error("Failed to write move to cart response", "error", err.Error())
+	}
+}
+
+// SaveForLater moves a cart line to one of the customer's wishlists, or
+// to their saved-for-later list when the body names none, and returns the
+// cart.
+func (h *CartHandler) SaveForLater(w http.ResponseWriter, r *http.Request) {
+	customerID, ok := h.customerID(w, r)
+	if !ok {
+		return
+	}
+	cartID, ok := requiredPathParam(w, r, "id", "Missing cart ID or line number")
+	if !ok {
+		return
+	}
+	lineNumber, ok := requiredPathParam(w, r, "line", "Missing cart ID or line number")
+	if !ok {
+		return
+	}
+
+	var req SaveForLaterRequest
+	if err := httpx.DecodeJSON(r, &req); err != nil && !errors.Is(err, io.EOF) {
+		httperr.InvalidRequest(w, "Invalid JSON")
+		return
+	}
+
+	cart, err := h.service.SaveForLater(r.Context(), customerID, cartID, lineNumber, req.WishlistID)
+	if err != nil {
+		httperr.FromError(w, err, "Failed to save item for later")
+		return
+	}
+
+	if err := httpx.WriteJSON(w, http.StatusOK, cart); err != nil {
+		h.logger.Error("Failed to write save for later response", "error", err.Error())
+	}
+}
+
+// customerID returns the customer the signed-in user is linked to, or
+// writes the error response and returns false.
+// WishlistOwner lets a request for wishlist {id} through only when the
+// signed-in customer owns the wishlist, so that its alerts are streamed to
+// no one else. Other customers' wishlists are reported as not found.
+func (h *CartHandler) WishlistOwner(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		customerID, ok := h.customerID(w, r)
+		if !ok {
+			return
+		}
+		wishlistID, ok := requiredPathParam(w, r, "id", "Missing wishlist ID")
+		if !ok {
+			return
+		}
+
+		if _, err := h.service.GetWishlist(r.Context(), customerID, wishlistID); err != nil {
+			httperr.FromError(w, err, "Failed to get wishlist")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (h *CartHandler) customerID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	claims, ok := auth.GetClaims(r.Context())
+	if !ok {
+		httperr.Unauthorized(w, "authentication required")
+		return "", false
+	}
+	customerID, err := h.service.ResolveCustomer(claims)
+	if err != nil {
+		httperr.FromError(w, err, "Failed to resolve customer")
+		return "", false
+	}
+	return customerID, true
 }
 
 func requiredPathParam(w http.ResponseWriter, r *http.Request, key, missingMessage string) (string, bool) {
@@ -0,0 +1,42 @@
+package cart
+
+import (
+	"sync"
+)
+
+// IdentityCache maps Keycloak subjects to customer IDs so a signed-in
+// shopper can be matched to their customer record. It is bootstrapped from
+// CustomerEvents at startup and kept current via subscription.
+type IdentityCache struct {
+	mu    sync.RWMutex
+	cache map[string]string // keycloak_sub → customer_id
+}
+
+// NewIdentityCache creates a new empty cache
+func NewIdentityCache() *IdentityCache {
+	return &IdentityCache{
+		cache: make(map[string]string),
+	}
+}
+
+// Get returns the customer ID linked to a Keycloak subject
+func (c *IdentityCache) Get(keycloakSub string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	customerID, ok := c.cache[keycloakSub]
+	return customerID, ok
+}
+
+// Set links a Keycloak subject to a customer ID
+func (c *IdentityCache) Set(keycloakSub string, customerID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cache[keycloakSub] = customerID
+}
+
+// Count returns the number of entries in the cache
+func (c *IdentityCache) Count() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.cache)
+}
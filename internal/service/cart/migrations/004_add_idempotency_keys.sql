@@ -0,0 +1,19 @@
+-- Migration: Add idempotency key storage
+-- Stores request fingerprints and responses for Idempotency-Key replay
+
+CREATE SCHEMA IF NOT EXISTS idempotency;
+
+CREATE TABLE IF NOT EXISTS idempotency.idempotency_keys (
+    scope            VARCHAR(512) NOT NULL,
+    idempotency_key  VARCHAR(255) NOT NULL,
+    fingerprint      VARCHAR(64)  NOT NULL,
+    status           VARCHAR(20)  NOT NULL CHECK (status IN ('in_progress', 'completed')),
+    response_status  INTEGER,
+    response_headers JSONB,
+    response_body    BYTEA,
+    created_at       TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_at     TIMESTAMP,
+    PRIMARY KEY (scope, idempotency_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_created_at ON idempotency.idempotency_keys(created_at);
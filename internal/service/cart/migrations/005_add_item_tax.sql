@@ -0,0 +1,6 @@
+-- Migration: Add per-line tax to cart items
+-- Stores the tax code, amount and applied jurisdiction rates for each line
+
+ALTER TABLE carts.CartItem ADD COLUMN tax_code VARCHAR(50) NOT NULL DEFAULT '';
+ALTER TABLE carts.CartItem ADD COLUMN tax numeric(19,2) NOT NULL DEFAULT 0;
+ALTER TABLE carts.CartItem ADD COLUMN tax_breakdown JSONB;
@@ -0,0 +1,6 @@
+-- Migration: Add selected shipping method to carts
+-- Shipping is priced from the cart service's rate table for this method,
+-- by the weight of each line's product when it reports one
+
+ALTER TABLE carts.Cart ADD COLUMN shipping_method VARCHAR(30) NOT NULL DEFAULT '';
+ALTER TABLE carts.CartItem ADD COLUMN weight_lb numeric(10,3) NOT NULL DEFAULT 0;
@@ -0,0 +1,53 @@
+-- Migration: Add promotions and coupon codes
+-- Promotions are automatic (no code) or redeemed with a coupon code. Carts
+-- keep the codes applied to them and the discounts computed with the totals;
+-- redemptions are recorded at checkout and enforce the usage limits.
+
+ALTER TABLE carts.CartItem ADD COLUMN category text NOT NULL DEFAULT '';
+ALTER TABLE carts.CartItem ADD COLUMN brand text NOT NULL DEFAULT '';
+
+ALTER TABLE carts.Cart ADD COLUMN discount numeric(19,2) NOT NULL DEFAULT 0;
+ALTER TABLE carts.Cart ADD COLUMN discounts JSONB;
+
+CREATE TABLE IF NOT EXISTS carts.Promotion (
+    id bigint GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    code text,  -- NULL for automatic promotions
+    name text NOT NULL,
+    kind text NOT NULL,
+    value numeric(19,2) NOT NULL DEFAULT 0,
+    buy_quantity integer NOT NULL DEFAULT 0,
+    get_quantity integer NOT NULL DEFAULT 0,
+    categories JSONB NOT NULL DEFAULT '[]',
+    brands JSONB NOT NULL DEFAULT '[]',
+    min_subtotal numeric(19,2) NOT NULL DEFAULT 0,
+    max_uses integer NOT NULL DEFAULT 0,  -- 0 for unlimited
+    max_uses_per_customer integer NOT NULL DEFAULT 0,  -- 0 for unlimited
+    starts_at timestamp,
+    ends_at timestamp,
+    active boolean NOT NULL DEFAULT true,
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_promotion_kind CHECK (kind IN ('percent_off', 'amount_off', 'buy_x_get_y'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_promotion_code ON carts.Promotion (upper(code)) WHERE code IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS carts.CartCoupon (
+    id bigint GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    cart_id uuid NOT NULL,
+    code text NOT NULL,
+    applied_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT unique_coupon_per_cart UNIQUE (cart_id, code)
+);
+
+CREATE TABLE IF NOT EXISTS carts.PromotionRedemption (
+    id bigint GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    promotion_id bigint NOT NULL REFERENCES carts.Promotion (id),
+    cart_id uuid NOT NULL,
+    customer_key text NOT NULL DEFAULT '',  -- customer ID, or contact email for guest carts
+    code text,
+    amount numeric(19,2) NOT NULL,
+    redeemed_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT unique_redemption_per_cart UNIQUE (promotion_id, cart_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_redemption_customer ON carts.PromotionRedemption (promotion_id, customer_key);
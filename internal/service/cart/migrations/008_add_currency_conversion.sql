@@ -0,0 +1,12 @@
+-- Migration: Add currency conversion for cart lines
+-- Lines for products priced in another currency than the cart keep the
+-- catalog price and the exchange rate snapshotted when they were priced.
+-- Promotion amounts are in the promotion's currency (empty for any).
+
+ALTER TABLE carts.CartItem ADD COLUMN original_unit_price numeric(19,2) NOT NULL DEFAULT 0;
+ALTER TABLE carts.CartItem ADD COLUMN original_currency text NOT NULL DEFAULT '';
+ALTER TABLE carts.CartItem ADD COLUMN fx_rate numeric(19,8) NOT NULL DEFAULT 0;
+ALTER TABLE carts.CartItem ADD COLUMN fx_source text NOT NULL DEFAULT '';
+ALTER TABLE carts.CartItem ADD COLUMN fx_as_of timestamp;
+
+ALTER TABLE carts.Promotion ADD COLUMN currency text NOT NULL DEFAULT '';
@@ -0,0 +1,10 @@
+-- Migration: Add cart expiry
+-- Active carts not updated within the configured TTL are expired and a
+-- cart.abandoned event is published. Expired guest carts later have their
+-- contact, card and address rows purged.
+
+ALTER TABLE carts.Cart DROP CONSTRAINT chk_status;
+ALTER TABLE carts.Cart ADD CONSTRAINT chk_status
+    CHECK (current_status IN ('active', 'checked_out', 'completed', 'cancelled', 'expired', 'purged'));
+
+CREATE INDEX idx_cart_status_updated_at ON carts.Cart(current_status, updated_at);
@@ -0,0 +1,8 @@
+-- Migration: Add cart claim
+-- A guest cart claimed by a customer who already has an active cart has its
+-- items merged into that cart and is marked merged. Merged guest carts are
+-- purged like expired ones.
+
+ALTER TABLE carts.Cart DROP CONSTRAINT chk_status;
+ALTER TABLE carts.Cart ADD CONSTRAINT chk_status
+    CHECK (current_status IN ('active', 'checked_out', 'completed', 'cancelled', 'expired', 'purged', 'merged'));
@@ -0,0 +1,14 @@
+-- Migration: Add validation retry
+-- Lines left pending_validation, for example while the product service is
+-- down, have their validation requested again after a timeout and are
+-- backordered after a number of attempts.
+
+ALTER TABLE carts.CartItem ADD COLUMN validation_requested_at TIMESTAMP;
+ALTER TABLE carts.CartItem ADD COLUMN validation_attempts INT NOT NULL DEFAULT 0;
+
+UPDATE carts.CartItem
+SET validation_requested_at = CURRENT_TIMESTAMP, validation_attempts = 1
+WHERE status = 'pending_validation';
+
+CREATE INDEX idx_cart_items_pending_validation ON carts.CartItem(validation_requested_at)
+    WHERE status = 'pending_validation';
@@ -0,0 +1,24 @@
+-- Migration: Add checkout saga
+-- A checkout is followed until its order is created and its stock reserved,
+-- when the cart is completed. A rejected order, a failed reservation or no
+-- outcome before the deadline rolls the checkout back: the cart is reopened,
+-- or cancelled when its customer has started another cart since. A cart
+-- checked out again starts the next attempt; events of earlier attempts are
+-- ignored.
+
+CREATE TABLE IF NOT EXISTS carts.CheckoutSaga (
+    cart_id uuid PRIMARY KEY REFERENCES carts.Cart(cart_id) ON DELETE CASCADE,
+    attempt integer NOT NULL DEFAULT 1,
+    status text NOT NULL DEFAULT 'pending',
+    order_id text,
+    inventory_status text NOT NULL DEFAULT 'pending',
+    reason text,
+    deadline timestamp NOT NULL,
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_saga_status CHECK (status IN ('pending', 'completed', 'compensated')),
+    CONSTRAINT chk_saga_inventory_status CHECK (inventory_status IN ('pending', 'reserved', 'failed'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_checkout_saga_pending_deadline ON carts.CheckoutSaga(deadline)
+    WHERE status = 'pending';
@@ -0,0 +1,9 @@
+-- Migration: Add price change tracking
+-- Lines repriced after a product price change keep the unit price they had
+-- before and when it changed, until the shopper acknowledges the change.
+-- Checkout is refused while a change is unacknowledged.
+
+ALTER TABLE carts.CartItem ADD COLUMN previous_unit_price numeric(19,2);
+ALTER TABLE carts.CartItem ADD COLUMN price_changed_at TIMESTAMP;
+
+CREATE INDEX idx_cart_items_product_id ON carts.CartItem(product_id);
@@ -0,0 +1,37 @@
+-- Migration: Add wishlists
+-- Customers keep named wishlists and one saved-for-later list, which cart
+-- lines can be moved to and back from. Each saved product keeps the price
+-- and stock it was last seen with, so a change can be pushed to the
+-- list's SSE subscribers.
+
+CREATE TABLE IF NOT EXISTS carts.Wishlist (
+    wishlist_id uuid PRIMARY KEY,
+    customer_id uuid NOT NULL,
+    name text NOT NULL,
+    kind text NOT NULL DEFAULT 'wishlist',
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_wishlist_kind CHECK (kind IN ('wishlist', 'saved_for_later')),
+    CONSTRAINT unique_wishlist_name_per_customer UNIQUE (customer_id, name)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_wishlist_saved_for_later ON carts.Wishlist(customer_id)
+    WHERE kind = 'saved_for_later';
+
+CREATE TABLE IF NOT EXISTS carts.WishlistItem (
+    id bigint GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    wishlist_id uuid NOT NULL REFERENCES carts.Wishlist(wishlist_id) ON DELETE CASCADE,
+    product_id text NOT NULL,
+    product_name text NOT NULL DEFAULT '',
+    image_url text NOT NULL DEFAULT '',
+    quantity int NOT NULL DEFAULT 1,
+    price numeric(19,2) NOT NULL DEFAULT 0,
+    currency text NOT NULL DEFAULT '',
+    in_stock boolean NOT NULL DEFAULT true,
+    added_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_wishlist_quantity_positive CHECK (quantity > 0),
+    CONSTRAINT unique_product_per_wishlist UNIQUE (wishlist_id, product_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_wishlist_items_product_id ON carts.WishlistItem(product_id);
@@ -0,0 +1,10 @@
+-- Migration: Record processed reorder requests
+-- A redelivered order.reorder_requested event answers with the recorded
+-- outcome instead of adding the order's items to the cart again.
+
+CREATE TABLE IF NOT EXISTS carts.ReorderRequest (
+    request_id text PRIMARY KEY,
+    cart_id uuid NOT NULL,
+    lines JSONB NOT NULL,
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
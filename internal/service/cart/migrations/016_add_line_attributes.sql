@@ -0,0 +1,8 @@
+-- Migration: Add variant options to cart lines
+-- The size and color a shopper selected for a line, checked against the
+-- product's options, and an optional gift message. A product can be in a
+-- cart once per size and color.
+
+ALTER TABLE carts.CartItem ADD COLUMN size text NOT NULL DEFAULT '';
+ALTER TABLE carts.CartItem ADD COLUMN color text NOT NULL DEFAULT '';
+ALTER TABLE carts.CartItem ADD COLUMN gift_message text NOT NULL DEFAULT '';
@@ -0,0 +1,138 @@
+package cart
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go-shopping-poc/internal/platform/httperr"
+)
+
+// Carts are versioned by Cart.Version, which every change to the cart row
+// increments. The version is exposed as the cart's ETag, and a request to
+// change a cart may send it back in If-Match to fail with 412 instead of
+// overwriting a change made since the shopper last read the cart.
+
+// ETag returns the entity tag of a cart version.
+func ETag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// parseIfMatch returns the cart version required by an If-Match header.
+// Weak tags are accepted. An empty header or "*" requires no version.
+func parseIfMatch(header string) (version int, ok bool, err error) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return 0, false, nil
+	}
+	tag := strings.TrimPrefix(header, "W/")
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, false, fmt.Errorf("invalid entity tag %q", header)
+	}
+	version, err = strconv.Atoi(tag[1 : len(tag)-1])
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid entity tag %q", header)
+	}
+	return version, true, nil
+}
+
+type versionKey struct{}
+
+// versionState carries a request's If-Match version to the service and
+// the cart version it leaves behind back to the response.
+type versionState struct {
+	expected *int
+	current  *int
+}
+
+// IfMatch is middleware for cart routes. On requests that change a cart it
+// passes the version required by If-Match to the service and sets the ETag
+// of the resulting cart version on the response. Safe methods pass through
+// untouched; handlers that return a cart set its ETag themselves.
+func IfMatch(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		state := &versionState{}
+		version, ok, err := parseIfMatch(r.Header.Get("If-Match"))
+		if err != nil {
+			httperr.InvalidRequest(w, "Invalid If-Match header")
+			return
+		}
+		if ok {
+			state.expected = &version
+		}
+
+		ctx := context.WithValue(r.Context(), versionKey{}, state)
+		next.ServeHTTP(&etagWriter{ResponseWriter: w, state: state}, r.WithContext(ctx))
+	})
+}
+
+// etagWriter sets the ETag header from the recorded cart version when the
+// response header is written.
+type etagWriter struct {
+	http.ResponseWriter
+	state       *versionState
+	wroteHeader bool
+}
+
+func (w *etagWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if w.state.current != nil && status < http.StatusBadRequest {
+			w.Header().Set("ETag", ETag(*w.state.current))
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *etagWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// checkVersion fails with ErrCartVersionConflict when the request requires
+// a cart version other than cart's.
+func checkVersion(ctx context.Context, cart *Cart) error {
+	state, ok := ctx.Value(versionKey{}).(*versionState)
+	if !ok || state.expected == nil {
+		return nil
+	}
+	if *state.expected != cart.Version {
+		return fmt.Errorf("%w: version %d required, cart is at version %d", ErrCartVersionConflict, *state.expected, cart.Version)
+	}
+	return nil
+}
+
+// recordVersion records the cart version a request leaves behind for the
+// response ETag.
+func recordVersion(ctx context.Context, cart *Cart) {
+	if state, ok := ctx.Value(versionKey{}).(*versionState); ok {
+		version := cart.Version
+		state.current = &version
+	}
+}
+
+// maxVersionRetries bounds how often RetryOnVersionConflict runs an update.
+const maxVersionRetries = 3
+
+// RetryOnVersionConflict runs update, which loads and saves a cart, again
+// while the save loses to a concurrent change of the cart. It is meant for
+// background updates; requests report the conflict to the client instead.
+func RetryOnVersionConflict(update func() error) error {
+	var err error
+	for range maxVersionRetries {
+		if err = update(); !errors.Is(err, ErrCartVersionConflict) {
+			return err
+		}
+	}
+	return err
+}
@@ -0,0 +1,188 @@
+package cart
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-shopping-poc/internal/platform/httperr"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		header      string
+		wantVersion int
+		wantOK      bool
+		wantErr     bool
+	}{
+		{header: "", wantOK: false},
+		{header: "*", wantOK: false},
+		{header: " * ", wantOK: false},
+		{header: `"3"`, wantVersion: 3, wantOK: true},
+		{header: ` "12" `, wantVersion: 12, wantOK: true},
+		{header: `W/"7"`, wantVersion: 7, wantOK: true},
+		{header: `"0"`, wantVersion: 0, wantOK: true},
+		{header: `3`, wantErr: true},
+		{header: `"3`, wantErr: true},
+		{header: `'3'`, wantErr: true},
+		{header: `W/3`, wantErr: true},
+		{header: `w/"3"`, wantErr: true},
+		{header: `"abc"`, wantErr: true},
+		{header: "`3`", wantErr: true},
+		{header: `"\x33"`, wantErr: true},
+		{header: `"`, wantErr: true},
+		{header: `"3", "4"`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.header, func(t *testing.T) {
+			t.Parallel()
+
+			version, ok, err := parseIfMatch(tt.header)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseIfMatch(%q) error = %v, wantErr %v", tt.header, err, tt.wantErr)
+			}
+			if version != tt.wantVersion || ok != tt.wantOK {
+				t.Errorf("parseIfMatch(%q) = %d, %v; want %d, %v", tt.header, version, ok, tt.wantVersion, tt.wantOK)
+			}
+		})
+	}
+}
+
+// versionedHandler checks the cart at version 4 against the request's
+// If-Match and, when it matches, saves it as version 5 and responds with
+// status.
+func versionedHandler(status int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cart := &Cart{Version: 4}
+		if err := checkVersion(r.Context(), cart); err != nil {
+			httperr.FromError(w, err, "Failed to update cart")
+			return
+		}
+		cart.Version++
+		recordVersion(r.Context(), cart)
+		w.WriteHeader(status)
+	})
+}
+
+func TestIfMatch(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		method     string
+		ifMatch    string
+		status     int
+		wantStatus int
+		wantETag   string
+		wantCode   string
+	}{
+		{name: "matching version", method: http.MethodPut, ifMatch: `"4"`, status: http.StatusOK, wantStatus: http.StatusOK, wantETag: `"5"`},
+		{name: "weak matching version", method: http.MethodDelete, ifMatch: `W/"4"`, status: http.StatusNoContent, wantStatus: http.StatusNoContent, wantETag: `"5"`},
+		{name: "no precondition", method: http.MethodPost, status: http.StatusCreated, wantStatus: http.StatusCreated, wantETag: `"5"`},
+		{name: "any version", method: http.MethodPut, ifMatch: "*", status: http.StatusOK, wantStatus: http.StatusOK, wantETag: `"5"`},
+		{name: "stale version", method: http.MethodPut, ifMatch: `"3"`, wantStatus: http.StatusPreconditionFailed, wantCode: "cart_version_conflict"},
+		{name: "malformed tag", method: http.MethodPut, ifMatch: `4`, wantStatus: http.StatusBadRequest, wantCode: "invalid_request"},
+		{name: "failed change", method: http.MethodPut, ifMatch: `"4"`, status: http.StatusUnprocessableEntity, wantStatus: http.StatusUnprocessableEntity},
+		{name: "safe method passes through", method: http.MethodGet, ifMatch: `"3"`, status: http.StatusOK, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(tt.method, "/carts/1", nil)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			rr := httptest.NewRecorder()
+			IfMatch(versionedHandler(tt.status)).ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if got := rr.Header().Get("ETag"); got != tt.wantETag {
+				t.Errorf("ETag = %q, want %q", got, tt.wantETag)
+			}
+			if tt.wantCode != "" {
+				var problem struct {
+					Code string `json:"code"`
+				}
+				if err := json.Unmarshal(rr.Body.Bytes(), &problem); err != nil {
+					t.Fatalf("failed to unmarshal problem: %v", err)
+				}
+				if problem.Code != tt.wantCode {
+					t.Errorf("code = %q, want %q", problem.Code, tt.wantCode)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckVersion(t *testing.T) {
+	t.Parallel()
+
+	expected := 2
+	ctx := context.WithValue(context.Background(), versionKey{}, &versionState{expected: &expected})
+
+	if err := checkVersion(ctx, &Cart{Version: 2}); err != nil {
+		t.Errorf("checkVersion() at the required version error = %v", err)
+	}
+	err := checkVersion(ctx, &Cart{Version: 3})
+	if !errors.Is(err, ErrCartVersionConflict) {
+		t.Fatalf("checkVersion() error = %v, want ErrCartVersionConflict", err)
+	}
+	if mapping, ok := httperr.Lookup(fmt.Errorf("failed to update cart: %w", err)); !ok || mapping.Status != http.StatusPreconditionFailed {
+		t.Errorf("Lookup() = %+v, %v; want 412", mapping, ok)
+	}
+	if err := checkVersion(context.Background(), &Cart{Version: 3}); err != nil {
+		t.Errorf("checkVersion() without If-Match error = %v", err)
+	}
+}
+
+func TestRetryOnVersionConflict(t *testing.T) {
+	t.Parallel()
+
+	conflict := fmt.Errorf("failed to save cart: %w", ErrCartVersionConflict)
+	other := errors.New("database unavailable")
+
+	tests := []struct {
+		name      string
+		results   []error
+		wantErr   error
+		wantCalls int
+	}{
+		{name: "saved first time", results: []error{nil}, wantCalls: 1},
+		{name: "saved after conflicts", results: []error{conflict, conflict, nil}, wantCalls: 3},
+		{name: "other error is not retried", results: []error{other}, wantErr: other, wantCalls: 1},
+		{name: "other error after a conflict", results: []error{conflict, other}, wantErr: other, wantCalls: 2},
+		{name: "conflicts exhaust the retries", results: []error{conflict, conflict, conflict, nil}, wantErr: ErrCartVersionConflict, wantCalls: maxVersionRetries},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			calls := 0
+			err := RetryOnVersionConflict(func() error {
+				calls++
+				return tt.results[calls-1]
+			})
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("RetryOnVersionConflict() error = %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("update called %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package cart
+
+import (
+	"context"
+	"fmt"
+
+	"go-shopping-poc/internal/platform/fx"
+	"go-shopping-poc/internal/platform/money"
+	"go-shopping-poc/internal/platform/promotion"
+	"go-shopping-poc/internal/platform/shipping"
+	"go-shopping-poc/internal/platform/tax"
+)
+
+// DefaultCurrency is the currency of carts created without one.
+const DefaultCurrency = "USD"
+
+// Pricing holds the calculators used to compute cart totals.
+type Pricing struct {
+	Tax        tax.TaxCalculator
+	Shipping   shipping.Calculator
+	Promotions PromotionSource // optional; nil disables promotions
+
+	// FX converts catalog prices into the cart currency; nil accepts only
+	// prices already in the cart currency. With RejectMixedCurrency, items
+	// priced in another currency are refused rather than converted.
+	FX                  fx.Provider
+	RejectMixedCurrency bool
+}
+
+// PromotionSource loads the promotions a cart may use: every automatic
+// promotion plus those whose code is in codes, with redemption counts for
+// customerKey. Automatic promotions are returned first.
+type PromotionSource interface {
+	Promotions(ctx context.Context, customerKey string, codes []string) ([]promotion.Promotion, error)
+}
+
+// PriceItem sets item's unit price from the catalog price, converting it
+// into currency at the current rate when the two differ. The catalog price
+// and the rate used are recorded on the item so later rate changes do not
+// reprice it. A price of unspecified currency is taken to be in currency.
+func (p Pricing) PriceItem(ctx context.Context, item *CartItem, price money.Money, currency string) error {
+	if p.RejectMixedCurrency && !money.SameCurrency(price, money.Zero(currency)) {
+		return fmt.Errorf("%w: product is priced in %s, cart is in %s", ErrCurrencyMismatch, price.Currency(), currency)
+	}
+	return p.convertItem(ctx, item, price, currency)
+}
+
+// convertItem prices item in currency regardless of RejectMixedCurrency;
+// it backs explicit conversions such as CartService.ChangeCurrency.
+func (p Pricing) convertItem(ctx context.Context, item *CartItem, price money.Money, currency string) error {
+	item.OriginalUnitPrice = money.Money{}
+	item.OriginalCurrency = ""
+	item.FXRate = 0
+	item.FXSource = ""
+	item.FXAsOf = nil
+
+	if money.SameCurrency(price, money.Zero(currency)) {
+		item.UnitPrice = price.In(currency)
+		return nil
+	}
+	if p.FX == nil {
+		return fmt.Errorf("%w: product is priced in %s, cart is in %s", ErrCurrencyMismatch, price.Currency(), currency)
+	}
+
+	rate, err := p.FX.Rate(ctx, price.Currency(), currency)
+	if err != nil {
+		return fmt.Errorf("failed to get exchange rate: %w", err)
+	}
+	asOf := rate.AsOf
+	item.UnitPrice = rate.Convert(price)
+	item.OriginalUnitPrice = price
+	item.OriginalCurrency = price.Currency()
+	item.FXRate = rate.Value
+	item.FXSource = rate.Source
+	item.FXAsOf = &asOf
+	return nil
+}
@@ -0,0 +1,65 @@
+package cart
+
+import (
+	"net/http"
+
+	platformerrors "go-shopping-poc/internal/platform/errors"
+	"go-shopping-poc/internal/platform/fx"
+	"go-shopping-poc/internal/platform/httperr"
+	"go-shopping-poc/internal/platform/promotion"
+	"go-shopping-poc/internal/platform/shipping"
+)
+
+// init maps cart domain errors to problem responses. Checkout errors are
+// registered before ErrCartNotReadyForCheckout so the specific code wins.
+func init() {
+	httperr.Register(ErrCartNotFound, httperr.Mapping{Status: http.StatusNotFound, Type: platformerrors.ErrorTypeNotFound, Code: "cart_not_found", Message: "Cart not found"})
+	httperr.Register(ErrCartItemNotFound, httperr.Mapping{Status: http.StatusNotFound, Type: platformerrors.ErrorTypeNotFound, Code: "cart_item_not_found", Message: "Cart item not found"})
+	httperr.Register(ErrInvalidUUID, httperr.Mapping{Status: http.StatusBadRequest, Type: platformerrors.ErrorTypeInvalidRequest, Code: "cart_invalid_id", Message: "Invalid cart ID"})
+	httperr.Register(ErrInvalidCustomerID, httperr.Mapping{Status: http.StatusBadRequest, Type: platformerrors.ErrorTypeInvalidRequest, Code: "cart_invalid_customer_id", Message: "Invalid customer ID"})
+	httperr.Register(ErrInvalidQuantity, httperr.Mapping{Status: http.StatusBadRequest, Type: platformerrors.ErrorTypeValidation, Code: "cart_invalid_quantity", Message: "Quantity must be positive"})
+	httperr.Register(ErrDuplicateActiveCart, httperr.Mapping{Status: http.StatusConflict, Type: platformerrors.ErrorTypeConflict, Code: "cart_already_active"})
+	httperr.Register(ErrCartNotActive, httperr.Mapping{Status: http.StatusConflict, Type: platformerrors.ErrorTypeConflict, Code: "cart_not_active"})
+	httperr.Register(ErrItemAlreadyInCart, httperr.Mapping{Status: http.StatusConflict, Type: platformerrors.ErrorTypeConflict, Code: "cart_item_exists"})
+	httperr.Register(ErrItemValidationInProgress, httperr.Mapping{Status: http.StatusConflict, Type: platformerrors.ErrorTypeConflict, Code: "cart_item_validation_in_progress"})
+
+	httperr.Register(ErrCartMustBeActiveForCheckout, httperr.Mapping{Status: http.StatusBadRequest, Type: platformerrors.ErrorTypeValidation, Code: "checkout_cart_not_active", Message: "cart not ready for checkout: cart must be active to checkout"})
+	httperr.Register(ErrCartMustHaveItemsForCheckout, httperr.Mapping{Status: http.StatusBadRequest, Type: platformerrors.ErrorTypeValidation, Code: "checkout_cart_empty", Message: "Cart is empty"})
+	httperr.Register(ErrCartContactRequiredForCheckout, httperr.Mapping{Status: http.StatusBadRequest, Type: platformerrors.ErrorTypeValidation, Code: "checkout_contact_required", Message: "Contact information required"})
+	httperr.Register(ErrCartPaymentRequiredForCheckout, httperr.Mapping{Status: http.StatusBadRequest, Type: platformerrors.ErrorTypeValidation, Code: "checkout_payment_required", Message: "Payment method required"})
+	httperr.Register(ErrCartShippingMethodRequiredForCheckout, httperr.Mapping{Status: http.StatusBadRequest, Type: platformerrors.ErrorTypeValidation, Code: "checkout_shipping_method_required", Message: "Shipping method required"})
+	httperr.Register(ErrCartItemsPendingValidation, httperr.Mapping{Status: http.StatusConflict, Type: platformerrors.ErrorTypeConflict, Code: "checkout_items_pending_validation"})
+	httperr.Register(ErrCartItemsBackordered, httperr.Mapping{Status: http.StatusConflict, Type: platformerrors.ErrorTypeConflict, Code: "checkout_items_backordered"})
+	httperr.Register(ErrCartPriceChangesUnacknowledged, httperr.Mapping{Status: http.StatusConflict, Type: platformerrors.ErrorTypeConflict, Code: "checkout_price_changes_unacknowledged"})
+	httperr.Register(ErrInsufficientStock, httperr.Mapping{Status: http.StatusConflict, Type: platformerrors.ErrorTypeConflict, Code: "checkout_insufficient_stock", ExposeDetail: true})
+	httperr.Register(ErrCartNotReadyForCheckout, httperr.Mapping{Status: http.StatusBadRequest, Type: platformerrors.ErrorTypeValidation, Code: "checkout_not_ready"})
+
+	httperr.Register(shipping.ErrUnknownMethod, httperr.Mapping{Status: http.StatusBadRequest, Type: platformerrors.ErrorTypeValidation, Code: "shipping_method_unknown", Message: "Unknown shipping method"})
+	httperr.Register(shipping.ErrDestinationRequired, httperr.Mapping{Status: http.StatusUnprocessableEntity, Type: platformerrors.ErrorTypeValidation, Code: "shipping_address_required"})
+	httperr.Register(shipping.ErrMethodUnavailable, httperr.Mapping{Status: http.StatusUnprocessableEntity, Type: platformerrors.ErrorTypeValidation, Code: "shipping_method_unavailable"})
+
+	httperr.Register(ErrCouponNotFound, httperr.Mapping{Status: http.StatusNotFound, Type: platformerrors.ErrorTypeNotFound, Code: "coupon_not_found", Message: "Coupon not found"})
+	httperr.Register(ErrCouponAlreadyApplied, httperr.Mapping{Status: http.StatusConflict, Type: platformerrors.ErrorTypeConflict, Code: "coupon_already_applied", Message: "Coupon already applied"})
+	httperr.Register(ErrCouponNotApplied, httperr.Mapping{Status: http.StatusNotFound, Type: platformerrors.ErrorTypeNotFound, Code: "coupon_not_applied", Message: "Coupon not applied to cart"})
+	httperr.Register(promotion.ErrNotActive, httperr.Mapping{Status: http.StatusUnprocessableEntity, Type: platformerrors.ErrorTypeValidation, Code: "coupon_not_active", Message: "Coupon is not active"})
+	httperr.Register(promotion.ErrMinimumSpendNotMet, httperr.Mapping{Status: http.StatusUnprocessableEntity, Type: platformerrors.ErrorTypeValidation, Code: "coupon_minimum_spend_not_met", Message: "Minimum spend not met for coupon"})
+	httperr.Register(promotion.ErrNotApplicable, httperr.Mapping{Status: http.StatusUnprocessableEntity, Type: platformerrors.ErrorTypeValidation, Code: "coupon_not_applicable", Message: "Coupon does not apply to cart items"})
+	httperr.Register(promotion.ErrUsageLimitReached, httperr.Mapping{Status: http.StatusConflict, Type: platformerrors.ErrorTypeConflict, Code: "coupon_usage_limit_reached", Message: "Coupon usage limit reached"})
+	httperr.Register(promotion.ErrCustomerUsageLimitReached, httperr.Mapping{Status: http.StatusConflict, Type: platformerrors.ErrorTypeConflict, Code: "coupon_already_redeemed", Message: "Coupon already used"})
+	httperr.Register(promotion.ErrCustomerRequired, httperr.Mapping{Status: http.StatusUnprocessableEntity, Type: platformerrors.ErrorTypeValidation, Code: "coupon_customer_required", Message: "Sign in or add contact details to use this coupon"})
+
+	httperr.Register(ErrCustomerNotLinked, httperr.Mapping{Status: http.StatusForbidden, Type: platformerrors.ErrorTypeForbidden, Code: "customer_not_linked", Message: "Signed-in user is not linked to a customer"})
+	httperr.Register(ErrCartOwnedByOtherCustomer, httperr.Mapping{Status: http.StatusForbidden, Type: platformerrors.ErrorTypeForbidden, Code: "cart_owned_by_other_customer", Message: "Cart belongs to another customer"})
+	httperr.Register(ErrCartNotClaimed, httperr.Mapping{Status: http.StatusConflict, Type: platformerrors.ErrorTypeConflict, Code: "cart_not_claimed", Message: "Cart must be claimed before saving items"})
+
+	httperr.Register(ErrWishlistNotFound, httperr.Mapping{Status: http.StatusNotFound, Type: platformerrors.ErrorTypeNotFound, Code: "wishlist_not_found", Message: "Wishlist not found"})
+	httperr.Register(ErrWishlistItemNotFound, httperr.Mapping{Status: http.StatusNotFound, Type: platformerrors.ErrorTypeNotFound, Code: "wishlist_item_not_found", Message: "Wishlist item not found"})
+	httperr.Register(ErrWishlistNameTaken, httperr.Mapping{Status: http.StatusConflict, Type: platformerrors.ErrorTypeConflict, Code: "wishlist_name_taken", Message: "A wishlist of that name already exists"})
+	httperr.Register(ErrWishlistProductUnknown, httperr.Mapping{Status: http.StatusNotFound, Type: platformerrors.ErrorTypeNotFound, Code: "wishlist_product_not_found", Message: "Product not found"})
+
+	httperr.Register(ErrCartVersionConflict, httperr.Mapping{Status: http.StatusPreconditionFailed, Type: platformerrors.ErrorTypePreconditionFailed, Code: "cart_version_conflict", Message: "Cart was modified by another request"})
+
+	httperr.Register(ErrCurrencyMismatch, httperr.Mapping{Status: http.StatusUnprocessableEntity, Type: platformerrors.ErrorTypeValidation, Code: "currency_mismatch", ExposeDetail: true})
+	httperr.Register(fx.ErrUnsupportedCurrency, httperr.Mapping{Status: http.StatusUnprocessableEntity, Type: platformerrors.ErrorTypeValidation, Code: "currency_unsupported", ExposeDetail: true})
+	httperr.Register(fx.ErrRateUnavailable, httperr.Mapping{Status: http.StatusServiceUnavailable, Type: platformerrors.ErrorTypeInternal, Code: "fx_rate_unavailable", Message: "Exchange rates are temporarily unavailable"})
+}
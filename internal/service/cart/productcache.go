@@ -2,15 +2,63 @@ package cart
 
 import (
 	"sync"
+	"sync/atomic"
+	"time"
+
+	"go-shopping-poc/internal/platform/money"
+
+	"github.com/prometheus/client_golang/prometheus"
 )
 
+var (
+	productCacheLookups = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cart_product_cache_lookups_total",
+			Help: "Product cache lookups by result (hit, miss, stale)",
+		},
+		[]string{"result"},
+	)
+	productCacheEntries = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "cart_product_cache_entries",
+			Help: "Number of products in the product cache",
+		},
+	)
+	productCacheWarm = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "cart_product_cache_warm",
+			Help: "1 once the product cache has been warmed from ProductEvents",
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(productCacheLookups)
+	prometheus.MustRegister(productCacheEntries)
+	prometheus.MustRegister(productCacheWarm)
+}
+
 // ProductEntry holds the data needed for product validation in the cart service.
 // Only the fields required for validation are stored — no full product aggregate.
+// FinalPrice carries the catalog currency when the product event includes it.
 type ProductEntry struct {
-	ProductID  string  `json:"product_id"`
-	InStock    bool    `json:"in_stock"`
-	FinalPrice float64 `json:"final_price"`
-	Name       string  `json:"name"`
+	ProductID  string      `json:"product_id"`
+	InStock    bool        `json:"in_stock"`
+	FinalPrice money.Money `json:"final_price"`
+	Name       string      `json:"name"`
+	Category   string      `json:"category"`
+	Brand      string      `json:"brand"`
+	// Sizes and Color are the variant options of the product.
+	// VariantsKnown is false for entries from events that did not carry
+	// them.
+	Sizes         []string `json:"sizes,omitempty"`
+	Color         string   `json:"color,omitempty"`
+	VariantsKnown bool     `json:"variants_known"`
+	// WeightLb is the shipping weight in pounds, zero when unknown.
+	WeightLb float64 `json:"weight_lb,omitempty"`
+	// UpdatedAt is the timestamp of the event the entry was last stored
+	// from; set by the cache.
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 // ProductCache provides a thread-safe in-memory cache of product identity data.
@@ -18,32 +66,80 @@ type ProductEntry struct {
 // events from the ProductEvents topic. The cache is used to accelerate product
 // validation when items are added to a cart, eliminating the event round-trip
 // for products already in the cache.
+//
+// Available stock is tracked separately from product entries, from the
+// inventory events the product service publishes on the same topic, so
+// that catalog updates do not discard it.
+//
+// Entries not refreshed within the cache TTL are stale: Get treats them as
+// missing, so the item is validated by the product service again, which
+// refreshes the entry. The cache is warm once it has been filled from the
+// history of the ProductEvents topic at startup.
 type ProductCache struct {
 	mu    sync.RWMutex
 	items map[string]ProductEntry // product_id → ProductEntry
+	stock map[string]int          // product_id → available quantity
+	ttl   time.Duration
+	warm  atomic.Bool
 }
 
-// NewProductCache creates a new empty product cache.
+// NewProductCache creates a new empty product cache whose entries never
+// go stale.
 func NewProductCache() *ProductCache {
+	return NewProductCacheWithTTL(0)
+}
+
+// NewProductCacheWithTTL creates a new empty product cache whose entries go
+// stale ttl after they were last stored. Zero disables staleness.
+func NewProductCacheWithTTL(ttl time.Duration) *ProductCache {
 	return &ProductCache{
 		items: make(map[string]ProductEntry),
+		stock: make(map[string]int),
+		ttl:   ttl,
 	}
 }
 
-// Get returns the ProductEntry for a given product_id and whether it was found.
+// Get returns the ProductEntry for a given product_id and whether it was
+// found. Stale entries are reported as not found.
 func (c *ProductCache) Get(productID string) (ProductEntry, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	entry, ok := c.items[productID]
+	switch {
+	case !ok:
+		productCacheLookups.WithLabelValues("miss").Inc()
+		return ProductEntry{}, false
+	case c.isStale(entry):
+		productCacheLookups.WithLabelValues("stale").Inc()
+		return ProductEntry{}, false
+	}
+	productCacheLookups.WithLabelValues("hit").Inc()
+	return entry, true
+}
+
+// Peek returns the entry for a product, stale or not, without counting a
+// lookup. Use this to carry fields over when updating an entry.
+func (c *ProductCache) Peek(productID string) (ProductEntry, bool) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 	entry, ok := c.items[productID]
 	return entry, ok
 }
 
-// Set upserts a product entry into the cache.
+// Set upserts a product entry into the cache and stamps it with updatedAt,
+// the timestamp of the event it came from, so that entries replayed from
+// old events go stale by their age. A zero updatedAt stamps the current
+// time.
 // Use this for ProductCreated and ProductUpdated events.
-func (c *ProductCache) Set(productID string, entry ProductEntry) {
+func (c *ProductCache) Set(productID string, entry ProductEntry, updatedAt time.Time) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	if updatedAt.IsZero() {
+		updatedAt = time.Now()
+	}
+	entry.UpdatedAt = updatedAt
 	c.items[productID] = entry
+	productCacheEntries.Set(float64(len(c.items)))
 }
 
 // Delete removes a product entry from the cache.
@@ -52,6 +148,54 @@ func (c *ProductCache) Delete(productID string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	delete(c.items, productID)
+	delete(c.stock, productID)
+	productCacheEntries.Set(float64(len(c.items)))
+}
+
+// MarkWarm records that the cache has been warmed at startup.
+func (c *ProductCache) MarkWarm() {
+	c.warm.Store(true)
+	productCacheWarm.Set(1)
+}
+
+// IsWarm reports whether the cache has been warmed at startup.
+func (c *ProductCache) IsWarm() bool {
+	return c.warm.Load()
+}
+
+func (c *ProductCache) isStale(entry ProductEntry) bool {
+	return c.ttl > 0 && time.Since(entry.UpdatedAt) > c.ttl
+}
+
+// SetAvailable records the quantity of a product left to sell, as last
+// reported by an inventory event.
+func (c *ProductCache) SetAvailable(productID string, available int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stock[productID] = available
+}
+
+// Available returns the last reported available quantity of a product and
+// whether any was reported.
+func (c *ProductCache) Available(productID string) (int, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	available, ok := c.stock[productID]
+	return available, ok
+}
+
+// CanSupply reports whether quantity of a product can be sold: the product
+// is in stock and, if its stock is known, enough of it is available.
+func (c *ProductCache) CanSupply(productID string, quantity int) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if entry, ok := c.items[productID]; ok && !entry.InStock {
+		return false
+	}
+	if available, ok := c.stock[productID]; ok && available < quantity {
+		return false
+	}
+	return true
 }
 
 // Count returns the number of entries in the cache.
@@ -0,0 +1,79 @@
+package cart
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProductCacheSetStampsEntries(t *testing.T) {
+	t.Parallel()
+
+	eventTime := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		updatedAt time.Time
+		wantNow   bool
+	}{
+		{name: "event timestamp is kept", updatedAt: eventTime},
+		{name: "zero timestamp stamps now", updatedAt: time.Time{}, wantNow: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cache := NewProductCache()
+			before := time.Now()
+			cache.Set("p1", ProductEntry{ProductID: "p1", UpdatedAt: eventTime.Add(-time.Hour)}, tt.updatedAt)
+
+			entry, ok := cache.Peek("p1")
+			if !ok {
+				t.Fatal("Peek() found no entry")
+			}
+			if tt.wantNow {
+				if entry.UpdatedAt.Before(before) || entry.UpdatedAt.After(time.Now()) {
+					t.Errorf("UpdatedAt = %v, want the time of Set", entry.UpdatedAt)
+				}
+				return
+			}
+			if !entry.UpdatedAt.Equal(tt.updatedAt) {
+				t.Errorf("UpdatedAt = %v, want %v", entry.UpdatedAt, tt.updatedAt)
+			}
+		})
+	}
+}
+
+func TestProductCacheStaleness(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		ttl      time.Duration
+		age      time.Duration
+		wantGet  bool
+		wantPeek bool
+	}{
+		{name: "fresh entry is a hit", ttl: time.Hour, age: time.Minute, wantGet: true, wantPeek: true},
+		{name: "entry older than the TTL is a miss", ttl: time.Hour, age: 2 * time.Hour, wantGet: false, wantPeek: true},
+		{name: "zero TTL never goes stale", ttl: 0, age: 1000 * time.Hour, wantGet: true, wantPeek: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cache := NewProductCacheWithTTL(tt.ttl)
+			cache.Set("p1", ProductEntry{ProductID: "p1", InStock: true}, time.Now().Add(-tt.age))
+
+			if _, ok := cache.Get("p1"); ok != tt.wantGet {
+				t.Errorf("Get() found = %v, want %v", ok, tt.wantGet)
+			}
+			if _, ok := cache.Peek("p1"); ok != tt.wantPeek {
+				t.Errorf("Peek() found = %v, want %v", ok, tt.wantPeek)
+			}
+		})
+	}
+}
@@ -4,56 +4,84 @@ import (
 	"context"
 	"errors"
 	"log/slog"
+	"time"
 
 	"go-shopping-poc/internal/platform/database"
 	"go-shopping-poc/internal/platform/outbox"
 )
 
 var (
-	ErrCartNotFound        = errors.New("cart not found")
-	ErrCartItemNotFound    = errors.New("cart item not found")
-	ErrAddressNotFound     = errors.New("address not found")
-	ErrContactNotFound     = errors.New("contact not found")
-	ErrCreditCardNotFound  = errors.New("credit card not found")
-	ErrInvalidUUID         = errors.New("invalid UUID format")
-	ErrDatabaseOperation   = errors.New("database operation failed")
-	ErrTransactionFailed   = errors.New("transaction failed")
-	ErrDuplicateActiveCart = errors.New("customer already has an active cart")
+	ErrCartNotFound         = errors.New("cart not found")
+	ErrCartItemNotFound     = errors.New("cart item not found")
+	ErrAddressNotFound      = errors.New("address not found")
+	ErrContactNotFound      = errors.New("contact not found")
+	ErrCreditCardNotFound   = errors.New("credit card not found")
+	ErrInvalidUUID          = errors.New("invalid UUID format")
+	ErrDatabaseOperation    = errors.New("database operation failed")
+	ErrTransactionFailed    = errors.New("transaction failed")
+	ErrDuplicateActiveCart  = errors.New("customer already has an active cart")
+	ErrCartVersionConflict  = errors.New("cart was modified by another request")
+	ErrCouponAlreadyApplied = errors.New("coupon already applied to cart")
+	ErrCouponNotApplied     = errors.New("coupon not applied to cart")
+	ErrCheckoutSagaNotFound = errors.New("checkout saga not found")
+	ErrWishlistNotFound     = errors.New("wishlist not found")
+	ErrWishlistItemNotFound = errors.New("wishlist item not found")
+	ErrWishlistNameTaken    = errors.New("customer already has a wishlist of that name")
+
+	ErrReorderRequestNotFound = errors.New("reorder request not found")
 )
 
 type CartRepository interface {
 	CreateCart(ctx context.Context, cart *Cart) error
 	GetCartByID(ctx context.Context, cartID string) (*Cart, error)
 	UpdateCart(ctx context.Context, cart *Cart) error
+	UpdateCartTx(ctx context.Context, tx database.Tx, cart *Cart) error
 	DeleteCart(ctx context.Context, cartID string) error
 	GetActiveCartByCustomerID(ctx context.Context, customerID string) (*Cart, error)
 
 	AddItem(ctx context.Context, cartID string, item *CartItem) error
 	AddItemTx(ctx context.Context, tx database.Tx, cartID string, item *CartItem) error
-	UpdateItemQuantity(ctx context.Context, cartID string, lineNumber string, quantity int) error
-	RemoveItem(ctx context.Context, cartID string, lineNumber string) error
+	UpdateItemQuantityTx(ctx context.Context, tx database.Tx, cartID string, lineNumber string, quantity int) error
+	RemoveItemTx(ctx context.Context, tx database.Tx, cartID string, lineNumber string) error
+	UpdateItemAttributesTx(ctx context.Context, tx database.Tx, cartID string, lineNumber string, attrs LineAttributes) error
 	GetCartItems(ctx context.Context, cartID string) ([]CartItem, error)
 
 	// Validation support methods
 	GetItemByValidationID(ctx context.Context, validationID string) (*CartItem, error)
-	GetItemByProductID(ctx context.Context, cartID, productID string) (*CartItem, error)
-	UpdateItemStatus(ctx context.Context, item *CartItem) error
+	UpdateItemStatusAndTotals(ctx context.Context, cart *Cart, item *CartItem) error
+	UpdateItemPricesTx(ctx context.Context, tx database.Tx, cart *Cart) error
+	ListStalePendingItems(ctx context.Context, requestedBefore time.Time, limit int) ([]CartItem, error)
+	RetryItemValidation(ctx context.Context, item *CartItem) error
+	ListActiveCartsWithProduct(ctx context.Context, productID string) ([]string, error)
 
-	SetContact(ctx context.Context, cartID string, contact *Contact) error
+	SetContact(ctx context.Context, cart *Cart, contact *Contact) error
 	GetContact(ctx context.Context, cartID string) (*Contact, error)
-	AddAddress(ctx context.Context, cartID string, address *Address) error
+	AddAddressTx(ctx context.Context, tx database.Tx, cartID string, address *Address) error
 	GetAddresses(ctx context.Context, cartID string) ([]Address, error)
 	UpdateAddress(ctx context.Context, addressID int64, address *Address) error
 	RemoveAddress(ctx context.Context, addressID int64) error
 
-	SetCreditCard(ctx context.Context, cartID string, card *CreditCard) error
+	SetCreditCard(ctx context.Context, cart *Cart, card *CreditCard) error
 	GetCreditCard(ctx context.Context, cartID string) (*CreditCard, error)
-	RemoveCreditCard(ctx context.Context, cartID string) error
+	RemoveCreditCard(ctx context.Context, cart *Cart) error
 
 	GetStatusHistory(ctx context.Context, cartID string) ([]CartStatus, error)
 	AddStatusEntry(ctx context.Context, cartID string, status string) error
 
-	CheckoutCart(ctx context.Context, cartID string) (*Cart, error)
+	AddCouponTx(ctx context.Context, tx database.Tx, cartID string, code string) error
+	RemoveCouponTx(ctx context.Context, tx database.Tx, cartID string, code string) error
+	GetCoupons(ctx context.Context, cartID string) ([]string, error)
+
+	CheckoutCart(ctx context.Context, cartID string, version int, deadline time.Time) (*Cart, error)
+	SagaStore
+	WishlistStore
+	ClaimCart(ctx context.Context, cartID string, customerID string, merge MergeFunc, totals TotalsFunc) (*Cart, error)
+
+	GetReorderRequest(ctx context.Context, requestID string) (*ReorderRequest, error)
+	SaveReorderRequest(ctx context.Context, req *ReorderRequest) error
+
+	ExpireInactiveCarts(ctx context.Context, cutoff time.Time, limit int) ([]*Cart, error)
+	PurgeExpiredGuestCarts(ctx context.Context, cutoff time.Time, limit int) ([]string, error)
 }
 
 type cartRepository struct {
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -13,7 +14,10 @@ import (
 	events "go-shopping-poc/internal/contracts/events"
 )
 
-func (r *cartRepository) CheckoutCart(ctx context.Context, cartID string) (*Cart, error) {
+// CheckoutCart checks out the cart at version, the version whose totals the
+// caller saved, and starts its checkout saga with deadline. It fails with
+// ErrCartVersionConflict if the cart changed since.
+func (r *cartRepository) CheckoutCart(ctx context.Context, cartID string, version int, deadline time.Time) (*Cart, error) {
 	cartUUID, err := uuid.Parse(cartID)
 	if err != nil {
 		return nil, fmt.Errorf("%w: invalid cart ID: %w", ErrInvalidUUID, err)
@@ -35,6 +39,9 @@ func (r *cartRepository) CheckoutCart(ctx context.Context, cartID string) (*Cart
 	if err != nil {
 		return nil, err
 	}
+	if cart.Version != version {
+		return nil, ErrCartVersionConflict
+	}
 
 	if err := r.loadCartRelationsTx(ctx, tx, cart); err != nil {
 		return nil, err
@@ -44,6 +51,10 @@ func (r *cartRepository) CheckoutCart(ctx context.Context, cartID string) (*Cart
 		return nil, fmt.Errorf("%w: %w", ErrCartNotReadyForCheckout, err)
 	}
 
+	if err := r.redeemPromotionsTx(ctx, tx, cart); err != nil {
+		return nil, err
+	}
+
 	if err := cart.SetStatus("checked_out"); err != nil {
 		return nil, err
 	}
@@ -56,27 +67,43 @@ func (r *cartRepository) CheckoutCart(ctx context.Context, cartID string) (*Cart
 		    shipping = $4,
 		    total_price = $5,
 		    version = version + 1
-		WHERE cart_id = $6
+		WHERE cart_id = $6 AND version = $7
 	`
-	_, err = tx.Exec(ctx, query,
+	result, err := tx.Exec(ctx, query,
 		cart.CurrentStatus, cart.NetPrice, cart.Tax,
-		cart.Shipping, cart.TotalPrice, cart.CartID)
+		cart.Shipping, cart.TotalPrice, cart.CartID, cart.Version)
 	if err != nil {
 		return nil, fmt.Errorf("%w: failed to update cart: %w", ErrDatabaseOperation, err)
 	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get rows affected: %w", ErrDatabaseOperation, err)
+	}
+	if rows == 0 {
+		return nil, r.versionConflictTx(ctx, tx, cart.CartID)
+	}
+	cart.Version++
 
 	if err := r.addStatusEntryTx(ctx, tx, cartID, "checked_out"); err != nil {
 		return nil, err
 	}
 
+	attempt, err := r.startCheckoutSagaTx(ctx, tx, cart.CartID, deadline)
+	if err != nil {
+		return nil, err
+	}
+
 	var customerIDStr *string
 	if cart.CustomerID != nil {
 		id := cart.CustomerID.String()
 		customerIDStr = &id
 	}
 
-	snapshot := createCartSnapshot(cart)
-	evt := events.NewCartCheckedOutEventWithSnapshot(cartID, customerIDStr, snapshot)
+	snapshot, err := createCartSnapshot(cart)
+	if err != nil {
+		return nil, err
+	}
+	evt := events.NewCartCheckedOutEventWithSnapshot(cartID, customerIDStr, attempt, snapshot)
 	if err := r.outboxWriter.WriteEvent(ctx, tx, evt); err != nil {
 		return nil, fmt.Errorf("failed to write checkout event: %w", err)
 	}
@@ -146,17 +173,13 @@ func (r *cartRepository) addStatusEntryTx(ctx context.Context, tx database.Tx, c
 func (r *cartRepository) getCartByIDTx(ctx context.Context, tx database.Tx, cartID uuid.UUID) (*Cart, error) {
 	query := `
 		SELECT cart_id, customer_id, contact_id, credit_card_id, current_status,
-		       currency, net_price, tax, shipping, total_price, created_at, updated_at, version
+		       currency, net_price, tax, shipping, shipping_method, discount, discounts, total_price, created_at, updated_at, version
 		FROM carts.Cart
 		WHERE cart_id = $1
 	`
 
 	var cart Cart
-	err := tx.QueryRow(ctx, query, cartID).Scan(
-		&cart.CartID, &cart.CustomerID, &cart.ContactID, &cart.CreditCardID, &cart.CurrentStatus,
-		&cart.Currency, &cart.NetPrice, &cart.Tax, &cart.Shipping, &cart.TotalPrice,
-		&cart.CreatedAt, &cart.UpdatedAt, &cart.Version,
-	)
+	err := tx.GetContext(ctx, &cart, query, cartID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrCartNotFound
@@ -169,7 +192,9 @@ func (r *cartRepository) getCartByIDTx(ctx context.Context, tx database.Tx, cart
 
 func (r *cartRepository) loadCartRelationsTx(ctx context.Context, tx database.Tx, cart *Cart) error {
 	query := `
-		SELECT id, cart_id, line_number, product_id, product_name, unit_price, quantity, total_price, status, validation_id, backorder_reason, image_url
+		SELECT id, cart_id, line_number, product_id, product_name, unit_price, quantity, total_price, status, validation_id, backorder_reason, image_url,
+		       tax_code, tax, tax_breakdown, category, brand, original_unit_price, original_currency, fx_rate, fx_source, fx_as_of,
+		       previous_unit_price, price_changed_at, size, color, gift_message, weight_lb
 		FROM carts.CartItem
 		WHERE cart_id = $1
 		ORDER BY line_number
@@ -207,18 +232,26 @@ func (r *cartRepository) loadCartRelationsTx(ctx context.Context, tx database.Tx
 		return fmt.Errorf("failed to load addresses: %w", err)
 	}
 
+	query = `SELECT code FROM carts.CartCoupon WHERE cart_id = $1 ORDER BY applied_at, id`
+	err = tx.SelectContext(ctx, &cart.Coupons, query, cart.CartID)
+	if err != nil {
+		return fmt.Errorf("failed to load coupons: %w", err)
+	}
+
 	return nil
 }
 
-func createCartSnapshot(cart *Cart) *events.CartSnapshot {
+func createCartSnapshot(cart *Cart) (*events.CartSnapshot, error) {
 	snapshot := &events.CartSnapshot{
-		Currency:   cart.Currency,
-		NetPrice:   cart.NetPrice,
-		Tax:        cart.Tax,
-		Shipping:   cart.Shipping,
-		TotalPrice: cart.TotalPrice,
-		Items:      make([]events.SnapshotItem, len(cart.Items)),
-		Addresses:  make([]events.SnapshotAddress, 0),
+		Currency:       cart.Currency,
+		NetPrice:       cart.NetPrice,
+		Tax:            cart.Tax,
+		Shipping:       cart.Shipping,
+		ShippingMethod: cart.ShippingMethod,
+		Discount:       cart.Discount,
+		TotalPrice:     cart.TotalPrice,
+		Items:          make([]events.SnapshotItem, len(cart.Items)),
+		Addresses:      make([]events.SnapshotAddress, 0),
 	}
 
 	if cart.CustomerID != nil {
@@ -245,7 +278,20 @@ func createCartSnapshot(cart *Cart) *events.CartSnapshot {
 		}
 	}
 
+	for _, disc := range cart.Discounts {
+		snapshot.Discounts = append(snapshot.Discounts, events.SnapshotDiscount{
+			PromotionID: disc.PromotionID,
+			Code:        disc.Code,
+			Name:        disc.Name,
+			Amount:      disc.Amount.WithCurrency(cart.Currency),
+		})
+	}
+
 	for i, item := range cart.Items {
+		discount, err := cart.LineDiscount(item.LineNumber)
+		if err != nil {
+			return nil, fmt.Errorf("failed to discount line %s: %w", item.LineNumber, err)
+		}
 		snapshot.Items[i] = events.SnapshotItem{
 			LineNumber:  item.LineNumber,
 			ProductID:   item.ProductID,
@@ -254,6 +300,22 @@ func createCartSnapshot(cart *Cart) *events.CartSnapshot {
 			Quantity:    item.Quantity,
 			TotalPrice:  item.TotalPrice,
 			ImageURL:    item.ImageURL,
+			Size:        item.Size,
+			Color:       item.Color,
+			GiftMessage: item.GiftMessage,
+			TaxCode:     item.TaxCode,
+			Tax:         item.Tax,
+			Discount:    discount,
+		}
+		for _, rate := range item.TaxBreakdown {
+			snapshot.Items[i].TaxBreakdown = append(snapshot.Items[i].TaxBreakdown, events.SnapshotTaxRate{
+				Jurisdiction: rate.Jurisdiction,
+				Name:         rate.Name,
+				TaxCode:      rate.TaxCode,
+				Rate:         rate.Rate,
+				Amount:       rate.Amount.WithCurrency(cart.Currency),
+				TableVersion: rate.TableVersion,
+			})
 		}
 	}
 
@@ -270,5 +332,5 @@ func createCartSnapshot(cart *Cart) *events.CartSnapshot {
 		})
 	}
 
-	return snapshot
+	return snapshot, nil
 }
@@ -0,0 +1,231 @@
+package cart
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	events "go-shopping-poc/internal/contracts/events"
+	"go-shopping-poc/internal/platform/database"
+)
+
+// MergeFunc decides how a claimed guest cart's items join the customer's
+// active cart. It returns the target lines it changed and the new lines to
+// add to the target; lines left pending_validation are sent for validation.
+type MergeFunc func(ctx context.Context, guest, target *Cart) (updated []*CartItem, added []*CartItem, err error)
+
+// TotalsFunc recomputes the totals of a cart before it is saved.
+type TotalsFunc func(ctx context.Context, cart *Cart) error
+
+// ClaimCart attaches the active guest cart cartID to customerID. When the
+// customer has no active cart the guest cart is assigned to them and a
+// cart.claimed event is written. Otherwise merge combines the guest items
+// into the customer's cart, the guest cart is marked merged and a
+// cart.merged event is written. The totals of the customer's cart are
+// recomputed with totals and saved in the same transaction. It returns the
+// customer's active cart.
+//
+// Claims for the same customer are serialized so two guest carts claimed
+// at once cannot both become the customer's active cart.
+func (r *cartRepository) ClaimCart(ctx context.Context, cartID string, customerID string, merge MergeFunc, totals TotalsFunc) (*Cart, error) {
+	guestUUID, err := uuid.Parse(cartID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid cart ID: %w", ErrInvalidUUID, err)
+	}
+	customerUUID, err := uuid.Parse(customerID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCustomerID, err)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, customerID); err != nil {
+		return nil, fmt.Errorf("%w: failed to lock customer carts: %w", ErrDatabaseOperation, err)
+	}
+
+	guest, err := r.lockCartTx(ctx, tx, guestUUID)
+	if err != nil {
+		return nil, err
+	}
+	if guest.CustomerID != nil && *guest.CustomerID != customerUUID {
+		return nil, ErrCartOwnedByOtherCustomer
+	}
+	if guest.CurrentStatus != "active" {
+		return nil, fmt.Errorf("cannot claim cart: %w", ErrCartNotActive)
+	}
+	if guest.CustomerID != nil {
+		// Already claimed by this customer
+		if err := r.loadCartRelationsTx(ctx, tx, guest); err != nil {
+			return nil, err
+		}
+		return guest, nil
+	}
+
+	var targetID uuid.UUID
+	err = tx.GetContext(ctx, &targetID, `
+		SELECT cart_id FROM carts.Cart
+		WHERE customer_id = $1 AND current_status = 'active'
+	`, customerUUID)
+	var claimed *Cart
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		claimed, err = r.assignCustomerTx(ctx, tx, guest, customerUUID, totals)
+	case err != nil:
+		return nil, fmt.Errorf("%w: failed to get customer cart: %w", ErrDatabaseOperation, err)
+	default:
+		claimed, err = r.mergeCartTx(ctx, tx, guest, targetID, merge, totals)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%w: failed to commit transaction: %w", ErrTransactionFailed, err)
+	}
+	committed = true
+
+	return claimed, nil
+}
+
+// assignCustomerTx makes guest the customer's active cart.
+func (r *cartRepository) assignCustomerTx(ctx context.Context, tx database.Tx, guest *Cart, customerID uuid.UUID, totals TotalsFunc) (*Cart, error) {
+	if err := r.loadCartRelationsTx(ctx, tx, guest); err != nil {
+		return nil, err
+	}
+
+	// Customer promotions may now apply
+	guest.CustomerID = &customerID
+	if err := totals(ctx, guest); err != nil {
+		return nil, err
+	}
+	if err := r.UpdateCartTx(ctx, tx, guest); err != nil {
+		return nil, err
+	}
+
+	evt := events.NewCartClaimedEvent(guest.CartID.String(), customerID.String())
+	if err := r.outboxWriter.WriteEvent(ctx, tx, evt); err != nil {
+		return nil, fmt.Errorf("failed to write cart claimed event: %w", err)
+	}
+
+	return guest, nil
+}
+
+// mergeCartTx merges guest into the customer's active cart targetID and
+// marks guest merged. It returns the target cart as merged.
+func (r *cartRepository) mergeCartTx(ctx context.Context, tx database.Tx, guest *Cart, targetID uuid.UUID, merge MergeFunc, totals TotalsFunc) (*Cart, error) {
+	target, err := r.lockCartTx(ctx, tx, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.loadCartRelationsTx(ctx, tx, guest); err != nil {
+		return nil, err
+	}
+	if err := r.loadCartRelationsTx(ctx, tx, target); err != nil {
+		return nil, err
+	}
+
+	updated, added, err := merge(ctx, guest, target)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range updated {
+		if err := r.updateMergedItemTx(ctx, tx, targetID, item); err != nil {
+			return nil, err
+		}
+	}
+	for _, item := range added {
+		if err := r.AddItemTx(ctx, tx, targetID.String(), item); err != nil {
+			return nil, err
+		}
+	}
+	for _, item := range slices.Concat(updated, added) {
+		if !item.IsPendingValidation() {
+			continue
+		}
+		evt := events.NewCartItemAddedEvent(targetID.String(), item.LineNumber, item.ProductID, item.Quantity, *item.ValidationID)
+		if err := r.outboxWriter.WriteEvent(ctx, tx, evt); err != nil {
+			return nil, fmt.Errorf("failed to write cart item event: %w", err)
+		}
+	}
+
+	if err := guest.SetStatus("merged"); err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec(ctx, `UPDATE carts.Cart SET current_status = $1, version = version + 1 WHERE cart_id = $2`, guest.CurrentStatus, guest.CartID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to mark cart merged: %w", ErrDatabaseOperation, err)
+	}
+	if err := r.addStatusEntryTx(ctx, tx, guest.CartID.String(), "merged"); err != nil {
+		return nil, err
+	}
+
+	evt := events.NewCartMergedEvent(targetID.String(), guest.CartID.String(), target.CustomerID.String(), len(guest.Items))
+	if err := r.outboxWriter.WriteEvent(ctx, tx, evt); err != nil {
+		return nil, fmt.Errorf("failed to write cart merged event: %w", err)
+	}
+
+	merged, err := r.getCartByIDTx(ctx, tx, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.loadCartRelationsTx(ctx, tx, merged); err != nil {
+		return nil, err
+	}
+	if err := totals(ctx, merged); err != nil {
+		return nil, err
+	}
+	if err := r.UpdateCartTx(ctx, tx, merged); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+// lockCartTx reads a cart row and locks it until tx ends.
+func (r *cartRepository) lockCartTx(ctx context.Context, tx database.Tx, cartID uuid.UUID) (*Cart, error) {
+	if _, err := tx.Exec(ctx, `SELECT 1 FROM carts.Cart WHERE cart_id = $1 FOR UPDATE`, cartID); err != nil {
+		return nil, fmt.Errorf("%w: failed to lock cart: %w", ErrDatabaseOperation, err)
+	}
+	return r.getCartByIDTx(ctx, tx, cartID)
+}
+
+// updateMergedItemTx saves the quantity, price and validation state of a
+// target cart line changed by a merge. The line is validated again at the
+// current price, so a price change it was flagged with is dropped.
+func (r *cartRepository) updateMergedItemTx(ctx context.Context, tx database.Tx, cartID uuid.UUID, item *CartItem) error {
+	item.CalculateLineTotal()
+	item.requestValidation(time.Now())
+	item.clearPriceChange()
+	_, err := tx.Exec(ctx, `
+		UPDATE carts.CartItem
+		SET quantity = $1, product_name = $2, unit_price = $3, total_price = $4, status = $5, validation_id = $6,
+		    backorder_reason = $7, tax_code = $8, category = $9, brand = $10, original_unit_price = $11,
+		    original_currency = $12, fx_rate = $13, fx_source = $14, fx_as_of = $15,
+		    validation_requested_at = $16, validation_attempts = $17, previous_unit_price = $18, price_changed_at = $19,
+		    weight_lb = $20
+		WHERE cart_id = $21 AND line_number = $22
+	`, item.Quantity, item.ProductName, item.UnitPrice, item.TotalPrice, item.Status, item.ValidationID,
+		item.BackorderReason, item.TaxCode, item.Category, item.Brand, item.OriginalUnitPrice,
+		item.OriginalCurrency, item.FXRate, item.FXSource, item.FXAsOf,
+		item.ValidationRequestedAt, item.ValidationAttempts, item.PreviousUnitPrice, item.PriceChangedAt,
+		item.WeightLb, cartID, item.LineNumber)
+	if err != nil {
+		return fmt.Errorf("%w: failed to update merged item: %w", ErrDatabaseOperation, err)
+	}
+	return nil
+}
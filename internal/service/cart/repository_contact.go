@@ -7,21 +7,23 @@ import (
 	"fmt"
 
 	"github.com/google/uuid"
+
+	"go-shopping-poc/internal/platform/database"
 )
 
-func (r *cartRepository) SetContact(ctx context.Context, cartID string, contact *Contact) error {
+// SetContact replaces the contact of cart. The cart update is a
+// compare-and-swap on cart.Version: it fails with ErrCartVersionConflict
+// when the cart changed since it was read, and increments cart.Version when
+// it succeeds.
+func (r *cartRepository) SetContact(ctx context.Context, cart *Cart, contact *Contact) error {
 	r.logger.Debug("Setting contact for cart",
-		"cart_id", cartID,
+		"cart_id", cart.CartID,
 		"contact", contact,
 	)
-	cartUUID, err := uuid.Parse(cartID)
-	if err != nil {
-		return fmt.Errorf("%w: invalid cart ID: %w", ErrInvalidUUID, err)
-	}
 
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		r.logger.Error("Failed to begin transaction for setting contact", "cart_id", cartID, "error", err.Error())
+		r.logger.Error("Failed to begin transaction for setting contact", "cart_id", cart.CartID, "error", err.Error())
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 
@@ -32,31 +34,43 @@ func (r *cartRepository) SetContact(ctx context.Context, cartID string, contact
 		}
 	}()
 
-	_, _ = tx.Exec(ctx, `DELETE FROM carts.Contact WHERE cart_id = $1`, cartUUID)
+	_, _ = tx.Exec(ctx, `DELETE FROM carts.Contact WHERE cart_id = $1`, cart.CartID)
 
-	contact.CartID = cartUUID
+	contact.CartID = cart.CartID
 	err = tx.QueryRow(ctx, `
 		INSERT INTO carts.Contact (cart_id, email, first_name, last_name, phone)
 		VALUES ($1, $2, $3, $4, $5)
 		RETURNING id
-	`, cartUUID, contact.Email, contact.FirstName, contact.LastName, contact.Phone).Scan(&contact.ID)
+	`, cart.CartID, contact.Email, contact.FirstName, contact.LastName, contact.Phone).Scan(&contact.ID)
 	if err != nil {
-		r.logger.Error("Failed to insert contact into database", "cart_id", cartID, "error", err.Error())
+		r.logger.Error("Failed to insert contact into database", "cart_id", cart.CartID, "error", err.Error())
 		return fmt.Errorf("%w: failed to insert contact: %w", ErrDatabaseOperation, err)
 	}
 
-	_, err = tx.Exec(ctx, `UPDATE carts.Cart SET contact_id = $1 WHERE cart_id = $2`, contact.ID, cartUUID)
+	result, err := tx.Exec(ctx, `
+		UPDATE carts.Cart SET contact_id = $1, version = version + 1
+		WHERE cart_id = $2 AND version = $3
+	`, contact.ID, cart.CartID, cart.Version)
 	if err != nil {
-		r.logger.Error("Failed to update cart with contact", "cart_id", cartID, "error", err.Error())
+		r.logger.Error("Failed to update cart with contact", "cart_id", cart.CartID, "error", err.Error())
 		return fmt.Errorf("%w: failed to update cart contact: %w", ErrDatabaseOperation, err)
 	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: failed to get rows affected: %w", ErrDatabaseOperation, err)
+	}
+	if rows == 0 {
+		return r.versionConflictTx(ctx, tx, cart.CartID)
+	}
 
 	if err := tx.Commit(); err != nil {
-		r.logger.Error("Failed to commit transaction for setting contact", "cart_id", cartID, "error", err.Error())
+		r.logger.Error("Failed to commit transaction for setting contact", "cart_id", cart.CartID, "error", err.Error())
 		return fmt.Errorf("%w: failed to commit transaction: %w", ErrTransactionFailed, err)
 	}
 	committed = true
 
+	cart.ContactID = &contact.ID
+	cart.Version++
 	return nil
 }
 
@@ -82,17 +96,19 @@ func (r *cartRepository) GetContact(ctx context.Context, cartID string) (*Contac
 	return &contact, nil
 }
 
-func (r *cartRepository) AddAddress(ctx context.Context, cartID string, address *Address) error {
+// AddAddressTx adds an address to the cart within tx.
+func (r *cartRepository) AddAddressTx(ctx context.Context, tx database.Tx, cartID string, address *Address) error {
 	cartUUID, err := uuid.Parse(cartID)
 	if err != nil {
 		return fmt.Errorf("%w: invalid cart ID: %w", ErrInvalidUUID, err)
 	}
 
 	address.CartID = cartUUID
-	_, err = r.db.Exec(ctx, `
+	err = tx.QueryRow(ctx, `
 		INSERT INTO carts.Address (cart_id, address_type, first_name, last_name, address_1, address_2, city, state, zip)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-	`, cartUUID, address.AddressType, address.FirstName, address.LastName, address.Address1, address.Address2, address.City, address.State, address.Zip)
+		RETURNING id
+	`, cartUUID, address.AddressType, address.FirstName, address.LastName, address.Address1, address.Address2, address.City, address.State, address.Zip).Scan(&address.ID)
 	if err != nil {
 		return fmt.Errorf("%w: failed to insert address: %w", ErrDatabaseOperation, err)
 	}
@@ -10,6 +10,7 @@ import (
 	"github.com/google/uuid"
 
 	events "go-shopping-poc/internal/contracts/events"
+	"go-shopping-poc/internal/platform/database"
 )
 
 func (r *cartRepository) CreateCart(ctx context.Context, cart *Cart) error {
@@ -29,22 +30,23 @@ func (r *cartRepository) CreateCart(ctx context.Context, cart *Cart) error {
 
 	cart.CartID = uuid.New()
 	cart.CurrentStatus = "active"
+	cart.Version = 1
 	cart.CreatedAt = time.Now()
 	cart.UpdatedAt = time.Now()
 
 	query := `
 		INSERT INTO carts.Cart (
 			cart_id, customer_id, contact_id, credit_card_id, current_status,
-			currency, net_price, tax, shipping, total_price, created_at, updated_at
+			currency, net_price, tax, shipping, total_price, created_at, updated_at, version
 		) VALUES (
 			$1, $2, $3, $4, $5,
-			$6, $7, $8, $9, $10, $11, $12
+			$6, $7, $8, $9, $10, $11, $12, $13
 		)
 	`
 
 	_, err = tx.ExecContext(ctx, query,
 		cart.CartID, cart.CustomerID, cart.ContactID, cart.CreditCardID, cart.CurrentStatus,
-		cart.Currency, cart.NetPrice, cart.Tax, cart.Shipping, cart.TotalPrice, cart.CreatedAt, cart.UpdatedAt)
+		cart.Currency, cart.NetPrice, cart.Tax, cart.Shipping, cart.TotalPrice, cart.CreatedAt, cart.UpdatedAt, cart.Version)
 	if err != nil {
 		return fmt.Errorf("%w: failed to insert cart: %w", ErrDatabaseOperation, err)
 	}
@@ -80,7 +82,7 @@ func (r *cartRepository) GetCartByID(ctx context.Context, cartID string) (*Cart,
 
 	query := `
 		SELECT cart_id, customer_id, contact_id, credit_card_id, current_status,
-		       currency, net_price, tax, shipping, total_price, created_at, updated_at, version
+		       currency, net_price, tax, shipping, shipping_method, discount, discounts, total_price, created_at, updated_at, version
 		FROM carts.Cart
 		WHERE cart_id = $1
 	`
@@ -103,7 +105,38 @@ func (r *cartRepository) GetCartByID(ctx context.Context, cartID string) (*Cart,
 	return &cart, nil
 }
 
+// UpdateCart saves the cart totals together with the per-line tax computed
+// by CalculateTotals. See UpdateCartTx.
 func (r *cartRepository) UpdateCart(ctx context.Context, cart *Cart) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err := r.UpdateCartTx(ctx, tx, cart); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w: failed to commit transaction: %w", ErrTransactionFailed, err)
+	}
+	committed = true
+
+	return nil
+}
+
+// UpdateCartTx saves the cart totals and per-line tax within tx. The update
+// is a compare-and-swap on cart.Version: it fails with
+// ErrCartVersionConflict when the cart changed since it was read, and
+// increments cart.Version when it succeeds.
+func (r *cartRepository) UpdateCartTx(ctx context.Context, tx database.Tx, cart *Cart) error {
 	query := `
 		UPDATE carts.Cart
 		SET customer_id = $1,
@@ -114,14 +147,18 @@ func (r *cartRepository) UpdateCart(ctx context.Context, cart *Cart) error {
 		    net_price = $6,
 		    tax = $7,
 		    shipping = $8,
-		    total_price = $9,
+		    shipping_method = $9,
+		    discount = $10,
+		    discounts = $11,
+		    total_price = $12,
 		    version = version + 1
-		WHERE cart_id = $10
+		WHERE cart_id = $13 AND version = $14
 	`
 
-	result, err := r.db.Exec(ctx, query,
+	result, err := tx.Exec(ctx, query,
 		cart.CustomerID, cart.ContactID, cart.CreditCardID, cart.CurrentStatus,
-		cart.Currency, cart.NetPrice, cart.Tax, cart.Shipping, cart.TotalPrice, cart.CartID)
+		cart.Currency, cart.NetPrice, cart.Tax, cart.Shipping, cart.ShippingMethod, cart.Discount, cart.Discounts, cart.TotalPrice,
+		cart.CartID, cart.Version)
 	if err != nil {
 		return fmt.Errorf("%w: failed to update cart: %w", ErrDatabaseOperation, err)
 	}
@@ -131,12 +168,39 @@ func (r *cartRepository) UpdateCart(ctx context.Context, cart *Cart) error {
 		return fmt.Errorf("%w: failed to get rows affected: %w", ErrDatabaseOperation, err)
 	}
 	if rows == 0 {
-		return ErrCartNotFound
+		return r.versionConflictTx(ctx, tx, cart.CartID)
+	}
+
+	for _, item := range cart.Items {
+		_, err := tx.Exec(ctx, `
+			UPDATE carts.CartItem
+			SET tax_code = $1, tax = $2, tax_breakdown = $3
+			WHERE cart_id = $4 AND line_number = $5
+		`, item.TaxCode, item.Tax, item.TaxBreakdown, cart.CartID, item.LineNumber)
+		if err != nil {
+			return fmt.Errorf("%w: failed to update item tax: %w", ErrDatabaseOperation, err)
+		}
 	}
 
+	cart.Version++
 	return nil
 }
 
+// versionConflictTx explains a compare-and-swap update of cartID that
+// matched no row: ErrCartNotFound if the cart is gone, otherwise
+// ErrCartVersionConflict.
+func (r *cartRepository) versionConflictTx(ctx context.Context, tx database.Tx, cartID uuid.UUID) error {
+	var exists bool
+	err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM carts.Cart WHERE cart_id = $1)`, cartID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("%w: failed to check cart: %w", ErrDatabaseOperation, err)
+	}
+	if !exists {
+		return ErrCartNotFound
+	}
+	return ErrCartVersionConflict
+}
+
 func (r *cartRepository) DeleteCart(ctx context.Context, cartID string) error {
 	id, err := uuid.Parse(cartID)
 	if err != nil {
@@ -205,7 +269,7 @@ func (r *cartRepository) GetActiveCartByCustomerID(ctx context.Context, customer
 
 	query := `
 		SELECT cart_id, customer_id, contact_id, credit_card_id, current_status,
-		       currency, net_price, tax, shipping, total_price, created_at, updated_at, version
+		       currency, net_price, tax, shipping, shipping_method, discount, discounts, total_price, created_at, updated_at, version
 		FROM carts.Cart
 		WHERE customer_id = $1 AND current_status = 'active'
 	`
@@ -259,5 +323,11 @@ func (r *cartRepository) loadCartRelations(ctx context.Context, cart *Cart) erro
 		return fmt.Errorf("failed to load status history: %w", err)
 	}
 
+	cart.Coupons, err = r.GetCoupons(ctx, cart.CartID.String())
+	if err != nil {
+		r.logger.Error("Failed to load coupons for cart", "error", err.Error())
+		return fmt.Errorf("failed to load coupons: %w", err)
+	}
+
 	return nil
 }
@@ -0,0 +1,163 @@
+package cart
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	events "go-shopping-poc/internal/contracts/events"
+)
+
+// ExpireInactiveCarts expires up to limit active carts last updated before
+// cutoff, recording the change in status history and writing a
+// cart.abandoned event with a snapshot of each. Carts locked by another
+// transaction are skipped and picked up by a later run.
+func (r *cartRepository) ExpireInactiveCarts(ctx context.Context, cutoff time.Time, limit int) ([]*Cart, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var cartIDs []uuid.UUID
+	err = tx.SelectContext(ctx, &cartIDs, `
+		SELECT cart_id
+		FROM carts.Cart
+		WHERE current_status = 'active' AND updated_at < $1
+		ORDER BY updated_at
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	`, cutoff, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to select inactive carts: %w", ErrDatabaseOperation, err)
+	}
+
+	expired := make([]*Cart, 0, len(cartIDs))
+	for _, cartID := range cartIDs {
+		cart, err := r.getCartByIDTx(ctx, tx, cartID)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.loadCartRelationsTx(ctx, tx, cart); err != nil {
+			return nil, err
+		}
+		if err := cart.SetStatus("expired"); err != nil {
+			return nil, err
+		}
+
+		_, err = tx.Exec(ctx, `UPDATE carts.Cart SET current_status = $1, version = version + 1 WHERE cart_id = $2`, cart.CurrentStatus, cart.CartID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to expire cart: %w", ErrDatabaseOperation, err)
+		}
+		if err := r.addStatusEntryTx(ctx, tx, cartID.String(), "expired"); err != nil {
+			return nil, err
+		}
+
+		var customerIDStr *string
+		if cart.CustomerID != nil {
+			id := cart.CustomerID.String()
+			customerIDStr = &id
+		}
+		snapshot, err := abandonedSnapshot(cart)
+		if err != nil {
+			return nil, err
+		}
+		evt := events.NewCartAbandonedEvent(cartID.String(), customerIDStr, snapshot)
+		if err := r.outboxWriter.WriteEvent(ctx, tx, evt); err != nil {
+			return nil, fmt.Errorf("failed to write abandoned event: %w", err)
+		}
+
+		expired = append(expired, cart)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%w: failed to commit transaction: %w", ErrTransactionFailed, err)
+	}
+	committed = true
+
+	return expired, nil
+}
+
+// abandonedSnapshot returns the snapshot a cart.abandoned event carries:
+// the cart without its card details.
+func abandonedSnapshot(cart *Cart) (*events.CartSnapshot, error) {
+	snapshot, err := createCartSnapshot(cart)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.CreditCard = nil
+	return snapshot, nil
+}
+
+// PurgeExpiredGuestCarts deletes the contact, credit card and address rows
+// of up to limit expired or merged guest carts last updated before cutoff
+// and marks them purged. Items and status history are kept. It returns the IDs of
+// the purged carts.
+func (r *cartRepository) PurgeExpiredGuestCarts(ctx context.Context, cutoff time.Time, limit int) ([]string, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var cartIDs []uuid.UUID
+	err = tx.SelectContext(ctx, &cartIDs, `
+		SELECT cart_id
+		FROM carts.Cart
+		WHERE current_status IN ('expired', 'merged') AND customer_id IS NULL AND updated_at < $1
+		ORDER BY updated_at
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	`, cutoff, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to select expired guest carts: %w", ErrDatabaseOperation, err)
+	}
+
+	purged := make([]string, 0, len(cartIDs))
+	for _, cartID := range cartIDs {
+		for _, query := range []string{
+			`DELETE FROM carts.Contact WHERE cart_id = $1`,
+			`DELETE FROM carts.CreditCard WHERE cart_id = $1`,
+			`DELETE FROM carts.Address WHERE cart_id = $1`,
+		} {
+			if _, err := tx.Exec(ctx, query, cartID); err != nil {
+				return nil, fmt.Errorf("%w: failed to purge cart %s: %w", ErrDatabaseOperation, cartID, err)
+			}
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE carts.Cart
+			SET current_status = 'purged', contact_id = NULL, credit_card_id = NULL, version = version + 1
+			WHERE cart_id = $1
+		`, cartID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to mark cart purged: %w", ErrDatabaseOperation, err)
+		}
+		if err := r.addStatusEntryTx(ctx, tx, cartID.String(), "purged"); err != nil {
+			return nil, err
+		}
+
+		purged = append(purged, cartID.String())
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%w: failed to commit transaction: %w", ErrTransactionFailed, err)
+	}
+	committed = true
+
+	return purged, nil
+}
@@ -0,0 +1,171 @@
+package cart
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"go-shopping-poc/internal/platform/database"
+)
+
+// storedCart is the part of a cart row and its relations the purge reads
+// and clears.
+type storedCart struct {
+	customerID *uuid.UUID
+	status     string
+	updatedAt  time.Time
+	contact    bool
+	creditCard bool
+	addresses  int
+	history    []string
+}
+
+// fakePurgeDB runs the guest purge's statements against stored carts. The
+// cart select honours the statuses and the guest filter its query names.
+type fakePurgeDB struct {
+	database.Database
+	carts map[uuid.UUID]*storedCart
+}
+
+func (db *fakePurgeDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (database.Tx, error) {
+	return &fakePurgeTx{db: db}, nil
+}
+
+type fakePurgeTx struct {
+	database.Tx
+	db *fakePurgeDB
+}
+
+func (tx *fakePurgeTx) Commit() error   { return nil }
+func (tx *fakePurgeTx) Rollback() error { return nil }
+
+var quotedStatus = regexp.MustCompile(`'(\w+)'`)
+
+func (tx *fakePurgeTx) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	where := query[strings.Index(query, "WHERE"):]
+	var statuses []string
+	for _, m := range quotedStatus.FindAllStringSubmatch(where, -1) {
+		statuses = append(statuses, m[1])
+	}
+	guestsOnly := strings.Contains(where, "customer_id IS NULL")
+	cutoff, limit := args[0].(time.Time), args[1].(int)
+
+	var ids []uuid.UUID
+	for id, cart := range tx.db.carts {
+		if slices.Contains(statuses, cart.status) && (!guestsOnly || cart.customerID == nil) && cart.updatedAt.Before(cutoff) {
+			ids = append(ids, id)
+		}
+	}
+	slices.SortFunc(ids, func(a, b uuid.UUID) int {
+		return tx.db.carts[a].updatedAt.Compare(tx.db.carts[b].updatedAt)
+	})
+	*dest.(*[]uuid.UUID) = ids[:min(limit, len(ids))]
+	return nil
+}
+
+func (tx *fakePurgeTx) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	cart := tx.db.carts[args[0].(uuid.UUID)]
+	switch {
+	case strings.Contains(query, "DELETE FROM carts.Contact"):
+		cart.contact = false
+	case strings.Contains(query, "DELETE FROM carts.CreditCard"):
+		cart.creditCard = false
+	case strings.Contains(query, "DELETE FROM carts.Address"):
+		cart.addresses = 0
+	case strings.Contains(query, "SET current_status = 'purged'"):
+		cart.status = "purged"
+	case strings.Contains(query, "INSERT INTO carts.CartStatus"):
+		cart.history = append(cart.history, args[1].(string))
+	default:
+		return nil, fmt.Errorf("unexpected exec: %s", query)
+	}
+	return nil, nil
+}
+
+func TestPurgeExpiredGuestCarts(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	customerID := uuid.New()
+	filled := func(status string, customerID *uuid.UUID, age time.Duration) *storedCart {
+		return &storedCart{
+			customerID: customerID,
+			status:     status,
+			updatedAt:  now.Add(-age),
+			contact:    true,
+			creditCard: true,
+			addresses:  2,
+			history:    []string{"active", status},
+		}
+	}
+
+	guest, merged, customer, recent, active := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	db := &fakePurgeDB{carts: map[uuid.UUID]*storedCart{
+		guest:    filled("expired", nil, 48*time.Hour),
+		merged:   filled("merged", nil, 36*time.Hour),
+		customer: filled("expired", &customerID, 48*time.Hour),
+		recent:   filled("expired", nil, time.Hour),
+		active:   filled("active", nil, 48*time.Hour),
+	}}
+	repo := NewCartRepository(db, nil)
+
+	purged, err := repo.PurgeExpiredGuestCarts(context.Background(), now.Add(-24*time.Hour), expiryBatchSize)
+	if err != nil {
+		t.Fatalf("PurgeExpiredGuestCarts() error = %v", err)
+	}
+	if want := []string{guest.String(), merged.String()}; !slices.Equal(purged, want) {
+		t.Fatalf("purged = %v, want the old expired and merged guest carts %v", purged, want)
+	}
+
+	for id, status := range map[uuid.UUID]string{guest: "expired", merged: "merged"} {
+		got := db.carts[id]
+		if got.status != "purged" || got.contact || got.creditCard || got.addresses != 0 {
+			t.Errorf("%s guest cart = %+v, want purged without contact, card or addresses", status, got)
+		}
+		if want := []string{"active", status, "purged"}; !slices.Equal(got.history, want) {
+			t.Errorf("%s guest history = %v, want %v", status, got.history, want)
+		}
+	}
+	for name, id := range map[string]uuid.UUID{"customer": customer, "recent": recent, "active": active} {
+		if cart := db.carts[id]; cart.status == "purged" || !cart.contact || !cart.creditCard || cart.addresses != 2 {
+			t.Errorf("%s cart = %+v, want it untouched", name, cart)
+		}
+	}
+}
+
+func TestAbandonedSnapshotStripsCard(t *testing.T) {
+	t.Parallel()
+
+	contactID, cardID := int64(1), int64(2)
+	cart := &Cart{
+		CartID:        uuid.New(),
+		Currency:      "USD",
+		CurrentStatus: "expired",
+		ContactID:     &contactID,
+		CreditCardID:  &cardID,
+		Contact:       &Contact{Email: "shopper@example.com"},
+		CreditCard:    &CreditCard{CardNumber: "4111111111111111", CardCVV: "123"},
+		Items:         []CartItem{{LineNumber: "001", ProductID: "p1", Quantity: 1}},
+	}
+
+	snapshot, err := abandonedSnapshot(cart)
+	if err != nil {
+		t.Fatalf("abandonedSnapshot() error = %v", err)
+	}
+	if snapshot.CreditCard != nil {
+		t.Errorf("snapshot carries card %+v, want none", snapshot.CreditCard)
+	}
+	if snapshot.Contact == nil || snapshot.Contact.Email != "shopper@example.com" || len(snapshot.Items) != 1 {
+		t.Errorf("snapshot = %+v, want the contact and items kept", snapshot)
+	}
+	if cart.CreditCard == nil {
+		t.Error("abandonedSnapshot() cleared the cart's own card")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"go-shopping-poc/internal/platform/database"
 
@@ -47,14 +48,17 @@ func (r *cartRepository) AddItem(ctx context.Context, cartID string, item *CartI
 
 	query := `
 		INSERT INTO carts.CartItem (
-			cart_id, line_number, product_id, product_name, unit_price, quantity, total_price, image_url
+			cart_id, line_number, product_id, product_name, unit_price, quantity, total_price, image_url, tax_code,
+			category, brand, original_unit_price, original_currency, fx_rate, fx_source, fx_as_of, size, color, gift_message, weight_lb
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20
 		)
 	`
 
 	_, err = tx.ExecContext(ctx, query,
-		item.CartID, item.LineNumber, item.ProductID, item.ProductName, item.UnitPrice, item.Quantity, item.TotalPrice, item.ImageURL)
+		item.CartID, item.LineNumber, item.ProductID, item.ProductName, item.UnitPrice, item.Quantity, item.TotalPrice, item.ImageURL, item.TaxCode,
+		item.Category, item.Brand, item.OriginalUnitPrice, item.OriginalCurrency, item.FXRate, item.FXSource, item.FXAsOf,
+		item.Size, item.Color, item.GiftMessage, item.WeightLb)
 	if err != nil {
 		r.logger.Error("Failed to insert item into database", "cart_id", cartID, "error", err.Error())
 		return fmt.Errorf("%w: failed to insert item: %w", ErrDatabaseOperation, err)
@@ -69,7 +73,9 @@ func (r *cartRepository) AddItem(ctx context.Context, cartID string, item *CartI
 	return nil
 }
 
-func (r *cartRepository) UpdateItemQuantity(ctx context.Context, cartID string, lineNumber string, quantity int) error {
+// UpdateItemQuantityTx changes the quantity of a line within tx and
+// recomputes its total. Cart totals are saved separately by UpdateCartTx.
+func (r *cartRepository) UpdateItemQuantityTx(ctx context.Context, tx database.Tx, cartID string, lineNumber string, quantity int) error {
 	cartUUID, err := uuid.Parse(cartID)
 	if err != nil {
 		return fmt.Errorf("%w: invalid cart ID: %w", ErrInvalidUUID, err)
@@ -80,7 +86,7 @@ func (r *cartRepository) UpdateItemQuantity(ctx context.Context, cartID string,
 	}
 
 	var item CartItem
-	err = r.db.GetContext(ctx, &item, `
+	err = tx.GetContext(ctx, &item, `
 		SELECT id, unit_price FROM carts.CartItem 
 		WHERE cart_id = $1 AND line_number = $2
 	`, cartUUID, lineNumber)
@@ -91,9 +97,9 @@ func (r *cartRepository) UpdateItemQuantity(ctx context.Context, cartID string,
 		return fmt.Errorf("%w: failed to get item: %w", ErrDatabaseOperation, err)
 	}
 
-	newTotal := float64(quantity) * item.UnitPrice
+	newTotal := item.UnitPrice.Mul(int64(quantity))
 
-	_, err = r.db.Exec(ctx, `
+	_, err = tx.Exec(ctx, `
 		UPDATE carts.CartItem
 		SET quantity = $1, total_price = $2
 		WHERE cart_id = $3 AND line_number = $4
@@ -105,13 +111,15 @@ func (r *cartRepository) UpdateItemQuantity(ctx context.Context, cartID string,
 	return nil
 }
 
-func (r *cartRepository) RemoveItem(ctx context.Context, cartID string, lineNumber string) error {
+// RemoveItemTx deletes a line within tx. Cart totals are saved separately
+// by UpdateCartTx.
+func (r *cartRepository) RemoveItemTx(ctx context.Context, tx database.Tx, cartID string, lineNumber string) error {
 	cartUUID, err := uuid.Parse(cartID)
 	if err != nil {
 		return fmt.Errorf("%w: invalid cart ID: %w", ErrInvalidUUID, err)
 	}
 
-	_, err = r.db.Exec(ctx, `DELETE FROM carts.CartItem WHERE cart_id = $1 AND line_number = $2`, cartUUID, lineNumber)
+	_, err = tx.Exec(ctx, `DELETE FROM carts.CartItem WHERE cart_id = $1 AND line_number = $2`, cartUUID, lineNumber)
 	if err != nil {
 		return fmt.Errorf("%w: failed to delete item: %w", ErrDatabaseOperation, err)
 	}
@@ -119,6 +127,33 @@ func (r *cartRepository) RemoveItem(ctx context.Context, cartID string, lineNumb
 	return nil
 }
 
+// UpdateItemAttributesTx changes the size, color and gift message of a
+// line within tx.
+func (r *cartRepository) UpdateItemAttributesTx(ctx context.Context, tx database.Tx, cartID string, lineNumber string, attrs LineAttributes) error {
+	cartUUID, err := uuid.Parse(cartID)
+	if err != nil {
+		return fmt.Errorf("%w: invalid cart ID: %w", ErrInvalidUUID, err)
+	}
+
+	result, err := tx.Exec(ctx, `
+		UPDATE carts.CartItem
+		SET size = $1, color = $2, gift_message = $3
+		WHERE cart_id = $4 AND line_number = $5
+	`, attrs.Size, attrs.Color, attrs.GiftMessage, cartUUID, lineNumber)
+	if err != nil {
+		return fmt.Errorf("%w: failed to update item attributes: %w", ErrDatabaseOperation, err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: failed to get rows affected: %w", ErrDatabaseOperation, err)
+	}
+	if rows == 0 {
+		return ErrCartItemNotFound
+	}
+
+	return nil
+}
+
 func (r *cartRepository) GetCartItems(ctx context.Context, cartID string) ([]CartItem, error) {
 	cartUUID, err := uuid.Parse(cartID)
 	if err != nil {
@@ -130,7 +165,9 @@ func (r *cartRepository) GetCartItems(ctx context.Context, cartID string) ([]Car
 	err = r.db.SelectContext(ctx, &items, `
 		SELECT ci.id, ci.cart_id, ci.line_number, ci.product_id, ci.product_name, 
 		       ci.unit_price, ci.quantity, ci.total_price, ci.status, ci.validation_id, ci.backorder_reason,
-		       ci.image_url
+		       ci.image_url, ci.tax_code, ci.tax, ci.tax_breakdown, ci.category, ci.brand,
+		       ci.original_unit_price, ci.original_currency, ci.fx_rate, ci.fx_source, ci.fx_as_of,
+		       ci.previous_unit_price, ci.price_changed_at, ci.size, ci.color, ci.gift_message, ci.weight_lb
 		FROM carts.CartItem ci
 		WHERE ci.cart_id = $1
 		ORDER BY ci.line_number
@@ -163,17 +200,22 @@ func (r *cartRepository) AddItemTx(ctx context.Context, tx database.Tx, cartID s
 	item.LineNumber = fmt.Sprintf("%03d", nextLine)
 	item.CartID = cartUUID
 	item.CalculateLineTotal()
+	item.requestValidation(time.Now())
 
 	query := `
 		INSERT INTO carts.CartItem (
-			cart_id, line_number, product_id, product_name, unit_price, quantity, total_price, status, validation_id, image_url
+			cart_id, line_number, product_id, product_name, unit_price, quantity, total_price, status, validation_id, image_url, tax_code,
+			category, brand, original_unit_price, original_currency, fx_rate, fx_source, fx_as_of, validation_requested_at, validation_attempts,
+			size, color, gift_message, weight_lb
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24
 		)
 	`
 
 	_, err = tx.Exec(ctx, query,
-		item.CartID, item.LineNumber, item.ProductID, item.ProductName, item.UnitPrice, item.Quantity, item.TotalPrice, item.Status, item.ValidationID, item.ImageURL)
+		item.CartID, item.LineNumber, item.ProductID, item.ProductName, item.UnitPrice, item.Quantity, item.TotalPrice, item.Status, item.ValidationID, item.ImageURL, item.TaxCode,
+		item.Category, item.Brand, item.OriginalUnitPrice, item.OriginalCurrency, item.FXRate, item.FXSource, item.FXAsOf, item.ValidationRequestedAt, item.ValidationAttempts,
+		item.Size, item.Color, item.GiftMessage, item.WeightLb)
 	if err != nil {
 		r.logger.Error("Failed to insert item into database (transactional)", "cart_id", cartID, "error", err.Error())
 		return fmt.Errorf("%w: failed to insert item: %w", ErrDatabaseOperation, err)
@@ -186,7 +228,9 @@ func (r *cartRepository) AddItemTx(ctx context.Context, tx database.Tx, cartID s
 func (r *cartRepository) GetItemByValidationID(ctx context.Context, validationID string) (*CartItem, error) {
 	var item CartItem
 	err := r.db.GetContext(ctx, &item, `
-		SELECT id, cart_id, line_number, product_id, product_name, unit_price, quantity, total_price, status, validation_id, backorder_reason
+		SELECT id, cart_id, line_number, product_id, product_name, unit_price, quantity, total_price, status, validation_id, backorder_reason, tax_code,
+		       category, brand, original_unit_price, original_currency, fx_rate, fx_source, fx_as_of,
+		       previous_unit_price, price_changed_at, size, color, gift_message, weight_lb
 		FROM carts.CartItem
 		WHERE validation_id = $1
 	`, validationID)
@@ -201,41 +245,80 @@ func (r *cartRepository) GetItemByValidationID(ctx context.Context, validationID
 	return &item, nil
 }
 
-// GetItemByProductID finds a cart item by cart ID and product ID (for duplicate detection)
-func (r *cartRepository) GetItemByProductID(ctx context.Context, cartID, productID string) (*CartItem, error) {
-	cartUUID, err := uuid.Parse(cartID)
+// UpdateItemStatusAndTotals saves the validation outcome of item together
+// with the cart totals in one transaction. The cart update is a
+// compare-and-swap on cart.Version, see UpdateCartTx.
+func (r *cartRepository) UpdateItemStatusAndTotals(ctx context.Context, cart *Cart, item *CartItem) error {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return nil, fmt.Errorf("%w: invalid cart ID: %w", ErrInvalidUUID, err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 
-	var item CartItem
-	err = r.db.GetContext(ctx, &item, `
-		SELECT id, cart_id, line_number, product_id, product_name, unit_price, quantity, total_price, status, validation_id, backorder_reason
-		FROM carts.CartItem
-		WHERE cart_id = $1 AND product_id = $2
-	`, cartUUID, productID)
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
 
+	_, err = tx.Exec(ctx, `
+		UPDATE carts.CartItem
+		SET product_name = $1, unit_price = $2, total_price = $3, status = $4, backorder_reason = $5, tax_code = $6,
+		    category = $7, brand = $8, original_unit_price = $9, original_currency = $10, fx_rate = $11, fx_source = $12,
+		    fx_as_of = $13, size = $14, color = $15, weight_lb = $16
+		WHERE id = $17
+	`, item.ProductName, item.UnitPrice, item.TotalPrice, item.Status, item.BackorderReason, item.TaxCode,
+		item.Category, item.Brand, item.OriginalUnitPrice, item.OriginalCurrency, item.FXRate, item.FXSource,
+		item.FXAsOf, item.Size, item.Color, item.WeightLb, item.ID)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, ErrCartItemNotFound
-		}
-		return nil, fmt.Errorf("%w: failed to get item by product ID: %w", ErrDatabaseOperation, err)
+		return fmt.Errorf("%w: failed to update item status: %w", ErrDatabaseOperation, err)
 	}
 
-	return &item, nil
+	if err := r.UpdateCartTx(ctx, tx, cart); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w: failed to commit transaction: %w", ErrTransactionFailed, err)
+	}
+	committed = true
+
+	return nil
 }
 
-// UpdateItemStatus updates item status and details after validation
-func (r *cartRepository) UpdateItemStatus(ctx context.Context, item *CartItem) error {
-	_, err := r.db.Exec(ctx, `
-		UPDATE carts.CartItem
-		SET product_name = $1, unit_price = $2, total_price = $3, status = $4, backorder_reason = $5
-		WHERE id = $6
-	`, item.ProductName, item.UnitPrice, item.TotalPrice, item.Status, item.BackorderReason, item.ID)
+// UpdateItemPricesTx saves the repriced unit prices, exchange rates and
+// price changes of the cart's lines within tx. The cart currency and totals are saved by
+// UpdateCartTx.
+func (r *cartRepository) UpdateItemPricesTx(ctx context.Context, tx database.Tx, cart *Cart) error {
+	for _, item := range cart.Items {
+		_, err := tx.Exec(ctx, `
+			UPDATE carts.CartItem
+			SET unit_price = $1, total_price = $2, original_unit_price = $3, original_currency = $4,
+			    fx_rate = $5, fx_source = $6, fx_as_of = $7, previous_unit_price = $8, price_changed_at = $9
+			WHERE cart_id = $10 AND line_number = $11
+		`, item.UnitPrice, item.TotalPrice, item.OriginalUnitPrice, item.OriginalCurrency,
+			item.FXRate, item.FXSource, item.FXAsOf, item.PreviousUnitPrice, item.PriceChangedAt, cart.CartID, item.LineNumber)
+		if err != nil {
+			return fmt.Errorf("%w: failed to update item price: %w", ErrDatabaseOperation, err)
+		}
+	}
+	return nil
+}
 
+// ListActiveCartsWithProduct returns the IDs of the active carts holding a
+// confirmed line of productID.
+func (r *cartRepository) ListActiveCartsWithProduct(ctx context.Context, productID string) ([]string, error) {
+	var cartIDs []string
+	err := r.db.SelectContext(ctx, &cartIDs, `
+		SELECT DISTINCT ci.cart_id::text
+		FROM carts.CartItem ci
+		JOIN carts.Cart c ON c.cart_id = ci.cart_id
+		WHERE ci.product_id = $1
+		  AND ci.status = 'confirmed'
+		  AND c.current_status = 'active'
+	`, productID)
 	if err != nil {
-		return fmt.Errorf("%w: failed to update item status: %w", ErrDatabaseOperation, err)
+		return nil, fmt.Errorf("%w: failed to list carts with product: %w", ErrDatabaseOperation, err)
 	}
-
-	return nil
+	return cartIDs, nil
 }
@@ -9,16 +9,15 @@ import (
 	"github.com/google/uuid"
 )
 
-func (r *cartRepository) SetCreditCard(ctx context.Context, cartID string, card *CreditCard) error {
+// SetCreditCard replaces the credit card of cart. The cart update is a
+// compare-and-swap on cart.Version: it fails with ErrCartVersionConflict
+// when the cart changed since it was read, and increments cart.Version when
+// it succeeds.
+func (r *cartRepository) SetCreditCard(ctx context.Context, cart *Cart, card *CreditCard) error {
 	r.logger.Debug("Setting credit card for cart",
-		"cart_id", cartID,
+		"cart_id", cart.CartID,
 		"card", card,
 	)
-	cartUUID, err := uuid.Parse(cartID)
-	if err != nil {
-		r.logger.Error("Failed to parse cart ID for setting credit card", "cart_id", cartID, "error", err.Error())
-		return fmt.Errorf("%w: invalid cart ID: %w", ErrInvalidUUID, err)
-	}
 
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -32,32 +31,44 @@ func (r *cartRepository) SetCreditCard(ctx context.Context, cartID string, card
 		}
 	}()
 
-	_, _ = tx.Exec(ctx, `DELETE FROM carts.CreditCard WHERE cart_id = $1`, cartUUID)
+	_, _ = tx.Exec(ctx, `DELETE FROM carts.CreditCard WHERE cart_id = $1`, cart.CartID)
 
-	card.CartID = cartUUID
+	card.CartID = cart.CartID
 	err = tx.QueryRow(ctx, `
 		INSERT INTO carts.CreditCard (
 			cart_id, card_type, card_number, card_holder_name, card_expires, card_cvv
 		) VALUES (
 			$1, $2, $3, $4, $5, $6)
 		RETURNING id
-	`, cartUUID, card.CardType, card.CardNumber, card.CardHolderName, card.CardExpires, card.CardCVV).Scan(&card.ID)
+	`, cart.CartID, card.CardType, card.CardNumber, card.CardHolderName, card.CardExpires, card.CardCVV).Scan(&card.ID)
 	if err != nil {
-		r.logger.Error("Failed to insert credit card", "cart_id", cartID, "error", err.Error())
+		r.logger.Error("Failed to insert credit card", "cart_id", cart.CartID, "error", err.Error())
 		return fmt.Errorf("%w: failed to insert credit card: %w", ErrDatabaseOperation, err)
 	}
 
-	_, err = tx.Exec(ctx, `UPDATE carts.Cart SET credit_card_id = $1 WHERE cart_id = $2`, card.ID, cartUUID)
+	result, err := tx.Exec(ctx, `
+		UPDATE carts.Cart SET credit_card_id = $1, version = version + 1
+		WHERE cart_id = $2 AND version = $3
+	`, card.ID, cart.CartID, cart.Version)
 	if err != nil {
-		r.logger.Error("Failed to update cart with credit card", "cart_id", cartID, "error", err.Error())
+		r.logger.Error("Failed to update cart with credit card", "cart_id", cart.CartID, "error", err.Error())
 		return fmt.Errorf("%w: failed to update cart credit card: %w", ErrDatabaseOperation, err)
 	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: failed to get rows affected: %w", ErrDatabaseOperation, err)
+	}
+	if rows == 0 {
+		return r.versionConflictTx(ctx, tx, cart.CartID)
+	}
 
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("%w: failed to commit transaction: %w", ErrTransactionFailed, err)
 	}
 	committed = true
 
+	cart.CreditCardID = &card.ID
+	cart.Version++
 	return nil
 }
 
@@ -83,12 +94,9 @@ func (r *cartRepository) GetCreditCard(ctx context.Context, cartID string) (*Cre
 	return &card, nil
 }
 
-func (r *cartRepository) RemoveCreditCard(ctx context.Context, cartID string) error {
-	cartUUID, err := uuid.Parse(cartID)
-	if err != nil {
-		return fmt.Errorf("%w: invalid cart ID: %w", ErrInvalidUUID, err)
-	}
-
+// RemoveCreditCard removes the credit card of cart, as a compare-and-swap on
+// cart.Version like SetCreditCard.
+func (r *cartRepository) RemoveCreditCard(ctx context.Context, cart *Cart) error {
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
@@ -101,12 +109,22 @@ func (r *cartRepository) RemoveCreditCard(ctx context.Context, cartID string) er
 		}
 	}()
 
-	_, err = tx.Exec(ctx, `UPDATE carts.Cart SET credit_card_id = NULL WHERE cart_id = $1`, cartUUID)
+	result, err := tx.Exec(ctx, `
+		UPDATE carts.Cart SET credit_card_id = NULL, version = version + 1
+		WHERE cart_id = $1 AND version = $2
+	`, cart.CartID, cart.Version)
 	if err != nil {
 		return fmt.Errorf("%w: failed to update cart: %w", ErrDatabaseOperation, err)
 	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: failed to get rows affected: %w", ErrDatabaseOperation, err)
+	}
+	if rows == 0 {
+		return r.versionConflictTx(ctx, tx, cart.CartID)
+	}
 
-	_, err = tx.Exec(ctx, `DELETE FROM carts.CreditCard WHERE cart_id = $1`, cartUUID)
+	_, err = tx.Exec(ctx, `DELETE FROM carts.CreditCard WHERE cart_id = $1`, cart.CartID)
 	if err != nil {
 		return fmt.Errorf("%w: failed to delete credit card: %w", ErrDatabaseOperation, err)
 	}
@@ -116,5 +134,7 @@ func (r *cartRepository) RemoveCreditCard(ctx context.Context, cartID string) er
 	}
 	committed = true
 
+	cart.CreditCardID = nil
+	cart.Version++
 	return nil
 }
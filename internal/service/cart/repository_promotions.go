@@ -0,0 +1,263 @@
+package cart
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"go-shopping-poc/internal/platform/database"
+	"go-shopping-poc/internal/platform/money"
+	"go-shopping-poc/internal/platform/promotion"
+
+	"github.com/google/uuid"
+)
+
+// AddCouponTx records code against the cart within tx. Applying the same
+// code twice returns ErrCouponAlreadyApplied.
+func (r *cartRepository) AddCouponTx(ctx context.Context, tx database.Tx, cartID string, code string) error {
+	cartUUID, err := uuid.Parse(cartID)
+	if err != nil {
+		return fmt.Errorf("%w: invalid cart ID: %w", ErrInvalidUUID, err)
+	}
+
+	result, err := tx.Exec(ctx, `
+		INSERT INTO carts.CartCoupon (cart_id, code)
+		VALUES ($1, $2)
+		ON CONFLICT (cart_id, code) DO NOTHING
+	`, cartUUID, code)
+	if err != nil {
+		return fmt.Errorf("%w: failed to add coupon: %w", ErrDatabaseOperation, err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: failed to get rows affected: %w", ErrDatabaseOperation, err)
+	}
+	if rows == 0 {
+		return ErrCouponAlreadyApplied
+	}
+	return nil
+}
+
+// RemoveCouponTx removes code from the cart within tx.
+func (r *cartRepository) RemoveCouponTx(ctx context.Context, tx database.Tx, cartID string, code string) error {
+	cartUUID, err := uuid.Parse(cartID)
+	if err != nil {
+		return fmt.Errorf("%w: invalid cart ID: %w", ErrInvalidUUID, err)
+	}
+
+	result, err := tx.Exec(ctx, `DELETE FROM carts.CartCoupon WHERE cart_id = $1 AND code = $2`, cartUUID, code)
+	if err != nil {
+		return fmt.Errorf("%w: failed to remove coupon: %w", ErrDatabaseOperation, err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: failed to get rows affected: %w", ErrDatabaseOperation, err)
+	}
+	if rows == 0 {
+		return ErrCouponNotApplied
+	}
+	return nil
+}
+
+func (r *cartRepository) GetCoupons(ctx context.Context, cartID string) ([]string, error) {
+	cartUUID, err := uuid.Parse(cartID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid cart ID: %w", ErrInvalidUUID, err)
+	}
+
+	var codes []string
+	err = r.db.SelectContext(ctx, &codes, `
+		SELECT code FROM carts.CartCoupon
+		WHERE cart_id = $1
+		ORDER BY applied_at, id
+	`, cartUUID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get coupons: %w", ErrDatabaseOperation, err)
+	}
+	return codes, nil
+}
+
+// redeemPromotionsTx records a redemption for every discount on the cart.
+// Each promotion row is locked while its limits are re-checked, so
+// concurrent checkouts cannot exceed a usage limit.
+func (r *cartRepository) redeemPromotionsTx(ctx context.Context, tx database.Tx, cart *Cart) error {
+	customerKey := cart.CustomerKey()
+	now := time.Now()
+
+	for _, disc := range cart.Discounts {
+		var row promotionRow
+		err := tx.GetContext(ctx, &row, `
+			SELECT id, code, name, kind, value, value AS amount, buy_quantity, get_quantity, categories, brands, currency,
+			       min_subtotal, max_uses, max_uses_per_customer, starts_at, ends_at, active
+			FROM carts.Promotion
+			WHERE id = $1
+			FOR UPDATE
+		`, disc.PromotionID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("%w: promotion %d", promotion.ErrNotActive, disc.PromotionID)
+			}
+			return fmt.Errorf("%w: failed to lock promotion: %w", ErrDatabaseOperation, err)
+		}
+
+		p, err := row.toPromotion()
+		if err != nil {
+			return err
+		}
+		if !p.Active || !p.InWindow(now) {
+			return fmt.Errorf("%w: %s", promotion.ErrNotActive, p.Name)
+		}
+
+		err = tx.QueryRow(ctx, `
+			SELECT count(*),
+			       count(*) FILTER (WHERE customer_key = $2 AND customer_key <> '')
+			FROM carts.PromotionRedemption
+			WHERE promotion_id = $1
+		`, p.ID, customerKey).Scan(&p.Redemptions, &p.CustomerRedemptions)
+		if err != nil {
+			return fmt.Errorf("%w: failed to count redemptions: %w", ErrDatabaseOperation, err)
+		}
+		if p.MaxUses > 0 && p.Redemptions >= p.MaxUses {
+			return fmt.Errorf("%w: %s", promotion.ErrUsageLimitReached, p.Name)
+		}
+		if p.MaxUsesPerCustomer > 0 {
+			if customerKey == "" {
+				return fmt.Errorf("%w: %s", promotion.ErrCustomerRequired, p.Name)
+			}
+			if p.CustomerRedemptions >= p.MaxUsesPerCustomer {
+				return fmt.Errorf("%w: %s", promotion.ErrCustomerUsageLimitReached, p.Name)
+			}
+		}
+
+		var code *string
+		if disc.Code != "" {
+			code = &disc.Code
+		}
+		_, err = tx.Exec(ctx, `
+			INSERT INTO carts.PromotionRedemption (promotion_id, cart_id, customer_key, code, amount)
+			VALUES ($1, $2, $3, $4, $5)
+		`, p.ID, cart.CartID, customerKey, code, disc.Amount)
+		if err != nil {
+			return fmt.Errorf("%w: failed to record redemption: %w", ErrDatabaseOperation, err)
+		}
+	}
+	return nil
+}
+
+// promotionRepository implements PromotionSource against carts.Promotion.
+type promotionRepository struct {
+	db     database.Database
+	logger *slog.Logger
+}
+
+// NewPromotionRepository returns a PromotionSource backed by the cart database.
+func NewPromotionRepository(db database.Database) PromotionSource {
+	return &promotionRepository{
+		db:     db,
+		logger: slog.Default().With("component", "promotion_repository"),
+	}
+}
+
+var _ PromotionSource = (*promotionRepository)(nil)
+
+type promotionRow struct {
+	ID                  int64       `db:"id"`
+	Code                *string     `db:"code"`
+	Name                string      `db:"name"`
+	Kind                string      `db:"kind"`
+	Value               float64     `db:"value"`
+	Amount              money.Money `db:"amount"`
+	BuyQuantity         int         `db:"buy_quantity"`
+	GetQuantity         int         `db:"get_quantity"`
+	Categories          []byte      `db:"categories"`
+	Brands              []byte      `db:"brands"`
+	Currency            string      `db:"currency"`
+	MinSubtotal         money.Money `db:"min_subtotal"`
+	MaxUses             int         `db:"max_uses"`
+	MaxUsesPerCustomer  int         `db:"max_uses_per_customer"`
+	StartsAt            *time.Time  `db:"starts_at"`
+	EndsAt              *time.Time  `db:"ends_at"`
+	Active              bool        `db:"active"`
+	Redemptions         int         `db:"redemptions"`
+	CustomerRedemptions int         `db:"customer_redemptions"`
+}
+
+func (row promotionRow) toPromotion() (promotion.Promotion, error) {
+	p := promotion.Promotion{
+		ID:                  row.ID,
+		Name:                row.Name,
+		Kind:                promotion.Kind(row.Kind),
+		Value:               row.Value,
+		BuyQuantity:         row.BuyQuantity,
+		GetQuantity:         row.GetQuantity,
+		Currency:            row.Currency,
+		MinSubtotal:         row.MinSubtotal.WithCurrency(row.Currency),
+		MaxUses:             row.MaxUses,
+		MaxUsesPerCustomer:  row.MaxUsesPerCustomer,
+		StartsAt:            row.StartsAt,
+		EndsAt:              row.EndsAt,
+		Active:              row.Active,
+		Redemptions:         row.Redemptions,
+		CustomerRedemptions: row.CustomerRedemptions,
+	}
+	if p.Kind == promotion.KindAmountOff {
+		p.Amount = row.Amount.WithCurrency(row.Currency)
+	}
+	if row.Code != nil {
+		p.Code = *row.Code
+	}
+	if len(row.Categories) > 0 {
+		if err := json.Unmarshal(row.Categories, &p.Categories); err != nil {
+			return p, fmt.Errorf("%w: promotion %d categories: %w", promotion.ErrInvalidPromotion, row.ID, err)
+		}
+	}
+	if len(row.Brands) > 0 {
+		if err := json.Unmarshal(row.Brands, &p.Brands); err != nil {
+			return p, fmt.Errorf("%w: promotion %d brands: %w", promotion.ErrInvalidPromotion, row.ID, err)
+		}
+	}
+	return p, nil
+}
+
+// Promotions returns the active automatic promotions and the promotions
+// matching codes, in that order. Coupon codes match case-insensitively.
+func (r *promotionRepository) Promotions(ctx context.Context, customerKey string, codes []string) ([]promotion.Promotion, error) {
+	upper := make([]string, len(codes))
+	for i, code := range codes {
+		upper[i] = strings.ToUpper(code)
+	}
+
+	var rows []promotionRow
+	err := r.db.SelectContext(ctx, &rows, `
+		SELECT p.id, p.code, p.name, p.kind, p.value, p.value AS amount, p.buy_quantity, p.get_quantity, p.categories, p.brands, p.currency,
+		       p.min_subtotal, p.max_uses, p.max_uses_per_customer, p.starts_at, p.ends_at, p.active,
+		       (SELECT count(*) FROM carts.PromotionRedemption pr WHERE pr.promotion_id = p.id) AS redemptions,
+		       (SELECT count(*) FROM carts.PromotionRedemption pr
+		         WHERE pr.promotion_id = p.id AND pr.customer_key = $1 AND pr.customer_key <> '') AS customer_redemptions
+		FROM carts.Promotion p
+		WHERE (p.code IS NULL AND p.active)
+		   OR upper(p.code) = ANY($2)
+		ORDER BY p.code IS NOT NULL, p.id
+	`, customerKey, upper)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get promotions: %w", ErrDatabaseOperation, err)
+	}
+
+	promos := make([]promotion.Promotion, 0, len(rows))
+	for _, row := range rows {
+		p, err := row.toPromotion()
+		if err != nil {
+			r.logger.Warn("Skipping invalid promotion", "promotion_id", row.ID, "error", err.Error())
+			continue
+		}
+		promos = append(promos, p)
+	}
+	return promos, nil
+}
@@ -0,0 +1,66 @@
+package cart
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	events "go-shopping-poc/internal/contracts/events"
+)
+
+// ReorderRequest is a processed reorder request: the cart the order's items
+// went to and what became of each.
+type ReorderRequest struct {
+	RequestID string
+	CartID    uuid.UUID
+	Lines     []events.ReorderLineResult
+}
+
+// GetReorderRequest returns the recorded outcome of requestID, or
+// ErrReorderRequestNotFound if it has not been processed.
+func (r *cartRepository) GetReorderRequest(ctx context.Context, requestID string) (*ReorderRequest, error) {
+	var row struct {
+		CartID uuid.UUID `db:"cart_id"`
+		Lines  []byte    `db:"lines"`
+	}
+	err := r.db.GetContext(ctx, &row, `
+		SELECT cart_id, lines
+		FROM carts.ReorderRequest
+		WHERE request_id = $1
+	`, requestID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrReorderRequestNotFound
+		}
+		return nil, fmt.Errorf("%w: failed to get reorder request: %w", ErrDatabaseOperation, err)
+	}
+
+	req := &ReorderRequest{RequestID: requestID, CartID: row.CartID}
+	if err := json.Unmarshal(row.Lines, &req.Lines); err != nil {
+		return nil, fmt.Errorf("%w: reorder request %s lines: %w", ErrDatabaseOperation, requestID, err)
+	}
+	return req, nil
+}
+
+// SaveReorderRequest records the outcome of a processed reorder request. A
+// request already recorded keeps its first outcome.
+func (r *cartRepository) SaveReorderRequest(ctx context.Context, req *ReorderRequest) error {
+	lines, err := json.Marshal(req.Lines)
+	if err != nil {
+		return fmt.Errorf("failed to marshal reorder lines: %w", err)
+	}
+
+	_, err = r.db.Exec(ctx, `
+		INSERT INTO carts.ReorderRequest (request_id, cart_id, lines)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (request_id) DO NOTHING
+	`, req.RequestID, req.CartID, lines)
+	if err != nil {
+		return fmt.Errorf("%w: failed to save reorder request: %w", ErrDatabaseOperation, err)
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package cart
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"go-shopping-poc/internal/platform/database"
+
+	events "go-shopping-poc/internal/contracts/events"
+)
+
+// startCheckoutSagaTx starts the checkout saga of a cart being checked out
+// and returns the number of the checkout attempt. The saga of an earlier,
+// compensated checkout of the cart is replaced by the next attempt.
+func (r *cartRepository) startCheckoutSagaTx(ctx context.Context, tx database.Tx, cartID uuid.UUID, deadline time.Time) (int, error) {
+	var attempt int
+	err := tx.GetContext(ctx, &attempt, `
+		INSERT INTO carts.CheckoutSaga (cart_id, attempt, status, inventory_status, deadline)
+		VALUES ($1, 1, 'pending', 'pending', $2)
+		ON CONFLICT (cart_id) DO UPDATE
+		SET attempt = carts.CheckoutSaga.attempt + 1,
+		    status = 'pending',
+		    order_id = NULL,
+		    inventory_status = 'pending',
+		    reason = NULL,
+		    deadline = EXCLUDED.deadline,
+		    created_at = CURRENT_TIMESTAMP,
+		    updated_at = CURRENT_TIMESTAMP
+		RETURNING attempt
+	`, cartID, deadline)
+	if err != nil {
+		return 0, fmt.Errorf("%w: failed to start checkout saga: %w", ErrDatabaseOperation, err)
+	}
+	return attempt, nil
+}
+
+// AdvanceCheckoutSaga applies step to the saga of a cart and carries out
+// the action it returns in the same transaction. The saga row is locked
+// while the step runs, so events of one checkout are applied one at a time.
+func (r *cartRepository) AdvanceCheckoutSaga(ctx context.Context, cartID string, step SagaStep) (*SagaResult, error) {
+	cartUUID, err := uuid.Parse(cartID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid cart ID: %w", ErrInvalidUUID, err)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var saga CheckoutSaga
+	err = tx.GetContext(ctx, &saga, `
+		SELECT cart_id, attempt, status, order_id, inventory_status, reason, deadline, created_at, updated_at
+		FROM carts.CheckoutSaga
+		WHERE cart_id = $1
+		FOR UPDATE
+	`, cartUUID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCheckoutSagaNotFound
+		}
+		return nil, fmt.Errorf("%w: failed to get checkout saga: %w", ErrDatabaseOperation, err)
+	}
+
+	action := step(&saga)
+	saga.UpdatedAt = time.Now()
+	_, err = tx.Exec(ctx, `
+		UPDATE carts.CheckoutSaga
+		SET status = $1, order_id = $2, inventory_status = $3, reason = $4, updated_at = $5
+		WHERE cart_id = $6
+	`, saga.Status, saga.OrderID, saga.InventoryStatus, saga.Reason, saga.UpdatedAt, cartUUID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to update checkout saga: %w", ErrDatabaseOperation, err)
+	}
+
+	cart, err := r.getCartByIDTx(ctx, tx, cartUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch action {
+	case SagaActionComplete:
+		if cart.CurrentStatus == "checked_out" {
+			if err := r.setCartStatusTx(ctx, tx, cart, "completed"); err != nil {
+				return nil, err
+			}
+		}
+	case SagaActionCompensate:
+		if cart.CurrentStatus == "checked_out" {
+			if err := r.reopenCartTx(ctx, tx, cart); err != nil {
+				return nil, err
+			}
+		}
+		if err := r.writeCheckoutFailedTx(ctx, tx, cart, &saga); err != nil {
+			return nil, err
+		}
+	case SagaActionCancelLate:
+		if err := r.writeCheckoutFailedTx(ctx, tx, cart, &saga); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%w: failed to commit transaction: %w", ErrTransactionFailed, err)
+	}
+	committed = true
+
+	return &SagaResult{Saga: saga, Action: action, CartStatus: cart.CurrentStatus}, nil
+}
+
+// ListExpiredCheckoutSagas returns up to limit pending sagas whose deadline
+// is before now, oldest deadline first.
+func (r *cartRepository) ListExpiredCheckoutSagas(ctx context.Context, now time.Time, limit int) ([]CheckoutSaga, error) {
+	var sagas []CheckoutSaga
+	err := r.db.SelectContext(ctx, &sagas, `
+		SELECT cart_id, attempt, status, order_id, inventory_status, reason, deadline, created_at, updated_at
+		FROM carts.CheckoutSaga
+		WHERE status = 'pending' AND deadline < $1
+		ORDER BY deadline
+		LIMIT $2
+	`, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to list expired checkout sagas: %w", ErrDatabaseOperation, err)
+	}
+	return sagas, nil
+}
+
+// reopenCartTx returns a cart whose checkout failed to its customer and
+// gives back the promotion redemptions recorded at checkout. The cart is
+// cancelled instead when its customer has another active cart.
+func (r *cartRepository) reopenCartTx(ctx context.Context, tx database.Tx, cart *Cart) error {
+	hasActiveCart := false
+	if cart.CustomerID != nil {
+		err := tx.QueryRow(ctx, `
+			SELECT EXISTS (
+				SELECT 1 FROM carts.Cart
+				WHERE customer_id = $1 AND current_status = 'active' AND cart_id <> $2
+			)
+		`, *cart.CustomerID, cart.CartID).Scan(&hasActiveCart)
+		if err != nil {
+			return fmt.Errorf("%w: failed to check for active cart: %w", ErrDatabaseOperation, err)
+		}
+	}
+
+	if err := r.setCartStatusTx(ctx, tx, cart, ReopenedCartStatus(hasActiveCart)); err != nil {
+		return err
+	}
+
+	_, err := tx.Exec(ctx, `DELETE FROM carts.PromotionRedemption WHERE cart_id = $1`, cart.CartID)
+	if err != nil {
+		return fmt.Errorf("%w: failed to release promotion redemptions: %w", ErrDatabaseOperation, err)
+	}
+	return nil
+}
+
+// setCartStatusTx moves a cart to status and records the change.
+func (r *cartRepository) setCartStatusTx(ctx context.Context, tx database.Tx, cart *Cart, status string) error {
+	if err := cart.SetStatus(status); err != nil {
+		return err
+	}
+	_, err := tx.Exec(ctx, `UPDATE carts.Cart SET current_status = $1, version = version + 1 WHERE cart_id = $2`, cart.CurrentStatus, cart.CartID)
+	if err != nil {
+		return fmt.Errorf("%w: failed to update cart status: %w", ErrDatabaseOperation, err)
+	}
+	cart.Version++
+	return r.addStatusEntryTx(ctx, tx, cart.CartID.String(), status)
+}
+
+// writeCheckoutFailedTx writes the cart.checkout_failed event of a
+// compensated saga.
+func (r *cartRepository) writeCheckoutFailedTx(ctx context.Context, tx database.Tx, cart *Cart, saga *CheckoutSaga) error {
+	var customerIDStr *string
+	if cart.CustomerID != nil {
+		id := cart.CustomerID.String()
+		customerIDStr = &id
+	}
+
+	evt := events.NewCartCheckoutFailedEvent(cart.CartID.String(), customerIDStr, saga.Attempt, saga.orderID(), saga.failureReason())
+	if err := r.outboxWriter.WriteEvent(ctx, tx, evt); err != nil {
+		return fmt.Errorf("failed to write checkout failed event: %w", err)
+	}
+	return nil
+}
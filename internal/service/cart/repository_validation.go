@@ -0,0 +1,83 @@
+package cart
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	events "go-shopping-poc/internal/contracts/events"
+)
+
+// ListStalePendingItems returns up to limit pending_validation lines of
+// active carts whose validation was last requested before requestedBefore,
+// oldest first.
+func (r *cartRepository) ListStalePendingItems(ctx context.Context, requestedBefore time.Time, limit int) ([]CartItem, error) {
+	var items []CartItem
+	err := r.db.SelectContext(ctx, &items, `
+		SELECT ci.id, ci.cart_id, ci.line_number, ci.product_id, ci.quantity, ci.status, ci.validation_id,
+		       ci.validation_requested_at, ci.validation_attempts
+		FROM carts.CartItem ci
+		JOIN carts.Cart c ON c.cart_id = ci.cart_id
+		WHERE ci.status = 'pending_validation'
+		  AND ci.validation_requested_at < $1
+		  AND c.current_status = 'active'
+		ORDER BY ci.validation_requested_at
+		LIMIT $2
+	`, requestedBefore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to list pending items: %w", ErrDatabaseOperation, err)
+	}
+	return items, nil
+}
+
+// RetryItemValidation requests validation of a pending line again by
+// writing another cart.item.added event, and counts the attempt. It
+// returns ErrCartItemNotFound if the line is gone or no longer pending.
+func (r *cartRepository) RetryItemValidation(ctx context.Context, item *CartItem) error {
+	if item.ValidationID == nil {
+		return fmt.Errorf("item %s has no validation ID", item.LineNumber)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	now := time.Now()
+	var attempts int
+	err = tx.QueryRow(ctx, `
+		UPDATE carts.CartItem
+		SET validation_requested_at = $1, validation_attempts = validation_attempts + 1
+		WHERE id = $2 AND status = 'pending_validation'
+		RETURNING validation_attempts
+	`, now, item.ID).Scan(&attempts)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrCartItemNotFound
+		}
+		return fmt.Errorf("%w: failed to update item: %w", ErrDatabaseOperation, err)
+	}
+
+	evt := events.NewCartItemAddedEvent(item.CartID.String(), item.LineNumber, item.ProductID, item.Quantity, *item.ValidationID)
+	if err := r.outboxWriter.WriteEvent(ctx, tx, evt); err != nil {
+		return fmt.Errorf("failed to write cart item event: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w: failed to commit transaction: %w", ErrTransactionFailed, err)
+	}
+	committed = true
+
+	item.ValidationRequestedAt = &now
+	item.ValidationAttempts = attempts
+	return nil
+}
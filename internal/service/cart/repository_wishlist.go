@@ -0,0 +1,246 @@
+package cart
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"go-shopping-poc/internal/platform/database"
+)
+
+const wishlistColumns = `wishlist_id, customer_id, name, kind, created_at, updated_at`
+
+const wishlistItemColumns = `id, wishlist_id, product_id, product_name, image_url, quantity, price, currency, in_stock, added_at, updated_at`
+
+// CreateWishlist saves a new wishlist. It returns ErrWishlistNameTaken if
+// the customer has a list of that name.
+func (r *cartRepository) CreateWishlist(ctx context.Context, wishlist *Wishlist) error {
+	result, err := r.db.Exec(ctx, `
+		INSERT INTO carts.Wishlist (wishlist_id, customer_id, name, kind, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (customer_id, name) DO NOTHING
+	`, wishlist.WishlistID, wishlist.CustomerID, wishlist.Name, wishlist.Kind, wishlist.CreatedAt, wishlist.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("%w: failed to create wishlist: %w", ErrDatabaseOperation, err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return ErrWishlistNameTaken
+	}
+	return nil
+}
+
+// GetWishlist returns a wishlist with its items.
+func (r *cartRepository) GetWishlist(ctx context.Context, wishlistID string) (*Wishlist, error) {
+	id, err := uuid.Parse(wishlistID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid wishlist ID: %w", ErrInvalidUUID, err)
+	}
+
+	var wishlist Wishlist
+	err = r.db.GetContext(ctx, &wishlist, `SELECT `+wishlistColumns+` FROM carts.Wishlist WHERE wishlist_id = $1`, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrWishlistNotFound
+		}
+		return nil, fmt.Errorf("%w: failed to get wishlist: %w", ErrDatabaseOperation, err)
+	}
+
+	wishlist.Items = []WishlistItem{}
+	err = r.db.SelectContext(ctx, &wishlist.Items, `
+		SELECT `+wishlistItemColumns+`
+		FROM carts.WishlistItem
+		WHERE wishlist_id = $1
+		ORDER BY added_at, id
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get wishlist items: %w", ErrDatabaseOperation, err)
+	}
+	return &wishlist, nil
+}
+
+// ListWishlists returns the lists of a customer with their items, the
+// saved-for-later list first and the others by name.
+func (r *cartRepository) ListWishlists(ctx context.Context, customerID string) ([]Wishlist, error) {
+	id, err := uuid.Parse(customerID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid customer ID: %w", ErrInvalidUUID, err)
+	}
+
+	wishlists := []Wishlist{}
+	err = r.db.SelectContext(ctx, &wishlists, `
+		SELECT `+wishlistColumns+`
+		FROM carts.Wishlist
+		WHERE customer_id = $1
+		ORDER BY kind = 'saved_for_later' DESC, name
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to list wishlists: %w", ErrDatabaseOperation, err)
+	}
+
+	var items []WishlistItem
+	err = r.db.SelectContext(ctx, &items, `
+		SELECT wi.id, wi.wishlist_id, wi.product_id, wi.product_name, wi.image_url, wi.quantity, wi.price,
+		       wi.currency, wi.in_stock, wi.added_at, wi.updated_at
+		FROM carts.WishlistItem wi
+		JOIN carts.Wishlist w ON w.wishlist_id = wi.wishlist_id
+		WHERE w.customer_id = $1
+		ORDER BY wi.added_at, wi.id
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to list wishlist items: %w", ErrDatabaseOperation, err)
+	}
+
+	byID := make(map[uuid.UUID]*Wishlist, len(wishlists))
+	for i := range wishlists {
+		wishlists[i].Items = []WishlistItem{}
+		byID[wishlists[i].WishlistID] = &wishlists[i]
+	}
+	for _, item := range items {
+		if wishlist, ok := byID[item.WishlistID]; ok {
+			wishlist.Items = append(wishlist.Items, item)
+		}
+	}
+	return wishlists, nil
+}
+
+// DeleteWishlist deletes a wishlist and its items.
+func (r *cartRepository) DeleteWishlist(ctx context.Context, wishlistID string) error {
+	id, err := uuid.Parse(wishlistID)
+	if err != nil {
+		return fmt.Errorf("%w: invalid wishlist ID: %w", ErrInvalidUUID, err)
+	}
+
+	result, err := r.db.Exec(ctx, `DELETE FROM carts.Wishlist WHERE wishlist_id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("%w: failed to delete wishlist: %w", ErrDatabaseOperation, err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return ErrWishlistNotFound
+	}
+	return nil
+}
+
+// SavedForLaterTx returns the saved-for-later list of a customer within
+// tx, creating it if needed.
+func (r *cartRepository) SavedForLaterTx(ctx context.Context, tx database.Tx, customerID uuid.UUID) (*Wishlist, error) {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO carts.Wishlist (wishlist_id, customer_id, name, kind)
+		VALUES ($1, $2, $3, 'saved_for_later')
+		ON CONFLICT DO NOTHING
+	`, uuid.New(), customerID, SavedForLaterName)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to create saved-for-later list: %w", ErrDatabaseOperation, err)
+	}
+
+	var wishlist Wishlist
+	err = tx.GetContext(ctx, &wishlist, `
+		SELECT `+wishlistColumns+`
+		FROM carts.Wishlist
+		WHERE customer_id = $1 AND kind = 'saved_for_later'
+	`, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get saved-for-later list: %w", ErrDatabaseOperation, err)
+	}
+	return &wishlist, nil
+}
+
+// AddWishlistItemTx saves item to its wishlist within tx. A product already
+// on the list has item's quantity added and its price, stock and name
+// refreshed; item is left holding the saved row.
+func (r *cartRepository) AddWishlistItemTx(ctx context.Context, tx database.Tx, item *WishlistItem) error {
+	err := tx.GetContext(ctx, item, `
+		INSERT INTO carts.WishlistItem (wishlist_id, product_id, product_name, image_url, quantity, price, currency, in_stock)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (wishlist_id, product_id) DO UPDATE
+		SET quantity = carts.WishlistItem.quantity + EXCLUDED.quantity,
+		    product_name = EXCLUDED.product_name,
+		    image_url = CASE WHEN EXCLUDED.image_url = '' THEN carts.WishlistItem.image_url ELSE EXCLUDED.image_url END,
+		    price = EXCLUDED.price,
+		    currency = EXCLUDED.currency,
+		    in_stock = EXCLUDED.in_stock,
+		    updated_at = CURRENT_TIMESTAMP
+		RETURNING `+wishlistItemColumns,
+		item.WishlistID, item.ProductID, item.ProductName, item.ImageURL, item.Quantity, item.Price, item.Currency, item.InStock)
+	if err != nil {
+		return fmt.Errorf("%w: failed to save wishlist item: %w", ErrDatabaseOperation, err)
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE carts.Wishlist SET updated_at = CURRENT_TIMESTAMP WHERE wishlist_id = $1`, item.WishlistID)
+	if err != nil {
+		return fmt.Errorf("%w: failed to touch wishlist: %w", ErrDatabaseOperation, err)
+	}
+	return nil
+}
+
+// AddWishlistItem saves item to its wishlist, see AddWishlistItemTx.
+func (r *cartRepository) AddWishlistItem(ctx context.Context, item *WishlistItem) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err := r.AddWishlistItemTx(ctx, tx, item); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w: failed to commit transaction: %w", ErrTransactionFailed, err)
+	}
+	committed = true
+	return nil
+}
+
+// RemoveWishlistItem removes a product from a wishlist.
+func (r *cartRepository) RemoveWishlistItem(ctx context.Context, wishlistID, productID string) error {
+	id, err := uuid.Parse(wishlistID)
+	if err != nil {
+		return fmt.Errorf("%w: invalid wishlist ID: %w", ErrInvalidUUID, err)
+	}
+
+	result, err := r.db.Exec(ctx, `DELETE FROM carts.WishlistItem WHERE wishlist_id = $1 AND product_id = $2`, id, productID)
+	if err != nil {
+		return fmt.Errorf("%w: failed to remove wishlist item: %w", ErrDatabaseOperation, err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return ErrWishlistItemNotFound
+	}
+	return nil
+}
+
+// ListWishlistItemsByProduct returns every saved item of productID.
+func (r *cartRepository) ListWishlistItemsByProduct(ctx context.Context, productID string) ([]WishlistItem, error) {
+	var items []WishlistItem
+	err := r.db.SelectContext(ctx, &items, `
+		SELECT `+wishlistItemColumns+`
+		FROM carts.WishlistItem
+		WHERE product_id = $1
+	`, productID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to list wishlist items: %w", ErrDatabaseOperation, err)
+	}
+	return items, nil
+}
+
+// UpdateWishlistItemProduct saves the product name, price and stock of a
+// saved item.
+func (r *cartRepository) UpdateWishlistItemProduct(ctx context.Context, item *WishlistItem) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE carts.WishlistItem
+		SET product_name = $1, price = $2, currency = $3, in_stock = $4, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $5
+	`, item.ProductName, item.Price, item.Currency, item.InStock, item.ID)
+	if err != nil {
+		return fmt.Errorf("%w: failed to update wishlist item: %w", ErrDatabaseOperation, err)
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+package cart
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// A checkout is followed by a checkout saga. Checking out a cart starts
+// the saga, and the order service (order.created or order.rejected) and
+// the product service (inventory.reserved or inventory.reservation_failed)
+// report how their part went. The saga completes the cart once the order
+// exists and its stock is reserved. A rejected order, a failed reservation
+// or no outcome before the deadline compensates the checkout instead: the
+// cart is reopened, or cancelled when its customer has started another
+// cart since, its promotion redemptions are given back and
+// cart.checkout_failed asks the other services to cancel the order and
+// release the stock.
+//
+// A reopened cart can be checked out again. Each checkout of a cart is
+// numbered one higher than the last, and the number travels with every
+// event of the checkout, so that an order or reservation reported late
+// for an earlier checkout does not advance the saga of the current one.
+
+// Checkout saga statuses
+const (
+	SagaPending     = "pending"
+	SagaCompleted   = "completed"
+	SagaCompensated = "compensated"
+)
+
+// Stock reservation statuses of a checkout saga
+const (
+	SagaInventoryPending  = "pending"
+	SagaInventoryReserved = "reserved"
+	SagaInventoryFailed   = "failed"
+)
+
+// Reasons a checkout saga is compensated for when the event reporting the
+// failure gives none.
+const (
+	SagaReasonTimeout           = "timeout"
+	SagaReasonOrderRejected     = "order_rejected"
+	SagaReasonReservationFailed = "reservation_failed"
+)
+
+// CheckoutSaga is the state of the checkout of a cart.
+type CheckoutSaga struct {
+	CartID          uuid.UUID `json:"cart_id" db:"cart_id"`
+	Attempt         int       `json:"attempt" db:"attempt"`
+	Status          string    `json:"status" db:"status"`
+	OrderID         *string   `json:"order_id,omitempty" db:"order_id"`
+	InventoryStatus string    `json:"inventory_status" db:"inventory_status"`
+	Reason          *string   `json:"reason,omitempty" db:"reason"`
+	Deadline        time.Time `json:"deadline" db:"deadline"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// SagaAction is what a step of a checkout saga requires of its cart.
+type SagaAction string
+
+const (
+	// SagaActionNone leaves the cart as it is.
+	SagaActionNone SagaAction = ""
+	// SagaActionComplete completes the checked out cart.
+	SagaActionComplete SagaAction = "complete"
+	// SagaActionCompensate reopens or cancels the checked out cart, gives
+	// back its promotion redemptions and emits cart.checkout_failed.
+	SagaActionCompensate SagaAction = "compensate"
+	// SagaActionCancelLate emits cart.checkout_failed again for an order
+	// or reservation reported after the saga was compensated.
+	SagaActionCancelLate SagaAction = "cancel_late"
+)
+
+// SagaStep applies an event to a checkout saga.
+type SagaStep func(saga *CheckoutSaga) SagaAction
+
+// SagaResult is a checkout saga after a step, with the action the step
+// took and the status the step left the cart in.
+type SagaResult struct {
+	Saga       CheckoutSaga
+	Action     SagaAction
+	CartStatus string
+}
+
+// SagaStore persists checkout sagas.
+type SagaStore interface {
+	// AdvanceCheckoutSaga applies step to the saga of a cart and carries
+	// out the action it returns, atomically. It returns
+	// ErrCheckoutSagaNotFound if the cart has no saga.
+	AdvanceCheckoutSaga(ctx context.Context, cartID string, step SagaStep) (*SagaResult, error)
+	// ListExpiredCheckoutSagas returns up to limit pending sagas whose
+	// deadline is before now, oldest deadline first.
+	ListExpiredCheckoutSagas(ctx context.Context, now time.Time, limit int) ([]CheckoutSaga, error)
+}
+
+// ReopenedCartStatus returns the status a checked out cart is given when
+// its checkout is compensated: active again, unless its customer has
+// another active cart, which is kept instead.
+func ReopenedCartStatus(customerHasActiveCart bool) string {
+	if customerHasActiveCart {
+		return "cancelled"
+	}
+	return "active"
+}
+
+// OrderCreated records the order created for checkout attempt.
+func (s *CheckoutSaga) OrderCreated(attempt int, orderID string) SagaAction {
+	if !s.sameAttempt(attempt) {
+		return SagaActionNone
+	}
+	switch s.Status {
+	case SagaPending:
+		s.OrderID = &orderID
+		if s.InventoryStatus == SagaInventoryReserved {
+			return s.complete()
+		}
+	case SagaCompensated:
+		// The order came too late: have it cancelled too
+		if s.OrderID == nil || *s.OrderID != orderID {
+			s.OrderID = &orderID
+			return SagaActionCancelLate
+		}
+	}
+	return SagaActionNone
+}
+
+// OrderRejected records that no order could be created for checkout
+// attempt.
+func (s *CheckoutSaga) OrderRejected(attempt int, reason string) SagaAction {
+	if s.Status != SagaPending || !s.sameAttempt(attempt) {
+		return SagaActionNone
+	}
+	if reason == "" {
+		reason = SagaReasonOrderRejected
+	}
+	return s.compensate(reason)
+}
+
+// InventoryReserved records that the stock of checkout attempt is
+// reserved.
+func (s *CheckoutSaga) InventoryReserved(attempt int) SagaAction {
+	if !s.sameAttempt(attempt) {
+		return SagaActionNone
+	}
+	switch s.Status {
+	case SagaPending:
+		s.InventoryStatus = SagaInventoryReserved
+		if s.OrderID != nil {
+			return s.complete()
+		}
+	case SagaCompensated:
+		// The stock was reserved too late: have it released too
+		if s.InventoryStatus != SagaInventoryReserved {
+			s.InventoryStatus = SagaInventoryReserved
+			return SagaActionCancelLate
+		}
+	}
+	return SagaActionNone
+}
+
+// InventoryFailed records that the stock of checkout attempt could not be
+// reserved.
+func (s *CheckoutSaga) InventoryFailed(attempt int, reason string) SagaAction {
+	if !s.sameAttempt(attempt) {
+		return SagaActionNone
+	}
+	switch s.Status {
+	case SagaPending:
+		s.InventoryStatus = SagaInventoryFailed
+		if reason == "" {
+			reason = SagaReasonReservationFailed
+		}
+		return s.compensate(reason)
+	case SagaCompensated:
+		s.InventoryStatus = SagaInventoryFailed
+	}
+	return SagaActionNone
+}
+
+// TimedOut compensates a pending checkout whose deadline passed before
+// now.
+func (s *CheckoutSaga) TimedOut(now time.Time) SagaAction {
+	if s.Status != SagaPending || !now.After(s.Deadline) {
+		return SagaActionNone
+	}
+	return s.compensate(SagaReasonTimeout)
+}
+
+// sameAttempt reports whether an event of checkout attempt belongs to the
+// saga's checkout. An earlier checkout of the cart was compensated before
+// this one started, which also cancelled its order and stock. Events
+// published before checkouts were numbered carry attempt 0 and are taken
+// to belong.
+func (s *CheckoutSaga) sameAttempt(attempt int) bool {
+	return attempt == 0 || s.Attempt == 0 || attempt == s.Attempt
+}
+
+// failureReason returns the reason the saga was compensated for.
+func (s *CheckoutSaga) failureReason() string {
+	if s.Reason == nil {
+		return ""
+	}
+	return *s.Reason
+}
+
+// orderID returns the ID of the order created for the checkout, if any.
+func (s *CheckoutSaga) orderID() string {
+	if s.OrderID == nil {
+		return ""
+	}
+	return *s.OrderID
+}
+
+func (s *CheckoutSaga) complete() SagaAction {
+	s.Status = SagaCompleted
+	return SagaActionComplete
+}
+
+func (s *CheckoutSaga) compensate(reason string) SagaAction {
+	s.Status = SagaCompensated
+	s.Reason = &reason
+	return SagaActionCompensate
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
+	"time"
 
 	"net/http"
 
@@ -12,11 +14,17 @@ import (
 
 	events "go-shopping-poc/internal/contracts/events"
 	"go-shopping-poc/internal/platform/database"
+	platformerrors "go-shopping-poc/internal/platform/errors"
 	"go-shopping-poc/internal/platform/event/bus"
+	"go-shopping-poc/internal/platform/fx"
 	"go-shopping-poc/internal/platform/logging"
+	"go-shopping-poc/internal/platform/money"
 	"go-shopping-poc/internal/platform/outbox"
+	"go-shopping-poc/internal/platform/promotion"
 	"go-shopping-poc/internal/platform/service"
+	"go-shopping-poc/internal/platform/shipping"
 	"go-shopping-poc/internal/platform/sse"
+	"go-shopping-poc/internal/platform/tax"
 )
 
 type CartInfrastructure struct {
@@ -65,9 +73,12 @@ type CartService struct {
 	infrastructure *CartInfrastructure
 	config         *Config
 	productCache   *ProductCache
+	identityCache  *IdentityCache
+	pricing        Pricing
+	sagas          *CheckoutSagas
 }
 
-func NewCartService(logger *slog.Logger, infrastructure *CartInfrastructure, config *Config) *CartService {
+func NewCartService(logger *slog.Logger, infrastructure *CartInfrastructure, config *Config, pricing Pricing) *CartService {
 	if logger == nil {
 		logger = logging.FromContext(context.Background())
 	}
@@ -79,11 +90,14 @@ func NewCartService(logger *slog.Logger, infrastructure *CartInfrastructure, con
 		repo:             repo,
 		infrastructure:   infrastructure,
 		config:           config,
-		productCache:     NewProductCache(),
+		productCache:     NewProductCacheWithTTL(config.CartProductCacheTTL()),
+		identityCache:    NewIdentityCache(),
+		pricing:          pricing,
+		sagas:            newCheckoutSagas(repo, infrastructure, logger),
 	}
 }
 
-func NewCartServiceWithRepo(logger *slog.Logger, repo CartRepository, infrastructure *CartInfrastructure, config *Config) *CartService {
+func NewCartServiceWithRepo(logger *slog.Logger, repo CartRepository, infrastructure *CartInfrastructure, config *Config, pricing Pricing) *CartService {
 	if logger == nil {
 		logger = logging.FromContext(context.Background())
 	}
@@ -93,13 +107,22 @@ func NewCartServiceWithRepo(logger *slog.Logger, repo CartRepository, infrastruc
 		repo:             repo,
 		infrastructure:   infrastructure,
 		config:           config,
-		productCache:     NewProductCache(),
+		productCache:     NewProductCacheWithTTL(config.CartProductCacheTTL()),
+		identityCache:    NewIdentityCache(),
+		pricing:          pricing,
+		sagas:            newCheckoutSagas(repo, infrastructure, logger),
 	}
 }
 
-func (s *CartService) CreateCart(ctx context.Context, customerID *string) (*Cart, error) {
+// CreateCart creates an active cart priced in currency, the customer's
+// presentment currency; an empty currency uses DefaultCurrency.
+func (s *CartService) CreateCart(ctx context.Context, customerID *string, currency string) (*Cart, error) {
+	currency, err := s.presentmentCurrency(ctx, currency)
+	if err != nil {
+		return nil, err
+	}
 	cart := &Cart{
-		Currency:      "USD",
+		Currency:      currency,
 		CurrentStatus: "active",
 	}
 
@@ -107,7 +130,7 @@ func (s *CartService) CreateCart(ctx context.Context, customerID *string) (*Cart
 		id, err := uuid.Parse(*customerID)
 		if err != nil {
 			s.logger.Warn("Invalid customer ID provided when creating cart", "customer_id", *customerID, "error", err.Error())
-			return nil, fmt.Errorf("invalid customer ID: %w", err)
+			return nil, fmt.Errorf("%w: %w", ErrInvalidCustomerID, err)
 		}
 		cart.CustomerID = &id
 		s.logger.Debug("Creating cart with customer ID", "customer_id", *customerID)
@@ -119,10 +142,37 @@ func (s *CartService) CreateCart(ctx context.Context, customerID *string) (*Cart
 		return nil, fmt.Errorf("failed to create cart: %w", err)
 	}
 
-	s.logger.Info("Created new cart", "cart_id", cart.CartID)
+	recordVersion(ctx, cart)
+	s.logger.Info("Created new cart", "cart_id", cart.CartID, "currency", cart.Currency)
 	return cart, nil
 }
 
+// presentmentCurrency validates a cart currency. Cart amounts are stored
+// with two decimals, so currencies with other minor units are rejected;
+// currencies other than DefaultCurrency also need an exchange rate from it.
+func (s *CartService) presentmentCurrency(ctx context.Context, currency string) (string, error) {
+	if strings.TrimSpace(currency) == "" {
+		return DefaultCurrency, nil
+	}
+	if !money.ValidCurrency(currency) {
+		return "", platformerrors.NewValidationError("currency", "currency must be a three-letter ISO code")
+	}
+	currency = money.NormalizeCurrency(currency)
+	if money.Exponent(currency) != money.DefaultExponent {
+		return "", fmt.Errorf("%w: %s", fx.ErrUnsupportedCurrency, currency)
+	}
+	if currency == DefaultCurrency {
+		return currency, nil
+	}
+	if s.pricing.FX == nil {
+		return "", fmt.Errorf("%w: %s", fx.ErrUnsupportedCurrency, currency)
+	}
+	if _, err := s.pricing.FX.Rate(ctx, DefaultCurrency, currency); err != nil {
+		return "", err
+	}
+	return currency, nil
+}
+
 func (s *CartService) GetCart(ctx context.Context, cartID string) (*Cart, error) {
 	cart, err := s.repo.GetCartByID(ctx, cartID)
 	if err != nil {
@@ -135,6 +185,17 @@ func (s *CartService) GetCart(ctx context.Context, cartID string) (*Cart, error)
 }
 
 func (s *CartService) DeleteCart(ctx context.Context, cartID string) error {
+	cart, err := s.repo.GetCartByID(ctx, cartID)
+	if err != nil {
+		if errors.Is(err, ErrCartNotFound) {
+			return err
+		}
+		return fmt.Errorf("failed to get cart: %w", err)
+	}
+	if err := checkVersion(ctx, cart); err != nil {
+		return err
+	}
+
 	if err := s.repo.DeleteCart(ctx, cartID); err != nil {
 		if errors.Is(err, ErrCartNotFound) {
 			return err
@@ -144,7 +205,11 @@ func (s *CartService) DeleteCart(ctx context.Context, cartID string) error {
 	return nil
 }
 
-func (s *CartService) AddItem(ctx context.Context, cartID string, productID string, quantity int, imageURL string) (*CartItem, error) {
+// AddItem adds quantity of a product to a cart, in the variant selected by
+// attrs. A product in the product cache is confirmed or backordered at
+// once; any other is added pending_validation and validated through
+// cart.item.added.
+func (s *CartService) AddItem(ctx context.Context, cartID string, productID string, quantity int, imageURL string, attrs LineAttributes) (*CartItem, error) {
 	s.logger.Debug("Adding item to cart",
 		"cart_id", cartID,
 		"product_id", productID,
@@ -153,7 +218,7 @@ func (s *CartService) AddItem(ctx context.Context, cartID string, productID stri
 	)
 
 	if quantity <= 0 {
-		return nil, errors.New("quantity must be positive")
+		return nil, ErrInvalidQuantity
 	}
 
 	cart, err := s.repo.GetCartByID(ctx, cartID)
@@ -162,19 +227,38 @@ func (s *CartService) AddItem(ctx context.Context, cartID string, productID stri
 	}
 
 	if cart.CurrentStatus != "active" {
-		return nil, errors.New("cannot add items to non-active cart")
+		return nil, fmt.Errorf("cannot add items to non-active cart: %w", ErrCartNotActive)
+	}
+	if err := checkVersion(ctx, cart); err != nil {
+		return nil, err
 	}
 
-	// Check if product already exists in cart (prevent duplicates during validation)
-	existingItem, err := s.repo.GetItemByProductID(ctx, cartID, productID)
-	if err == nil && existingItem != nil {
-		if existingItem.IsPendingValidation() {
-			return nil, errors.New("product is already being added to cart, please wait for validation")
-		}
-		if existingItem.IsConfirmed() {
-			return nil, errors.New("product already exists in cart, use update quantity instead")
-		}
-		// If backorder, allow adding again (will create new validation attempt)
+	item, err := s.newItem(ctx, cart, productID, quantity, imageURL, attrs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Add item, write event and save totals in one transaction
+	err = s.saveCart(ctx, cart, func(tx database.Tx) error {
+		return s.addItemTx(ctx, tx, cart, item)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.itemAdded(cartID, item)
+	return item, nil
+}
+
+// newItem returns the line a product is added to cart as, without saving
+// it. The variant is checked against the product cache; a product already
+// in the cart in the same variant is refused unless it is backordered.
+func (s *CartService) newItem(ctx context.Context, cart *Cart, productID string, quantity int, imageURL string, attrs LineAttributes) (*CartItem, error) {
+	if quantity <= 0 {
+		return nil, ErrInvalidQuantity
+	}
+	if err := attrs.Normalize(); err != nil {
+		return nil, err
 	}
 
 	// Fast path: check product cache before emitting event.
@@ -182,215 +266,152 @@ func (s *CartService) AddItem(ctx context.Context, cartID string, productID stri
 	// without the event round-trip.
 	cacheEntry, cacheHit := s.productCache.Get(productID)
 	if cacheHit {
-		if !cacheEntry.InStock {
-			s.logger.Debug("Product out of stock (cache)", "product_id", productID)
-			// Create a backorder item — same as the event-driven path's ProductUnavailable handling
-			validationID := uuid.New().String()
-			reason := "product_out_of_stock"
-			item := &CartItem{
-				ProductID:       productID,
-				Quantity:        quantity,
-				ImageURL:        imageURL,
-				Status:          "backorder",
-				ValidationID:    &validationID,
-				BackorderReason: &reason,
-			}
-
-			tx, err := s.infrastructure.Database.BeginTx(ctx, nil)
-			if err != nil {
-				return nil, fmt.Errorf("failed to begin transaction: %w", err)
-			}
-
-			committed := false
-			defer func() {
-				if !committed {
-					_ = tx.Rollback()
-				}
-			}()
-
-			if err := s.repo.AddItemTx(ctx, tx, cartID, item); err != nil {
-				return nil, fmt.Errorf("failed to add item: %w", err)
-			}
-
-			if err := tx.Commit(); err != nil {
-				return nil, fmt.Errorf("failed to commit transaction: %w", err)
-			}
-			committed = true
-
-			s.logger.Debug("Updating cart totals with backorder item", "cart_id", cartID)
-			if err := s.repo.UpdateCart(ctx, cart); err != nil {
-				s.logger.Warn("Failed to update cart totals", "cart_id", cartID, "error", err.Error())
-			}
-
-			if s.infrastructure.SSEProvider != nil {
-				s.infrastructure.SSEProvider.GetHub().Publish(cartID, "cart.item.backorder", map[string]interface{}{
-					"line_number":      item.LineNumber,
-					"product_id":       productID,
-					"status":           "backorder",
-					"backorder_reason": reason,
-				})
-			}
-
-			s.logger.Info("Added backorder item (cache miss - out of stock)",
-				"cart_id", cartID,
-				"product_id", productID,
-				"quantity", quantity,
-			)
-			return item, nil
-		}
-
-		// Product is in stock — fast path: confirm immediately without event round-trip.
-		validationID := uuid.New().String()
-		item := &CartItem{
-			ProductID:    productID,
-			Quantity:     quantity,
-			ImageURL:     imageURL,
-			Status:       "confirmed",
-			ValidationID: &validationID,
-			ProductName:  cacheEntry.Name,
-			UnitPrice:    cacheEntry.FinalPrice,
-		}
-
-		tx, err := s.infrastructure.Database.BeginTx(ctx, nil)
-		if err != nil {
-			return nil, fmt.Errorf("failed to begin transaction: %w", err)
-		}
-
-		committed := false
-		defer func() {
-			if !committed {
-				_ = tx.Rollback()
-			}
-		}()
-
-		if err := s.repo.AddItemTx(ctx, tx, cartID, item); err != nil {
-			return nil, fmt.Errorf("failed to add item: %w", err)
+		if err := attrs.ResolveVariant(cacheEntry); err != nil {
+			return nil, err
 		}
+	}
 
-		if err := tx.Commit(); err != nil {
-			return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	// Check if product already exists in cart (prevent duplicates during validation)
+	for i := range cart.Items {
+		existing := &cart.Items[i]
+		if existing.ProductID != productID || !existing.SameVariant(attrs) {
+			continue
 		}
-		committed = true
-
-		cart.Items = append(cart.Items, *item)
-		cart.CalculateTotals()
-
-		s.logger.Debug("Updating cart totals after confirmed item", "cart_id", cartID)
-		if err := s.repo.UpdateCart(ctx, cart); err != nil {
-			s.logger.Warn("Failed to update cart totals", "cart_id", cartID, "error", err.Error())
+		if existing.IsPendingValidation() {
+			return nil, ErrItemValidationInProgress
 		}
-
-		item.CalculateLineTotal()
-
-		if s.infrastructure.SSEProvider != nil {
-			s.infrastructure.SSEProvider.GetHub().Publish(cartID, "cart.item.validated", map[string]interface{}{
-				"line_number":  item.LineNumber,
-				"product_id":   productID,
-				"product_name": cacheEntry.Name,
-				"unit_price":   cacheEntry.FinalPrice,
-				"quantity":     item.Quantity,
-				"total_price":  item.TotalPrice,
-				"status":       "validated",
-			})
+		if existing.IsConfirmed() {
+			return nil, ErrItemAlreadyInCart
 		}
-
-		s.logger.Info("Added item to cart (fast path - cache hit)",
-			"cart_id", cartID,
-			"product_id", productID,
-			"quantity", quantity,
-		)
-		return item, nil
+		// If backorder, allow adding again (will create new validation attempt)
 	}
 
-	// Slow path: product not in cache. Fall back to event-driven validation.
-	// This is the existing behavior — emit CartItemAdded and wait for
-	// ProductValidated/ProductUnavailable events from the product service.
-
-	s.logger.Debug("Product not in cache, emitting validation event", "product_id", productID)
-
 	validationID := uuid.New().String()
 	item := &CartItem{
-		ProductID:    productID,
-		Quantity:     quantity,
-		ImageURL:     imageURL,
-		Status:       "pending_validation",
-		ValidationID: &validationID,
+		ProductID:      productID,
+		Quantity:       quantity,
+		ImageURL:       imageURL,
+		ValidationID:   &validationID,
+		LineAttributes: attrs,
+	}
+
+	if !cacheHit {
+		// Slow path: product not in cache. Fall back to event-driven validation.
+		// This is the existing behavior — emit CartItemAdded and wait for
+		// ProductValidated/ProductUnavailable events from the product service.
+		s.logger.Debug("Product not in cache, emitting validation event", "product_id", productID)
+		item.Status = "pending_validation"
 		// LineNumber will be assigned by repository
 		// ProductName and UnitPrice will be updated after validation
+		return item, nil
 	}
 
-	// Begin transaction to add item and write event
-	tx, err := s.infrastructure.Database.BeginTx(ctx, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	if !s.productCache.CanSupply(productID, quantity) {
+		s.logger.Debug("Product out of stock (cache)", "product_id", productID, "quantity", quantity)
+		// Create a backorder item — same as the event-driven path's ProductUnavailable handling
+		reason := "product_out_of_stock"
+		if cacheEntry.InStock {
+			reason = "insufficient_stock"
+		}
+		item.Status = "backorder"
+		item.BackorderReason = &reason
+		return item, nil
 	}
 
-	committed := false
-	defer func() {
-		if !committed {
-			_ = tx.Rollback()
-		}
-	}()
+	// Product is in stock — fast path: confirm immediately without event round-trip.
+	item.Status = "confirmed"
+	item.ProductName = cacheEntry.Name
+	item.TaxCode = s.pricing.Tax.TaxCode(cacheEntry.Category)
+	item.Category = cacheEntry.Category
+	item.Brand = cacheEntry.Brand
+	item.WeightLb = cacheEntry.WeightLb
+	if err := s.pricing.PriceItem(ctx, item, cacheEntry.FinalPrice, cart.Currency); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
 
-	// Add item to cart within transaction
+// addItemTx saves a line built by newItem to cart within tx, and writes
+// the cart.item.added event requesting validation of a pending line.
+func (s *CartService) addItemTx(ctx context.Context, tx database.Tx, cart *Cart, item *CartItem) error {
+	cartID := cart.CartID.String()
 	if err := s.repo.AddItemTx(ctx, tx, cartID, item); err != nil {
-		return nil, fmt.Errorf("failed to add item: %w", err)
+		return fmt.Errorf("failed to add item: %w", err)
 	}
+	cart.Items = append(cart.Items, *item)
 
+	if !item.IsPendingValidation() {
+		return nil
+	}
 	// Emit CartItemAdded event to outbox (transactional)
 	// This notifies other services (like product) that an item was added
-	cartItemEvent := events.NewCartItemAddedEvent(cartID, item.LineNumber, productID, quantity, validationID)
+	cartItemEvent := events.NewCartItemAddedEvent(cartID, item.LineNumber, item.ProductID, item.Quantity, *item.ValidationID)
 	if err := s.infrastructure.OutboxWriter.WriteEvent(ctx, tx, cartItemEvent); err != nil {
-		return nil, fmt.Errorf("failed to write cart item event: %w", err)
-	}
-
-	// Commit transaction
-	if err := tx.Commit(); err != nil {
-		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+		return fmt.Errorf("failed to write cart item event: %w", err)
 	}
-	committed = true
+	return nil
+}
 
-	// Trigger immediate outbox processing for low latency
-	if s.infrastructure.OutboxPublisher != nil {
-		go func() {
-			if err := s.infrastructure.OutboxPublisher.ProcessNow(); err != nil {
-				s.logger.Warn("Failed to trigger immediate outbox processing",
-					"error", err.Error(),
-				)
-			}
-		}()
-	}
+// itemAdded tells the cart's SSE subscribers about a line saved by
+// addItemTx, or triggers the outbox for the validation of a pending one.
+func (s *CartService) itemAdded(cartID string, item *CartItem) {
+	switch {
+	case item.IsBackorder():
+		if s.infrastructure.SSEProvider != nil {
+			s.infrastructure.SSEProvider.GetHub().Publish(cartID, "cart.item.backorder", map[string]interface{}{
+				"line_number":      item.LineNumber,
+				"product_id":       item.ProductID,
+				"status":           "backorder",
+				"backorder_reason": *item.BackorderReason,
+			})
+		}
+		s.logger.Info("Added backorder item (cache hit - out of stock)",
+			"cart_id", cartID,
+			"product_id", item.ProductID,
+			"quantity", item.Quantity,
+		)
 
-	// Update cart totals with pending item (best effort, not transactional)
-	cart.Items = append(cart.Items, *item)
-	cart.CalculateTotals()
+	case item.IsConfirmed():
+		if s.infrastructure.SSEProvider != nil {
+			s.infrastructure.SSEProvider.GetHub().Publish(cartID, "cart.item.validated", map[string]interface{}{
+				"line_number":  item.LineNumber,
+				"product_id":   item.ProductID,
+				"product_name": item.ProductName,
+				"unit_price":   item.UnitPrice,
+				"quantity":     item.Quantity,
+				"total_price":  item.TotalPrice,
+				"status":       "validated",
+			})
+		}
+		s.logger.Info("Added item to cart (fast path - cache hit)",
+			"cart_id", cartID,
+			"product_id", item.ProductID,
+			"quantity", item.Quantity,
+		)
 
-	s.logger.Debug("Updating cart totals after adding pending item", "cart_id", cartID)
-	if err := s.repo.UpdateCart(ctx, cart); err != nil {
-		s.logger.Warn("Failed to update cart totals",
+	default:
+		// Trigger immediate outbox processing for low latency
+		if s.infrastructure.OutboxPublisher != nil {
+			go func() {
+				if err := s.infrastructure.OutboxPublisher.ProcessNow(); err != nil {
+					s.logger.Warn("Failed to trigger immediate outbox processing",
+						"error", err.Error(),
+					)
+				}
+			}()
+		}
+		s.logger.Info("Added item to cart, pending validation",
 			"cart_id", cartID,
-			"error", err.Error(),
+			"product_id", item.ProductID,
+			"quantity", item.Quantity,
+			"item_line_number", item.LineNumber,
 		)
 	}
-
-	s.logger.Debug("Added pending item to cart (cache miss, awaiting validation)",
-		"cart_id", cartID,
-		"product_id", productID,
-		"quantity", quantity,
-		"item_line_number", item.LineNumber,
-	)
-	s.logger.Info("Added item to cart, pending validation",
-		"cart_id", cartID,
-		"product_id", productID,
-		"quantity", quantity,
-		"item_line_number", item.LineNumber,
-	)
-	return item, nil
 }
 
 func (s *CartService) UpdateItemQuantity(ctx context.Context, cartID string, lineNumber string, quantity int) error {
 	if quantity <= 0 {
-		return errors.New("quantity must be positive")
+		return ErrInvalidQuantity
 	}
 
 	cart, err := s.repo.GetCartByID(ctx, cartID)
@@ -399,11 +420,11 @@ func (s *CartService) UpdateItemQuantity(ctx context.Context, cartID string, lin
 	}
 
 	if cart.CurrentStatus != "active" {
-		return errors.New("cannot modify items in non-active cart")
+		return fmt.Errorf("cannot modify items in non-active cart: %w", ErrCartNotActive)
 	}
 
-	if err := s.repo.UpdateItemQuantity(ctx, cartID, lineNumber, quantity); err != nil {
-		return fmt.Errorf("failed to update item: %w", err)
+	if err := checkVersion(ctx, cart); err != nil {
+		return err
 	}
 
 	for i := range cart.Items {
@@ -412,13 +433,12 @@ func (s *CartService) UpdateItemQuantity(ctx context.Context, cartID string, lin
 			break
 		}
 	}
-	cart.CalculateTotals()
-
-	if err := s.repo.UpdateCart(ctx, cart); err != nil {
-		return fmt.Errorf("failed to update cart totals: %w", err)
-	}
-
-	return nil
+	return s.saveCart(ctx, cart, func(tx database.Tx) error {
+		if err := s.repo.UpdateItemQuantityTx(ctx, tx, cartID, lineNumber, quantity); err != nil {
+			return fmt.Errorf("failed to update item: %w", err)
+		}
+		return nil
+	})
 }
 
 func (s *CartService) RemoveItem(ctx context.Context, cartID string, lineNumber string) error {
@@ -428,11 +448,11 @@ func (s *CartService) RemoveItem(ctx context.Context, cartID string, lineNumber
 	}
 
 	if cart.CurrentStatus != "active" {
-		return errors.New("cannot remove items from non-active cart")
+		return fmt.Errorf("cannot remove items from non-active cart: %w", ErrCartNotActive)
 	}
 
-	if err := s.repo.RemoveItem(ctx, cartID, lineNumber); err != nil {
-		return fmt.Errorf("failed to remove item: %w", err)
+	if err := checkVersion(ctx, cart); err != nil {
+		return err
 	}
 
 	var newItems []CartItem
@@ -442,13 +462,12 @@ func (s *CartService) RemoveItem(ctx context.Context, cartID string, lineNumber
 		}
 	}
 	cart.Items = newItems
-	cart.CalculateTotals()
-
-	if err := s.repo.UpdateCart(ctx, cart); err != nil {
-		return fmt.Errorf("failed to update cart totals: %w", err)
-	}
-
-	return nil
+	return s.saveCart(ctx, cart, func(tx database.Tx) error {
+		if err := s.repo.RemoveItemTx(ctx, tx, cartID, lineNumber); err != nil {
+			return fmt.Errorf("failed to remove item: %w", err)
+		}
+		return nil
+	})
 }
 
 func (s *CartService) SetContact(ctx context.Context, cartID string, contact *Contact) error {
@@ -477,16 +496,20 @@ func (s *CartService) SetContact(ctx context.Context, cartID string, contact *Co
 		s.logger.Debug("Cannot set contact for non-active cart",
 			"cart_id", cartID,
 		)
-		return errors.New("cannot modify contact for non-active cart")
+		return fmt.Errorf("cannot modify contact for non-active cart: %w", ErrCartNotActive)
+	}
+	if err := checkVersion(ctx, cart); err != nil {
+		return err
 	}
 
-	if err := s.repo.SetContact(ctx, cartID, contact); err != nil {
+	if err := s.repo.SetContact(ctx, cart, contact); err != nil {
 		s.logger.Debug("Failed to set contact for cart",
 			"cart_id", cartID,
 			"error", err.Error(),
 		)
 		return fmt.Errorf("failed to set contact: %w", err)
 	}
+	recordVersion(ctx, cart)
 
 	return nil
 }
@@ -502,14 +525,22 @@ func (s *CartService) AddAddress(ctx context.Context, cartID string, address *Ad
 	}
 
 	if cart.CurrentStatus != "active" {
-		return errors.New("cannot add address to non-active cart")
+		return fmt.Errorf("cannot add address to non-active cart: %w", ErrCartNotActive)
 	}
 
-	if err := s.repo.AddAddress(ctx, cartID, address); err != nil {
-		return fmt.Errorf("failed to add address: %w", err)
+	if err := checkVersion(ctx, cart); err != nil {
+		return err
 	}
 
-	return nil
+	// Saved with the totals since a new shipping address changes the tax
+	// jurisdictions
+	cart.Addresses = append(cart.Addresses, *address)
+	return s.saveCart(ctx, cart, func(tx database.Tx) error {
+		if err := s.repo.AddAddressTx(ctx, tx, cartID, address); err != nil {
+			return fmt.Errorf("failed to add address: %w", err)
+		}
+		return nil
+	})
 }
 
 func (s *CartService) SetCreditCard(ctx context.Context, cartID string, card *CreditCard) error {
@@ -534,12 +565,16 @@ func (s *CartService) SetCreditCard(ctx context.Context, cartID string, card *Cr
 		s.logger.Debug("Cannot set credit card for non-active cart",
 			"cart_id", cartID,
 		)
-		return errors.New("cannot modify payment for non-active cart")
+		return fmt.Errorf("cannot modify payment for non-active cart: %w", ErrCartNotActive)
+	}
+	if err := checkVersion(ctx, cart); err != nil {
+		return err
 	}
 
-	if err := s.repo.SetCreditCard(ctx, cartID, card); err != nil {
+	if err := s.repo.SetCreditCard(ctx, cart, card); err != nil {
 		return fmt.Errorf("failed to set credit card: %w", err)
 	}
+	recordVersion(ctx, cart)
 
 	return nil
 }
@@ -550,35 +585,249 @@ func (s *CartService) Checkout(ctx context.Context, cartID string) (*Cart, error
 		return nil, fmt.Errorf("failed to get cart: %w", err)
 	}
 
-	// Check for pending validation items - cannot checkout until all items are validated
-	for _, item := range cart.Items {
-		if item.IsPendingValidation() {
-			return nil, ErrCartItemsPendingValidation
-		}
+	if err := checkVersion(ctx, cart); err != nil {
+		return nil, err
 	}
 
-	cart.CalculateTotals()
+	// Cannot checkout until all items are validated and none is backordered
+	if err := cart.checkItemsResolved(); err != nil {
+		return nil, err
+	}
 
-	if err := s.repo.UpdateCart(ctx, cart); err != nil {
-		return nil, fmt.Errorf("failed to update cart totals: %w", err)
+	if err := s.checkStock(cart); err != nil {
+		return nil, err
 	}
 
-	checkedOutCart, err := s.repo.CheckoutCart(ctx, cartID)
+	if err := s.updateTotals(ctx, cart); err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(s.config.CartCheckoutTimeout())
+	checkedOutCart, err := s.repo.CheckoutCart(ctx, cartID, cart.Version, deadline)
 	if err != nil {
 		return nil, fmt.Errorf("checkout failed: %w", err)
 	}
+	recordVersion(ctx, checkedOutCart)
+	s.sagas.Started(checkedOutCart, deadline)
 
 	s.logger.Info("Cart checked out successfully", "cart_id", cartID)
 
 	return checkedOutCart, nil
 }
 
-func (s *CartService) CalculateTax(ctx context.Context, netPrice float64) (float64, error) {
-	return netPrice * 0.03, nil
+// checkStock refuses checkout when the last reported stock of a confirmed
+// line is below its quantity. The product service has the final say when
+// it reserves the checkout; this catches shortages it already reported.
+func (s *CartService) checkStock(cart *Cart) error {
+	var short []string
+	for _, item := range cart.Items {
+		if !item.IsConfirmed() {
+			continue
+		}
+		if available, ok := s.productCache.Available(item.ProductID); ok && available < item.Quantity {
+			short = append(short, fmt.Sprintf("line %s: %d requested, %d available", item.LineNumber, item.Quantity, max(available, 0)))
+		}
+	}
+	if len(short) > 0 {
+		return fmt.Errorf("%w: %s", ErrInsufficientStock, strings.Join(short, "; "))
+	}
+	return nil
+}
+
+// CalculateTax returns the per-line tax for cart without saving it.
+func (s *CartService) CalculateTax(ctx context.Context, cart *Cart) (*tax.Result, error) {
+	req, err := cart.taxRequest()
+	if err != nil {
+		return nil, err
+	}
+	return s.pricing.Tax.Calculate(ctx, req)
+}
+
+// updateTotals recalculates totals and line tax and saves them.
+func (s *CartService) updateTotals(ctx context.Context, cart *Cart) error {
+	return s.saveCart(ctx, cart, nil)
+}
+
+// saveCart applies change, if any, and saves the recalculated totals of
+// cart in one transaction. change runs first and must leave cart reflecting
+// what it wrote. The save fails with ErrCartVersionConflict if the cart
+// changed since it was read.
+func (s *CartService) saveCart(ctx context.Context, cart *Cart, change func(tx database.Tx) error) error {
+	tx, err := s.infrastructure.Database.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if change != nil {
+		if err := change(tx); err != nil {
+			return err
+		}
+	}
+
+	if err := cart.CalculateTotals(ctx, s.pricing); err != nil {
+		return err
+	}
+	if err := s.repo.UpdateCartTx(ctx, tx, cart); err != nil {
+		return fmt.Errorf("failed to update cart totals: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	committed = true
+
+	recordVersion(ctx, cart)
+	return nil
+}
+
+// CalculateShipping returns the shipping charge for the cart's selected
+// method, or zero when none is selected.
+func (s *CartService) CalculateShipping(ctx context.Context, cart *Cart) (money.Money, error) {
+	if cart.ShippingMethod == "" {
+		return money.Zero(cart.Currency), nil
+	}
+	req, err := cart.ShippingRequest()
+	if err != nil {
+		return money.Money{}, err
+	}
+	quote, err := s.pricing.Shipping.Rate(ctx, cart.ShippingMethod, req)
+	if err != nil {
+		return money.Money{}, err
+	}
+	return quote.Amount, nil
+}
+
+// GetShippingQuotes prices every shipping method available for the cart.
+func (s *CartService) GetShippingQuotes(ctx context.Context, cartID string) ([]shipping.Quote, error) {
+	cart, err := s.repo.GetCartByID(ctx, cartID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cart: %w", err)
+	}
+
+	req, err := cart.ShippingRequest()
+	if err != nil {
+		return nil, err
+	}
+	quotes, err := s.pricing.Shipping.Quote(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to quote shipping: %w", err)
+	}
+	return quotes, nil
 }
 
-func (s *CartService) CalculateShipping(ctx context.Context, cart *Cart) (float64, error) {
-	return 0.0, nil
+// SetShippingMethod selects a shipping method and updates the cart totals.
+func (s *CartService) SetShippingMethod(ctx context.Context, cartID string, method string) (*Cart, error) {
+	if method == "" {
+		return nil, platformerrors.NewValidationError("method", "method is required")
+	}
+
+	cart, err := s.repo.GetCartByID(ctx, cartID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cart: %w", err)
+	}
+
+	if cart.CurrentStatus != "active" {
+		return nil, fmt.Errorf("cannot set shipping for non-active cart: %w", ErrCartNotActive)
+	}
+	if err := checkVersion(ctx, cart); err != nil {
+		return nil, err
+	}
+
+	req, err := cart.ShippingRequest()
+	if err != nil {
+		return nil, err
+	}
+	quote, err := s.pricing.Shipping.Rate(ctx, method, req)
+	if err != nil {
+		s.logger.Debug("Shipping method rejected", "cart_id", cartID, "method", method, "error", err.Error())
+		return nil, fmt.Errorf("cannot use shipping method %q: %w", method, err)
+	}
+
+	cart.ShippingMethod = quote.Method
+	if err := s.updateTotals(ctx, cart); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Shipping method selected", "cart_id", cartID, "method", quote.Method, "shipping", cart.Shipping)
+	return cart, nil
+}
+
+// ChangeCurrency reprices the cart in currency. Each line is converted from
+// its catalog price at the current rate, replacing any earlier snapshot;
+// unvalidated lines are priced when they are validated. An unacknowledged
+// previous price is converted at the rate between the two cart currencies.
+func (s *CartService) ChangeCurrency(ctx context.Context, cartID string, currency string) (*Cart, error) {
+	if strings.TrimSpace(currency) == "" {
+		return nil, platformerrors.NewValidationError("currency", "currency is required")
+	}
+	currency, err := s.presentmentCurrency(ctx, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	cart, err := s.repo.GetCartByID(ctx, cartID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cart: %w", err)
+	}
+
+	if cart.CurrentStatus != "active" {
+		return nil, fmt.Errorf("cannot change currency of non-active cart: %w", ErrCartNotActive)
+	}
+	if err := checkVersion(ctx, cart); err != nil {
+		return nil, err
+	}
+	if cart.Currency == currency {
+		return cart, nil
+	}
+
+	var previousRate *fx.Rate
+	for i := range cart.Items {
+		item := &cart.Items[i]
+		if !item.IsConfirmed() {
+			continue
+		}
+		if err := s.pricing.convertItem(ctx, item, item.CatalogPrice(cart.Currency), currency); err != nil {
+			return nil, err
+		}
+		item.CalculateLineTotal()
+		// An unacknowledged price change is shown in the new currency too
+		if item.PreviousUnitPrice != nil {
+			if previousRate == nil {
+				if s.pricing.FX == nil {
+					return nil, fmt.Errorf("%w: %s", fx.ErrUnsupportedCurrency, currency)
+				}
+				rate, err := s.pricing.FX.Rate(ctx, cart.Currency, currency)
+				if err != nil {
+					return nil, fmt.Errorf("failed to get exchange rate: %w", err)
+				}
+				previousRate = &rate
+			}
+			previous := previousRate.Convert(*item.PreviousUnitPrice)
+			item.PreviousUnitPrice = &previous
+		}
+	}
+
+	from := cart.Currency
+	cart.Currency = currency
+	err = s.saveCart(ctx, cart, func(tx database.Tx) error {
+		if err := s.repo.UpdateItemPricesTx(ctx, tx, cart); err != nil {
+			return fmt.Errorf("failed to change currency: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Cart currency changed", "cart_id", cartID, "from", from, "to", currency)
+	return cart, nil
 }
 
 // GetRepository returns the cart repository for use by event handlers
@@ -591,7 +840,115 @@ func (s *CartService) GetInfrastructure() *CartInfrastructure {
 	return s.infrastructure
 }
 
+// ApplyCoupon validates code against the cart and applies it. The promotion
+// must currently qualify; the error explains why it does not.
+func (s *CartService) ApplyCoupon(ctx context.Context, cartID string, code string) (*Cart, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return nil, platformerrors.NewValidationError("code", "code is required")
+	}
+	if s.pricing.Promotions == nil {
+		return nil, ErrCouponNotFound
+	}
+
+	cart, err := s.repo.GetCartByID(ctx, cartID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cart: %w", err)
+	}
+
+	if cart.CurrentStatus != "active" {
+		return nil, fmt.Errorf("cannot apply coupon to non-active cart: %w", ErrCartNotActive)
+	}
+	if err := checkVersion(ctx, cart); err != nil {
+		return nil, err
+	}
+	if cart.HasCoupon(code) {
+		return nil, ErrCouponAlreadyApplied
+	}
+
+	promos, err := s.pricing.Promotions.Promotions(ctx, cart.CustomerKey(), []string{code})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load promotions: %w", err)
+	}
+	var coupon *promotion.Promotion
+	for i := range promos {
+		if strings.EqualFold(promos[i].Code, code) {
+			coupon = &promos[i]
+			break
+		}
+	}
+	if coupon == nil {
+		return nil, ErrCouponNotFound
+	}
+
+	if _, err := promotion.Evaluate(*coupon, cart.PromotionRequest(time.Now())); err != nil {
+		s.logger.Debug("Coupon rejected", "cart_id", cartID, "code", code, "error", err.Error())
+		return nil, fmt.Errorf("cannot apply coupon %s: %w", code, err)
+	}
+
+	cart.Coupons = append(cart.Coupons, code)
+	err = s.saveCart(ctx, cart, func(tx database.Tx) error {
+		return s.repo.AddCouponTx(ctx, tx, cartID, code)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Coupon applied", "cart_id", cartID, "code", code, "discount", cart.Discount)
+	return cart, nil
+}
+
+// RemoveCoupon removes code from the cart and updates the totals.
+func (s *CartService) RemoveCoupon(ctx context.Context, cartID string, code string) (*Cart, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+
+	cart, err := s.repo.GetCartByID(ctx, cartID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cart: %w", err)
+	}
+
+	if cart.CurrentStatus != "active" {
+		return nil, fmt.Errorf("cannot remove coupon from non-active cart: %w", ErrCartNotActive)
+	}
+
+	if err := checkVersion(ctx, cart); err != nil {
+		return nil, err
+	}
+
+	coupons := cart.Coupons[:0]
+	for _, applied := range cart.Coupons {
+		if !strings.EqualFold(applied, code) {
+			coupons = append(coupons, applied)
+		}
+	}
+	cart.Coupons = coupons
+	err = s.saveCart(ctx, cart, func(tx database.Tx) error {
+		return s.repo.RemoveCouponTx(ctx, tx, cartID, code)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Coupon removed", "cart_id", cartID, "code", code)
+	return cart, nil
+}
+
+// GetCheckoutSagas returns the checkout sagas for use by event handlers.
+func (s *CartService) GetCheckoutSagas() *CheckoutSagas {
+	return s.sagas
+}
+
+// GetPricing returns the pricing calculators for use by event handlers.
+func (s *CartService) GetPricing() Pricing {
+	return s.pricing
+}
+
 // GetProductCache returns the product cache for use by event handlers.
 func (s *CartService) GetProductCache() *ProductCache {
 	return s.productCache
 }
+
+// GetIdentityCache returns the identity cache for use by event handlers.
+func (s *CartService) GetIdentityCache() *IdentityCache {
+	return s.identityCache
+}
@@ -0,0 +1,254 @@
+package cart
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go-shopping-poc/internal/platform/database"
+	platformerrors "go-shopping-poc/internal/platform/errors"
+)
+
+// Item operations of a batch update.
+const (
+	ItemOpAdd    = "add"
+	ItemOpUpdate = "update"
+	ItemOpRemove = "remove"
+)
+
+// maxItemOperations bounds the operations of one batch update.
+const maxItemOperations = 100
+
+// ItemOperation is one change of a batch update. An add takes a product,
+// quantity and variant like AddItem. An update names an existing line and
+// changes what is given of its quantity, size, color and gift message. A
+// remove names the line to drop.
+type ItemOperation struct {
+	Op         string `json:"op"`
+	LineNumber string `json:"line_number,omitempty"`
+	ProductID  string `json:"product_id,omitempty"`
+	Quantity   int    `json:"quantity,omitempty"`
+	ImageURL   string `json:"image_url,omitempty"`
+	LineAttributes
+}
+
+// UpdateItems applies ops to the lines of a cart in order and saves them
+// in one transaction: either every operation is applied or none is. Line
+// numbers refer to the lines of the cart before the batch. A failing
+// operation is reported with its index.
+func (s *CartService) UpdateItems(ctx context.Context, cartID string, ops []ItemOperation) (*Cart, error) {
+	if len(ops) == 0 {
+		return nil, platformerrors.NewValidationError("operations", "operations is required")
+	}
+	if len(ops) > maxItemOperations {
+		return nil, platformerrors.NewValidationError("operations", fmt.Sprintf("at most %d operations are allowed", maxItemOperations))
+	}
+
+	cart, err := s.repo.GetCartByID(ctx, cartID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cart: %w", err)
+	}
+
+	if cart.CurrentStatus != "active" {
+		return nil, fmt.Errorf("cannot modify items in non-active cart: %w", ErrCartNotActive)
+	}
+	if err := checkVersion(ctx, cart); err != nil {
+		return nil, err
+	}
+
+	batch := &itemBatch{cart: cart, variants: make(map[string]bool)}
+	for i, op := range ops {
+		var err error
+		switch op.Op {
+		case ItemOpAdd:
+			err = s.batchAdd(ctx, batch, op)
+		case ItemOpUpdate:
+			err = s.batchUpdate(batch, op)
+		case ItemOpRemove:
+			err = batch.remove(op.LineNumber)
+		default:
+			err = platformerrors.NewValidationError("op", "op must be one of: add, update, remove")
+		}
+		if err != nil {
+			return nil, operationError(i, err)
+		}
+	}
+
+	err = s.saveCart(ctx, cart, func(tx database.Tx) error {
+		for _, lineNumber := range batch.removed {
+			if err := s.repo.RemoveItemTx(ctx, tx, cartID, lineNumber); err != nil {
+				return fmt.Errorf("failed to remove item: %w", err)
+			}
+		}
+		for _, lineNumber := range batch.updated {
+			item := batch.find(lineNumber)
+			if item == nil {
+				continue
+			}
+			if err := s.repo.UpdateItemQuantityTx(ctx, tx, cartID, lineNumber, item.Quantity); err != nil {
+				return fmt.Errorf("failed to update item: %w", err)
+			}
+			if err := s.repo.UpdateItemAttributesTx(ctx, tx, cartID, lineNumber, item.LineAttributes); err != nil {
+				return fmt.Errorf("failed to update item: %w", err)
+			}
+		}
+		for _, item := range batch.added {
+			if err := s.addItemTx(ctx, tx, cart, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range batch.added {
+		s.itemAdded(cartID, item)
+	}
+
+	s.logger.Info("Cart items updated",
+		"cart_id", cartID,
+		"added", len(batch.added),
+		"updated", len(batch.updated),
+		"removed", len(batch.removed),
+	)
+	return cart, nil
+}
+
+// itemBatch holds the changes of a batch update while its operations are
+// applied to cart in memory.
+type itemBatch struct {
+	cart    *Cart
+	added   []*CartItem
+	updated []string
+	removed []string
+	// variants holds the variant keys of the lines added by the batch.
+	variants map[string]bool
+}
+
+// batchAdd builds the line of an add operation.
+func (s *CartService) batchAdd(ctx context.Context, batch *itemBatch, op ItemOperation) error {
+	if op.ProductID == "" {
+		return platformerrors.NewValidationError("product_id", "product_id is required")
+	}
+	item, err := s.newItem(ctx, batch.cart, op.ProductID, op.Quantity, op.ImageURL, op.LineAttributes)
+	if err != nil {
+		return err
+	}
+	key := item.variantKey()
+	if batch.variants[key] {
+		return ErrItemAlreadyInCart
+	}
+	batch.variants[key] = true
+	batch.added = append(batch.added, item)
+	return nil
+}
+
+// batchUpdate applies an update operation to its line. A new size or
+// color is checked against the product cache and must not select the
+// variant of another line.
+func (s *CartService) batchUpdate(batch *itemBatch, op ItemOperation) error {
+	item := batch.find(op.LineNumber)
+	if item == nil {
+		return ErrCartItemNotFound
+	}
+	if op.Quantity < 0 {
+		return ErrInvalidQuantity
+	}
+
+	attrs := op.LineAttributes
+	if err := attrs.Normalize(); err != nil {
+		return err
+	}
+	if attrs.Size != "" || attrs.Color != "" {
+		variant := item.LineAttributes
+		if attrs.Size != "" {
+			variant.Size = attrs.Size
+		}
+		if attrs.Color != "" {
+			variant.Color = attrs.Color
+		}
+		entry, ok := s.productCache.Get(item.ProductID)
+		if !ok {
+			return platformerrors.NewValidationError("size", "product options are not available, try again later")
+		}
+		if err := variant.ResolveVariant(entry); err != nil {
+			return err
+		}
+		if batch.hasVariant(item, variant) {
+			return ErrItemAlreadyInCart
+		}
+		item.Size, item.Color = variant.Size, variant.Color
+	}
+	if attrs.GiftMessage != "" {
+		item.GiftMessage = attrs.GiftMessage
+	}
+	if op.Quantity > 0 {
+		item.Quantity = op.Quantity
+	}
+
+	for _, lineNumber := range batch.updated {
+		if lineNumber == item.LineNumber {
+			return nil
+		}
+	}
+	batch.updated = append(batch.updated, item.LineNumber)
+	return nil
+}
+
+// find returns the line of the cart numbered lineNumber, or nil.
+func (b *itemBatch) find(lineNumber string) *CartItem {
+	for i := range b.cart.Items {
+		if b.cart.Items[i].LineNumber == lineNumber {
+			return &b.cart.Items[i]
+		}
+	}
+	return nil
+}
+
+// hasVariant reports whether a line other than item, or a line added by
+// the batch, is of the product of item in variant.
+func (b *itemBatch) hasVariant(item *CartItem, variant LineAttributes) bool {
+	for i := range b.cart.Items {
+		other := &b.cart.Items[i]
+		if other.LineNumber != item.LineNumber && other.ProductID == item.ProductID && other.SameVariant(variant) {
+			return true
+		}
+	}
+	key := (&CartItem{ProductID: item.ProductID, LineAttributes: variant}).variantKey()
+	return b.variants[key]
+}
+
+// remove drops a line from the cart.
+func (b *itemBatch) remove(lineNumber string) error {
+	for i := range b.cart.Items {
+		if b.cart.Items[i].LineNumber != lineNumber {
+			continue
+		}
+		b.cart.Items = append(b.cart.Items[:i], b.cart.Items[i+1:]...)
+		for j, updated := range b.updated {
+			if updated == lineNumber {
+				b.updated = append(b.updated[:j], b.updated[j+1:]...)
+				break
+			}
+		}
+		b.removed = append(b.removed, lineNumber)
+		return nil
+	}
+	return ErrCartItemNotFound
+}
+
+// operationError reports err as the failure of operation i. Field errors
+// are prefixed with the path of the operation.
+func operationError(i int, err error) error {
+	var verr *platformerrors.ValidationError
+	if errors.As(err, &verr) {
+		fields := make([]platformerrors.FieldError, len(verr.Fields))
+		for j, f := range verr.Fields {
+			fields[j] = platformerrors.FieldError{Field: fmt.Sprintf("operations[%d].%s", i, f.Field), Message: f.Message}
+		}
+		return &platformerrors.ValidationError{Fields: fields}
+	}
+	return fmt.Errorf("operation %d: %w", i, err)
+}
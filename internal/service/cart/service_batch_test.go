@@ -0,0 +1,347 @@
+package cart
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"go-shopping-poc/internal/platform/database"
+	platformerrors "go-shopping-poc/internal/platform/errors"
+	"go-shopping-poc/internal/platform/money/moneytest"
+	"go-shopping-poc/internal/platform/tax"
+)
+
+var errWriteFailed = errors.New("write failed")
+
+// fakeTx stages the writes of a transaction and applies them on commit.
+type fakeTx struct {
+	database.Tx
+	writes []func()
+}
+
+func (tx *fakeTx) Commit() error {
+	for _, write := range tx.writes {
+		write()
+	}
+	return nil
+}
+
+func (tx *fakeTx) Rollback() error {
+	tx.writes = nil
+	return nil
+}
+
+type fakeDB struct {
+	database.Database
+}
+
+func (db *fakeDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (database.Tx, error) {
+	return &fakeTx{}, nil
+}
+
+// fakeItemRepo holds one cart in memory. Item and cart writes are staged
+// on the transaction they are made in; failOn names a write that fails.
+type fakeItemRepo struct {
+	CartRepository
+	stored *Cart
+	failOn string
+}
+
+func (r *fakeItemRepo) GetCartByID(ctx context.Context, cartID string) (*Cart, error) {
+	cart := *r.stored
+	cart.Items = append([]CartItem(nil), r.stored.Items...)
+	return &cart, nil
+}
+
+func (r *fakeItemRepo) write(tx database.Tx, name string, write func()) error {
+	if r.failOn == name {
+		return errWriteFailed
+	}
+	tx.(*fakeTx).writes = append(tx.(*fakeTx).writes, write)
+	return nil
+}
+
+func (r *fakeItemRepo) item(lineNumber string) *CartItem {
+	for i := range r.stored.Items {
+		if r.stored.Items[i].LineNumber == lineNumber {
+			return &r.stored.Items[i]
+		}
+	}
+	return nil
+}
+
+func (r *fakeItemRepo) RemoveItemTx(ctx context.Context, tx database.Tx, cartID, lineNumber string) error {
+	return r.write(tx, "RemoveItemTx", func() {
+		for i := range r.stored.Items {
+			if r.stored.Items[i].LineNumber == lineNumber {
+				r.stored.Items = append(r.stored.Items[:i], r.stored.Items[i+1:]...)
+				return
+			}
+		}
+	})
+}
+
+func (r *fakeItemRepo) UpdateItemQuantityTx(ctx context.Context, tx database.Tx, cartID, lineNumber string, quantity int) error {
+	return r.write(tx, "UpdateItemQuantityTx", func() { r.item(lineNumber).Quantity = quantity })
+}
+
+func (r *fakeItemRepo) UpdateItemAttributesTx(ctx context.Context, tx database.Tx, cartID, lineNumber string, attrs LineAttributes) error {
+	return r.write(tx, "UpdateItemAttributesTx", func() { r.item(lineNumber).LineAttributes = attrs })
+}
+
+func (r *fakeItemRepo) AddItemTx(ctx context.Context, tx database.Tx, cartID string, item *CartItem) error {
+	item.LineNumber = fmt.Sprintf("%03d", len(r.stored.Items)+1)
+	added := *item
+	return r.write(tx, "AddItemTx", func() { r.stored.Items = append(r.stored.Items, added) })
+}
+
+func (r *fakeItemRepo) UpdateCartTx(ctx context.Context, tx database.Tx, cart *Cart) error {
+	netPrice := cart.NetPrice
+	if err := r.write(tx, "UpdateCartTx", func() {
+		r.stored.NetPrice = netPrice
+		r.stored.Version++
+	}); err != nil {
+		return err
+	}
+	cart.Version++
+	return nil
+}
+
+// newBatchService returns a service over a cart of two lines: 001 of two
+// units of p1 in size M and 002 of one unit of p2. The product cache
+// holds p1 in sizes M and L, p2 and p3.
+func newBatchService(t *testing.T, failOn string) (*CartService, *fakeItemRepo) {
+	t.Helper()
+
+	table, err := tax.DefaultRateTable()
+	if err != nil {
+		t.Fatalf("DefaultRateTable() error = %v", err)
+	}
+	repo := &fakeItemRepo{
+		failOn: failOn,
+		stored: &Cart{
+			CartID:        uuid.New(),
+			Currency:      "USD",
+			CurrentStatus: "active",
+			NetPrice:      moneytest.USD("25.00"),
+			Items: []CartItem{
+				{LineNumber: "001", ProductID: "p1", Quantity: 2, UnitPrice: moneytest.USD("10.00"), Status: "confirmed", LineAttributes: LineAttributes{Size: "M"}},
+				{LineNumber: "002", ProductID: "p2", Quantity: 1, UnitPrice: moneytest.USD("5.00"), Status: "confirmed"},
+			},
+		},
+	}
+	s := NewCartServiceWithRepo(nil, repo, &CartInfrastructure{Database: &fakeDB{}}, &Config{}, Pricing{Tax: tax.NewTableCalculator(table)})
+	s.productCache.Set("p1", ProductEntry{ProductID: "p1", InStock: true, FinalPrice: moneytest.USD("10.00"), Name: "Shirt", Sizes: []string{"M", "L"}, VariantsKnown: true}, time.Time{})
+	s.productCache.Set("p2", ProductEntry{ProductID: "p2", InStock: true, FinalPrice: moneytest.USD("5.00"), Name: "Socks"}, time.Time{})
+	s.productCache.Set("p3", ProductEntry{ProductID: "p3", InStock: true, FinalPrice: moneytest.USD("7.50"), Name: "Cap"}, time.Time{})
+	return s, repo
+}
+
+// lines describes the lines of cart as line:product:size:quantity.
+func lines(cart *Cart) string {
+	var parts []string
+	for _, item := range cart.Items {
+		parts = append(parts, fmt.Sprintf("%s:%s:%s:%d", item.LineNumber, item.ProductID, item.Size, item.Quantity))
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestUpdateItems(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		ops       []ItemOperation
+		wantLines string
+		wantNet   string
+	}{
+		{
+			name:      "update quantity",
+			ops:       []ItemOperation{{Op: ItemOpUpdate, LineNumber: "001", Quantity: 3}},
+			wantLines: "001:p1:M:3 002:p2::1",
+			wantNet:   "35.00",
+		},
+		{
+			name:      "change size",
+			ops:       []ItemOperation{{Op: ItemOpUpdate, LineNumber: "001", LineAttributes: LineAttributes{Size: "L"}}},
+			wantLines: "001:p1:L:2 002:p2::1",
+			wantNet:   "25.00",
+		},
+		{
+			name: "add, update and remove",
+			ops: []ItemOperation{
+				{Op: ItemOpAdd, ProductID: "p3", Quantity: 2},
+				{Op: ItemOpUpdate, LineNumber: "001", Quantity: 1},
+				{Op: ItemOpRemove, LineNumber: "002"},
+			},
+			wantLines: "001:p1:M:1 003:p3::2",
+			wantNet:   "25.00",
+		},
+		{
+			name: "add another size of a line",
+			ops: []ItemOperation{
+				{Op: ItemOpAdd, ProductID: "p1", Quantity: 1, LineAttributes: LineAttributes{Size: "L"}},
+			},
+			wantLines: "001:p1:M:2 002:p2::1 003:p1:L:1",
+			wantNet:   "35.00",
+		},
+		{
+			name: "update then remove a line",
+			ops: []ItemOperation{
+				{Op: ItemOpUpdate, LineNumber: "002", Quantity: 4},
+				{Op: ItemOpRemove, LineNumber: "002"},
+			},
+			wantLines: "001:p1:M:2",
+			wantNet:   "20.00",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s, repo := newBatchService(t, "")
+			cart, err := s.UpdateItems(context.Background(), repo.stored.CartID.String(), tt.ops)
+			if err != nil {
+				t.Fatalf("UpdateItems() error = %v", err)
+			}
+			if got := lines(repo.stored); got != tt.wantLines {
+				t.Errorf("stored lines = %q, want %q", got, tt.wantLines)
+			}
+			if got := lines(cart); got != tt.wantLines {
+				t.Errorf("returned lines = %q, want %q", got, tt.wantLines)
+			}
+			if want := moneytest.USD(tt.wantNet); !repo.stored.NetPrice.Equal(want) {
+				t.Errorf("stored NetPrice = %s, want %s", repo.stored.NetPrice, want)
+			}
+			if repo.stored.Version != 1 || cart.Version != 1 {
+				t.Errorf("Version stored = %d, returned = %d, want 1", repo.stored.Version, cart.Version)
+			}
+		})
+	}
+}
+
+func TestUpdateItemsAppliesNoneOnFailure(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		ops       []ItemOperation
+		failOn    string
+		wantErr   error
+		wantField string
+	}{
+		{
+			name: "unknown line",
+			ops: []ItemOperation{
+				{Op: ItemOpUpdate, LineNumber: "001", Quantity: 3},
+				{Op: ItemOpRemove, LineNumber: "009"},
+			},
+			wantErr: ErrCartItemNotFound,
+		},
+		{
+			name: "line removed earlier in the batch",
+			ops: []ItemOperation{
+				{Op: ItemOpRemove, LineNumber: "002"},
+				{Op: ItemOpUpdate, LineNumber: "002", Quantity: 3},
+			},
+			wantErr: ErrCartItemNotFound,
+		},
+		{
+			name: "product added twice",
+			ops: []ItemOperation{
+				{Op: ItemOpAdd, ProductID: "p3", Quantity: 1},
+				{Op: ItemOpAdd, ProductID: "p3", Quantity: 2},
+			},
+			wantErr: ErrItemAlreadyInCart,
+		},
+		{
+			name: "size of another line",
+			ops: []ItemOperation{
+				{Op: ItemOpAdd, ProductID: "p1", Quantity: 1, LineAttributes: LineAttributes{Size: "L"}},
+				{Op: ItemOpUpdate, LineNumber: "001", LineAttributes: LineAttributes{Size: "L"}},
+			},
+			wantErr: ErrItemAlreadyInCart,
+		},
+		{
+			name: "negative quantity",
+			ops: []ItemOperation{
+				{Op: ItemOpRemove, LineNumber: "002"},
+				{Op: ItemOpUpdate, LineNumber: "001", Quantity: -1},
+			},
+			wantErr: ErrInvalidQuantity,
+		},
+		{
+			name: "unknown op",
+			ops: []ItemOperation{
+				{Op: ItemOpRemove, LineNumber: "002"},
+				{Op: "replace", LineNumber: "001"},
+			},
+			wantField: "operations[1].op",
+		},
+		{
+			name: "add without product",
+			ops: []ItemOperation{
+				{Op: ItemOpUpdate, LineNumber: "001", Quantity: 3},
+				{Op: ItemOpAdd, Quantity: 1},
+			},
+			wantField: "operations[1].product_id",
+		},
+		{
+			name: "failing write",
+			ops: []ItemOperation{
+				{Op: ItemOpRemove, LineNumber: "002"},
+				{Op: ItemOpUpdate, LineNumber: "001", Quantity: 3},
+			},
+			failOn:  "UpdateItemAttributesTx",
+			wantErr: errWriteFailed,
+		},
+		{
+			name: "failing cart update",
+			ops: []ItemOperation{
+				{Op: ItemOpAdd, ProductID: "p3", Quantity: 1},
+				{Op: ItemOpRemove, LineNumber: "002"},
+			},
+			failOn:  "UpdateCartTx",
+			wantErr: errWriteFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s, repo := newBatchService(t, tt.failOn)
+			before := lines(repo.stored)
+			_, err := s.UpdateItems(context.Background(), repo.stored.CartID.String(), tt.ops)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("UpdateItems() error = %v, want %v", err, tt.wantErr)
+				}
+				if tt.failOn == "" && (err == nil || !strings.HasPrefix(err.Error(), "operation 1:")) {
+					t.Errorf("UpdateItems() error = %v, want it to name operation 1", err)
+				}
+			}
+			if tt.wantField != "" {
+				var verr *platformerrors.ValidationError
+				if !errors.As(err, &verr) || len(verr.Fields) != 1 || verr.Fields[0].Field != tt.wantField {
+					t.Errorf("UpdateItems() error = %v, want a validation error of %s", err, tt.wantField)
+				}
+			}
+			if got := lines(repo.stored); got != before {
+				t.Errorf("stored lines = %q, want them unchanged as %q", got, before)
+			}
+			if repo.stored.Version != 0 {
+				t.Errorf("stored Version = %d, want 0", repo.stored.Version)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package cart
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+
+	events "go-shopping-poc/internal/contracts/events"
+	"go-shopping-poc/internal/platform/auth"
+	kafka "go-shopping-poc/internal/platform/event/bus/kafka"
+	"go-shopping-poc/internal/platform/money"
+)
+
+// ClaimCart attaches the guest cart cartID to the customer signed in with
+// claims. If the customer has no active cart the guest cart becomes theirs;
+// otherwise its items are merged into their active cart and the guest cart
+// is closed. Quantities of the same product are summed and every merged
+// line is repriced and stock-checked again. Coupons, contact, addresses and
+// payment of a merged guest cart are not carried over. It returns the
+// customer's active cart.
+func (s *CartService) ClaimCart(ctx context.Context, cartID string, claims *auth.Claims) (*Cart, error) {
+	customerID, err := s.ResolveCustomer(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	guest, err := s.repo.GetCartByID(ctx, cartID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cart: %w", err)
+	}
+	if err := checkVersion(ctx, guest); err != nil {
+		return nil, err
+	}
+
+	cart, err := s.repo.ClaimCart(ctx, cartID, customerID, s.mergeItems, func(ctx context.Context, c *Cart) error {
+		return c.CalculateTotals(ctx, s.pricing)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim cart: %w", err)
+	}
+	merged := cart.CartID.String() != cartID
+	recordVersion(ctx, cart)
+
+	if s.infrastructure.OutboxPublisher != nil {
+		go func() {
+			if err := s.infrastructure.OutboxPublisher.ProcessNow(); err != nil {
+				s.logger.Warn("Failed to trigger immediate outbox processing", "error", err.Error())
+			}
+		}()
+	}
+
+	if merged && s.infrastructure.SSEProvider != nil {
+		s.infrastructure.SSEProvider.GetHub().Publish(cartID, "cart.merged", map[string]interface{}{
+			"cart_id":        cartID,
+			"merged_into_id": cart.CartID.String(),
+		})
+	}
+
+	s.logger.Info("Cart claimed",
+		"cart_id", cartID,
+		"customer_id", customerID,
+		"merged_into", cart.CartID.String(),
+		"merged", merged,
+	)
+	return s.GetCart(ctx, cart.CartID.String())
+}
+
+// ResolveCustomer returns the ID of the customer linked to the Keycloak
+// subject of claims.
+func (s *CartService) ResolveCustomer(claims *auth.Claims) (string, error) {
+	if claims == nil || claims.Subject == "" {
+		return "", fmt.Errorf("%w: missing sub in token", ErrCustomerNotLinked)
+	}
+	customerID, ok := s.identityCache.Get(claims.Subject)
+	if !ok {
+		return "", ErrCustomerNotLinked
+	}
+	return customerID, nil
+}
+
+// mergeItems is the MergeFunc used by ClaimCart. A guest line for a product
+// variant already in the target adds its quantity to that line; other guest
+// lines become new target lines. Every line touched is revalidated.
+func (s *CartService) mergeItems(ctx context.Context, guest, target *Cart) ([]*CartItem, []*CartItem, error) {
+	lines := make(map[string]*CartItem, len(target.Items))
+	for i := range target.Items {
+		lines[target.Items[i].variantKey()] = &target.Items[i]
+	}
+
+	var updated, added []*CartItem
+	touched := make(map[*CartItem]bool)
+	for _, item := range guest.Items {
+		line, ok := lines[item.variantKey()]
+		if !ok {
+			line = &CartItem{
+				ProductID:      item.ProductID,
+				ImageURL:       item.ImageURL,
+				ProductName:    item.ProductName,
+				Category:       item.Category,
+				Brand:          item.Brand,
+				TaxCode:        item.TaxCode,
+				LineAttributes: item.LineAttributes,
+			}
+			lines[item.variantKey()] = line
+			added = append(added, line)
+			touched[line] = true
+		} else if !touched[line] {
+			updated = append(updated, line)
+			touched[line] = true
+		}
+		line.Quantity += item.Quantity
+	}
+
+	for _, line := range slices.Concat(updated, added) {
+		if err := s.revalidateItem(ctx, line, target.Currency); err != nil {
+			return nil, nil, err
+		}
+	}
+	return updated, added, nil
+}
+
+// revalidateItem checks a merged line against the product cache. A cached
+// product is confirmed at its current price, or backordered when stock is
+// short; an uncached product is left pending_validation so it goes through
+// product service validation like a newly added item.
+func (s *CartService) revalidateItem(ctx context.Context, item *CartItem, currency string) error {
+	validationID := uuid.New().String()
+	item.ValidationID = &validationID
+	item.BackorderReason = nil
+
+	entry, ok := s.productCache.Get(item.ProductID)
+	if ok && s.productCache.CanSupply(item.ProductID, item.Quantity) {
+		item.Status = "confirmed"
+		item.ProductName = entry.Name
+		item.TaxCode = s.pricing.Tax.TaxCode(entry.Category)
+		item.Category = entry.Category
+		item.Brand = entry.Brand
+		item.WeightLb = entry.WeightLb
+		if err := s.pricing.PriceItem(ctx, item, entry.FinalPrice, currency); err != nil {
+			return err
+		}
+		item.CalculateLineTotal()
+		return nil
+	}
+
+	if ok {
+		reason := "product_out_of_stock"
+		if entry.InStock {
+			reason = "insufficient_stock"
+		}
+		item.Status = "backorder"
+		item.BackorderReason = &reason
+	} else {
+		item.Status = "pending_validation"
+	}
+	item.UnitPrice = money.Zero(currency)
+	item.OriginalUnitPrice = money.Money{}
+	item.OriginalCurrency = ""
+	item.FXRate = 0
+	item.FXSource = ""
+	item.FXAsOf = nil
+	item.CalculateLineTotal()
+	return nil
+}
+
+// BootstrapIdentityCache replays historical CustomerEvents from Kafka and
+// populates the identity cache before the HTTP server starts accepting
+// traffic.
+func (s *CartService) BootstrapIdentityCache(ctx context.Context) error {
+	eb, ok := s.infrastructure.EventBus.(*kafka.EventBus)
+	if !ok {
+		return fmt.Errorf("event bus does not support replay")
+	}
+
+	messages, err := eb.ReplayTopic(ctx, "CustomerEvents", kafka.DefaultReplayOptions())
+	if err != nil {
+		return fmt.Errorf("failed to replay CustomerEvents: %w", err)
+	}
+
+	factory := events.CustomerEventFactory{}
+	for _, msg := range messages {
+		evt, err := factory.FromJSON(msg.Value)
+		if err != nil {
+			s.logger.Warn("Failed to unmarshal customer event during bootstrap", "error", err)
+			continue
+		}
+		if evt.EventType != events.CustomerCreated && evt.EventType != events.CustomerUpdated {
+			continue
+		}
+		if keycloakSub := evt.EventPayload.Details["keycloak_sub"]; keycloakSub != "" {
+			s.identityCache.Set(keycloakSub, evt.EventPayload.CustomerID)
+		}
+	}
+
+	s.logger.Info("Identity cache bootstrapped", "entries", s.identityCache.Count(), "messages_replayed", len(messages))
+	return nil
+}
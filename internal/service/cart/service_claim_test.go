@@ -0,0 +1,392 @@
+package cart
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"go-shopping-poc/internal/platform/database"
+	"go-shopping-poc/internal/platform/money"
+	"go-shopping-poc/internal/platform/money/moneytest"
+	"go-shopping-poc/internal/platform/outbox"
+	"go-shopping-poc/internal/platform/tax"
+)
+
+// newClaimService returns a service whose product cache holds p1 at 10.00,
+// p2 at 5.00 and p3 at 7.50, all in stock.
+func newClaimService(t *testing.T) *CartService {
+	t.Helper()
+
+	table, err := tax.DefaultRateTable()
+	if err != nil {
+		t.Fatalf("DefaultRateTable() error = %v", err)
+	}
+	s := NewCartServiceWithRepo(slog.New(slog.DiscardHandler), nil, &CartInfrastructure{}, &Config{}, Pricing{Tax: tax.NewTableCalculator(table)})
+	s.productCache.Set("p1", ProductEntry{ProductID: "p1", InStock: true, FinalPrice: moneytest.USD("10.00"), Name: "Shirt"}, time.Time{})
+	s.productCache.Set("p2", ProductEntry{ProductID: "p2", InStock: true, FinalPrice: moneytest.USD("5.00"), Name: "Socks"}, time.Time{})
+	s.productCache.Set("p3", ProductEntry{ProductID: "p3", InStock: true, FinalPrice: moneytest.USD("7.50"), Name: "Cap"}, time.Time{})
+	return s
+}
+
+// customerCart returns an active cart of the customer with two lines: 001
+// of two units of p1 and 002 of one unit of p2.
+func customerCart(customerID uuid.UUID) *Cart {
+	return &Cart{
+		CartID:        uuid.New(),
+		CustomerID:    &customerID,
+		Currency:      "USD",
+		CurrentStatus: "active",
+		Items: []CartItem{
+			{LineNumber: "001", ProductID: "p1", Quantity: 2, UnitPrice: moneytest.USD("10.00"), TotalPrice: moneytest.USD("20.00"), Status: "confirmed"},
+			{LineNumber: "002", ProductID: "p2", Quantity: 1, UnitPrice: moneytest.USD("5.00"), TotalPrice: moneytest.USD("5.00"), Status: "confirmed"},
+		},
+	}
+}
+
+// merged describes lines as product:quantity:status:total, with the size
+// of a line in parentheses after its product.
+func merged(lines []*CartItem) string {
+	var parts []string
+	for _, item := range lines {
+		product := item.ProductID
+		if item.Size != "" {
+			product += "(" + item.Size + ")"
+		}
+		status := item.Status
+		if item.BackorderReason != nil {
+			status += "/" + *item.BackorderReason
+		}
+		parts = append(parts, fmt.Sprintf("%s:%d:%s:%s", product, item.Quantity, status, item.TotalPrice))
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestMergeItems(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		guest       []CartItem
+		wantUpdated string
+		wantAdded   string
+	}{
+		{
+			name:        "same product adds to the line",
+			guest:       []CartItem{{ProductID: "p1", Quantity: 1}},
+			wantUpdated: "p1:3:confirmed:30.00",
+		},
+		{
+			name:      "other product is a new line",
+			guest:     []CartItem{{ProductID: "p3", Quantity: 1}},
+			wantAdded: "p3:1:confirmed:7.50",
+		},
+		{
+			name:        "guest lines of one product update the line once",
+			guest:       []CartItem{{ProductID: "p1", Quantity: 1}, {ProductID: "p1", Quantity: 2}},
+			wantUpdated: "p1:5:confirmed:50.00",
+		},
+		{
+			name:      "guest lines of a new product add one line",
+			guest:     []CartItem{{ProductID: "p3", Quantity: 1}, {ProductID: "p3", Quantity: 1}},
+			wantAdded: "p3:2:confirmed:15.00",
+		},
+		{
+			name:      "same product in another size is a new line",
+			guest:     []CartItem{{ProductID: "p1", Quantity: 1, LineAttributes: LineAttributes{Size: "L"}}},
+			wantAdded: "p1(L):1:confirmed:10.00",
+		},
+		{
+			name: "guest lines of one size add one line",
+			guest: []CartItem{
+				{ProductID: "p3", Quantity: 1, LineAttributes: LineAttributes{Size: "M"}},
+				{ProductID: "p3", Quantity: 1, LineAttributes: LineAttributes{Size: "L"}},
+				{ProductID: "p3", Quantity: 1, LineAttributes: LineAttributes{Size: "M"}},
+			},
+			wantAdded: "p3(M):2:confirmed:15.00 p3(L):1:confirmed:7.50",
+		},
+		{
+			name:        "updated lines come first",
+			guest:       []CartItem{{ProductID: "p3", Quantity: 1}, {ProductID: "p2", Quantity: 2}},
+			wantUpdated: "p2:3:confirmed:15.00",
+			wantAdded:   "p3:1:confirmed:7.50",
+		},
+		{
+			name:        "merged quantity over stock backorders the line",
+			guest:       []CartItem{{ProductID: "p2", Quantity: 3}},
+			wantUpdated: "p2:4:backorder/insufficient_stock:0.00",
+		},
+		{
+			name:      "short stock backorders",
+			guest:     []CartItem{{ProductID: "p3", Quantity: 3}},
+			wantAdded: "p3:3:backorder/insufficient_stock:0.00",
+		},
+		{
+			name:      "out of stock backorders",
+			guest:     []CartItem{{ProductID: "p4", Quantity: 1}},
+			wantAdded: "p4:1:backorder/product_out_of_stock:0.00",
+		},
+		{
+			name:      "uncached product awaits validation",
+			guest:     []CartItem{{ProductID: "p9", Quantity: 1, UnitPrice: moneytest.USD("99.00")}},
+			wantAdded: "p9:1:pending_validation:0.00",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := newClaimService(t)
+			s.productCache.Set("p4", ProductEntry{ProductID: "p4", InStock: false, FinalPrice: moneytest.USD("3.00"), Name: "Scarf"}, time.Time{})
+			s.productCache.SetAvailable("p2", 3)
+			s.productCache.SetAvailable("p3", 2)
+
+			updated, added, err := s.mergeItems(context.Background(), &Cart{Currency: "USD", Items: tt.guest}, customerCart(uuid.New()))
+			if err != nil {
+				t.Fatalf("mergeItems() error = %v", err)
+			}
+			if got := merged(updated); got != tt.wantUpdated {
+				t.Errorf("updated = %q, want %q", got, tt.wantUpdated)
+			}
+			if got := merged(added); got != tt.wantAdded {
+				t.Errorf("added = %q, want %q", got, tt.wantAdded)
+			}
+			for _, line := range slices.Concat(updated, added) {
+				if line.ValidationID == nil {
+					t.Errorf("line %s has no validation ID", line.ProductID)
+				}
+			}
+		})
+	}
+}
+
+// fakeClaimDB holds carts in memory and runs the claim's statements
+// against them. Writes apply at once; writes counts them.
+type fakeClaimDB struct {
+	database.Database
+	carts   map[uuid.UUID]*Cart
+	history map[uuid.UUID][]string
+	events  []string
+	writes  int
+}
+
+func newFakeClaimDB(carts ...*Cart) *fakeClaimDB {
+	db := &fakeClaimDB{carts: make(map[uuid.UUID]*Cart), history: make(map[uuid.UUID][]string)}
+	for _, cart := range carts {
+		db.carts[cart.CartID] = cart
+	}
+	return db
+}
+
+func (db *fakeClaimDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (database.Tx, error) {
+	return &fakeClaimTx{db: db}, nil
+}
+
+type fakeClaimTx struct {
+	database.Tx
+	db *fakeClaimDB
+}
+
+func (tx *fakeClaimTx) Commit() error   { return nil }
+func (tx *fakeClaimTx) Rollback() error { return nil }
+
+func (tx *fakeClaimTx) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	switch dest := dest.(type) {
+	case *Cart:
+		cart, ok := tx.db.carts[args[0].(uuid.UUID)]
+		if !ok {
+			return sql.ErrNoRows
+		}
+		*dest = *cart
+		dest.Items = nil
+	case *uuid.UUID:
+		for id, cart := range tx.db.carts {
+			if cart.CustomerID != nil && *cart.CustomerID == args[0].(uuid.UUID) && cart.CurrentStatus == "active" {
+				*dest = id
+				return nil
+			}
+		}
+		return sql.ErrNoRows
+	default:
+		return fmt.Errorf("unexpected get: %s", query)
+	}
+	return nil
+}
+
+func (tx *fakeClaimTx) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	switch dest := dest.(type) {
+	case *[]CartItem:
+		*dest = slices.Clone(tx.db.carts[args[0].(uuid.UUID)].Items)
+	case *[]Address, *[]string:
+	default:
+		return fmt.Errorf("unexpected select: %s", query)
+	}
+	return nil
+}
+
+func (tx *fakeClaimTx) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	switch {
+	case strings.Contains(query, "pg_advisory_xact_lock"), strings.Contains(query, "SELECT 1 FROM carts.Cart"):
+		return nil, nil
+	case strings.Contains(query, "INSERT INTO outbox.outbox"):
+		tx.db.events = append(tx.db.events, args[0].(string))
+	case strings.Contains(query, "SET customer_id = $1,"):
+		cart := tx.db.carts[args[12].(uuid.UUID)]
+		if cart.Version != args[13].(int) {
+			return driver.RowsAffected(0), nil
+		}
+		cart.CustomerID = args[0].(*uuid.UUID)
+		cart.CurrentStatus = args[3].(string)
+		cart.TotalPrice = args[11].(money.Money)
+		cart.Version++
+		tx.db.writes++
+		return driver.RowsAffected(1), nil
+	case strings.Contains(query, "UPDATE carts.Cart SET current_status"):
+		cart := tx.db.carts[args[1].(uuid.UUID)]
+		cart.CurrentStatus = args[0].(string)
+		cart.Version++
+	case strings.Contains(query, "INSERT INTO carts.CartStatus"):
+		cartID := args[0].(uuid.UUID)
+		tx.db.history[cartID] = append(tx.db.history[cartID], args[1].(string))
+	case strings.Contains(query, "SET tax_code = $1, tax = $2"):
+	case strings.Contains(query, "UPDATE carts.CartItem"):
+		cart := tx.db.carts[args[len(args)-2].(uuid.UUID)]
+		for i := range cart.Items {
+			if cart.Items[i].LineNumber == args[len(args)-1].(string) {
+				cart.Items[i].Quantity = args[0].(int)
+				cart.Items[i].TotalPrice = args[3].(money.Money)
+				cart.Items[i].Status = args[4].(string)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unexpected exec: %s", query)
+	}
+	tx.db.writes++
+	return nil, nil
+}
+
+func TestClaimCart(t *testing.T) {
+	t.Parallel()
+
+	customerID, otherID := uuid.New(), uuid.New()
+	guestCart := func(status string, owner *uuid.UUID) *Cart {
+		return &Cart{
+			CartID:        uuid.New(),
+			CustomerID:    owner,
+			Currency:      "USD",
+			CurrentStatus: status,
+			Items:         []CartItem{{LineNumber: "001", ProductID: "p1", Quantity: 1, Status: "confirmed"}},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		guest       *Cart
+		target      *Cart
+		wantErr     error
+		wantOwned   bool
+		wantMerged  bool
+		wantEvents  []string
+		wantHistory []string
+	}{
+		{
+			name:       "guest cart becomes the customer's",
+			guest:      guestCart("active", nil),
+			wantOwned:  true,
+			wantEvents: []string{"cart.claimed"},
+		},
+		{
+			name:      "claimed again by its customer",
+			guest:     guestCart("active", &customerID),
+			wantOwned: true,
+		},
+		{
+			name:    "owned by another customer",
+			guest:   guestCart("active", &otherID),
+			wantErr: ErrCartOwnedByOtherCustomer,
+		},
+		{
+			name:    "checked out guest cart",
+			guest:   guestCart("checked_out", nil),
+			wantErr: ErrCartNotActive,
+		},
+		{
+			name:    "expired guest cart",
+			guest:   guestCart("expired", nil),
+			target:  customerCart(customerID),
+			wantErr: ErrCartNotActive,
+		},
+		{
+			name:        "merged into the customer's active cart",
+			guest:       guestCart("active", nil),
+			target:      customerCart(customerID),
+			wantMerged:  true,
+			wantEvents:  []string{"cart.merged"},
+			wantHistory: []string{"merged"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db := newFakeClaimDB(tt.guest)
+			if tt.target != nil {
+				db.carts[tt.target.CartID] = tt.target
+			}
+			repo := NewCartRepository(db, outbox.NewWriter(db))
+			s := newClaimService(t)
+
+			totals := func(ctx context.Context, cart *Cart) error { return cart.CalculateTotals(ctx, s.pricing) }
+
+			got, err := repo.ClaimCart(context.Background(), tt.guest.CartID.String(), customerID.String(), s.mergeItems, totals)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ClaimCart() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if db.writes != 0 {
+					t.Errorf("ClaimCart() made %d writes, want none", db.writes)
+				}
+				return
+			}
+
+			guest := db.carts[tt.guest.CartID]
+			if tt.wantOwned {
+				if got.CartID != guest.CartID || guest.CustomerID == nil || *guest.CustomerID != customerID {
+					t.Errorf("claimed cart = %s owned by %v, want the guest cart owned by %s", got.CartID, guest.CustomerID, customerID)
+				}
+			}
+			if saved := db.carts[got.CartID]; len(tt.wantEvents) > 0 && saved.Version != 1 {
+				t.Errorf("customer cart version = %d, want 1 after saving its totals", saved.Version)
+			}
+			if tt.wantMerged {
+				if got.CartID != tt.target.CartID {
+					t.Errorf("ClaimCart() returned %s, want the customer's cart %s", got.CartID, tt.target.CartID)
+				}
+				if guest.CurrentStatus != "merged" || guest.CustomerID != nil {
+					t.Errorf("guest cart = %s owned by %v, want merged and unowned", guest.CurrentStatus, guest.CustomerID)
+				}
+				if line := db.carts[tt.target.CartID].Items[0]; line.Quantity != 3 || !line.TotalPrice.Equal(moneytest.USD("30.00")) {
+					t.Errorf("target line 001 = %d for %s, want 3 for 30.00", line.Quantity, line.TotalPrice)
+				}
+			}
+			if !slices.Equal(db.events, tt.wantEvents) {
+				t.Errorf("events = %v, want %v", db.events, tt.wantEvents)
+			}
+			if history := db.history[tt.guest.CartID]; !slices.Equal(history, tt.wantHistory) {
+				t.Errorf("guest history = %v, want %v", history, tt.wantHistory)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package cart
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// expiryBatchSize bounds how many carts one expiry transaction handles.
+const expiryBatchSize = 100
+
+// ExpireCarts expires active carts that have not been updated within the
+// inactive TTL and purges the contact and card data of guest carts expired
+// or merged longer than the purge delay. Both are driven by carts.updated_at. It
+// returns the number of carts expired and purged.
+func (s *CartService) ExpireCarts(ctx context.Context) (expired int, purged int, err error) {
+	now := time.Now()
+
+	expireCutoff := now.Add(-s.config.CartInactiveTTL())
+	for {
+		carts, err := s.repo.ExpireInactiveCarts(ctx, expireCutoff, expiryBatchSize)
+		if err != nil {
+			return expired, purged, fmt.Errorf("failed to expire carts: %w", err)
+		}
+		expired += len(carts)
+		for _, cart := range carts {
+			s.publishCartExpired(cart)
+		}
+		if len(carts) < expiryBatchSize {
+			break
+		}
+	}
+
+	purgeCutoff := now.Add(-s.config.CartGuestPurgeAfter())
+	for {
+		cartIDs, err := s.repo.PurgeExpiredGuestCarts(ctx, purgeCutoff, expiryBatchSize)
+		if err != nil {
+			return expired, purged, fmt.Errorf("failed to purge guest carts: %w", err)
+		}
+		purged += len(cartIDs)
+		if len(cartIDs) < expiryBatchSize {
+			break
+		}
+	}
+
+	if expired > 0 && s.infrastructure.OutboxPublisher != nil {
+		go func() {
+			if err := s.infrastructure.OutboxPublisher.ProcessNow(); err != nil {
+				s.logger.Warn("Failed to trigger immediate outbox processing", "error", err.Error())
+			}
+		}()
+	}
+
+	return expired, purged, nil
+}
+
+// RunExpiry runs ExpireCarts every sweep interval until ctx is cancelled.
+func (s *CartService) RunExpiry(ctx context.Context) {
+	interval := s.config.CartExpirySweepInterval()
+	s.logger.Info("Cart expiry started",
+		"interval", interval.String(),
+		"inactive_ttl", s.config.CartInactiveTTL().String(),
+		"guest_purge_after", s.config.CartGuestPurgeAfter().String(),
+	)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Cart expiry stopped")
+			return
+		case <-ticker.C:
+			expired, purged, err := s.ExpireCarts(ctx)
+			if err != nil {
+				s.logger.Error("Cart expiry failed", "error", err.Error())
+			}
+			if expired > 0 || purged > 0 {
+				s.logger.Info("Cart expiry completed", "expired", expired, "purged", purged)
+			}
+		}
+	}
+}
+
+// publishCartExpired tells a shopper still connected to an expired cart's
+// stream that the cart is no longer active.
+func (s *CartService) publishCartExpired(cart *Cart) {
+	s.logger.Info("Cart expired", "cart_id", cart.CartID.String(), "last_updated", cart.UpdatedAt)
+	if s.infrastructure.SSEProvider == nil {
+		return
+	}
+	s.infrastructure.SSEProvider.GetHub().Publish(cart.CartID.String(), "cart.expired", map[string]interface{}{
+		"cart_id":        cart.CartID.String(),
+		"current_status": cart.CurrentStatus,
+		"last_updated":   cart.UpdatedAt,
+	})
+}
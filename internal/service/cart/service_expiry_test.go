@@ -0,0 +1,156 @@
+package cart
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fakeExpiryRepo records the cutoffs the expiry sweep passes and hands out
+// a fixed number of carts per call.
+type fakeExpiryRepo struct {
+	CartRepository
+	expireBatches []int
+	purgeBatches  []int
+	expireCutoffs []time.Time
+	purgeCutoffs  []time.Time
+}
+
+func (r *fakeExpiryRepo) ExpireInactiveCarts(ctx context.Context, cutoff time.Time, limit int) ([]*Cart, error) {
+	r.expireCutoffs = append(r.expireCutoffs, cutoff)
+	carts := make([]*Cart, batch(r.expireBatches, len(r.expireCutoffs)))
+	for i := range carts {
+		carts[i] = &Cart{CartID: uuid.New(), CurrentStatus: "expired"}
+	}
+	return carts, nil
+}
+
+func (r *fakeExpiryRepo) PurgeExpiredGuestCarts(ctx context.Context, cutoff time.Time, limit int) ([]string, error) {
+	r.purgeCutoffs = append(r.purgeCutoffs, cutoff)
+	return make([]string, batch(r.purgeBatches, len(r.purgeCutoffs))), nil
+}
+
+// batch returns the size of the nth call's batch, zero past the last.
+func batch(sizes []int, n int) int {
+	if n > len(sizes) {
+		return 0
+	}
+	return sizes[n-1]
+}
+
+func TestExpireCarts(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		config        Config
+		expireBatches []int
+		purgeBatches  []int
+		wantTTL       time.Duration
+		wantPurge     time.Duration
+		wantExpired   int
+		wantPurged    int
+		wantCalls     [2]int
+	}{
+		{
+			name:      "defaults",
+			wantTTL:   defaultInactiveTTL,
+			wantPurge: defaultGuestPurgeAfter,
+			wantCalls: [2]int{1, 1},
+		},
+		{
+			name:          "configured durations",
+			config:        Config{InactiveTTL: 2 * time.Hour, GuestPurgeAfter: 24 * time.Hour},
+			expireBatches: []int{3},
+			purgeBatches:  []int{1},
+			wantTTL:       2 * time.Hour,
+			wantPurge:     24 * time.Hour,
+			wantExpired:   3,
+			wantPurged:    1,
+			wantCalls:     [2]int{1, 1},
+		},
+		{
+			name:          "full batches sweep again",
+			expireBatches: []int{expiryBatchSize, 5},
+			purgeBatches:  []int{expiryBatchSize, expiryBatchSize},
+			wantTTL:       defaultInactiveTTL,
+			wantPurge:     defaultGuestPurgeAfter,
+			wantExpired:   expiryBatchSize + 5,
+			wantPurged:    2 * expiryBatchSize,
+			wantCalls:     [2]int{2, 3},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := &fakeExpiryRepo{expireBatches: tt.expireBatches, purgeBatches: tt.purgeBatches}
+			s := NewCartServiceWithRepo(slog.New(slog.DiscardHandler), repo, &CartInfrastructure{}, &tt.config, Pricing{})
+
+			before := time.Now()
+			expired, purged, err := s.ExpireCarts(context.Background())
+			after := time.Now()
+			if err != nil {
+				t.Fatalf("ExpireCarts() error = %v", err)
+			}
+			if expired != tt.wantExpired || purged != tt.wantPurged {
+				t.Errorf("ExpireCarts() = %d expired, %d purged; want %d, %d", expired, purged, tt.wantExpired, tt.wantPurged)
+			}
+			if len(repo.expireCutoffs) != tt.wantCalls[0] || len(repo.purgeCutoffs) != tt.wantCalls[1] {
+				t.Errorf("calls = %d expire, %d purge; want %v", len(repo.expireCutoffs), len(repo.purgeCutoffs), tt.wantCalls)
+			}
+			for _, cutoff := range repo.expireCutoffs {
+				if cutoff.Before(before.Add(-tt.wantTTL)) || cutoff.After(after.Add(-tt.wantTTL)) {
+					t.Errorf("expire cutoff = %v, want %v before now", cutoff, tt.wantTTL)
+				}
+			}
+			for _, cutoff := range repo.purgeCutoffs {
+				if cutoff.Before(before.Add(-tt.wantPurge)) || cutoff.After(after.Add(-tt.wantPurge)) {
+					t.Errorf("purge cutoff = %v, want %v before now", cutoff, tt.wantPurge)
+				}
+			}
+		})
+	}
+}
+
+func TestSetStatusPurge(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		from    string
+		wantErr bool
+	}{
+		{from: "expired"},
+		{from: "merged"},
+		{from: "active", wantErr: true},
+		{from: "checked_out", wantErr: true},
+		{from: "purged", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.from, func(t *testing.T) {
+			t.Parallel()
+
+			cart := &Cart{CartID: uuid.New(), CurrentStatus: tt.from}
+			err := cart.SetStatus("purged")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetStatus(purged) from %s error = %v, wantErr %v", tt.from, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if cart.CurrentStatus != tt.from || len(cart.StatusHistory) != 0 {
+					t.Errorf("cart = %s with %d history entries, want it unchanged", cart.CurrentStatus, len(cart.StatusHistory))
+				}
+				return
+			}
+			if cart.CurrentStatus != "purged" || len(cart.StatusHistory) != 1 || cart.StatusHistory[0].Status != "purged" {
+				t.Errorf("cart = %s with history %+v, want purged recorded", cart.CurrentStatus, cart.StatusHistory)
+			}
+		})
+	}
+}
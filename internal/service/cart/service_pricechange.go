@@ -0,0 +1,155 @@
+package cart
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go-shopping-poc/internal/platform/database"
+	"go-shopping-poc/internal/platform/money"
+)
+
+// ReconcileProductPrice reprices the confirmed lines of productID in active
+// carts whose catalog price differs from price, the product's new final
+// price. Repriced lines take the new price at once and record the price
+// the shopper last saw, and checkout is refused until the shopper
+// acknowledges the change. It returns the number of lines repriced.
+func (s *CartService) ReconcileProductPrice(ctx context.Context, productID string, price money.Money) (int, error) {
+	cartIDs, err := s.repo.ListActiveCartsWithProduct(ctx, productID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list carts with product: %w", err)
+	}
+
+	repriced := 0
+	for _, cartID := range cartIDs {
+		lines, err := s.repriceProduct(ctx, cartID, productID, price)
+		// Checked out or deleted since it was listed
+		if errors.Is(err, ErrCartNotFound) {
+			continue
+		}
+		if err != nil {
+			return repriced, fmt.Errorf("failed to reprice product %s in cart %s: %w", productID, cartID, err)
+		}
+		repriced += lines
+	}
+	return repriced, nil
+}
+
+// repriceProduct reprices the lines of productID in one cart, saves them
+// with the cart totals and tells the shopper. It returns the number of
+// lines repriced.
+func (s *CartService) repriceProduct(ctx context.Context, cartID, productID string, price money.Money) (int, error) {
+	var cart *Cart
+	var changed []CartItem
+	err := RetryOnVersionConflict(func() error {
+		var err error
+		cart, err = s.repo.GetCartByID(ctx, cartID)
+		if err != nil {
+			return err
+		}
+
+		changed = changed[:0]
+		if cart.CurrentStatus != "active" {
+			return nil
+		}
+
+		now := time.Now()
+		for i := range cart.Items {
+			item := &cart.Items[i]
+			if item.ProductID != productID || !item.IsConfirmed() {
+				continue
+			}
+			if item.CatalogPrice(cart.Currency).Equal(price) {
+				continue
+			}
+			unitPrice := item.UnitPrice.In(cart.Currency)
+			if err := s.pricing.convertItem(ctx, item, price, cart.Currency); err != nil {
+				return err
+			}
+			item.CalculateLineTotal()
+			item.recordPriceChange(unitPrice, now)
+			changed = append(changed, *item)
+		}
+		if len(changed) == 0 {
+			return nil
+		}
+
+		return s.saveCart(ctx, cart, func(tx database.Tx) error {
+			if err := s.repo.UpdateItemPricesTx(ctx, tx, cart); err != nil {
+				return fmt.Errorf("failed to reprice items: %w", err)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, item := range changed {
+		previous := item.UnitPrice
+		if item.PreviousUnitPrice != nil {
+			previous = *item.PreviousUnitPrice
+		}
+		if s.infrastructure.SSEProvider != nil {
+			s.infrastructure.SSEProvider.GetHub().Publish(cartID, "cart.item.price_changed", map[string]interface{}{
+				"line_number":         item.LineNumber,
+				"product_id":          item.ProductID,
+				"previous_unit_price": previous,
+				"unit_price":          item.UnitPrice,
+				"total_price":         item.TotalPrice,
+				"acknowledged":        !item.HasPriceChange(),
+			})
+		}
+
+		s.logger.Info("Cart item repriced after product price change",
+			"cart_id", cartID,
+			"line_number", item.LineNumber,
+			"product_id", item.ProductID,
+			"previous_unit_price", previous.String(),
+			"unit_price", item.UnitPrice.String(),
+			"currency", cart.Currency,
+		)
+	}
+	return len(changed), nil
+}
+
+// AcknowledgePriceChanges records that the shopper has seen the price
+// changes of the cart's lines, which allows checkout again.
+func (s *CartService) AcknowledgePriceChanges(ctx context.Context, cartID string) (*Cart, error) {
+	cart, err := s.repo.GetCartByID(ctx, cartID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cart: %w", err)
+	}
+
+	if cart.CurrentStatus != "active" {
+		return nil, fmt.Errorf("cannot acknowledge price changes of non-active cart: %w", ErrCartNotActive)
+	}
+	if err := checkVersion(ctx, cart); err != nil {
+		return nil, err
+	}
+
+	acknowledged := 0
+	for i := range cart.Items {
+		if cart.Items[i].HasPriceChange() {
+			cart.Items[i].clearPriceChange()
+			acknowledged++
+		}
+	}
+	if acknowledged == 0 {
+		return cart, nil
+	}
+
+	err = s.saveCart(ctx, cart, func(tx database.Tx) error {
+		if err := s.repo.UpdateItemPricesTx(ctx, tx, cart); err != nil {
+			return fmt.Errorf("failed to acknowledge price changes: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Cart price changes acknowledged", "cart_id", cartID, "lines", acknowledged)
+	return cart, nil
+}
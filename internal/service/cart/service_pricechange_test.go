@@ -0,0 +1,258 @@
+package cart
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"go-shopping-poc/internal/platform/database"
+	"go-shopping-poc/internal/platform/money/moneytest"
+)
+
+// fakePriceRepo keeps carts by ID. Saving item prices stores a copy of the
+// cart, so the tests see only what the service saved.
+type fakePriceRepo struct {
+	CartRepository
+	carts map[string]*Cart
+	saves int
+}
+
+func (r *fakePriceRepo) ListActiveCartsWithProduct(ctx context.Context, productID string) ([]string, error) {
+	// A cart deleted since it was listed
+	ids := []string{uuid.NewString()}
+	for id, cart := range r.carts {
+		for _, item := range cart.Items {
+			if item.ProductID == productID {
+				ids = append(ids, id)
+				break
+			}
+		}
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+func (r *fakePriceRepo) GetCartByID(ctx context.Context, cartID string) (*Cart, error) {
+	stored, ok := r.carts[cartID]
+	if !ok {
+		return nil, ErrCartNotFound
+	}
+	cart := *stored
+	cart.Items = slices.Clone(stored.Items)
+	return &cart, nil
+}
+
+func (r *fakePriceRepo) UpdateItemPricesTx(ctx context.Context, tx database.Tx, cart *Cart) error {
+	stored := *cart
+	stored.Items = slices.Clone(cart.Items)
+	r.carts[cart.CartID.String()] = &stored
+	r.saves++
+	return nil
+}
+
+func (r *fakePriceRepo) UpdateCartTx(ctx context.Context, tx database.Tx, cart *Cart) error {
+	return nil
+}
+
+// fakePriceDB begins transactions that do nothing.
+type fakePriceDB struct {
+	database.Database
+}
+
+func (db fakePriceDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (database.Tx, error) {
+	return fakePriceTx{}, nil
+}
+
+type fakePriceTx struct {
+	database.Tx
+}
+
+func (fakePriceTx) Commit() error   { return nil }
+func (fakePriceTx) Rollback() error { return nil }
+
+func newPriceService(t *testing.T, repo *fakePriceRepo) *CartService {
+	t.Helper()
+
+	s := newValidationService(t, repo)
+	s.infrastructure.Database = fakePriceDB{}
+	return s
+}
+
+// pricedCart returns a USD cart in status with one line of quantity units
+// of productID at price.
+func pricedCart(status, productID, itemStatus string, quantity int, price string) *Cart {
+	item := CartItem{LineNumber: "001", ProductID: productID, Quantity: quantity, UnitPrice: moneytest.USD(price), Status: itemStatus}
+	item.CalculateLineTotal()
+	return &Cart{CartID: uuid.New(), Currency: "USD", CurrentStatus: status, Items: []CartItem{item}}
+}
+
+// priceChange describes line 001 of a stored cart as unit/total, followed
+// by "was <previous>" while its price change is unacknowledged.
+func priceChange(cart *Cart) string {
+	item := cart.Items[0]
+	got := item.UnitPrice.String() + "/" + item.TotalPrice.String()
+	if item.HasPriceChange() {
+		got += " was " + item.PreviousUnitPrice.String()
+	}
+	return got
+}
+
+func TestRecordPriceChange(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		previous string
+		from     string
+		to       string
+		want     string
+	}{
+		{name: "first change records the price before", from: "10.00", to: "12.00", want: "12.00 was 10.00"},
+		{name: "further change keeps the first unacknowledged price", previous: "10.00", from: "12.00", to: "15.00", want: "15.00 was 10.00"},
+		{name: "return to the first price drops the change", previous: "10.00", from: "12.00", to: "10.00", want: "10.00"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			item := CartItem{UnitPrice: moneytest.USD(tt.to)}
+			if tt.previous != "" {
+				previous := moneytest.USD(tt.previous)
+				item.PreviousUnitPrice = &previous
+				item.PriceChangedAt = &at
+			}
+			item.recordPriceChange(moneytest.USD(tt.from), at.Add(time.Hour))
+
+			got := item.UnitPrice.String()
+			if item.HasPriceChange() {
+				got += " was " + item.PreviousUnitPrice.String()
+			}
+			if got != tt.want {
+				t.Errorf("recordPriceChange() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReconcileProductPrice(t *testing.T) {
+	t.Parallel()
+
+	active := pricedCart("active", "p1", "confirmed", 2, "10.00")
+	pending := pricedCart("active", "p1", "pending_validation", 1, "10.00")
+	checkedOut := pricedCart("checked_out", "p1", "confirmed", 1, "10.00")
+	repo := &fakePriceRepo{carts: map[string]*Cart{}}
+	for _, cart := range []*Cart{active, pending, checkedOut} {
+		repo.carts[cart.CartID.String()] = cart
+	}
+	s := newPriceService(t, repo)
+	ctx := context.Background()
+
+	steps := []struct {
+		price        string
+		wantRepriced int
+		want         string
+	}{
+		{price: "12.00", wantRepriced: 1, want: "12.00/24.00 was 10.00"},
+		{price: "12.00", wantRepriced: 0, want: "12.00/24.00 was 10.00"},
+		{price: "15.00", wantRepriced: 1, want: "15.00/30.00 was 10.00"},
+		{price: "10.00", wantRepriced: 1, want: "10.00/20.00"},
+	}
+	for _, step := range steps {
+		repriced, err := s.ReconcileProductPrice(ctx, "p1", moneytest.USD(step.price))
+		if err != nil {
+			t.Fatalf("ReconcileProductPrice(%s) error = %v", step.price, err)
+		}
+		if repriced != step.wantRepriced {
+			t.Errorf("ReconcileProductPrice(%s) = %d, want %d", step.price, repriced, step.wantRepriced)
+		}
+		if got := priceChange(repo.carts[active.CartID.String()]); got != step.want {
+			t.Errorf("after ReconcileProductPrice(%s) active cart = %q, want %q", step.price, got, step.want)
+		}
+	}
+
+	for name, cart := range map[string]*Cart{"pending line": pending, "checked out cart": checkedOut} {
+		if got := priceChange(repo.carts[cart.CartID.String()]); got != "10.00/10.00" {
+			t.Errorf("%s = %q, want it left at 10.00/10.00", name, got)
+		}
+	}
+}
+
+func TestAcknowledgePriceChanges(t *testing.T) {
+	t.Parallel()
+
+	changed := pricedCart("active", "p1", "confirmed", 2, "12.00")
+	changed.Items[0].recordPriceChange(moneytest.USD("10.00"), time.Now())
+	unchanged := pricedCart("active", "p1", "confirmed", 1, "10.00")
+	checkedOut := pricedCart("checked_out", "p1", "confirmed", 1, "12.00")
+	checkedOut.Items[0].recordPriceChange(moneytest.USD("10.00"), time.Now())
+
+	tests := []struct {
+		name      string
+		cart      *Cart
+		wantErr   error
+		wantSaves int
+	}{
+		{name: "price change is acknowledged", cart: changed, wantSaves: 1},
+		{name: "cart without changes is not saved", cart: unchanged, wantSaves: 0},
+		{name: "non-active cart is refused", cart: checkedOut, wantErr: ErrCartNotActive},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := &fakePriceRepo{carts: map[string]*Cart{tt.cart.CartID.String(): tt.cart}}
+			s := newPriceService(t, repo)
+
+			cart, err := s.AcknowledgePriceChanges(context.Background(), tt.cart.CartID.String())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AcknowledgePriceChanges() error = %v, want %v", err, tt.wantErr)
+			}
+			if repo.saves != tt.wantSaves {
+				t.Errorf("saves = %d, want %d", repo.saves, tt.wantSaves)
+			}
+			if err != nil {
+				return
+			}
+			if cart.Items[0].HasPriceChange() {
+				t.Error("returned line still has a price change")
+			}
+			if stored := repo.carts[tt.cart.CartID.String()]; stored.Items[0].HasPriceChange() {
+				t.Error("stored line still has a price change")
+			}
+		})
+	}
+}
+
+func TestCanCheckoutRefusesUnacknowledgedPriceChange(t *testing.T) {
+	t.Parallel()
+
+	cart := pricedCart("active", "p1", "confirmed", 1, "12.00")
+	cart.Contact = &Contact{Email: "shopper@example.com"}
+	cart.CreditCard = &CreditCard{CardNumber: "4111111111111111"}
+	cart.ShippingMethod = "standard"
+
+	if err := cart.CanCheckout(); err != nil {
+		t.Fatalf("CanCheckout() error = %v before the price change", err)
+	}
+
+	cart.Items[0].recordPriceChange(moneytest.USD("10.00"), time.Now())
+	if err := cart.CanCheckout(); !errors.Is(err, ErrCartPriceChangesUnacknowledged) {
+		t.Errorf("CanCheckout() error = %v, want %v", err, ErrCartPriceChangesUnacknowledged)
+	}
+
+	cart.Items[0].clearPriceChange()
+	if err := cart.CanCheckout(); err != nil {
+		t.Errorf("CanCheckout() error = %v after acknowledging", err)
+	}
+}
@@ -0,0 +1,83 @@
+package cart
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	events "go-shopping-poc/internal/contracts/events"
+	"go-shopping-poc/internal/platform/event/bus"
+	"go-shopping-poc/internal/platform/event/bus/kafka"
+
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+// BootstrapProductCache replays historical ProductEvents from Kafka into
+// the product cache, so that items of known products are confirmed without
+// the validation round trip right after a restart. Product events are
+// passed to apply, the handler that keeps the cache current from live
+// events; inventory events only record the stock they report. The cache is
+// marked warm when the replay ends, even if it failed, so that the service
+// does not stay unready while Kafka is down.
+func (s *CartService) BootstrapProductCache(ctx context.Context, apply bus.HandlerFunc[events.ProductEvent]) error {
+	start := time.Now()
+	defer s.productCache.MarkWarm()
+
+	eb, ok := s.infrastructure.EventBus.(*kafka.EventBus)
+	if !ok {
+		return fmt.Errorf("event bus does not support replay")
+	}
+
+	topic := events.ProductEvent{}.Topic()
+	messages, err := eb.ReplayTopic(ctx, topic, kafka.DefaultReplayOptions())
+	if err != nil {
+		return fmt.Errorf("failed to replay %s: %w", topic, err)
+	}
+
+	s.replayProductEvents(ctx, messages, apply)
+
+	s.logger.Info("Product cache bootstrapped",
+		"entries", s.productCache.Count(),
+		"messages_replayed", len(messages),
+		"duration", time.Since(start).String(),
+	)
+	return nil
+}
+
+// replayProductEvents applies replayed ProductEvents messages to the product
+// cache in log order, so that the last event of a product wins. Messages
+// that cannot be decoded are skipped.
+func (s *CartService) replayProductEvents(ctx context.Context, messages []kafkago.Message, apply bus.HandlerFunc[events.ProductEvent]) {
+	for _, msg := range messages {
+		var envelope struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(msg.Value, &envelope); err != nil {
+			s.logger.Warn("Failed to unmarshal product event during bootstrap", "error", err)
+			continue
+		}
+
+		if strings.HasPrefix(envelope.Type, "inventory.") {
+			evt, err := events.InventoryEventFactory{}.FromJSON(msg.Value)
+			if err != nil {
+				s.logger.Warn("Failed to unmarshal inventory event during bootstrap", "error", err)
+				continue
+			}
+			for _, line := range evt.Data.Lines {
+				s.productCache.SetAvailable(line.ProductID, line.Available)
+			}
+			continue
+		}
+
+		evt, err := events.ProductEventFactory{}.FromJSON(msg.Value)
+		if err != nil {
+			s.logger.Warn("Failed to unmarshal product event during bootstrap", "error", err)
+			continue
+		}
+		if err := apply(ctx, evt); err != nil {
+			s.logger.Warn("Failed to apply product event during bootstrap", "event_type", evt.EventType, "error", err)
+		}
+	}
+}
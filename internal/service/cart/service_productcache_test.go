@@ -0,0 +1,142 @@
+package cart
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	kafkago "github.com/segmentio/kafka-go"
+
+	events "go-shopping-poc/internal/contracts/events"
+)
+
+// replayMessage encodes event as a replayed ProductEvents message.
+func replayMessage(t *testing.T, event any) kafkago.Message {
+	t.Helper()
+
+	value, err := json.Marshal(event)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	return kafkago.Message{Value: value}
+}
+
+func newProductCacheService() *CartService {
+	return NewCartServiceWithRepo(slog.New(slog.DiscardHandler), nil, &CartInfrastructure{}, &Config{}, Pricing{})
+}
+
+func TestReplayProductEvents(t *testing.T) {
+	t.Parallel()
+
+	s := newProductCacheService()
+
+	// apply stands in for the product event handler: it stores the name of
+	// created and updated products and removes deleted ones.
+	var applied []string
+	apply := func(_ context.Context, evt events.ProductEvent) error {
+		id := evt.EventPayload.ProductID
+		applied = append(applied, string(evt.EventType)+"/"+id)
+		switch evt.EventType {
+		case events.ProductDeleted:
+			s.productCache.Delete(id)
+		case events.ProductUnavailable:
+			return errors.New("unavailable events fail to apply")
+		default:
+			s.productCache.Set(id, ProductEntry{ProductID: id, Name: evt.EventPayload.Details["name"]}, evt.Timestamp)
+		}
+		return nil
+	}
+
+	messages := []kafkago.Message{
+		replayMessage(t, events.NewProductCreatedEvent("p1", map[string]string{"name": "Shirt"})),
+		replayMessage(t, events.NewInventoryReservedEvent("c1", time.Now(), []events.InventoryLine{{ProductID: "p1", Quantity: 2, Available: 8}})),
+		{Value: []byte("not json")},
+		replayMessage(t, events.NewProductUnavailableEvent("p3", "out of stock", "c1", "001", "v1")),
+		replayMessage(t, events.NewProductCreatedEvent("p2", map[string]string{"name": "Socks"})),
+		replayMessage(t, events.NewProductUpdatedEvent("p1", map[string]string{"name": "Oxford shirt"})),
+		replayMessage(t, events.NewInventoryReleasedEvent("c1", "", "expired", []events.InventoryLine{{ProductID: "p1", Quantity: 2, Available: 10}})),
+		replayMessage(t, events.NewProductDeletedEvent("p2", nil)),
+	}
+
+	s.replayProductEvents(context.Background(), messages, apply)
+
+	wantApplied := "product.created/p1 product.unavailable/p3 product.created/p2 product.updated/p1 product.deleted/p2"
+	if got := strings.Join(applied, " "); got != wantApplied {
+		t.Errorf("applied = %q, want %q", got, wantApplied)
+	}
+	if entry, ok := s.productCache.Get("p1"); !ok || entry.Name != "Oxford shirt" {
+		t.Errorf("Get(p1) = %+v, %v, want the updated entry", entry, ok)
+	}
+	if _, ok := s.productCache.Get("p2"); ok {
+		t.Error("Get(p2) found the deleted product")
+	}
+	if available, ok := s.productCache.Available("p1"); !ok || available != 10 {
+		t.Errorf("Available(p1) = %d, %v, want 10 from the last inventory event", available, ok)
+	}
+}
+
+func TestBootstrapProductCacheMarksWarmOnFailure(t *testing.T) {
+	t.Parallel()
+
+	s := newProductCacheService()
+	if s.productCache.IsWarm() {
+		t.Fatal("IsWarm() = true before bootstrap")
+	}
+
+	apply := func(context.Context, events.ProductEvent) error { return nil }
+	if err := s.BootstrapProductCache(context.Background(), apply); err == nil {
+		t.Fatal("BootstrapProductCache() error = nil without a Kafka event bus")
+	}
+	if !s.productCache.IsWarm() {
+		t.Error("IsWarm() = false after a failed bootstrap")
+	}
+}
+
+func TestReady(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		warm       bool
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "cold cache is unready", warm: false, wantStatus: http.StatusServiceUnavailable, wantBody: "warming"},
+		{name: "warm cache is ready", warm: true, wantStatus: http.StatusOK, wantBody: "ready"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := newProductCacheService()
+			if tt.warm {
+				s.productCache.MarkWarm()
+			}
+			h := NewCartHandler(slog.New(slog.DiscardHandler), s)
+
+			rec := httptest.NewRecorder()
+			h.Ready(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			var body struct {
+				Status string `json:"status"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("json.Unmarshal() error = %v", err)
+			}
+			if body.Status != tt.wantBody {
+				t.Errorf("body status = %q, want %q", body.Status, tt.wantBody)
+			}
+		})
+	}
+}
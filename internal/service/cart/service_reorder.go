@@ -0,0 +1,174 @@
+package cart
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	events "go-shopping-poc/internal/contracts/events"
+	platformerrors "go-shopping-poc/internal/platform/errors"
+)
+
+// Reorder adds the items of a past order to the active cart of a customer,
+// creating the cart in currency when the customer has none. Each item goes
+// through AddItem like one added by the shopper; a product already
+// confirmed in the cart has its quantity extended instead. It returns the
+// cart and what became of each item.
+//
+// The outcome is recorded under requestID, and a request already processed
+// returns its recorded outcome without adding the items again.
+func (s *CartService) Reorder(ctx context.Context, requestID string, customerID string, currency string, items []events.ReorderItem) (*Cart, []events.ReorderLineResult, error) {
+	processed, err := s.repo.GetReorderRequest(ctx, requestID)
+	if err == nil {
+		s.logger.Info("Reorder request already processed",
+			"request_id", requestID,
+			"cart_id", processed.CartID,
+		)
+		cart, err := s.repo.GetCartByID(ctx, processed.CartID.String())
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get cart: %w", err)
+		}
+		return cart, processed.Lines, nil
+	}
+	if !errors.Is(err, ErrReorderRequestNotFound) {
+		return nil, nil, fmt.Errorf("failed to get reorder request: %w", err)
+	}
+
+	cart, err := s.reorderCart(ctx, customerID, currency)
+	if err != nil {
+		return nil, nil, err
+	}
+	cartID := cart.CartID.String()
+
+	results := make([]events.ReorderLineResult, 0, len(items))
+	for _, item := range items {
+		result := events.ReorderLineResult{
+			ProductID:   item.ProductID,
+			ProductName: item.ProductName,
+			Quantity:    item.Quantity,
+		}
+		s.reorderItem(ctx, cartID, item, &result)
+		results = append(results, result)
+	}
+
+	if err := s.repo.SaveReorderRequest(ctx, &ReorderRequest{RequestID: requestID, CartID: cart.CartID, Lines: results}); err != nil {
+		return nil, nil, fmt.Errorf("failed to record reorder request: %w", err)
+	}
+
+	if cart, err = s.repo.GetCartByID(ctx, cartID); err != nil {
+		return nil, nil, fmt.Errorf("failed to get cart: %w", err)
+	}
+
+	s.logger.Info("Order items reordered",
+		"cart_id", cartID,
+		"customer_id", customerID,
+		"items", len(items),
+	)
+	return cart, results, nil
+}
+
+// reorderCart returns the active cart of a customer, creating one when
+// there is none. A currency the cart cannot be priced in falls back to
+// DefaultCurrency.
+func (s *CartService) reorderCart(ctx context.Context, customerID string, currency string) (*Cart, error) {
+	cart, err := s.repo.GetActiveCartByCustomerID(ctx, customerID)
+	if err == nil {
+		return cart, nil
+	}
+	if !errors.Is(err, ErrCartNotFound) {
+		if errors.Is(err, ErrInvalidUUID) {
+			return nil, ErrInvalidCustomerID
+		}
+		return nil, fmt.Errorf("failed to get active cart: %w", err)
+	}
+
+	if _, err := s.presentmentCurrency(ctx, currency); err != nil {
+		s.logger.Warn("Reorder currency not supported, using default",
+			"customer_id", customerID,
+			"currency", currency,
+			"error", err.Error(),
+		)
+		currency = ""
+	}
+	return s.CreateCart(ctx, &customerID, currency)
+}
+
+// reorderItem adds one order item to cartID and records the outcome in
+// result.
+func (s *CartService) reorderItem(ctx context.Context, cartID string, item events.ReorderItem, result *events.ReorderLineResult) {
+	attrs := LineAttributes{Size: item.Size, Color: item.Color, GiftMessage: item.GiftMessage}
+	var added *CartItem
+	err := RetryOnVersionConflict(func() error {
+		var err error
+		added, err = s.AddItem(ctx, cartID, item.ProductID, item.Quantity, item.ImageURL, attrs)
+		if errors.Is(err, ErrItemAlreadyInCart) {
+			added, err = s.extendItem(ctx, cartID, item.ProductID, attrs, item.Quantity)
+		}
+		return err
+	})
+
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrInvalidQuantity):
+		result.Status, result.Reason = events.ReorderLineUnavailable, "invalid_quantity"
+		return
+	case errors.Is(err, ErrQuantityNotInStock):
+		result.Status, result.Reason = events.ReorderLineUnavailable, "insufficient_stock"
+		return
+	case errors.As(err, new(*platformerrors.ValidationError)):
+		result.Status, result.Reason = events.ReorderLineUnavailable, "variant_unavailable"
+		return
+	case errors.Is(err, ErrItemValidationInProgress):
+		result.Status, result.Reason = events.ReorderLineUnavailable, "validation_in_progress"
+		return
+	default:
+		s.logger.Warn("Reorder item failed",
+			"cart_id", cartID,
+			"product_id", item.ProductID,
+			"error", err.Error(),
+		)
+		result.Status, result.Reason = events.ReorderLineUnavailable, "add_failed"
+		return
+	}
+
+	result.LineNumber = added.LineNumber
+	if added.ProductName != "" {
+		result.ProductName = added.ProductName
+	}
+	switch {
+	case added.IsConfirmed():
+		result.Status = events.ReorderLineAdded
+	case added.IsBackorder():
+		result.Status = events.ReorderLineBackorder
+		if added.BackorderReason != nil {
+			result.Reason = *added.BackorderReason
+		}
+	default:
+		result.Status = events.ReorderLinePending
+	}
+}
+
+// extendItem adds quantity to the confirmed line of productID in cartID in
+// the variant selected by attrs. The line is left as it is, with
+// ErrQuantityNotInStock, when the product cannot supply the grown quantity.
+func (s *CartService) extendItem(ctx context.Context, cartID string, productID string, attrs LineAttributes, quantity int) (*CartItem, error) {
+	cart, err := s.repo.GetCartByID(ctx, cartID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cart: %w", err)
+	}
+	for i := range cart.Items {
+		item := &cart.Items[i]
+		if item.ProductID != productID || !item.IsConfirmed() || !item.SameVariant(attrs) {
+			continue
+		}
+		if !s.productCache.CanSupply(productID, item.Quantity+quantity) {
+			return nil, fmt.Errorf("%w: line %s: %d requested", ErrQuantityNotInStock, item.LineNumber, item.Quantity+quantity)
+		}
+		item.Quantity += quantity
+		if err := s.UpdateItemQuantity(ctx, cartID, item.LineNumber, item.Quantity); err != nil {
+			return nil, err
+		}
+		return item, nil
+	}
+	return nil, ErrCartItemNotFound
+}
@@ -0,0 +1,124 @@
+package cart
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	events "go-shopping-poc/internal/contracts/events"
+	"go-shopping-poc/internal/platform/database"
+	"go-shopping-poc/internal/platform/money/moneytest"
+)
+
+// fakeReorderRepo keeps the customer's active cart and the recorded reorder
+// requests. Items added go straight to the stored cart.
+type fakeReorderRepo struct {
+	CartRepository
+	cart     *Cart
+	requests map[string]*ReorderRequest
+	added    int
+}
+
+func (r *fakeReorderRepo) GetReorderRequest(ctx context.Context, requestID string) (*ReorderRequest, error) {
+	req, ok := r.requests[requestID]
+	if !ok {
+		return nil, ErrReorderRequestNotFound
+	}
+	return req, nil
+}
+
+func (r *fakeReorderRepo) SaveReorderRequest(ctx context.Context, req *ReorderRequest) error {
+	r.requests[req.RequestID] = req
+	return nil
+}
+
+func (r *fakeReorderRepo) GetActiveCartByCustomerID(ctx context.Context, customerID string) (*Cart, error) {
+	return r.GetCartByID(ctx, r.cart.CartID.String())
+}
+
+func (r *fakeReorderRepo) GetCartByID(ctx context.Context, cartID string) (*Cart, error) {
+	cart := *r.cart
+	cart.Items = slices.Clone(r.cart.Items)
+	return &cart, nil
+}
+
+func (r *fakeReorderRepo) GetItemByProductID(ctx context.Context, cartID, productID string) (*CartItem, error) {
+	for _, item := range r.cart.Items {
+		if item.ProductID == productID {
+			return &item, nil
+		}
+	}
+	return nil, ErrCartItemNotFound
+}
+
+func (r *fakeReorderRepo) AddItemTx(ctx context.Context, tx database.Tx, cartID string, item *CartItem) error {
+	item.LineNumber = fmt.Sprintf("%03d", len(r.cart.Items)+1)
+	r.cart.Items = append(r.cart.Items, *item)
+	r.added++
+	return nil
+}
+
+func (r *fakeReorderRepo) UpdateCartTx(ctx context.Context, tx database.Tx, cart *Cart) error {
+	return nil
+}
+
+// reorderLines describes lines as product:status[/reason].
+func reorderLines(lines []events.ReorderLineResult) string {
+	var parts []string
+	for _, line := range lines {
+		part := line.ProductID + ":" + line.Status
+		if line.Reason != "" {
+			part += "/" + line.Reason
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestReorderReplaysRedeliveredRequest(t *testing.T) {
+	t.Parallel()
+
+	customerID := uuid.NewString()
+	cart := pricedCart("active", "p3", "confirmed", 2, "4.00")
+	repo := &fakeReorderRepo{cart: cart, requests: map[string]*ReorderRequest{}}
+	s := newValidationService(t, repo)
+	s.infrastructure.Database = fakePriceDB{}
+	s.productCache.Set("p1", ProductEntry{ProductID: "p1", InStock: true, FinalPrice: moneytest.USD("10.00"), Name: "Shirt"}, time.Time{})
+	s.productCache.Set("p3", ProductEntry{ProductID: "p3", InStock: true, FinalPrice: moneytest.USD("4.00"), Name: "Cap"}, time.Time{})
+	s.productCache.SetAvailable("p3", 2)
+
+	items := []events.ReorderItem{
+		{ProductID: "p1", Quantity: 1},
+		{ProductID: "p2", Quantity: 0},
+		{ProductID: "p3", Quantity: 1},
+	}
+	const want = "p1:added p2:unavailable/invalid_quantity p3:unavailable/insufficient_stock"
+
+	for _, delivery := range []string{"first", "redelivered"} {
+		got, lines, err := s.Reorder(context.Background(), "reorder-1", customerID, "USD", items)
+		if err != nil {
+			t.Fatalf("%s Reorder() error = %v", delivery, err)
+		}
+		if got.CartID != cart.CartID {
+			t.Errorf("%s Reorder() cart = %s, want %s", delivery, got.CartID, cart.CartID)
+		}
+		if desc := reorderLines(lines); desc != want {
+			t.Errorf("%s Reorder() lines = %q, want %q", delivery, desc, want)
+		}
+	}
+
+	if repo.added != 1 {
+		t.Errorf("items added = %d, want 1 across both deliveries", repo.added)
+	}
+	if n := len(repo.cart.Items); n != 2 {
+		t.Errorf("cart has %d lines, want 2", n)
+	}
+	if line := repo.cart.Items[0]; line.Quantity != 2 {
+		t.Errorf("p3 quantity = %d, want it left at 2 for want of stock", line.Quantity)
+	}
+}
@@ -0,0 +1,213 @@
+package cart
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go-shopping-poc/internal/platform/outbox"
+)
+
+// sagaBatchSize bounds how many timed out checkouts one query returns.
+const sagaBatchSize = 100
+
+// SSEPublisher publishes server-sent events to the subscribers of a
+// stream. *sse.Hub implements it.
+type SSEPublisher interface {
+	Publish(streamID string, event string, data interface{})
+}
+
+// CheckoutSagas advances checkout sagas as the order and product services
+// report on a checkout, and keeps the shopper posted over SSE.
+type CheckoutSagas struct {
+	store     SagaStore
+	sse       SSEPublisher
+	publisher *outbox.Publisher
+	logger    *slog.Logger
+}
+
+// NewCheckoutSagas creates checkout sagas kept in store. sse and
+// publisher may be nil.
+func NewCheckoutSagas(store SagaStore, sse SSEPublisher, publisher *outbox.Publisher, logger *slog.Logger) *CheckoutSagas {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &CheckoutSagas{
+		store:     store,
+		sse:       sse,
+		publisher: publisher,
+		logger:    logger.With("component", "checkout_saga"),
+	}
+}
+
+// newCheckoutSagas creates the checkout sagas of the cart service.
+func newCheckoutSagas(store SagaStore, infrastructure *CartInfrastructure, logger *slog.Logger) *CheckoutSagas {
+	var publisher SSEPublisher
+	if infrastructure.SSEProvider != nil {
+		publisher = infrastructure.SSEProvider.GetHub()
+	}
+	return NewCheckoutSagas(store, publisher, infrastructure.OutboxPublisher, logger)
+}
+
+// Started tells the shopper that the checkout of cart is under way.
+func (c *CheckoutSagas) Started(cart *Cart, deadline time.Time) {
+	c.publish(cart.CartID.String(), "checkout.started", map[string]interface{}{
+		"cart_id":  cart.CartID.String(),
+		"status":   cart.CurrentStatus,
+		"deadline": deadline,
+	})
+}
+
+// OrderCreated records the order created for checkout attempt of a cart.
+func (c *CheckoutSagas) OrderCreated(ctx context.Context, cartID string, attempt int, orderID string) (*SagaResult, error) {
+	return c.advance(ctx, cartID, "order.created", func(saga *CheckoutSaga) SagaAction {
+		return saga.OrderCreated(attempt, orderID)
+	})
+}
+
+// OrderRejected records that no order could be created for checkout
+// attempt of a cart.
+func (c *CheckoutSagas) OrderRejected(ctx context.Context, cartID string, attempt int, reason string) (*SagaResult, error) {
+	return c.advance(ctx, cartID, "order.rejected", func(saga *CheckoutSaga) SagaAction {
+		return saga.OrderRejected(attempt, reason)
+	})
+}
+
+// InventoryReserved records that the stock of checkout attempt of a cart
+// is reserved.
+func (c *CheckoutSagas) InventoryReserved(ctx context.Context, cartID string, attempt int) (*SagaResult, error) {
+	return c.advance(ctx, cartID, "inventory.reserved", func(saga *CheckoutSaga) SagaAction {
+		return saga.InventoryReserved(attempt)
+	})
+}
+
+// InventoryFailed records that the stock of checkout attempt of a cart
+// could not be reserved.
+func (c *CheckoutSagas) InventoryFailed(ctx context.Context, cartID string, attempt int, reason string) (*SagaResult, error) {
+	return c.advance(ctx, cartID, "inventory.reservation_failed", func(saga *CheckoutSaga) SagaAction {
+		return saga.InventoryFailed(attempt, reason)
+	})
+}
+
+// ExpireCheckouts rolls back the pending checkouts whose deadline passed
+// and returns how many were rolled back.
+func (c *CheckoutSagas) ExpireCheckouts(ctx context.Context) (int, error) {
+	expired := 0
+	for {
+		now := time.Now()
+		sagas, err := c.store.ListExpiredCheckoutSagas(ctx, now, sagaBatchSize)
+		if err != nil {
+			return expired, fmt.Errorf("failed to list expired checkouts: %w", err)
+		}
+		for _, saga := range sagas {
+			cartID := saga.CartID.String()
+			result, err := c.advance(ctx, cartID, "timeout", func(saga *CheckoutSaga) SagaAction {
+				return saga.TimedOut(now)
+			})
+			// Cart deleted since it was listed
+			if errors.Is(err, ErrCheckoutSagaNotFound) || errors.Is(err, ErrCartNotFound) {
+				continue
+			}
+			if err != nil {
+				return expired, fmt.Errorf("failed to expire checkout of cart %s: %w", cartID, err)
+			}
+			if result.Action == SagaActionCompensate {
+				expired++
+			}
+		}
+		if len(sagas) < sagaBatchSize {
+			return expired, nil
+		}
+	}
+}
+
+// advance applies step to the saga of a cart and tells the shopper where
+// the checkout stands.
+func (c *CheckoutSagas) advance(ctx context.Context, cartID, cause string, step SagaStep) (*SagaResult, error) {
+	result, err := c.store.AdvanceCheckoutSaga(ctx, cartID, step)
+	if err != nil {
+		return nil, err
+	}
+
+	saga := &result.Saga
+	log := c.logger.With("cart_id", cartID, "cause", cause, "action", string(result.Action))
+
+	switch result.Action {
+	case SagaActionComplete:
+		c.publish(cartID, "checkout.completed", map[string]interface{}{
+			"cart_id":  cartID,
+			"order_id": saga.orderID(),
+			"status":   result.CartStatus,
+		})
+		log.Info("Checkout completed", "order_id", saga.orderID())
+	case SagaActionCompensate:
+		c.publish(cartID, "checkout.failed", map[string]interface{}{
+			"cart_id":  cartID,
+			"order_id": saga.orderID(),
+			"reason":   saga.failureReason(),
+			"status":   result.CartStatus,
+		})
+		c.processOutbox()
+		log.Warn("Checkout rolled back", "reason", saga.failureReason(), "cart_status", result.CartStatus)
+	case SagaActionCancelLate:
+		c.processOutbox()
+		log.Warn("Late checkout outcome cancelled", "order_id", saga.orderID(), "inventory_status", saga.InventoryStatus)
+	default:
+		if saga.Status == SagaPending {
+			c.publish(cartID, "checkout.updated", map[string]interface{}{
+				"cart_id":          cartID,
+				"order_id":         saga.orderID(),
+				"inventory_status": saga.InventoryStatus,
+				"status":           result.CartStatus,
+			})
+		}
+		log.Debug("Checkout saga advanced", "saga_status", saga.Status)
+	}
+	return result, nil
+}
+
+func (c *CheckoutSagas) publish(cartID, event string, data map[string]interface{}) {
+	if c.sse != nil {
+		c.sse.Publish(cartID, event, data)
+	}
+}
+
+func (c *CheckoutSagas) processOutbox() {
+	if c.publisher != nil {
+		go func() {
+			if err := c.publisher.ProcessNow(); err != nil {
+				c.logger.Warn("Failed to trigger immediate outbox processing", "error", err.Error())
+			}
+		}()
+	}
+}
+
+// RunCheckoutTimeouts rolls back timed out checkouts every checkout sweep
+// interval until ctx is cancelled.
+func (s *CartService) RunCheckoutTimeouts(ctx context.Context) {
+	interval := s.config.CartCheckoutSweepInterval()
+	s.logger.Info("Checkout timeout job started",
+		"interval", interval.String(),
+		"timeout", s.config.CartCheckoutTimeout().String(),
+	)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Checkout timeout job stopped")
+			return
+		case <-ticker.C:
+			expired, err := s.sagas.ExpireCheckouts(ctx)
+			if err != nil && !errors.Is(err, context.Canceled) {
+				s.logger.Error("Checkout timeout run failed", "error", err.Error())
+			}
+			if expired > 0 {
+				s.logger.Info("Checkout timeout run completed", "rolled_back", expired)
+			}
+		}
+	}
+}
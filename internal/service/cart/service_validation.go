@@ -0,0 +1,154 @@
+package cart
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// validationBatchSize bounds how many pending items one reconcile query
+// returns.
+const validationBatchSize = 100
+
+// backorderReasonValidationTimeout is the backorder reason of items whose
+// validation was never answered.
+const backorderReasonValidationTimeout = "validation_timeout"
+
+// ReconcilePendingValidations follows up on items left pending_validation
+// longer than the validation timeout, for example while the product service
+// is down. Validation of such an item is requested again by re-emitting
+// cart.item.added until it has been requested the configured number of
+// times; after that, or at once if the item has no validation ID, the item
+// is backordered. It returns the number of items retried and backordered.
+func (s *CartService) ReconcilePendingValidations(ctx context.Context) (retried int, backordered int, err error) {
+	cutoff := time.Now().Add(-s.config.CartPendingValidationTimeout())
+	maxAttempts := s.config.CartPendingValidationMaxAttempts()
+
+	for {
+		items, err := s.repo.ListStalePendingItems(ctx, cutoff, validationBatchSize)
+		if err != nil {
+			return retried, backordered, fmt.Errorf("failed to list pending items: %w", err)
+		}
+		for i := range items {
+			item := &items[i]
+			if item.ValidationID == nil || item.ValidationAttempts >= maxAttempts {
+				// Without a validation ID no response can be matched to
+				// the item, so asking again cannot help
+				if item.ValidationID == nil {
+					s.logger.Warn("Pending item has no validation ID", "cart_id", item.CartID.String(), "line_number", item.LineNumber)
+				}
+				err = s.backorderStaleItem(ctx, item)
+				if err == nil {
+					backordered++
+				}
+			} else {
+				err = s.repo.RetryItemValidation(ctx, item)
+				if err == nil {
+					retried++
+				}
+			}
+			// Validated or removed since it was listed
+			if errors.Is(err, ErrCartItemNotFound) || errors.Is(err, ErrCartNotFound) {
+				continue
+			}
+			if err != nil {
+				return retried, backordered, fmt.Errorf("failed to reconcile item %s of cart %s: %w", item.LineNumber, item.CartID, err)
+			}
+		}
+		if len(items) < validationBatchSize {
+			break
+		}
+	}
+
+	if retried > 0 && s.infrastructure.OutboxPublisher != nil {
+		go func() {
+			if err := s.infrastructure.OutboxPublisher.ProcessNow(); err != nil {
+				s.logger.Warn("Failed to trigger immediate outbox processing", "error", err.Error())
+			}
+		}()
+	}
+
+	return retried, backordered, nil
+}
+
+// RunValidationReconciler runs ReconcilePendingValidations every sweep
+// interval until ctx is cancelled.
+func (s *CartService) RunValidationReconciler(ctx context.Context) {
+	interval := s.config.CartValidationSweepInterval()
+	s.logger.Info("Pending validation reconciler started",
+		"interval", interval.String(),
+		"timeout", s.config.CartPendingValidationTimeout().String(),
+		"max_attempts", s.config.CartPendingValidationMaxAttempts(),
+	)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Pending validation reconciler stopped")
+			return
+		case <-ticker.C:
+			retried, backordered, err := s.ReconcilePendingValidations(ctx)
+			if err != nil {
+				s.logger.Error("Pending validation reconcile failed", "error", err.Error())
+			}
+			if retried > 0 || backordered > 0 {
+				s.logger.Info("Pending validation reconcile completed", "retried", retried, "backordered", backordered)
+			}
+		}
+	}
+}
+
+// backorderStaleItem backorders a pending item whose validation timed out,
+// saves it with the cart totals and tells the shopper. It returns
+// ErrCartItemNotFound if the item is gone or no longer pending.
+func (s *CartService) backorderStaleItem(ctx context.Context, stale *CartItem) error {
+	cartID := stale.CartID.String()
+	err := RetryOnVersionConflict(func() error {
+		cart, err := s.repo.GetCartByID(ctx, cartID)
+		if err != nil {
+			return err
+		}
+
+		var item *CartItem
+		for i := range cart.Items {
+			if cart.Items[i].LineNumber == stale.LineNumber {
+				item = &cart.Items[i]
+				break
+			}
+		}
+		if item == nil || !item.IsPendingValidation() {
+			return ErrCartItemNotFound
+		}
+
+		if err := item.MarkAsBackorder(backorderReasonValidationTimeout); err != nil {
+			return err
+		}
+		if err := cart.CalculateTotals(ctx, s.pricing); err != nil {
+			return err
+		}
+		return s.repo.UpdateItemStatusAndTotals(ctx, cart, item)
+	})
+	if err != nil {
+		return err
+	}
+
+	if s.infrastructure.SSEProvider != nil {
+		s.infrastructure.SSEProvider.GetHub().Publish(cartID, "cart.item.backorder", map[string]interface{}{
+			"line_number":      stale.LineNumber,
+			"product_id":       stale.ProductID,
+			"status":           "backorder",
+			"backorder_reason": backorderReasonValidationTimeout,
+		})
+	}
+
+	s.logger.Info("Pending item backordered after validation timeout",
+		"cart_id", cartID,
+		"line_number", stale.LineNumber,
+		"product_id", stale.ProductID,
+		"attempts", stale.ValidationAttempts,
+	)
+	return nil
+}
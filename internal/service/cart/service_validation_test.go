@@ -0,0 +1,172 @@
+package cart
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"go-shopping-poc/internal/platform/money/moneytest"
+	"go-shopping-poc/internal/platform/tax"
+)
+
+// fakeValidationRepo lists its stale items once and keeps one cart whose
+// lines the reconciler backorders. retryErr fails the retry of a line.
+type fakeValidationRepo struct {
+	CartRepository
+	cart     *Cart
+	stale    []CartItem
+	retryErr map[string]error
+	listed   bool
+	retried  []string
+}
+
+func (r *fakeValidationRepo) ListStalePendingItems(ctx context.Context, requestedBefore time.Time, limit int) ([]CartItem, error) {
+	if r.listed {
+		return nil, nil
+	}
+	r.listed = true
+	return slices.Clone(r.stale), nil
+}
+
+func (r *fakeValidationRepo) RetryItemValidation(ctx context.Context, item *CartItem) error {
+	if item.ValidationID == nil {
+		return fmt.Errorf("item %s has no validation ID", item.LineNumber)
+	}
+	if err := r.retryErr[item.LineNumber]; err != nil {
+		return err
+	}
+	r.retried = append(r.retried, item.LineNumber)
+	item.ValidationAttempts++
+	return nil
+}
+
+func (r *fakeValidationRepo) GetCartByID(ctx context.Context, cartID string) (*Cart, error) {
+	cart := *r.cart
+	cart.Items = slices.Clone(r.cart.Items)
+	return &cart, nil
+}
+
+func (r *fakeValidationRepo) UpdateItemStatusAndTotals(ctx context.Context, cart *Cart, item *CartItem) error {
+	for i := range r.cart.Items {
+		if r.cart.Items[i].LineNumber == item.LineNumber {
+			r.cart.Items[i] = *item
+		}
+	}
+	return nil
+}
+
+// status describes the stored line lineNumber as status/reason.
+func (r *fakeValidationRepo) status(lineNumber string) string {
+	for _, item := range r.cart.Items {
+		if item.LineNumber == lineNumber {
+			if item.BackorderReason != nil {
+				return item.Status + "/" + *item.BackorderReason
+			}
+			return item.Status
+		}
+	}
+	return ""
+}
+
+func newValidationService(t *testing.T, repo CartRepository) *CartService {
+	t.Helper()
+
+	table, err := tax.DefaultRateTable()
+	if err != nil {
+		t.Fatalf("DefaultRateTable() error = %v", err)
+	}
+	return NewCartServiceWithRepo(slog.New(slog.DiscardHandler), repo, &CartInfrastructure{}, &Config{}, Pricing{Tax: tax.NewTableCalculator(table)})
+}
+
+func TestReconcilePendingValidations(t *testing.T) {
+	t.Parallel()
+
+	cartID := uuid.New()
+	validationID := "validation-1"
+	pending := func(lineNumber string, attempts int, withID bool) CartItem {
+		item := CartItem{
+			CartID:             cartID,
+			LineNumber:         lineNumber,
+			ProductID:          "p" + lineNumber,
+			Quantity:           1,
+			UnitPrice:          moneytest.USD("0.00"),
+			Status:             "pending_validation",
+			ValidationAttempts: attempts,
+		}
+		if withID {
+			item.ValidationID = &validationID
+		}
+		return item
+	}
+
+	stale := []CartItem{
+		pending("001", 1, true),
+		pending("002", defaultPendingValidationMaxAttempts, true),
+		pending("003", 0, false),
+		pending("004", 1, true),
+		pending("005", defaultPendingValidationMaxAttempts, true),
+		pending("006", 0, true),
+	}
+	stored := slices.Clone(stale)
+	// Validated between the listing and the backorder
+	stored[4].Status = "confirmed"
+
+	repo := &fakeValidationRepo{
+		cart:     &Cart{CartID: cartID, Currency: "USD", CurrentStatus: "active", Items: stored},
+		stale:    stale,
+		retryErr: map[string]error{"004": ErrCartItemNotFound},
+	}
+	s := newValidationService(t, repo)
+
+	retried, backordered, err := s.ReconcilePendingValidations(context.Background())
+	if err != nil {
+		t.Fatalf("ReconcilePendingValidations() error = %v", err)
+	}
+	if retried != 2 || backordered != 2 {
+		t.Errorf("ReconcilePendingValidations() = %d retried, %d backordered; want 2, 2", retried, backordered)
+	}
+	if want := []string{"001", "006"}; !slices.Equal(repo.retried, want) {
+		t.Errorf("retried lines = %v, want %v", repo.retried, want)
+	}
+
+	want := map[string]string{
+		"001": "pending_validation",
+		"002": "backorder/" + backorderReasonValidationTimeout,
+		"003": "backorder/" + backorderReasonValidationTimeout,
+		"004": "pending_validation",
+		"005": "confirmed",
+		"006": "pending_validation",
+	}
+	for lineNumber, status := range want {
+		if got := repo.status(lineNumber); got != status {
+			t.Errorf("line %s = %s, want %s", lineNumber, got, status)
+		}
+	}
+}
+
+func TestReconcilePendingValidationsStopsOnError(t *testing.T) {
+	t.Parallel()
+
+	failed := errors.New("outbox unavailable")
+	validationID := "validation-1"
+	item := CartItem{CartID: uuid.New(), LineNumber: "001", Status: "pending_validation", ValidationID: &validationID}
+	repo := &fakeValidationRepo{
+		stale:    []CartItem{item, item},
+		retryErr: map[string]error{"001": failed},
+	}
+	s := newValidationService(t, repo)
+
+	retried, backordered, err := s.ReconcilePendingValidations(context.Background())
+	if !errors.Is(err, failed) {
+		t.Fatalf("ReconcilePendingValidations() error = %v, want %v", err, failed)
+	}
+	if retried != 0 || backordered != 0 {
+		t.Errorf("ReconcilePendingValidations() = %d retried, %d backordered; want 0, 0", retried, backordered)
+	}
+}
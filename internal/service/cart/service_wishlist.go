@@ -0,0 +1,332 @@
+package cart
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"go-shopping-poc/internal/platform/database"
+	"go-shopping-poc/internal/platform/money"
+)
+
+// ListWishlists returns the lists of a customer with their items.
+func (s *CartService) ListWishlists(ctx context.Context, customerID string) ([]Wishlist, error) {
+	wishlists, err := s.repo.ListWishlists(ctx, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list wishlists: %w", err)
+	}
+	return wishlists, nil
+}
+
+// CreateWishlist creates a named wishlist for a customer.
+func (s *CartService) CreateWishlist(ctx context.Context, customerID string, name string) (*Wishlist, error) {
+	customerUUID, err := uuid.Parse(customerID)
+	if err != nil {
+		return nil, ErrInvalidCustomerID
+	}
+	wishlist, err := NewWishlist(customerUUID, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateWishlist(ctx, wishlist); err != nil {
+		return nil, fmt.Errorf("failed to create wishlist: %w", err)
+	}
+
+	s.logger.Info("Wishlist created", "wishlist_id", wishlist.WishlistID, "customer_id", customerID)
+	return wishlist, nil
+}
+
+// GetWishlist returns a wishlist of a customer. Lists of other customers
+// are reported as ErrWishlistNotFound.
+func (s *CartService) GetWishlist(ctx context.Context, customerID string, wishlistID string) (*Wishlist, error) {
+	if _, err := uuid.Parse(wishlistID); err != nil {
+		return nil, ErrWishlistNotFound
+	}
+	wishlist, err := s.repo.GetWishlist(ctx, wishlistID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wishlist: %w", err)
+	}
+	if !wishlist.OwnedBy(customerID) {
+		return nil, ErrWishlistNotFound
+	}
+	return wishlist, nil
+}
+
+// DeleteWishlist deletes a wishlist of a customer and its items.
+func (s *CartService) DeleteWishlist(ctx context.Context, customerID string, wishlistID string) error {
+	if _, err := s.GetWishlist(ctx, customerID, wishlistID); err != nil {
+		return err
+	}
+	if err := s.repo.DeleteWishlist(ctx, wishlistID); err != nil {
+		return fmt.Errorf("failed to delete wishlist: %w", err)
+	}
+
+	s.logger.Info("Wishlist deleted", "wishlist_id", wishlistID, "customer_id", customerID)
+	return nil
+}
+
+// AddWishlistItem saves a product to a wishlist of a customer at its
+// current price and stock. The product must be known to the product cache.
+func (s *CartService) AddWishlistItem(ctx context.Context, customerID, wishlistID, productID string, quantity int, imageURL string) (*WishlistItem, error) {
+	if quantity <= 0 {
+		return nil, ErrInvalidQuantity
+	}
+	wishlist, err := s.GetWishlist(ctx, customerID, wishlistID)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, ok := s.productCache.Peek(productID)
+	if !ok {
+		return nil, ErrWishlistProductUnknown
+	}
+
+	item := &WishlistItem{
+		WishlistID:  wishlist.WishlistID,
+		ProductID:   productID,
+		ProductName: entry.Name,
+		ImageURL:    imageURL,
+		Quantity:    quantity,
+		InStock:     entry.InStock,
+	}
+	item.setPrice(entry.FinalPrice)
+	if err := s.repo.AddWishlistItem(ctx, item); err != nil {
+		return nil, fmt.Errorf("failed to add wishlist item: %w", err)
+	}
+	return item, nil
+}
+
+// RemoveWishlistItem removes a product from a wishlist of a customer.
+func (s *CartService) RemoveWishlistItem(ctx context.Context, customerID, wishlistID, productID string) error {
+	if _, err := s.GetWishlist(ctx, customerID, wishlistID); err != nil {
+		return err
+	}
+	if err := s.repo.RemoveWishlistItem(ctx, wishlistID, productID); err != nil {
+		return fmt.Errorf("failed to remove wishlist item: %w", err)
+	}
+	return nil
+}
+
+// SaveForLater moves a line of a customer's cart to one of their
+// wishlists, or to their saved-for-later list when wishlistID is empty.
+// The line is removed and the list updated in one transaction.
+func (s *CartService) SaveForLater(ctx context.Context, customerID, cartID, lineNumber, wishlistID string) (*Cart, error) {
+	cart, err := s.customerCart(ctx, customerID, cartID)
+	if err != nil {
+		return nil, err
+	}
+	if cart.CurrentStatus != "active" {
+		return nil, fmt.Errorf("cannot save items of non-active cart: %w", ErrCartNotActive)
+	}
+	if err := checkVersion(ctx, cart); err != nil {
+		return nil, err
+	}
+
+	var target *Wishlist
+	if wishlistID != "" {
+		if target, err = s.GetWishlist(ctx, customerID, wishlistID); err != nil {
+			return nil, err
+		}
+	}
+
+	var line *CartItem
+	var remaining []CartItem
+	for i := range cart.Items {
+		if cart.Items[i].LineNumber == lineNumber {
+			line = &cart.Items[i]
+		} else {
+			remaining = append(remaining, cart.Items[i])
+		}
+	}
+	if line == nil {
+		return nil, ErrCartItemNotFound
+	}
+	saved := s.wishlistItemFromLine(cart, line)
+	cart.Items = remaining
+
+	err = s.saveCart(ctx, cart, func(tx database.Tx) error {
+		if err := s.repo.RemoveItemTx(ctx, tx, cartID, lineNumber); err != nil {
+			return fmt.Errorf("failed to remove item: %w", err)
+		}
+		if target == nil {
+			savedForLater, err := s.repo.SavedForLaterTx(ctx, tx, *cart.CustomerID)
+			if err != nil {
+				return err
+			}
+			target = savedForLater
+		}
+		saved.WishlistID = target.WishlistID
+		if err := s.repo.AddWishlistItemTx(ctx, tx, saved); err != nil {
+			return fmt.Errorf("failed to save item for later: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Cart item saved for later",
+		"cart_id", cartID,
+		"line_number", lineNumber,
+		"product_id", saved.ProductID,
+		"wishlist_id", saved.WishlistID,
+	)
+	return cart, nil
+}
+
+// MoveToCart adds a product saved to a wishlist of a customer to their
+// cart, in the quantity saved, and takes it off the list. The line goes
+// through the usual add-item validation.
+func (s *CartService) MoveToCart(ctx context.Context, customerID, wishlistID, productID, cartID string) (*CartItem, error) {
+	wishlist, err := s.GetWishlist(ctx, customerID, wishlistID)
+	if err != nil {
+		return nil, err
+	}
+	var saved *WishlistItem
+	for i := range wishlist.Items {
+		if wishlist.Items[i].ProductID == productID {
+			saved = &wishlist.Items[i]
+			break
+		}
+	}
+	if saved == nil {
+		return nil, ErrWishlistItemNotFound
+	}
+
+	if _, err := s.customerCart(ctx, customerID, cartID); err != nil {
+		return nil, err
+	}
+	item, err := s.AddItem(ctx, cartID, productID, saved.Quantity, saved.ImageURL, LineAttributes{})
+	if err != nil {
+		return nil, err
+	}
+
+	// The line is in the cart now; a failure here leaves the product on
+	// the list too, which the shopper can remove.
+	if err := s.repo.RemoveWishlistItem(ctx, wishlistID, productID); err != nil && !errors.Is(err, ErrWishlistItemNotFound) {
+		s.logger.Warn("Failed to remove moved item from wishlist",
+			"wishlist_id", wishlistID,
+			"product_id", productID,
+			"error", err.Error(),
+		)
+	}
+
+	s.logger.Info("Wishlist item moved to cart",
+		"wishlist_id", wishlistID,
+		"product_id", productID,
+		"cart_id", cartID,
+		"line_number", item.LineNumber,
+	)
+	return item, nil
+}
+
+// UpdateSavedProduct records the name, final price and stock reported for a
+// product on every wishlist holding it, and alerts the list's SSE
+// subscribers when the price or stock changed. A nil price or inStock is
+// left as it was. It returns the number of saved items updated.
+func (s *CartService) UpdateSavedProduct(ctx context.Context, productID, name string, price *money.Money, inStock *bool) (int, error) {
+	items, err := s.repo.ListWishlistItemsByProduct(ctx, productID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list saved items: %w", err)
+	}
+
+	updated := 0
+	for i := range items {
+		item := &items[i]
+		previous := item.CatalogPrice()
+		priceChanged := price != nil && !previous.Equal(*price)
+		stockChanged := inStock != nil && *inStock != item.InStock
+		nameChanged := name != "" && name != item.ProductName
+		if !priceChanged && !stockChanged && !nameChanged {
+			continue
+		}
+
+		if priceChanged {
+			item.setPrice(*price)
+		}
+		if stockChanged {
+			item.InStock = *inStock
+		}
+		if nameChanged {
+			item.ProductName = name
+		}
+		if err := s.repo.UpdateWishlistItemProduct(ctx, item); err != nil {
+			return updated, fmt.Errorf("failed to update saved item %d: %w", item.ID, err)
+		}
+		updated++
+
+		if priceChanged {
+			s.publishWishlistAlert(item, "wishlist.item.price_changed", map[string]interface{}{
+				"previous_price": previous,
+				"price":          item.Price,
+				"currency":       item.Currency,
+			})
+		}
+		if stockChanged {
+			event := "wishlist.item.out_of_stock"
+			if item.InStock {
+				event = "wishlist.item.back_in_stock"
+			}
+			s.publishWishlistAlert(item, event, map[string]interface{}{
+				"in_stock": item.InStock,
+			})
+		}
+	}
+	return updated, nil
+}
+
+// publishWishlistAlert pushes event about a saved item to the SSE
+// subscribers of its wishlist.
+func (s *CartService) publishWishlistAlert(item *WishlistItem, event string, data map[string]interface{}) {
+	s.logger.Debug("Wishlist alert",
+		"wishlist_id", item.WishlistID,
+		"product_id", item.ProductID,
+		"event", event,
+	)
+	if s.infrastructure.SSEProvider == nil {
+		return
+	}
+	data["wishlist_id"] = item.WishlistID.String()
+	data["product_id"] = item.ProductID
+	data["product_name"] = item.ProductName
+	s.infrastructure.SSEProvider.GetHub().Publish(item.WishlistID.String(), event, data)
+}
+
+// customerCart returns a cart owned by customerID. Guest carts must be
+// claimed first.
+func (s *CartService) customerCart(ctx context.Context, customerID, cartID string) (*Cart, error) {
+	cart, err := s.repo.GetCartByID(ctx, cartID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cart: %w", err)
+	}
+	if cart.CustomerID == nil {
+		return nil, ErrCartNotClaimed
+	}
+	if cart.CustomerID.String() != customerID {
+		return nil, ErrCartOwnedByOtherCustomer
+	}
+	return cart, nil
+}
+
+// wishlistItemFromLine returns the wishlist item a cart line is saved as,
+// priced and stocked from the product cache when the product is cached.
+func (s *CartService) wishlistItemFromLine(cart *Cart, line *CartItem) *WishlistItem {
+	saved := &WishlistItem{
+		ProductID:   line.ProductID,
+		ProductName: line.ProductName,
+		ImageURL:    line.ImageURL,
+		Quantity:    line.Quantity,
+		InStock:     !line.IsBackorder(),
+	}
+	saved.setPrice(line.CatalogPrice(cart.Currency))
+	if entry, ok := s.productCache.Peek(line.ProductID); ok {
+		if entry.Name != "" {
+			saved.ProductName = entry.Name
+		}
+		saved.setPrice(entry.FinalPrice)
+		saved.InStock = entry.InStock
+	}
+	return saved
+}
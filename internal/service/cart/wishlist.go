@@ -0,0 +1,129 @@
+package cart
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"go-shopping-poc/internal/platform/database"
+	platformerrors "go-shopping-poc/internal/platform/errors"
+	"go-shopping-poc/internal/platform/money"
+)
+
+// A customer keeps any number of named wishlists and one saved-for-later
+// list. Cart lines are moved to a list and back; each saved product keeps
+// the price and stock it was last seen with, and a product.updated that
+// changes either is pushed to the SSE subscribers of the lists holding it.
+
+// Wishlist kinds
+const (
+	WishlistKindWishlist      = "wishlist"
+	WishlistKindSavedForLater = "saved_for_later"
+)
+
+// SavedForLaterName is the name of a customer's saved-for-later list.
+const SavedForLaterName = "Saved for later"
+
+// maxWishlistNameLength bounds the name of a wishlist.
+const maxWishlistNameLength = 100
+
+// Wishlist is a named list of products a customer keeps out of the cart.
+type Wishlist struct {
+	WishlistID uuid.UUID      `json:"wishlist_id" db:"wishlist_id"`
+	CustomerID uuid.UUID      `json:"customer_id" db:"customer_id"`
+	Name       string         `json:"name" db:"name"`
+	Kind       string         `json:"kind" db:"kind"`
+	CreatedAt  time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at" db:"updated_at"`
+	Items      []WishlistItem `json:"items"`
+}
+
+// WishlistItem is a product saved to a wishlist, with the catalog price
+// and stock it was last seen with.
+type WishlistItem struct {
+	ID          int64       `json:"id" db:"id"`
+	WishlistID  uuid.UUID   `json:"wishlist_id" db:"wishlist_id"`
+	ProductID   string      `json:"product_id" db:"product_id"`
+	ProductName string      `json:"product_name" db:"product_name"`
+	ImageURL    string      `json:"image_url" db:"image_url"`
+	Quantity    int         `json:"quantity" db:"quantity"`
+	Price       money.Money `json:"price" db:"price"`
+	Currency    string      `json:"currency" db:"currency"`
+	InStock     bool        `json:"in_stock" db:"in_stock"`
+	AddedAt     time.Time   `json:"added_at" db:"added_at"`
+	UpdatedAt   time.Time   `json:"updated_at" db:"updated_at"`
+}
+
+// NewWishlist returns a wishlist named name for customerID.
+func NewWishlist(customerID uuid.UUID, name string) (*Wishlist, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, platformerrors.NewValidationError("name", "name is required")
+	}
+	if len(name) > maxWishlistNameLength {
+		return nil, platformerrors.NewValidationError("name", "name is too long")
+	}
+	if strings.EqualFold(name, SavedForLaterName) {
+		return nil, ErrWishlistNameTaken
+	}
+	now := time.Now()
+	return &Wishlist{
+		WishlistID: uuid.New(),
+		CustomerID: customerID,
+		Name:       name,
+		Kind:       WishlistKindWishlist,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		Items:      []WishlistItem{},
+	}, nil
+}
+
+// OwnedBy reports whether the wishlist belongs to customerID.
+func (w *Wishlist) OwnedBy(customerID string) bool {
+	return w.CustomerID.String() == customerID
+}
+
+// CatalogPrice returns the price the product was last seen at, in its own
+// currency.
+func (wi *WishlistItem) CatalogPrice() money.Money {
+	return wi.Price.In(wi.Currency)
+}
+
+// setPrice records price as the price the product was last seen at.
+func (wi *WishlistItem) setPrice(price money.Money) {
+	wi.Price = price
+	wi.Currency = price.Currency()
+}
+
+// WishlistStore persists wishlists.
+type WishlistStore interface {
+	// CreateWishlist saves a new wishlist. It returns ErrWishlistNameTaken
+	// if the customer has a list of that name.
+	CreateWishlist(ctx context.Context, wishlist *Wishlist) error
+	// GetWishlist returns a wishlist with its items, or
+	// ErrWishlistNotFound.
+	GetWishlist(ctx context.Context, wishlistID string) (*Wishlist, error)
+	// ListWishlists returns the lists of a customer with their items, the
+	// saved-for-later list first.
+	ListWishlists(ctx context.Context, customerID string) ([]Wishlist, error)
+	DeleteWishlist(ctx context.Context, wishlistID string) error
+	// SavedForLaterTx returns the saved-for-later list of a customer
+	// within tx, creating it if needed.
+	SavedForLaterTx(ctx context.Context, tx database.Tx, customerID uuid.UUID) (*Wishlist, error)
+
+	// AddWishlistItemTx saves item to its wishlist within tx. A product
+	// already on the list has item's quantity added and its price, stock
+	// and name refreshed.
+	AddWishlistItemTx(ctx context.Context, tx database.Tx, item *WishlistItem) error
+	AddWishlistItem(ctx context.Context, item *WishlistItem) error
+	// RemoveWishlistItem removes a product from a wishlist and returns
+	// ErrWishlistItemNotFound if it is not on it.
+	RemoveWishlistItem(ctx context.Context, wishlistID, productID string) error
+	// ListWishlistItemsByProduct returns every saved item of productID.
+	ListWishlistItemsByProduct(ctx context.Context, productID string) ([]WishlistItem, error)
+	// UpdateWishlistItemProduct saves the product name, price and stock of
+	// a saved item.
+	UpdateWishlistItemProduct(ctx context.Context, item *WishlistItem) error
+}
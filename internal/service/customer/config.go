@@ -2,10 +2,17 @@ package customer
 
 import (
 	"errors"
+	"fmt"
+	"time"
 
 	"go-shopping-poc/internal/platform/config"
+	"go-shopping-poc/internal/platform/ratelimit"
 )
 
+// defaultLookupLimit is deliberately tight: the email lookup can otherwise be
+// used to enumerate customers.
+var defaultLookupLimit = ratelimit.Limit{Requests: 20, Period: time.Minute}
+
 // Config defines customer service configuration
 type Config struct {
 	// Database configuration
@@ -21,6 +28,9 @@ type Config struct {
 	// Keycloak configuration (optional)
 	KeycloakIssuer  string `mapstructure:"keycloak_issuer"`
 	KeycloakJWKSURL string `mapstructure:"keycloak_jwks_url"`
+
+	// Rate limit for customer lookup by email, e.g. "20/m"; empty uses the default
+	RateLimitLookup string `mapstructure:"customer_rate_limit_lookup"`
 }
 
 // LoadConfig loads customer service configuration
@@ -39,5 +49,14 @@ func (c *Config) Validate() error {
 	if c.WriteTopic == "" {
 		return errors.New("write topic is required")
 	}
+	if _, err := ratelimit.ParseLimitOrDefault(c.RateLimitLookup, defaultLookupLimit); err != nil {
+		return fmt.Errorf("lookup rate limit: %w", err)
+	}
 	return nil
 }
+
+// LookupLimit returns the rate limit for GET /customers/{email}.
+func (c *Config) LookupLimit() ratelimit.Limit {
+	limit, _ := ratelimit.ParseLimitOrDefault(c.RateLimitLookup, defaultLookupLimit)
+	return limit
+}
@@ -34,13 +34,20 @@ func (h *CustomerHandler) CreateCustomer(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Validate required fields
-	if customer.Username == "" || customer.Email == "" {
-		httperr.Validation(w, "username and email are required")
+	var fieldErrs []httperr.FieldError
+	if customer.Username == "" {
+		fieldErrs = append(fieldErrs, httperr.FieldError{Field: "username", Message: "username is required"})
+	}
+	if customer.Email == "" {
+		fieldErrs = append(fieldErrs, httperr.FieldError{Field: "email", Message: "email is required"})
+	}
+	if len(fieldErrs) > 0 {
+		httperr.ValidationFields(w, "username and email are required", fieldErrs...)
 		return
 	}
 
 	if err := h.service.CreateCustomer(r.Context(), &customer); err != nil {
-		httperr.Internal(w, "Failed to create customer")
+		httperr.FromError(w, err, "Failed to create customer")
 		return
 	}
 	if err := httpx.WriteJSON(w, http.StatusCreated, customer); err != nil {
@@ -63,7 +70,7 @@ func (h *CustomerHandler) UpdateCustomer(w http.ResponseWriter, r *http.Request)
 	}
 
 	if err := h.service.UpdateCustomer(r.Context(), &customer); err != nil {
-		httperr.Internal(w, "Failed to update customer")
+		httperr.FromError(w, err, "Failed to update customer")
 		return
 	}
 	if err := httpx.WriteJSON(w, http.StatusOK, customer); err != nil {
@@ -85,14 +92,14 @@ func (h *CustomerHandler) PatchCustomer(w http.ResponseWriter, r *http.Request)
 	}
 
 	if err := h.service.PatchCustomer(r.Context(), customerID, &patchData); err != nil {
-		httperr.Internal(w, "Failed to patch customer")
+		httperr.FromError(w, err, "Failed to patch customer")
 		return
 	}
 
 	// Return updated customer
 	updated, err := h.service.GetCustomerByID(r.Context(), customerID)
 	if err != nil {
-		httperr.Internal(w, "Failed to retrieve updated customer")
+		httperr.FromError(w, err, "Failed to retrieve updated customer")
 		return
 	}
 	if updated == nil {
@@ -120,7 +127,7 @@ func (h *CustomerHandler) GetCustomerByEmail(w http.ResponseWriter, r *http.Requ
 
 	cust, err := h.service.GetCustomerByEmail(r.Context(), email)
 	if err != nil {
-		httperr.Internal(w, "Customer lookup failed")
+		httperr.FromError(w, err, "Customer lookup failed")
 		return
 	}
 	if cust == nil {
@@ -153,7 +160,7 @@ func (h *CustomerHandler) GetCustomerByEmailPath(w http.ResponseWriter, r *http.
 	}
 	cust, err := h.service.GetCustomerByEmail(r.Context(), email)
 	if err != nil {
-		httperr.Internal(w, "Customer lookup failed")
+		httperr.FromError(w, err, "Customer lookup failed")
 		return
 	}
 	if cust == nil {
@@ -178,7 +185,7 @@ func (h *CustomerHandler) AddAddress(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if _, err := h.service.AddAddress(r.Context(), id, &addr); err != nil {
-		httperr.Internal(w, "Failed to add address")
+		httperr.FromError(w, err, "Failed to add address")
 		return
 	}
 	w.WriteHeader(http.StatusCreated)
@@ -198,7 +205,7 @@ func (h *CustomerHandler) UpdateAddress(w http.ResponseWriter, r *http.Request)
 	}
 
 	if err := h.service.UpdateAddress(r.Context(), addressID, &addr); err != nil {
-		httperr.Internal(w, "Failed to update address")
+		httperr.FromError(w, err, "Failed to update address")
 		return
 	}
 	httpx.WriteNoContent(w)
@@ -211,7 +218,7 @@ func (h *CustomerHandler) DeleteAddress(w http.ResponseWriter, r *http.Request)
 		return
 	}
 	if err := h.service.DeleteAddress(r.Context(), addressID); err != nil {
-		httperr.Internal(w, "Failed to delete address")
+		httperr.FromError(w, err, "Failed to delete address")
 		return
 	}
 	httpx.WriteNoContent(w)
@@ -229,7 +236,7 @@ func (h *CustomerHandler) AddCreditCard(w http.ResponseWriter, r *http.Request)
 		return
 	}
 	if _, err := h.service.AddCreditCard(r.Context(), id, &card); err != nil {
-		httperr.Internal(w, "Failed to add credit card")
+		httperr.FromError(w, err, "Failed to add credit card")
 		return
 	}
 	w.WriteHeader(http.StatusCreated)
@@ -247,7 +254,7 @@ func (h *CustomerHandler) UpdateCreditCard(w http.ResponseWriter, r *http.Reques
 		return
 	}
 	if err := h.service.UpdateCreditCard(r.Context(), cardID, &card); err != nil {
-		httperr.Internal(w, "Failed to update credit card")
+		httperr.FromError(w, err, "Failed to update credit card")
 		return
 	}
 	httpx.WriteNoContent(w)
@@ -260,7 +267,7 @@ func (h *CustomerHandler) DeleteCreditCard(w http.ResponseWriter, r *http.Reques
 		return
 	}
 	if err := h.service.DeleteCreditCard(r.Context(), cardID); err != nil {
-		httperr.Internal(w, "Failed to delete credit card")
+		httperr.FromError(w, err, "Failed to delete credit card")
 		return
 	}
 	httpx.WriteNoContent(w)
@@ -277,7 +284,7 @@ func (h *CustomerHandler) SetDefaultShippingAddress(w http.ResponseWriter, r *ht
 		return
 	}
 	if err := h.service.SetDefaultShippingAddress(r.Context(), customerID, addressID); err != nil {
-		httperr.Internal(w, "Failed to set default shipping address")
+		httperr.FromError(w, err, "Failed to set default shipping address")
 		return
 	}
 	httpx.WriteNoContent(w)
@@ -294,7 +301,7 @@ func (h *CustomerHandler) SetDefaultBillingAddress(w http.ResponseWriter, r *htt
 		return
 	}
 	if err := h.service.SetDefaultBillingAddress(r.Context(), customerID, addressID); err != nil {
-		httperr.Internal(w, "Failed to set default billing address")
+		httperr.FromError(w, err, "Failed to set default billing address")
 		return
 	}
 	httpx.WriteNoContent(w)
@@ -311,7 +318,7 @@ func (h *CustomerHandler) SetDefaultCreditCard(w http.ResponseWriter, r *http.Re
 		return
 	}
 	if err := h.service.SetDefaultCreditCard(r.Context(), customerID, cardID); err != nil {
-		httperr.Internal(w, "Failed to set default credit card")
+		httperr.FromError(w, err, "Failed to set default credit card")
 		return
 	}
 	httpx.WriteNoContent(w)
@@ -324,7 +331,7 @@ func (h *CustomerHandler) ClearDefaultShippingAddress(w http.ResponseWriter, r *
 		return
 	}
 	if err := h.service.ClearDefaultShippingAddress(r.Context(), customerID); err != nil {
-		httperr.Internal(w, "Failed to clear default shipping address")
+		httperr.FromError(w, err, "Failed to clear default shipping address")
 		return
 	}
 	httpx.WriteNoContent(w)
@@ -337,7 +344,7 @@ func (h *CustomerHandler) ClearDefaultBillingAddress(w http.ResponseWriter, r *h
 		return
 	}
 	if err := h.service.ClearDefaultBillingAddress(r.Context(), customerID); err != nil {
-		httperr.Internal(w, "Failed to clear default billing address")
+		httperr.FromError(w, err, "Failed to clear default billing address")
 		return
 	}
 	httpx.WriteNoContent(w)
@@ -350,7 +357,7 @@ func (h *CustomerHandler) ClearDefaultCreditCard(w http.ResponseWriter, r *http.
 		return
 	}
 	if err := h.service.ClearDefaultCreditCard(r.Context(), customerID); err != nil {
-		httperr.Internal(w, "Failed to clear default credit card")
+		httperr.FromError(w, err, "Failed to clear default credit card")
 		return
 	}
 	httpx.WriteNoContent(w)
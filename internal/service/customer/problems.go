@@ -0,0 +1,17 @@
+package customer
+
+import (
+	"net/http"
+
+	platformerrors "go-shopping-poc/internal/platform/errors"
+	"go-shopping-poc/internal/platform/httperr"
+)
+
+// init maps customer domain errors to problem responses.
+func init() {
+	httperr.Register(ErrCustomerNotFound, httperr.Mapping{Status: http.StatusNotFound, Type: platformerrors.ErrorTypeNotFound, Code: "customer_not_found", Message: "Customer not found"})
+	httperr.Register(ErrAddressNotFound, httperr.Mapping{Status: http.StatusNotFound, Type: platformerrors.ErrorTypeNotFound, Code: "customer_address_not_found", Message: "Address not found"})
+	httperr.Register(ErrCreditCardNotFound, httperr.Mapping{Status: http.StatusNotFound, Type: platformerrors.ErrorTypeNotFound, Code: "customer_credit_card_not_found", Message: "Credit card not found"})
+	httperr.Register(ErrInvalidUUID, httperr.Mapping{Status: http.StatusBadRequest, Type: platformerrors.ErrorTypeInvalidRequest, Code: "customer_invalid_id", Message: "Invalid ID format"})
+	httperr.Register(ErrInvalidCustomerData, httperr.Mapping{Status: http.StatusBadRequest, Type: platformerrors.ErrorTypeValidation, Code: "customer_invalid_data", ExposeDetail: true})
+}
@@ -30,6 +30,8 @@ var (
 	ErrInvalidUUID        = errors.New("invalid UUID format")
 	ErrDatabaseOperation  = errors.New("database operation failed")
 	ErrTransactionFailed  = errors.New("transaction failed")
+
+	ErrInvalidCustomerData = errors.New("invalid customer data")
 )
 
 // CustomerRepository defines the contract for customer data access operations.
@@ -171,20 +171,20 @@ func (s *CustomerService) GetCustomerByID(ctx context.Context, customerID string
 func (s *CustomerService) CreateCustomer(ctx context.Context, customer *Customer) error {
 	// Validate the customer entity
 	if err := customer.Validate(); err != nil {
-		return fmt.Errorf("customer validation failed: %w", err)
+		return fmt.Errorf("%w: customer validation failed: %w", ErrInvalidCustomerData, err)
 	}
 
 	// Validate addresses if provided
 	for i, addr := range customer.Addresses {
 		if err := addr.Validate(); err != nil {
-			return fmt.Errorf("address %d validation failed: %w", i, err)
+			return fmt.Errorf("%w: address %d validation failed: %w", ErrInvalidCustomerData, i, err)
 		}
 	}
 
 	// Validate credit cards if provided
 	for i, card := range customer.CreditCards {
 		if err := card.Validate(); err != nil {
-			return fmt.Errorf("credit card %d validation failed: %w", i, err)
+			return fmt.Errorf("%w: credit card %d validation failed: %w", ErrInvalidCustomerData, i, err)
 		}
 	}
 
@@ -205,7 +205,7 @@ func (s *CustomerService) UpdateCustomer(ctx context.Context, customer *Customer
 func (s *CustomerService) PatchCustomer(ctx context.Context, customerID string, patchData *PatchCustomerRequest) error {
 	// Validate patch data
 	if err := s.ValidatePatchData(patchData); err != nil {
-		return fmt.Errorf("invalid patch data: %w", err)
+		return fmt.Errorf("%w: invalid patch data: %w", ErrInvalidCustomerData, err)
 	}
 
 	// Delegate to repository for selective updates
@@ -216,23 +216,23 @@ func (s *CustomerService) PatchCustomer(ctx context.Context, customerID string,
 // ValidatePatchData validates the patch request data
 func (s *CustomerService) ValidatePatchData(patchData *PatchCustomerRequest) error {
 	if patchData == nil {
-		return fmt.Errorf("patch data cannot be nil")
+		return fmt.Errorf("%w: patch data cannot be nil", ErrInvalidCustomerData)
 	}
 
 	// Validate UUID fields if provided
 	if patchData.DefaultShippingAddressID != nil && *patchData.DefaultShippingAddressID != "" {
 		if _, err := uuid.Parse(*patchData.DefaultShippingAddressID); err != nil {
-			return fmt.Errorf("invalid default_shipping_address_id: %w", err)
+			return fmt.Errorf("%w: invalid default_shipping_address_id: %w", ErrInvalidCustomerData, err)
 		}
 	}
 	if patchData.DefaultBillingAddressID != nil && *patchData.DefaultBillingAddressID != "" {
 		if _, err := uuid.Parse(*patchData.DefaultBillingAddressID); err != nil {
-			return fmt.Errorf("invalid default_billing_address_id: %w", err)
+			return fmt.Errorf("%w: invalid default_billing_address_id: %w", ErrInvalidCustomerData, err)
 		}
 	}
 	if patchData.DefaultCreditCardID != nil && *patchData.DefaultCreditCardID != "" {
 		if _, err := uuid.Parse(*patchData.DefaultCreditCardID); err != nil {
-			return fmt.Errorf("invalid default_credit_card_id: %w", err)
+			return fmt.Errorf("%w: invalid default_credit_card_id: %w", ErrInvalidCustomerData, err)
 		}
 	}
 
@@ -267,7 +267,7 @@ func (s *CustomerService) ApplyFieldUpdates(customer *Customer, patchData *Patch
 		} else {
 			uuid, err := uuid.Parse(*patchData.DefaultShippingAddressID)
 			if err != nil {
-				return fmt.Errorf("invalid default_shipping_address_id: %w", err)
+				return fmt.Errorf("%w: invalid default_shipping_address_id: %w", ErrInvalidCustomerData, err)
 			}
 			customer.DefaultShippingAddressID = &uuid
 		}
@@ -278,7 +278,7 @@ func (s *CustomerService) ApplyFieldUpdates(customer *Customer, patchData *Patch
 		} else {
 			uuid, err := uuid.Parse(*patchData.DefaultBillingAddressID)
 			if err != nil {
-				return fmt.Errorf("invalid default_billing_address_id: %w", err)
+				return fmt.Errorf("%w: invalid default_billing_address_id: %w", ErrInvalidCustomerData, err)
 			}
 			customer.DefaultBillingAddressID = &uuid
 		}
@@ -289,7 +289,7 @@ func (s *CustomerService) ApplyFieldUpdates(customer *Customer, patchData *Patch
 		} else {
 			uuid, err := uuid.Parse(*patchData.DefaultCreditCardID)
 			if err != nil {
-				return fmt.Errorf("invalid default_credit_card_id: %w", err)
+				return fmt.Errorf("%w: invalid default_credit_card_id: %w", ErrInvalidCustomerData, err)
 			}
 			customer.DefaultCreditCardID = &uuid
 		}
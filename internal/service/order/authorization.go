@@ -14,10 +14,10 @@ import (
 // Slow path: synchronous Kafka request/response (~50-200 ms) only on cache miss.
 func (s *OrderService) VerifyCustomerIdentity(ctx context.Context, claims *auth.Claims) (*CustomerIdentity, error) {
 	if claims.Email == "" {
-		return nil, fmt.Errorf("missing email in token")
+		return nil, fmt.Errorf("%w: missing email in token", ErrIdentityClaimsIncomplete)
 	}
 	if claims.Subject == "" {
-		return nil, fmt.Errorf("missing sub in token")
+		return nil, fmt.Errorf("%w: missing sub in token", ErrIdentityClaimsIncomplete)
 	}
 
 	// Fast path: cache lookup
@@ -58,7 +58,7 @@ func (s *OrderService) verifyViaKafka(ctx context.Context, claims *auth.Claims)
 		s.mu.Lock()
 		delete(s.verificationCallbacks, requestID)
 		s.mu.Unlock()
-		return nil, fmt.Errorf("identity verification timed out after 5s")
+		return nil, fmt.Errorf("%w after 5s", ErrIdentityVerificationTimeout)
 	case <-ctx.Done():
 		s.mu.Lock()
 		delete(s.verificationCallbacks, requestID)
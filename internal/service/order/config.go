@@ -4,6 +4,7 @@ import (
 	"errors"
 
 	"go-shopping-poc/internal/platform/config"
+	"go-shopping-poc/internal/platform/payment"
 )
 
 type Config struct {
@@ -14,6 +15,19 @@ type Config struct {
 	Group           string   `mapstructure:"order_group"`
 	KeycloakIssuer  string   `mapstructure:"keycloak_issuer"`
 	KeycloakJWKSURL string   `mapstructure:"keycloak_jwks_url"`
+
+	// Shipments are booked with Carrier ("fake" when empty); webhooks of
+	// the fake carrier must be signed with CarrierWebhookSecret and are
+	// rejected without one. With auth on, fulfillment endpoints require
+	// FulfillmentRole and are not served without one.
+	Carrier              string `mapstructure:"order_carrier"`
+	CarrierWebhookSecret string `mapstructure:"order_carrier_webhook_secret"`
+	FulfillmentRole      string `mapstructure:"order_fulfillment_role"`
+
+	// Orders are paid through PaymentGateway ("fake" when empty). The
+	// "http" gateway talks to the payment service at PaymentGatewayURL.
+	PaymentGateway    string `mapstructure:"order_payment_gateway"`
+	PaymentGatewayURL string `mapstructure:"order_payment_gateway_url"`
 }
 
 func LoadConfig() (*Config, error) {
@@ -30,5 +44,8 @@ func (c *Config) Validate() error {
 	if c.WriteTopic == "" {
 		return errors.New("write topic is required")
 	}
+	if c.PaymentGateway == payment.HTTPName && c.PaymentGatewayURL == "" {
+		return errors.New("payment gateway URL is required for the http gateway")
+	}
 	return nil
 }
@@ -5,34 +5,44 @@ import (
 	"fmt"
 	"time"
 
+	"go-shopping-poc/internal/platform/money"
+	"go-shopping-poc/internal/platform/tax"
+
 	"github.com/google/uuid"
 )
 
 type Order struct {
-	OrderID       uuid.UUID  `json:"order_id" db:"order_id"`
-	OrderNumber   string     `json:"order_number" db:"order_number"`
-	CartID        uuid.UUID  `json:"cart_id" db:"cart_id"`
-	CustomerID    *uuid.UUID `json:"customer_id,omitempty" db:"customer_id"`
-	ContactID     int64      `json:"-" db:"contact_id"`
-	CreditCardID  int64      `json:"-" db:"credit_card_id"`
-	Currency      string     `json:"currency" db:"currency"`
-	NetPrice      float64    `json:"net_price" db:"net_price"`
-	Tax           float64    `json:"tax" db:"tax"`
-	Shipping      float64    `json:"shipping" db:"shipping"`
-	TotalPrice    float64    `json:"total_price" db:"total_price"`
-	CurrentStatus string     `json:"current_status" db:"current_status"`
-	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+	OrderID        uuid.UUID   `json:"order_id" db:"order_id"`
+	OrderNumber    string      `json:"order_number" db:"order_number"`
+	CartID         uuid.UUID   `json:"cart_id" db:"cart_id"`
+	Attempt        int         `json:"checkout_attempt,omitempty" db:"checkout_attempt"`
+	CustomerID     *uuid.UUID  `json:"customer_id,omitempty" db:"customer_id"`
+	ContactID      int64       `json:"-" db:"contact_id"`
+	CreditCardID   int64       `json:"-" db:"credit_card_id"`
+	Currency       string      `json:"currency" db:"currency"`
+	NetPrice       money.Money `json:"net_price" db:"net_price"`
+	Tax            money.Money `json:"tax" db:"tax"`
+	Shipping       money.Money `json:"shipping" db:"shipping"`
+	ShippingMethod string      `json:"shipping_method,omitempty" db:"shipping_method"`
+	Discount       money.Money `json:"discount" db:"discount"`
+	TotalPrice     money.Money `json:"total_price" db:"total_price"`
+	Refunded       money.Money `json:"refunded" db:"refunded"`
+	CurrentStatus  string      `json:"current_status" db:"current_status"`
+	CreatedAt      time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at" db:"updated_at"`
 
 	Contact       *Contact      `json:"contact,omitempty"`
 	CreditCard    *CreditCard   `json:"credit_card,omitempty"`
 	Addresses     []Address     `json:"addresses,omitempty"`
 	Items         []OrderItem   `json:"items,omitempty"`
+	Shipments     []Shipment    `json:"shipments,omitempty"`
+	Returns       []Return      `json:"returns,omitempty"`
+	Payment       *Payment      `json:"payment,omitempty"`
 	StatusHistory []OrderStatus `json:"status_history,omitempty"`
 }
 
 func (o *Order) Validate() error {
-	validStatuses := []string{"created", "confirmed", "processing", "shipped", "delivered", "cancelled", "refunded"}
+	validStatuses := []string{"created", "confirmed", "processing", "partially_shipped", "shipped", "delivered", "cancelled", "partially_refunded", "refunded"}
 	if !contains(validStatuses, o.CurrentStatus) {
 		return errors.New("invalid order status")
 	}
@@ -43,22 +53,42 @@ func (o *Order) Validate() error {
 }
 
 func (o *Order) CanCancel() error {
-	nonCancellableStatuses := []string{"shipped", "delivered", "cancelled", "refunded"}
+	nonCancellableStatuses := []string{"partially_shipped", "shipped", "delivered", "cancelled", "partially_refunded", "refunded"}
 	if contains(nonCancellableStatuses, o.CurrentStatus) {
-		return fmt.Errorf("order cannot be cancelled in current status: %s", o.CurrentStatus)
+		return fmt.Errorf("%w: %s", ErrOrderCannotBeCancelled, o.CurrentStatus)
 	}
 	return nil
 }
 
-func (o *Order) SetStatus(newStatus string) error {
+// ActorSystem is the actor of status changes made by the services rather
+// than a person.
+const ActorSystem = "system"
+
+// CustomerActor returns the actor of a status change made by a customer.
+func CustomerActor(customerID string) string {
+	return "customer:" + customerID
+}
+
+// StatusTransition is a status change of an order: the status it left and
+// the history entry of the status it entered.
+type StatusTransition struct {
+	From string
+	OrderStatus
+}
+
+// SetStatus moves the order to newStatus, recording notes and the actor
+// making the change in its status history.
+func (o *Order) SetStatus(newStatus string, notes string, actor string) error {
 	validTransitions := map[string][]string{
-		"created":    {"confirmed", "cancelled"},
-		"confirmed":  {"processing", "cancelled"},
-		"processing": {"shipped", "cancelled"},
-		"shipped":    {"delivered"},
-		"delivered":  {"refunded"},
-		"cancelled":  {},
-		"refunded":   {},
+		"created":            {"confirmed", "cancelled"},
+		"confirmed":          {"processing", "cancelled"},
+		"processing":         {"partially_shipped", "shipped", "cancelled"},
+		"partially_shipped":  {"shipped"},
+		"shipped":            {"delivered"},
+		"delivered":          {"partially_refunded", "refunded"},
+		"partially_refunded": {"refunded"},
+		"cancelled":          {},
+		"refunded":           {},
 	}
 
 	allowed, ok := validTransitions[o.CurrentStatus]
@@ -75,26 +105,45 @@ func (o *Order) SetStatus(newStatus string) error {
 		OrderID:   o.OrderID,
 		Status:    newStatus,
 		ChangedAt: time.Now(),
+		Notes:     notes,
+		Actor:     actor,
 	})
 	return nil
 }
 
 type OrderItem struct {
-	ID             int64     `json:"id" db:"id"`
-	OrderID        uuid.UUID `json:"order_id" db:"order_id"`
-	LineNumber     int       `json:"line_number" db:"line_number"`
-	ProductID      string    `json:"product_id" db:"product_id"`
-	ProductName    string    `json:"product_name" db:"product_name"`
-	UnitPrice      float64   `json:"unit_price" db:"unit_price"`
-	Quantity       int       `json:"quantity" db:"quantity"`
-	TotalPrice     float64   `json:"total_price" db:"total_price"`
-	ImageURL       string    `json:"image_url" db:"image_url"`
-	ItemStatus     string    `json:"item_status" db:"item_status"`
-	ItemStatusDate time.Time `json:"item_status_date_time" db:"item_status_date_time"`
+	ID             int64       `json:"id" db:"id"`
+	OrderID        uuid.UUID   `json:"order_id" db:"order_id"`
+	LineNumber     int         `json:"line_number" db:"line_number"`
+	ProductID      string      `json:"product_id" db:"product_id"`
+	ProductName    string      `json:"product_name" db:"product_name"`
+	UnitPrice      money.Money `json:"unit_price" db:"unit_price"`
+	Quantity       int         `json:"quantity" db:"quantity"`
+	TotalPrice     money.Money `json:"total_price" db:"total_price"`
+	ImageURL       string      `json:"image_url" db:"image_url"`
+	ItemStatus     string      `json:"item_status" db:"item_status"`
+	ItemStatusDate time.Time   `json:"item_status_date_time" db:"item_status_date_time"`
+
+	// Tax charged on this line as computed by the cart at checkout
+	TaxCode      string        `json:"tax_code" db:"tax_code"`
+	Tax          money.Money   `json:"tax" db:"tax"`
+	TaxBreakdown tax.Breakdown `json:"tax_breakdown,omitempty" db:"tax_breakdown"`
+
+	// Promotion discount allocated to this line at checkout
+	Discount money.Money `json:"discount" db:"discount"`
+
+	// Variant options selected for the cart line
+	Size        string `json:"size,omitempty" db:"size"`
+	Color       string `json:"color,omitempty" db:"color"`
+	GiftMessage string `json:"gift_message,omitempty" db:"gift_message"`
+
+	// Units in returns that were not rejected, and units refunded
+	ReturnedQuantity int `json:"returned_quantity" db:"returned_quantity"`
+	RefundedQuantity int `json:"refunded_quantity" db:"refunded_quantity"`
 }
 
 func (oi *OrderItem) CalculateLineTotal() {
-	oi.TotalPrice = float64(oi.Quantity) * oi.UnitPrice
+	oi.TotalPrice = oi.UnitPrice.Mul(int64(oi.Quantity))
 }
 
 type Contact struct {
@@ -142,6 +191,7 @@ type OrderStatus struct {
 	Status    string    `json:"status" db:"order_status"`
 	ChangedAt time.Time `json:"timestamp" db:"status_date_time"`
 	Notes     string    `json:"notes,omitempty" db:"notes"`
+	Actor     string    `json:"actor,omitempty" db:"actor"`
 }
 
 func contains(slice []string, item string) bool {
@@ -154,18 +204,18 @@ func contains(slice []string, item string) bool {
 }
 
 type Cart struct {
-	CartID        uuid.UUID  `json:"cart_id" db:"cart_id"`
-	CustomerID    *uuid.UUID `json:"customer_id,omitempty" db:"customer_id"`
-	ContactID     *int64     `json:"-" db:"contact_id"`
-	CreditCardID  *int64     `json:"-" db:"credit_card_id"`
-	CurrentStatus string     `json:"current_status" db:"current_status"`
-	Currency      string     `json:"currency" db:"currency"`
-	NetPrice      float64    `json:"net_price" db:"net_price"`
-	Tax           float64    `json:"tax" db:"tax"`
-	Shipping      float64    `json:"shipping" db:"shipping"`
-	TotalPrice    float64    `json:"total_price" db:"total_price"`
-	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+	CartID        uuid.UUID   `json:"cart_id" db:"cart_id"`
+	CustomerID    *uuid.UUID  `json:"customer_id,omitempty" db:"customer_id"`
+	ContactID     *int64      `json:"-" db:"contact_id"`
+	CreditCardID  *int64      `json:"-" db:"credit_card_id"`
+	CurrentStatus string      `json:"current_status" db:"current_status"`
+	Currency      string      `json:"currency" db:"currency"`
+	NetPrice      money.Money `json:"net_price" db:"net_price"`
+	Tax           money.Money `json:"tax" db:"tax"`
+	Shipping      money.Money `json:"shipping" db:"shipping"`
+	TotalPrice    money.Money `json:"total_price" db:"total_price"`
+	CreatedAt     time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time   `json:"updated_at" db:"updated_at"`
 
 	Contact    *Contact    `json:"contact,omitempty"`
 	Addresses  []Address   `json:"addresses,omitempty"`
@@ -174,12 +224,12 @@ type Cart struct {
 }
 
 type CartItem struct {
-	ID          int64     `json:"id" db:"id"`
-	CartID      uuid.UUID `json:"cart_id" db:"cart_id"`
-	LineNumber  string    `json:"line_number" db:"line_number"`
-	ProductID   string    `json:"product_id" db:"product_id"`
-	ProductName string    `json:"product_name" db:"product_name"`
-	UnitPrice   float64   `json:"unit_price" db:"unit_price"`
-	Quantity    int       `json:"quantity" db:"quantity"`
-	TotalPrice  float64   `json:"total_price" db:"total_price"`
+	ID          int64       `json:"id" db:"id"`
+	CartID      uuid.UUID   `json:"cart_id" db:"cart_id"`
+	LineNumber  string      `json:"line_number" db:"line_number"`
+	ProductID   string      `json:"product_id" db:"product_id"`
+	ProductName string      `json:"product_name" db:"product_name"`
+	UnitPrice   money.Money `json:"unit_price" db:"unit_price"`
+	Quantity    int         `json:"quantity" db:"quantity"`
+	TotalPrice  money.Money `json:"total_price" db:"total_price"`
 }
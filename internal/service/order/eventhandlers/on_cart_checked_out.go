@@ -2,6 +2,7 @@ package eventhandlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 
@@ -11,6 +12,13 @@ import (
 	"go-shopping-poc/internal/service/order"
 )
 
+// Reasons reported in order.rejected
+const (
+	rejectReasonSnapshotMissing     = "snapshot_missing"
+	rejectReasonOrderCreationFailed = "order_creation_failed"
+	rejectReasonPaymentDeclined     = "payment_declined"
+)
+
 type OnCartCheckedOut struct {
 	service *order.OrderService
 	logger  *slog.Logger
@@ -74,16 +82,33 @@ func (h *OnCartCheckedOut) createOrderFromCart(ctx context.Context, cartEvent ev
 	snapshot := cartEvent.EventPayload.CartSnapshot
 	if snapshot == nil {
 		log.Error("Cart snapshot missing")
-		return fmt.Errorf("cart snapshot is required to create order")
+		return h.rejectCheckout(ctx, cartEvent, rejectReasonSnapshotMissing,
+			fmt.Errorf("cart snapshot is required to create order"))
 	}
 
-	order, err := h.service.CreateOrderFromSnapshot(ctx, cartID, snapshot)
+	created, err := h.service.CreateOrderFromSnapshot(ctx, cartID, cartEvent.EventPayload.CheckoutAttempt(), snapshot)
+	if errors.Is(err, order.ErrPaymentDeclined) {
+		log.Warn("Payment declined", "error", err.Error())
+		return h.rejectCheckout(ctx, cartEvent, rejectReasonPaymentDeclined, err)
+	}
 	if err != nil {
 		log.Error("Create order from cart failed", "error", err.Error())
-		return err
+		return h.rejectCheckout(ctx, cartEvent, rejectReasonOrderCreationFailed, err)
 	}
 
-	log.Info("Order created from cart", "order_number", order.OrderNumber)
+	log.Info("Order created from cart", "order_number", created.OrderNumber)
+	return nil
+}
+
+// rejectCheckout publishes order.rejected for a cart no order could be
+// created for, so that the cart service rolls its checkout back. The
+// checkout counts as handled once the rejection is recorded.
+func (h *OnCartCheckedOut) rejectCheckout(ctx context.Context, cartEvent events.CartEvent, reason string, cause error) error {
+	cartID := cartEvent.EventPayload.CartID
+	if err := h.service.RejectCheckout(ctx, cartID, cartEvent.EventPayload.CheckoutAttempt(), cartEvent.EventPayload.CustomerID, reason); err != nil {
+		return fmt.Errorf("%w (rejecting checkout: %w)", cause, err)
+	}
+	h.logger.Warn("Checkout rejected", "cart_id", cartID, "reason", reason, "error", cause.Error())
 	return nil
 }
 
@@ -0,0 +1,85 @@
+package eventhandlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	events "go-shopping-poc/internal/contracts/events"
+	"go-shopping-poc/internal/platform/event/bus"
+	"go-shopping-poc/internal/platform/event/handler"
+	"go-shopping-poc/internal/service/order"
+)
+
+// OnCartCheckoutFailed cancels the orders created for a checkout the cart
+// service rolled back, for example because stock could not be reserved or
+// the order arrived after the checkout timed out.
+type OnCartCheckoutFailed struct {
+	service *order.OrderService
+	logger  *slog.Logger
+}
+
+func NewOnCartCheckoutFailed(service *order.OrderService, logger *slog.Logger) *OnCartCheckoutFailed {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &OnCartCheckoutFailed{
+		service: service,
+		logger:  logger.With("component", "order_on_cart_checkout_failed"),
+	}
+}
+
+func (h *OnCartCheckoutFailed) Handle(ctx context.Context, event events.Event) error {
+	var cartEvent events.CartEvent
+	switch e := event.(type) {
+	case events.CartEvent:
+		cartEvent = e
+	case *events.CartEvent:
+		cartEvent = *e
+	default:
+		h.logger.Error("Expected CartEvent", "actual_type", fmt.Sprintf("%T", event))
+		return nil
+	}
+	log := h.logger.With(
+		"operation", "handle_cart_checkout_failed",
+		"event_id", cartEvent.ID,
+		"event_type", cartEvent.EventType,
+		"cart_id", cartEvent.EventPayload.CartID,
+	)
+
+	if cartEvent.EventType != events.CartCheckoutFailed {
+		log.Debug("Ignore event type")
+		return nil
+	}
+
+	utils := handler.NewEventUtils()
+	utils.LogEventProcessing(ctx, string(cartEvent.EventType),
+		cartEvent.EventPayload.CartID,
+		cartEvent.EventPayload.Details["order_id"])
+
+	reason := cartEvent.EventPayload.Details["reason"]
+	cancelled, err := h.service.CancelCheckoutOrders(ctx, cartEvent.EventPayload.CartID, cartEvent.EventPayload.CheckoutAttempt(), reason)
+
+	utils.LogEventCompletion(ctx, string(cartEvent.EventType),
+		cartEvent.EventPayload.CartID, err)
+	if err != nil {
+		log.Error("Cancel orders of failed checkout failed", "error", err.Error())
+		return err
+	}
+	log.Info("Failed checkout processed", "reason", reason, "cancelled_orders", cancelled)
+	return nil
+}
+
+func (h *OnCartCheckoutFailed) EventType() string {
+	return string(events.CartCheckoutFailed)
+}
+
+func (h *OnCartCheckoutFailed) CreateFactory() events.EventFactory[events.CartEvent] {
+	return events.CartEventFactory{}
+}
+
+func (h *OnCartCheckoutFailed) CreateHandler() bus.HandlerFunc[events.CartEvent] {
+	return func(ctx context.Context, event events.CartEvent) error {
+		return h.Handle(ctx, event)
+	}
+}
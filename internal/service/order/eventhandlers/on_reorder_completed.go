@@ -0,0 +1,65 @@
+package eventhandlers
+
+import (
+	"context"
+	"log/slog"
+
+	events "go-shopping-poc/internal/contracts/events"
+	"go-shopping-poc/internal/platform/event/bus"
+	"go-shopping-poc/internal/service/order"
+)
+
+// OnReorderCompleted dispatches cart.reorder_completed responses back to
+// the waiting Reorder call.
+type OnReorderCompleted struct {
+	service *order.OrderService
+	logger  *slog.Logger
+}
+
+func NewOnReorderCompleted(service *order.OrderService, logger *slog.Logger) *OnReorderCompleted {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &OnReorderCompleted{
+		service: service,
+		logger:  logger.With("component", "on_reorder_completed"),
+	}
+}
+
+func (h *OnReorderCompleted) Handle(ctx context.Context, event events.Event) error {
+	var respEvent events.CartReorderCompletedEvent
+	switch e := event.(type) {
+	case events.CartReorderCompletedEvent:
+		respEvent = e
+	case *events.CartReorderCompletedEvent:
+		respEvent = *e
+	default:
+		return nil
+	}
+
+	if respEvent.EventType != events.CartReorderCompleted {
+		return nil
+	}
+
+	if respEvent.Data.RequestID == "" {
+		h.logger.Warn("Received reorder response with empty request ID")
+		return nil
+	}
+
+	h.service.DispatchReorderResult(respEvent.Data)
+	return nil
+}
+
+func (h *OnReorderCompleted) EventType() string {
+	return string(events.CartReorderCompleted)
+}
+
+func (h *OnReorderCompleted) CreateFactory() events.EventFactory[events.CartReorderCompletedEvent] {
+	return events.CartReorderCompletedEventFactory{}
+}
+
+func (h *OnReorderCompleted) CreateHandler() bus.HandlerFunc[events.CartReorderCompletedEvent] {
+	return func(ctx context.Context, event events.CartReorderCompletedEvent) error {
+		return h.Handle(ctx, event)
+	}
+}
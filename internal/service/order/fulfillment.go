@@ -0,0 +1,195 @@
+package order
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	platformerrors "go-shopping-poc/internal/platform/errors"
+)
+
+// An order is fulfilled by shipments. Each shipment carries some of its
+// lines and is booked with a carrier, whose tracking webhooks move the
+// shipment and its lines along. The status of the order follows its lines:
+// partially_shipped once some lines left, shipped once all did, delivered
+// once all arrived.
+
+// Fulfillment status of an order line.
+const (
+	ItemStatusPending   = "pending"
+	ItemStatusShipped   = "shipped"
+	ItemStatusDelivered = "delivered"
+)
+
+// Shipment is a parcel carrying lines of an order.
+type Shipment struct {
+	ShipmentID     uuid.UUID  `json:"shipment_id" db:"shipment_id"`
+	OrderID        uuid.UUID  `json:"order_id" db:"order_id"`
+	Carrier        string     `json:"carrier" db:"carrier"`
+	TrackingNumber string     `json:"tracking_number" db:"tracking_number"`
+	TrackingURL    string     `json:"tracking_url,omitempty" db:"tracking_url"`
+	ShippingMethod string     `json:"shipping_method,omitempty" db:"shipping_method"`
+	Status         string     `json:"status" db:"status"`
+	StatusDetail   string     `json:"status_detail,omitempty" db:"status_detail"`
+	StatusAt       time.Time  `json:"status_at" db:"status_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty" db:"delivered_at"`
+
+	LineNumbers []int `json:"line_numbers" db:"-"`
+}
+
+// StaffActor returns the actor of a status change made by staff.
+func StaffActor(subject string) string {
+	return "staff:" + subject
+}
+
+// CarrierActor returns the actor of a status change reported by a carrier.
+func CarrierActor(carrier string) string {
+	return "carrier:" + carrier
+}
+
+// fulfillmentStatuses are the statuses an order goes through while it is
+// fulfilled, in order.
+var fulfillmentStatuses = []string{"confirmed", "processing", "partially_shipped", "shipped", "delivered"}
+
+// CanFulfill reports whether lines of the order can be shipped.
+func (o *Order) CanFulfill() error {
+	switch o.CurrentStatus {
+	case "confirmed", "processing", "partially_shipped":
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrOrderNotFulfillable, o.CurrentStatus)
+}
+
+// PendingLines returns the numbers of the lines not shipped yet.
+func (o *Order) PendingLines() []int {
+	var lines []int
+	for _, item := range o.Items {
+		if item.ItemStatus == ItemStatusPending || item.ItemStatus == "" {
+			lines = append(lines, item.LineNumber)
+		}
+	}
+	return lines
+}
+
+// ShipLines marks lines as shipped. Every line must exist and be pending,
+// and may be named once.
+func (o *Order) ShipLines(lines []int, at time.Time) error {
+	if len(lines) == 0 {
+		return platformerrors.NewValidationError("line_numbers", "line_numbers is required")
+	}
+	seen := make(map[int]bool, len(lines))
+	for _, line := range lines {
+		if seen[line] {
+			return platformerrors.NewValidationError("line_numbers", fmt.Sprintf("line %d is listed more than once", line))
+		}
+		seen[line] = true
+
+		item := o.item(line)
+		if item == nil {
+			return fmt.Errorf("%w: line %d", ErrOrderItemNotFound, line)
+		}
+		if item.ItemStatus != ItemStatusPending && item.ItemStatus != "" {
+			return fmt.Errorf("%w: line %d is %s", ErrLineNotShippable, line, item.ItemStatus)
+		}
+	}
+	for _, line := range lines {
+		item := o.item(line)
+		item.ItemStatus = ItemStatusShipped
+		item.ItemStatusDate = at
+	}
+	return nil
+}
+
+// DeliverLines marks the shipped lines among lines as delivered.
+func (o *Order) DeliverLines(lines []int, at time.Time) {
+	for _, line := range lines {
+		if item := o.item(line); item != nil && item.ItemStatus == ItemStatusShipped {
+			item.ItemStatus = ItemStatusDelivered
+			item.ItemStatusDate = at
+		}
+	}
+}
+
+// FulfillmentStatus returns the status the fulfillment of the lines puts
+// the order in. Orders outside fulfillment keep their status.
+func (o *Order) FulfillmentStatus() string {
+	if !contains(fulfillmentStatuses, o.CurrentStatus) || len(o.Items) == 0 {
+		return o.CurrentStatus
+	}
+
+	shipped, delivered := 0, 0
+	for _, item := range o.Items {
+		switch item.ItemStatus {
+		case ItemStatusShipped:
+			shipped++
+		case ItemStatusDelivered:
+			delivered++
+		}
+	}
+	switch {
+	case delivered == len(o.Items):
+		return "delivered"
+	case shipped+delivered == len(o.Items):
+		return "shipped"
+	case shipped+delivered > 0:
+		return "partially_shipped"
+	}
+	return o.CurrentStatus
+}
+
+// AdvanceFulfillment moves the order forward to its FulfillmentStatus,
+// through the statuses in between when it cannot go there at once, and
+// returns the transitions made. An order already past that status stays.
+func (o *Order) AdvanceFulfillment(notes string, actor string) ([]StatusTransition, error) {
+	target := o.FulfillmentStatus()
+	if fulfillmentRank(target) <= fulfillmentRank(o.CurrentStatus) {
+		return nil, nil
+	}
+	var transitions []StatusTransition
+	for o.CurrentStatus != target {
+		next := target
+		if !o.canMoveTo(target) {
+			next = nextFulfillmentStatus(o.CurrentStatus)
+		}
+		from := o.CurrentStatus
+		if err := o.SetStatus(next, notes, actor); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidStatusTransition, err)
+		}
+		transitions = append(transitions, StatusTransition{From: from, OrderStatus: o.StatusHistory[len(o.StatusHistory)-1]})
+	}
+	return transitions, nil
+}
+
+// canMoveTo reports whether the order can go to status in one transition.
+func (o *Order) canMoveTo(status string) bool {
+	probe := Order{CurrentStatus: o.CurrentStatus}
+	return probe.SetStatus(status, "", "") == nil
+}
+
+// fulfillmentRank returns the position of status in fulfillmentStatuses,
+// or -1.
+func fulfillmentRank(status string) int {
+	for i, s := range fulfillmentStatuses {
+		if s == status {
+			return i
+		}
+	}
+	return -1
+}
+
+// nextFulfillmentStatus returns the fulfillment status after status.
+func nextFulfillmentStatus(status string) string {
+	return fulfillmentStatuses[fulfillmentRank(status)+1]
+}
+
+// item returns the line numbered line, or nil.
+func (o *Order) item(line int) *OrderItem {
+	for i := range o.Items {
+		if o.Items[i].LineNumber == line {
+			return &o.Items[i]
+		}
+	}
+	return nil
+}
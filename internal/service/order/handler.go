@@ -1,7 +1,9 @@
 package order
 
 import (
+	"context"
 	"errors"
+	"io"
 	"net/http"
 
 	"go-shopping-poc/internal/platform/auth"
@@ -25,23 +27,21 @@ func (h *OrderHandler) GetOrder(w http.ResponseWriter, r *http.Request) {
 
 	claims, ok := auth.GetClaims(r.Context())
 	if !ok {
-		http.Error(w, "authentication required", http.StatusUnauthorized)
+		httperr.Unauthorized(w, "authentication required")
 		return
 	}
 
 	identity, err := h.service.VerifyCustomerIdentity(r.Context(), claims)
 	if err != nil {
-		httperr.Forbidden(w, "authentication failed")
+		if !httperr.TryFromError(w, err) {
+			httperr.Forbidden(w, "authentication failed")
+		}
 		return
 	}
 
 	order, err := h.service.GetOrder(r.Context(), orderID)
 	if err != nil {
-		if errors.Is(err, ErrOrderNotFound) {
-			httperr.NotFound(w, "Order not found")
-			return
-		}
-		httperr.Internal(w, "Failed to get order")
+		httperr.FromError(w, err, "Failed to get order")
 		return
 	}
 
@@ -65,15 +65,13 @@ func (h *OrderHandler) GetOrdersByCustomer(w http.ResponseWriter, r *http.Reques
 
 	claims, ok := auth.GetClaims(r.Context())
 	if !ok {
-		http.Error(w, "authentication required", http.StatusUnauthorized)
+		httperr.Unauthorized(w, "authentication required")
 		return
 	}
 
 	identity, err := h.service.VerifyCustomerIdentity(r.Context(), claims)
 	if err != nil {
-		if err.Error() == "identity verification timed out after 5s" {
-			httperr.GatewayTimeout(w, "identity verification failed: timeout")
-		} else {
+		if !httperr.TryFromError(w, err) {
 			httperr.Forbidden(w, "cannot verify customer identity")
 		}
 		return
@@ -86,7 +84,7 @@ func (h *OrderHandler) GetOrdersByCustomer(w http.ResponseWriter, r *http.Reques
 
 	orders, err := h.service.GetOrdersByCustomer(r.Context(), identity.CustomerID)
 	if err != nil {
-		httperr.Internal(w, "Failed to get orders")
+		httperr.FromError(w, err, "Failed to get orders")
 		return
 	}
 
@@ -104,38 +102,32 @@ func (h *OrderHandler) CancelOrder(w http.ResponseWriter, r *http.Request) {
 
 	claims, ok := auth.GetClaims(r.Context())
 	if !ok {
-		http.Error(w, "authentication required", http.StatusUnauthorized)
+		httperr.Unauthorized(w, "authentication required")
 		return
 	}
 
 	identity, err := h.service.VerifyCustomerIdentity(r.Context(), claims)
 	if err != nil {
-		httperr.Forbidden(w, "authentication failed")
+		if !httperr.TryFromError(w, err) {
+			httperr.Forbidden(w, "authentication failed")
+		}
 		return
 	}
 
 	// Verify ownership before cancellation
 	order, err := h.service.GetOrder(r.Context(), orderID)
 	if err != nil {
-		if errors.Is(err, ErrOrderNotFound) {
-			httperr.NotFound(w, "Order not found")
-			return
-		}
-		httperr.Internal(w, "Failed to get order")
+		httperr.FromError(w, err, "Failed to get order")
 		return
 	}
-	if order.CustomerID != nil && order.CustomerID.String() != identity.CustomerID {
+	if order.CustomerID == nil || order.CustomerID.String() != identity.CustomerID {
 		httperr.Forbidden(w, "you can only cancel your own orders")
 		return
 	}
 
-	err = h.service.CancelOrder(r.Context(), orderID)
+	err = h.service.CancelOrder(r.Context(), orderID, CustomerActor(identity.CustomerID))
 	if err != nil {
-		if errors.Is(err, ErrOrderCannotBeCancelled) {
-			httperr.Validation(w, err.Error())
-			return
-		}
-		httperr.Internal(w, "Failed to cancel order")
+		httperr.FromError(w, err, "Failed to cancel order")
 		return
 	}
 
@@ -150,13 +142,15 @@ func (h *OrderHandler) UpdateOrderStatus(w http.ResponseWriter, r *http.Request)
 
 	claims, ok := auth.GetClaims(r.Context())
 	if !ok {
-		http.Error(w, "authentication required", http.StatusUnauthorized)
+		httperr.Unauthorized(w, "authentication required")
 		return
 	}
 
 	identity, err := h.service.VerifyCustomerIdentity(r.Context(), claims)
 	if err != nil {
-		httperr.Forbidden(w, "authentication failed")
+		if !httperr.TryFromError(w, err) {
+			httperr.Forbidden(w, "authentication failed")
+		}
 		return
 	}
 
@@ -167,39 +161,251 @@ func (h *OrderHandler) UpdateOrderStatus(w http.ResponseWriter, r *http.Request)
 	}
 
 	if req.Status == "" {
-		httperr.Validation(w, "status is required")
+		httperr.ValidationFields(w, "status is required",
+			httperr.FieldError{Field: "status", Message: "status is required"})
+		return
+	}
+	// Every other status follows payment, fulfillment and returns
+	if req.Status != "cancelled" {
+		httperr.ValidationFields(w, "customers can only cancel orders",
+			httperr.FieldError{Field: "status", Message: "status must be cancelled"})
 		return
 	}
 
 	order, err := h.service.GetOrder(r.Context(), orderID)
 	if err != nil {
-		if errors.Is(err, ErrOrderNotFound) {
-			httperr.NotFound(w, "Order not found")
-			return
-		}
-		httperr.Internal(w, "Failed to get order")
+		httperr.FromError(w, err, "Failed to get order")
 		return
 	}
 
 	// Verify ownership - customer can only update their own orders
-	if order.CustomerID != nil && order.CustomerID.String() != identity.CustomerID {
+	if order.CustomerID == nil || order.CustomerID.String() != identity.CustomerID {
 		httperr.Forbidden(w, "you can only update your own orders")
 		return
 	}
 
-	err = h.service.UpdateOrderStatus(r.Context(), orderID, req.Status)
+	err = h.service.UpdateOrderStatus(r.Context(), orderID, req.Status, req.Notes, CustomerActor(identity.CustomerID))
 	if err != nil {
-		if errors.Is(err, ErrInvalidStatusTransition) {
-			httperr.Validation(w, err.Error())
-			return
+		httperr.FromError(w, err, "Failed to update order status")
+		return
+	}
+
+	httpx.WriteNoContent(w)
+}
+
+func (h *OrderHandler) Reorder(w http.ResponseWriter, r *http.Request) {
+	orderID, ok := requiredPathParam(w, r, "id", "Missing order ID")
+	if !ok {
+		return
+	}
+
+	claims, ok := auth.GetClaims(r.Context())
+	if !ok {
+		httperr.Unauthorized(w, "authentication required")
+		return
+	}
+
+	identity, err := h.service.VerifyCustomerIdentity(r.Context(), claims)
+	if err != nil {
+		if !httperr.TryFromError(w, err) {
+			httperr.Forbidden(w, "authentication failed")
 		}
-		httperr.Internal(w, "Failed to update order status")
+		return
+	}
+
+	order, err := h.service.GetOrder(r.Context(), orderID)
+	if err != nil {
+		httperr.FromError(w, err, "Failed to get order")
+		return
+	}
+
+	// Guest orders have no customer to reorder for
+	if order.CustomerID == nil || order.CustomerID.String() != identity.CustomerID {
+		httperr.Forbidden(w, "you can only reorder your own orders")
+		return
+	}
+
+	result, err := h.service.Reorder(r.Context(), order, identity.CustomerID)
+	if err != nil {
+		httperr.FromError(w, err, "Failed to reorder")
+		return
+	}
+
+	if err := httpx.WriteJSON(w, reorderStatus(result), result); err != nil {
+		httperr.Internal(w, "Failed to encode response")
+		return
+	}
+}
+
+// reorderStatus answers a reorder still pending in the cart service with 202
+// Accepted and a completed one with 200 OK.
+func reorderStatus(result *ReorderResult) int {
+	if result.Pending {
+		return http.StatusAccepted
+	}
+	return http.StatusOK
+}
+
+// CreateShipment books a shipment of lines of an order. It is meant for
+// fulfillment staff; the route requires the fulfillment role when one is
+// configured.
+func (h *OrderHandler) CreateShipment(w http.ResponseWriter, r *http.Request) {
+	orderID, ok := requiredPathParam(w, r, "id", "Missing order ID")
+	if !ok {
+		return
+	}
+
+	claims, ok := auth.GetClaims(r.Context())
+	if !ok {
+		httperr.Unauthorized(w, "authentication required")
+		return
+	}
+
+	var req ShipmentRequest
+	if err := httpx.DecodeJSON(r, &req); err != nil {
+		httperr.InvalidRequest(w, "Invalid JSON in request body")
+		return
+	}
+
+	shipment, err := h.service.CreateShipment(r.Context(), orderID, req, StaffActor(claims.Subject))
+	if err != nil {
+		httperr.FromError(w, err, "Failed to create shipment")
+		return
+	}
+
+	if err := httpx.WriteJSON(w, http.StatusCreated, shipment); err != nil {
+		httperr.Internal(w, "Failed to encode response")
+		return
+	}
+}
+
+// CarrierWebhook applies the tracking updates a carrier sends. Carriers
+// authenticate by signing their webhooks rather than with a token.
+func (h *OrderHandler) CarrierWebhook(w http.ResponseWriter, r *http.Request) {
+	name, ok := requiredPathParam(w, r, "carrier", "Missing carrier")
+	if !ok {
+		return
+	}
+
+	updates, err := h.service.ParseCarrierWebhook(name, r)
+	if err != nil {
+		httperr.FromError(w, err, "Failed to read carrier webhook")
+		return
+	}
+
+	if err := h.service.ApplyTrackingUpdates(r.Context(), name, updates); err != nil {
+		httperr.FromError(w, err, "Failed to apply tracking updates")
 		return
 	}
 
 	httpx.WriteNoContent(w)
 }
 
+// RequestReturn requests a return of delivered lines of the customer's
+// order.
+func (h *OrderHandler) RequestReturn(w http.ResponseWriter, r *http.Request) {
+	orderID, ok := requiredPathParam(w, r, "id", "Missing order ID")
+	if !ok {
+		return
+	}
+
+	claims, ok := auth.GetClaims(r.Context())
+	if !ok {
+		httperr.Unauthorized(w, "authentication required")
+		return
+	}
+
+	identity, err := h.service.VerifyCustomerIdentity(r.Context(), claims)
+	if err != nil {
+		if !httperr.TryFromError(w, err) {
+			httperr.Forbidden(w, "authentication failed")
+		}
+		return
+	}
+
+	var req ReturnRequest
+	if err := httpx.DecodeJSON(r, &req); err != nil {
+		httperr.InvalidRequest(w, "Invalid JSON in request body")
+		return
+	}
+
+	order, err := h.service.GetOrder(r.Context(), orderID)
+	if err != nil {
+		httperr.FromError(w, err, "Failed to get order")
+		return
+	}
+	if order.CustomerID != nil && order.CustomerID.String() != identity.CustomerID {
+		httperr.Forbidden(w, "you can only return your own orders")
+		return
+	}
+
+	ret, err := h.service.RequestReturn(r.Context(), orderID, req.Lines, CustomerActor(identity.CustomerID))
+	if err != nil {
+		httperr.FromError(w, err, "Failed to request return")
+		return
+	}
+
+	if err := httpx.WriteJSON(w, http.StatusCreated, ret); err != nil {
+		httperr.Internal(w, "Failed to encode response")
+		return
+	}
+}
+
+// ApproveReturn approves a requested return. It and the other return
+// steps are meant for fulfillment staff; the routes require the
+// fulfillment role when one is configured.
+func (h *OrderHandler) ApproveReturn(w http.ResponseWriter, r *http.Request) {
+	h.returnStep(w, r, h.service.ApproveReturn, "Failed to approve return")
+}
+
+// RejectReturn rejects a return not received yet.
+func (h *OrderHandler) RejectReturn(w http.ResponseWriter, r *http.Request) {
+	h.returnStep(w, r, h.service.RejectReturn, "Failed to reject return")
+}
+
+// ReceiveReturn records the items of an approved return as received.
+func (h *OrderHandler) ReceiveReturn(w http.ResponseWriter, r *http.Request) {
+	h.returnStep(w, r, h.service.ReceiveReturn, "Failed to receive return")
+}
+
+// RefundReturn refunds a received return.
+func (h *OrderHandler) RefundReturn(w http.ResponseWriter, r *http.Request) {
+	h.returnStep(w, r, h.service.RefundReturn, "Failed to refund return")
+}
+
+// returnStep applies step to the return named in the path on behalf of
+// the authenticated staff member. The request body is optional.
+func (h *OrderHandler) returnStep(w http.ResponseWriter, r *http.Request,
+	step func(ctx context.Context, returnID string, req ReturnStepRequest, actor string) (*Return, error), failure string) {
+	returnID, ok := requiredPathParam(w, r, "id", "Missing return ID")
+	if !ok {
+		return
+	}
+
+	claims, ok := auth.GetClaims(r.Context())
+	if !ok {
+		httperr.Unauthorized(w, "authentication required")
+		return
+	}
+
+	var req ReturnStepRequest
+	if err := httpx.DecodeJSON(r, &req); err != nil && !errors.Is(err, io.EOF) {
+		httperr.InvalidRequest(w, "Invalid JSON in request body")
+		return
+	}
+
+	ret, err := step(r.Context(), returnID, req, StaffActor(claims.Subject))
+	if err != nil {
+		httperr.FromError(w, err, failure)
+		return
+	}
+
+	if err := httpx.WriteJSON(w, http.StatusOK, ret); err != nil {
+		httperr.Internal(w, "Failed to encode response")
+		return
+	}
+}
+
 func requiredPathParam(w http.ResponseWriter, r *http.Request, key, missingMessage string) (string, bool) {
 	value, err := httpx.RequirePathParam(r, key)
 	if err != nil {
@@ -212,4 +418,9 @@ func requiredPathParam(w http.ResponseWriter, r *http.Request, key, missingMessa
 
 type UpdateStatusRequest struct {
 	Status string `json:"status"`
+	Notes  string `json:"notes,omitempty"`
+}
+
+type ReturnRequest struct {
+	Lines []ReturnLineRequest `json:"lines"`
 }
@@ -0,0 +1,6 @@
+-- Migration: Add per-line tax to order items
+-- Snapshot of the tax code, amount and applied jurisdiction rates from checkout
+
+ALTER TABLE orders.OrderItem ADD COLUMN tax_code VARCHAR(50) NOT NULL DEFAULT '';
+ALTER TABLE orders.OrderItem ADD COLUMN tax numeric(19,4) NOT NULL DEFAULT 0;
+ALTER TABLE orders.OrderItem ADD COLUMN tax_breakdown JSONB;
@@ -0,0 +1,4 @@
+-- Migration: Add shipping method to orders
+-- Snapshot of the shipping method selected on the cart at checkout
+
+ALTER TABLE orders.OrderHead ADD COLUMN shipping_method VARCHAR(30) NOT NULL DEFAULT '';
@@ -0,0 +1,5 @@
+-- Migration: Add promotion discounts to orders
+-- Snapshot of the cart discount and its allocation to lines at checkout
+
+ALTER TABLE orders.OrderHead ADD COLUMN discount numeric(19,2) NOT NULL DEFAULT 0;
+ALTER TABLE orders.OrderItem ADD COLUMN discount numeric(19,2) NOT NULL DEFAULT 0;
@@ -0,0 +1,6 @@
+-- Migration: Add variant options to order items
+-- Snapshot of the size, color and gift message selected for the cart line
+
+ALTER TABLE orders.OrderItem ADD COLUMN size text NOT NULL DEFAULT '';
+ALTER TABLE orders.OrderItem ADD COLUMN color text NOT NULL DEFAULT '';
+ALTER TABLE orders.OrderItem ADD COLUMN gift_message text NOT NULL DEFAULT '';
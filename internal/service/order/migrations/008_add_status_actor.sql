@@ -0,0 +1,4 @@
+-- Migration: Record who changed the status of an order
+-- "system" for changes made by the services, "customer:<id>" for the shopper
+
+ALTER TABLE orders.OrderStatus ADD COLUMN actor text NOT NULL DEFAULT 'system';
@@ -0,0 +1,47 @@
+-- Migration: Add shipments and fulfillment tracking
+-- Shipments carry lines of an order and are tracked through carrier
+-- webhooks; the order status follows the fulfillment of its lines
+
+ALTER TABLE orders.OrderHead DROP CONSTRAINT IF EXISTS orderhead_current_status_check;
+ALTER TABLE orders.OrderHead ADD CONSTRAINT orderhead_current_status_check
+    CHECK (current_status in ('created', 'confirmed', 'processing', 'partially_shipped', 'shipped', 'delivered', 'cancelled', 'refunded'));
+
+ALTER TABLE orders.OrderStatus DROP CONSTRAINT IF EXISTS orderstatus_order_status_check;
+ALTER TABLE orders.OrderStatus ADD CONSTRAINT orderstatus_order_status_check
+    CHECK (order_status in ('created', 'confirmed', 'processing', 'partially_shipped', 'shipped', 'delivered', 'cancelled', 'refunded'));
+
+CREATE TABLE orders.Shipment (
+    shipment_id uuid not null primary key,
+    order_id uuid not null,
+    carrier text not null,
+    tracking_number text not null,
+    tracking_url text not null default '',
+    shipping_method text not null default '',
+    status text not null,
+    status_detail text not null default '',
+    status_at timestamp not null,
+    created_at timestamp default CURRENT_TIMESTAMP not null,
+    delivered_at timestamp,
+    UNIQUE(carrier, tracking_number)
+);
+
+-- A line ships in one shipment only
+CREATE TABLE orders.ShipmentItem (
+    shipment_id uuid not null,
+    order_id uuid not null,
+    line_number int not null,
+    primary key (shipment_id, line_number),
+    UNIQUE(order_id, line_number)
+);
+
+ALTER TABLE IF EXISTS orders.Shipment
+    ADD CONSTRAINT fk_order_id
+        FOREIGN KEY (order_id)
+            REFERENCES orders.OrderHead(order_id) ON DELETE CASCADE;
+
+ALTER TABLE IF EXISTS orders.ShipmentItem
+    ADD CONSTRAINT fk_shipment_id
+        FOREIGN KEY (shipment_id)
+            REFERENCES orders.Shipment(shipment_id) ON DELETE CASCADE;
+
+CREATE INDEX idx_shipment_order_id ON orders.Shipment(order_id);
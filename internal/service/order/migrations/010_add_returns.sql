@@ -0,0 +1,54 @@
+-- Migration: Add returns (RMA) with per-line refunds
+-- Customers return delivered lines; staff approve, receive and refund
+-- them. Each line tracks the units in returns and the units refunded, and
+-- the order the total refunded so far
+
+ALTER TABLE orders.OrderHead DROP CONSTRAINT IF EXISTS orderhead_current_status_check;
+ALTER TABLE orders.OrderHead ADD CONSTRAINT orderhead_current_status_check
+    CHECK (current_status in ('created', 'confirmed', 'processing', 'partially_shipped', 'shipped', 'delivered', 'cancelled', 'partially_refunded', 'refunded'));
+
+ALTER TABLE orders.OrderStatus DROP CONSTRAINT IF EXISTS orderstatus_order_status_check;
+ALTER TABLE orders.OrderStatus ADD CONSTRAINT orderstatus_order_status_check
+    CHECK (order_status in ('created', 'confirmed', 'processing', 'partially_shipped', 'shipped', 'delivered', 'cancelled', 'partially_refunded', 'refunded'));
+
+ALTER TABLE orders.OrderHead ADD COLUMN refunded numeric(19,4) NOT NULL DEFAULT 0;
+
+ALTER TABLE orders.OrderItem ADD COLUMN returned_quantity int NOT NULL DEFAULT 0;
+ALTER TABLE orders.OrderItem ADD COLUMN refunded_quantity int NOT NULL DEFAULT 0;
+ALTER TABLE orders.OrderItem ADD CONSTRAINT orderitem_returned_quantity_check
+    CHECK (refunded_quantity <= returned_quantity AND returned_quantity <= quantity);
+
+CREATE TABLE orders.Return (
+    return_id uuid not null primary key,
+    rma_number text not null unique,
+    order_id uuid not null,
+    status text not null check (status in ('requested', 'approved', 'rejected', 'received', 'refunded')),
+    notes text not null default '',
+    refund_total numeric(19,4) not null default 0,
+    created_at timestamp default CURRENT_TIMESTAMP not null,
+    updated_at timestamp default CURRENT_TIMESTAMP not null
+);
+
+CREATE TABLE orders.ReturnLine (
+    return_id uuid not null,
+    line_number int not null,
+    product_id text not null,
+    quantity int not null check (quantity > 0),
+    reason text not null,
+    restock boolean not null default true,
+    refund_amount numeric(19,4) not null default 0,
+    refund_tax numeric(19,4) not null default 0,
+    primary key (return_id, line_number)
+);
+
+ALTER TABLE IF EXISTS orders.Return
+    ADD CONSTRAINT fk_order_id
+        FOREIGN KEY (order_id)
+            REFERENCES orders.OrderHead(order_id) ON DELETE CASCADE;
+
+ALTER TABLE IF EXISTS orders.ReturnLine
+    ADD CONSTRAINT fk_return_id
+        FOREIGN KEY (return_id)
+            REFERENCES orders.Return(return_id) ON DELETE CASCADE;
+
+CREATE INDEX idx_return_order_id ON orders.Return(order_id);
@@ -0,0 +1,40 @@
+-- Migration: Add card payments taken through a payment gateway
+-- The order total is authorized at creation, captured on the first
+-- shipment, voided on cancellation and refunded with returns. Every
+-- gateway call is recorded under the idempotency key it was made with
+
+CREATE TABLE orders.Payment (
+    payment_id uuid not null primary key,
+    order_id uuid not null unique,
+    gateway text not null,
+    authorization_id text not null,
+    status text not null check (status in ('authorized', 'captured', 'partially_refunded', 'refunded', 'voided')),
+    amount numeric(19,4) not null,
+    captured numeric(19,4) not null default 0,
+    refunded numeric(19,4) not null default 0,
+    created_at timestamp default CURRENT_TIMESTAMP not null,
+    updated_at timestamp default CURRENT_TIMESTAMP not null,
+    CHECK (refunded <= captured AND captured <= amount)
+);
+
+CREATE TABLE orders.PaymentTransaction (
+    payment_id uuid not null,
+    kind text not null check (kind in ('authorize', 'capture', 'void', 'refund')),
+    idempotency_key text not null,
+    transaction_id text not null,
+    status text not null check (status in ('approved', 'declined')),
+    amount numeric(19,4) not null,
+    decline_code text not null default '',
+    created_at timestamp default CURRENT_TIMESTAMP not null,
+    primary key (payment_id, idempotency_key)
+);
+
+ALTER TABLE IF EXISTS orders.Payment
+    ADD CONSTRAINT fk_order_id
+        FOREIGN KEY (order_id)
+            REFERENCES orders.OrderHead(order_id) ON DELETE CASCADE;
+
+ALTER TABLE IF EXISTS orders.PaymentTransaction
+    ADD CONSTRAINT fk_payment_id
+        FOREIGN KEY (payment_id)
+            REFERENCES orders.Payment(payment_id) ON DELETE CASCADE;
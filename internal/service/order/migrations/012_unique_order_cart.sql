@@ -0,0 +1,10 @@
+-- Migration: One live order per checked out cart
+-- A redelivered cart.checked_out must not create a second order, nor a
+-- second payment on the same cart. A cart whose checkout was rolled back
+-- is reopened and can be checked out again, so cancelled orders do not
+-- count
+
+DROP INDEX IF EXISTS orders.idx_orderhead_cart_id;
+CREATE INDEX idx_orderhead_cart_id ON orders.OrderHead(cart_id);
+CREATE UNIQUE INDEX idx_orderhead_live_cart_id ON orders.OrderHead(cart_id)
+    WHERE current_status <> 'cancelled';
@@ -0,0 +1,42 @@
+-- Migration: Store order amounts in whole cents
+-- Amounts used to be computed in float64 and written to numeric(19,4)
+-- columns, so older rows can hold fractions of a cent. The service reads
+-- amounts as integer cents, rounding half to even; round the stored
+-- amounts the same way and narrow the columns so they match what is read
+
+CREATE FUNCTION orders.round_half_even(amount numeric) RETURNS numeric AS $$
+    SELECT CASE
+        WHEN abs(amount * 100 - trunc(amount * 100)) = 0.5
+            THEN (trunc(amount * 100) + abs(trunc(amount * 100) % 2) * sign(amount)) / 100
+        ELSE round(amount, 2)
+    END
+$$ LANGUAGE sql IMMUTABLE;
+
+ALTER TABLE orders.OrderItem
+    ALTER COLUMN unit_price TYPE numeric(19,2) USING orders.round_half_even(unit_price),
+    ALTER COLUMN total_price TYPE numeric(19,2) USING orders.round_half_even(total_price),
+    ALTER COLUMN tax TYPE numeric(19,2) USING orders.round_half_even(tax);
+
+ALTER TABLE orders.OrderHead
+    ALTER COLUMN net_price TYPE numeric(19,2) USING orders.round_half_even(net_price),
+    ALTER COLUMN tax TYPE numeric(19,2) USING orders.round_half_even(tax),
+    ALTER COLUMN shipping TYPE numeric(19,2) USING orders.round_half_even(shipping),
+    ALTER COLUMN total_price TYPE numeric(19,2) USING orders.round_half_even(total_price),
+    ALTER COLUMN refunded TYPE numeric(19,2) USING orders.round_half_even(refunded);
+
+ALTER TABLE orders.Return
+    ALTER COLUMN refund_total TYPE numeric(19,2) USING orders.round_half_even(refund_total);
+
+ALTER TABLE orders.ReturnLine
+    ALTER COLUMN refund_amount TYPE numeric(19,2) USING orders.round_half_even(refund_amount),
+    ALTER COLUMN refund_tax TYPE numeric(19,2) USING orders.round_half_even(refund_tax);
+
+ALTER TABLE orders.Payment
+    ALTER COLUMN amount TYPE numeric(19,2) USING orders.round_half_even(amount),
+    ALTER COLUMN captured TYPE numeric(19,2) USING orders.round_half_even(captured),
+    ALTER COLUMN refunded TYPE numeric(19,2) USING orders.round_half_even(refunded);
+
+ALTER TABLE orders.PaymentTransaction
+    ALTER COLUMN amount TYPE numeric(19,2) USING orders.round_half_even(amount);
+
+DROP FUNCTION orders.round_half_even(numeric);
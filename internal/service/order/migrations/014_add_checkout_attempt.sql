@@ -0,0 +1,7 @@
+-- Migration: Record the checkout attempt of each order
+-- A cart whose checkout was rolled back can be checked out again. Each
+-- checkout of a cart is numbered, so that rolling one back cancels only
+-- the orders created for it. Orders created before checkouts were numbered
+-- keep attempt 0
+
+ALTER TABLE orders.OrderHead ADD COLUMN checkout_attempt integer NOT NULL DEFAULT 0;
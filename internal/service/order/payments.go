@@ -0,0 +1,176 @@
+package order
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"go-shopping-poc/internal/platform/money"
+	"go-shopping-poc/internal/platform/payment"
+)
+
+// An order is paid for with a card payment taken through a gateway. The
+// total is authorized when the order is created, which confirms it; a
+// declined card rejects the checkout instead. The authorization is
+// captured when the first shipment is booked, voided when the order is
+// cancelled, and refunded with its returns. Every gateway call is recorded
+// as a transaction under the idempotency key it was made with, so a call
+// whose outcome was recorded is not made again.
+
+// Payment status
+const (
+	PaymentAuthorized        = "authorized"
+	PaymentCaptured          = "captured"
+	PaymentPartiallyRefunded = "partially_refunded"
+	PaymentRefunded          = "refunded"
+	PaymentVoided            = "voided"
+)
+
+// Kind of a payment transaction
+const (
+	PaymentKindAuthorize = "authorize"
+	PaymentKindCapture   = "capture"
+	PaymentKindVoid      = "void"
+	PaymentKindRefund    = "refund"
+)
+
+// Payment is the card payment of an order.
+type Payment struct {
+	PaymentID       uuid.UUID   `json:"payment_id" db:"payment_id"`
+	OrderID         uuid.UUID   `json:"order_id" db:"order_id"`
+	Gateway         string      `json:"gateway" db:"gateway"`
+	AuthorizationID string      `json:"authorization_id" db:"authorization_id"`
+	Status          string      `json:"status" db:"status"`
+	Amount          money.Money `json:"amount" db:"amount"`
+	Captured        money.Money `json:"captured" db:"captured"`
+	Refunded        money.Money `json:"refunded" db:"refunded"`
+	CreatedAt       time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time   `json:"updated_at" db:"updated_at"`
+
+	Transactions []PaymentTransaction `json:"transactions,omitempty" db:"-"`
+}
+
+// PaymentTransaction is a gateway call made for a payment.
+type PaymentTransaction struct {
+	PaymentID      uuid.UUID   `json:"-" db:"payment_id"`
+	Kind           string      `json:"kind" db:"kind"`
+	IdempotencyKey string      `json:"idempotency_key" db:"idempotency_key"`
+	TransactionID  string      `json:"transaction_id" db:"transaction_id"`
+	Status         string      `json:"status" db:"status"`
+	Amount         money.Money `json:"amount" db:"amount"`
+	DeclineCode    string      `json:"decline_code,omitempty" db:"decline_code"`
+	CreatedAt      time.Time   `json:"created_at" db:"created_at"`
+}
+
+// Approved reports whether the gateway approved the call.
+func (t *PaymentTransaction) Approved() bool {
+	return t.Status == payment.StatusApproved
+}
+
+// paymentMoves lists the statuses each transaction kind moves a payment
+// from, and the status it moves it to. Refunds end in partially_refunded
+// or refunded depending on what is left.
+var paymentMoves = map[string]struct {
+	from []string
+	to   string
+}{
+	PaymentKindCapture: {from: []string{PaymentAuthorized}, to: PaymentCaptured},
+	PaymentKindVoid:    {from: []string{PaymentAuthorized}, to: PaymentVoided},
+	PaymentKindRefund:  {from: []string{PaymentCaptured, PaymentPartiallyRefunded}, to: PaymentRefunded},
+}
+
+// NewPayment returns the payment of an authorization the gateway named
+// gateway approved for amount under key.
+func NewPayment(gateway string, key string, amount money.Money, result *payment.Result, at time.Time) *Payment {
+	p := &Payment{
+		PaymentID:       uuid.New(),
+		Gateway:         gateway,
+		AuthorizationID: result.AuthorizationID,
+		Status:          PaymentAuthorized,
+		Amount:          amount,
+		CreatedAt:       at,
+		UpdatedAt:       at,
+	}
+	p.record(PaymentKindAuthorize, key, amount, result, at)
+	return p
+}
+
+// CanMove reports whether a transaction of kind can be made for amount.
+func (p *Payment) CanMove(kind string, amount money.Money) error {
+	move, ok := paymentMoves[kind]
+	if !ok || !contains(move.from, p.Status) {
+		return fmt.Errorf("%w: cannot %s a payment that is %s", ErrInvalidPaymentTransition, kind, p.Status)
+	}
+	if kind != PaymentKindRefund {
+		return nil
+	}
+	left, err := p.Captured.Sub(p.Refunded)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidPaymentTransition, err)
+	}
+	if exceeds, err := amount.GreaterThan(left); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidPaymentTransition, err)
+	} else if exceeds {
+		return fmt.Errorf("%w: refund of %s exceeds what is left of %s captured", ErrInvalidPaymentTransition, amount, p.Captured)
+	}
+	return nil
+}
+
+// Apply records a gateway call of kind for amount made under key and,
+// when it was approved, moves the payment along with it. It returns the
+// transaction recorded.
+func (p *Payment) Apply(kind string, key string, amount money.Money, result *payment.Result, at time.Time) (PaymentTransaction, error) {
+	if err := p.CanMove(kind, amount); err != nil {
+		return PaymentTransaction{}, err
+	}
+	txn := p.record(kind, key, amount, result, at)
+	if !txn.Approved() {
+		return txn, nil
+	}
+
+	p.Status = paymentMoves[kind].to
+	switch kind {
+	case PaymentKindCapture:
+		p.Captured = amount
+	case PaymentKindRefund:
+		// CanMove checked the refund is in the captured currency.
+		p.Refunded, _ = p.Refunded.Add(amount)
+		if !p.Refunded.Equal(p.Captured) {
+			p.Status = PaymentPartiallyRefunded
+		}
+	}
+	p.UpdatedAt = at
+	return txn, nil
+}
+
+// Transaction returns the transaction made under key, or nil if none was.
+func (p *Payment) Transaction(key string) *PaymentTransaction {
+	for i := range p.Transactions {
+		if p.Transactions[i].IdempotencyKey == key {
+			return &p.Transactions[i]
+		}
+	}
+	return nil
+}
+
+func (p *Payment) record(kind string, key string, amount money.Money, result *payment.Result, at time.Time) PaymentTransaction {
+	txn := PaymentTransaction{
+		PaymentID:      p.PaymentID,
+		Kind:           kind,
+		IdempotencyKey: key,
+		TransactionID:  result.TransactionID,
+		Status:         result.Status,
+		Amount:         amount,
+		DeclineCode:    result.DeclineCode,
+		CreatedAt:      at,
+	}
+	p.Transactions = append(p.Transactions, txn)
+	return txn
+}
+
+// paymentKey returns the idempotency key of a gateway call of kind for
+// what ref names, such as a return.
+func paymentKey(ref string, kind string) string {
+	return ref + ":" + kind
+}
@@ -0,0 +1,169 @@
+package order
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"go-shopping-poc/internal/platform/money"
+	"go-shopping-poc/internal/platform/money/moneytest"
+	"go-shopping-poc/internal/platform/payment"
+)
+
+var (
+	approved = &payment.Result{Status: payment.StatusApproved, TransactionID: "txn"}
+	declined = &payment.Result{Status: payment.StatusDeclined, TransactionID: "txn", DeclineCode: "insufficient_funds"}
+)
+
+// newPayment returns a payment of 50.00 in status, with captured and
+// refunded so far.
+func newPayment(status, captured, refunded string) *Payment {
+	return &Payment{
+		Status:   status,
+		Amount:   moneytest.USD("50.00"),
+		Captured: moneytest.USD(captured),
+		Refunded: moneytest.USD(refunded),
+	}
+}
+
+func TestPaymentCanMove(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		payment  *Payment
+		kind     string
+		amount   money.Money
+		wantMove bool
+	}{
+		{"capture authorized", newPayment(PaymentAuthorized, "0", "0"), PaymentKindCapture, moneytest.USD("50.00"), true},
+		{"void authorized", newPayment(PaymentAuthorized, "0", "0"), PaymentKindVoid, moneytest.USD("50.00"), true},
+		{"refund authorized", newPayment(PaymentAuthorized, "0", "0"), PaymentKindRefund, moneytest.USD("10.00"), false},
+		{"capture captured", newPayment(PaymentCaptured, "50.00", "0"), PaymentKindCapture, moneytest.USD("50.00"), false},
+		{"void captured", newPayment(PaymentCaptured, "50.00", "0"), PaymentKindVoid, moneytest.USD("50.00"), false},
+		{"refund captured", newPayment(PaymentCaptured, "50.00", "0"), PaymentKindRefund, moneytest.USD("10.00"), true},
+		{"refund all captured", newPayment(PaymentCaptured, "50.00", "0"), PaymentKindRefund, moneytest.USD("50.00"), true},
+		{"refund over captured", newPayment(PaymentCaptured, "50.00", "0"), PaymentKindRefund, moneytest.USD("50.01"), false},
+		{"refund what is left", newPayment(PaymentPartiallyRefunded, "50.00", "20.00"), PaymentKindRefund, moneytest.USD("30.00"), true},
+		{"refund over what is left", newPayment(PaymentPartiallyRefunded, "50.00", "20.00"), PaymentKindRefund, moneytest.USD("30.01"), false},
+		{"refund other currency", newPayment(PaymentCaptured, "50.00", "0"), PaymentKindRefund, money.MustParse("10.00", "EUR"), false},
+		{"refund refunded", newPayment(PaymentRefunded, "50.00", "50.00"), PaymentKindRefund, moneytest.USD("0.01"), false},
+		{"capture voided", newPayment(PaymentVoided, "0", "0"), PaymentKindCapture, moneytest.USD("50.00"), false},
+		{"unknown kind", newPayment(PaymentAuthorized, "0", "0"), PaymentKindAuthorize, moneytest.USD("50.00"), false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.payment.CanMove(tt.kind, tt.amount)
+			if tt.wantMove && err != nil {
+				t.Errorf("CanMove() error = %v, want nil", err)
+			}
+			if !tt.wantMove && !errors.Is(err, ErrInvalidPaymentTransition) {
+				t.Errorf("CanMove() error = %v, want %v", err, ErrInvalidPaymentTransition)
+			}
+		})
+	}
+}
+
+func TestPaymentApply(t *testing.T) {
+	t.Parallel()
+
+	type call struct {
+		kind   string
+		amount string
+		result *payment.Result
+	}
+	tests := []struct {
+		name         string
+		calls        []call
+		wantStatus   string
+		wantCaptured string
+		wantRefunded string
+	}{
+		{
+			name:         "capture",
+			calls:        []call{{PaymentKindCapture, "50.00", approved}},
+			wantStatus:   PaymentCaptured,
+			wantCaptured: "50.00",
+			wantRefunded: "0",
+		},
+		{
+			name:         "declined capture",
+			calls:        []call{{PaymentKindCapture, "50.00", declined}},
+			wantStatus:   PaymentAuthorized,
+			wantCaptured: "0",
+			wantRefunded: "0",
+		},
+		{
+			name:         "void",
+			calls:        []call{{PaymentKindVoid, "50.00", approved}},
+			wantStatus:   PaymentVoided,
+			wantCaptured: "0",
+			wantRefunded: "0",
+		},
+		{
+			name:         "partial refund",
+			calls:        []call{{PaymentKindCapture, "50.00", approved}, {PaymentKindRefund, "20.00", approved}},
+			wantStatus:   PaymentPartiallyRefunded,
+			wantCaptured: "50.00",
+			wantRefunded: "20.00",
+		},
+		{
+			name:         "refunds adding up to capture",
+			calls:        []call{{PaymentKindCapture, "50.00", approved}, {PaymentKindRefund, "20.00", approved}, {PaymentKindRefund, "30.00", approved}},
+			wantStatus:   PaymentRefunded,
+			wantCaptured: "50.00",
+			wantRefunded: "50.00",
+		},
+		{
+			name:         "declined refund",
+			calls:        []call{{PaymentKindCapture, "50.00", approved}, {PaymentKindRefund, "20.00", declined}},
+			wantStatus:   PaymentCaptured,
+			wantCaptured: "50.00",
+			wantRefunded: "0",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			at := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+			p := NewPayment("fake", "order:authorize", moneytest.USD("50.00"), approved, at)
+			for i, c := range tt.calls {
+				txn, err := p.Apply(c.kind, paymentKey(fmt.Sprintf("call-%d", i), c.kind), moneytest.USD(c.amount), c.result, at)
+				if err != nil {
+					t.Fatalf("call %d: Apply() error = %v", i, err)
+				}
+				if txn.Approved() != (c.result.Status == payment.StatusApproved) {
+					t.Errorf("call %d: transaction status = %s, want %s", i, txn.Status, c.result.Status)
+				}
+			}
+			if p.Status != tt.wantStatus {
+				t.Errorf("Status = %s, want %s", p.Status, tt.wantStatus)
+			}
+			if want := moneytest.USD(tt.wantCaptured); !p.Captured.Equal(want) {
+				t.Errorf("Captured = %s, want %s", p.Captured, want)
+			}
+			if want := moneytest.USD(tt.wantRefunded); !p.Refunded.Equal(want) {
+				t.Errorf("Refunded = %s, want %s", p.Refunded, want)
+			}
+			if got, want := len(p.Transactions), len(tt.calls)+1; got != want {
+				t.Errorf("recorded %d transactions, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestPaymentApplyRejectedMoveRecordsNothing(t *testing.T) {
+	t.Parallel()
+
+	p := newPayment(PaymentCaptured, "50.00", "0")
+	if _, err := p.Apply(PaymentKindRefund, "ref:refund", moneytest.USD("60.00"), approved, time.Now()); !errors.Is(err, ErrInvalidPaymentTransition) {
+		t.Fatalf("Apply() error = %v, want %v", err, ErrInvalidPaymentTransition)
+	}
+	if len(p.Transactions) != 0 || p.Status != PaymentCaptured || !p.Refunded.IsZero() {
+		t.Errorf("rejected refund changed the payment: %+v", p)
+	}
+}
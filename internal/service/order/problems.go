@@ -0,0 +1,30 @@
+package order
+
+import (
+	"net/http"
+
+	platformerrors "go-shopping-poc/internal/platform/errors"
+	"go-shopping-poc/internal/platform/httperr"
+)
+
+// init maps order domain errors to problem responses.
+func init() {
+	httperr.Register(ErrOrderNotFound, httperr.Mapping{Status: http.StatusNotFound, Type: platformerrors.ErrorTypeNotFound, Code: "order_not_found", Message: "Order not found"})
+	httperr.Register(ErrOrderItemNotFound, httperr.Mapping{Status: http.StatusNotFound, Type: platformerrors.ErrorTypeNotFound, Code: "order_item_not_found", Message: "Order item not found"})
+	httperr.Register(ErrInvalidUUID, httperr.Mapping{Status: http.StatusBadRequest, Type: platformerrors.ErrorTypeInvalidRequest, Code: "order_invalid_id", Message: "Invalid order ID"})
+	httperr.Register(ErrInvalidStatusTransition, httperr.Mapping{Status: http.StatusBadRequest, Type: platformerrors.ErrorTypeValidation, Code: "order_invalid_status_transition", ExposeDetail: true})
+	httperr.Register(ErrOrderCannotBeCancelled, httperr.Mapping{Status: http.StatusBadRequest, Type: platformerrors.ErrorTypeValidation, Code: "order_not_cancellable", ExposeDetail: true})
+	httperr.Register(ErrOrderNotReorderable, httperr.Mapping{Status: http.StatusConflict, Type: platformerrors.ErrorTypeConflict, Code: "order_not_reorderable", ExposeDetail: true})
+	httperr.Register(ErrReorderFailed, httperr.Mapping{Status: http.StatusBadGateway, Type: platformerrors.ErrorTypeInternal, Code: "reorder_failed", Message: "Reorder could not be completed"})
+	httperr.Register(ErrOrderNotFulfillable, httperr.Mapping{Status: http.StatusConflict, Type: platformerrors.ErrorTypeConflict, Code: "order_not_fulfillable", ExposeDetail: true})
+	httperr.Register(ErrLineNotShippable, httperr.Mapping{Status: http.StatusConflict, Type: platformerrors.ErrorTypeConflict, Code: "order_line_not_shippable", ExposeDetail: true})
+	httperr.Register(ErrShipmentNotFound, httperr.Mapping{Status: http.StatusNotFound, Type: platformerrors.ErrorTypeNotFound, Code: "shipment_not_found", Message: "Shipment not found"})
+	httperr.Register(ErrOrderNotReturnable, httperr.Mapping{Status: http.StatusConflict, Type: platformerrors.ErrorTypeConflict, Code: "order_not_returnable", ExposeDetail: true})
+	httperr.Register(ErrLineNotReturnable, httperr.Mapping{Status: http.StatusConflict, Type: platformerrors.ErrorTypeConflict, Code: "order_line_not_returnable", ExposeDetail: true})
+	httperr.Register(ErrReturnNotFound, httperr.Mapping{Status: http.StatusNotFound, Type: platformerrors.ErrorTypeNotFound, Code: "return_not_found", Message: "Return not found"})
+	httperr.Register(ErrInvalidReturnTransition, httperr.Mapping{Status: http.StatusConflict, Type: platformerrors.ErrorTypeConflict, Code: "return_invalid_status_transition", ExposeDetail: true})
+	httperr.Register(ErrPaymentDeclined, httperr.Mapping{Status: http.StatusPaymentRequired, Type: platformerrors.ErrorTypeConflict, Code: "payment_declined", ExposeDetail: true})
+	httperr.Register(ErrInvalidPaymentTransition, httperr.Mapping{Status: http.StatusConflict, Type: platformerrors.ErrorTypeConflict, Code: "payment_invalid_status_transition", ExposeDetail: true})
+	httperr.Register(ErrIdentityClaimsIncomplete, httperr.Mapping{Status: http.StatusForbidden, Type: platformerrors.ErrorTypeForbidden, Code: "identity_claims_incomplete", Message: "authentication failed"})
+	httperr.Register(ErrIdentityVerificationTimeout, httperr.Mapping{Status: http.StatusGatewayTimeout, Type: platformerrors.ErrorTypeGatewayTimeout, Code: "identity_verification_timeout", Message: "identity verification failed: timeout"})
+}
@@ -0,0 +1,121 @@
+package order
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	events "go-shopping-poc/internal/contracts/events"
+)
+
+// defaultReorderTimeout bounds how long Reorder waits for the cart service.
+const defaultReorderTimeout = 5 * time.Second
+
+// ReorderResult is the outcome of a reorder. Added holds the items put in
+// the cart, confirmed or awaiting validation; Unavailable those the cart
+// could not take or holds on backorder. A Pending result timed out waiting
+// for the cart service, which may still complete it.
+type ReorderResult struct {
+	RequestID   string                     `json:"request_id"`
+	OrderID     string                     `json:"order_id"`
+	CartID      string                     `json:"cart_id,omitempty"`
+	Pending     bool                       `json:"pending,omitempty"`
+	Added       []events.ReorderLineResult `json:"added"`
+	Unavailable []events.ReorderLineResult `json:"unavailable"`
+}
+
+type reorderReply struct {
+	payload events.CartReorderResultPayload
+}
+
+// Reorder asks the cart service to add the items of order to the active cart
+// of customerID and waits for its answer.
+func (s *OrderService) Reorder(ctx context.Context, order *Order, customerID string) (*ReorderResult, error) {
+	if len(order.Items) == 0 {
+		return nil, fmt.Errorf("%w: order has no items", ErrOrderNotReorderable)
+	}
+
+	items := make([]events.ReorderItem, 0, len(order.Items))
+	for _, item := range order.Items {
+		items = append(items, events.ReorderItem{
+			ProductID:   item.ProductID,
+			ProductName: item.ProductName,
+			Quantity:    item.Quantity,
+			ImageURL:    item.ImageURL,
+		})
+	}
+
+	orderID := order.OrderID.String()
+	requestID := fmt.Sprintf("reorder-%s-%d", orderID, time.Now().UnixNano())
+	ch := make(chan reorderReply, 1)
+
+	s.mu.Lock()
+	s.reorderCallbacks[requestID] = ch
+	s.mu.Unlock()
+
+	reqEvent := events.NewOrderReorderRequestedEvent(requestID, orderID, customerID, order.Currency, items)
+	if err := s.infrastructure.EventBus.Publish(ctx, reqEvent.Topic(), reqEvent); err != nil {
+		s.mu.Lock()
+		delete(s.reorderCallbacks, requestID)
+		s.mu.Unlock()
+		return nil, fmt.Errorf("failed to publish reorder request: %w", err)
+	}
+
+	select {
+	case reply := <-ch:
+		if reply.payload.Error != "" {
+			return nil, fmt.Errorf("%w: %s", ErrReorderFailed, reply.payload.Error)
+		}
+		result := &ReorderResult{
+			RequestID:   requestID,
+			OrderID:     orderID,
+			CartID:      reply.payload.CartID,
+			Added:       []events.ReorderLineResult{},
+			Unavailable: []events.ReorderLineResult{},
+		}
+		for _, line := range reply.payload.Lines {
+			switch line.Status {
+			case events.ReorderLineAdded, events.ReorderLinePending:
+				result.Added = append(result.Added, line)
+			default:
+				result.Unavailable = append(result.Unavailable, line)
+			}
+		}
+		s.logger.Info("Order reordered",
+			"order_id", orderID,
+			"cart_id", result.CartID,
+			"added", len(result.Added),
+			"unavailable", len(result.Unavailable),
+		)
+		return result, nil
+	case <-time.After(s.reorderTimeout):
+		s.mu.Lock()
+		delete(s.reorderCallbacks, requestID)
+		s.mu.Unlock()
+		s.logger.Warn("Reorder response timed out", "order_id", orderID, "request_id", requestID)
+		return &ReorderResult{RequestID: requestID, OrderID: orderID, Pending: true}, nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		delete(s.reorderCallbacks, requestID)
+		s.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// DispatchReorderResult signals a waiting Reorder call with the answer of
+// the cart service.
+func (s *OrderService) DispatchReorderResult(payload events.CartReorderResultPayload) {
+	s.mu.Lock()
+	ch, ok := s.reorderCallbacks[payload.RequestID]
+	if ok {
+		delete(s.reorderCallbacks, payload.RequestID)
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		s.logger.Debug("Reorder response for unknown request", "request_id", payload.RequestID)
+		return
+	}
+
+	ch <- reorderReply{payload: payload}
+}
@@ -0,0 +1,159 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	events "go-shopping-poc/internal/contracts/events"
+	"go-shopping-poc/internal/platform/event/bus"
+)
+
+// reorderBus answers each reorder request published to it with the reply
+// of the test, as the cart service would, or not at all when reply is nil.
+type reorderBus struct {
+	bus.Bus
+	service *OrderService
+	reply   func(req events.OrderReorderRequestPayload) events.CartReorderResultPayload
+}
+
+func (b *reorderBus) Publish(ctx context.Context, topic string, event events.Event) error {
+	req, ok := event.(*events.OrderReorderRequestEvent)
+	if !ok {
+		return errors.New("unexpected event " + event.Type())
+	}
+	if b.reply != nil {
+		go b.service.DispatchReorderResult(b.reply(req.Data))
+	}
+	return nil
+}
+
+func newReorderService(reply func(req events.OrderReorderRequestPayload) events.CartReorderResultPayload) *OrderService {
+	b := &reorderBus{reply: reply}
+	s := NewOrderService(slog.New(slog.DiscardHandler), &OrderInfrastructure{EventBus: b}, &Config{})
+	s.reorderTimeout = 50 * time.Millisecond
+	b.service = s
+	return s
+}
+
+func reorderableOrder(products ...string) *Order {
+	order := &Order{OrderID: uuid.New(), Currency: "USD"}
+	for _, productID := range products {
+		order.Items = append(order.Items, OrderItem{ProductID: productID, Quantity: 1})
+	}
+	return order
+}
+
+// lineProducts describes lines as product:status.
+func lineProducts(lines []events.ReorderLineResult) string {
+	var parts []string
+	for _, line := range lines {
+		parts = append(parts, line.ProductID+":"+line.Status)
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestReorder(t *testing.T) {
+	t.Parallel()
+
+	// answer echoes every item of the request with the status the cart
+	// service gave its product.
+	answer := func(statuses map[string]string) func(req events.OrderReorderRequestPayload) events.CartReorderResultPayload {
+		return func(req events.OrderReorderRequestPayload) events.CartReorderResultPayload {
+			payload := events.CartReorderResultPayload{RequestID: req.RequestID, OrderID: req.OrderID, CartID: "cart-1"}
+			for _, item := range req.Items {
+				payload.Lines = append(payload.Lines, events.ReorderLineResult{ProductID: item.ProductID, Quantity: item.Quantity, Status: statuses[item.ProductID]})
+			}
+			return payload
+		}
+	}
+
+	tests := []struct {
+		name            string
+		order           *Order
+		reply           func(req events.OrderReorderRequestPayload) events.CartReorderResultPayload
+		wantErr         error
+		wantStatus      int
+		wantCartID      string
+		wantAdded       string
+		wantUnavailable string
+	}{
+		{
+			name:  "lines are split into added and unavailable",
+			order: reorderableOrder("p1", "p2", "p3", "p4"),
+			reply: answer(map[string]string{
+				"p1": events.ReorderLineAdded,
+				"p2": events.ReorderLinePending,
+				"p3": events.ReorderLineBackorder,
+				"p4": events.ReorderLineUnavailable,
+			}),
+			wantStatus:      http.StatusOK,
+			wantCartID:      "cart-1",
+			wantAdded:       "p1:added p2:pending_validation",
+			wantUnavailable: "p3:backorder p4:unavailable",
+		},
+		{
+			name:       "no answer in time is pending",
+			order:      reorderableOrder("p1"),
+			wantStatus: http.StatusAccepted,
+		},
+		{
+			name:  "cart service error fails the reorder",
+			order: reorderableOrder("p1"),
+			reply: func(req events.OrderReorderRequestPayload) events.CartReorderResultPayload {
+				return events.CartReorderResultPayload{RequestID: req.RequestID, Error: "invalid customer"}
+			},
+			wantErr: ErrReorderFailed,
+		},
+		{
+			name:    "order without items is refused",
+			order:   reorderableOrder(),
+			wantErr: ErrOrderNotReorderable,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := newReorderService(tt.reply)
+			result, err := s.Reorder(context.Background(), tt.order, uuid.NewString())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Reorder() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if got := reorderStatus(result); got != tt.wantStatus {
+				t.Errorf("reorderStatus() = %d, want %d", got, tt.wantStatus)
+			}
+			if result.CartID != tt.wantCartID {
+				t.Errorf("CartID = %q, want %q", result.CartID, tt.wantCartID)
+			}
+			if got := lineProducts(result.Added); got != tt.wantAdded {
+				t.Errorf("Added = %q, want %q", got, tt.wantAdded)
+			}
+			if got := lineProducts(result.Unavailable); got != tt.wantUnavailable {
+				t.Errorf("Unavailable = %q, want %q", got, tt.wantUnavailable)
+			}
+			if result.RequestID == "" || result.OrderID != tt.order.OrderID.String() {
+				t.Errorf("result = %+v, want the request and order IDs", result)
+			}
+
+			s.mu.Lock()
+			waiting := len(s.reorderCallbacks)
+			s.mu.Unlock()
+			if waiting != 0 {
+				t.Errorf("%d reorder callbacks left waiting", waiting)
+			}
+		})
+	}
+}
@@ -17,6 +17,22 @@ var (
 	ErrTransactionFailed       = errors.New("transaction failed")
 	ErrInvalidStatusTransition = errors.New("invalid order status transition")
 	ErrOrderCannotBeCancelled  = errors.New("order cannot be cancelled in current status")
+	ErrOrderNotReorderable     = errors.New("order cannot be reordered")
+	ErrReorderFailed           = errors.New("reorder failed")
+	ErrOrderNotFulfillable     = errors.New("order cannot be fulfilled in current status")
+	ErrLineNotShippable        = errors.New("order line cannot be shipped")
+	ErrShipmentNotFound        = errors.New("shipment not found")
+	ErrOrderNotReturnable      = errors.New("order cannot be returned in current status")
+	ErrLineNotReturnable       = errors.New("order line cannot be returned")
+	ErrReturnNotFound          = errors.New("return not found")
+	ErrInvalidReturnTransition = errors.New("invalid return status transition")
+
+	ErrOrderExists              = errors.New("order of cart exists")
+	ErrPaymentDeclined          = errors.New("payment declined")
+	ErrInvalidPaymentTransition = errors.New("invalid payment status transition")
+
+	ErrIdentityClaimsIncomplete    = errors.New("token is missing identity claims")
+	ErrIdentityVerificationTimeout = errors.New("identity verification timed out")
 )
 
 type OrderRepository interface {
@@ -24,7 +40,18 @@ type OrderRepository interface {
 	GetOrderByID(ctx context.Context, orderID string) (*Order, error)
 	GetOrdersByCustomerID(ctx context.Context, customerID string) ([]Order, error)
 	GetOrdersByCartID(ctx context.Context, cartID string) ([]Order, error)
-	UpdateOrderStatus(ctx context.Context, orderID string, newStatus string, notes string) error
+	UpdateOrderStatus(ctx context.Context, orderID string, newStatus string, notes string, actor string) error
+	RejectCheckout(ctx context.Context, cartID string, attempt int, customerID *string, reason string) error
+
+	CreateShipment(ctx context.Context, order *Order, shipment *Shipment, transitions []StatusTransition) error
+	UpdateShipment(ctx context.Context, order *Order, shipment *Shipment, transitions []StatusTransition) error
+	GetShipmentByTrackingNumber(ctx context.Context, carrier string, trackingNumber string) (*Shipment, error)
+
+	CreateReturn(ctx context.Context, order *Order, ret *Return, actor string) error
+	UpdateReturn(ctx context.Context, order *Order, ret *Return, from string, actor string, transitions []StatusTransition) error
+	GetReturnByID(ctx context.Context, returnID string) (*Return, error)
+
+	UpdatePayment(ctx context.Context, pay *Payment, txn PaymentTransaction) error
 
 	GetStatusHistory(ctx context.Context, orderID string) ([]OrderStatus, error)
 	AddStatusEntry(ctx context.Context, orderID string, status string, notes string) error
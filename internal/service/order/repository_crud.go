@@ -13,6 +13,8 @@ import (
 	"go-shopping-poc/internal/platform/database"
 )
 
+// CreateOrder saves a new order with its payment, if any. It fails with
+// ErrOrderExists if the cart of the order has one not cancelled already.
 func (r *orderRepository) CreateOrder(ctx context.Context, order *Order) error {
 	r.logger.Debug("Creating new order")
 
@@ -55,22 +57,30 @@ func (r *orderRepository) CreateOrder(ctx context.Context, order *Order) error {
 	query := `
 		INSERT INTO orders.OrderHead (
 			order_id, order_number, cart_id, customer_id, contact_id, credit_card_id,
-			currency, net_price, tax, shipping, total_price, current_status,
-			created_at, updated_at
+			currency, net_price, tax, shipping, shipping_method, discount, total_price, current_status,
+			created_at, updated_at, checkout_attempt
 		) VALUES (
 			$1, $2, $3, $4, $5, $6,
-			$7, $8, $9, $10, $11, $12,
-			$13, $14
+			$7, $8, $9, $10, $11, $12, $13, $14,
+			$15, $16, $17
 		)
+		ON CONFLICT (cart_id) WHERE current_status <> 'cancelled' DO NOTHING
 	`
 
-	_, err = tx.ExecContext(ctx, query,
+	result, err := tx.ExecContext(ctx, query,
 		order.OrderID, order.OrderNumber, order.CartID, order.CustomerID, order.ContactID, order.CreditCardID,
-		order.Currency, order.NetPrice, order.Tax, order.Shipping, order.TotalPrice, order.CurrentStatus,
-		order.CreatedAt, order.UpdatedAt)
+		order.Currency, order.NetPrice, order.Tax, order.Shipping, order.ShippingMethod, order.Discount, order.TotalPrice, order.CurrentStatus,
+		order.CreatedAt, order.UpdatedAt, order.Attempt)
 	if err != nil {
 		return fmt.Errorf("%w: failed to insert order: %w", ErrDatabaseOperation, err)
 	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: failed to get rows affected: %w", ErrDatabaseOperation, err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("%w: cart %s", ErrOrderExists, order.CartID)
+	}
 
 	for i := range order.Items {
 		order.Items[i].OrderID = order.OrderID
@@ -86,7 +96,7 @@ func (r *orderRepository) CreateOrder(ctx context.Context, order *Order) error {
 		}
 	}
 
-	if err := r.addStatusEntryTx(ctx, tx, order.OrderID.String(), "created", ""); err != nil {
+	if err := r.addStatusEntryTx(ctx, tx, order.OrderID.String(), "created", "", ActorSystem); err != nil {
 		return err
 	}
 
@@ -96,10 +106,25 @@ func (r *orderRepository) CreateOrder(ctx context.Context, order *Order) error {
 		customerIDStr = &id
 	}
 	evt := events.NewOrderCreatedEvent(order.OrderID.String(), order.OrderNumber, order.CartID.String(), customerIDStr, order.TotalPrice)
+	evt.Data.Attempt = order.Attempt
 	if err := r.outboxWriter.WriteEvent(ctx, tx, evt); err != nil {
 		return fmt.Errorf("failed to write order created event: %w", err)
 	}
 
+	// An authorized payment confirms the order
+	if order.Payment != nil {
+		if err := r.insertPaymentTx(ctx, tx, order); err != nil {
+			return err
+		}
+		if err := order.SetStatus("confirmed", "Payment authorized", ActorSystem); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidStatusTransition, err)
+		}
+		transition := StatusTransition{From: "created", OrderStatus: order.StatusHistory[len(order.StatusHistory)-1]}
+		if err := r.changeStatusTx(ctx, tx, order, transition); err != nil {
+			return err
+		}
+	}
+
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("%w: failed to commit transaction: %w", ErrTransactionFailed, err)
 	}
@@ -155,13 +180,15 @@ func (r *orderRepository) insertCreditCardTx(ctx context.Context, tx database.Tx
 
 func (r *orderRepository) insertOrderItemTx(ctx context.Context, tx database.Tx, item *OrderItem) error {
 	query := `
-		INSERT INTO orders.OrderItem (order_id, line_number, product_id, product_name, unit_price, quantity, total_price, image_url, item_status, item_status_date_time)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		INSERT INTO orders.OrderItem (order_id, line_number, product_id, product_name, unit_price, quantity, total_price, image_url, item_status, item_status_date_time,
+		                              tax_code, tax, tax_breakdown, discount, size, color, gift_message)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
 	`
 
 	_, err := tx.ExecContext(ctx, query,
 		item.OrderID, item.LineNumber, item.ProductID, item.ProductName,
 		item.UnitPrice, item.Quantity, item.TotalPrice, item.ImageURL, item.ItemStatus, item.ItemStatusDate,
+		item.TaxCode, item.Tax, item.TaxBreakdown, item.Discount, item.Size, item.Color, item.GiftMessage,
 	)
 	if err != nil {
 		return fmt.Errorf("%w: failed to insert order item: %w", ErrDatabaseOperation, err)
@@ -195,8 +222,8 @@ func (r *orderRepository) GetOrderByID(ctx context.Context, orderID string) (*Or
 
 	query := `
 		SELECT order_id, order_number, cart_id, customer_id, contact_id, credit_card_id,
-		       currency, net_price, tax, shipping, total_price, current_status,
-		       created_at, updated_at
+		       currency, net_price, tax, shipping, shipping_method, discount, total_price, refunded, current_status,
+		       created_at, updated_at, checkout_attempt
 		FROM orders.OrderHead
 		WHERE order_id = $1
 	`
@@ -225,8 +252,8 @@ func (r *orderRepository) GetOrdersByCustomerID(ctx context.Context, customerID
 
 	query := `
 		SELECT order_id, order_number, cart_id, customer_id, contact_id, credit_card_id,
-		       currency, net_price, tax, shipping, total_price, current_status,
-		       created_at, updated_at
+		       currency, net_price, tax, shipping, shipping_method, discount, total_price, refunded, current_status,
+		       created_at, updated_at, checkout_attempt
 		FROM orders.OrderHead
 		WHERE customer_id = $1
 		ORDER BY created_at DESC
@@ -255,8 +282,8 @@ func (r *orderRepository) GetOrdersByCartID(ctx context.Context, cartID string)
 
 	query := `
 		SELECT order_id, order_number, cart_id, customer_id, contact_id, credit_card_id,
-		       currency, net_price, tax, shipping, total_price, current_status,
-		       created_at, updated_at
+		       currency, net_price, tax, shipping, shipping_method, discount, total_price, refunded, current_status,
+		       created_at, updated_at, checkout_attempt
 		FROM orders.OrderHead
 		WHERE cart_id = $1
 		ORDER BY created_at DESC
@@ -277,15 +304,20 @@ func (r *orderRepository) GetOrdersByCartID(ctx context.Context, cartID string)
 	return orders, nil
 }
 
-func (r *orderRepository) UpdateOrderStatus(ctx context.Context, orderID string, newStatus string, notes string) error {
+// UpdateOrderStatus moves an order to newStatus and writes an
+// order.status_changed event for the transition, plus order.cancelled when
+// the order is cancelled.
+func (r *orderRepository) UpdateOrderStatus(ctx context.Context, orderID string, newStatus string, notes string, actor string) error {
 	order, err := r.GetOrderByID(ctx, orderID)
 	if err != nil {
 		return err
 	}
 
-	if err := order.SetStatus(newStatus); err != nil {
+	fromStatus := order.CurrentStatus
+	if err := order.SetStatus(newStatus, notes, actor); err != nil {
 		return fmt.Errorf("%w: %w", ErrInvalidStatusTransition, err)
 	}
+	transition := StatusTransition{From: fromStatus, OrderStatus: order.StatusHistory[len(order.StatusHistory)-1]}
 
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -299,33 +331,89 @@ func (r *orderRepository) UpdateOrderStatus(ctx context.Context, orderID string,
 		}
 	}()
 
+	if err := r.changeStatusTx(ctx, tx, order, transition); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w: failed to commit transaction: %w", ErrTransactionFailed, err)
+	}
+	committed = true
+
+	return nil
+}
+
+// changeStatusTx saves a status transition of order within tx, with its
+// history entry and order.status_changed event, plus order.cancelled when
+// the order is cancelled. It fails with ErrInvalidStatusTransition if the
+// order is no longer in the status the transition leaves.
+func (r *orderRepository) changeStatusTx(ctx context.Context, tx database.Tx, order *Order, transition StatusTransition) error {
 	query := `
 		UPDATE orders.OrderHead
 		SET current_status = $1,
 		    updated_at = CURRENT_TIMESTAMP
-		WHERE order_id = $2
+		WHERE order_id = $2 AND current_status = $3
 	`
-	_, err = tx.ExecContext(ctx, query, newStatus, order.OrderID)
+	result, err := tx.ExecContext(ctx, query, transition.Status, order.OrderID, transition.From)
 	if err != nil {
 		return fmt.Errorf("%w: failed to update order status: %w", ErrDatabaseOperation, err)
 	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: failed to get rows affected: %w", ErrDatabaseOperation, err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("%w: order is no longer %s", ErrInvalidStatusTransition, transition.From)
+	}
 
-	if err := r.addStatusEntryTx(ctx, tx, orderID, newStatus, notes); err != nil {
+	if err := r.addStatusEntryTx(ctx, tx, order.OrderID.String(), transition.Status, transition.Notes, transition.Actor); err != nil {
 		return err
 	}
 
-	if newStatus == "cancelled" {
-		var customerIDStr *string
-		if order.CustomerID != nil {
-			id := order.CustomerID.String()
-			customerIDStr = &id
-		}
-		evt := events.NewOrderUpdatedEvent(order.OrderID.String(), order.OrderNumber, order.CartID.String(), customerIDStr, order.TotalPrice)
+	var customerIDStr *string
+	if order.CustomerID != nil {
+		id := order.CustomerID.String()
+		customerIDStr = &id
+	}
+	changed := events.NewOrderStatusChangedEvent(order.OrderID.String(), order.OrderNumber, order.CartID.String(), customerIDStr,
+		transition.From, transition.Status, transition.Notes, transition.Actor, transition.ChangedAt)
+	if err := r.outboxWriter.WriteEvent(ctx, tx, changed); err != nil {
+		return fmt.Errorf("failed to write order status changed event: %w", err)
+	}
+
+	if transition.Status == "cancelled" {
+		evt := events.NewOrderCancelledEvent(order.OrderID.String(), order.OrderNumber, order.CartID.String(), customerIDStr, order.TotalPrice)
+		evt.Data.Attempt = order.Attempt
 		if err := r.outboxWriter.WriteEvent(ctx, tx, evt); err != nil {
 			return fmt.Errorf("failed to write order cancelled event: %w", err)
 		}
 	}
 
+	return nil
+}
+
+// RejectCheckout writes an order.rejected event for checkout attempt of a
+// cart no order could be created for, so that the cart service rolls the
+// checkout back.
+func (r *orderRepository) RejectCheckout(ctx context.Context, cartID string, attempt int, customerID *string, reason string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	evt := events.NewOrderRejectedEvent(cartID, customerID, reason)
+	evt.Data.Attempt = attempt
+	if err := r.outboxWriter.WriteEvent(ctx, tx, evt); err != nil {
+		return fmt.Errorf("failed to write order rejected event: %w", err)
+	}
+
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("%w: failed to commit transaction: %w", ErrTransactionFailed, err)
 	}
@@ -357,6 +445,21 @@ func (r *orderRepository) loadOrderRelations(ctx context.Context, order *Order)
 		return fmt.Errorf("failed to load items: %w", err)
 	}
 
+	order.Shipments, err = r.getShipmentsByOrderID(ctx, order.OrderID)
+	if err != nil {
+		return fmt.Errorf("failed to load shipments: %w", err)
+	}
+
+	order.Returns, err = r.getReturnsByOrderID(ctx, order.OrderID)
+	if err != nil {
+		return fmt.Errorf("failed to load returns: %w", err)
+	}
+
+	order.Payment, err = r.getPaymentByOrderID(ctx, order.OrderID)
+	if err != nil {
+		return fmt.Errorf("failed to load payment: %w", err)
+	}
+
 	order.StatusHistory, err = r.GetStatusHistory(ctx, order.OrderID.String())
 	if err != nil {
 		return fmt.Errorf("failed to load status history: %w", err)
@@ -413,7 +516,8 @@ func (r *orderRepository) getAddressesByOrderID(ctx context.Context, orderID uui
 
 func (r *orderRepository) getOrderItemsByOrderID(ctx context.Context, orderID uuid.UUID) ([]OrderItem, error) {
 	query := `
-		SELECT id, order_id, line_number, product_id, product_name, unit_price, quantity, total_price, image_url, item_status, item_status_date_time
+		SELECT id, order_id, line_number, product_id, product_name, unit_price, quantity, total_price, image_url, item_status, item_status_date_time,
+		       tax_code, tax, tax_breakdown, discount, size, color, gift_message, returned_quantity, refunded_quantity
 		FROM orders.OrderItem
 		WHERE order_id = $1
 		ORDER BY line_number
@@ -436,7 +540,7 @@ func (r *orderRepository) GetStatusHistory(ctx context.Context, orderID string)
 
 	var history []OrderStatus
 	err = r.db.SelectContext(ctx, &history, `
-		SELECT id, order_id, order_status, status_date_time, notes
+		SELECT id, order_id, order_status, status_date_time, notes, actor
 		FROM orders.OrderStatus
 		WHERE order_id = $1
 		ORDER BY status_date_time DESC
@@ -465,16 +569,16 @@ func (r *orderRepository) AddStatusEntry(ctx context.Context, orderID string, st
 	return nil
 }
 
-func (r *orderRepository) addStatusEntryTx(ctx context.Context, tx database.Tx, orderID string, status string, notes string) error {
+func (r *orderRepository) addStatusEntryTx(ctx context.Context, tx database.Tx, orderID string, status string, notes string, actor string) error {
 	orderUUID, err := uuid.Parse(orderID)
 	if err != nil {
 		return fmt.Errorf("%w: invalid order ID: %w", ErrInvalidUUID, err)
 	}
 
 	_, err = tx.Exec(ctx, `
-		INSERT INTO orders.OrderStatus (order_id, order_status, status_date_time, notes)
-		VALUES ($1, $2, CURRENT_TIMESTAMP, $3)
-	`, orderUUID, status, notes)
+		INSERT INTO orders.OrderStatus (order_id, order_status, status_date_time, notes, actor)
+		VALUES ($1, $2, CURRENT_TIMESTAMP, $3, $4)
+	`, orderUUID, status, notes, actor)
 	if err != nil {
 		return fmt.Errorf("%w: failed to add status entry: %w", ErrDatabaseOperation, err)
 	}
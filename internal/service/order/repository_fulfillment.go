@@ -0,0 +1,183 @@
+package order
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// CreateShipment saves a shipment of order, marks its lines shipped and
+// saves the status transitions the shipment caused, in one transaction. It
+// fails with ErrLineNotShippable if a line was shipped meanwhile.
+func (r *orderRepository) CreateShipment(ctx context.Context, order *Order, shipment *Shipment, transitions []StatusTransition) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	result, err := tx.Exec(ctx, `
+		UPDATE orders.OrderItem
+		SET item_status = $1, item_status_date_time = $2
+		WHERE order_id = $3 AND line_number = ANY($4) AND item_status = $5
+	`, ItemStatusShipped, shipment.CreatedAt, order.OrderID, shipment.LineNumbers, ItemStatusPending)
+	if err != nil {
+		return fmt.Errorf("%w: failed to ship items: %w", ErrDatabaseOperation, err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: failed to get rows affected: %w", ErrDatabaseOperation, err)
+	}
+	if int(rows) != len(shipment.LineNumbers) {
+		return fmt.Errorf("%w: lines were shipped meanwhile", ErrLineNotShippable)
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO orders.Shipment (shipment_id, order_id, carrier, tracking_number, tracking_url, shipping_method,
+		                             status, status_detail, status_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, shipment.ShipmentID, shipment.OrderID, shipment.Carrier, shipment.TrackingNumber, shipment.TrackingURL, shipment.ShippingMethod,
+		shipment.Status, shipment.StatusDetail, shipment.StatusAt, shipment.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("%w: failed to insert shipment: %w", ErrDatabaseOperation, err)
+	}
+
+	for _, line := range shipment.LineNumbers {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO orders.ShipmentItem (shipment_id, order_id, line_number)
+			VALUES ($1, $2, $3)
+		`, shipment.ShipmentID, shipment.OrderID, line)
+		if err != nil {
+			return fmt.Errorf("%w: failed to insert shipment item: %w", ErrDatabaseOperation, err)
+		}
+	}
+
+	for _, transition := range transitions {
+		if err := r.changeStatusTx(ctx, tx, order, transition); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w: failed to commit transaction: %w", ErrTransactionFailed, err)
+	}
+	committed = true
+
+	return nil
+}
+
+// UpdateShipment saves the tracking status of a shipment, marks its lines
+// delivered once it is, and saves the status transitions the update
+// caused, in one transaction.
+func (r *orderRepository) UpdateShipment(ctx context.Context, order *Order, shipment *Shipment, transitions []StatusTransition) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	_, err = tx.Exec(ctx, `
+		UPDATE orders.Shipment
+		SET status = $1, status_detail = $2, status_at = $3, delivered_at = $4
+		WHERE shipment_id = $5
+	`, shipment.Status, shipment.StatusDetail, shipment.StatusAt, shipment.DeliveredAt, shipment.ShipmentID)
+	if err != nil {
+		return fmt.Errorf("%w: failed to update shipment: %w", ErrDatabaseOperation, err)
+	}
+
+	if shipment.DeliveredAt != nil {
+		_, err = tx.Exec(ctx, `
+			UPDATE orders.OrderItem
+			SET item_status = $1, item_status_date_time = $2
+			WHERE order_id = $3 AND line_number = ANY($4) AND item_status = $5
+		`, ItemStatusDelivered, *shipment.DeliveredAt, order.OrderID, shipment.LineNumbers, ItemStatusShipped)
+		if err != nil {
+			return fmt.Errorf("%w: failed to deliver items: %w", ErrDatabaseOperation, err)
+		}
+	}
+
+	for _, transition := range transitions {
+		if err := r.changeStatusTx(ctx, tx, order, transition); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w: failed to commit transaction: %w", ErrTransactionFailed, err)
+	}
+	committed = true
+
+	return nil
+}
+
+func (r *orderRepository) GetShipmentByTrackingNumber(ctx context.Context, carrier string, trackingNumber string) (*Shipment, error) {
+	var shipment Shipment
+	err := r.db.GetContext(ctx, &shipment, `
+		SELECT shipment_id, order_id, carrier, tracking_number, tracking_url, shipping_method,
+		       status, status_detail, status_at, created_at, delivered_at
+		FROM orders.Shipment
+		WHERE carrier = $1 AND tracking_number = $2
+	`, carrier, trackingNumber)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrShipmentNotFound
+		}
+		return nil, fmt.Errorf("%w: failed to get shipment: %w", ErrDatabaseOperation, err)
+	}
+
+	shipment.LineNumbers, err = r.getShipmentLines(ctx, shipment.ShipmentID)
+	if err != nil {
+		return nil, err
+	}
+	return &shipment, nil
+}
+
+func (r *orderRepository) getShipmentsByOrderID(ctx context.Context, orderID uuid.UUID) ([]Shipment, error) {
+	var shipments []Shipment
+	err := r.db.SelectContext(ctx, &shipments, `
+		SELECT shipment_id, order_id, carrier, tracking_number, tracking_url, shipping_method,
+		       status, status_detail, status_at, created_at, delivered_at
+		FROM orders.Shipment
+		WHERE order_id = $1
+		ORDER BY created_at
+	`, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get shipments: %w", ErrDatabaseOperation, err)
+	}
+
+	for i := range shipments {
+		shipments[i].LineNumbers, err = r.getShipmentLines(ctx, shipments[i].ShipmentID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return shipments, nil
+}
+
+func (r *orderRepository) getShipmentLines(ctx context.Context, shipmentID uuid.UUID) ([]int, error) {
+	var lines []int
+	err := r.db.SelectContext(ctx, &lines, `
+		SELECT line_number FROM orders.ShipmentItem
+		WHERE shipment_id = $1
+		ORDER BY line_number
+	`, shipmentID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get shipment lines: %w", ErrDatabaseOperation, err)
+	}
+	return lines, nil
+}
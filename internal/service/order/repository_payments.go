@@ -0,0 +1,170 @@
+package order
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"go-shopping-poc/internal/platform/database"
+)
+
+// UpdatePayment saves a gateway call made for a payment and, when it was
+// approved, moves the payment along with it, in one transaction. A call
+// recorded already under the same idempotency key is skipped. It fails
+// with ErrInvalidPaymentTransition if the payment was moved meanwhile so
+// that the call no longer applies.
+func (r *orderRepository) UpdatePayment(ctx context.Context, pay *Payment, txn PaymentTransaction) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	inserted, err := r.insertPaymentTransactionTx(ctx, tx, txn)
+	if err != nil {
+		return err
+	}
+	if !inserted {
+		r.logger.Debug("Payment transaction recorded already", "payment_id", pay.PaymentID, "idempotency_key", txn.IdempotencyKey)
+		return nil
+	}
+
+	if txn.Approved() {
+		if err := r.movePaymentTx(ctx, tx, pay, txn); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w: failed to commit transaction: %w", ErrTransactionFailed, err)
+	}
+	committed = true
+
+	return nil
+}
+
+// movePaymentTx applies an approved gateway call to the payment row. The
+// amounts are applied relative to the row, so concurrent refunds add up.
+func (r *orderRepository) movePaymentTx(ctx context.Context, tx database.Tx, pay *Payment, txn PaymentTransaction) error {
+	var query string
+	args := []any{txn.CreatedAt, pay.PaymentID}
+	switch txn.Kind {
+	case PaymentKindCapture:
+		query = `
+			UPDATE orders.Payment
+			SET status = 'captured', captured = $3, updated_at = $1
+			WHERE payment_id = $2 AND status = 'authorized'
+		`
+		args = append(args, txn.Amount)
+	case PaymentKindVoid:
+		query = `
+			UPDATE orders.Payment
+			SET status = 'voided', updated_at = $1
+			WHERE payment_id = $2 AND status = 'authorized'
+		`
+	case PaymentKindRefund:
+		query = `
+			UPDATE orders.Payment
+			SET refunded = refunded + $3,
+			    status = CASE WHEN refunded + $3 >= captured THEN 'refunded' ELSE 'partially_refunded' END,
+			    updated_at = $1
+			WHERE payment_id = $2 AND status IN ('captured', 'partially_refunded') AND refunded + $3 <= captured
+		`
+		args = append(args, txn.Amount)
+	default:
+		return fmt.Errorf("%w: unknown transaction kind %s", ErrInvalidPaymentTransition, txn.Kind)
+	}
+
+	result, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%w: failed to update payment: %w", ErrDatabaseOperation, err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: failed to get rows affected: %w", ErrDatabaseOperation, err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("%w: payment was moved meanwhile, cannot %s", ErrInvalidPaymentTransition, txn.Kind)
+	}
+	return nil
+}
+
+// insertPaymentTx saves the payment of order with its transactions.
+func (r *orderRepository) insertPaymentTx(ctx context.Context, tx database.Tx, order *Order) error {
+	pay := order.Payment
+	pay.OrderID = order.OrderID
+	_, err := tx.Exec(ctx, `
+		INSERT INTO orders.Payment (payment_id, order_id, gateway, authorization_id, status,
+		                            amount, captured, refunded, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, pay.PaymentID, pay.OrderID, pay.Gateway, pay.AuthorizationID, pay.Status,
+		pay.Amount, pay.Captured, pay.Refunded, pay.CreatedAt, pay.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("%w: failed to insert payment: %w", ErrDatabaseOperation, err)
+	}
+
+	for _, txn := range pay.Transactions {
+		if _, err := r.insertPaymentTransactionTx(ctx, tx, txn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertPaymentTransactionTx saves a payment transaction and reports
+// whether it was new.
+func (r *orderRepository) insertPaymentTransactionTx(ctx context.Context, tx database.Tx, txn PaymentTransaction) (bool, error) {
+	result, err := tx.Exec(ctx, `
+		INSERT INTO orders.PaymentTransaction (payment_id, kind, idempotency_key, transaction_id,
+		                                       status, amount, decline_code, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (payment_id, idempotency_key) DO NOTHING
+	`, txn.PaymentID, txn.Kind, txn.IdempotencyKey, txn.TransactionID,
+		txn.Status, txn.Amount, txn.DeclineCode, txn.CreatedAt)
+	if err != nil {
+		return false, fmt.Errorf("%w: failed to insert payment transaction: %w", ErrDatabaseOperation, err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%w: failed to get rows affected: %w", ErrDatabaseOperation, err)
+	}
+	return rows > 0, nil
+}
+
+// getPaymentByOrderID returns the payment of an order, or nil for orders
+// created without one.
+func (r *orderRepository) getPaymentByOrderID(ctx context.Context, orderID uuid.UUID) (*Payment, error) {
+	var pay Payment
+	err := r.db.GetContext(ctx, &pay, `
+		SELECT payment_id, order_id, gateway, authorization_id, status,
+		       amount, captured, refunded, created_at, updated_at
+		FROM orders.Payment
+		WHERE order_id = $1
+	`, orderID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%w: failed to get payment: %w", ErrDatabaseOperation, err)
+	}
+
+	err = r.db.SelectContext(ctx, &pay.Transactions, `
+		SELECT payment_id, kind, idempotency_key, transaction_id, status, amount, decline_code, created_at
+		FROM orders.PaymentTransaction
+		WHERE payment_id = $1
+		ORDER BY created_at
+	`, pay.PaymentID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get payment transactions: %w", ErrDatabaseOperation, err)
+	}
+	return &pay, nil
+}
@@ -0,0 +1,363 @@
+package order
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	events "go-shopping-poc/internal/contracts/events"
+	"go-shopping-poc/internal/platform/database"
+)
+
+// returnEventTypes maps the status of a return to the event of the step
+// that put it there.
+var returnEventTypes = map[string]events.OrderEventType{
+	ReturnRequested: events.OrderReturnRequested,
+	ReturnApproved:  events.OrderReturnApproved,
+	ReturnRejected:  events.OrderReturnRejected,
+	ReturnReceived:  events.OrderReturnReceived,
+	ReturnRefunded:  events.OrderReturnRefunded,
+}
+
+// CreateReturn numbers and saves a requested return of order, reserving
+// its quantities on the order lines, with a status history entry and its
+// order.return_requested event, in one transaction. It fails with
+// ErrLineNotReturnable if a line was returned meanwhile.
+func (r *orderRepository) CreateReturn(ctx context.Context, order *Order, ret *Return, actor string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	// The order row lock serializes the numbering of its returns
+	var count int
+	err = tx.QueryRow(ctx, `
+		SELECT (SELECT count(*) FROM orders.Return WHERE order_id = $1)
+		FROM orders.OrderHead
+		WHERE order_id = $1
+		FOR UPDATE
+	`, order.OrderID).Scan(&count)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrOrderNotFound
+		}
+		return fmt.Errorf("%w: failed to lock order: %w", ErrDatabaseOperation, err)
+	}
+	ret.RMANumber = fmt.Sprintf("RMA-%s-%02d", strings.TrimPrefix(order.OrderNumber, "ORD-"), count+1)
+
+	for _, line := range ret.Lines {
+		result, err := tx.Exec(ctx, `
+			UPDATE orders.OrderItem
+			SET returned_quantity = returned_quantity + $1
+			WHERE order_id = $2 AND line_number = $3 AND item_status = $4
+			  AND returned_quantity + $1 <= quantity
+		`, line.Quantity, order.OrderID, line.LineNumber, ItemStatusDelivered)
+		if err != nil {
+			return fmt.Errorf("%w: failed to reserve returned quantity: %w", ErrDatabaseOperation, err)
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("%w: failed to get rows affected: %w", ErrDatabaseOperation, err)
+		}
+		if rows == 0 {
+			return fmt.Errorf("%w: line %d was returned meanwhile", ErrLineNotReturnable, line.LineNumber)
+		}
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO orders.Return (return_id, rma_number, order_id, status, notes, refund_total, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, ret.ReturnID, ret.RMANumber, ret.OrderID, ret.Status, ret.Notes, ret.RefundTotal, ret.CreatedAt, ret.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("%w: failed to insert return: %w", ErrDatabaseOperation, err)
+	}
+
+	for _, line := range ret.Lines {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO orders.ReturnLine (return_id, line_number, product_id, quantity, reason, restock, refund_amount, refund_tax)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		`, ret.ReturnID, line.LineNumber, line.ProductID, line.Quantity, line.Reason, line.Restock, line.RefundAmount, line.RefundTax)
+		if err != nil {
+			return fmt.Errorf("%w: failed to insert return line: %w", ErrDatabaseOperation, err)
+		}
+	}
+
+	notes := returnNotes(ret, "requested")
+	if err := r.addStatusEntryTx(ctx, tx, order.OrderID.String(), order.CurrentStatus, notes, actor); err != nil {
+		return err
+	}
+	if err := r.writeReturnEventTx(ctx, tx, order, ret, notes, actor); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w: failed to commit transaction: %w", ErrTransactionFailed, err)
+	}
+	committed = true
+
+	return nil
+}
+
+// UpdateReturn saves the step of a return from status from to its current
+// status, with what the step changes on the order, in one transaction: a
+// rejection gives its quantities back to the order lines, a receipt
+// records which lines are restocked, and a refund records the refunded
+// amounts and quantities and the order's refunded total. The status
+// transitions of the order are saved with it; without any, a status
+// history entry records the step. It fails with ErrInvalidReturnTransition
+// if the return or the refunded lines changed meanwhile.
+func (r *orderRepository) UpdateReturn(ctx context.Context, order *Order, ret *Return, from string, actor string, transitions []StatusTransition) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	result, err := tx.Exec(ctx, `
+		UPDATE orders.Return
+		SET status = $1, notes = $2, refund_total = $3, updated_at = $4
+		WHERE return_id = $5 AND status = $6
+	`, ret.Status, ret.Notes, ret.RefundTotal, ret.UpdatedAt, ret.ReturnID, from)
+	if err != nil {
+		return fmt.Errorf("%w: failed to update return: %w", ErrDatabaseOperation, err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: failed to get rows affected: %w", ErrDatabaseOperation, err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("%w: return is no longer %s", ErrInvalidReturnTransition, from)
+	}
+
+	switch ret.Status {
+	case ReturnRejected:
+		err = r.releaseReturnedTx(ctx, tx, order, ret)
+	case ReturnReceived:
+		err = r.saveRestockTx(ctx, tx, ret)
+	case ReturnRefunded:
+		err = r.saveRefundTx(ctx, tx, order, ret)
+	}
+	if err != nil {
+		return err
+	}
+
+	notes := returnNotes(ret, ret.Status)
+	for _, transition := range transitions {
+		if err := r.changeStatusTx(ctx, tx, order, transition); err != nil {
+			return err
+		}
+	}
+	if len(transitions) == 0 {
+		if err := r.addStatusEntryTx(ctx, tx, order.OrderID.String(), order.CurrentStatus, notes, actor); err != nil {
+			return err
+		}
+	}
+	if err := r.writeReturnEventTx(ctx, tx, order, ret, notes, actor); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w: failed to commit transaction: %w", ErrTransactionFailed, err)
+	}
+	committed = true
+
+	return nil
+}
+
+// releaseReturnedTx gives the quantities of a rejected return back to the
+// order lines.
+func (r *orderRepository) releaseReturnedTx(ctx context.Context, tx database.Tx, order *Order, ret *Return) error {
+	for _, line := range ret.Lines {
+		_, err := tx.Exec(ctx, `
+			UPDATE orders.OrderItem
+			SET returned_quantity = returned_quantity - $1
+			WHERE order_id = $2 AND line_number = $3
+		`, line.Quantity, order.OrderID, line.LineNumber)
+		if err != nil {
+			return fmt.Errorf("%w: failed to release returned quantity: %w", ErrDatabaseOperation, err)
+		}
+	}
+	return nil
+}
+
+// saveRestockTx records which lines of a received return are restocked.
+func (r *orderRepository) saveRestockTx(ctx context.Context, tx database.Tx, ret *Return) error {
+	for _, line := range ret.Lines {
+		_, err := tx.Exec(ctx, `
+			UPDATE orders.ReturnLine
+			SET restock = $1
+			WHERE return_id = $2 AND line_number = $3
+		`, line.Restock, ret.ReturnID, line.LineNumber)
+		if err != nil {
+			return fmt.Errorf("%w: failed to update return line: %w", ErrDatabaseOperation, err)
+		}
+	}
+	return nil
+}
+
+// saveRefundTx records the refunded amounts of a return's lines, adds its
+// quantities to the refunded quantities of the order lines and its total
+// to the order's refunded total. Each order line must still have the
+// refunded quantity its refund was priced on.
+func (r *orderRepository) saveRefundTx(ctx context.Context, tx database.Tx, order *Order, ret *Return) error {
+	for _, line := range ret.Lines {
+		_, err := tx.Exec(ctx, `
+			UPDATE orders.ReturnLine
+			SET refund_amount = $1, refund_tax = $2
+			WHERE return_id = $3 AND line_number = $4
+		`, line.RefundAmount, line.RefundTax, ret.ReturnID, line.LineNumber)
+		if err != nil {
+			return fmt.Errorf("%w: failed to update return line: %w", ErrDatabaseOperation, err)
+		}
+
+		item := order.item(line.LineNumber)
+		if item == nil {
+			return fmt.Errorf("%w: line %d", ErrOrderItemNotFound, line.LineNumber)
+		}
+		result, err := tx.Exec(ctx, `
+			UPDATE orders.OrderItem
+			SET refunded_quantity = $1
+			WHERE order_id = $2 AND line_number = $3 AND refunded_quantity = $4
+		`, item.RefundedQuantity, order.OrderID, line.LineNumber, item.RefundedQuantity-line.Quantity)
+		if err != nil {
+			return fmt.Errorf("%w: failed to update refunded quantity: %w", ErrDatabaseOperation, err)
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("%w: failed to get rows affected: %w", ErrDatabaseOperation, err)
+		}
+		if rows == 0 {
+			return fmt.Errorf("%w: line %d was refunded meanwhile", ErrInvalidReturnTransition, line.LineNumber)
+		}
+	}
+
+	_, err := tx.Exec(ctx, `
+		UPDATE orders.OrderHead
+		SET refunded = refunded + $1, updated_at = CURRENT_TIMESTAMP
+		WHERE order_id = $2
+	`, ret.RefundTotal, order.OrderID)
+	if err != nil {
+		return fmt.Errorf("%w: failed to update refunded total: %w", ErrDatabaseOperation, err)
+	}
+	return nil
+}
+
+// writeReturnEventTx writes the event of the step that put a return in its
+// current status.
+func (r *orderRepository) writeReturnEventTx(ctx context.Context, tx database.Tx, order *Order, ret *Return, notes string, actor string) error {
+	var customerIDStr *string
+	if order.CustomerID != nil {
+		id := order.CustomerID.String()
+		customerIDStr = &id
+	}
+
+	lines := make([]events.ReturnLine, 0, len(ret.Lines))
+	for _, line := range ret.Lines {
+		lines = append(lines, events.ReturnLine{
+			LineNumber:   line.LineNumber,
+			ProductID:    line.ProductID,
+			Quantity:     line.Quantity,
+			Reason:       line.Reason,
+			Restock:      line.Restock,
+			RefundAmount: line.RefundAmount,
+			RefundTax:    line.RefundTax,
+		})
+	}
+
+	evt := events.NewOrderReturnEvent(returnEventTypes[ret.Status], events.OrderReturnPayload{
+		ReturnID:    ret.ReturnID.String(),
+		RMANumber:   ret.RMANumber,
+		OrderID:     order.OrderID.String(),
+		OrderNumber: order.OrderNumber,
+		CartID:      order.CartID.String(),
+		CustomerID:  customerIDStr,
+		Status:      ret.Status,
+		Notes:       notes,
+		Actor:       actor,
+		Lines:       lines,
+		RefundTotal: ret.RefundTotal,
+		Refunded:    order.Refunded,
+	})
+	if err := r.outboxWriter.WriteEvent(ctx, tx, evt); err != nil {
+		return fmt.Errorf("failed to write %s event: %w", evt.EventType, err)
+	}
+	return nil
+}
+
+func (r *orderRepository) GetReturnByID(ctx context.Context, returnID string) (*Return, error) {
+	id, err := uuid.Parse(returnID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid return ID: %w", ErrInvalidUUID, err)
+	}
+
+	var ret Return
+	err = r.db.GetContext(ctx, &ret, `
+		SELECT return_id, rma_number, order_id, status, notes, refund_total, created_at, updated_at
+		FROM orders.Return
+		WHERE return_id = $1
+	`, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrReturnNotFound
+		}
+		return nil, fmt.Errorf("%w: failed to get return: %w", ErrDatabaseOperation, err)
+	}
+
+	ret.Lines, err = r.getReturnLines(ctx, ret.ReturnID)
+	if err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+func (r *orderRepository) getReturnsByOrderID(ctx context.Context, orderID uuid.UUID) ([]Return, error) {
+	var returns []Return
+	err := r.db.SelectContext(ctx, &returns, `
+		SELECT return_id, rma_number, order_id, status, notes, refund_total, created_at, updated_at
+		FROM orders.Return
+		WHERE order_id = $1
+		ORDER BY created_at
+	`, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get returns: %w", ErrDatabaseOperation, err)
+	}
+
+	for i := range returns {
+		returns[i].Lines, err = r.getReturnLines(ctx, returns[i].ReturnID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return returns, nil
+}
+
+func (r *orderRepository) getReturnLines(ctx context.Context, returnID uuid.UUID) ([]ReturnLine, error) {
+	var lines []ReturnLine
+	err := r.db.SelectContext(ctx, &lines, `
+		SELECT return_id, line_number, product_id, quantity, reason, restock, refund_amount, refund_tax
+		FROM orders.ReturnLine
+		WHERE return_id = $1
+		ORDER BY line_number
+	`, returnID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get return lines: %w", ErrDatabaseOperation, err)
+	}
+	return lines, nil
+}
@@ -0,0 +1,127 @@
+package order
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	events "go-shopping-poc/internal/contracts/events"
+	"go-shopping-poc/internal/platform/database"
+	"go-shopping-poc/internal/platform/money/moneytest"
+	"go-shopping-poc/internal/platform/outbox"
+)
+
+// statusTx records the status updates, history entries and outbox events
+// written by the order repository.
+type statusTx struct {
+	database.Tx
+	statuses []string
+	actors   []string
+	outbox   []outboxEntry
+}
+
+type outboxEntry struct {
+	eventType string
+	payload   []byte
+}
+
+func (tx *statusTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if strings.Contains(query, "UPDATE orders.OrderHead") {
+		tx.statuses = append(tx.statuses, args[0].(string))
+	}
+	return driverResult(1), nil
+}
+
+func (tx *statusTx) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	switch {
+	case strings.Contains(query, "INSERT INTO orders.OrderStatus"):
+		tx.actors = append(tx.actors, args[3].(string))
+	case strings.Contains(query, "INSERT INTO outbox.outbox"):
+		tx.outbox = append(tx.outbox, outboxEntry{eventType: args[0].(string), payload: args[2].([]byte)})
+	}
+	return driverResult(1), nil
+}
+
+type driverResult int64
+
+func (r driverResult) LastInsertId() (int64, error) { return 0, nil }
+func (r driverResult) RowsAffected() (int64, error) { return int64(r), nil }
+
+func TestChangeStatusTxWritesStatusChanged(t *testing.T) {
+	t.Parallel()
+
+	transitions := []struct{ from, to string }{
+		{"created", "confirmed"},
+		{"created", "cancelled"},
+		{"confirmed", "processing"},
+		{"confirmed", "cancelled"},
+		{"processing", "shipped"},
+		{"processing", "cancelled"},
+		{"shipped", "delivered"},
+		{"delivered", "refunded"},
+	}
+
+	for _, tt := range transitions {
+		tt := tt
+		t.Run(tt.from+"->"+tt.to, func(t *testing.T) {
+			t.Parallel()
+
+			customerID := uuid.New()
+			order := &Order{
+				OrderID:       uuid.New(),
+				OrderNumber:   "ORD-1",
+				CartID:        uuid.New(),
+				CustomerID:    &customerID,
+				TotalPrice:    moneytest.USD("25.00"),
+				CurrentStatus: tt.from,
+			}
+			actor := CustomerActor(customerID.String())
+			if err := order.SetStatus(tt.to, "because", actor); err != nil {
+				t.Fatalf("SetStatus() error = %v", err)
+			}
+			transition := StatusTransition{From: tt.from, OrderStatus: order.StatusHistory[len(order.StatusHistory)-1]}
+
+			repo := &orderRepository{outboxWriter: outbox.NewWriter(nil)}
+			tx := &statusTx{}
+			if err := repo.changeStatusTx(context.Background(), tx, order, transition); err != nil {
+				t.Fatalf("changeStatusTx() error = %v", err)
+			}
+
+			if len(tx.statuses) != 1 || tx.statuses[0] != tt.to {
+				t.Errorf("status updates = %v, want [%s]", tx.statuses, tt.to)
+			}
+			if len(tx.actors) != 1 || tx.actors[0] != actor {
+				t.Errorf("history actors = %v, want [%s]", tx.actors, actor)
+			}
+
+			wantTypes := []string{string(events.OrderStatusChanged)}
+			if tt.to == "cancelled" {
+				wantTypes = append(wantTypes, string(events.OrderCancelled))
+			}
+			var gotTypes []string
+			for _, entry := range tx.outbox {
+				gotTypes = append(gotTypes, entry.eventType)
+			}
+			if strings.Join(gotTypes, " ") != strings.Join(wantTypes, " ") {
+				t.Fatalf("outbox events = %v, want %v", gotTypes, wantTypes)
+			}
+
+			var changed events.OrderStatusChangedEvent
+			if err := json.Unmarshal(tx.outbox[0].payload, &changed); err != nil {
+				t.Fatalf("unmarshal status changed: %v", err)
+			}
+			data := changed.Data
+			if data.OrderID != order.OrderID.String() || data.FromStatus != tt.from || data.ToStatus != tt.to ||
+				data.Actor != actor || data.Notes != "because" {
+				t.Errorf("status changed = %+v, want %s -> %s by %s", data, tt.from, tt.to, actor)
+			}
+			if data.CustomerID == nil || *data.CustomerID != customerID.String() {
+				t.Errorf("status changed customer = %v, want %s", data.CustomerID, customerID)
+			}
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package order
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	platformerrors "go-shopping-poc/internal/platform/errors"
+	"go-shopping-poc/internal/platform/money"
+)
+
+// A return (RMA) takes delivered order lines back. The customer requests
+// it per line with a reason; staff approve or reject it, receive the items
+// and decide whether they go back on sale, then refund it. The refund of
+// each line is its share of the line's discounted price and tax, and the
+// order moves to partially_refunded or refunded with it.
+
+// Return statuses, in the order a return goes through them.
+const (
+	ReturnRequested = "requested"
+	ReturnApproved  = "approved"
+	ReturnRejected  = "rejected"
+	ReturnReceived  = "received"
+	ReturnRefunded  = "refunded"
+)
+
+// returnReasons are the reasons a line can be returned for.
+var returnReasons = []string{"damaged", "defective", "wrong_item", "not_as_described", "no_longer_needed", "other"}
+
+// Return is a request to take back lines of an order.
+type Return struct {
+	ReturnID    uuid.UUID   `json:"return_id" db:"return_id"`
+	RMANumber   string      `json:"rma_number" db:"rma_number"`
+	OrderID     uuid.UUID   `json:"order_id" db:"order_id"`
+	Status      string      `json:"status" db:"status"`
+	Notes       string      `json:"notes,omitempty" db:"notes"`
+	RefundTotal money.Money `json:"refund_total" db:"refund_total"`
+	CreatedAt   time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at" db:"updated_at"`
+
+	Lines []ReturnLine `json:"lines"`
+}
+
+// ReturnLine is the quantity of one order line in a return.
+type ReturnLine struct {
+	ReturnID     uuid.UUID   `json:"-" db:"return_id"`
+	LineNumber   int         `json:"line_number" db:"line_number"`
+	ProductID    string      `json:"product_id" db:"product_id"`
+	Quantity     int         `json:"quantity" db:"quantity"`
+	Reason       string      `json:"reason" db:"reason"`
+	Restock      bool        `json:"restock" db:"restock"`
+	RefundAmount money.Money `json:"refund_amount" db:"refund_amount"`
+	RefundTax    money.Money `json:"refund_tax" db:"refund_tax"`
+}
+
+// ReturnLineRequest asks to return quantity of an order line for reason.
+type ReturnLineRequest struct {
+	LineNumber int    `json:"line_number"`
+	Quantity   int    `json:"quantity"`
+	Reason     string `json:"reason"`
+}
+
+// canMoveReturn lists the statuses a return can move to from each status.
+var canMoveReturn = map[string][]string{
+	ReturnRequested: {ReturnApproved, ReturnRejected},
+	ReturnApproved:  {ReturnReceived, ReturnRejected},
+	ReturnReceived:  {ReturnRefunded},
+}
+
+// SetStatus moves the return to status.
+func (r *Return) SetStatus(status string, notes string, at time.Time) error {
+	if !contains(canMoveReturn[r.Status], status) {
+		return fmt.Errorf("%w: from %s to %s", ErrInvalidReturnTransition, r.Status, status)
+	}
+	r.Status = status
+	if notes != "" {
+		r.Notes = notes
+	}
+	r.UpdatedAt = at
+	return nil
+}
+
+// line returns the line of the return for order line lineNumber, or nil.
+func (r *Return) line(lineNumber int) *ReturnLine {
+	for i := range r.Lines {
+		if r.Lines[i].LineNumber == lineNumber {
+			return &r.Lines[i]
+		}
+	}
+	return nil
+}
+
+// CanReturn reports whether lines of the order can be returned.
+func (o *Order) CanReturn() error {
+	switch o.CurrentStatus {
+	case "delivered", "partially_refunded":
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrOrderNotReturnable, o.CurrentStatus)
+}
+
+// NewReturn builds a return of lines of the order. Every line must be
+// delivered, named once and returned at most up to the quantity not
+// already in another return.
+func (o *Order) NewReturn(lines []ReturnLineRequest, at time.Time) (*Return, error) {
+	if err := o.CanReturn(); err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, platformerrors.NewValidationError("lines", "lines is required")
+	}
+
+	ret := &Return{
+		ReturnID:    uuid.New(),
+		OrderID:     o.OrderID,
+		Status:      ReturnRequested,
+		RefundTotal: money.Zero(o.Currency),
+		CreatedAt:   at,
+		UpdatedAt:   at,
+	}
+	seen := make(map[int]bool, len(lines))
+	for i, line := range lines {
+		field := fmt.Sprintf("lines[%d]", i)
+		if seen[line.LineNumber] {
+			return nil, platformerrors.NewValidationError(field+".line_number", fmt.Sprintf("line %d is listed more than once", line.LineNumber))
+		}
+		seen[line.LineNumber] = true
+		if !contains(returnReasons, line.Reason) {
+			return nil, platformerrors.NewValidationError(field+".reason", "reason must be one of: damaged, defective, wrong_item, not_as_described, no_longer_needed, other")
+		}
+
+		item := o.item(line.LineNumber)
+		if item == nil {
+			return nil, fmt.Errorf("%w: line %d", ErrOrderItemNotFound, line.LineNumber)
+		}
+		if item.ItemStatus != ItemStatusDelivered {
+			return nil, fmt.Errorf("%w: line %d is not delivered", ErrLineNotReturnable, line.LineNumber)
+		}
+		if line.Quantity <= 0 {
+			return nil, platformerrors.NewValidationError(field+".quantity", "quantity must be positive")
+		}
+		if left := item.Quantity - item.ReturnedQuantity; line.Quantity > left {
+			return nil, fmt.Errorf("%w: line %d has %d left to return", ErrLineNotReturnable, line.LineNumber, left)
+		}
+
+		ret.Lines = append(ret.Lines, ReturnLine{
+			ReturnID:     ret.ReturnID,
+			LineNumber:   line.LineNumber,
+			ProductID:    item.ProductID,
+			Quantity:     line.Quantity,
+			Reason:       line.Reason,
+			Restock:      true,
+			RefundAmount: money.Zero(o.Currency),
+			RefundTax:    money.Zero(o.Currency),
+		})
+	}
+	return ret, nil
+}
+
+// RefundReturn prices the lines of a received return and adds them to the
+// refunded quantities and total of the order. A line refunds the share of
+// its order line's discounted price and tax its quantity takes after what
+// was refunded before, so that refunding every unit refunds the line to
+// the cent.
+func (o *Order) RefundReturn(ret *Return) error {
+	total := money.Zero(o.Currency)
+	for i := range ret.Lines {
+		line := &ret.Lines[i]
+		item := o.item(line.LineNumber)
+		if item == nil {
+			return fmt.Errorf("%w: line %d", ErrOrderItemNotFound, line.LineNumber)
+		}
+		if item.RefundedQuantity+line.Quantity > item.Quantity {
+			return fmt.Errorf("%w: line %d is refunded already", ErrLineNotReturnable, line.LineNumber)
+		}
+
+		net, err := item.TotalPrice.Sub(item.Discount)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line.LineNumber, err)
+		}
+		if line.RefundAmount, err = refundShare(net, item.RefundedQuantity, line.Quantity, item.Quantity); err != nil {
+			return fmt.Errorf("line %d: %w", line.LineNumber, err)
+		}
+		if line.RefundTax, err = refundShare(item.Tax, item.RefundedQuantity, line.Quantity, item.Quantity); err != nil {
+			return fmt.Errorf("line %d: %w", line.LineNumber, err)
+		}
+		item.RefundedQuantity += line.Quantity
+		if total, err = money.Sum(o.Currency, total, line.RefundAmount, line.RefundTax); err != nil {
+			return fmt.Errorf("line %d: %w", line.LineNumber, err)
+		}
+	}
+	refunded, err := o.Refunded.Add(total)
+	if err != nil {
+		return err
+	}
+	ret.RefundTotal = total
+	o.Refunded = refunded
+	return nil
+}
+
+// RefundStatus returns the status the refunded quantities of the lines
+// put the order in: refunded once every unit is, partially_refunded once
+// some are.
+func (o *Order) RefundStatus() string {
+	refunded, quantity := 0, 0
+	for _, item := range o.Items {
+		refunded += item.RefundedQuantity
+		quantity += item.Quantity
+	}
+	switch {
+	case refunded > 0 && refunded == quantity:
+		return "refunded"
+	case refunded > 0:
+		return "partially_refunded"
+	}
+	return o.CurrentStatus
+}
+
+// refundShare returns the part of amount, spread over quantity units,
+// that the units after the first refunded take: the share of the first
+// refunded+units less the share of the first refunded. Shares of the
+// first n units are rounded the same way whatever n, so the parts refunded
+// one return at a time add up to amount.
+func refundShare(amount money.Money, refunded, units, quantity int) (money.Money, error) {
+	return unitsShare(amount, refunded+units, quantity).Sub(unitsShare(amount, refunded, quantity))
+}
+
+// unitsShare returns the share of amount the first n of quantity units
+// take.
+func unitsShare(amount money.Money, n, quantity int) money.Money {
+	shares := amount.Allocate(int64(n), int64(quantity-n))
+	if shares == nil {
+		return money.Zero(amount.Currency())
+	}
+	return shares[0]
+}
+
+// returnNotes describes a step of a return in the order's status history.
+func returnNotes(ret *Return, step string) string {
+	return fmt.Sprintf("Return %s %s", ret.RMANumber, step)
+}
@@ -0,0 +1,181 @@
+package order
+
+import (
+	"errors"
+	"testing"
+
+	"go-shopping-poc/internal/platform/money/moneytest"
+)
+
+// newDeliveredOrder returns an order of two delivered lines: three units
+// at 10.00 with 0.80 tax, and two units at 25.00 less a 5.00 discount with
+// 1.60 tax.
+func newDeliveredOrder() *Order {
+	return &Order{
+		Currency:      "USD",
+		CurrentStatus: "delivered",
+		Refunded:      moneytest.USD("0"),
+		Items: []OrderItem{
+			{LineNumber: 1, ProductID: "p1", Quantity: 3, TotalPrice: moneytest.USD("10.00"), Discount: moneytest.USD("0"), Tax: moneytest.USD("0.80"), ItemStatus: ItemStatusDelivered},
+			{LineNumber: 2, ProductID: "p2", Quantity: 2, TotalPrice: moneytest.USD("25.00"), Discount: moneytest.USD("5.00"), Tax: moneytest.USD("1.60"), ItemStatus: ItemStatusDelivered},
+		},
+	}
+}
+
+func TestRefundShare(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name                      string
+		amount                    string
+		refunded, units, quantity int
+		want                      string
+	}{
+		{"whole line", "10.00", 0, 3, 3, "10.00"},
+		{"first unit", "10.00", 0, 1, 3, "3.33"},
+		{"second unit", "10.00", 1, 1, 3, "3.34"},
+		{"last unit", "10.00", 2, 1, 3, "3.33"},
+		{"rest after first", "10.00", 1, 2, 3, "6.67"},
+		{"even split", "20.00", 0, 1, 2, "10.00"},
+		{"nothing to share", "0", 0, 1, 2, "0.00"},
+		{"single cent", "0.01", 0, 1, 3, "0.00"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := refundShare(moneytest.USD(tt.amount), tt.refunded, tt.units, tt.quantity)
+			if err != nil {
+				t.Fatalf("refundShare() error = %v", err)
+			}
+			if want := moneytest.USD(tt.want); !got.Equal(want) {
+				t.Errorf("refundShare() = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestRefundShareUnitByUnitAddsUpToAmount(t *testing.T) {
+	t.Parallel()
+
+	for _, amount := range []string{"10.00", "0.01", "99.99", "1234.57"} {
+		for quantity := 1; quantity <= 7; quantity++ {
+			total := moneytest.USD("0")
+			for refunded := 0; refunded < quantity; refunded++ {
+				share, err := refundShare(moneytest.USD(amount), refunded, 1, quantity)
+				if err != nil {
+					t.Fatalf("refundShare(%s, %d of %d) error = %v", amount, refunded, quantity, err)
+				}
+				if total, err = total.Add(share); err != nil {
+					t.Fatalf("Add() error = %v", err)
+				}
+			}
+			if !total.Equal(moneytest.USD(amount)) {
+				t.Errorf("%s over %d units refunded one at a time = %s", amount, quantity, total)
+			}
+		}
+	}
+}
+
+func TestRefundReturn(t *testing.T) {
+	t.Parallel()
+
+	type returned struct {
+		line, quantity int
+	}
+	tests := []struct {
+		name         string
+		returns      [][]returned
+		wantTotals   []string
+		wantRefunded string
+		wantStatus   string
+	}{
+		{
+			name:         "one unit",
+			returns:      [][]returned{{{1, 1}}},
+			wantTotals:   []string{"3.60"},
+			wantRefunded: "3.60",
+			wantStatus:   "partially_refunded",
+		},
+		{
+			name:         "discounted line",
+			returns:      [][]returned{{{2, 2}}},
+			wantTotals:   []string{"21.60"},
+			wantRefunded: "21.60",
+			wantStatus:   "partially_refunded",
+		},
+		{
+			name:         "every line at once",
+			returns:      [][]returned{{{1, 3}, {2, 2}}},
+			wantTotals:   []string{"32.40"},
+			wantRefunded: "32.40",
+			wantStatus:   "refunded",
+		},
+		{
+			name:         "line over two returns",
+			returns:      [][]returned{{{1, 1}, {2, 2}}, {{1, 2}}},
+			wantTotals:   []string{"25.20", "7.20"},
+			wantRefunded: "32.40",
+			wantStatus:   "refunded",
+		},
+		{
+			name:         "unit by unit",
+			returns:      [][]returned{{{1, 1}}, {{1, 1}}, {{1, 1}}},
+			wantTotals:   []string{"3.60", "3.60", "3.60"},
+			wantRefunded: "10.80",
+			wantStatus:   "partially_refunded",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			o := newDeliveredOrder()
+			for i, lines := range tt.returns {
+				ret := &Return{}
+				for _, l := range lines {
+					ret.Lines = append(ret.Lines, ReturnLine{LineNumber: l.line, Quantity: l.quantity})
+				}
+				if err := o.RefundReturn(ret); err != nil {
+					t.Fatalf("return %d: RefundReturn() error = %v", i, err)
+				}
+				if want := moneytest.USD(tt.wantTotals[i]); !ret.RefundTotal.Equal(want) {
+					t.Errorf("return %d: RefundTotal = %s, want %s", i, ret.RefundTotal, want)
+				}
+			}
+			if want := moneytest.USD(tt.wantRefunded); !o.Refunded.Equal(want) {
+				t.Errorf("Refunded = %s, want %s", o.Refunded, want)
+			}
+			if got := o.RefundStatus(); got != tt.wantStatus {
+				t.Errorf("RefundStatus() = %s, want %s", got, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestRefundReturnRejects(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		lines    []ReturnLine
+		refunded int
+		want     error
+	}{
+		{"unknown line", []ReturnLine{{LineNumber: 9, Quantity: 1}}, 0, ErrOrderItemNotFound},
+		{"more than ordered", []ReturnLine{{LineNumber: 1, Quantity: 4}}, 0, ErrLineNotReturnable},
+		{"refunded already", []ReturnLine{{LineNumber: 1, Quantity: 2}}, 2, ErrLineNotReturnable},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			o := newDeliveredOrder()
+			o.Items[0].RefundedQuantity = tt.refunded
+			err := o.RefundReturn(&Return{Lines: tt.lines})
+			if !errors.Is(err, tt.want) {
+				t.Errorf("RefundReturn() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -12,12 +12,15 @@ import (
 	"github.com/google/uuid"
 
 	events "go-shopping-poc/internal/contracts/events"
+	"go-shopping-poc/internal/platform/carrier"
 	"go-shopping-poc/internal/platform/database"
 	"go-shopping-poc/internal/platform/event/bus"
 	kafka "go-shopping-poc/internal/platform/event/bus/kafka"
 	"go-shopping-poc/internal/platform/logging"
 	"go-shopping-poc/internal/platform/outbox"
+	"go-shopping-poc/internal/platform/payment"
 	"go-shopping-poc/internal/platform/service"
+	"go-shopping-poc/internal/platform/tax"
 )
 
 type OrderInfrastructure struct {
@@ -26,6 +29,8 @@ type OrderInfrastructure struct {
 	OutboxWriter    *outbox.Writer
 	OutboxPublisher *outbox.Publisher
 	CORSHandler     func(http.Handler) http.Handler
+	Carriers        *carrier.Registry
+	Payments        *payment.Registry
 }
 
 func NewOrderInfrastructure(
@@ -34,6 +39,8 @@ func NewOrderInfrastructure(
 	outboxWriter *outbox.Writer,
 	outboxPublisher *outbox.Publisher,
 	corsHandler func(http.Handler) http.Handler,
+	carriers *carrier.Registry,
+	payments *payment.Registry,
 ) *OrderInfrastructure {
 	return &OrderInfrastructure{
 		Database:        db,
@@ -41,6 +48,8 @@ func NewOrderInfrastructure(
 		OutboxWriter:    outboxWriter,
 		OutboxPublisher: outboxPublisher,
 		CORSHandler:     corsHandler,
+		Carriers:        carriers,
+		Payments:        payments,
 	}
 }
 
@@ -61,6 +70,8 @@ type OrderService struct {
 	identityCache         *IdentityCache
 	mu                    sync.Mutex
 	verificationCallbacks map[string]chan verificationResult
+	reorderCallbacks      map[string]chan reorderReply
+	reorderTimeout        time.Duration
 }
 
 type verificationResult struct {
@@ -82,6 +93,8 @@ func NewOrderService(logger *slog.Logger, infrastructure *OrderInfrastructure, c
 		config:                config,
 		identityCache:         NewIdentityCache(),
 		verificationCallbacks: make(map[string]chan verificationResult),
+		reorderCallbacks:      make(map[string]chan reorderReply),
+		reorderTimeout:        defaultReorderTimeout,
 	}
 }
 
@@ -110,7 +123,9 @@ func (s *OrderService) GetOrdersByCustomer(ctx context.Context, customerID strin
 	return orders, nil
 }
 
-func (s *OrderService) CreateOrderFromSnapshot(ctx context.Context, cartID string, snapshot *events.CartSnapshot) (*Order, error) {
+// CreateOrderFromSnapshot creates the order for checkout attempt of a
+// cart from the snapshot taken at checkout.
+func (s *OrderService) CreateOrderFromSnapshot(ctx context.Context, cartID string, attempt int, snapshot *events.CartSnapshot) (*Order, error) {
 	s.logger.Debug("Creating order from cart snapshot", "cart_id", cartID)
 
 	cartIDUUID, err := uuid.Parse(cartID)
@@ -118,15 +133,23 @@ func (s *OrderService) CreateOrderFromSnapshot(ctx context.Context, cartID strin
 		return nil, fmt.Errorf("invalid cart ID: %w", err)
 	}
 
+	// A redelivered checkout finds the order it created
+	if existing, err := s.checkoutOrder(ctx, cartID); err != nil || existing != nil {
+		return existing, err
+	}
+
 	order := &Order{
-		CartID:        cartIDUUID,
-		Currency:      snapshot.Currency,
-		NetPrice:      snapshot.NetPrice,
-		Tax:           snapshot.Tax,
-		Shipping:      snapshot.Shipping,
-		TotalPrice:    snapshot.TotalPrice,
-		CurrentStatus: "created",
-		Items:         make([]OrderItem, len(snapshot.Items)),
+		CartID:         cartIDUUID,
+		Attempt:        attempt,
+		Currency:       snapshot.Currency,
+		NetPrice:       snapshot.NetPrice,
+		Tax:            snapshot.Tax,
+		Shipping:       snapshot.Shipping,
+		ShippingMethod: snapshot.ShippingMethod,
+		Discount:       snapshot.Discount,
+		TotalPrice:     snapshot.TotalPrice,
+		CurrentStatus:  "created",
+		Items:          make([]OrderItem, len(snapshot.Items)),
 	}
 
 	if snapshot.CustomerID != nil {
@@ -168,6 +191,22 @@ func (s *OrderService) CreateOrderFromSnapshot(ctx context.Context, cartID strin
 			ImageURL:       item.ImageURL,
 			ItemStatus:     "pending",
 			ItemStatusDate: time.Now(),
+			TaxCode:        item.TaxCode,
+			Tax:            item.Tax,
+			Discount:       item.Discount,
+			Size:           item.Size,
+			Color:          item.Color,
+			GiftMessage:    item.GiftMessage,
+		}
+		for _, rate := range item.TaxBreakdown {
+			order.Items[i].TaxBreakdown = append(order.Items[i].TaxBreakdown, tax.AppliedRate{
+				Jurisdiction: rate.Jurisdiction,
+				Name:         rate.Name,
+				TaxCode:      rate.TaxCode,
+				Rate:         rate.Rate,
+				Amount:       rate.Amount,
+				TableVersion: rate.TableVersion,
+			})
 		}
 	}
 
@@ -184,7 +223,19 @@ func (s *OrderService) CreateOrderFromSnapshot(ctx context.Context, cartID strin
 		})
 	}
 
+	pay, err := s.authorizePayment(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+	order.Payment = pay
+
 	if err := s.repo.CreateOrder(ctx, order); err != nil {
+		if pay != nil {
+			s.releaseAuthorization(ctx, order, pay)
+		}
+		if errors.Is(err, ErrOrderExists) {
+			return s.checkoutOrder(ctx, cartID)
+		}
 		return nil, fmt.Errorf("failed to create order: %w", err)
 	}
 
@@ -200,7 +251,25 @@ func (s *OrderService) CreateOrderFromSnapshot(ctx context.Context, cartID strin
 	return order, nil
 }
 
-func (s *OrderService) CancelOrder(ctx context.Context, orderID string) error {
+// checkoutOrder returns the order not cancelled of a checked out cart, or
+// nil if it has none. Carts whose checkout was rolled back are checked out
+// again, with their earlier orders cancelled.
+func (s *OrderService) checkoutOrder(ctx context.Context, cartID string) (*Order, error) {
+	orders, err := s.repo.GetOrdersByCartID(ctx, cartID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get orders: %w", err)
+	}
+	for i := range orders {
+		if orders[i].CurrentStatus != "cancelled" {
+			s.logger.Info("Order of cart exists", "cart_id", cartID, "order_id", orders[i].OrderID)
+			return &orders[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// CancelOrder cancels an order on behalf of actor.
+func (s *OrderService) CancelOrder(ctx context.Context, orderID string, actor string) error {
 	s.logger.Debug("Cancelling order", "order_id", orderID)
 
 	order, err := s.repo.GetOrderByID(ctx, orderID)
@@ -212,14 +281,75 @@ func (s *OrderService) CancelOrder(ctx context.Context, orderID string) error {
 		return fmt.Errorf("cannot cancel order: %w", err)
 	}
 
-	if err := s.repo.UpdateOrderStatus(ctx, orderID, "cancelled", "Order cancelled by customer"); err != nil {
+	if err := s.repo.UpdateOrderStatus(ctx, orderID, "cancelled", "Order cancelled by customer", actor); err != nil {
 		return fmt.Errorf("failed to cancel order: %w", err)
 	}
+	s.voidPayment(ctx, order)
 
+	s.processOutbox()
 	return nil
 }
 
-func (s *OrderService) UpdateOrderStatus(ctx context.Context, orderID string, newStatus string) error {
+// RejectCheckout reports that no order could be created for checkout
+// attempt of a cart, for reason.
+func (s *OrderService) RejectCheckout(ctx context.Context, cartID string, attempt int, customerID *string, reason string) error {
+	s.logger.Debug("Rejecting checkout", "cart_id", cartID, "attempt", attempt, "reason", reason)
+
+	if err := s.repo.RejectCheckout(ctx, cartID, attempt, customerID, reason); err != nil {
+		return fmt.Errorf("failed to reject checkout: %w", err)
+	}
+
+	s.processOutbox()
+	return nil
+}
+
+// CancelCheckoutOrders cancels the orders created for checkout attempt of
+// a cart that the cart service rolled back. Orders of other checkouts of
+// the cart, and orders that can no longer be cancelled, are left alone;
+// attempt 0 cancels the orders of every checkout. It returns the number of
+// orders cancelled.
+func (s *OrderService) CancelCheckoutOrders(ctx context.Context, cartID string, attempt int, reason string) (int, error) {
+	orders, err := s.repo.GetOrdersByCartID(ctx, cartID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get orders: %w", err)
+	}
+
+	cancelled := 0
+	for _, order := range orders {
+		if attempt != 0 && order.Attempt != attempt {
+			continue
+		}
+		if err := order.CanCancel(); err != nil {
+			s.logger.Debug("Order of failed checkout not cancellable", "order_id", order.OrderID, "status", order.CurrentStatus)
+			continue
+		}
+		if err := s.repo.UpdateOrderStatus(ctx, order.OrderID.String(), "cancelled", "Checkout failed: "+reason, ActorSystem); err != nil {
+			return cancelled, fmt.Errorf("failed to cancel order %s: %w", order.OrderID, err)
+		}
+		s.voidPayment(ctx, &order)
+		cancelled++
+	}
+
+	if cancelled > 0 {
+		s.processOutbox()
+	}
+	return cancelled, nil
+}
+
+// processOutbox triggers immediate outbox processing for low latency.
+func (s *OrderService) processOutbox() {
+	if s.infrastructure.OutboxPublisher != nil {
+		go func() {
+			if err := s.infrastructure.OutboxPublisher.ProcessNow(); err != nil {
+				s.logger.Warn("Failed to trigger immediate outbox processing", "error", err.Error())
+			}
+		}()
+	}
+}
+
+// UpdateOrderStatus moves an order to newStatus on behalf of actor, with
+// optional notes recorded in its status history.
+func (s *OrderService) UpdateOrderStatus(ctx context.Context, orderID string, newStatus string, notes string, actor string) error {
 	s.logger.Debug("Updating order status", "order_id", orderID, "new_status", newStatus)
 
 	order, err := s.repo.GetOrderByID(ctx, orderID)
@@ -227,14 +357,27 @@ func (s *OrderService) UpdateOrderStatus(ctx context.Context, orderID string, ne
 		return fmt.Errorf("failed to get order: %w", err)
 	}
 
-	if err := order.SetStatus(newStatus); err != nil {
+	// Refunds move the order with the returns they refund, and shipments
+	// with the fulfillment of its lines
+	switch newStatus {
+	case "partially_refunded", "refunded":
+		return fmt.Errorf("%w: orders are refunded through returns", ErrInvalidStatusTransition)
+	case "processing", "partially_shipped", "shipped", "delivered":
+		return fmt.Errorf("%w: %s follows the fulfillment of the order's lines", ErrInvalidStatusTransition, newStatus)
+	}
+
+	if err := order.SetStatus(newStatus, notes, actor); err != nil {
 		return fmt.Errorf("invalid status transition: %w", err)
 	}
 
-	if err := s.repo.UpdateOrderStatus(ctx, orderID, newStatus, ""); err != nil {
+	if err := s.repo.UpdateOrderStatus(ctx, orderID, newStatus, notes, actor); err != nil {
 		return fmt.Errorf("failed to update order status: %w", err)
 	}
+	if newStatus == "cancelled" {
+		s.voidPayment(ctx, order)
+	}
 
+	s.processOutbox()
 	return nil
 }
 
@@ -0,0 +1,220 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"go-shopping-poc/internal/platform/carrier"
+)
+
+// ShipmentRequest selects the lines of a shipment and how they ship. No
+// lines ships every line not shipped yet; an empty Carrier uses the
+// configured carrier and an empty ShippingMethod the order's.
+type ShipmentRequest struct {
+	LineNumbers    []int  `json:"line_numbers"`
+	Carrier        string `json:"carrier,omitempty"`
+	ShippingMethod string `json:"shipping_method,omitempty"`
+}
+
+// CreateShipment books a shipment of lines of an order with a carrier on
+// behalf of actor, and moves the order along with its lines.
+func (s *OrderService) CreateShipment(ctx context.Context, orderID string, req ShipmentRequest, actor string) (*Shipment, error) {
+	s.logger.Debug("Creating shipment", "order_id", orderID, "lines", req.LineNumbers)
+
+	order, err := s.repo.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+	if err := order.CanFulfill(); err != nil {
+		return nil, err
+	}
+
+	lines := req.LineNumbers
+	if len(lines) == 0 {
+		if lines = order.PendingLines(); len(lines) == 0 {
+			return nil, fmt.Errorf("%w: every line has shipped", ErrLineNotShippable)
+		}
+	}
+	now := time.Now()
+	if err := order.ShipLines(lines, now); err != nil {
+		return nil, err
+	}
+
+	c, err := s.carrier(req.Carrier)
+	if err != nil {
+		return nil, err
+	}
+	method := req.ShippingMethod
+	if method == "" {
+		method = order.ShippingMethod
+	}
+	booking, err := c.Book(ctx, carrier.Shipment{
+		Reference:   order.OrderNumber,
+		Method:      method,
+		Destination: shippingDestination(order),
+		Parcels:     1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to book shipment: %w", err)
+	}
+	// The first shipment captures the payment, once the carrier took it
+	if err := s.capturePayment(ctx, order); err != nil {
+		return nil, err
+	}
+
+	shipment := &Shipment{
+		ShipmentID:     uuid.New(),
+		OrderID:        order.OrderID,
+		Carrier:        c.Name(),
+		TrackingNumber: booking.TrackingNumber,
+		TrackingURL:    booking.TrackingURL,
+		ShippingMethod: method,
+		Status:         booking.Status,
+		StatusAt:       now,
+		CreatedAt:      now,
+		LineNumbers:    lines,
+	}
+	notes := fmt.Sprintf("Shipment %s via %s", shipment.TrackingNumber, shipment.Carrier)
+	transitions, err := order.AdvanceFulfillment(notes, actor)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateShipment(ctx, order, shipment, transitions); err != nil {
+		return nil, fmt.Errorf("failed to create shipment: %w", err)
+	}
+
+	s.processOutbox()
+	s.logger.Info("Shipment created",
+		"order_id", orderID,
+		"shipment_id", shipment.ShipmentID,
+		"carrier", shipment.Carrier,
+		"tracking_number", shipment.TrackingNumber,
+		"lines", len(lines),
+		"order_status", order.CurrentStatus,
+	)
+	return shipment, nil
+}
+
+// ParseCarrierWebhook reads the tracking updates of a webhook request sent
+// by the carrier registered as name.
+func (s *OrderService) ParseCarrierWebhook(name string, r *http.Request) ([]carrier.TrackingUpdate, error) {
+	c, err := s.infrastructure.Carriers.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return c.ParseWebhook(r)
+}
+
+// ApplyTrackingUpdates moves the shipments of a carrier along with its
+// tracking updates, and their orders with them. Updates for shipments not
+// booked here are skipped.
+func (s *OrderService) ApplyTrackingUpdates(ctx context.Context, name string, updates []carrier.TrackingUpdate) error {
+	applied := 0
+	for _, update := range updates {
+		ok, err := s.applyTrackingUpdate(ctx, name, update)
+		if errors.Is(err, ErrShipmentNotFound) {
+			s.logger.Warn("Tracking update for unknown shipment", "carrier", name, "tracking_number", update.TrackingNumber)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if ok {
+			applied++
+		}
+	}
+
+	if applied > 0 {
+		s.processOutbox()
+	}
+	return nil
+}
+
+// applyTrackingUpdate applies one tracking update and reports whether it
+// changed the shipment. Updates older than the shipment's status, and any
+// after its delivery, are ignored.
+func (s *OrderService) applyTrackingUpdate(ctx context.Context, name string, update carrier.TrackingUpdate) (bool, error) {
+	shipment, err := s.repo.GetShipmentByTrackingNumber(ctx, name, update.TrackingNumber)
+	if err != nil {
+		return false, err
+	}
+	log := s.logger.With("shipment_id", shipment.ShipmentID, "tracking_number", update.TrackingNumber, "status", update.Status)
+	if shipment.DeliveredAt != nil || update.OccurredAt.Before(shipment.StatusAt) {
+		log.Debug("Stale tracking update ignored")
+		return false, nil
+	}
+
+	order, err := s.repo.GetOrderByID(ctx, shipment.OrderID.String())
+	if err != nil {
+		return false, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	shipment.Status = update.Status
+	shipment.StatusAt = update.OccurredAt
+	shipment.StatusDetail = trackingDetail(update)
+	if update.Status == carrier.StatusDelivered {
+		deliveredAt := update.OccurredAt
+		shipment.DeliveredAt = &deliveredAt
+		order.DeliverLines(shipment.LineNumbers, deliveredAt)
+	}
+
+	notes := fmt.Sprintf("Shipment %s %s", shipment.TrackingNumber, update.Status)
+	transitions, err := order.AdvanceFulfillment(notes, CarrierActor(name))
+	if err != nil {
+		return false, err
+	}
+	if err := s.repo.UpdateShipment(ctx, order, shipment, transitions); err != nil {
+		return false, fmt.Errorf("failed to update shipment: %w", err)
+	}
+
+	log.Info("Shipment tracking updated", "order_id", order.OrderID, "order_status", order.CurrentStatus)
+	return true, nil
+}
+
+// carrier returns the carrier registered as name, or the configured
+// carrier when name is empty.
+func (s *OrderService) carrier(name string) (carrier.Carrier, error) {
+	if name == "" {
+		name = s.config.Carrier
+	}
+	if name == "" {
+		name = carrier.FakeName
+	}
+	return s.infrastructure.Carriers.Get(name)
+}
+
+// shippingDestination returns the shipping address of an order as a
+// carrier address.
+func shippingDestination(order *Order) carrier.Address {
+	for _, addr := range order.Addresses {
+		if addr.AddressType != "shipping" {
+			continue
+		}
+		return carrier.Address{
+			Name:     strings.TrimSpace(addr.FirstName + " " + addr.LastName),
+			Address1: addr.Address1,
+			Address2: addr.Address2,
+			City:     addr.City,
+			State:    addr.State,
+			Zip:      addr.Zip,
+		}
+	}
+	return carrier.Address{}
+}
+
+// trackingDetail describes where and how a tracking update happened.
+func trackingDetail(update carrier.TrackingUpdate) string {
+	switch {
+	case update.Location != "" && update.Description != "":
+		return update.Location + ": " + update.Description
+	case update.Location != "":
+		return update.Location
+	}
+	return update.Description
+}
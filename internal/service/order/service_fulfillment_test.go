@@ -0,0 +1,316 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"go-shopping-poc/internal/platform/carrier"
+)
+
+// fakeFulfillmentRepo keeps one order and its shipments, and records the
+// status transitions saved with them.
+type fakeFulfillmentRepo struct {
+	OrderRepository
+	order       *Order
+	shipments   map[string]*Shipment // tracking number → shipment
+	transitions []string
+	saves       int
+}
+
+func (r *fakeFulfillmentRepo) GetOrderByID(ctx context.Context, orderID string) (*Order, error) {
+	if r.order.OrderID.String() != orderID {
+		return nil, ErrOrderNotFound
+	}
+	order := *r.order
+	order.Items = slices.Clone(r.order.Items)
+	order.StatusHistory = slices.Clone(r.order.StatusHistory)
+	return &order, nil
+}
+
+func (r *fakeFulfillmentRepo) GetShipmentByTrackingNumber(ctx context.Context, carrierName string, trackingNumber string) (*Shipment, error) {
+	shipment, ok := r.shipments[trackingNumber]
+	if !ok || shipment.Carrier != carrierName {
+		return nil, ErrShipmentNotFound
+	}
+	copied := *shipment
+	return &copied, nil
+}
+
+func (r *fakeFulfillmentRepo) CreateShipment(ctx context.Context, order *Order, shipment *Shipment, transitions []StatusTransition) error {
+	return r.save(order, shipment, transitions)
+}
+
+func (r *fakeFulfillmentRepo) UpdateShipment(ctx context.Context, order *Order, shipment *Shipment, transitions []StatusTransition) error {
+	return r.save(order, shipment, transitions)
+}
+
+func (r *fakeFulfillmentRepo) save(order *Order, shipment *Shipment, transitions []StatusTransition) error {
+	r.saves++
+	r.order = order
+	r.shipments[shipment.TrackingNumber] = shipment
+	for _, transition := range transitions {
+		r.transitions = append(r.transitions, transition.From+"->"+transition.Status)
+	}
+	return nil
+}
+
+func newFulfillmentService(order *Order, shipments ...*Shipment) (*OrderService, *fakeFulfillmentRepo) {
+	repo := &fakeFulfillmentRepo{order: order, shipments: make(map[string]*Shipment)}
+	for _, shipment := range shipments {
+		repo.shipments[shipment.TrackingNumber] = shipment
+	}
+	s := NewOrderService(slog.New(slog.DiscardHandler), &OrderInfrastructure{
+		Carriers: carrier.NewRegistry(carrier.NewFakeCarrier("")),
+	}, &Config{})
+	s.repo = repo
+	return s, repo
+}
+
+// fulfillableOrder returns an order in status with a line per item status.
+func fulfillableOrder(status string, itemStatuses ...string) *Order {
+	order := &Order{
+		OrderID:       uuid.New(),
+		OrderNumber:   "ORD-1",
+		CurrentStatus: status,
+		Addresses: []Address{
+			{AddressType: "shipping", FirstName: "Ann", Address1: "1 Main St", City: "Springfield", Zip: "12345"},
+		},
+	}
+	for i, itemStatus := range itemStatuses {
+		order.Items = append(order.Items, OrderItem{LineNumber: i + 1, ItemStatus: itemStatus})
+	}
+	return order
+}
+
+// itemStatuses describes the lines of order as their fulfillment statuses.
+func itemStatuses(order *Order) string {
+	var statuses []string
+	for _, item := range order.Items {
+		statuses = append(statuses, item.ItemStatus)
+	}
+	return strings.Join(statuses, " ")
+}
+
+func TestCreateShipmentDerivesOrderStatus(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		order           *Order
+		lines           []int
+		wantErr         error
+		wantStatus      string
+		wantItems       string
+		wantTransitions []string
+	}{
+		{
+			name:            "some lines ship",
+			order:           fulfillableOrder("confirmed", ItemStatusPending, ItemStatusPending),
+			lines:           []int{1},
+			wantStatus:      "partially_shipped",
+			wantItems:       "shipped pending",
+			wantTransitions: []string{"confirmed->processing", "processing->partially_shipped"},
+		},
+		{
+			name:            "no lines ships every pending line",
+			order:           fulfillableOrder("confirmed", ItemStatusPending, ""),
+			wantStatus:      "shipped",
+			wantItems:       "shipped shipped",
+			wantTransitions: []string{"confirmed->processing", "processing->shipped"},
+		},
+		{
+			name:            "last pending line ships",
+			order:           fulfillableOrder("partially_shipped", ItemStatusShipped, ItemStatusPending),
+			lines:           []int{2},
+			wantStatus:      "shipped",
+			wantItems:       "shipped shipped",
+			wantTransitions: []string{"partially_shipped->shipped"},
+		},
+		{
+			name:       "shipped line cannot ship again",
+			order:      fulfillableOrder("partially_shipped", ItemStatusShipped, ItemStatusPending),
+			lines:      []int{1},
+			wantErr:    ErrLineNotShippable,
+			wantStatus: "partially_shipped",
+			wantItems:  "shipped pending",
+		},
+		{
+			name:       "every line shipped",
+			order:      fulfillableOrder("partially_shipped", ItemStatusShipped, ItemStatusDelivered),
+			wantErr:    ErrLineNotShippable,
+			wantStatus: "partially_shipped",
+			wantItems:  "shipped delivered",
+		},
+		{
+			name:       "cancelled order",
+			order:      fulfillableOrder("cancelled", ItemStatusPending),
+			lines:      []int{1},
+			wantErr:    ErrOrderNotFulfillable,
+			wantStatus: "cancelled",
+			wantItems:  "pending",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s, repo := newFulfillmentService(tt.order)
+			shipment, err := s.CreateShipment(context.Background(), tt.order.OrderID.String(), ShipmentRequest{LineNumbers: tt.lines}, StaffActor("ann"))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("CreateShipment() error = %v, want %v", err, tt.wantErr)
+				}
+				if repo.saves != 0 {
+					t.Errorf("saved %d times, want none", repo.saves)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("CreateShipment() error = %v", err)
+				}
+				if shipment.Carrier != carrier.FakeName || shipment.Status != carrier.StatusLabelCreated {
+					t.Errorf("shipment = %s %s, want %s %s", shipment.Carrier, shipment.Status, carrier.FakeName, carrier.StatusLabelCreated)
+				}
+			}
+
+			if repo.order.CurrentStatus != tt.wantStatus {
+				t.Errorf("order status = %s, want %s", repo.order.CurrentStatus, tt.wantStatus)
+			}
+			if got := itemStatuses(repo.order); got != tt.wantItems {
+				t.Errorf("item statuses = %q, want %q", got, tt.wantItems)
+			}
+			if !slices.Equal(repo.transitions, tt.wantTransitions) {
+				t.Errorf("transitions = %v, want %v", repo.transitions, tt.wantTransitions)
+			}
+		})
+	}
+}
+
+func TestApplyTrackingUpdatesDerivesOrderStatus(t *testing.T) {
+	t.Parallel()
+
+	booked := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	shipmentOf := func(order *Order, tracking string, lines ...int) *Shipment {
+		return &Shipment{
+			ShipmentID:     uuid.New(),
+			OrderID:        order.OrderID,
+			Carrier:        carrier.FakeName,
+			TrackingNumber: tracking,
+			Status:         carrier.StatusLabelCreated,
+			StatusAt:       booked,
+			LineNumbers:    lines,
+		}
+	}
+
+	tests := []struct {
+		name            string
+		order           *Order
+		shipments       func(order *Order) []*Shipment
+		update          carrier.TrackingUpdate
+		wantStatus      string
+		wantItems       string
+		wantShipment    string
+		wantTransitions []string
+	}{
+		{
+			name:  "delivery of every line delivers the order",
+			order: fulfillableOrder("shipped", ItemStatusShipped, ItemStatusShipped),
+			shipments: func(order *Order) []*Shipment {
+				return []*Shipment{shipmentOf(order, "FK1", 1, 2)}
+			},
+			update:          carrier.TrackingUpdate{TrackingNumber: "FK1", Status: carrier.StatusDelivered, OccurredAt: booked.Add(time.Hour)},
+			wantStatus:      "delivered",
+			wantItems:       "delivered delivered",
+			wantShipment:    carrier.StatusDelivered,
+			wantTransitions: []string{"shipped->delivered"},
+		},
+		{
+			name:  "delivery of some lines keeps the order shipped",
+			order: fulfillableOrder("shipped", ItemStatusShipped, ItemStatusShipped),
+			shipments: func(order *Order) []*Shipment {
+				return []*Shipment{shipmentOf(order, "FK1", 1), shipmentOf(order, "FK2", 2)}
+			},
+			update:       carrier.TrackingUpdate{TrackingNumber: "FK1", Status: carrier.StatusDelivered, OccurredAt: booked.Add(time.Hour)},
+			wantStatus:   "shipped",
+			wantItems:    "delivered shipped",
+			wantShipment: carrier.StatusDelivered,
+		},
+		{
+			name:  "delivery before the other lines ship keeps the order partially shipped",
+			order: fulfillableOrder("partially_shipped", ItemStatusShipped, ItemStatusPending),
+			shipments: func(order *Order) []*Shipment {
+				return []*Shipment{shipmentOf(order, "FK1", 1)}
+			},
+			update:       carrier.TrackingUpdate{TrackingNumber: "FK1", Status: carrier.StatusDelivered, OccurredAt: booked.Add(time.Hour)},
+			wantStatus:   "partially_shipped",
+			wantItems:    "delivered pending",
+			wantShipment: carrier.StatusDelivered,
+		},
+		{
+			name:  "transit update moves only the shipment",
+			order: fulfillableOrder("shipped", ItemStatusShipped),
+			shipments: func(order *Order) []*Shipment {
+				return []*Shipment{shipmentOf(order, "FK1", 1)}
+			},
+			update:       carrier.TrackingUpdate{TrackingNumber: "FK1", Status: carrier.StatusInTransit, OccurredAt: booked.Add(time.Hour)},
+			wantStatus:   "shipped",
+			wantItems:    "shipped",
+			wantShipment: carrier.StatusInTransit,
+		},
+		{
+			name:  "stale update is ignored",
+			order: fulfillableOrder("shipped", ItemStatusShipped),
+			shipments: func(order *Order) []*Shipment {
+				return []*Shipment{shipmentOf(order, "FK1", 1)}
+			},
+			update:       carrier.TrackingUpdate{TrackingNumber: "FK1", Status: carrier.StatusDelivered, OccurredAt: booked.Add(-time.Hour)},
+			wantStatus:   "shipped",
+			wantItems:    "shipped",
+			wantShipment: carrier.StatusLabelCreated,
+		},
+		{
+			name:  "unknown shipment is skipped",
+			order: fulfillableOrder("shipped", ItemStatusShipped),
+			shipments: func(order *Order) []*Shipment {
+				return []*Shipment{shipmentOf(order, "FK1", 1)}
+			},
+			update:       carrier.TrackingUpdate{TrackingNumber: "FK9", Status: carrier.StatusDelivered, OccurredAt: booked.Add(time.Hour)},
+			wantStatus:   "shipped",
+			wantItems:    "shipped",
+			wantShipment: carrier.StatusLabelCreated,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s, repo := newFulfillmentService(tt.order, tt.shipments(tt.order)...)
+			if err := s.ApplyTrackingUpdates(context.Background(), carrier.FakeName, []carrier.TrackingUpdate{tt.update}); err != nil {
+				t.Fatalf("ApplyTrackingUpdates() error = %v", err)
+			}
+
+			if repo.order.CurrentStatus != tt.wantStatus {
+				t.Errorf("order status = %s, want %s", repo.order.CurrentStatus, tt.wantStatus)
+			}
+			if got := itemStatuses(repo.order); got != tt.wantItems {
+				t.Errorf("item statuses = %q, want %q", got, tt.wantItems)
+			}
+			if got := repo.shipments["FK1"].Status; got != tt.wantShipment {
+				t.Errorf("shipment status = %s, want %s", got, tt.wantShipment)
+			}
+			if !slices.Equal(repo.transitions, tt.wantTransitions) {
+				t.Errorf("transitions = %v, want %v", repo.transitions, tt.wantTransitions)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package order
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"go-shopping-poc/internal/platform/money"
+	"go-shopping-poc/internal/platform/payment"
+)
+
+// authorizePayment authorizes the total of an order on its card with the
+// configured gateway. Orders without a card are not paid for here and get
+// no payment. A declined card fails with ErrPaymentDeclined.
+func (s *OrderService) authorizePayment(ctx context.Context, order *Order) (*Payment, error) {
+	if order.CreditCard == nil {
+		return nil, nil
+	}
+	name := s.config.PaymentGateway
+	if name == "" {
+		name = payment.FakeName
+	}
+	gateway, err := s.infrastructure.Payments.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Each attempt authorizes afresh: an attempt whose order was not saved
+	// voids its authorization, which a replayed key would hand back as
+	// approved
+	key := paymentKey("cart:"+order.CartID.String()+":"+uuid.NewString(), PaymentKindAuthorize)
+	result, err := gateway.Authorize(ctx, payment.AuthorizeRequest{
+		IdempotencyKey: key,
+		Reference:      order.CartID.String(),
+		Amount:         order.TotalPrice,
+		Currency:       order.Currency,
+		Card: payment.Card{
+			Number:     order.CreditCard.CardNumber,
+			HolderName: order.CreditCard.CardHolderName,
+			Expires:    order.CreditCard.CardExpires,
+			CVV:        order.CreditCard.CardCVV,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to authorize payment: %w", err)
+	}
+	if !result.Approved() {
+		return nil, fmt.Errorf("%w: %s", ErrPaymentDeclined, result.DeclineCode)
+	}
+
+	s.logger.Info("Payment authorized", "cart_id", order.CartID, "gateway", name, "authorization_id", result.AuthorizationID)
+	return NewPayment(name, key, order.TotalPrice, result, time.Now()), nil
+}
+
+// releaseAuthorization voids an authorization whose order could not be
+// saved. Failures are logged; the gateway lets the authorization lapse.
+func (s *OrderService) releaseAuthorization(ctx context.Context, order *Order, pay *Payment) {
+	gateway, err := s.infrastructure.Payments.Get(pay.Gateway)
+	if err == nil {
+		_, err = gateway.Void(ctx, payment.VoidRequest{
+			IdempotencyKey:  paymentKey("authorization:"+pay.AuthorizationID, PaymentKindVoid),
+			AuthorizationID: pay.AuthorizationID,
+		})
+	}
+	if err != nil {
+		s.logger.Warn("Failed to void authorization of unsaved order",
+			"cart_id", order.CartID, "authorization_id", pay.AuthorizationID, "error", err.Error())
+	}
+}
+
+// capturePayment captures the authorized total of an order. It does
+// nothing for orders without a payment or whose payment was captured.
+func (s *OrderService) capturePayment(ctx context.Context, order *Order) error {
+	pay := order.Payment
+	if pay == nil || pay.Status != PaymentAuthorized {
+		return nil
+	}
+	return s.paymentCall(ctx, pay, PaymentKindCapture, paymentKey("payment:"+pay.PaymentID.String(), PaymentKindCapture), pay.Amount,
+		func(gateway payment.Gateway, key string) (*payment.Result, error) {
+			return gateway.Capture(ctx, payment.CaptureRequest{IdempotencyKey: key, AuthorizationID: pay.AuthorizationID, Amount: pay.Amount})
+		})
+}
+
+// voidPayment releases the authorization of a cancelled order. Failures
+// are logged rather than failing the cancellation; the gateway lets the
+// authorization lapse.
+func (s *OrderService) voidPayment(ctx context.Context, order *Order) {
+	pay := order.Payment
+	if pay == nil || pay.Status != PaymentAuthorized {
+		return
+	}
+	err := s.paymentCall(ctx, pay, PaymentKindVoid, paymentKey("payment:"+pay.PaymentID.String(), PaymentKindVoid), pay.Amount,
+		func(gateway payment.Gateway, key string) (*payment.Result, error) {
+			return gateway.Void(ctx, payment.VoidRequest{IdempotencyKey: key, AuthorizationID: pay.AuthorizationID})
+		})
+	if err != nil {
+		s.logger.Warn("Failed to void payment of cancelled order",
+			"order_id", order.OrderID, "payment_id", pay.PaymentID, "error", err.Error())
+	}
+}
+
+// refundPayment refunds the refund total of a return. It does nothing for
+// orders without a payment.
+func (s *OrderService) refundPayment(ctx context.Context, order *Order, ret *Return) error {
+	pay := order.Payment
+	if pay == nil || !ret.RefundTotal.IsPositive() {
+		return nil
+	}
+	amount := ret.RefundTotal
+	return s.paymentCall(ctx, pay, PaymentKindRefund, paymentKey("return:"+ret.ReturnID.String(), PaymentKindRefund), amount,
+		func(gateway payment.Gateway, key string) (*payment.Result, error) {
+			return gateway.Refund(ctx, payment.RefundRequest{IdempotencyKey: key, AuthorizationID: pay.AuthorizationID, Amount: amount})
+		})
+}
+
+// paymentCall makes a gateway call of kind for amount under key and saves
+// its outcome. A call recorded already under key is not made again, so
+// retrying an operation does not charge twice. A declined call fails with
+// ErrPaymentDeclined.
+func (s *OrderService) paymentCall(ctx context.Context, pay *Payment, kind string, key string, amount money.Money,
+	call func(gateway payment.Gateway, key string) (*payment.Result, error)) error {
+	if txn := pay.Transaction(key); txn != nil {
+		if !txn.Approved() {
+			return fmt.Errorf("%w: %s", ErrPaymentDeclined, txn.DeclineCode)
+		}
+		return nil
+	}
+	if err := pay.CanMove(kind, amount); err != nil {
+		return err
+	}
+
+	gateway, err := s.infrastructure.Payments.Get(pay.Gateway)
+	if err != nil {
+		return err
+	}
+	result, err := call(gateway, key)
+	if err != nil {
+		return fmt.Errorf("failed to %s payment: %w", kind, err)
+	}
+
+	txn, err := pay.Apply(kind, key, amount, result, time.Now())
+	if err != nil {
+		return err
+	}
+	if err := s.repo.UpdatePayment(ctx, pay, txn); err != nil {
+		return fmt.Errorf("failed to save payment: %w", err)
+	}
+	if !txn.Approved() {
+		return fmt.Errorf("%w: %s", ErrPaymentDeclined, txn.DeclineCode)
+	}
+
+	s.logger.Info("Payment updated",
+		"order_id", pay.OrderID,
+		"payment_id", pay.PaymentID,
+		"kind", kind,
+		"amount", amount.String(),
+		"status", pay.Status,
+	)
+	return nil
+}
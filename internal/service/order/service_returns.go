@@ -0,0 +1,136 @@
+package order
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	platformerrors "go-shopping-poc/internal/platform/errors"
+)
+
+// ReturnStepRequest carries the notes of a return step. On receipt, Lines
+// tells which lines go back on sale; lines not listed are restocked.
+type ReturnStepRequest struct {
+	Notes string        `json:"notes,omitempty"`
+	Lines []RestockLine `json:"lines,omitempty"`
+}
+
+// RestockLine tells whether the received items of a return line go back
+// on sale.
+type RestockLine struct {
+	LineNumber int  `json:"line_number"`
+	Restock    bool `json:"restock"`
+}
+
+// RequestReturn requests a return of lines of an order on behalf of actor.
+func (s *OrderService) RequestReturn(ctx context.Context, orderID string, lines []ReturnLineRequest, actor string) (*Return, error) {
+	s.logger.Debug("Requesting return", "order_id", orderID, "lines", len(lines))
+
+	order, err := s.repo.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	ret, err := order.NewReturn(lines, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateReturn(ctx, order, ret, actor); err != nil {
+		return nil, fmt.Errorf("failed to create return: %w", err)
+	}
+
+	s.processOutbox()
+	s.logger.Info("Return requested", "order_id", orderID, "return_id", ret.ReturnID, "rma_number", ret.RMANumber, "lines", len(ret.Lines))
+	return ret, nil
+}
+
+// ApproveReturn approves a requested return on behalf of actor.
+func (s *OrderService) ApproveReturn(ctx context.Context, returnID string, req ReturnStepRequest, actor string) (*Return, error) {
+	return s.updateReturn(ctx, returnID, ReturnApproved, req.Notes, actor, nil)
+}
+
+// RejectReturn rejects a return not received yet on behalf of actor. Its
+// lines can be returned again.
+func (s *OrderService) RejectReturn(ctx context.Context, returnID string, req ReturnStepRequest, actor string) (*Return, error) {
+	return s.updateReturn(ctx, returnID, ReturnRejected, req.Notes, actor, nil)
+}
+
+// ReceiveReturn records the items of an approved return as received on
+// behalf of actor, and which of them are restocked.
+func (s *OrderService) ReceiveReturn(ctx context.Context, returnID string, req ReturnStepRequest, actor string) (*Return, error) {
+	return s.updateReturn(ctx, returnID, ReturnReceived, req.Notes, actor, func(_ *Order, ret *Return) ([]StatusTransition, error) {
+		for i, restock := range req.Lines {
+			line := ret.line(restock.LineNumber)
+			if line == nil {
+				return nil, platformerrors.NewValidationError(fmt.Sprintf("lines[%d].line_number", i), fmt.Sprintf("line %d is not in the return", restock.LineNumber))
+			}
+			line.Restock = restock.Restock
+		}
+		return nil, nil
+	})
+}
+
+// RefundReturn refunds a received return on behalf of actor through the
+// payment of its order, and moves the order to partially_refunded or
+// refunded.
+func (s *OrderService) RefundReturn(ctx context.Context, returnID string, req ReturnStepRequest, actor string) (*Return, error) {
+	return s.updateReturn(ctx, returnID, ReturnRefunded, req.Notes, actor, func(order *Order, ret *Return) ([]StatusTransition, error) {
+		if err := order.RefundReturn(ret); err != nil {
+			return nil, err
+		}
+		if err := s.refundPayment(ctx, order, ret); err != nil {
+			return nil, err
+		}
+		target := order.RefundStatus()
+		if target == order.CurrentStatus {
+			return nil, nil
+		}
+		from := order.CurrentStatus
+		notes := fmt.Sprintf("%s: %s", returnNotes(ret, ReturnRefunded), ret.RefundTotal.String())
+		if err := order.SetStatus(target, notes, actor); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidStatusTransition, err)
+		}
+		return []StatusTransition{{From: from, OrderStatus: order.StatusHistory[len(order.StatusHistory)-1]}}, nil
+	})
+}
+
+// updateReturn moves a return to status, applying what the step changes
+// on the return and its order with apply, and saves it.
+func (s *OrderService) updateReturn(ctx context.Context, returnID string, status string, notes string, actor string,
+	apply func(order *Order, ret *Return) ([]StatusTransition, error)) (*Return, error) {
+	s.logger.Debug("Updating return", "return_id", returnID, "status", status)
+
+	ret, err := s.repo.GetReturnByID(ctx, returnID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get return: %w", err)
+	}
+	order, err := s.repo.GetOrderByID(ctx, ret.OrderID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	from := ret.Status
+	if err := ret.SetStatus(status, notes, time.Now()); err != nil {
+		return nil, err
+	}
+	var transitions []StatusTransition
+	if apply != nil {
+		if transitions, err = apply(order, ret); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.repo.UpdateReturn(ctx, order, ret, from, actor, transitions); err != nil {
+		return nil, fmt.Errorf("failed to update return: %w", err)
+	}
+
+	s.processOutbox()
+	s.logger.Info("Return updated",
+		"order_id", order.OrderID,
+		"return_id", ret.ReturnID,
+		"rma_number", ret.RMANumber,
+		"status", ret.Status,
+		"refund_total", ret.RefundTotal.String(),
+		"order_status", order.CurrentStatus,
+	)
+	return ret, nil
+}
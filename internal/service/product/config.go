@@ -2,9 +2,18 @@ package product
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
 	"go-shopping-poc/internal/platform/config"
+	"go-shopping-poc/internal/platform/ratelimit"
+)
+
+var defaultSearchLimit = ratelimit.Limit{Requests: 120, Period: time.Minute}
+
+const (
+	defaultReservationTTL         = 15 * time.Minute
+	defaultReservationSweepPeriod = time.Minute
 )
 
 // Config defines product service configuration
@@ -30,6 +39,14 @@ type Config struct {
 	ReadTopics []string `mapstructure:"product_read_topics"` // Topics to consume from (e.g., "CartEvents")
 	WriteTopic string   `mapstructure:"product_write_topic"` // Topic to write to (e.g., "ProductEvents")
 	Group      string   `mapstructure:"product_group"`       // Consumer group ID
+
+	// Rate limit for product search, e.g. "120/m"; empty uses the default
+	RateLimitSearch string `mapstructure:"product_rate_limit_search"`
+
+	// Stock reservations: how long checkout holds stock for the order, and
+	// how often expired holds are released; zero uses the defaults
+	ReservationTTL         time.Duration `mapstructure:"product_reservation_ttl"`
+	ReservationSweepPeriod time.Duration `mapstructure:"product_reservation_sweep_period"`
 }
 
 // LoadConfig loads product service configuration
@@ -58,10 +75,41 @@ func (c *Config) Validate() error {
 	if c.MinIOBucket == "" {
 		return errors.New("MinIO bucket is required")
 	}
+	if _, err := ratelimit.ParseLimitOrDefault(c.RateLimitSearch, defaultSearchLimit); err != nil {
+		return fmt.Errorf("search rate limit: %w", err)
+	}
+	if c.ReservationTTL < 0 {
+		return errors.New("reservation TTL cannot be negative")
+	}
+	if c.ReservationSweepPeriod < 0 {
+		return errors.New("reservation sweep period cannot be negative")
+	}
 	// Removed outbox configuration validation
 	return nil
 }
 
+// SearchLimit returns the rate limit for GET /products/search.
+func (c *Config) SearchLimit() ratelimit.Limit {
+	limit, _ := ratelimit.ParseLimitOrDefault(c.RateLimitSearch, defaultSearchLimit)
+	return limit
+}
+
+// ReservationHoldTTL returns how long a checkout reservation holds stock.
+func (c *Config) ReservationHoldTTL() time.Duration {
+	if c.ReservationTTL == 0 {
+		return defaultReservationTTL
+	}
+	return c.ReservationTTL
+}
+
+// ReservationSweepInterval returns how often expired reservations are released.
+func (c *Config) ReservationSweepInterval() time.Duration {
+	if c.ReservationSweepPeriod == 0 {
+		return defaultReservationSweepPeriod
+	}
+	return c.ReservationSweepPeriod
+}
+
 // DefaultConfig returns a configuration with sensible defaults
 func DefaultConfig() *Config {
 	return &Config{
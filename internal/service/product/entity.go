@@ -13,19 +13,20 @@ import (
 	"time"
 
 	"go-shopping-poc/internal/platform/database"
+	"go-shopping-poc/internal/platform/money"
 )
 
 // Product represents a product entity in the e-commerce system.
 type Product struct {
-	ID           int64   `json:"id" db:"id"`
-	Name         string  `json:"name" db:"name"`
-	Description  string  `json:"description" db:"description"`
-	InitialPrice float64 `json:"initial_price" db:"initial_price"`
-	FinalPrice   float64 `json:"final_price" db:"final_price"`
-	Currency     string  `json:"currency" db:"currency"`
-	InStock      bool    `json:"in_stock" db:"in_stock"`
-	Color        string  `json:"color" db:"color"`
-	Size         string  `json:"size" db:"size"`
+	ID           int64       `json:"id" db:"id"`
+	Name         string      `json:"name" db:"name"`
+	Description  string      `json:"description" db:"description"`
+	InitialPrice money.Money `json:"initial_price" db:"initial_price"`
+	FinalPrice   money.Money `json:"final_price" db:"final_price"`
+	Currency     string      `json:"currency" db:"currency"`
+	InStock      bool        `json:"in_stock" db:"in_stock"`
+	Color        string      `json:"color" db:"color"`
+	Size         string      `json:"size" db:"size"`
 	// REMOVED: MainImage - now determined by is_main flag in Images slice
 	CountryCode       string        `json:"country_code" db:"country_code"`
 	ImageCount        int           `json:"image_count" db:"image_count"`
@@ -35,6 +36,7 @@ type Product struct {
 	Category          string        `json:"category" db:"category"`
 	Brand             string        `json:"brand" db:"brand"`
 	AllAvailableSizes database.JSON `json:"all_available_sizes" db:"all_available_sizes"`
+	WeightLb          float64       `json:"weight_lb" db:"weight_lb"`
 	CreatedAt         time.Time     `json:"created_at" db:"created_at"`
 	UpdatedAt         time.Time     `json:"updated_at" db:"updated_at"`
 
@@ -55,13 +57,15 @@ func (p *Product) Validate() error {
 		return errors.New("product name must be 500 characters or less")
 	}
 
-	if p.InitialPrice < 0 {
+	if p.InitialPrice.IsNegative() {
 		return errors.New("initial price cannot be negative")
 	}
-	if p.FinalPrice < 0 {
+	if p.FinalPrice.IsNegative() {
 		return errors.New("final price cannot be negative")
 	}
-	if p.FinalPrice > p.InitialPrice {
+	if above, err := p.FinalPrice.GreaterThan(p.InitialPrice); err != nil {
+		return errors.New("final price and initial price must be in the same currency")
+	} else if above {
 		return errors.New("final price cannot be greater than initial price")
 	}
 
@@ -101,12 +105,17 @@ func (p *Product) Validate() error {
 		return errors.New("image count cannot be negative")
 	}
 
+	if p.WeightLb < 0 {
+		return errors.New("weight cannot be negative")
+	}
+
 	return nil
 }
 
 // IsOnSale returns true if the product has a discounted price
 func (p *Product) IsOnSale() bool {
-	return p.FinalPrice < p.InitialPrice && p.InitialPrice > 0
+	below, err := p.FinalPrice.LessThan(p.InitialPrice)
+	return err == nil && below && p.InitialPrice.IsPositive()
 }
 
 // DiscountPercentage returns the discount percentage if the product is on sale
@@ -114,7 +123,11 @@ func (p *Product) DiscountPercentage() float64 {
 	if !p.IsOnSale() {
 		return 0
 	}
-	return ((p.InitialPrice - p.FinalPrice) / p.InitialPrice) * 100
+	saved, err := p.InitialPrice.Sub(p.FinalPrice)
+	if err != nil {
+		return 0
+	}
+	return float64(saved.Minor()) / float64(p.InitialPrice.Minor()) * 100
 }
 
 // FormattedPrice returns a formatted price string with currency
@@ -123,7 +136,22 @@ func (p *Product) FormattedPrice() string {
 	if currency == "" {
 		currency = "USD"
 	}
-	return fmt.Sprintf("%.2f %s", p.FinalPrice, currency)
+	return p.FinalPrice.In(currency).Format()
+}
+
+// AvailableSizes returns the sizes the product is offered in, from
+// AllAvailableSizes, or its Size when no list was recorded.
+func (p *Product) AvailableSizes() []string {
+	var sizes []string
+	if p.AllAvailableSizes.Data != nil {
+		if data, err := json.Marshal(p.AllAvailableSizes.Data); err == nil {
+			_ = json.Unmarshal(data, &sizes)
+		}
+	}
+	if len(sizes) == 0 && p.Size != "" {
+		sizes = []string{p.Size}
+	}
+	return sizes
 }
 
 // HasImages returns true if the product has associated images
@@ -0,0 +1,82 @@
+package eventhandlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	events "go-shopping-poc/internal/contracts/events"
+	"go-shopping-poc/internal/platform/event/bus"
+	"go-shopping-poc/internal/platform/event/handler"
+	"go-shopping-poc/internal/service/product"
+)
+
+// OnCartCheckedOut reserves stock for the items of a checked-out cart.
+// The outcome is published by the inventory service as inventory.reserved
+// or inventory.reservation_failed.
+type OnCartCheckedOut struct {
+	inventory *product.InventoryService
+	logger    *slog.Logger
+}
+
+// NewOnCartCheckedOut creates a new cart checked out handler
+func NewOnCartCheckedOut(inventory *product.InventoryService, logger *slog.Logger) *OnCartCheckedOut {
+	return &OnCartCheckedOut{
+		inventory: inventory,
+		logger:    logger.With("component", "on_cart_checked_out"),
+	}
+}
+
+// Handle processes cart checked out events
+func (h *OnCartCheckedOut) Handle(ctx context.Context, event events.Event) error {
+	cartEvent, ok := event.(events.CartEvent)
+	if !ok {
+		h.logger.Error("Expected CartEvent", "event_type", fmt.Sprintf("%T", event))
+		return nil
+	}
+
+	if cartEvent.EventType != events.CartCheckedOut {
+		h.logger.Debug("Ignoring event type", "event_type", cartEvent.EventType)
+		return nil
+	}
+
+	cartID := cartEvent.EventPayload.CartID
+	utils := handler.NewEventUtils()
+	utils.LogEventProcessing(ctx, string(cartEvent.EventType), cartID, "")
+
+	snapshot := cartEvent.EventPayload.CartSnapshot
+	if snapshot == nil || len(snapshot.Items) == 0 {
+		h.logger.Warn("Checked out cart has no snapshot items", "cart_id", cartID)
+		return nil
+	}
+
+	res, err := h.inventory.ReserveCheckout(ctx, cartID, cartEvent.EventPayload.CheckoutAttempt(), snapshot.Items)
+	utils.LogEventCompletion(ctx, string(cartEvent.EventType), cartID, err)
+	if err != nil {
+		h.logger.Error("Failed to reserve stock", "cart_id", cartID, "error", err.Error())
+		return err
+	}
+
+	h.logger.Debug("Stock reservation processed", "cart_id", cartID, "status", res.Status)
+	return nil
+}
+
+// CreateHandler returns a bus.HandlerFunc that wraps the Handle method
+func (h *OnCartCheckedOut) CreateHandler() bus.HandlerFunc[events.CartEvent] {
+	return func(ctx context.Context, event events.CartEvent) error {
+		return h.Handle(ctx, event)
+	}
+}
+
+// CreateFactory returns an EventFactory for CartEvent
+func (h *OnCartCheckedOut) CreateFactory() events.EventFactory[events.CartEvent] {
+	return events.CartEventFactory{}
+}
+
+// Ensure OnCartCheckedOut implements HandlerFactory
+var _ handler.HandlerFactory[events.CartEvent] = (*OnCartCheckedOut)(nil)
+
+// EventType returns the event type this handler processes
+func (h *OnCartCheckedOut) EventType() string {
+	return string(events.CartCheckedOut)
+}
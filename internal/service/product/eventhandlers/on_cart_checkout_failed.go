@@ -0,0 +1,78 @@
+package eventhandlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	events "go-shopping-poc/internal/contracts/events"
+	"go-shopping-poc/internal/platform/event/bus"
+	"go-shopping-poc/internal/platform/event/handler"
+	"go-shopping-poc/internal/service/product"
+)
+
+// OnCartCheckoutFailed returns the stock reserved for a checkout the cart
+// service rolled back, whether the reservation is still held or was
+// already committed to an order.
+type OnCartCheckoutFailed struct {
+	inventory *product.InventoryService
+	logger    *slog.Logger
+}
+
+// NewOnCartCheckoutFailed creates a new cart checkout failed handler
+func NewOnCartCheckoutFailed(inventory *product.InventoryService, logger *slog.Logger) *OnCartCheckoutFailed {
+	return &OnCartCheckoutFailed{
+		inventory: inventory,
+		logger:    logger.With("component", "on_cart_checkout_failed"),
+	}
+}
+
+// Handle processes cart checkout failed events
+func (h *OnCartCheckoutFailed) Handle(ctx context.Context, event events.Event) error {
+	cartEvent, ok := event.(events.CartEvent)
+	if !ok {
+		h.logger.Error("Expected CartEvent", "event_type", fmt.Sprintf("%T", event))
+		return nil
+	}
+
+	if cartEvent.EventType != events.CartCheckoutFailed {
+		h.logger.Debug("Ignoring event type", "event_type", cartEvent.EventType)
+		return nil
+	}
+
+	cartID := cartEvent.EventPayload.CartID
+	orderID := cartEvent.EventPayload.Details["order_id"]
+	utils := handler.NewEventUtils()
+	utils.LogEventProcessing(ctx, string(cartEvent.EventType), cartID, orderID)
+
+	res, err := h.inventory.ReleaseCheckout(ctx, cartID, cartEvent.EventPayload.CheckoutAttempt(), orderID)
+	utils.LogEventCompletion(ctx, string(cartEvent.EventType), cartID, err)
+	if err != nil {
+		h.logger.Error("Failed to release stock", "cart_id", cartID, "error", err.Error())
+		return err
+	}
+
+	h.logger.Debug("Stock release processed", "cart_id", cartID, "status", res.Status,
+		"reason", cartEvent.EventPayload.Details["reason"])
+	return nil
+}
+
+// CreateHandler returns a bus.HandlerFunc that wraps the Handle method
+func (h *OnCartCheckoutFailed) CreateHandler() bus.HandlerFunc[events.CartEvent] {
+	return func(ctx context.Context, event events.CartEvent) error {
+		return h.Handle(ctx, event)
+	}
+}
+
+// CreateFactory returns an EventFactory for CartEvent
+func (h *OnCartCheckoutFailed) CreateFactory() events.EventFactory[events.CartEvent] {
+	return events.CartEventFactory{}
+}
+
+// Ensure OnCartCheckoutFailed implements HandlerFactory
+var _ handler.HandlerFactory[events.CartEvent] = (*OnCartCheckoutFailed)(nil)
+
+// EventType returns the event type this handler processes
+func (h *OnCartCheckoutFailed) EventType() string {
+	return string(events.CartCheckoutFailed)
+}
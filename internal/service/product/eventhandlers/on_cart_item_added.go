@@ -9,21 +9,38 @@ import (
 	events "go-shopping-poc/internal/contracts/events"
 	"go-shopping-poc/internal/platform/event/bus"
 	"go-shopping-poc/internal/platform/event/handler"
+	"go-shopping-poc/internal/platform/money"
 	"go-shopping-poc/internal/service/product"
 )
 
+// productCatalog is the part of the catalog service the handler reads
+// products and publishes through.
+type productCatalog interface {
+	GetProductByID(ctx context.Context, productID int64) (*product.Product, error)
+	GetInfrastructure() *product.CatalogInfrastructure
+}
+
+// stockLevels is the part of the inventory service the handler checks
+// available stock with.
+type stockLevels interface {
+	GetStockLevel(ctx context.Context, productID int64) (*product.StockLevel, error)
+}
+
 // OnCartItemAdded handles cart item addition events
-// It validates the product and emits product validation events
+// It validates the product and its available stock and emits product
+// validation events
 type OnCartItemAdded struct {
-	service *product.CatalogService
-	logger  *slog.Logger
+	service   productCatalog
+	inventory stockLevels
+	logger    *slog.Logger
 }
 
 // NewOnCartItemAdded creates a new cart item added handler
-func NewOnCartItemAdded(service *product.CatalogService, logger *slog.Logger) *OnCartItemAdded {
+func NewOnCartItemAdded(service *product.CatalogService, inventory *product.InventoryService, logger *slog.Logger) *OnCartItemAdded {
 	return &OnCartItemAdded{
-		service: service,
-		logger:  logger.With("component", "on_cart_item_added"),
+		service:   service,
+		inventory: inventory,
+		logger:    logger.With("component", "on_cart_item_added"),
 	}
 }
 
@@ -54,26 +71,36 @@ func (h *OnCartItemAdded) Handle(ctx context.Context, event events.Event) error
 	productID, err := strconv.ParseInt(payload.ProductID, 10, 64)
 	if err != nil {
 		h.logger.Error("Invalid product ID format", "product_id", payload.ProductID, "error", err.Error())
-		return h.publishValidationResult(ctx, payload, false, 0, "invalid_product_id")
+		return h.publishValidationResult(ctx, payload, false, money.Money{}, "invalid_product_id")
 	}
 
 	// Get product details
 	product, err := h.service.GetProductByID(ctx, productID)
 	if err != nil {
 		h.logger.Debug("Product not found", "product_id", payload.ProductID, "error", err.Error())
-		return h.publishValidationResult(ctx, payload, false, 0, "product_not_found")
+		return h.publishValidationResult(ctx, payload, false, money.Money{}, "product_not_found")
 	}
 
 	if !product.InStock {
 		h.logger.Debug("Product is out of stock", "product_id", payload.ProductID)
-		return h.publishValidationResult(ctx, payload, false, 0, "out_of_stock")
+		return h.publishValidationResult(ctx, payload, false, money.Money{}, "out_of_stock")
+	}
+
+	level, err := h.inventory.GetStockLevel(ctx, productID)
+	if err != nil {
+		h.logger.Debug("Stock level not found", "product_id", payload.ProductID, "error", err.Error())
+		return h.publishValidationResult(ctx, payload, false, money.Money{}, "out_of_stock")
+	}
+	if level.Available() < payload.Quantity {
+		h.logger.Debug("Insufficient stock", "product_id", payload.ProductID, "available", level.Available(), "quantity", payload.Quantity)
+		return h.publishValidationResult(ctx, payload, false, money.Money{}, "insufficient_stock")
 	}
 
 	h.logger.Debug("Product validated successfully", "product_id", payload.ProductID)
-	return h.publishValidationResult(ctx, payload, true, product.FinalPrice, "")
+	return h.publishValidationResult(ctx, payload, true, product.FinalPrice.In(product.Currency), "")
 }
 
-func (h *OnCartItemAdded) publishValidationResult(ctx context.Context, payload events.CartItemPayload, isAvailable bool, unitPrice float64, reason string) error {
+func (h *OnCartItemAdded) publishValidationResult(ctx context.Context, payload events.CartItemPayload, isAvailable bool, unitPrice money.Money, reason string) error {
 	infra := h.service.GetInfrastructure()
 
 	tx, err := infra.Database.BeginTx(ctx, nil)
@@ -93,11 +120,18 @@ func (h *OnCartItemAdded) publishValidationResult(ctx context.Context, payload e
 		// Get product name from service (we need to fetch it again or pass it)
 		productID, _ := strconv.ParseInt(payload.ProductID, 10, 64)
 		product, _ := h.service.GetProductByID(ctx, productID)
-		productName := ""
+		productName, category, brand, color := "", "", "", ""
+		var sizes []string
+		var weightLb float64
 		if product != nil {
 			productName = product.Name
+			category = product.Category
+			brand = product.Brand
+			sizes = product.AvailableSizes()
+			color = product.Color
+			weightLb = product.WeightLb
 		}
-		event = events.NewProductValidatedEvent(payload.ProductID, productName, unitPrice, payload.CartID, payload.LineNumber, payload.ValidationID)
+		event = events.NewProductValidatedEvent(payload.ProductID, productName, category, brand, unitPrice, sizes, color, weightLb, payload.CartID, payload.LineNumber, payload.ValidationID)
 	} else {
 		event = events.NewProductUnavailableEvent(payload.ProductID, reason, payload.CartID, payload.LineNumber, payload.ValidationID)
 	}
@@ -0,0 +1,139 @@
+package eventhandlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+
+	events "go-shopping-poc/internal/contracts/events"
+	"go-shopping-poc/internal/platform/database"
+	"go-shopping-poc/internal/platform/money"
+	"go-shopping-poc/internal/platform/outbox"
+	"go-shopping-poc/internal/service/product"
+)
+
+// fakeOutboxDB captures the product events written to the outbox.
+type fakeOutboxDB struct {
+	database.Database
+	written []events.ProductEvent
+}
+
+func (db *fakeOutboxDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (database.Tx, error) {
+	return &fakeOutboxTx{db: db}, nil
+}
+
+type fakeOutboxTx struct {
+	database.Tx
+	db     *fakeOutboxDB
+	staged []events.ProductEvent
+}
+
+func (tx *fakeOutboxTx) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	var evt events.ProductEvent
+	if err := json.Unmarshal(args[2].([]byte), &evt); err != nil {
+		return nil, err
+	}
+	tx.staged = append(tx.staged, evt)
+	return nil, nil
+}
+
+func (tx *fakeOutboxTx) Commit() error {
+	tx.db.written = append(tx.db.written, tx.staged...)
+	return nil
+}
+
+func (tx *fakeOutboxTx) Rollback() error { return nil }
+
+type fakeCatalog struct {
+	products map[int64]*product.Product
+	infra    *product.CatalogInfrastructure
+}
+
+func (c *fakeCatalog) GetProductByID(ctx context.Context, productID int64) (*product.Product, error) {
+	p, ok := c.products[productID]
+	if !ok {
+		return nil, errors.New("product not found")
+	}
+	return p, nil
+}
+
+func (c *fakeCatalog) GetInfrastructure() *product.CatalogInfrastructure { return c.infra }
+
+type fakeStockLevels map[int64]product.StockLevel
+
+func (s fakeStockLevels) GetStockLevel(ctx context.Context, productID int64) (*product.StockLevel, error) {
+	level, ok := s[productID]
+	if !ok {
+		return nil, errors.New("stock level not found")
+	}
+	return &level, nil
+}
+
+func TestOnCartItemAddedChecksAvailableStock(t *testing.T) {
+	t.Parallel()
+
+	products := map[int64]*product.Product{
+		1: {ID: 1, Name: "Mug", InStock: true, FinalPrice: money.MustParse("12.50", "USD"), Currency: "USD"},
+		2: {ID: 2, Name: "Lamp", InStock: false, FinalPrice: money.MustParse("40.00", "USD"), Currency: "USD"},
+		3: {ID: 3, Name: "Rug", InStock: true, FinalPrice: money.MustParse("99.00", "USD"), Currency: "USD"},
+	}
+	stock := fakeStockLevels{
+		1: {ProductID: 1, OnHand: 10, Reserved: 7},
+		2: {ProductID: 2, OnHand: 10},
+	}
+
+	tests := []struct {
+		name       string
+		productID  string
+		quantity   int
+		wantType   events.ProductEventType
+		wantReason string
+	}{
+		{name: "fits what is not reserved", productID: "1", quantity: 3, wantType: events.ProductValidated},
+		{name: "more than is not reserved", productID: "1", quantity: 4, wantType: events.ProductUnavailable, wantReason: "insufficient_stock"},
+		{name: "flagged out of stock", productID: "2", quantity: 1, wantType: events.ProductUnavailable, wantReason: "out_of_stock"},
+		{name: "no stock level", productID: "3", quantity: 1, wantType: events.ProductUnavailable, wantReason: "out_of_stock"},
+		{name: "unknown product", productID: "4", quantity: 1, wantType: events.ProductUnavailable, wantReason: "product_not_found"},
+		{name: "invalid product id", productID: "mug", quantity: 1, wantType: events.ProductUnavailable, wantReason: "invalid_product_id"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db := &fakeOutboxDB{}
+			catalog := &fakeCatalog{
+				products: products,
+				infra:    &product.CatalogInfrastructure{Database: db, OutboxWriter: outbox.NewWriter(db)},
+			}
+			h := &OnCartItemAdded{service: catalog, inventory: stock, logger: slog.New(slog.DiscardHandler)}
+
+			evt := events.NewCartItemAddedEvent("cart-1", "001", tt.productID, tt.quantity, "validation-1")
+			if err := h.Handle(context.Background(), *evt); err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+
+			if len(db.written) != 1 {
+				t.Fatalf("wrote %d events, want 1", len(db.written))
+			}
+			got := db.written[0]
+			if got.EventType != tt.wantType {
+				t.Errorf("event type = %s, want %s", got.EventType, tt.wantType)
+			}
+			details := got.EventPayload.Details
+			if details["reason"] != tt.wantReason {
+				t.Errorf("reason = %q, want %q", details["reason"], tt.wantReason)
+			}
+			if details["validation_id"] != "validation-1" || details["line_number"] != "001" {
+				t.Errorf("details = %v, want the cart line's validation id and line number", details)
+			}
+			if tt.wantType == events.ProductValidated && (details["unit_price"] != "12.50" || details["currency"] != "USD") {
+				t.Errorf("price = %s %s, want 12.50 USD", details["unit_price"], details["currency"])
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package eventhandlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	events "go-shopping-poc/internal/contracts/events"
+	"go-shopping-poc/internal/platform/event/bus"
+	"go-shopping-poc/internal/platform/event/handler"
+	"go-shopping-poc/internal/service/product"
+)
+
+// OnOrderEvent settles checkout reservations as orders progress:
+// order.created commits the cart's reservation and order.cancelled
+// releases it.
+type OnOrderEvent struct {
+	inventory *product.InventoryService
+	logger    *slog.Logger
+}
+
+// NewOnOrderEvent creates a new order event handler
+func NewOnOrderEvent(inventory *product.InventoryService, logger *slog.Logger) *OnOrderEvent {
+	return &OnOrderEvent{
+		inventory: inventory,
+		logger:    logger.With("component", "on_order_event"),
+	}
+}
+
+// Handle processes order created and cancelled events
+func (h *OnOrderEvent) Handle(ctx context.Context, event events.Event) error {
+	orderEvent, ok := event.(events.OrderEvent)
+	if !ok {
+		h.logger.Error("Expected OrderEvent", "event_type", fmt.Sprintf("%T", event))
+		return nil
+	}
+
+	payload := orderEvent.Data
+	var err error
+	switch orderEvent.EventType {
+	case events.OrderCreated:
+		_, err = h.inventory.CommitOrder(ctx, payload.CartID, payload.Attempt, payload.OrderID)
+	case events.OrderCancelled:
+		_, err = h.inventory.ReleaseOrder(ctx, payload.CartID, payload.Attempt, payload.OrderID)
+	default:
+		h.logger.Debug("Ignoring event type", "event_type", orderEvent.EventType)
+		return nil
+	}
+
+	utils := handler.NewEventUtils()
+	utils.LogEventCompletion(ctx, string(orderEvent.EventType), payload.OrderID, err)
+	if err != nil {
+		h.logger.Error("Failed to settle reservation",
+			"event_type", orderEvent.EventType,
+			"order_id", payload.OrderID,
+			"cart_id", payload.CartID,
+			"error", err.Error(),
+		)
+		return err
+	}
+	return nil
+}
+
+// CreateHandler returns a bus.HandlerFunc that wraps the Handle method
+func (h *OnOrderEvent) CreateHandler() bus.HandlerFunc[events.OrderEvent] {
+	return func(ctx context.Context, event events.OrderEvent) error {
+		return h.Handle(ctx, event)
+	}
+}
+
+// CreateFactory returns an EventFactory for OrderEvent
+func (h *OnOrderEvent) CreateFactory() events.EventFactory[events.OrderEvent] {
+	return events.OrderEventFactory{}
+}
+
+// Ensure OnOrderEvent implements HandlerFactory
+var _ handler.HandlerFactory[events.OrderEvent] = (*OnOrderEvent)(nil)
+
+// EventType returns the event type this handler processes
+func (h *OnOrderEvent) EventType() string {
+	return string(events.OrderCreated)
+}
@@ -0,0 +1,76 @@
+package eventhandlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	events "go-shopping-poc/internal/contracts/events"
+	"go-shopping-poc/internal/platform/event/bus"
+	"go-shopping-poc/internal/platform/event/handler"
+	"go-shopping-poc/internal/service/product"
+)
+
+// OnOrderReturnReceived puts returned items back on hand: order.return_received
+// restocks the lines of the return that staff marked for restocking.
+type OnOrderReturnReceived struct {
+	inventory *product.InventoryService
+	logger    *slog.Logger
+}
+
+// NewOnOrderReturnReceived creates a new order return handler
+func NewOnOrderReturnReceived(inventory *product.InventoryService, logger *slog.Logger) *OnOrderReturnReceived {
+	return &OnOrderReturnReceived{
+		inventory: inventory,
+		logger:    logger.With("component", "on_order_return_received"),
+	}
+}
+
+// Handle processes order return received events. Other return steps and
+// other events on OrderEvents are ignored.
+func (h *OnOrderReturnReceived) Handle(ctx context.Context, event events.Event) error {
+	returnEvent, ok := event.(events.OrderReturnEvent)
+	if !ok {
+		h.logger.Error("Expected OrderReturnEvent", "event_type", fmt.Sprintf("%T", event))
+		return nil
+	}
+	if returnEvent.EventType != events.OrderReturnReceived {
+		return nil
+	}
+
+	payload := returnEvent.Data
+	_, err := h.inventory.RestockReturn(ctx, payload.ReturnID, payload.CartID, payload.OrderID, payload.Lines)
+
+	utils := handler.NewEventUtils()
+	utils.LogEventCompletion(ctx, string(returnEvent.EventType), payload.OrderID, err)
+	if err != nil {
+		h.logger.Error("Failed to restock return",
+			"return_id", payload.ReturnID,
+			"rma_number", payload.RMANumber,
+			"order_id", payload.OrderID,
+			"error", err.Error(),
+		)
+		return err
+	}
+	return nil
+}
+
+// CreateHandler returns a bus.HandlerFunc that wraps the Handle method
+func (h *OnOrderReturnReceived) CreateHandler() bus.HandlerFunc[events.OrderReturnEvent] {
+	return func(ctx context.Context, event events.OrderReturnEvent) error {
+		return h.Handle(ctx, event)
+	}
+}
+
+// CreateFactory returns an EventFactory for OrderReturnEvent
+func (h *OnOrderReturnReceived) CreateFactory() events.EventFactory[events.OrderReturnEvent] {
+	return events.OrderReturnEventFactory{}
+}
+
+// Ensure OnOrderReturnReceived implements HandlerFactory
+var _ handler.HandlerFactory[events.OrderReturnEvent] = (*OnOrderReturnReceived)(nil)
+
+// EventType returns the event type this handler processes
+func (h *OnOrderReturnReceived) EventType() string {
+	return string(events.OrderReturnReceived)
+}
@@ -12,6 +12,7 @@ import (
 
 	"go-shopping-poc/internal/platform/httperr"
 	"go-shopping-poc/internal/platform/httpx"
+	"go-shopping-poc/internal/platform/logging"
 	"go-shopping-poc/internal/platform/storage/minio"
 )
 
@@ -42,7 +43,7 @@ func (h *CatalogHandler) GetAllProducts(w http.ResponseWriter, r *http.Request)
 
 	products, err := h.service.GetAllProducts(r.Context(), limit, offset)
 	if err != nil {
-		httperr.Internal(w, "Failed to retrieve products")
+		httperr.FromError(w, err, "Failed to retrieve products")
 		return
 	}
 
@@ -85,7 +86,7 @@ func (h *CatalogHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 
 	product, err := h.service.GetProductByID(r.Context(), productID)
 	if err != nil {
-		httperr.Internal(w, "Failed to retrieve product")
+		httperr.FromError(w, err, "Failed to retrieve product")
 		return
 	}
 
@@ -111,7 +112,7 @@ func (h *CatalogHandler) GetProductsByCategory(w http.ResponseWriter, r *http.Re
 
 	products, err := h.service.GetProductsByCategory(r.Context(), category, limit, offset)
 	if err != nil {
-		httperr.Internal(w, "Failed to retrieve products by category")
+		httperr.FromError(w, err, "Failed to retrieve products by category")
 		return
 	}
 
@@ -146,7 +147,7 @@ func (h *CatalogHandler) GetProductsByBrand(w http.ResponseWriter, r *http.Reque
 
 	products, err := h.service.GetProductsByBrand(r.Context(), brand, limit, offset)
 	if err != nil {
-		httperr.Internal(w, "Failed to retrieve products by brand")
+		httperr.FromError(w, err, "Failed to retrieve products by brand")
 		return
 	}
 
@@ -181,7 +182,7 @@ func (h *CatalogHandler) SearchProducts(w http.ResponseWriter, r *http.Request)
 
 	products, err := h.service.SearchProducts(r.Context(), query, limit, offset)
 	if err != nil {
-		httperr.Internal(w, "Failed to search products")
+		httperr.FromError(w, err, "Failed to search products")
 		return
 	}
 
@@ -211,7 +212,7 @@ func (h *CatalogHandler) GetProductsInStock(w http.ResponseWriter, r *http.Reque
 
 	products, err := h.service.GetProductsInStock(r.Context(), limit, offset)
 	if err != nil {
-		httperr.Internal(w, "Failed to retrieve in-stock products")
+		httperr.FromError(w, err, "Failed to retrieve in-stock products")
 		return
 	}
 
@@ -255,7 +256,7 @@ func (h *CatalogHandler) GetProductImages(w http.ResponseWriter, r *http.Request
 	// Get product with images loaded
 	product, err := h.service.GetProductByID(r.Context(), productID)
 	if err != nil {
-		httperr.Internal(w, "Failed to retrieve product")
+		httperr.FromError(w, err, "Failed to retrieve product")
 		return
 	}
 
@@ -302,7 +303,7 @@ func (h *CatalogHandler) GetProductMainImage(w http.ResponseWriter, r *http.Requ
 	// Get product to access images via the loaded relationship
 	product, err := h.service.GetProductByID(r.Context(), productID)
 	if err != nil {
-		httperr.Internal(w, "Failed to retrieve product")
+		httperr.FromError(w, err, "Failed to retrieve product")
 		return
 	}
 
@@ -335,7 +336,7 @@ func (h *CatalogHandler) GetProductMainImage(w http.ResponseWriter, r *http.Requ
 // Example: /api/v1/products/40121298/images/image_0.jpg -> object name: products/40121298/image_0.jpg
 func (h *CatalogHandler) GetDirectImage(w http.ResponseWriter, r *http.Request) {
 	startedAt := time.Now()
-	requestID := requestIDFromRequest(r)
+	requestID := logging.RequestIDFromContext(r.Context())
 	log := h.logger.With("operation", "get_direct_image", "request_id", requestID)
 	log.Debug("Direct image request received", "method", r.Method, "path", r.URL.Path)
 
@@ -445,14 +446,3 @@ func parsePagination(r *http.Request) (limit, offset int) {
 
 	return limit, offset
 }
-
-func requestIDFromRequest(r *http.Request) string {
-	if requestID := r.Header.Get("X-Request-ID"); requestID != "" {
-		return requestID
-	}
-	if requestID := r.Header.Get("X-Request-Id"); requestID != "" {
-		return requestID
-	}
-
-	return ""
-}
@@ -0,0 +1,86 @@
+package product
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"go-shopping-poc/internal/platform/httperr"
+	"go-shopping-poc/internal/platform/httpx"
+
+	"github.com/google/uuid"
+)
+
+// InventoryHandler handles HTTP requests for product stock levels.
+type InventoryHandler struct {
+	service *InventoryService
+	logger  *slog.Logger
+}
+
+// NewInventoryHandler creates a new inventory handler instance.
+func NewInventoryHandler(logger *slog.Logger, service *InventoryService) *InventoryHandler {
+	return &InventoryHandler{
+		service: service,
+		logger:  logger.With("component", "inventory_handler"),
+	}
+}
+
+// stockResponse is a product's stock level with its available quantity.
+type stockResponse struct {
+	*StockLevel
+	Available int  `json:"available"`
+	InStock   bool `json:"in_stock"`
+}
+
+// GetStockLevel handles GET /api/v1/products/{id}/stock - retrieves a product's stock level
+func (h *InventoryHandler) GetStockLevel(w http.ResponseWriter, r *http.Request) {
+	idStr, ok := requiredPathParam(w, r, "id", "Missing product ID in path")
+	if !ok {
+		return
+	}
+
+	productID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || productID <= 0 {
+		httperr.Validation(w, "Invalid product ID format")
+		return
+	}
+
+	level, err := h.service.GetStockLevel(r.Context(), productID)
+	if err != nil {
+		httperr.FromError(w, err, "Failed to retrieve stock level")
+		return
+	}
+
+	response := stockResponse{
+		StockLevel: level,
+		Available:  level.Available(),
+		InStock:    level.Available() > 0,
+	}
+	if err := httpx.WriteJSON(w, http.StatusOK, response); err != nil {
+		httperr.Internal(w, "Failed to encode response")
+		return
+	}
+}
+
+// GetReservation handles GET /api/v1/reservations/{cartId} - retrieves the stock reservation of a cart
+func (h *InventoryHandler) GetReservation(w http.ResponseWriter, r *http.Request) {
+	cartID, ok := requiredPathParam(w, r, "cartId", "Missing cart ID in path")
+	if !ok {
+		return
+	}
+	if _, err := uuid.Parse(cartID); err != nil {
+		httperr.Validation(w, "Invalid cart ID format")
+		return
+	}
+
+	res, err := h.service.GetReservation(r.Context(), cartID)
+	if err != nil {
+		httperr.FromError(w, err, "Failed to retrieve reservation")
+		return
+	}
+
+	if err := httpx.WriteJSON(w, http.StatusOK, res); err != nil {
+		httperr.Internal(w, "Failed to encode response")
+		return
+	}
+}
@@ -0,0 +1,88 @@
+package product
+
+import (
+	"cmp"
+	"errors"
+	"slices"
+	"time"
+)
+
+var (
+	ErrReservationNotFound = errors.New("reservation not found")
+	ErrInvalidReservation  = errors.New("invalid reservation")
+)
+
+// Reservation statuses. A reservation is held from checkout until its
+// order is created (committed) or cancelled, or until it expires.
+const (
+	ReservationHeld      = "held"
+	ReservationCommitted = "committed"
+	ReservationReleased  = "released"
+	ReservationExpired   = "expired"
+	ReservationFailed    = "failed"
+)
+
+// StockLevel holds a product's on-hand quantity and the part of it held
+// by reservations.
+type StockLevel struct {
+	ProductID int64     `json:"product_id" db:"product_id"`
+	OnHand    int       `json:"on_hand" db:"on_hand"`
+	Reserved  int       `json:"reserved" db:"reserved"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Available returns the quantity that can still be reserved.
+func (s StockLevel) Available() int {
+	return s.OnHand - s.Reserved
+}
+
+// Reservation is the stock held for one checked-out cart.
+type Reservation struct {
+	CartID    string            `json:"cart_id" db:"cart_id"`
+	Attempt   int               `json:"attempt" db:"attempt"`
+	OrderID   *string           `json:"order_id,omitempty" db:"order_id"`
+	Status    string            `json:"status" db:"status"`
+	Reason    *string           `json:"reason,omitempty" db:"reason"`
+	ExpiresAt *time.Time        `json:"expires_at,omitempty" db:"expires_at"`
+	CreatedAt time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at" db:"updated_at"`
+	Lines     []ReservationLine `json:"lines,omitempty"`
+}
+
+// ReservationLine is the quantity of one product held by a reservation.
+// Available is the product's unreserved quantity after the reservation
+// changed; it is not persisted.
+type ReservationLine struct {
+	ProductID int64 `json:"product_id" db:"product_id"`
+	Quantity  int   `json:"quantity" db:"quantity"`
+	Available int   `json:"available" db:"-"`
+}
+
+// mergeReservationLines sums quantities per product and orders lines by
+// product ID so concurrent reservations lock inventory rows in the same
+// order.
+func mergeReservationLines(lines []ReservationLine) ([]ReservationLine, error) {
+	byProduct := make(map[int64]int, len(lines))
+	var order []int64
+	for _, line := range lines {
+		if line.Quantity <= 0 {
+			return nil, ErrInvalidReservation
+		}
+		if _, seen := byProduct[line.ProductID]; !seen {
+			order = append(order, line.ProductID)
+		}
+		byProduct[line.ProductID] += line.Quantity
+	}
+	if len(order) == 0 {
+		return nil, ErrInvalidReservation
+	}
+
+	merged := make([]ReservationLine, 0, len(order))
+	for _, id := range order {
+		merged = append(merged, ReservationLine{ProductID: id, Quantity: byProduct[id]})
+	}
+	slices.SortFunc(merged, func(a, b ReservationLine) int {
+		return cmp.Compare(a.ProductID, b.ProductID)
+	})
+	return merged, nil
+}
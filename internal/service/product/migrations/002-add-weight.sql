@@ -0,0 +1,6 @@
+-- Migration: Add product shipping weight
+-- The weight in pounds is published with product events so the cart can
+-- quote shipping by it. Zero means unknown; shipping then uses its default
+-- item weight.
+
+ALTER TABLE products.Products ADD COLUMN weight_lb DECIMAL(10,3) NOT NULL DEFAULT 0;
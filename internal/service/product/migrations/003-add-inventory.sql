@@ -0,0 +1,78 @@
+-- Migration: Add inventory quantities and checkout reservations
+-- Each product has an on-hand quantity and the part of it reserved by
+-- checked-out carts. products.in_stock is kept in sync with whether any
+-- unreserved stock is left, so catalog queries keep working unchanged.
+
+CREATE TABLE products.Inventory (
+    product_id BIGINT PRIMARY KEY REFERENCES products.Products(id) ON DELETE CASCADE,
+    on_hand INTEGER NOT NULL DEFAULT 0 CHECK (on_hand >= 0),
+    reserved INTEGER NOT NULL DEFAULT 0 CHECK (reserved >= 0),
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (reserved <= on_hand)
+);
+
+-- One reservation per checked-out cart. Status moves from held to
+-- committed when the order is created, or to released/expired.
+-- A reservation with no lines is a placeholder recording an order
+-- created or cancelled before its cart's stock was reserved.
+CREATE TABLE products.Reservation (
+    cart_id UUID PRIMARY KEY,
+    order_id UUID,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('held', 'committed', 'released', 'expired', 'failed')),
+    reason TEXT,
+    expires_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE products.Reservation_line (
+    cart_id UUID NOT NULL REFERENCES products.Reservation(cart_id) ON DELETE CASCADE,
+    product_id BIGINT NOT NULL REFERENCES products.Products(id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (cart_id, product_id)
+);
+
+CREATE INDEX idx_reservation_held_expiry ON products.Reservation(expires_at) WHERE status = 'held';
+
+CREATE TRIGGER update_inventory_updated_at
+    BEFORE UPDATE ON products.Inventory
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_reservation_updated_at
+    BEFORE UPDATE ON products.Reservation
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Products loaded without a quantity start with a default stock level.
+CREATE OR REPLACE FUNCTION create_product_inventory()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO products.Inventory (product_id, on_hand)
+    VALUES (NEW.id, CASE WHEN COALESCE(NEW.in_stock, true) THEN 100 ELSE 0 END)
+    ON CONFLICT (product_id) DO NOTHING;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER create_product_inventory_trigger
+    AFTER INSERT ON products.Products
+    FOR EACH ROW EXECUTE FUNCTION create_product_inventory();
+
+CREATE OR REPLACE FUNCTION sync_product_in_stock()
+RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE products.Products
+    SET in_stock = (NEW.on_hand - NEW.reserved) > 0
+    WHERE id = NEW.product_id
+      AND in_stock IS DISTINCT FROM ((NEW.on_hand - NEW.reserved) > 0);
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER sync_product_in_stock_trigger
+    AFTER UPDATE OF on_hand, reserved ON products.Inventory
+    FOR EACH ROW EXECUTE FUNCTION sync_product_in_stock();
+
+INSERT INTO products.Inventory (product_id, on_hand)
+SELECT id, CASE WHEN COALESCE(in_stock, true) THEN 100 ELSE 0 END
+FROM products.Products
+ON CONFLICT (product_id) DO NOTHING;
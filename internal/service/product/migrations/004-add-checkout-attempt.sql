@@ -0,0 +1,8 @@
+-- Migration: Record the checkout attempt of each reservation
+-- A cart whose checkout was rolled back can be checked out again, and the
+-- reservation of its earlier checkout is replaced. Each checkout of a cart
+-- is numbered, so that late events of an earlier checkout leave the stock
+-- of the current one alone. Reservations made before checkouts were
+-- numbered keep attempt 0.
+
+ALTER TABLE products.Reservation ADD COLUMN attempt INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,9 @@
+-- Migration: Add restocks of returned order lines
+-- Returned items the order service marks for restocking go back on hand
+-- once per return; the return ID makes a redelivered event a no-op.
+
+CREATE TABLE products.Restock (
+    return_id UUID PRIMARY KEY,
+    order_id UUID NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
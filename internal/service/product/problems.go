@@ -0,0 +1,18 @@
+package product
+
+import (
+	"net/http"
+
+	platformerrors "go-shopping-poc/internal/platform/errors"
+	"go-shopping-poc/internal/platform/httperr"
+)
+
+// init maps product domain errors to problem responses.
+func init() {
+	httperr.Register(ErrProductNotFound, httperr.Mapping{Status: http.StatusNotFound, Type: platformerrors.ErrorTypeNotFound, Code: "product_not_found", Message: "Product not found"})
+	httperr.Register(ErrProductImageNotFound, httperr.Mapping{Status: http.StatusNotFound, Type: platformerrors.ErrorTypeNotFound, Code: "product_image_not_found", Message: "Image not found"})
+	httperr.Register(ErrInvalidProductID, httperr.Mapping{Status: http.StatusBadRequest, Type: platformerrors.ErrorTypeValidation, Code: "product_invalid_id", Message: "Invalid product ID"})
+	httperr.Register(ErrReservationNotFound, httperr.Mapping{Status: http.StatusNotFound, Type: platformerrors.ErrorTypeNotFound, Code: "reservation_not_found", Message: "Reservation not found"})
+	httperr.Register(ErrInvalidReservation, httperr.Mapping{Status: http.StatusBadRequest, Type: platformerrors.ErrorTypeValidation, Code: "reservation_invalid", Message: "Invalid reservation"})
+	httperr.Register(ErrDuplicateImage, httperr.Mapping{Status: http.StatusConflict, Type: platformerrors.ErrorTypeConflict, Code: "product_duplicate_image"})
+}
@@ -44,8 +44,13 @@ func (r *productRepository) BulkInsertProducts(ctx context.Context, products []*
 		evt := events.NewProductCreatedEvent(fmt.Sprintf("%d", product.ID), map[string]string{
 			"name":        product.Name,
 			"brand":       product.Brand,
-			"final_price": fmt.Sprintf("%.2f", product.FinalPrice),
+			"category":    product.Category,
+			"final_price": product.FinalPrice.String(),
+			"currency":    product.Currency,
 			"in_stock":    fmt.Sprintf("%t", product.InStock),
+			"sizes":       events.EncodeSizes(product.AvailableSizes()),
+			"color":       product.Color,
+			"weight_lb":   events.EncodeWeight(product.WeightLb),
 			"images":      fmt.Sprintf("%d", len(product.Images)),
 		})
 
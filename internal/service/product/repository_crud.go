@@ -64,8 +64,13 @@ func (r *productRepository) insertProductWithImages(ctx context.Context, product
 	evt := events.NewProductCreatedEvent(fmt.Sprintf("%d", product.ID), map[string]string{
 		"name":        product.Name,
 		"brand":       product.Brand,
-		"final_price": fmt.Sprintf("%.2f", product.FinalPrice),
+		"category":    product.Category,
+		"final_price": product.FinalPrice.String(),
+		"currency":    product.Currency,
 		"in_stock":    fmt.Sprintf("%t", product.InStock),
+		"sizes":       events.EncodeSizes(product.AvailableSizes()),
+		"color":       product.Color,
+		"weight_lb":   events.EncodeWeight(product.WeightLb),
 		"images":      fmt.Sprintf("%d", len(product.Images)),
 	})
 
@@ -89,9 +94,9 @@ func (r *productRepository) insertProductRecord(ctx context.Context, tx database
 			id, name, description, initial_price, final_price, currency, in_stock,
 			color, size, country_code, image_count, model_number,
 			root_category, category, brand, other_attributes, all_available_sizes,
-			created_at, updated_at
+			weight_lb, created_at, updated_at
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20
 		) ON CONFLICT (id) DO NOTHING`
 
 	_, err := tx.ExecContext(ctx, query,
@@ -99,7 +104,7 @@ func (r *productRepository) insertProductRecord(ctx context.Context, tx database
 		product.Currency, product.InStock, product.Color, product.Size,
 		product.CountryCode, product.ImageCount, product.ModelNumber, product.RootCategory,
 		product.Category, product.Brand, product.OtherAttributes, product.AllAvailableSizes,
-		product.CreatedAt, product.UpdatedAt,
+		product.WeightLb, product.CreatedAt, product.UpdatedAt,
 	)
 	if err != nil {
 		return fmt.Errorf("%w: failed to insert product record: %w", ErrDatabaseOperation, err)
@@ -138,7 +143,7 @@ func (r *productRepository) UpdateProduct(ctx context.Context, product *Product)
 			currency = $6, in_stock = $7, color = $8, size = $9,
 			country_code = $10, image_count = $11, model_number = $12,
 			root_category = $13, category = $14, brand = $15,
-			other_attributes = $16, all_available_sizes = $17, updated_at = $18
+			other_attributes = $16, all_available_sizes = $17, weight_lb = $18, updated_at = $19
 		WHERE id = $1`
 
 	result, err := tx.ExecContext(ctx, query,
@@ -146,7 +151,7 @@ func (r *productRepository) UpdateProduct(ctx context.Context, product *Product)
 		product.Currency, product.InStock, product.Color, product.Size,
 		product.CountryCode, product.ImageCount, product.ModelNumber, product.RootCategory,
 		product.Category, product.Brand, product.OtherAttributes, product.AllAvailableSizes,
-		product.UpdatedAt,
+		product.WeightLb, product.UpdatedAt,
 	)
 	if err != nil {
 		return fmt.Errorf("%w: failed to update product: %w", ErrDatabaseOperation, err)
@@ -164,8 +169,13 @@ func (r *productRepository) UpdateProduct(ctx context.Context, product *Product)
 	evt := events.NewProductUpdatedEvent(fmt.Sprintf("%d", product.ID), map[string]string{
 		"name":        product.Name,
 		"brand":       product.Brand,
-		"final_price": fmt.Sprintf("%.2f", product.FinalPrice),
+		"category":    product.Category,
+		"final_price": product.FinalPrice.String(),
+		"currency":    product.Currency,
 		"in_stock":    fmt.Sprintf("%t", product.InStock),
+		"sizes":       events.EncodeSizes(product.AvailableSizes()),
+		"color":       product.Color,
+		"weight_lb":   events.EncodeWeight(product.WeightLb),
 	})
 
 	if err := r.outboxWriter.WriteEvent(ctx, tx, evt); err != nil {
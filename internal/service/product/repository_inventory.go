@@ -0,0 +1,715 @@
+// Package product provides data access operations for product entities.
+//
+// This file contains inventory operations: stock levels and the
+// reservations held for checked-out carts. Every change to a reservation
+// writes its inventory event to the outbox in the same transaction.
+//
+// Reservation and order events for a cart arrive on different topics and
+// may be processed in either order. Each operation therefore takes a
+// per-cart advisory lock and records a placeholder reservation (no lines)
+// when an order is created or cancelled before its stock was reserved.
+//
+// A cart whose checkout was rolled back can be checked out again. Every
+// operation names the checkout attempt it belongs to: operations of an
+// earlier attempt than the reservation's are ignored, and the first
+// operation of a later attempt replaces the reservation, returning any
+// stock the earlier attempt still held.
+package product
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	events "go-shopping-poc/internal/contracts/events"
+	"go-shopping-poc/internal/platform/database"
+	"go-shopping-poc/internal/platform/outbox"
+)
+
+type InventoryRepository interface {
+	GetStockLevel(ctx context.Context, productID int64) (*StockLevel, error)
+	GetReservation(ctx context.Context, cartID string) (*Reservation, error)
+
+	Reserve(ctx context.Context, cartID string, attempt int, lines []ReservationLine, expiresAt time.Time) (*Reservation, error)
+	Commit(ctx context.Context, cartID string, attempt int, orderID string) (*Reservation, error)
+	Release(ctx context.Context, cartID string, attempt int, orderID, reason string) (*Reservation, error)
+	ExpireHeld(ctx context.Context, now time.Time, limit int) ([]*Reservation, error)
+	Restock(ctx context.Context, returnID, cartID, orderID string, lines []ReservationLine) ([]ReservationLine, error)
+}
+
+type inventoryRepository struct {
+	db           database.Database
+	outboxWriter *outbox.Writer
+	logger       *slog.Logger
+}
+
+func NewInventoryRepository(db database.Database, outboxWriter *outbox.Writer, logger *slog.Logger) InventoryRepository {
+	if logger == nil {
+		logger = slog.Default().With("component", "inventory_repository")
+	}
+	return &inventoryRepository{
+		db:           db,
+		outboxWriter: outboxWriter,
+		logger:       logger,
+	}
+}
+
+// GetStockLevel returns the on-hand and reserved quantities of a product.
+func (r *inventoryRepository) GetStockLevel(ctx context.Context, productID int64) (*StockLevel, error) {
+	var level StockLevel
+	query := `
+		SELECT product_id, on_hand, reserved, updated_at
+		FROM products.Inventory
+		WHERE product_id = $1
+	`
+	err := r.db.GetContext(ctx, &level, query, productID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrProductNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get stock level: %w", ErrDatabaseOperation, err)
+	}
+	return &level, nil
+}
+
+// GetReservation returns the reservation of a cart with its lines.
+func (r *inventoryRepository) GetReservation(ctx context.Context, cartID string) (*Reservation, error) {
+	var res Reservation
+	query := `
+		SELECT cart_id, attempt, order_id, status, reason, expires_at, created_at, updated_at
+		FROM products.Reservation
+		WHERE cart_id = $1
+	`
+	err := r.db.GetContext(ctx, &res, query, cartID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrReservationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get reservation: %w", ErrDatabaseOperation, err)
+	}
+
+	linesQuery := `
+		SELECT product_id, quantity
+		FROM products.Reservation_line
+		WHERE cart_id = $1
+		ORDER BY product_id
+	`
+	if err := r.db.SelectContext(ctx, &res.Lines, linesQuery, cartID); err != nil {
+		return nil, fmt.Errorf("%w: failed to get reservation lines: %w", ErrDatabaseOperation, err)
+	}
+	return &res, nil
+}
+
+// Reserve holds stock for every line of checkout attempt of a cart until
+// expiresAt, or holds nothing if any product is short. A failed
+// reservation is returned with status failed and the short lines, each
+// carrying the quantity that was available.
+//
+// If the cart's order has already been created the stock is taken
+// directly and the reservation is committed. Redelivered checkouts,
+// checkouts whose order was cancelled first and checkouts superseded by a
+// later attempt are returned unchanged.
+func (r *inventoryRepository) Reserve(ctx context.Context, cartID string, attempt int, lines []ReservationLine, expiresAt time.Time) (*Reservation, error) {
+	merged, err := mergeReservationLines(lines)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := r.beginCartTx(ctx, cartID)
+	if err != nil {
+		return nil, err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	res, err := r.lockReservationTx(ctx, tx, cartID)
+	if err != nil {
+		return nil, err
+	}
+	if staleAttempt(res, attempt) {
+		r.logger.Debug("Checkout attempt superseded", "cart_id", cartID, "attempt", attempt, "current_attempt", res.Attempt)
+		return res, nil
+	}
+	superseded, err := r.supersedeTx(ctx, tx, res, attempt)
+	if err != nil {
+		return nil, err
+	}
+	if superseded {
+		res = nil
+	}
+	orderFirst := res != nil && res.Status == ReservationCommitted && len(res.Lines) == 0
+	if res != nil && !orderFirst {
+		r.logger.Debug("Reservation already processed", "cart_id", cartID, "status", res.Status)
+		return res, nil
+	}
+
+	shortages, err := r.lockStockTx(ctx, tx, merged)
+	if err != nil {
+		return nil, err
+	}
+
+	var evt *events.InventoryEvent
+	if len(shortages) > 0 {
+		reason := "insufficient_stock"
+		res, err = r.saveReservationTx(ctx, tx, cartID, attempt, orderIDOf(res), ReservationFailed, &reason, nil)
+		if err != nil {
+			return nil, err
+		}
+		res.Lines = shortages
+		evt = events.NewInventoryReservationFailedEvent(cartID, reason, inventoryLines(shortages))
+	} else if orderFirst {
+		if err := r.takeStockTx(ctx, tx, cartID, merged, true); err != nil {
+			return nil, err
+		}
+		if err := r.insertLinesTx(ctx, tx, cartID, merged); err != nil {
+			return nil, err
+		}
+		res.Lines = merged
+		evt = events.NewInventoryCommittedEvent(cartID, *res.OrderID, inventoryLines(merged))
+	} else {
+		res, err = r.saveReservationTx(ctx, tx, cartID, attempt, nil, ReservationHeld, nil, &expiresAt)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.takeStockTx(ctx, tx, cartID, merged, false); err != nil {
+			return nil, err
+		}
+		if err := r.insertLinesTx(ctx, tx, cartID, merged); err != nil {
+			return nil, err
+		}
+		res.Lines = merged
+		evt = events.NewInventoryReservedEvent(cartID, expiresAt, inventoryLines(merged))
+	}
+
+	evt.Data.Attempt = res.Attempt
+	if err := r.outboxWriter.WriteEvent(ctx, tx, evt); err != nil {
+		return nil, fmt.Errorf("%w: failed to write %s event: %w", ErrEventWriteFailed, evt.EventType, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%w: failed to commit transaction: %w", ErrTransactionFailed, err)
+	}
+	committed = true
+	return res, nil
+}
+
+// Commit turns a cart's held stock into a sale once the order of checkout
+// attempt exists. A reservation that expired before the order arrived is
+// re-acquired if the stock is still there and fails otherwise.
+func (r *inventoryRepository) Commit(ctx context.Context, cartID string, attempt int, orderID string) (*Reservation, error) {
+	tx, err := r.beginCartTx(ctx, cartID)
+	if err != nil {
+		return nil, err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	res, err := r.lockReservationTx(ctx, tx, cartID)
+	if err != nil {
+		return nil, err
+	}
+	if staleAttempt(res, attempt) {
+		r.logger.Debug("Order of superseded checkout attempt", "cart_id", cartID, "order_id", orderID, "attempt", attempt)
+		return res, nil
+	}
+	superseded, err := r.supersedeTx(ctx, tx, res, attempt)
+	if err != nil {
+		return nil, err
+	}
+	if superseded {
+		res = nil
+	}
+
+	var evt *events.InventoryEvent
+	switch {
+	case res == nil:
+		// The order was created before the checkout was reserved; Reserve
+		// takes the stock when the checkout arrives.
+		if res, err = r.saveReservationTx(ctx, tx, cartID, attempt, &orderID, ReservationCommitted, nil, nil); err != nil {
+			return nil, err
+		}
+	case res.Status == ReservationHeld:
+		lines := res.Lines
+		if err := r.commitHeldTx(ctx, tx, cartID, lines); err != nil {
+			return nil, err
+		}
+		if res, err = r.saveReservationTx(ctx, tx, cartID, attempt, &orderID, ReservationCommitted, nil, nil); err != nil {
+			return nil, err
+		}
+		res.Lines = lines
+		evt = events.NewInventoryCommittedEvent(cartID, orderID, inventoryLines(lines))
+	case res.Status == ReservationExpired:
+		lines := res.Lines
+		shortages, err := r.lockStockTx(ctx, tx, lines)
+		if err != nil {
+			return nil, err
+		}
+		if len(shortages) > 0 {
+			reason := "reservation_expired"
+			if res, err = r.saveReservationTx(ctx, tx, cartID, attempt, &orderID, ReservationFailed, &reason, nil); err != nil {
+				return nil, err
+			}
+			evt = events.NewInventoryReservationFailedEvent(cartID, reason, inventoryLines(shortages))
+			evt.Data.OrderID = orderID
+			break
+		}
+		if err := r.takeStockTx(ctx, tx, cartID, lines, true); err != nil {
+			return nil, err
+		}
+		if res, err = r.saveReservationTx(ctx, tx, cartID, attempt, &orderID, ReservationCommitted, nil, nil); err != nil {
+			return nil, err
+		}
+		res.Lines = lines
+		evt = events.NewInventoryCommittedEvent(cartID, orderID, inventoryLines(lines))
+	default:
+		r.logger.Debug("Reservation not committable", "cart_id", cartID, "status", res.Status)
+		return res, nil
+	}
+
+	if evt != nil {
+		evt.Data.Attempt = res.Attempt
+		if err := r.outboxWriter.WriteEvent(ctx, tx, evt); err != nil {
+			return nil, fmt.Errorf("%w: failed to write %s event: %w", ErrEventWriteFailed, evt.EventType, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%w: failed to commit transaction: %w", ErrTransactionFailed, err)
+	}
+	committed = true
+	return res, nil
+}
+
+// Release returns the stock of checkout attempt of a cart when its order
+// or checkout is cancelled: held stock becomes available again and
+// committed stock goes back on hand.
+func (r *inventoryRepository) Release(ctx context.Context, cartID string, attempt int, orderID, reason string) (*Reservation, error) {
+	tx, err := r.beginCartTx(ctx, cartID)
+	if err != nil {
+		return nil, err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	res, err := r.lockReservationTx(ctx, tx, cartID)
+	if err != nil {
+		return nil, err
+	}
+	if staleAttempt(res, attempt) {
+		r.logger.Debug("Release of superseded checkout attempt", "cart_id", cartID, "order_id", orderID, "attempt", attempt)
+		return res, nil
+	}
+	superseded, err := r.supersedeTx(ctx, tx, res, attempt)
+	if err != nil {
+		return nil, err
+	}
+	if superseded {
+		res = nil
+	}
+
+	var orderRef *string
+	if orderID != "" {
+		orderRef = &orderID
+	}
+
+	var evt *events.InventoryEvent
+	switch {
+	case res == nil:
+		// Cancelled before the checkout was reserved; Reserve skips it.
+		if res, err = r.saveReservationTx(ctx, tx, cartID, attempt, orderRef, ReservationReleased, &reason, nil); err != nil {
+			return nil, err
+		}
+	case res.Status == ReservationHeld || res.Status == ReservationCommitted:
+		lines := res.Lines
+		if err := r.returnStockTx(ctx, tx, cartID, lines, res.Status == ReservationCommitted); err != nil {
+			return nil, err
+		}
+		if res, err = r.saveReservationTx(ctx, tx, cartID, attempt, orderRef, ReservationReleased, &reason, nil); err != nil {
+			return nil, err
+		}
+		res.Lines = lines
+		if len(lines) > 0 {
+			evt = events.NewInventoryReleasedEvent(cartID, orderID, reason, inventoryLines(lines))
+		}
+	default:
+		r.logger.Debug("Reservation not releasable", "cart_id", cartID, "status", res.Status)
+		return res, nil
+	}
+
+	if evt != nil {
+		evt.Data.Attempt = res.Attempt
+		if err := r.outboxWriter.WriteEvent(ctx, tx, evt); err != nil {
+			return nil, fmt.Errorf("%w: failed to write %s event: %w", ErrEventWriteFailed, evt.EventType, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%w: failed to commit transaction: %w", ErrTransactionFailed, err)
+	}
+	committed = true
+	return res, nil
+}
+
+// Restock puts the returned lines of an order back on hand and returns
+// them with the quantity now available. A return is restocked once: a
+// return seen before restocks nothing and returns no lines.
+func (r *inventoryRepository) Restock(ctx context.Context, returnID, cartID, orderID string, lines []ReservationLine) ([]ReservationLine, error) {
+	merged, err := mergeReservationLines(lines)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to begin transaction: %w", ErrTransactionFailed, err)
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO products.Restock (return_id, order_id)
+		VALUES ($1, $2)
+		ON CONFLICT (return_id) DO NOTHING
+	`, returnID, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to record restock: %w", ErrDatabaseOperation, err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get rows affected: %w", ErrDatabaseOperation, err)
+	}
+	if rows == 0 {
+		r.logger.Debug("Return already restocked", "return_id", returnID)
+		return nil, nil
+	}
+
+	if err := r.returnStockTx(ctx, tx, cartID, merged, true); err != nil {
+		return nil, err
+	}
+	evt := events.NewInventoryRestockedEvent(cartID, orderID, returnID, inventoryLines(merged))
+	if err := r.outboxWriter.WriteEvent(ctx, tx, evt); err != nil {
+		return nil, fmt.Errorf("%w: failed to write %s event: %w", ErrEventWriteFailed, evt.EventType, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%w: failed to commit transaction: %w", ErrTransactionFailed, err)
+	}
+	committed = true
+	return merged, nil
+}
+
+// ExpireHeld releases up to limit held reservations whose expiry is at or
+// before now. Reservations locked by another transaction are skipped.
+func (r *inventoryRepository) ExpireHeld(ctx context.Context, now time.Time, limit int) ([]*Reservation, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to begin transaction: %w", ErrTransactionFailed, err)
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var cartIDs []string
+	query := `
+		SELECT cart_id
+		FROM products.Reservation
+		WHERE status = 'held' AND expires_at <= $1
+		ORDER BY expires_at
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	`
+	if err := tx.SelectContext(ctx, &cartIDs, query, now, limit); err != nil {
+		return nil, fmt.Errorf("%w: failed to select expired reservations: %w", ErrDatabaseOperation, err)
+	}
+
+	reason := "expired"
+	expired := make([]*Reservation, 0, len(cartIDs))
+	for _, cartID := range cartIDs {
+		lines, err := r.reservationLinesTx(ctx, tx, cartID)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.returnStockTx(ctx, tx, cartID, lines, false); err != nil {
+			return nil, err
+		}
+		res, err := r.saveReservationTx(ctx, tx, cartID, 0, nil, ReservationExpired, &reason, nil)
+		if err != nil {
+			return nil, err
+		}
+		res.Lines = lines
+
+		evt := events.NewInventoryReleasedEvent(cartID, "", reason, inventoryLines(lines))
+		evt.Data.Attempt = res.Attempt
+		if err := r.outboxWriter.WriteEvent(ctx, tx, evt); err != nil {
+			return nil, fmt.Errorf("%w: failed to write %s event: %w", ErrEventWriteFailed, evt.EventType, err)
+		}
+		expired = append(expired, res)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%w: failed to commit transaction: %w", ErrTransactionFailed, err)
+	}
+	committed = true
+	return expired, nil
+}
+
+// beginCartTx starts a transaction holding the cart's advisory lock, so
+// reservation operations on one cart run one at a time.
+func (r *inventoryRepository) beginCartTx(ctx context.Context, cartID string) (database.Tx, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to begin transaction: %w", ErrTransactionFailed, err)
+	}
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, cartID); err != nil {
+		_ = tx.Rollback()
+		return nil, fmt.Errorf("%w: failed to lock reservation: %w", ErrDatabaseOperation, err)
+	}
+	return tx, nil
+}
+
+// lockReservationTx loads a cart's reservation and lines, or nil if the
+// cart has none.
+func (r *inventoryRepository) lockReservationTx(ctx context.Context, tx database.Tx, cartID string) (*Reservation, error) {
+	var res Reservation
+	query := `
+		SELECT cart_id, attempt, order_id, status, reason, expires_at, created_at, updated_at
+		FROM products.Reservation
+		WHERE cart_id = $1
+		FOR UPDATE
+	`
+	err := tx.GetContext(ctx, &res, query, cartID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to lock reservation: %w", ErrDatabaseOperation, err)
+	}
+
+	if res.Lines, err = r.reservationLinesTx(ctx, tx, cartID); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (r *inventoryRepository) reservationLinesTx(ctx context.Context, tx database.Tx, cartID string) ([]ReservationLine, error) {
+	var lines []ReservationLine
+	query := `
+		SELECT product_id, quantity
+		FROM products.Reservation_line
+		WHERE cart_id = $1
+		ORDER BY product_id
+	`
+	if err := tx.SelectContext(ctx, &lines, query, cartID); err != nil {
+		return nil, fmt.Errorf("%w: failed to get reservation lines: %w", ErrDatabaseOperation, err)
+	}
+	return lines, nil
+}
+
+// saveReservationTx inserts or updates a cart's reservation header. An
+// attempt of 0 keeps the attempt the reservation was made for.
+func (r *inventoryRepository) saveReservationTx(ctx context.Context, tx database.Tx, cartID string, attempt int, orderID *string, status string, reason *string, expiresAt *time.Time) (*Reservation, error) {
+	var res Reservation
+	query := `
+		INSERT INTO products.Reservation (cart_id, attempt, order_id, status, reason, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (cart_id) DO UPDATE
+		SET attempt = GREATEST(EXCLUDED.attempt, products.Reservation.attempt),
+		    order_id = COALESCE(EXCLUDED.order_id, products.Reservation.order_id),
+		    status = EXCLUDED.status,
+		    reason = EXCLUDED.reason,
+		    expires_at = EXCLUDED.expires_at
+		RETURNING cart_id, attempt, order_id, status, reason, expires_at, created_at, updated_at
+	`
+	if err := tx.GetContext(ctx, &res, query, cartID, attempt, orderID, status, reason, expiresAt); err != nil {
+		return nil, fmt.Errorf("%w: failed to save reservation: %w", ErrDatabaseOperation, err)
+	}
+	return &res, nil
+}
+
+// lockStockTx locks the inventory rows of lines and returns the lines that
+// cannot be covered, each with the quantity available. Lines must be
+// sorted by product ID.
+func (r *inventoryRepository) lockStockTx(ctx context.Context, tx database.Tx, lines []ReservationLine) ([]ReservationLine, error) {
+	var shortages []ReservationLine
+	query := `
+		SELECT product_id, on_hand, reserved, updated_at
+		FROM products.Inventory
+		WHERE product_id = $1
+		FOR UPDATE
+	`
+	for _, line := range lines {
+		var level StockLevel
+		err := tx.GetContext(ctx, &level, query, line.ProductID)
+		if errors.Is(err, sql.ErrNoRows) {
+			level = StockLevel{ProductID: line.ProductID}
+		} else if err != nil {
+			return nil, fmt.Errorf("%w: failed to lock stock level: %w", ErrDatabaseOperation, err)
+		}
+		if level.Available() < line.Quantity {
+			shortages = append(shortages, ReservationLine{
+				ProductID: line.ProductID,
+				Quantity:  line.Quantity,
+				Available: max(level.Available(), 0),
+			})
+		}
+	}
+	return shortages, nil
+}
+
+// takeStockTx removes lines from the available stock: reserved when
+// holding, off hand when taken for an order. Each line's Available is set
+// to what is left.
+func (r *inventoryRepository) takeStockTx(ctx context.Context, tx database.Tx, cartID string, lines []ReservationLine, sold bool) error {
+	update := `
+		UPDATE products.Inventory
+		SET reserved = reserved + $2
+		WHERE product_id = $1
+		RETURNING on_hand - reserved
+	`
+	if sold {
+		update = `
+			UPDATE products.Inventory
+			SET on_hand = on_hand - $2
+			WHERE product_id = $1
+			RETURNING on_hand - reserved
+		`
+	}
+	for i := range lines {
+		if err := tx.GetContext(ctx, &lines[i].Available, update, lines[i].ProductID, lines[i].Quantity); err != nil {
+			return fmt.Errorf("%w: failed to take stock for cart %s: %w", ErrDatabaseOperation, cartID, err)
+		}
+	}
+	return nil
+}
+
+func (r *inventoryRepository) insertLinesTx(ctx context.Context, tx database.Tx, cartID string, lines []ReservationLine) error {
+	query := `
+		INSERT INTO products.Reservation_line (cart_id, product_id, quantity)
+		VALUES ($1, $2, $3)
+	`
+	for _, line := range lines {
+		if _, err := tx.ExecContext(ctx, query, cartID, line.ProductID, line.Quantity); err != nil {
+			return fmt.Errorf("%w: failed to insert reservation line: %w", ErrDatabaseOperation, err)
+		}
+	}
+	return nil
+}
+
+// commitHeldTx moves held lines from reserved to sold.
+func (r *inventoryRepository) commitHeldTx(ctx context.Context, tx database.Tx, cartID string, lines []ReservationLine) error {
+	query := `
+		UPDATE products.Inventory
+		SET on_hand = on_hand - $2, reserved = reserved - $2
+		WHERE product_id = $1
+		RETURNING on_hand - reserved
+	`
+	for i := range lines {
+		if err := tx.GetContext(ctx, &lines[i].Available, query, lines[i].ProductID, lines[i].Quantity); err != nil {
+			return fmt.Errorf("%w: failed to commit stock for cart %s: %w", ErrDatabaseOperation, cartID, err)
+		}
+	}
+	return nil
+}
+
+// returnStockTx gives lines back: unreserved when held, back on hand when
+// they had been sold.
+func (r *inventoryRepository) returnStockTx(ctx context.Context, tx database.Tx, cartID string, lines []ReservationLine, sold bool) error {
+	query := `
+		UPDATE products.Inventory
+		SET reserved = reserved - $2
+		WHERE product_id = $1
+		RETURNING on_hand - reserved
+	`
+	if sold {
+		query = `
+			UPDATE products.Inventory
+			SET on_hand = on_hand + $2
+			WHERE product_id = $1
+			RETURNING on_hand - reserved
+		`
+	}
+	for i := range lines {
+		if err := tx.GetContext(ctx, &lines[i].Available, query, lines[i].ProductID, lines[i].Quantity); err != nil {
+			return fmt.Errorf("%w: failed to return stock for cart %s: %w", ErrDatabaseOperation, cartID, err)
+		}
+	}
+	return nil
+}
+
+// supersedeTx clears the reservation res of an earlier checkout of a cart
+// when attempt is a later one, and reports whether it did. The earlier
+// checkout was rolled back before the cart was checked out again, so the
+// stock it still holds, for the checkout or for its order, is returned.
+func (r *inventoryRepository) supersedeTx(ctx context.Context, tx database.Tx, res *Reservation, attempt int) (bool, error) {
+	if res == nil || attempt <= res.Attempt {
+		return false, nil
+	}
+
+	if (res.Status == ReservationHeld || res.Status == ReservationCommitted) && len(res.Lines) > 0 {
+		lines := res.Lines
+		if err := r.returnStockTx(ctx, tx, res.CartID, lines, res.Status == ReservationCommitted); err != nil {
+			return false, err
+		}
+		orderID := ""
+		if res.OrderID != nil {
+			orderID = *res.OrderID
+		}
+		evt := events.NewInventoryReleasedEvent(res.CartID, orderID, "checkout_superseded", inventoryLines(lines))
+		evt.Data.Attempt = res.Attempt
+		if err := r.outboxWriter.WriteEvent(ctx, tx, evt); err != nil {
+			return false, fmt.Errorf("%w: failed to write %s event: %w", ErrEventWriteFailed, evt.EventType, err)
+		}
+	}
+
+	// Lines are deleted with the reservation
+	if _, err := tx.ExecContext(ctx, `DELETE FROM products.Reservation WHERE cart_id = $1`, res.CartID); err != nil {
+		return false, fmt.Errorf("%w: failed to clear superseded reservation: %w", ErrDatabaseOperation, err)
+	}
+	r.logger.Debug("Reservation superseded", "cart_id", res.CartID, "attempt", res.Attempt, "next_attempt", attempt, "status", res.Status)
+	return true, nil
+}
+
+// staleAttempt reports whether attempt is an earlier checkout of the cart
+// than the one res was made for. Operations of attempt 0, published before
+// checkouts were numbered, are never stale.
+func staleAttempt(res *Reservation, attempt int) bool {
+	return res != nil && attempt != 0 && attempt < res.Attempt
+}
+
+func orderIDOf(res *Reservation) *string {
+	if res == nil {
+		return nil
+	}
+	return res.OrderID
+}
+
+func inventoryLines(lines []ReservationLine) []events.InventoryLine {
+	out := make([]events.InventoryLine, 0, len(lines))
+	for _, line := range lines {
+		out = append(out, events.InventoryLine{
+			ProductID: strconv.FormatInt(line.ProductID, 10),
+			Quantity:  line.Quantity,
+			Available: line.Available,
+		})
+	}
+	return out
+}
@@ -0,0 +1,631 @@
+package product
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	events "go-shopping-poc/internal/contracts/events"
+	"go-shopping-poc/internal/platform/database"
+	"go-shopping-poc/internal/platform/outbox"
+)
+
+// inventoryState is what the inventory tables hold.
+type inventoryState struct {
+	stock        map[int64]StockLevel
+	reservations map[string]Reservation
+	lines        map[string][]ReservationLine
+	outbox       []events.InventoryEvent
+}
+
+func (s inventoryState) clone() inventoryState {
+	c := inventoryState{
+		stock:        maps.Clone(s.stock),
+		reservations: maps.Clone(s.reservations),
+		lines:        make(map[string][]ReservationLine, len(s.lines)),
+		outbox:       slices.Clone(s.outbox),
+	}
+	for cartID, lines := range s.lines {
+		c.lines[cartID] = slices.Clone(lines)
+	}
+	return c
+}
+
+// fakeInventoryDB runs the inventory repository's statements against
+// inventoryState. A transaction works on a copy that replaces the state on
+// commit.
+type fakeInventoryDB struct {
+	database.Database
+	state inventoryState
+}
+
+func newFakeInventoryDB(stock map[int64]int) *fakeInventoryDB {
+	db := &fakeInventoryDB{state: inventoryState{
+		stock:        make(map[int64]StockLevel),
+		reservations: make(map[string]Reservation),
+		lines:        make(map[string][]ReservationLine),
+	}}
+	for productID, onHand := range stock {
+		db.state.stock[productID] = StockLevel{ProductID: productID, OnHand: onHand}
+	}
+	return db
+}
+
+func (db *fakeInventoryDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (database.Tx, error) {
+	return &fakeInventoryTx{db: db, state: db.state.clone()}, nil
+}
+
+// eventTypes returns the types of the events written to the outbox.
+func (db *fakeInventoryDB) eventTypes() []events.InventoryEventType {
+	types := make([]events.InventoryEventType, 0, len(db.state.outbox))
+	for _, evt := range db.state.outbox {
+		types = append(types, evt.EventType)
+	}
+	return types
+}
+
+type fakeInventoryTx struct {
+	database.Tx
+	db    *fakeInventoryDB
+	state inventoryState
+}
+
+func (tx *fakeInventoryTx) Commit() error {
+	tx.db.state = tx.state
+	return nil
+}
+
+func (tx *fakeInventoryTx) Rollback() error { return nil }
+
+func (tx *fakeInventoryTx) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if !strings.Contains(query, "INSERT INTO outbox.outbox") {
+		return nil, fmt.Errorf("unexpected exec: %s", query)
+	}
+	var evt events.InventoryEvent
+	if err := json.Unmarshal(args[2].([]byte), &evt); err != nil {
+		return nil, err
+	}
+	tx.state.outbox = append(tx.state.outbox, evt)
+	return nil, nil
+}
+
+func (tx *fakeInventoryTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	switch {
+	case strings.Contains(query, "pg_advisory_xact_lock"):
+	case strings.Contains(query, "INSERT INTO products.Reservation_line"):
+		cartID := args[0].(string)
+		tx.state.lines[cartID] = append(tx.state.lines[cartID], ReservationLine{ProductID: args[1].(int64), Quantity: args[2].(int)})
+	case strings.Contains(query, "DELETE FROM products.Reservation WHERE"):
+		cartID := args[0].(string)
+		delete(tx.state.reservations, cartID)
+		delete(tx.state.lines, cartID)
+	default:
+		return nil, fmt.Errorf("unexpected exec: %s", query)
+	}
+	return nil, nil
+}
+
+func (tx *fakeInventoryTx) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	switch {
+	case strings.Contains(query, "FROM products.Reservation\n") && strings.Contains(query, "FOR UPDATE"):
+		res, ok := tx.state.reservations[args[0].(string)]
+		if !ok {
+			return sql.ErrNoRows
+		}
+		*dest.(*Reservation) = res
+	case strings.Contains(query, "INSERT INTO products.Reservation "):
+		cartID := args[0].(string)
+		res, ok := tx.state.reservations[cartID]
+		if !ok {
+			res = Reservation{CartID: cartID, CreatedAt: time.Now()}
+		}
+		res.Attempt = max(res.Attempt, args[1].(int))
+		if orderID := args[2].(*string); orderID != nil {
+			res.OrderID = orderID
+		}
+		res.Status = args[3].(string)
+		res.Reason = args[4].(*string)
+		res.ExpiresAt = args[5].(*time.Time)
+		res.UpdatedAt = time.Now()
+		tx.state.reservations[cartID] = res
+		*dest.(*Reservation) = res
+	case strings.Contains(query, "FROM products.Inventory") && strings.Contains(query, "FOR UPDATE"):
+		level, ok := tx.state.stock[args[0].(int64)]
+		if !ok {
+			return sql.ErrNoRows
+		}
+		*dest.(*StockLevel) = level
+	case strings.Contains(query, "UPDATE products.Inventory"):
+		productID, quantity := args[0].(int64), args[1].(int)
+		level := tx.state.stock[productID]
+		switch {
+		case strings.Contains(query, "SET on_hand = on_hand - $2, reserved = reserved - $2"):
+			level.OnHand -= quantity
+			level.Reserved -= quantity
+		case strings.Contains(query, "SET reserved = reserved + $2"):
+			level.Reserved += quantity
+		case strings.Contains(query, "SET reserved = reserved - $2"):
+			level.Reserved -= quantity
+		case strings.Contains(query, "SET on_hand = on_hand - $2"):
+			level.OnHand -= quantity
+		case strings.Contains(query, "SET on_hand = on_hand + $2"):
+			level.OnHand += quantity
+		default:
+			return fmt.Errorf("unexpected inventory update: %s", query)
+		}
+		tx.state.stock[productID] = level
+		*dest.(*int) = level.Available()
+	default:
+		return fmt.Errorf("unexpected get: %s", query)
+	}
+	return nil
+}
+
+func (tx *fakeInventoryTx) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	switch {
+	case strings.Contains(query, "FROM products.Reservation_line"):
+		*dest.(*[]ReservationLine) = slices.Clone(tx.state.lines[args[0].(string)])
+	case strings.Contains(query, "WHERE status = 'held' AND expires_at <= $1"):
+		now, limit := args[0].(time.Time), args[1].(int)
+		var held []Reservation
+		for _, res := range tx.state.reservations {
+			if res.Status == ReservationHeld && !res.ExpiresAt.After(now) {
+				held = append(held, res)
+			}
+		}
+		slices.SortFunc(held, func(a, b Reservation) int { return a.ExpiresAt.Compare(*b.ExpiresAt) })
+		cartIDs := make([]string, 0, limit)
+		for _, res := range held[:min(limit, len(held))] {
+			cartIDs = append(cartIDs, res.CartID)
+		}
+		*dest.(*[]string) = cartIDs
+	default:
+		return fmt.Errorf("unexpected select: %s", query)
+	}
+	return nil
+}
+
+func newTestInventoryRepository(stock map[int64]int) (*inventoryRepository, *fakeInventoryDB) {
+	db := newFakeInventoryDB(stock)
+	repo := NewInventoryRepository(db, outbox.NewWriter(db), nil).(*inventoryRepository)
+	return repo, db
+}
+
+func TestReservationLifecycle(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Now()
+	hold := now.Add(15 * time.Minute)
+	lines := []ReservationLine{{ProductID: 1, Quantity: 3}}
+
+	reserve := func(repo *inventoryRepository) (*Reservation, error) {
+		return repo.Reserve(ctx, "cart-1", 1, lines, hold)
+	}
+	commit := func(repo *inventoryRepository) (*Reservation, error) {
+		return repo.Commit(ctx, "cart-1", 1, "order-1")
+	}
+	release := func(repo *inventoryRepository) (*Reservation, error) {
+		return repo.Release(ctx, "cart-1", 1, "order-1", "order_cancelled")
+	}
+	expire := func(repo *inventoryRepository) (*Reservation, error) {
+		expired, err := repo.ExpireHeld(ctx, hold, expireBatchSize)
+		if err != nil || len(expired) == 0 {
+			return nil, err
+		}
+		return expired[0], nil
+	}
+	// sellOut reserves what another cart leaves of product 1.
+	sellOut := func(repo *inventoryRepository) (*Reservation, error) {
+		_, err := repo.Reserve(ctx, "cart-2", 1, []ReservationLine{{ProductID: 1, Quantity: 9}}, hold)
+		return nil, err
+	}
+
+	type step func(repo *inventoryRepository) (*Reservation, error)
+	tests := []struct {
+		name         string
+		onHand       int
+		steps        []step
+		wantStatus   string
+		wantReason   string
+		wantOnHand   int
+		wantReserved int
+		wantEvents   []events.InventoryEventType
+	}{
+		{
+			name:         "held",
+			onHand:       10,
+			steps:        []step{reserve},
+			wantStatus:   ReservationHeld,
+			wantOnHand:   10,
+			wantReserved: 3,
+			wantEvents:   []events.InventoryEventType{events.InventoryReserved},
+		},
+		{
+			name:       "held then committed",
+			onHand:     10,
+			steps:      []step{reserve, commit},
+			wantStatus: ReservationCommitted,
+			wantOnHand: 7,
+			wantEvents: []events.InventoryEventType{events.InventoryReserved, events.InventoryCommitted},
+		},
+		{
+			name:       "held then released",
+			onHand:     10,
+			steps:      []step{reserve, release},
+			wantStatus: ReservationReleased,
+			wantReason: "order_cancelled",
+			wantOnHand: 10,
+			wantEvents: []events.InventoryEventType{events.InventoryReserved, events.InventoryReleased},
+		},
+		{
+			name:       "committed then released",
+			onHand:     10,
+			steps:      []step{reserve, commit, release},
+			wantStatus: ReservationReleased,
+			wantReason: "order_cancelled",
+			wantOnHand: 10,
+			wantEvents: []events.InventoryEventType{events.InventoryReserved, events.InventoryCommitted, events.InventoryReleased},
+		},
+		{
+			name:       "held then expired",
+			onHand:     10,
+			steps:      []step{reserve, expire},
+			wantStatus: ReservationExpired,
+			wantReason: "expired",
+			wantOnHand: 10,
+			wantEvents: []events.InventoryEventType{events.InventoryReserved, events.InventoryReleased},
+		},
+		{
+			name:       "short stock fails",
+			onHand:     2,
+			steps:      []step{reserve},
+			wantStatus: ReservationFailed,
+			wantReason: "insufficient_stock",
+			wantOnHand: 2,
+			wantEvents: []events.InventoryEventType{events.InventoryReservationFailed},
+		},
+		{
+			name:       "failed is not committed",
+			onHand:     2,
+			steps:      []step{reserve, commit},
+			wantStatus: ReservationFailed,
+			wantReason: "insufficient_stock",
+			wantOnHand: 2,
+			wantEvents: []events.InventoryEventType{events.InventoryReservationFailed},
+		},
+		{
+			name:       "expired is re-acquired on commit",
+			onHand:     10,
+			steps:      []step{reserve, expire, commit},
+			wantStatus: ReservationCommitted,
+			wantOnHand: 7,
+			wantEvents: []events.InventoryEventType{events.InventoryReserved, events.InventoryReleased, events.InventoryCommitted},
+		},
+		{
+			name:         "expired fails on commit once sold",
+			onHand:       10,
+			steps:        []step{reserve, expire, sellOut, commit},
+			wantStatus:   ReservationFailed,
+			wantReason:   "reservation_expired",
+			wantOnHand:   10,
+			wantReserved: 9,
+			wantEvents: []events.InventoryEventType{
+				events.InventoryReserved, events.InventoryReleased, events.InventoryReserved, events.InventoryReservationFailed,
+			},
+		},
+		{
+			name:       "redelivered checkout holds once",
+			onHand:     10,
+			steps:      []step{reserve, reserve, commit, reserve},
+			wantStatus: ReservationCommitted,
+			wantOnHand: 7,
+			wantEvents: []events.InventoryEventType{events.InventoryReserved, events.InventoryCommitted},
+		},
+		{
+			name:       "order first takes stock on checkout",
+			onHand:     10,
+			steps:      []step{commit, reserve},
+			wantStatus: ReservationCommitted,
+			wantOnHand: 7,
+			wantEvents: []events.InventoryEventType{events.InventoryCommitted},
+		},
+		{
+			name:       "cancelled first takes nothing on checkout",
+			onHand:     10,
+			steps:      []step{release, reserve},
+			wantStatus: ReservationReleased,
+			wantReason: "order_cancelled",
+			wantOnHand: 10,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo, db := newTestInventoryRepository(map[int64]int{1: tt.onHand})
+			for i, step := range tt.steps {
+				if _, err := step(repo); err != nil {
+					t.Fatalf("step %d: error = %v", i, err)
+				}
+			}
+
+			res := db.state.reservations["cart-1"]
+			if res.Status != tt.wantStatus {
+				t.Errorf("Status = %q, want %q", res.Status, tt.wantStatus)
+			}
+			if reason := derefString(res.Reason); reason != tt.wantReason {
+				t.Errorf("Reason = %q, want %q", reason, tt.wantReason)
+			}
+			level := db.state.stock[1]
+			if level.OnHand != tt.wantOnHand || level.Reserved != tt.wantReserved {
+				t.Errorf("stock = %d on hand, %d reserved; want %d, %d", level.OnHand, level.Reserved, tt.wantOnHand, tt.wantReserved)
+			}
+			if got := db.eventTypes(); !slices.Equal(got, tt.wantEvents) {
+				t.Errorf("events = %v, want %v", got, tt.wantEvents)
+			}
+		})
+	}
+}
+
+func TestCheckoutAttempts(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	hold := time.Now().Add(15 * time.Minute)
+	lines := []ReservationLine{{ProductID: 1, Quantity: 3}}
+
+	type step func(repo *inventoryRepository) (*Reservation, error)
+	reserve := func(attempt int) step {
+		return func(repo *inventoryRepository) (*Reservation, error) {
+			return repo.Reserve(ctx, "cart-1", attempt, lines, hold)
+		}
+	}
+	commit := func(attempt int) step {
+		return func(repo *inventoryRepository) (*Reservation, error) {
+			return repo.Commit(ctx, "cart-1", attempt, fmt.Sprintf("order-%d", attempt))
+		}
+	}
+	checkoutFailed := func(attempt int) step {
+		return func(repo *inventoryRepository) (*Reservation, error) {
+			return repo.Release(ctx, "cart-1", attempt, "", "checkout_failed")
+		}
+	}
+	orderCancelled := func(attempt int) step {
+		return func(repo *inventoryRepository) (*Reservation, error) {
+			return repo.Release(ctx, "cart-1", attempt, fmt.Sprintf("order-%d", attempt), "order_cancelled")
+		}
+	}
+
+	tests := []struct {
+		name         string
+		steps        []step
+		wantAttempt  int
+		wantStatus   string
+		wantOrderID  string
+		wantOnHand   int
+		wantReserved int
+		wantEvents   []string
+	}{
+		{
+			name:         "re-checkout after rollback holds again",
+			steps:        []step{reserve(1), checkoutFailed(1), reserve(2)},
+			wantAttempt:  2,
+			wantStatus:   ReservationHeld,
+			wantOnHand:   10,
+			wantReserved: 3,
+			wantEvents:   []string{"inventory.reserved/1", "inventory.released/1", "inventory.reserved/2"},
+		},
+		{
+			name:        "re-checkout completes",
+			steps:       []step{reserve(1), checkoutFailed(1), reserve(2), commit(2)},
+			wantAttempt: 2,
+			wantStatus:  ReservationCommitted,
+			wantOrderID: "order-2",
+			wantOnHand:  7,
+			wantEvents:  []string{"inventory.reserved/1", "inventory.released/1", "inventory.reserved/2", "inventory.committed/2"},
+		},
+		{
+			name:         "late checkout failure of earlier attempt keeps stock",
+			steps:        []step{reserve(1), checkoutFailed(1), reserve(2), checkoutFailed(1)},
+			wantAttempt:  2,
+			wantStatus:   ReservationHeld,
+			wantOnHand:   10,
+			wantReserved: 3,
+			wantEvents:   []string{"inventory.reserved/1", "inventory.released/1", "inventory.reserved/2"},
+		},
+		{
+			name:         "late order of earlier attempt is ignored",
+			steps:        []step{reserve(1), checkoutFailed(1), reserve(2), commit(1), orderCancelled(1)},
+			wantAttempt:  2,
+			wantStatus:   ReservationHeld,
+			wantOnHand:   10,
+			wantReserved: 3,
+			wantEvents:   []string{"inventory.reserved/1", "inventory.released/1", "inventory.reserved/2"},
+		},
+		{
+			name:         "later attempt returns stock still held",
+			steps:        []step{reserve(1), reserve(2)},
+			wantAttempt:  2,
+			wantStatus:   ReservationHeld,
+			wantOnHand:   10,
+			wantReserved: 3,
+			wantEvents:   []string{"inventory.reserved/1", "inventory.released/1", "inventory.reserved/2"},
+		},
+		{
+			name:        "later attempt returns stock of earlier order",
+			steps:       []step{reserve(1), commit(1), reserve(2), orderCancelled(1)},
+			wantAttempt: 2,
+			wantStatus:  ReservationHeld,
+			// order-1's 3 went back on hand; attempt 2 holds 3
+			wantOnHand:   10,
+			wantReserved: 3,
+			wantEvents:   []string{"inventory.reserved/1", "inventory.committed/1", "inventory.released/1", "inventory.reserved/2"},
+		},
+		{
+			name:        "order of later attempt first",
+			steps:       []step{reserve(1), checkoutFailed(1), commit(2), reserve(2)},
+			wantAttempt: 2,
+			wantStatus:  ReservationCommitted,
+			wantOrderID: "order-2",
+			wantOnHand:  7,
+			wantEvents:  []string{"inventory.reserved/1", "inventory.released/1", "inventory.committed/2"},
+		},
+		{
+			name:        "later attempt cancelled first takes nothing",
+			steps:       []step{reserve(1), checkoutFailed(1), orderCancelled(2), reserve(2)},
+			wantAttempt: 2,
+			wantStatus:  ReservationReleased,
+			wantOrderID: "order-2",
+			wantOnHand:  10,
+			wantEvents:  []string{"inventory.reserved/1", "inventory.released/1"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo, db := newTestInventoryRepository(map[int64]int{1: 10})
+			for i, step := range tt.steps {
+				if _, err := step(repo); err != nil {
+					t.Fatalf("step %d: error = %v", i, err)
+				}
+			}
+
+			res := db.state.reservations["cart-1"]
+			if res.Attempt != tt.wantAttempt || res.Status != tt.wantStatus || derefString(res.OrderID) != tt.wantOrderID {
+				t.Errorf("reservation = attempt %d %s for %q, want attempt %d %s for %q",
+					res.Attempt, res.Status, derefString(res.OrderID), tt.wantAttempt, tt.wantStatus, tt.wantOrderID)
+			}
+			level := db.state.stock[1]
+			if level.OnHand != tt.wantOnHand || level.Reserved != tt.wantReserved {
+				t.Errorf("stock = %d on hand, %d reserved; want %d, %d", level.OnHand, level.Reserved, tt.wantOnHand, tt.wantReserved)
+			}
+			var got []string
+			for _, evt := range db.state.outbox {
+				got = append(got, fmt.Sprintf("%s/%d", evt.EventType, evt.Data.Attempt))
+			}
+			if !slices.Equal(got, tt.wantEvents) {
+				t.Errorf("events = %v, want %v", got, tt.wantEvents)
+			}
+		})
+	}
+}
+
+func TestOrderFirstPlaceholder(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	repo, db := newTestInventoryRepository(map[int64]int{1: 10, 2: 5})
+
+	res, err := repo.Commit(ctx, "cart-1", 1, "order-1")
+	if err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	if res.Status != ReservationCommitted || len(res.Lines) != 0 || len(db.state.outbox) != 0 {
+		t.Fatalf("placeholder = %+v with %d events, want committed without lines or events", res, len(db.state.outbox))
+	}
+
+	lines := []ReservationLine{{ProductID: 2, Quantity: 1}, {ProductID: 1, Quantity: 2}, {ProductID: 2, Quantity: 1}}
+	res, err = repo.Reserve(ctx, "cart-1", 1, lines, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+	if res.Status != ReservationCommitted || derefString(res.OrderID) != "order-1" {
+		t.Errorf("reservation = %+v, want committed to order-1", res)
+	}
+	if len(db.state.lines["cart-1"]) != 2 {
+		t.Errorf("lines = %+v, want one line per product", db.state.lines["cart-1"])
+	}
+	if got := db.state.stock[1]; got.OnHand != 8 || got.Reserved != 0 {
+		t.Errorf("product 1 stock = %+v, want 8 on hand", got)
+	}
+	if got := db.state.stock[2]; got.OnHand != 3 || got.Reserved != 0 {
+		t.Errorf("product 2 stock = %+v, want 3 on hand", got)
+	}
+
+	evt := db.state.outbox[0]
+	if evt.EventType != events.InventoryCommitted || evt.Data.OrderID != "order-1" {
+		t.Fatalf("event = %s for order %q, want committed for order-1", evt.EventType, evt.Data.OrderID)
+	}
+	for _, line := range evt.Data.Lines {
+		if line.ProductID == "1" && line.Available != 8 {
+			t.Errorf("product 1 available = %d, want 8", line.Available)
+		}
+	}
+}
+
+func TestReserveShortLinesCarryAvailable(t *testing.T) {
+	t.Parallel()
+
+	repo, db := newTestInventoryRepository(map[int64]int{1: 10, 2: 1})
+
+	lines := []ReservationLine{{ProductID: 1, Quantity: 3}, {ProductID: 2, Quantity: 2}, {ProductID: 3, Quantity: 1}}
+	res, err := repo.Reserve(context.Background(), "cart-1", 1, lines, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+	want := []ReservationLine{{ProductID: 2, Quantity: 2, Available: 1}, {ProductID: 3, Quantity: 1, Available: 0}}
+	if res.Status != ReservationFailed || !slices.Equal(res.Lines, want) {
+		t.Errorf("reservation = %s %+v, want failed %+v", res.Status, res.Lines, want)
+	}
+	if got := db.state.stock[1]; got.Reserved != 0 {
+		t.Errorf("product 1 reserved = %d, want nothing held when another line is short", got.Reserved)
+	}
+}
+
+func TestExpireHeldOldestFirstInBatches(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Now()
+	repo, db := newTestInventoryRepository(map[int64]int{1: 10})
+
+	holds := map[string]time.Time{
+		"cart-late":  now.Add(-time.Minute),
+		"cart-early": now.Add(-time.Hour),
+		"cart-live":  now.Add(time.Hour),
+	}
+	for cartID, expiresAt := range holds {
+		if _, err := repo.Reserve(ctx, cartID, 1, []ReservationLine{{ProductID: 1, Quantity: 1}}, expiresAt); err != nil {
+			t.Fatalf("Reserve(%s) error = %v", cartID, err)
+		}
+	}
+
+	var order []string
+	for range 3 {
+		expired, err := repo.ExpireHeld(ctx, now, 1)
+		if err != nil {
+			t.Fatalf("ExpireHeld() error = %v", err)
+		}
+		for _, res := range expired {
+			order = append(order, res.CartID)
+		}
+	}
+	if want := []string{"cart-early", "cart-late"}; !slices.Equal(order, want) {
+		t.Errorf("expired %v, want %v", order, want)
+	}
+	if got := db.state.reservations["cart-live"].Status; got != ReservationHeld {
+		t.Errorf("live reservation status = %q, want held", got)
+	}
+	if got := db.state.stock[1].Reserved; got != 1 {
+		t.Errorf("reserved = %d, want 1 for the live reservation", got)
+	}
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -37,7 +37,7 @@ func (r *productRepository) GetProductByID(ctx context.Context, productID int64)
 	query := `
 		SELECT id, name, description, initial_price, final_price, currency, in_stock,
 			   color, size, country_code, image_count, model_number,
-			   root_category, category, brand, other_attributes, all_available_sizes,
+			   root_category, category, brand, other_attributes, all_available_sizes, weight_lb,
 			   created_at, updated_at
 		FROM products.products WHERE id = $1`
 
@@ -47,7 +47,7 @@ func (r *productRepository) GetProductByID(ctx context.Context, productID int64)
 		&product.ID, &product.Name, &product.Description, &product.InitialPrice, &product.FinalPrice,
 		&product.Currency, &product.InStock, &product.Color, &product.Size,
 		&product.CountryCode, &product.ImageCount, &product.ModelNumber, &product.RootCategory,
-		&product.Category, &product.Brand, &product.OtherAttributes, &product.AllAvailableSizes,
+		&product.Category, &product.Brand, &product.OtherAttributes, &product.AllAvailableSizes, &product.WeightLb,
 		&product.CreatedAt, &product.UpdatedAt,
 	)
 	if err != nil {
@@ -84,7 +84,7 @@ func (r *productRepository) GetProductsByCategory(ctx context.Context, category
 	query := `
 		SELECT p.id, p.name, p.description, p.initial_price, p.final_price, p.currency, p.in_stock,
 			   p.color, p.size, p.country_code, p.image_count, p.model_number,
-			   p.root_category, p.category, p.brand, p.other_attributes, p.all_available_sizes,
+			   p.root_category, p.category, p.brand, p.other_attributes, p.all_available_sizes, p.weight_lb,
 			   p.created_at, p.updated_at,
 			   pi.minio_object_name
 		FROM products.products p
@@ -113,7 +113,7 @@ func (r *productRepository) GetProductsByCategory(ctx context.Context, category
 			&product.ID, &product.Name, &product.Description, &product.InitialPrice, &product.FinalPrice,
 			&product.Currency, &product.InStock, &product.Color, &product.Size,
 			&product.CountryCode, &product.ImageCount, &product.ModelNumber, &product.RootCategory,
-			&product.Category, &product.Brand, &product.OtherAttributes, &product.AllAvailableSizes,
+			&product.Category, &product.Brand, &product.OtherAttributes, &product.AllAvailableSizes, &product.WeightLb,
 			&product.CreatedAt, &product.UpdatedAt,
 			&product.MainImageURL,
 		)
@@ -147,7 +147,7 @@ func (r *productRepository) GetProductsByBrand(ctx context.Context, brand string
 	query := `
 		SELECT p.id, p.name, p.description, p.initial_price, p.final_price, p.currency, p.in_stock,
 			   p.color, p.size, p.country_code, p.image_count, p.model_number,
-			   p.root_category, p.category, p.brand, p.other_attributes, p.all_available_sizes,
+			   p.root_category, p.category, p.brand, p.other_attributes, p.all_available_sizes, p.weight_lb,
 			   p.created_at, p.updated_at,
 			   pi.minio_object_name
 		FROM products.products p
@@ -176,7 +176,7 @@ func (r *productRepository) GetProductsByBrand(ctx context.Context, brand string
 			&product.ID, &product.Name, &product.Description, &product.InitialPrice, &product.FinalPrice,
 			&product.Currency, &product.InStock, &product.Color, &product.Size,
 			&product.CountryCode, &product.ImageCount, &product.ModelNumber, &product.RootCategory,
-			&product.Category, &product.Brand, &product.OtherAttributes, &product.AllAvailableSizes,
+			&product.Category, &product.Brand, &product.OtherAttributes, &product.AllAvailableSizes, &product.WeightLb,
 			&product.CreatedAt, &product.UpdatedAt,
 			&product.MainImageURL,
 		)
@@ -210,7 +210,7 @@ func (r *productRepository) SearchProducts(ctx context.Context, query string, li
 	searchQuery := `
 		SELECT p.id, p.name, p.description, p.initial_price, p.final_price, p.currency, p.in_stock,
 			   p.color, p.size, p.country_code, p.image_count, p.model_number,
-			   p.root_category, p.category, p.brand, p.other_attributes, p.all_available_sizes,
+			   p.root_category, p.category, p.brand, p.other_attributes, p.all_available_sizes, p.weight_lb,
 			   p.created_at, p.updated_at,
 			   pi.minio_object_name
 		FROM products.products p
@@ -240,7 +240,7 @@ func (r *productRepository) SearchProducts(ctx context.Context, query string, li
 			&product.ID, &product.Name, &product.Description, &product.InitialPrice, &product.FinalPrice,
 			&product.Currency, &product.InStock, &product.Color, &product.Size,
 			&product.CountryCode, &product.ImageCount, &product.ModelNumber, &product.RootCategory,
-			&product.Category, &product.Brand, &product.OtherAttributes, &product.AllAvailableSizes,
+			&product.Category, &product.Brand, &product.OtherAttributes, &product.AllAvailableSizes, &product.WeightLb,
 			&product.CreatedAt, &product.UpdatedAt,
 			&product.MainImageURL,
 		)
@@ -271,7 +271,7 @@ func (r *productRepository) GetProductsInStock(ctx context.Context, limit, offse
 	query := `
 		SELECT p.id, p.name, p.description, p.initial_price, p.final_price, p.currency, p.in_stock,
 			   p.color, p.size, p.country_code, p.image_count, p.model_number,
-			   p.root_category, p.category, p.brand, p.other_attributes, p.all_available_sizes,
+			   p.root_category, p.category, p.brand, p.other_attributes, p.all_available_sizes, p.weight_lb,
 			   p.created_at, p.updated_at,
 			   pi.minio_object_name
 		FROM products.products p
@@ -300,7 +300,7 @@ func (r *productRepository) GetProductsInStock(ctx context.Context, limit, offse
 			&product.ID, &product.Name, &product.Description, &product.InitialPrice, &product.FinalPrice,
 			&product.Currency, &product.InStock, &product.Color, &product.Size,
 			&product.CountryCode, &product.ImageCount, &product.ModelNumber, &product.RootCategory,
-			&product.Category, &product.Brand, &product.OtherAttributes, &product.AllAvailableSizes,
+			&product.Category, &product.Brand, &product.OtherAttributes, &product.AllAvailableSizes, &product.WeightLb,
 			&product.CreatedAt, &product.UpdatedAt,
 			&product.MainImageURL,
 		)
@@ -331,7 +331,7 @@ func (r *productRepository) GetAllProducts(ctx context.Context, limit, offset in
 	query := `
 		SELECT p.id, p.name, p.description, p.initial_price, p.final_price, p.currency, p.in_stock,
 			   p.color, p.size, p.country_code, p.image_count, p.model_number,
-			   p.root_category, p.category, p.brand, p.other_attributes, p.all_available_sizes,
+			   p.root_category, p.category, p.brand, p.other_attributes, p.all_available_sizes, p.weight_lb,
 			   p.created_at, p.updated_at,
 			   pi.minio_object_name
 		FROM products.products p
@@ -359,7 +359,7 @@ func (r *productRepository) GetAllProducts(ctx context.Context, limit, offset in
 			&product.ID, &product.Name, &product.Description, &product.InitialPrice, &product.FinalPrice,
 			&product.Currency, &product.InStock, &product.Color, &product.Size,
 			&product.CountryCode, &product.ImageCount, &product.ModelNumber, &product.RootCategory,
-			&product.Category, &product.Brand, &product.OtherAttributes, &product.AllAvailableSizes,
+			&product.Category, &product.Brand, &product.OtherAttributes, &product.AllAvailableSizes, &product.WeightLb,
 			&product.CreatedAt, &product.UpdatedAt,
 			&product.MainImageURL,
 		)
@@ -16,6 +16,7 @@ import (
 	"go-shopping-poc/internal/platform/database"
 	"go-shopping-poc/internal/platform/downloader"
 	"go-shopping-poc/internal/platform/logging"
+	"go-shopping-poc/internal/platform/money"
 	"go-shopping-poc/internal/platform/outbox"
 	"go-shopping-poc/internal/platform/service"
 	"go-shopping-poc/internal/platform/storage/minio"
@@ -293,8 +294,8 @@ func (s *IngestionService) convertCSVRecordToProduct(record ProductCSVRecord) (*
 	product := &Product{
 		Name:         record.Name,
 		Description:  record.Description,
-		InitialPrice: record.InitialPrice,
-		FinalPrice:   record.FinalPrice,
+		InitialPrice: money.FromMajor(record.InitialPrice, record.Currency),
+		FinalPrice:   money.FromMajor(record.FinalPrice, record.Currency),
 		Currency:     record.Currency,
 		Color:        record.Color,
 		Size:         record.Size,
@@ -0,0 +1,219 @@
+package product
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	events "go-shopping-poc/internal/contracts/events"
+	"go-shopping-poc/internal/platform/logging"
+)
+
+// expireBatchSize bounds how many reservations one sweep releases per
+// transaction.
+const expireBatchSize = 100
+
+// InventoryService manages product stock levels and the reservations that
+// hold stock between checkout and order creation.
+//
+// Checkout reserves every line of the cart for the configured TTL; the
+// order.created event commits the reservation and order cancellation
+// releases it. Reservations that are not committed in time are released by
+// RunExpiry. Each outcome is published as an inventory event.
+type InventoryService struct {
+	logger         *slog.Logger
+	repo           InventoryRepository
+	infrastructure *CatalogInfrastructure
+	config         *Config
+}
+
+func NewInventoryService(logger *slog.Logger, infrastructure *CatalogInfrastructure, config *Config) *InventoryService {
+	if logger == nil {
+		logger = logging.FromContext(context.Background())
+	}
+	repo := NewInventoryRepository(infrastructure.Database, infrastructure.OutboxWriter, logger)
+
+	return &InventoryService{
+		logger:         logger.With("component", "inventory_service"),
+		repo:           repo,
+		infrastructure: infrastructure,
+		config:         config,
+	}
+}
+
+// GetStockLevel returns the stock level of a product.
+func (s *InventoryService) GetStockLevel(ctx context.Context, productID int64) (*StockLevel, error) {
+	level, err := s.repo.GetStockLevel(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stock level: %w", err)
+	}
+	return level, nil
+}
+
+// GetReservation returns the stock reservation of a checked-out cart.
+func (s *InventoryService) GetReservation(ctx context.Context, cartID string) (*Reservation, error) {
+	res, err := s.repo.GetReservation(ctx, cartID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reservation: %w", err)
+	}
+	return res, nil
+}
+
+// ReserveCheckout holds stock for the items of checkout attempt of a cart.
+// A shortage is not an error: the reservation is returned with status
+// failed and a reservation_failed event is published.
+func (s *InventoryService) ReserveCheckout(ctx context.Context, cartID string, attempt int, items []events.SnapshotItem) (*Reservation, error) {
+	lines := make([]ReservationLine, 0, len(items))
+	for _, item := range items {
+		productID, err := strconv.ParseInt(item.ProductID, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: product ID %q", ErrInvalidProductID, item.ProductID)
+		}
+		lines = append(lines, ReservationLine{ProductID: productID, Quantity: item.Quantity})
+	}
+
+	expiresAt := time.Now().Add(s.config.ReservationHoldTTL())
+	res, err := s.repo.Reserve(ctx, cartID, attempt, lines, expiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve stock: %w", err)
+	}
+	s.processOutbox()
+
+	s.logger.Info("Checkout reservation processed",
+		"cart_id", cartID,
+		"attempt", attempt,
+		"status", res.Status,
+		"lines", len(res.Lines),
+	)
+	return res, nil
+}
+
+// CommitOrder commits the reservation of the checkout attempt of a cart an
+// order was created for.
+func (s *InventoryService) CommitOrder(ctx context.Context, cartID string, attempt int, orderID string) (*Reservation, error) {
+	res, err := s.repo.Commit(ctx, cartID, attempt, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit reservation: %w", err)
+	}
+	s.processOutbox()
+
+	s.logger.Info("Order reservation processed", "cart_id", cartID, "order_id", orderID, "status", res.Status)
+	return res, nil
+}
+
+// ReleaseOrder returns the stock of a cancelled order, created for checkout
+// attempt of a cart.
+func (s *InventoryService) ReleaseOrder(ctx context.Context, cartID string, attempt int, orderID string) (*Reservation, error) {
+	res, err := s.repo.Release(ctx, cartID, attempt, orderID, "order_cancelled")
+	if err != nil {
+		return nil, fmt.Errorf("failed to release reservation: %w", err)
+	}
+	s.processOutbox()
+
+	s.logger.Info("Cancelled order reservation processed", "cart_id", cartID, "order_id", orderID, "status", res.Status)
+	return res, nil
+}
+
+// RestockReturn puts the returned items of an order that go back on sale
+// on hand. Items a return does not restock are skipped.
+func (s *InventoryService) RestockReturn(ctx context.Context, returnID, cartID, orderID string, returned []events.ReturnLine) ([]ReservationLine, error) {
+	var lines []ReservationLine
+	for _, line := range returned {
+		if !line.Restock {
+			continue
+		}
+		productID, err := strconv.ParseInt(line.ProductID, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: product ID %q", ErrInvalidProductID, line.ProductID)
+		}
+		lines = append(lines, ReservationLine{ProductID: productID, Quantity: line.Quantity})
+	}
+	if len(lines) == 0 {
+		s.logger.Debug("Return has nothing to restock", "return_id", returnID, "order_id", orderID)
+		return nil, nil
+	}
+
+	restocked, err := s.repo.Restock(ctx, returnID, cartID, orderID, lines)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restock return: %w", err)
+	}
+	s.processOutbox()
+
+	s.logger.Info("Return restocked", "return_id", returnID, "order_id", orderID, "lines", len(restocked))
+	return restocked, nil
+}
+
+// ReleaseCheckout returns the stock of checkout attempt of a cart that was
+// rolled back. orderID is empty when no order was created for the
+// checkout.
+func (s *InventoryService) ReleaseCheckout(ctx context.Context, cartID string, attempt int, orderID string) (*Reservation, error) {
+	res, err := s.repo.Release(ctx, cartID, attempt, orderID, "checkout_failed")
+	if err != nil {
+		return nil, fmt.Errorf("failed to release reservation: %w", err)
+	}
+	s.processOutbox()
+
+	s.logger.Info("Failed checkout reservation processed", "cart_id", cartID, "order_id", orderID, "status", res.Status)
+	return res, nil
+}
+
+// ExpireReservations releases every held reservation past its expiry and
+// returns how many were released.
+func (s *InventoryService) ExpireReservations(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		expired, err := s.repo.ExpireHeld(ctx, time.Now(), expireBatchSize)
+		if err != nil {
+			return total, fmt.Errorf("failed to expire reservations: %w", err)
+		}
+		total += len(expired)
+		for _, res := range expired {
+			s.logger.Info("Reservation expired", "cart_id", res.CartID, "lines", len(res.Lines))
+		}
+		if len(expired) < expireBatchSize {
+			break
+		}
+	}
+	if total > 0 {
+		s.processOutbox()
+	}
+	return total, nil
+}
+
+// RunExpiry releases expired reservations every sweep interval until ctx
+// is cancelled.
+func (s *InventoryService) RunExpiry(ctx context.Context) {
+	interval := s.config.ReservationSweepInterval()
+	s.logger.Info("Reservation expiry started",
+		"interval", interval.String(),
+		"ttl", s.config.ReservationHoldTTL().String(),
+	)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Reservation expiry stopped")
+			return
+		case <-ticker.C:
+			if _, err := s.ExpireReservations(ctx); err != nil {
+				s.logger.Error("Reservation expiry failed", "error", err.Error())
+			}
+		}
+	}
+}
+
+// processOutbox triggers immediate outbox processing for low latency.
+func (s *InventoryService) processOutbox() {
+	if s.infrastructure.OutboxPublisher == nil {
+		return
+	}
+	go func() {
+		if err := s.infrastructure.OutboxPublisher.ProcessNow(); err != nil {
+			s.logger.Warn("Failed to trigger immediate outbox processing", "error", err.Error())
+		}
+	}()
+}
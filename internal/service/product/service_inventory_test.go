@@ -0,0 +1,80 @@
+package product
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+// fakeExpiryRepo hands out a fixed number of expired reservations per call.
+type fakeExpiryRepo struct {
+	InventoryRepository
+	batches []int
+	err     error
+	limits  []int
+}
+
+func (r *fakeExpiryRepo) ExpireHeld(ctx context.Context, now time.Time, limit int) ([]*Reservation, error) {
+	r.limits = append(r.limits, limit)
+	if len(r.limits) > len(r.batches) {
+		return nil, r.err
+	}
+	n := r.batches[len(r.limits)-1]
+	expired := make([]*Reservation, 0, n)
+	for i := range n {
+		expired = append(expired, &Reservation{CartID: fmt.Sprintf("cart-%d", i), Status: ReservationExpired})
+	}
+	return expired, nil
+}
+
+func TestExpireReservations(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		batches   []int
+		err       error
+		wantTotal int
+		wantCalls int
+		wantErr   bool
+	}{
+		{name: "nothing held", batches: []int{0}, wantTotal: 0, wantCalls: 1},
+		{name: "partial batch", batches: []int{7}, wantTotal: 7, wantCalls: 1},
+		{name: "full batches sweep again", batches: []int{expireBatchSize, expireBatchSize, 7}, wantTotal: 207, wantCalls: 3},
+		{name: "full batch then empty", batches: []int{expireBatchSize, 0}, wantTotal: expireBatchSize, wantCalls: 2},
+		{name: "error keeps earlier batches", batches: []int{expireBatchSize}, err: errors.New("boom"), wantTotal: expireBatchSize, wantCalls: 2, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := &fakeExpiryRepo{batches: tt.batches, err: tt.err}
+			s := &InventoryService{
+				logger:         slog.New(slog.DiscardHandler),
+				repo:           repo,
+				infrastructure: &CatalogInfrastructure{},
+			}
+
+			total, err := s.ExpireReservations(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExpireReservations() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if total != tt.wantTotal {
+				t.Errorf("ExpireReservations() = %d, want %d", total, tt.wantTotal)
+			}
+			if len(repo.limits) != tt.wantCalls {
+				t.Errorf("ExpireHeld called %d times, want %d", len(repo.limits), tt.wantCalls)
+			}
+			for _, limit := range repo.limits {
+				if limit != expireBatchSize {
+					t.Errorf("ExpireHeld limit = %d, want %d", limit, expireBatchSize)
+				}
+			}
+		})
+	}
+}